@@ -19,13 +19,21 @@
 package main
 
 import (
+    "context"
+    "fmt"
     "log"
+    "os"
+    "os/exec"
     "pocketpilot/internal/config"
     "pocketpilot/internal/handlers"
     "pocketpilot/internal/middleware"
     "pocketpilot/internal/repository"
     "pocketpilot/internal/services"
     "pocketpilot/pkg/database"
+    "pocketpilot/pkg/imaging"
+    "pocketpilot/pkg/mail"
+    "pocketpilot/pkg/ocr"
+    "pocketpilot/pkg/storage"
 
     "github.com/gin-gonic/gin"
 
@@ -45,15 +53,83 @@ func main() {
 
     // repo init
     userRepo := repository.NewUserRepository(db.DB)
-    // expenseRepo := repository.NewExpenseRepository(db.DB)
+    teamRepo := repository.NewTeamRepository(db.DB)
+    policyRepo := repository.NewApprovalPolicyRepository(db.DB)
+    expenseRepo := repository.NewExpenseRepository(db.DB)
+    recurringRepo := repository.NewRecurringExpenseRepository(db.DB)
+    rateRepo := repository.NewExchangeRateRepository(db.DB)
+    splitRepo := repository.NewSplitRepository(db.DB)
+    budgetRepo := repository.NewBudgetRepository(db.DB)
+    reportRepo := repository.NewReportRepository(db.DB)
+    categoryRepo := repository.NewCategoryRepository(db.DB)
+    tagRepo := repository.NewTagRepository(db.DB)
+    receiptRepo := repository.NewReceiptRepository(db.DB)
+    tokenRepo := repository.NewTokenRepository(db.DB)
+    twoFactorRepo := repository.NewTwoFactorRepository(db.DB)
+
+    // receipt storage
+    receiptStorage, err := newReceiptStorage(cfg)
+    if err != nil {
+        log.Fatalf("Failed to set up receipt storage: %v", err)
+    }
+    
+    // outgoing email
+    mailer, err := newMailer(cfg)
+    if err != nil {
+        log.Fatalf("Failed to set up email: %v", err)
+    }
+    
+    // actions that need a verified email
+    restrictions, err := services.NewEmailRestrictions(cfg.UnverifiedEmailRestrictions)
+    if err != nil {
+        log.Fatalf("Invalid UNVERIFIED_EMAIL_RESTRICTIONS: %v", err)
+    }
     
     // service init
-    authService := services.NewAuthService(userRepo, cfg.JWTSecret)
-    // expenseService := services.NewExpenseService(expenseRepo, userRepo)
+    authService := services.NewAuthService(userRepo, tokenRepo, twoFactorRepo, mailer, cfg.JWTSecret, cfg.AppURL, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+    teamService := services.NewTeamService(teamRepo, userRepo, restrictions)
+    policyService := services.NewApprovalPolicyService(policyRepo, teamRepo)
+    expenseService := services.NewExpenseService(expenseRepo, userRepo, teamRepo, policyRepo, rateRepo, budgetRepo, categoryRepo, restrictions)
+    recurringService := services.NewRecurringExpenseService(recurringRepo, userRepo, teamRepo, categoryRepo, rateRepo)
+    rateService := services.NewExchangeRateService(rateRepo)
+    splitService := services.NewSplitService(splitRepo, expenseRepo, teamRepo)
+    budgetService := services.NewBudgetService(budgetRepo, rateRepo, teamRepo, categoryRepo)
+    reportService := services.NewReportService(reportRepo, userRepo, teamRepo, rateRepo)
+    categoryService := services.NewCategoryService(categoryRepo, teamRepo)
+    tagService := services.NewTagService(tagRepo, teamRepo)
+    var receiptScanner *services.ReceiptScanner
+    if engine := newOCREngine(cfg); engine != nil {
+        receiptScanner = services.NewReceiptScanner(receiptRepo, receiptStorage, engine, cfg.ReceiptScanInterval)
+    }
+    receiptService := services.NewReceiptService(receiptRepo, expenseRepo, teamRepo, receiptStorage, receiptScanner, newPDFRenderer(cfg), cfg.ReceiptURLTTL)
+
+    // exchange rates shipped with the deployment
+    if cfg.ExchangeRatesFile != "" {
+        loadExchangeRates(rateService, cfg.ExchangeRatesFile)
+    }
+
+    // background jobs
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    go services.NewRecurringScheduler(recurringService, cfg.RecurringSchedulerInterval).Run(ctx)
+    go services.NewTokenPruner(authService, cfg.TokenPruneInterval).Run(ctx)
+    if receiptScanner != nil {
+        go receiptScanner.Run(ctx)
+    }
     
     // handlers init
     authHandler := handlers.NewAuthHandler(authService)
-    // expenseHandler := handlers.NewExpenseHandler(expenseService)
+    teamHandler := handlers.NewTeamHandler(teamService)
+    policyHandler := handlers.NewApprovalPolicyHandler(policyService)
+    expenseHandler := handlers.NewExpenseHandler(expenseService)
+    recurringHandler := handlers.NewRecurringExpenseHandler(recurringService)
+    rateHandler := handlers.NewExchangeRateHandler(rateService)
+    splitHandler := handlers.NewSplitHandler(splitService)
+    budgetHandler := handlers.NewBudgetHandler(budgetService)
+    reportHandler := handlers.NewReportHandler(reportService)
+    categoryHandler := handlers.NewCategoryHandler(categoryService)
+    tagHandler := handlers.NewTagHandler(tagService)
+    receiptHandler := handlers.NewReceiptHandler(receiptService)
     
     // gin router
     router := gin.Default()
@@ -65,36 +141,157 @@ func main() {
     // Add Swagger UI endpoint (before routes for easy access)
     router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
     
-    // routes (NOW passing expenseHandler)
-    setupRoutes(router, authHandler, cfg.JWTSecret)
+    // routes
+    setupRoutes(router, authHandler, expenseHandler, teamHandler, policyHandler, recurringHandler, rateHandler, splitHandler, budgetHandler, reportHandler, categoryHandler, tagHandler, receiptHandler, authService, cfg.JWTSecret, cfg.AdminEmails)
     
     // start server
     log.Printf("Server starting on port %s", cfg.Port)
     log.Fatal(router.Run(":" + cfg.Port))
 }
 
-func setupRoutes(router *gin.Engine, authHandler *handlers.AuthHandler, jwtSecret string) {
+func setupRoutes(router *gin.Engine, authHandler *handlers.AuthHandler, expenseHandler *handlers.ExpenseHandler, teamHandler *handlers.TeamHandler, policyHandler *handlers.ApprovalPolicyHandler, recurringHandler *handlers.RecurringExpenseHandler, rateHandler *handlers.ExchangeRateHandler, splitHandler *handlers.SplitHandler, budgetHandler *handlers.BudgetHandler, reportHandler *handlers.ReportHandler, categoryHandler *handlers.CategoryHandler, tagHandler *handlers.TagHandler, receiptHandler *handlers.ReceiptHandler, authService *services.AuthService, jwtSecret string, adminEmails []string) {
     // Public auth routes
     router.POST("/api/auth/register", authHandler.Register)
     router.POST("/api/auth/login", authHandler.Login)
+    router.POST("/api/auth/login/mfa", authHandler.CompleteMFALogin)
+    router.POST("/api/auth/refresh", authHandler.Refresh)
+    router.POST("/api/auth/password/forgot", authHandler.ForgotPassword)
+    router.POST("/api/auth/password/reset", authHandler.ResetPassword)
+    router.POST("/api/auth/verify-email", authHandler.VerifyEmail)
+
+    // Receipt files behind signed URLs, authorized by their signature
+    router.GET("/api/receipts/files/*key", receiptHandler.ServeReceiptFile)
 
     // Protected group
     auth := router.Group("/api")
-    auth.Use(middleware.AuthMiddleware(jwtSecret))
+    auth.Use(middleware.AuthMiddleware(jwtSecret, authService))
 
     // Auth profile
     auth.GET("/auth/profile", authHandler.GetProfile)
+    auth.PUT("/auth/profile", authHandler.UpdateProfile)
+    auth.PUT("/auth/password", authHandler.ChangePassword)
+    auth.POST("/auth/verify-email/resend", authHandler.ResendVerificationEmail)
+    auth.POST("/auth/2fa/setup", authHandler.SetupTwoFactor)
+    auth.POST("/auth/2fa/enable", authHandler.EnableTwoFactor)
+    auth.POST("/auth/2fa/disable", authHandler.DisableTwoFactor)
+    auth.POST("/auth/logout", authHandler.Logout)
+    auth.GET("/auth/sessions", authHandler.GetSessions)
+    auth.DELETE("/auth/sessions", authHandler.RevokeOtherSessions)
+    auth.DELETE("/auth/sessions/:id", authHandler.RevokeSession)
+
+    // Team routes
+    teams := auth.Group("/teams")
+    {
+        teams.POST("/", teamHandler.CreateTeam)
+        teams.GET("/", teamHandler.GetTeams)
+        teams.GET("/:id", teamHandler.GetTeam)
+        teams.PUT("/:id", teamHandler.UpdateTeam)
+        teams.DELETE("/:id", teamHandler.DeleteTeam)
+        teams.GET("/:id/members", teamHandler.GetTeamMembers)
+        teams.POST("/:id/members", teamHandler.AddTeamMember)
+        teams.PUT("/:id/members/:userId", teamHandler.UpdateTeamMember)
+        teams.DELETE("/:id/members/:userId", teamHandler.RemoveTeamMember)
+        teams.GET("/:id/approval-policies", policyHandler.GetPolicies)
+        teams.POST("/:id/approval-policies", policyHandler.CreatePolicy)
+        teams.PUT("/:id/approval-policies/:policyId", policyHandler.UpdatePolicy)
+        teams.DELETE("/:id/approval-policies/:policyId", policyHandler.DeletePolicy)
+        teams.GET("/:id/balances", splitHandler.GetTeamBalances)
+        teams.GET("/:id/settlements", splitHandler.GetSettlements)
+        teams.POST("/:id/settlements", splitHandler.CreateSettlement)
+    }
+
+    // Expense routes
+    expenses := auth.Group("/expenses")
+    {
+        expenses.POST("/", expenseHandler.CreateExpense)
+        expenses.GET("/", expenseHandler.GetExpenses)
+        expenses.GET("/export", expenseHandler.ExportExpenses)
+        expenses.GET("/totals", expenseHandler.GetExpenseTotals)
+        expenses.POST("/import", expenseHandler.ImportExpenses)
+        expenses.POST("/import/statement", expenseHandler.ImportStatement)
+        expenses.GET("/:id", expenseHandler.GetExpense)
+        expenses.PUT("/:id", expenseHandler.UpdateExpense)
+        expenses.DELETE("/:id", expenseHandler.DeleteExpense)
+        expenses.GET("/team/:teamId", expenseHandler.GetTeamExpenses)
+        expenses.GET("/team/:teamId/export", expenseHandler.ExportTeamExpenses)
+        expenses.GET("/team/:teamId/totals", expenseHandler.GetTeamExpenseTotals)
+        expenses.POST("/:id/submit", expenseHandler.SubmitExpense)
+        expenses.POST("/:id/approve", expenseHandler.ApproveExpense)
+        expenses.POST("/:id/reject", expenseHandler.RejectExpense)
+        expenses.POST("/:id/reimburse", expenseHandler.ReimburseExpense)
+        expenses.GET("/:id/history", expenseHandler.GetExpenseHistory)
+        expenses.GET("/:id/approvals", expenseHandler.GetExpenseApprovals)
+        expenses.GET("/:id/splits", splitHandler.GetExpenseSplits)
+        expenses.PUT("/:id/splits", splitHandler.SplitExpense)
+        expenses.DELETE("/:id/splits", splitHandler.RemoveExpenseSplit)
+        expenses.POST("/:id/receipts", receiptHandler.UploadReceipt)
+        expenses.GET("/:id/receipts", receiptHandler.GetReceipts)
+        expenses.GET("/:id/receipts/:receiptId", receiptHandler.GetReceipt)
+        expenses.GET("/:id/receipts/:receiptId/download", receiptHandler.DownloadReceipt)
+        expenses.DELETE("/:id/receipts/:receiptId", receiptHandler.DeleteReceipt)
+        expenses.POST("/:id/receipts/:receiptId/scan", receiptHandler.RescanReceipt)
+        expenses.GET("/:id/receipts/:receiptId/reconciliation", receiptHandler.ReconcileReceipt)
+    }
 
-    // // Expense routes
-    // expenses := auth.Group("/expenses")
-    // {
-    //     expenses.POST("/", expenseHandler.CreateExpense)
-    //     expenses.GET("/", expenseHandler.GetExpenses)
-    //     expenses.GET("/:id", expenseHandler.GetExpense)
-    //     expenses.PUT("/:id", expenseHandler.UpdateExpense)
-    //     expenses.DELETE("/:id", expenseHandler.DeleteExpense)
-    //     expenses.GET("/team/:teamId", expenseHandler.GetTeamExpenses)
-    // }
+    // Category routes
+    categories := auth.Group("/categories")
+    {
+        categories.GET("/", categoryHandler.GetCategories)
+        categories.POST("/", categoryHandler.CreateCategory)
+        categories.PUT("/:id", categoryHandler.UpdateCategory)
+    }
+
+    // Tag routes
+    tags := auth.Group("/tags")
+    {
+        tags.GET("/", tagHandler.GetTags)
+        tags.PUT("/:id", tagHandler.RenameTag)
+        tags.DELETE("/:id", tagHandler.DeleteTag)
+        tags.POST("/:id/merge", tagHandler.MergeTag)
+    }
+
+    // Recurring expense routes
+    recurring := auth.Group("/recurring-expenses")
+    {
+        recurring.POST("/", recurringHandler.CreateRecurringExpense)
+        recurring.GET("/", recurringHandler.GetRecurringExpenses)
+        recurring.GET("/:id", recurringHandler.GetRecurringExpense)
+        recurring.PUT("/:id", recurringHandler.UpdateRecurringExpense)
+        recurring.DELETE("/:id", recurringHandler.DeleteRecurringExpense)
+    }
+
+    // Budget routes
+    budgets := auth.Group("/budgets")
+    {
+        budgets.POST("/", budgetHandler.CreateBudget)
+        budgets.GET("/", budgetHandler.GetBudgets)
+        budgets.GET("/status", budgetHandler.GetBudgetStatuses)
+        budgets.GET("/:id", budgetHandler.GetBudget)
+        budgets.PUT("/:id", budgetHandler.UpdateBudget)
+        budgets.DELETE("/:id", budgetHandler.DeleteBudget)
+        budgets.GET("/:id/status", budgetHandler.GetBudgetStatus)
+        budgets.GET("/:id/alerts", budgetHandler.GetBudgetAlerts)
+    }
+
+    // Report routes
+    reports := auth.Group("/reports")
+    {
+        reports.GET("/summary", reportHandler.GetSummary)
+        reports.GET("/categories", reportHandler.GetCategoryBreakdown)
+        reports.GET("/tags", reportHandler.GetTagBreakdown)
+        reports.GET("/statuses", reportHandler.GetStatusBreakdown)
+        reports.GET("/members", reportHandler.GetMemberBreakdown)
+        reports.GET("/timeline", reportHandler.GetTimeline)
+        reports.GET("/trends", reportHandler.GetTrend)
+    }
+
+    // Exchange rate routes, changed by admins only
+    rates := auth.Group("/exchange-rates")
+    {
+        rates.GET("/", rateHandler.GetRates)
+        rates.POST("/", middleware.RequireAdmin(adminEmails, authService), rateHandler.SetRates)
+        rates.POST("/import", middleware.RequireAdmin(adminEmails, authService), rateHandler.ImportRates)
+    }
 
     // Health check
     router.GET("/health", func(c *gin.Context) {
@@ -103,4 +300,105 @@ func setupRoutes(router *gin.Engine, authHandler *handlers.AuthHandler, jwtSecre
             "message": "Server is running",
         })
     })
-}
\ No newline at end of file
+}
+
+// loadExchangeRates imports a rate file at startup. A bad file is logged
+// rather than fatal, as expenses still work without rates.
+func loadExchangeRates(rateService *services.ExchangeRateService, path string) {
+    file, err := os.Open(path)
+    if err != nil {
+        log.Printf("Exchange rates not loaded: %v", err)
+        return
+    }
+    defer file.Close()
+
+    result, err := rateService.ImportRates(file, "")
+    if err != nil {
+        log.Printf("Exchange rates not loaded from %s: %v", path, err)
+        return
+    }
+    log.Printf("Loaded %d exchange rates from %s (%s to %s)", result.Imported, path, result.FirstDate, result.LastDate)
+}
+
+// newReceiptStorage sets up the configured receipt storage backend. Local
+// storage serves its signed URLs through the API itself.
+func newReceiptStorage(cfg *config.Config) (storage.Storage, error) {
+    switch cfg.ReceiptStorage {
+    case storage.BackendLocal:
+        return storage.NewLocal(cfg.ReceiptStorageDir, cfg.PublicURL+"/api/receipts/files", []byte(cfg.JWTSecret))
+    case storage.BackendS3:
+        return storage.NewS3(storage.S3Config{
+            Endpoint:        cfg.S3Endpoint,
+            Region:          cfg.S3Region,
+            Bucket:          cfg.S3Bucket,
+            AccessKeyID:     cfg.AWSAccessKeyID,
+            SecretAccessKey: cfg.AWSSecretAccessKey,
+            PathStyle:       cfg.S3PathStyle,
+        })
+    }
+    return nil, fmt.Errorf("unknown receipt storage %q, use %s or %s", cfg.ReceiptStorage, storage.BackendLocal, storage.BackendS3)
+}
+
+// newMailer sets up the configured way of sending emails
+func newMailer(cfg *config.Config) (mail.Sender, error) {
+    backend := cfg.MailBackend
+    if backend == "" {
+        backend = mail.BackendFile
+        if cfg.SMTPHost != "" {
+            backend = mail.BackendSMTP
+        }
+    }
+
+    switch backend {
+    case mail.BackendSMTP:
+        return mail.NewSMTP(mail.SMTPConfig{
+            Host:     cfg.SMTPHost,
+            Port:     cfg.SMTPPort,
+            Username: cfg.SMTPUsername,
+            Password: cfg.SMTPPassword,
+            From:     cfg.MailFrom,
+        })
+    case mail.BackendFile:
+        log.Printf("Emails are written to %s instead of being sent", cfg.MailDir)
+        return mail.NewDir(cfg.MailDir, cfg.MailFrom)
+    case mail.BackendMemory:
+        return mail.NewMemory(), nil
+    }
+    return nil, fmt.Errorf("unknown mail backend %q, use %s, %s or %s", backend, mail.BackendSMTP, mail.BackendFile, mail.BackendMemory)
+}
+
+// newOCREngine sets up the configured OCR engine, or returns nil when OCR is off
+func newOCREngine(cfg *config.Config) ocr.Engine {
+    engine := cfg.OCREngine
+    if engine == "" && cfg.GoogleVisionAPIKey != "" {
+        engine = ocr.EngineGoogleVision
+    }
+
+    switch engine {
+    case "":
+        log.Printf("Receipt OCR is off: set GOOGLE_VISION_API_KEY or OCR_ENGINE to turn it on")
+        return nil
+    case ocr.EngineGoogleVision:
+        if cfg.GoogleVisionAPIKey == "" {
+            log.Fatal("OCR_ENGINE=google-vision needs GOOGLE_VISION_API_KEY")
+        }
+        return ocr.NewGoogleVision(cfg.GoogleVisionAPIKey)
+    case ocr.EngineTesseract:
+        return ocr.NewTesseract(cfg.TesseractPath)
+    case ocr.EngineFixtures:
+        return ocr.NewFixtures(cfg.OCRFixturesDir)
+    }
+    log.Fatalf("Unknown OCR engine %q, use %s, %s or %s", engine, ocr.EngineGoogleVision, ocr.EngineTesseract, ocr.EngineFixtures)
+    return nil
+}
+
+// newPDFRenderer sets up rendering of PDF receipts, or returns nil when
+// pdftoppm is not installed
+func newPDFRenderer(cfg *config.Config) imaging.PDFRenderer {
+    path, err := exec.LookPath(cfg.PDFRendererPath)
+    if err != nil {
+        log.Printf("PDF receipts will have no images: %v", err)
+        return nil
+    }
+    return imaging.NewPoppler(path)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+    "net/http"
+    "github.com/gin-gonic/gin"
+    "pocketpilot/internal/models"
+    "pocketpilot/internal/services"
+    "pocketpilot/internal/utils"
+)
+
+type ApprovalPolicyHandler struct {
+    policyService *services.ApprovalPolicyService
+}
+
+func NewApprovalPolicyHandler(policyService *services.ApprovalPolicyService) *ApprovalPolicyHandler {
+    return &ApprovalPolicyHandler{policyService: policyService}
+}
+
+// @Summary Get approval policies
+// @Description List a team's approval policies in evaluation order
+// @Tags Approval Policies
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Team ID"
+// @Success 200 {array} models.ApprovalPolicy
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/teams/{id}/approval-policies [get]
+func (h *ApprovalPolicyHandler) GetPolicies(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    policies, err := h.policyService.GetTeamPolicies(c.Param("id"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Approval policies retrieved successfully", policies))
+}
+
+// @Summary Create approval policy
+// @Description Add an approval policy to a team (owners and admins only)
+// @Tags Approval Policies
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Team ID"
+// @Param policy body models.ApprovalPolicyRequest true "Policy payload"
+// @Success 201 {object} models.ApprovalPolicy
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/teams/{id}/approval-policies [post]
+func (h *ApprovalPolicyHandler) CreatePolicy(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.ApprovalPolicyRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    policy, err := h.policyService.CreatePolicy(c.Param("id"), userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusCreated, utils.SuccessResponse("Approval policy created successfully", policy))
+}
+
+// @Summary Update approval policy
+// @Description Replace an approval policy's conditions and steps (owners and admins only)
+// @Tags Approval Policies
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Team ID"
+// @Param policyId path string true "Policy ID"
+// @Param policy body models.ApprovalPolicyRequest true "Policy payload"
+// @Success 200 {object} models.ApprovalPolicy
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/teams/{id}/approval-policies/{policyId} [put]
+func (h *ApprovalPolicyHandler) UpdatePolicy(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.ApprovalPolicyRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    policy, err := h.policyService.UpdatePolicy(c.Param("id"), c.Param("policyId"), userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Approval policy updated successfully", policy))
+}
+
+// @Summary Delete approval policy
+// @Description Remove an approval policy from a team (owners and admins only)
+// @Tags Approval Policies
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Team ID"
+// @Param policyId path string true "Policy ID"
+// @Success 200
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/teams/{id}/approval-policies/{policyId} [delete]
+func (h *ApprovalPolicyHandler) DeletePolicy(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    err := h.policyService.DeletePolicy(c.Param("id"), c.Param("policyId"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Approval policy deleted successfully", nil))
+}
@@ -36,7 +36,7 @@ func (h *AuthHandler) Register (c *gin.Context) {
 		return
 	}
 
-	authResponse, err := h.authService.Register(&req)
+	authResponse, err := h.authService.Register(&req, sessionClient(c, req.ClientName))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
 		return
@@ -46,12 +46,13 @@ func (h *AuthHandler) Register (c *gin.Context) {
 }
 
 // @Summary Login user
-// @Description Authenticate user and return JWT
+// @Description Authenticate user and return a short-lived JWT with a refresh token. Users with two-factor authentication get a challenge token instead, to send to /api/auth/login/mfa with a code.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param login body models.LoginRequest true "Login payload"
-// @Success 200 {object} models.AuthResponse
+// @Success 201 {object} models.AuthResponse
+// @Success 200 {object} models.MFAChallengeResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Router /api/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -62,15 +63,392 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	authResponse, err := h.authService.Login(&req)
+	authResponse, challenge, err := h.authService.Login(&req, sessionClient(c, req.ClientName))
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, utils.ErrorResponse(err.Error()))
 		return
 	}
+	if challenge != nil {
+		c.JSON(http.StatusOK, utils.SuccessResponse("Two-factor code required", challenge))
+		return
+	}
 
 	    c.JSON(http.StatusCreated, utils.SuccessResponse("User Logged in successfully", authResponse))
 }
 
+// @Summary Finish two-factor login
+// @Description Exchange the challenge token from logging in and a code from the authenticator app, or a recovery code, for a short-lived JWT with a refresh token. A challenge expires after five minutes or five codes.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param login body models.MFALoginRequest true "Challenge token and code"
+// @Success 201 {object} models.AuthResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /api/auth/login/mfa [post]
+func (h *AuthHandler) CompleteMFALogin(c *gin.Context) {
+	var req models.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("invalid request data"))
+		return
+	}
+
+	authResponse, err := h.authService.CompleteMFALogin(&req, sessionClient(c, req.ClientName))
+	if err != nil {
+		c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse("User Logged in successfully", authResponse))
+}
+
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access token and a new refresh token. Each refresh token works once; reusing one revokes its session.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param refresh body models.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} models.AuthResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /api/auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+    var req models.RefreshTokenRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    authResponse, err := h.authService.Refresh(req.RefreshToken, sessionClient(c, ""))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Tokens refreshed successfully", authResponse))
+}
+
+// @Summary Logout
+// @Description End the session of the access token used for the request, revoking its tokens
+// @Tags Auth
+// @Security BearerAuth
+// @Success 204
+// @Failure 401 {object} models.ErrorResponse
+// @Router /api/auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+    if _, exists := c.Get("userID"); !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    if err := h.authService.Logout(c.GetString("tokenID"), c.GetString("sessionID")); err != nil {
+        c.JSON(http.StatusInternalServerError, utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.Status(http.StatusNoContent)
+}
+
+// @Summary List sessions
+// @Description List the devices the authenticated user is logged in on, most recently seen first. The session of the request is marked current.
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Session
+// @Failure 401 {object} models.ErrorResponse
+// @Router /api/auth/sessions [get]
+func (h *AuthHandler) GetSessions(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    sessions, err := h.authService.GetSessions(userID.(string), c.GetString("sessionID"))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Sessions retrieved successfully", sessions))
+}
+
+// @Summary Revoke session
+// @Description Log out of one session, which may be the current one
+// @Tags Auth
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 204
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    if err := h.authService.RevokeSession(userID.(string), c.Param("id")); err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.Status(http.StatusNoContent)
+}
+
+// @Summary Revoke other sessions
+// @Description Log out of every session but the current one
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.RevokeSessionsResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /api/auth/sessions [delete]
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    revoked, err := h.authService.RevokeOtherSessions(userID.(string), c.GetString("sessionID"))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Other sessions revoked successfully", &models.RevokeSessionsResponse{Revoked: revoked}))
+}
+
+// sessionClient describes the device a request comes from
+func sessionClient(c *gin.Context, clientName string) *models.SessionClient {
+    return &models.SessionClient{
+        ClientName: clientName,
+        UserAgent:  c.Request.UserAgent(),
+        IPAddress:  c.ClientIP(),
+    }
+}
+
+// @Summary Change password
+// @Description Set a new password, confirming the current one. Every other session is logged out.
+// @Tags Auth
+// @Accept json
+// @Security BearerAuth
+// @Param password body models.ChangePasswordRequest true "Current and new password"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /api/auth/password [put]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.ChangePasswordRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    if err := h.authService.ChangePassword(userID.(string), c.GetString("sessionID"), &req); err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.Status(http.StatusNoContent)
+}
+
+// @Summary Forgot password
+// @Description Email a password reset link, valid for an hour, to the account with this email. The response is the same whether or not there is one.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param email body models.ForgotPasswordRequest true "Account email"
+// @Success 202 {object} utils.APIResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /api/auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+    var req models.ForgotPasswordRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    if err := h.authService.ForgotPassword(&req); err != nil {
+        c.JSON(http.StatusInternalServerError, utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusAccepted, utils.SuccessResponse("If an account uses this email, a password reset link is on its way", nil))
+}
+
+// @Summary Reset password
+// @Description Set a new password with the token of a password reset link. The link works once and every session is logged out.
+// @Tags Auth
+// @Accept json
+// @Param reset body models.ResetPasswordRequest true "Reset token and new password"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Router /api/auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+    var req models.ResetPasswordRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    if err := h.authService.ResetPassword(&req); err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.Status(http.StatusNoContent)
+}
+
+// @Summary Verify email
+// @Description Confirm the email address of an account with the token of the link sent to it. Links expire after two days and stop working when the email changes.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param token body models.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Router /api/auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+    var req models.VerifyEmailRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    user, err := h.authService.VerifyEmail(req.Token)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Email verified successfully", user))
+}
+
+// @Summary Resend verification email
+// @Description Send another email verification link, at most once every two minutes
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} utils.APIResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Router /api/auth/verify-email/resend [post]
+func (h *AuthHandler) ResendVerificationEmail(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    if err := h.authService.ResendVerificationEmail(userID.(string)); err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusAccepted, utils.SuccessResponse("A verification link is on its way", nil))
+}
+
+// @Summary Set up two-factor authentication
+// @Description Create a secret for an authenticator app, to type in or scan as a QR code of the otpauth URI. Two-factor authentication turns on once a code confirms it.
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.TwoFactorSetup
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/auth/2fa/setup [post]
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    setup, err := h.authService.SetupTwoFactor(userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Add the secret to your authenticator app", setup))
+}
+
+// @Summary Enable two-factor authentication
+// @Description Turn on two-factor authentication with a code from the authenticator app. The recovery codes in the response are only shown once.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body models.EnableTwoFactorRequest true "Code from the authenticator app"
+// @Success 200 {object} models.RecoveryCodesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/auth/2fa/enable [post]
+func (h *AuthHandler) EnableTwoFactor(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.EnableTwoFactorRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    codes, err := h.authService.EnableTwoFactor(userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Two-factor authentication enabled, keep the recovery codes somewhere safe", codes))
+}
+
+// @Summary Disable two-factor authentication
+// @Description Turn off two-factor authentication, confirmed with the password and a code from the authenticator app or a recovery code
+// @Tags Auth
+// @Accept json
+// @Security BearerAuth
+// @Param credentials body models.DisableTwoFactorRequest true "Password and code"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/auth/2fa/disable [post]
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.DisableTwoFactorRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    if err := h.authService.DisableTwoFactor(userID.(string), &req); err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.Status(http.StatusNoContent)
+}
+
 // @Summary Get user profile
 // @Description Retrieve authenticated user's profile
 // @Tags Auth
@@ -93,4 +471,37 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
     }
 
     c.JSON(http.StatusOK, utils.SuccessResponse("Profile retrieved successfully", user))
-}
\ No newline at end of file
+}
+
+// @Summary Update user profile
+// @Description Change the authenticated user's name or base currency
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param profile body models.UpdateProfileRequest true "Profile changes"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /api/auth/profile [put]
+func (h *AuthHandler) UpdateProfile(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.UpdateProfileRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    user, err := h.authService.UpdateProfile(userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Profile updated successfully", user))
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+    "net/http"
+    "github.com/gin-gonic/gin"
+    "pocketpilot/internal/models"
+    "pocketpilot/internal/services"
+    "pocketpilot/internal/utils"
+)
+
+type BudgetHandler struct {
+    budgetService *services.BudgetService
+}
+
+func NewBudgetHandler(budgetService *services.BudgetService) *BudgetHandler {
+    return &BudgetHandler{budgetService: budgetService}
+}
+
+// @Summary Create budget
+// @Description Create a monthly, quarterly or yearly budget, overall or for one category. Team budgets are created by team owners and admins and count the team's expenses. Reaching a threshold raises an alert.
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param budget body models.CreateBudgetRequest true "Budget payload"
+// @Success 201 {object} models.Budget
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/budgets [post]
+func (h *BudgetHandler) CreateBudget(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.CreateBudgetRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    budget, err := h.budgetService.CreateBudget(userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusCreated, utils.SuccessResponse("Budget created successfully", budget))
+}
+
+// @Summary Get budgets
+// @Description List the user's personal budgets, or a team's budgets
+// @Tags Budgets
+// @Produce json
+// @Security BearerAuth
+// @Param team_id query string false "List this team's budgets"
+// @Success 200 {array} models.Budget
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/budgets [get]
+func (h *BudgetHandler) GetBudgets(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    budgets, err := h.budgetService.GetBudgets(userID.(string), teamIDQuery(c))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Budgets retrieved successfully", budgets))
+}
+
+// @Summary Get budget statuses
+// @Description Show spent versus remaining for every personal or team budget, in the period containing the date
+// @Tags Budgets
+// @Produce json
+// @Security BearerAuth
+// @Param team_id query string false "Show this team's budgets"
+// @Param date query string false "Day in the period, YYYY-MM-DD, defaults to today"
+// @Success 200 {array} models.BudgetStatus
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/budgets/status [get]
+func (h *BudgetHandler) GetBudgetStatuses(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    statuses, err := h.budgetService.GetBudgetStatuses(userID.(string), teamIDQuery(c), c.Query("date"))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Budget statuses retrieved successfully", statuses))
+}
+
+// @Summary Get budget
+// @Description Get a budget by ID
+// @Tags Budgets
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Budget ID"
+// @Success 200 {object} models.Budget
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/budgets/{id} [get]
+func (h *BudgetHandler) GetBudget(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    budget, err := h.budgetService.GetBudget(c.Param("id"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Budget retrieved successfully", budget))
+}
+
+// @Summary Update budget
+// @Description Change a budget's amount, rollover or alert thresholds
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Budget ID"
+// @Param budget body models.UpdateBudgetRequest true "Budget update payload"
+// @Success 200 {object} models.Budget
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/budgets/{id} [put]
+func (h *BudgetHandler) UpdateBudget(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.UpdateBudgetRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    budget, err := h.budgetService.UpdateBudget(c.Param("id"), userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Budget updated successfully", budget))
+}
+
+// @Summary Delete budget
+// @Description Delete a budget and its alerts
+// @Tags Budgets
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Budget ID"
+// @Success 204
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/budgets/{id} [delete]
+func (h *BudgetHandler) DeleteBudget(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    if err := h.budgetService.DeleteBudget(c.Param("id"), userID.(string)); err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Budget deleted successfully", nil))
+}
+
+// @Summary Get budget status
+// @Description Show spent versus remaining in the budget period containing the date, with what rollover carried in. Expenses in other currencies are converted at the rate of their day.
+// @Tags Budgets
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Budget ID"
+// @Param date query string false "Day in the period, YYYY-MM-DD, defaults to today"
+// @Success 200 {object} models.BudgetStatus
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/budgets/{id}/status [get]
+func (h *BudgetHandler) GetBudgetStatus(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    status, err := h.budgetService.GetBudgetStatus(c.Param("id"), userID.(string), c.Query("date"))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Budget status retrieved successfully", status))
+}
+
+// @Summary Get budget alerts
+// @Description List the thresholds a budget reached, newest first
+// @Tags Budgets
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Budget ID"
+// @Success 200 {array} models.BudgetAlert
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/budgets/{id}/alerts [get]
+func (h *BudgetHandler) GetBudgetAlerts(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    alerts, err := h.budgetService.GetBudgetAlerts(c.Param("id"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Budget alerts retrieved successfully", alerts))
+}
+
+// teamIDQuery returns the team_id query parameter, nil when absent
+func teamIDQuery(c *gin.Context) *string {
+    if teamID := c.Query("team_id"); teamID != "" {
+        return &teamID
+    }
+    return nil
+}
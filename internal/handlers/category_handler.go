@@ -0,0 +1,118 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+    "github.com/gin-gonic/gin"
+    "pocketpilot/internal/models"
+    "pocketpilot/internal/services"
+    "pocketpilot/internal/utils"
+)
+
+type CategoryHandler struct {
+    categoryService *services.CategoryService
+}
+
+func NewCategoryHandler(categoryService *services.CategoryService) *CategoryHandler {
+    return &CategoryHandler{categoryService: categoryService}
+}
+
+// @Summary Get categories
+// @Description List the system categories and the user's personal categories, or a team's categories. Archived categories are left out unless asked for.
+// @Tags Categories
+// @Produce json
+// @Security BearerAuth
+// @Param team_id query string false "List this team's categories"
+// @Param include_archived query bool false "Include archived categories"
+// @Success 200 {array} models.Category
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/categories [get]
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    includeArchived, _ := strconv.ParseBool(c.Query("include_archived"))
+
+    categories, err := h.categoryService.GetCategories(userID.(string), teamIDQuery(c), includeArchived)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Categories retrieved successfully", categories))
+}
+
+// @Summary Create category
+// @Description Create a personal category, or a team category as a team owner or admin. Categories may sit under a top-level parent.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param category body models.CreateCategoryRequest true "Category payload"
+// @Success 201 {object} models.Category
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/categories [post]
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.CreateCategoryRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    category, err := h.categoryService.CreateCategory(userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusCreated, utils.SuccessResponse("Category created successfully", category))
+}
+
+// @Summary Update category
+// @Description Rename, move, restyle, archive or restore a custom category. A new name carries over to the expenses filed under it. System categories cannot be changed.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Category ID"
+// @Param category body models.UpdateCategoryRequest true "Category update payload"
+// @Success 200 {object} models.Category
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/categories/{id} [put]
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.UpdateCategoryRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    category, err := h.categoryService.UpdateCategory(c.Param("id"), userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Category updated successfully", category))
+}
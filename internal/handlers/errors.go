@@ -0,0 +1,97 @@
+package handlers
+
+import (
+    "errors"
+    "net/http"
+    "pocketpilot/internal/services"
+)
+
+// statusForError maps service errors to HTTP statuses, falling back to the given status
+func statusForError(err error, fallback int) int {
+    switch {
+    case errors.Is(err, services.ErrTeamNotFound),
+        errors.Is(err, services.ErrTeamMemberNotFound),
+        errors.Is(err, services.ErrUserNotFound),
+        errors.Is(err, services.ErrExpenseNotFound),
+        errors.Is(err, services.ErrApprovalPolicyNotFound),
+        errors.Is(err, services.ErrRecurringExpenseNotFound),
+        errors.Is(err, services.ErrExpenseNotSplit),
+        errors.Is(err, services.ErrBudgetNotFound),
+        errors.Is(err, services.ErrCategoryNotFound),
+        errors.Is(err, services.ErrTagNotFound),
+        errors.Is(err, services.ErrReceiptNotFound),
+        errors.Is(err, services.ErrSessionNotFound):
+        return http.StatusNotFound
+    case errors.Is(err, services.ErrTeamAccessDenied),
+        errors.Is(err, services.ErrExpenseAccessDenied),
+        errors.Is(err, services.ErrSelfApproval),
+        errors.Is(err, services.ErrNotStepApprover),
+        errors.Is(err, services.ErrAlreadyApproved),
+        errors.Is(err, services.ErrSystemCategory),
+        errors.Is(err, services.ErrInvalidSignedURL),
+        errors.Is(err, services.ErrIncorrectPassword),
+        errors.Is(err, services.ErrEmailNotVerified),
+        errors.Is(err, services.ErrMemberEmailNotVerified):
+        return http.StatusForbidden
+    case errors.Is(err, services.ErrAlreadyTeamMember),
+        errors.Is(err, services.ErrLastTeamOwner),
+        errors.Is(err, services.ErrExpenseLocked),
+        errors.Is(err, services.ErrInvalidTransition),
+        errors.Is(err, services.ErrExpenseIsSplit),
+        errors.Is(err, services.ErrCategoryExists),
+        errors.Is(err, services.ErrTagExists),
+        errors.Is(err, services.ErrEmailAlreadyVerified),
+        errors.Is(err, services.ErrTwoFactorEnabled),
+        errors.Is(err, services.ErrTwoFactorNotEnabled),
+        errors.Is(err, services.ErrTwoFactorNotSetUp):
+        return http.StatusConflict
+    case errors.Is(err, services.ErrInvalidTeamRole),
+        errors.Is(err, services.ErrRejectionReasonRequired),
+        errors.Is(err, services.ErrNotTeamExpense),
+        errors.Is(err, services.ErrInvalidApprovalStep),
+        errors.Is(err, services.ErrInvalidApprover),
+        errors.Is(err, services.ErrDuplicateApprover),
+        errors.Is(err, services.ErrInvalidCursor),
+        errors.Is(err, services.ErrCursorSortUnsupported),
+        errors.Is(err, services.ErrUnsupportedExportFormat),
+        errors.Is(err, services.ErrInvalidExportColumn),
+        errors.Is(err, services.ErrInvalidDateFormat),
+        errors.Is(err, services.ErrInvalidImportFile),
+        errors.Is(err, services.ErrInvalidImportMapping),
+        errors.Is(err, services.ErrUnsupportedStatementFormat),
+        errors.Is(err, services.ErrInvalidStatement),
+        errors.Is(err, services.ErrInvalidRecurrence),
+        errors.Is(err, services.ErrInvalidCurrency),
+        errors.Is(err, services.ErrUnsupportedRateFormat),
+        errors.Is(err, services.ErrInvalidRateFile),
+        errors.Is(err, services.ErrInvalidExchangeRate),
+        errors.Is(err, services.ErrSplitNeedsTeam),
+        errors.Is(err, services.ErrInvalidSplit),
+        errors.Is(err, services.ErrInvalidSettlement),
+        errors.Is(err, services.ErrInvalidBudget),
+        errors.Is(err, services.ErrInvalidReport),
+        errors.Is(err, services.ErrUnknownCategory),
+        errors.Is(err, services.ErrCategoryArchived),
+        errors.Is(err, services.ErrInvalidCategory),
+        errors.Is(err, services.ErrInvalidTag),
+        errors.Is(err, services.ErrInvalidReceipt),
+        errors.Is(err, services.ErrInvalidResetToken),
+        errors.Is(err, services.ErrInvalidVerificationToken),
+        errors.Is(err, services.ErrInvalidTwoFactorCode):
+        return http.StatusBadRequest
+    case errors.Is(err, services.ErrInvalidRefreshToken),
+        errors.Is(err, services.ErrRefreshTokenReused),
+        errors.Is(err, services.ErrInvalidMFAChallenge):
+        return http.StatusUnauthorized
+    case errors.Is(err, services.ErrImportTooLarge),
+        errors.Is(err, services.ErrReceiptTooLarge):
+        return http.StatusRequestEntityTooLarge
+    case errors.Is(err, services.ErrUnsupportedReceiptType):
+        return http.StatusUnsupportedMediaType
+    case errors.Is(err, services.ErrVerificationEmailThrottled):
+        return http.StatusTooManyRequests
+    case errors.Is(err, services.ErrReceiptOCRDisabled):
+        return http.StatusServiceUnavailable
+    }
+    return fallback
+}
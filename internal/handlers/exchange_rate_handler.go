@@ -0,0 +1,93 @@
+package handlers
+
+import (
+    "net/http"
+    "pocketpilot/internal/models"
+    "pocketpilot/internal/services"
+    "pocketpilot/internal/utils"
+
+    "github.com/gin-gonic/gin"
+)
+
+type ExchangeRateHandler struct {
+    rateService *services.ExchangeRateService
+}
+
+func NewExchangeRateHandler(rateService *services.ExchangeRateService) *ExchangeRateHandler {
+    return &ExchangeRateHandler{rateService: rateService}
+}
+
+// @Summary Get exchange rates
+// @Description List the latest stored rate of every currency pair on a date
+// @Tags Exchange Rates
+// @Produce json
+// @Security BearerAuth
+// @Param date query string false "Date (YYYY-MM-DD), today when omitted"
+// @Param currency query string false "Only pairs involving this currency"
+// @Success 200 {array} models.ExchangeRate
+// @Failure 400 {object} models.ErrorResponse
+// @Router /api/exchange-rates [get]
+func (h *ExchangeRateHandler) GetRates(c *gin.Context) {
+    rates, err := h.rateService.GetRates(c.Query("date"), c.Query("currency"))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Exchange rates retrieved successfully", rates))
+}
+
+// @Summary Set exchange rates
+// @Description Store exchange rates by hand, replacing rates stored for the same pair and date. Admins only.
+// @Tags Exchange Rates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param rates body models.SetExchangeRatesRequest true "Rates"
+// @Success 200 {object} models.ExchangeRateImportResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /api/exchange-rates [post]
+func (h *ExchangeRateHandler) SetRates(c *gin.Context) {
+    var req models.SetExchangeRatesRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    result, err := h.rateService.SetRates(&req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Exchange rates saved successfully", result))
+}
+
+// @Summary Import exchange rates
+// @Description Load rates from the ECB's XML reference rates or a CSV file, replacing rates stored for the same pair and date. Admins only.
+// @Tags Exchange Rates
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "ECB XML or CSV rate file"
+// @Param format formData string false "ecb or csv, detected from the file when omitted"
+// @Success 200 {object} models.ExchangeRateImportResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /api/exchange-rates/import [post]
+func (h *ExchangeRateHandler) ImportRates(c *gin.Context) {
+    file, ok := importFile(c)
+    if !ok {
+        return
+    }
+    defer file.Close()
+
+    result, err := h.rateService.ImportRates(file, c.PostForm("format"))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Exchange rates imported successfully", result))
+}
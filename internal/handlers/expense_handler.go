@@ -1,14 +1,26 @@
 package handlers
 
 import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "mime/multipart"
     "net/http"
     "strconv"
+    "strings"
+    "time"
     "github.com/gin-gonic/gin"
     "pocketpilot/internal/models"
     "pocketpilot/internal/services"
     "pocketpilot/internal/utils"
+    "pocketpilot/pkg/money"
 )
 
+// maxImportFileSize caps the size of an uploaded import file
+const maxImportFileSize = 10 << 20
+
 type ExpenseHandler struct {
     expenseService *services.ExpenseService
 }
@@ -26,6 +38,8 @@ func NewExpenseHandler(expenseService *services.ExpenseService) *ExpenseHandler
 // @Param expense body models.CreateExpenseRequest true "Expense payload"
 // @Success 201 {object} models.Expense
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /api/expenses [post]
 func (h *ExpenseHandler) CreateExpense(c *gin.Context) {
     userID, exists := c.Get("userID")
@@ -42,7 +56,7 @@ func (h *ExpenseHandler) CreateExpense(c *gin.Context) {
 
     expense, err := h.expenseService.CreateExpense(userID.(string), &req)
     if err != nil {
-        c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
         return
     }
 
@@ -50,11 +64,28 @@ func (h *ExpenseHandler) CreateExpense(c *gin.Context) {
 }
 
 // @Summary Get expenses
-// @Description Retrieve user's expenses
+// @Description Retrieve user's expenses, optionally filtered, searched and sorted
 // @Tags Expenses
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {array} models.Expense
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size, at most 100" default(10)
+// @Param cursor query string false "Opaque cursor from a previous page's next_cursor, replaces page"
+// @Param include_total query bool false "Also return the total number of matching expenses"
+// @Param date_from query string false "Earliest expense date (YYYY-MM-DD)"
+// @Param date_to query string false "Latest expense date (YYYY-MM-DD)"
+// @Param category query []string false "Categories, repeat or comma-separate for several" collectionFormat(multi)
+// @Param tag query []string false "Tags, repeat or comma-separate for several; expenses with any of them match" collectionFormat(multi)
+// @Param status query []string false "Statuses, repeat or comma-separate for several" collectionFormat(multi)
+// @Param currency query string false "Currency code"
+// @Param min_amount query number false "Minimum amount"
+// @Param max_amount query number false "Maximum amount"
+// @Param team_id query string false "Only expenses of this team"
+// @Param q query string false "Text to search in descriptions"
+// @Param sort query string false "Sort field: expense_date, amount, created_at, category, status" default(expense_date)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Success 200 {array} models.Expense "Expenses, with a pagination block holding has_more, next_cursor and total"
+// @Failure 400 {object} models.ErrorResponse
 // @Router /api/expenses [get]
 func (h *ExpenseHandler) GetExpenses(c *gin.Context) {
     userID, exists := c.Get("userID")
@@ -63,16 +94,20 @@ func (h *ExpenseHandler) GetExpenses(c *gin.Context) {
         return
     }
 
-    page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-    limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+    filter, err := parseExpenseFilter(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+        return
+    }
+    page := parsePageRequest(c)
 
-    expenses, err := h.expenseService.GetUserExpenses(userID.(string), page, limit)
+    result, err := h.expenseService.GetUserExpenses(userID.(string), filter, page)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, utils.ErrorResponse(err.Error()))
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
         return
     }
 
-    c.JSON(http.StatusOK, utils.SuccessResponse("Expenses retrieved successfully", expenses))
+    c.JSON(http.StatusOK, utils.PaginatedResponse("Expenses retrieved successfully", result.Expenses, pagination(result)))
 }
 
 // @Summary Get expense
@@ -82,6 +117,7 @@ func (h *ExpenseHandler) GetExpenses(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "Expense ID"
 // @Success 200 {object} models.Expense
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /api/expenses/{id} [get]
 func (h *ExpenseHandler) GetExpense(c *gin.Context) {
@@ -94,7 +130,7 @@ func (h *ExpenseHandler) GetExpense(c *gin.Context) {
     expenseID := c.Param("id")
     expense, err := h.expenseService.GetExpense(expenseID, userID.(string))
     if err != nil {
-        c.JSON(http.StatusNotFound, utils.ErrorResponse(err.Error()))
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
         return
     }
 
@@ -111,6 +147,8 @@ func (h *ExpenseHandler) GetExpense(c *gin.Context) {
 // @Param expense body models.UpdateExpenseRequest true "Expense payload"
 // @Success 200 {object} models.Expense
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /api/expenses/{id} [put]
 func (h *ExpenseHandler) UpdateExpense(c *gin.Context) {
     userID, exists := c.Get("userID")
@@ -128,7 +166,7 @@ func (h *ExpenseHandler) UpdateExpense(c *gin.Context) {
 
     expense, err := h.expenseService.UpdateExpense(expenseID, userID.(string), &req)
     if err != nil {
-        c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
         return
     }
 
@@ -142,6 +180,7 @@ func (h *ExpenseHandler) UpdateExpense(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "Expense ID"
 // @Success 204
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /api/expenses/{id} [delete]
 func (h *ExpenseHandler) DeleteExpense(c *gin.Context) {
@@ -154,7 +193,7 @@ func (h *ExpenseHandler) DeleteExpense(c *gin.Context) {
     expenseID := c.Param("id")
     err := h.expenseService.DeleteExpense(expenseID, userID.(string))
     if err != nil {
-        c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
         return
     }
 
@@ -162,12 +201,30 @@ func (h *ExpenseHandler) DeleteExpense(c *gin.Context) {
 }
 
 // @Summary Get team expenses
-// @Description Retrieve expenses for a team
+// @Description Retrieve expenses for a team, accepting the same filters as the expense listing
 // @Tags Expenses
 // @Produce json
 // @Security BearerAuth
 // @Param teamId path string true "Team ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size, at most 100" default(10)
+// @Param cursor query string false "Opaque cursor from a previous page's next_cursor, replaces page"
+// @Param include_total query bool false "Also return the total number of matching expenses"
+// @Param date_from query string false "Earliest expense date (YYYY-MM-DD)"
+// @Param date_to query string false "Latest expense date (YYYY-MM-DD)"
+// @Param category query []string false "Categories, repeat or comma-separate for several" collectionFormat(multi)
+// @Param tag query []string false "Tags, repeat or comma-separate for several; expenses with any of them match" collectionFormat(multi)
+// @Param status query []string false "Statuses, repeat or comma-separate for several" collectionFormat(multi)
+// @Param currency query string false "Currency code"
+// @Param min_amount query number false "Minimum amount"
+// @Param max_amount query number false "Maximum amount"
+// @Param q query string false "Text to search in descriptions"
+// @Param sort query string false "Sort field: expense_date, amount, created_at, category, status" default(expense_date)
+// @Param order query string false "Sort order: asc or desc" default(desc)
 // @Success 200 {array} models.Expense
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /api/expenses/team/{teamId} [get]
 func (h *ExpenseHandler) GetTeamExpenses(c *gin.Context) {
     userID, exists := c.Get("userID")
@@ -177,14 +234,603 @@ func (h *ExpenseHandler) GetTeamExpenses(c *gin.Context) {
     }
 
     teamID := c.Param("teamId")
+
+    filter, err := parseExpenseFilter(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+        return
+    }
+    // The path already scopes the listing to one team
+    filter.TeamID = nil
+    page := parsePageRequest(c)
+
+    result, err := h.expenseService.GetTeamExpenses(teamID, userID.(string), filter, page)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.PaginatedResponse("Team expenses retrieved successfully", result.Expenses, pagination(result)))
+}
+
+// @Summary Get expense totals
+// @Description Sum the user's expenses matching the list filters in their base currency, converting each expense with the rate of its date
+// @Tags Expenses
+// @Produce json
+// @Security BearerAuth
+// @Param date_from query string false "Earliest expense date (YYYY-MM-DD)"
+// @Param date_to query string false "Latest expense date (YYYY-MM-DD)"
+// @Param category query []string false "Categories, repeat or comma-separate for several" collectionFormat(multi)
+// @Param tag query []string false "Tags, repeat or comma-separate for several; expenses with any of them match" collectionFormat(multi)
+// @Param status query []string false "Statuses, repeat or comma-separate for several" collectionFormat(multi)
+// @Param currency query string false "Currency code"
+// @Param team_id query string false "Only expenses of this team"
+// @Param q query string false "Text to search in descriptions"
+// @Success 200 {object} models.ExpenseTotals
+// @Failure 400 {object} models.ErrorResponse
+// @Router /api/expenses/totals [get]
+func (h *ExpenseHandler) GetExpenseTotals(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    filter, err := parseExpenseFilter(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    totals, err := h.expenseService.GetUserExpenseTotals(userID.(string), filter)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Expense totals retrieved successfully", totals))
+}
+
+// @Summary Get team expense totals
+// @Description Sum a team's expenses matching the list filters in the team's base currency, converting each expense with the rate of its date
+// @Tags Expenses
+// @Produce json
+// @Security BearerAuth
+// @Param teamId path string true "Team ID"
+// @Param date_from query string false "Earliest expense date (YYYY-MM-DD)"
+// @Param date_to query string false "Latest expense date (YYYY-MM-DD)"
+// @Param category query []string false "Categories, repeat or comma-separate for several" collectionFormat(multi)
+// @Param tag query []string false "Tags, repeat or comma-separate for several; expenses with any of them match" collectionFormat(multi)
+// @Param status query []string false "Statuses, repeat or comma-separate for several" collectionFormat(multi)
+// @Param currency query string false "Currency code"
+// @Param q query string false "Text to search in descriptions"
+// @Success 200 {object} models.ExpenseTotals
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/expenses/team/{teamId}/totals [get]
+func (h *ExpenseHandler) GetTeamExpenseTotals(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    filter, err := parseExpenseFilter(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+        return
+    }
+    filter.TeamID = nil
+
+    totals, err := h.expenseService.GetTeamExpenseTotals(c.Param("teamId"), userID.(string), filter)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Team expense totals retrieved successfully", totals))
+}
+
+// @Summary Export expenses
+// @Description Download the user's expenses matching the list filters as CSV or Excel. Dates and decimals follow the locale, taken from the locale parameter or the Accept-Language header.
+// @Tags Expenses
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Param format query string false "File format: csv or xlsx" default(csv)
+// @Param columns query []string false "Columns in order: id, date, description, category, amount, currency, status, team_id, user_id, receipt_url, created_at, updated_at" collectionFormat(multi)
+// @Param locale query string false "Locale such as en-US or de-DE"
+// @Param date_format query string false "Date format made of YYYY, YY, MM and DD, e.g. DD/MM/YYYY"
+// @Param date_from query string false "Earliest expense date (YYYY-MM-DD)"
+// @Param date_to query string false "Latest expense date (YYYY-MM-DD)"
+// @Param category query []string false "Categories, repeat or comma-separate for several" collectionFormat(multi)
+// @Param tag query []string false "Tags, repeat or comma-separate for several; expenses with any of them match" collectionFormat(multi)
+// @Param status query []string false "Statuses, repeat or comma-separate for several" collectionFormat(multi)
+// @Param currency query string false "Currency code"
+// @Param min_amount query number false "Minimum amount"
+// @Param max_amount query number false "Maximum amount"
+// @Param team_id query string false "Only expenses of this team"
+// @Param q query string false "Text to search in descriptions"
+// @Param sort query string false "Sort field: expense_date, amount, created_at, category, status" default(expense_date)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Success 200 {file} file
+// @Failure 400 {object} models.ErrorResponse
+// @Router /api/expenses/export [get]
+func (h *ExpenseHandler) ExportExpenses(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    filter, err := parseExpenseFilter(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+        return
+    }
+    opts := parseExportOptions(c)
+
+    streamExport(c, opts, "expenses", func(w io.Writer) error {
+        return h.expenseService.ExportUserExpenses(userID.(string), filter, opts, w)
+    })
+}
+
+// @Summary Export team expenses
+// @Description Download a team's expenses matching the list filters as CSV or Excel, for members who can read them
+// @Tags Expenses
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Param teamId path string true "Team ID"
+// @Param format query string false "File format: csv or xlsx" default(csv)
+// @Param columns query []string false "Columns in order: id, date, description, category, amount, currency, status, team_id, user_id, receipt_url, created_at, updated_at" collectionFormat(multi)
+// @Param locale query string false "Locale such as en-US or de-DE"
+// @Param date_format query string false "Date format made of YYYY, YY, MM and DD, e.g. DD/MM/YYYY"
+// @Param date_from query string false "Earliest expense date (YYYY-MM-DD)"
+// @Param date_to query string false "Latest expense date (YYYY-MM-DD)"
+// @Param category query []string false "Categories, repeat or comma-separate for several" collectionFormat(multi)
+// @Param tag query []string false "Tags, repeat or comma-separate for several; expenses with any of them match" collectionFormat(multi)
+// @Param status query []string false "Statuses, repeat or comma-separate for several" collectionFormat(multi)
+// @Param currency query string false "Currency code"
+// @Param min_amount query number false "Minimum amount"
+// @Param max_amount query number false "Maximum amount"
+// @Param q query string false "Text to search in descriptions"
+// @Param sort query string false "Sort field: expense_date, amount, created_at, category, status" default(expense_date)
+// @Param order query string false "Sort order: asc or desc" default(desc)
+// @Success 200 {file} file
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/expenses/team/{teamId}/export [get]
+func (h *ExpenseHandler) ExportTeamExpenses(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    teamID := c.Param("teamId")
+
+    filter, err := parseExpenseFilter(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+        return
+    }
+    filter.TeamID = nil
+    opts := parseExportOptions(c)
+
+    streamExport(c, opts, "team-expenses", func(w io.Writer) error {
+        return h.expenseService.ExportTeamExpenses(teamID, userID.(string), filter, opts, w)
+    })
+}
+
+// @Summary Import expenses
+// @Description Create expenses from a CSV file. Every row is validated like a new expense; valid rows are created in one transaction and invalid ones are reported by line. With dry_run nothing is created and a preview is returned.
+// @Tags Expenses
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "CSV file with a header row, at most 10000 rows"
+// @Param mapping formData string false "JSON object naming the header of each field: expense_date, amount, currency, description, category. Defaults to the export headers"
+// @Param dry_run formData bool false "Validate and preview without creating anything"
+// @Param team_id formData string false "Create the expenses in this team"
+// @Param locale formData string false "Locale of dates and numbers, such as en-US or de-DE"
+// @Param date_format formData string false "Date format made of YYYY, YY, MM and DD, e.g. DD/MM/YYYY"
+// @Param delimiter formData string false "Field delimiter, defaults to the locale's"
+// @Param default_currency formData string false "Currency for rows without one"
+// @Param default_category formData string false "Category for rows without one"
+// @Success 200 {object} models.ExpenseImportResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Router /api/expenses/import [post]
+func (h *ExpenseHandler) ImportExpenses(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    file, ok := importFile(c)
+    if !ok {
+        return
+    }
+    defer file.Close()
+
+    dryRun, _ := strconv.ParseBool(c.PostForm("dry_run"))
+    opts := &models.ExpenseImportOptions{
+        DryRun:          dryRun,
+        Locale:          c.PostForm("locale"),
+        DateFormat:      strings.TrimSpace(c.PostForm("date_format")),
+        Delimiter:       c.PostForm("delimiter"),
+        DefaultCurrency: c.PostForm("default_currency"),
+        DefaultCategory: c.PostForm("default_category"),
+    }
+    if teamID := c.PostForm("team_id"); teamID != "" {
+        opts.TeamID = &teamID
+    }
+    if mapping := c.PostForm("mapping"); mapping != "" {
+        if err := json.Unmarshal([]byte(mapping), &opts.Mapping); err != nil {
+            c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid column mapping"))
+            return
+        }
+    }
+
+    result, err := h.expenseService.ImportExpenses(userID.(string), file, opts)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    message := "Expenses imported successfully"
+    if result.DryRun {
+        message = "Import preview generated successfully"
+    }
+    c.JSON(http.StatusOK, utils.SuccessResponse(message, result))
+}
+
+// @Summary Import bank statement
+// @Description Create expenses from the debits of an OFX/QFX or QIF bank statement. Transactions already imported are skipped by their bank transaction ID, and credits are skipped as they are not expenses. With dry_run nothing is created and a preview is returned.
+// @Tags Expenses
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "OFX, QFX or QIF statement"
+// @Param format formData string false "ofx, qfx or qif, detected from the file when omitted"
+// @Param dry_run formData bool false "Validate and preview without creating anything"
+// @Param team_id formData string false "Create the expenses in this team"
+// @Param currency formData string false "Currency of transactions the file gives none for, which QIF never does"
+// @Param default_category formData string false "Category for transactions without one" default(Uncategorized)
+// @Param day_first formData bool false "QIF dates are DD/MM/YY rather than MM/DD/YY"
+// @Success 200 {object} models.ExpenseImportResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Router /api/expenses/import/statement [post]
+func (h *ExpenseHandler) ImportStatement(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    file, ok := importFile(c)
+    if !ok {
+        return
+    }
+    defer file.Close()
+
+    dryRun, _ := strconv.ParseBool(c.PostForm("dry_run"))
+    dayFirst, _ := strconv.ParseBool(c.PostForm("day_first"))
+    opts := &models.StatementImportOptions{
+        Format:          c.PostForm("format"),
+        DryRun:          dryRun,
+        Currency:        c.PostForm("currency"),
+        DefaultCategory: c.PostForm("default_category"),
+        DayFirst:        dayFirst,
+    }
+    if teamID := c.PostForm("team_id"); teamID != "" {
+        opts.TeamID = &teamID
+    }
+
+    result, err := h.expenseService.ImportStatement(userID.(string), file, opts)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    message := "Statement imported successfully"
+    if result.DryRun {
+        message = "Statement preview generated successfully"
+    }
+    c.JSON(http.StatusOK, utils.SuccessResponse(message, result))
+}
+
+// @Summary Submit expense
+// @Description Submit a draft or rejected team expense for approval
+// @Tags Expenses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Param action body models.ExpenseActionRequest false "Optional note"
+// @Success 200 {object} models.Expense
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/submit [post]
+func (h *ExpenseHandler) SubmitExpense(c *gin.Context) {
+    h.transitionExpense(c, h.expenseService.SubmitExpense, "Expense submitted successfully")
+}
+
+// @Summary Approve expense
+// @Description Approve the current step of a submitted team expense. The expense is approved once every step of its approval chain is.
+// @Tags Expenses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Param action body models.ExpenseActionRequest false "Optional note"
+// @Success 200 {object} models.Expense
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/approve [post]
+func (h *ExpenseHandler) ApproveExpense(c *gin.Context) {
+    h.transitionExpense(c, h.expenseService.ApproveExpense, "Expense approved successfully")
+}
+
+// @Summary Reject expense
+// @Description Reject the current step of a submitted team expense with a mandatory reason
+// @Tags Expenses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Param action body models.ExpenseActionRequest true "Rejection reason"
+// @Success 200 {object} models.Expense
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/reject [post]
+func (h *ExpenseHandler) RejectExpense(c *gin.Context) {
+    h.transitionExpense(c, h.expenseService.RejectExpense, "Expense rejected successfully")
+}
+
+// @Summary Reimburse expense
+// @Description Mark an approved team expense as reimbursed
+// @Tags Expenses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Param action body models.ExpenseActionRequest false "Optional note"
+// @Success 200 {object} models.Expense
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/reimburse [post]
+func (h *ExpenseHandler) ReimburseExpense(c *gin.Context) {
+    h.transitionExpense(c, h.expenseService.ReimburseExpense, "Expense reimbursed successfully")
+}
+
+// @Summary Get expense history
+// @Description Retrieve the status transition history of an expense
+// @Tags Expenses
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Success 200 {array} models.ExpenseTransition
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/history [get]
+func (h *ExpenseHandler) GetExpenseHistory(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    history, err := h.expenseService.GetExpenseHistory(c.Param("id"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Expense history retrieved successfully", history))
+}
+
+// @Summary Get expense approvals
+// @Description Retrieve the approval steps of every submission of an expense
+// @Tags Expenses
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Success 200 {array} models.ExpenseApprovalStep
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/approvals [get]
+func (h *ExpenseHandler) GetExpenseApprovals(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    steps, err := h.expenseService.GetExpenseApprovals(c.Param("id"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Expense approvals retrieved successfully", steps))
+}
+
+// transitionExpense runs a workflow action; the JSON body is optional
+func (h *ExpenseHandler) transitionExpense(c *gin.Context, action func(string, string, *models.ExpenseActionRequest) (*models.Expense, error), message string) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.ExpenseActionRequest
+    if c.Request.ContentLength != 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+            return
+        }
+    }
+
+    expense, err := action(c.Param("id"), userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse(message, expense))
+}
+
+// parseExpenseFilter reads listing filters from the query string. Multi-value
+// parameters may be repeated or comma-separated.
+func parseExpenseFilter(c *gin.Context) (*models.ExpenseFilter, error) {
+    filter := &models.ExpenseFilter{
+        DateFrom:   c.Query("date_from"),
+        DateTo:     c.Query("date_to"),
+        Categories: queryList(c, "category"),
+        Tags:       queryList(c, "tag"),
+        Statuses:   queryList(c, "status"),
+        Currency:   strings.ToUpper(strings.TrimSpace(c.Query("currency"))),
+        Search:     strings.TrimSpace(c.Query("q")),
+        Sort:       c.Query("sort"),
+        Order:      c.Query("order"),
+    }
+
+    if teamID := c.Query("team_id"); teamID != "" {
+        filter.TeamID = &teamID
+    }
+
+    for param, target := range map[string]**money.Amount{"min_amount": &filter.MinAmount, "max_amount": &filter.MaxAmount} {
+        raw := c.Query(param)
+        if raw == "" {
+            continue
+        }
+        value, err := money.Parse(raw)
+        if err != nil {
+            return nil, fmt.Errorf("invalid %s", param)
+        }
+        *target = &value
+    }
+
+    return filter, nil
+}
+
+// queryList collects a repeatable, comma-separated query parameter
+func queryList(c *gin.Context, key string) []string {
+    var values []string
+    for _, raw := range c.QueryArray(key) {
+        for _, value := range strings.Split(raw, ",") {
+            if value = strings.TrimSpace(value); value != "" {
+                values = append(values, value)
+            }
+        }
+    }
+    return values
+}
+
+// parsePageRequest reads page-number or cursor pagination from the query string
+func parsePageRequest(c *gin.Context) *models.PageRequest {
     page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
     limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+    includeTotal, _ := strconv.ParseBool(c.Query("include_total"))
+
+    return &models.PageRequest{
+        Page:         page,
+        Limit:        limit,
+        Cursor:       c.Query("cursor"),
+        IncludeTotal: includeTotal,
+    }
+}
+
+// pagination builds the response envelope's pagination block for a page of expenses
+func pagination(page *models.ExpensePage) *utils.Pagination {
+    return &utils.Pagination{
+        Page:       page.Page,
+        Limit:      page.Limit,
+        HasMore:    page.HasMore,
+        NextCursor: page.NextCursor,
+        Total:      page.Total,
+    }
+}
+
+// parseExportOptions reads the export format, columns and locale from the
+// query string, falling back to the Accept-Language header for the locale
+func parseExportOptions(c *gin.Context) *models.ExpenseExportOptions {
+    locale := strings.TrimSpace(c.Query("locale"))
+    if locale == "" {
+        // The first language is the preferred one: "de-DE,de;q=0.9,en;q=0.8"
+        accept := c.GetHeader("Accept-Language")
+        locale = strings.TrimSpace(strings.SplitN(strings.SplitN(accept, ",", 2)[0], ";", 2)[0])
+    }
+
+    return &models.ExpenseExportOptions{
+        Format:     strings.ToLower(c.DefaultQuery("format", models.ExportFormatCSV)),
+        Columns:    queryList(c, "columns"),
+        Locale:     locale,
+        DateFormat: strings.TrimSpace(c.Query("date_format")),
+    }
+}
+
+// streamExport sends an export as a file download. Errors found before the
+// first byte is written get a JSON error response; later ones can only cut the
+// download short.
+func streamExport(c *gin.Context, opts *models.ExpenseExportOptions, name string, export func(io.Writer) error) {
+    contentType := "text/csv; charset=utf-8"
+    if opts.Format == models.ExportFormatXLSX {
+        contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+    }
+    filename := fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("2006-01-02"), opts.Format)
+
+    c.Header("Content-Type", contentType)
+    c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+    c.Status(http.StatusOK)
 
-    expenses, err := h.expenseService.GetTeamExpenses(teamID, userID.(string), page, limit)
+    if err := export(c.Writer); err != nil {
+        if !c.Writer.Written() {
+            c.Writer.Header().Del("Content-Type")
+            c.Writer.Header().Del("Content-Disposition")
+            c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+            return
+        }
+        log.Printf("expense export failed mid-stream: %v", err)
+        c.Abort()
+    }
+}
+
+// importFile opens the uploaded import file, writing the error response when
+// there is none or it is too large
+func importFile(c *gin.Context) (multipart.File, bool) {
+    c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize)
+    fileHeader, err := c.FormFile("file")
     if err != nil {
-        c.JSON(http.StatusInternalServerError, utils.ErrorResponse(err.Error()))
-        return
+        var tooLarge *http.MaxBytesError
+        if errors.As(err, &tooLarge) {
+            c.JSON(http.StatusRequestEntityTooLarge, utils.ErrorResponse("Import file is too large"))
+            return nil, false
+        }
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("A file is required"))
+        return nil, false
     }
 
-    c.JSON(http.StatusOK, utils.SuccessResponse("Team expenses retrieved successfully", expenses))
-}
\ No newline at end of file
+    file, err := fileHeader.Open()
+    if err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return nil, false
+    }
+    return file, true
+}
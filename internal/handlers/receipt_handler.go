@@ -0,0 +1,263 @@
+package handlers
+
+import (
+    "errors"
+    "io"
+    "net/http"
+    "strings"
+    "github.com/gin-gonic/gin"
+    "pocketpilot/internal/services"
+    "pocketpilot/internal/utils"
+)
+
+// maxReceiptRequestSize leaves room for the multipart framing around a receipt
+const maxReceiptRequestSize = services.MaxReceiptSize + 1<<20
+
+type ReceiptHandler struct {
+    receiptService *services.ReceiptService
+}
+
+func NewReceiptHandler(receiptService *services.ReceiptService) *ReceiptHandler {
+    return &ReceiptHandler{receiptService: receiptService}
+}
+
+// @Summary Upload receipt
+// @Description Attach a receipt to a draft or rejected expense. Receipts are JPEG, PNG, WebP or HEIC images or PDFs of at most 10 MB, detected from the file's content. Photos are turned upright and lose their location and other metadata; JPEG and PNG images and PDFs get small, medium and large thumbnails. The response carries short-lived download URLs.
+// @Tags Receipts
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Param file formData file true "Receipt file"
+// @Success 201 {object} models.Receipt
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 415 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/receipts [post]
+func (h *ReceiptHandler) UploadReceipt(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxReceiptRequestSize)
+    fileHeader, err := c.FormFile("file")
+    if err != nil {
+        var tooLarge *http.MaxBytesError
+        if errors.As(err, &tooLarge) {
+            c.JSON(http.StatusRequestEntityTooLarge, utils.ErrorResponse(services.ErrReceiptTooLarge.Error()))
+            return
+        }
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("A file is required"))
+        return
+    }
+    file, err := fileHeader.Open()
+    if err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+    defer file.Close()
+
+    receipt, err := h.receiptService.UploadReceipt(c.Param("id"), userID.(string), fileHeader.Filename, file)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusCreated, utils.SuccessResponse("Receipt uploaded successfully", receipt))
+}
+
+// @Summary Get receipts
+// @Description List the receipts of an expense, each with a short-lived download URL
+// @Tags Receipts
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Success 200 {array} models.Receipt
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/receipts [get]
+func (h *ReceiptHandler) GetReceipts(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    receipts, err := h.receiptService.GetReceipts(c.Param("id"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Receipts retrieved successfully", receipts))
+}
+
+// @Summary Get receipt
+// @Description Get a receipt of an expense with a fresh short-lived download URL
+// @Tags Receipts
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Param receiptId path string true "Receipt ID"
+// @Success 200 {object} models.Receipt
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/receipts/{receiptId} [get]
+func (h *ReceiptHandler) GetReceipt(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    receipt, err := h.receiptService.GetReceipt(c.Param("id"), c.Param("receiptId"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Receipt retrieved successfully", receipt))
+}
+
+// @Summary Download receipt
+// @Description Redirect to a short-lived download URL for a receipt, or for one of its thumbnails or its rendered first page
+// @Tags Receipts
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Param receiptId path string true "Receipt ID"
+// @Param variant query string false "small, medium, large or page"
+// @Success 302
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/receipts/{receiptId}/download [get]
+func (h *ReceiptHandler) DownloadReceipt(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    url, err := h.receiptService.ReceiptDownloadURL(c.Param("id"), c.Param("receiptId"), userID.(string), c.Query("variant"))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.Redirect(http.StatusFound, url)
+}
+
+// @Summary Delete receipt
+// @Description Remove a receipt from a draft or rejected expense
+// @Tags Receipts
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Param receiptId path string true "Receipt ID"
+// @Success 204
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/receipts/{receiptId} [delete]
+func (h *ReceiptHandler) DeleteReceipt(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    if err := h.receiptService.DeleteReceipt(c.Param("id"), c.Param("receiptId"), userID.(string)); err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Receipt deleted successfully", nil))
+}
+
+// @Summary Serve receipt file
+// @Description Serve a receipt file through a signed URL issued by the local receipt storage. The signature authorizes the download, so no token is needed.
+// @Tags Receipts
+// @Produce octet-stream
+// @Param key path string true "Storage key"
+// @Param expires query string true "Expiry, as a Unix timestamp"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/receipts/files/{key} [get]
+func (h *ReceiptHandler) ServeReceiptFile(c *gin.Context) {
+    key := strings.TrimPrefix(c.Param("key"), "/")
+    file, contentType, err := h.receiptService.OpenSignedReceipt(key, c.Query("expires"), c.Query("signature"))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+    defer file.Close()
+
+    c.Header("Cache-Control", "private, no-store")
+    c.Header("X-Content-Type-Options", "nosniff")
+    c.Header("Content-Type", contentType)
+    c.Status(http.StatusOK)
+    if _, err := io.Copy(c.Writer, file); err != nil {
+        c.Error(err)
+    }
+}
+
+// @Summary Scan receipt again
+// @Description Queue a receipt for OCR again, for instance after a failed scan. The receipt's ocr_status shows when the scan is done.
+// @Tags Receipts
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Param receiptId path string true "Receipt ID"
+// @Success 202 {object} models.Receipt
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/receipts/{receiptId}/scan [post]
+func (h *ReceiptHandler) RescanReceipt(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    receipt, err := h.receiptService.RescanReceipt(c.Param("id"), c.Param("receiptId"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusAccepted, utils.SuccessResponse("Receipt queued for scanning", receipt))
+}
+
+// @Summary Reconcile receipt
+// @Description Compare the amount, currency and date OCR read on a receipt with its expense. While the expense is editable, mismatched amounts and dates come back as an update to send to PUT /api/expenses/{id}.
+// @Tags Receipts
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Param receiptId path string true "Receipt ID"
+// @Success 200 {object} models.ReceiptReconciliation
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/receipts/{receiptId}/reconciliation [get]
+func (h *ReceiptHandler) ReconcileReceipt(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    reconciliation, err := h.receiptService.ReconcileReceipt(c.Param("id"), c.Param("receiptId"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Receipt reconciled successfully", reconciliation))
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+    "net/http"
+    "github.com/gin-gonic/gin"
+    "pocketpilot/internal/models"
+    "pocketpilot/internal/services"
+    "pocketpilot/internal/utils"
+)
+
+type RecurringExpenseHandler struct {
+    recurringService *services.RecurringExpenseService
+}
+
+func NewRecurringExpenseHandler(recurringService *services.RecurringExpenseService) *RecurringExpenseHandler {
+    return &RecurringExpenseHandler{recurringService: recurringService}
+}
+
+// @Summary Create recurring expense
+// @Description Create a template that becomes a draft expense on every occurrence, e.g. monthly rent. Past start dates are caught up on.
+// @Tags Recurring Expenses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param recurring body models.CreateRecurringExpenseRequest true "Recurring expense payload"
+// @Success 201 {object} models.RecurringExpense
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /api/recurring-expenses [post]
+func (h *RecurringExpenseHandler) CreateRecurringExpense(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.CreateRecurringExpenseRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    recurring, err := h.recurringService.CreateRecurringExpense(userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusCreated, utils.SuccessResponse("Recurring expense created successfully", recurring))
+}
+
+// @Summary Get recurring expenses
+// @Description List the user's recurring expense templates
+// @Tags Recurring Expenses
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.RecurringExpense
+// @Router /api/recurring-expenses [get]
+func (h *RecurringExpenseHandler) GetRecurringExpenses(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    templates, err := h.recurringService.GetRecurringExpenses(userID.(string))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Recurring expenses retrieved successfully", templates))
+}
+
+// @Summary Get recurring expense
+// @Description Get a recurring expense template by ID
+// @Tags Recurring Expenses
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Recurring expense ID"
+// @Success 200 {object} models.RecurringExpense
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/recurring-expenses/{id} [get]
+func (h *RecurringExpenseHandler) GetRecurringExpense(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    recurring, err := h.recurringService.GetRecurringExpense(c.Param("id"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Recurring expense retrieved successfully", recurring))
+}
+
+// @Summary Update recurring expense
+// @Description Change a template's amount, description, category or end, or pause and resume it. Resumed templates skip the occurrences they missed.
+// @Tags Recurring Expenses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Recurring expense ID"
+// @Param recurring body models.UpdateRecurringExpenseRequest true "Recurring expense changes"
+// @Success 200 {object} models.RecurringExpense
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/recurring-expenses/{id} [put]
+func (h *RecurringExpenseHandler) UpdateRecurringExpense(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.UpdateRecurringExpenseRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    recurring, err := h.recurringService.UpdateRecurringExpense(c.Param("id"), userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Recurring expense updated successfully", recurring))
+}
+
+// @Summary Delete recurring expense
+// @Description Delete a recurring expense template. Expenses it already created are kept.
+// @Tags Recurring Expenses
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Recurring expense ID"
+// @Success 200
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/recurring-expenses/{id} [delete]
+func (h *RecurringExpenseHandler) DeleteRecurringExpense(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    err := h.recurringService.DeleteRecurringExpense(c.Param("id"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Recurring expense deleted successfully", nil))
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+    "github.com/gin-gonic/gin"
+    "pocketpilot/internal/models"
+    "pocketpilot/internal/services"
+    "pocketpilot/internal/utils"
+)
+
+type ReportHandler struct {
+    reportService *services.ReportService
+}
+
+func NewReportHandler(reportService *services.ReportService) *ReportHandler {
+    return &ReportHandler{reportService: reportService}
+}
+
+// @Summary Get spending summary
+// @Description Total, average and daily average spending over a date range, the current month by default, in the user's or team's base currency, with counts per status and the top categories. Rejected expenses only count in the status breakdown.
+// @Tags Reports
+// @Produce json
+// @Security BearerAuth
+// @Param team_id query string false "Report on this team's expenses"
+// @Param date_from query string false "Earliest expense date, YYYY-MM-DD"
+// @Param date_to query string false "Latest expense date, YYYY-MM-DD, defaults to today"
+// @Param category query []string false "Only these categories" collectionFormat(multi)
+// @Param tag query []string false "Only expenses with any of these tags" collectionFormat(multi)
+// @Param status query []string false "Only these statuses" collectionFormat(multi)
+// @Param currency query string false "Only expenses in this currency"
+// @Success 200 {object} models.ReportSummary
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/reports/summary [get]
+func (h *ReportHandler) GetSummary(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    filter, err := parseExpenseFilter(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    summary, err := h.reportService.GetSummary(userID.(string), filter)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Spending summary retrieved successfully", summary))
+}
+
+// @Summary Get spending by category
+// @Description Total spending per category over a date range, the current month by default, largest first
+// @Tags Reports
+// @Produce json
+// @Security BearerAuth
+// @Param team_id query string false "Report on this team's expenses"
+// @Param date_from query string false "Earliest expense date, YYYY-MM-DD"
+// @Param date_to query string false "Latest expense date, YYYY-MM-DD, defaults to today"
+// @Param tag query []string false "Only expenses with any of these tags" collectionFormat(multi)
+// @Param status query []string false "Only these statuses" collectionFormat(multi)
+// @Param currency query string false "Only expenses in this currency"
+// @Success 200 {object} models.Report
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/reports/categories [get]
+func (h *ReportHandler) GetCategoryBreakdown(c *gin.Context) {
+    h.breakdown(c, models.ReportByCategory, "Category breakdown retrieved successfully")
+}
+
+// @Summary Get spending by tag
+// @Description Total spending per tag over a date range, the current month by default, largest first. Expenses with several tags count toward each, so shares can add up to more than 100.
+// @Tags Reports
+// @Produce json
+// @Security BearerAuth
+// @Param team_id query string false "Report on this team's expenses"
+// @Param date_from query string false "Earliest expense date, YYYY-MM-DD"
+// @Param date_to query string false "Latest expense date, YYYY-MM-DD, defaults to today"
+// @Param category query []string false "Only these categories" collectionFormat(multi)
+// @Param tag query []string false "Only these tags" collectionFormat(multi)
+// @Param status query []string false "Only these statuses" collectionFormat(multi)
+// @Param currency query string false "Only expenses in this currency"
+// @Success 200 {object} models.Report
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/reports/tags [get]
+func (h *ReportHandler) GetTagBreakdown(c *gin.Context) {
+    h.breakdown(c, models.ReportByTag, "Tag breakdown retrieved successfully")
+}
+
+// @Summary Get spending by status
+// @Description Total expenses per status over a date range, the current month by default, including rejected ones
+// @Tags Reports
+// @Produce json
+// @Security BearerAuth
+// @Param team_id query string false "Report on this team's expenses"
+// @Param date_from query string false "Earliest expense date, YYYY-MM-DD"
+// @Param date_to query string false "Latest expense date, YYYY-MM-DD, defaults to today"
+// @Param category query []string false "Only these categories" collectionFormat(multi)
+// @Param tag query []string false "Only expenses with any of these tags" collectionFormat(multi)
+// @Param currency query string false "Only expenses in this currency"
+// @Success 200 {object} models.Report
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/reports/statuses [get]
+func (h *ReportHandler) GetStatusBreakdown(c *gin.Context) {
+    h.breakdown(c, models.ReportByStatus, "Status breakdown retrieved successfully")
+}
+
+// @Summary Get spending by team member
+// @Description Total spending per member of a team over a date range, the current month by default, largest first
+// @Tags Reports
+// @Produce json
+// @Security BearerAuth
+// @Param team_id query string true "Team ID"
+// @Param date_from query string false "Earliest expense date, YYYY-MM-DD"
+// @Param date_to query string false "Latest expense date, YYYY-MM-DD, defaults to today"
+// @Param category query []string false "Only these categories" collectionFormat(multi)
+// @Param tag query []string false "Only expenses with any of these tags" collectionFormat(multi)
+// @Param status query []string false "Only these statuses" collectionFormat(multi)
+// @Success 200 {object} models.Report
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/reports/members [get]
+func (h *ReportHandler) GetMemberBreakdown(c *gin.Context) {
+    h.breakdown(c, models.ReportByMember, "Member breakdown retrieved successfully")
+}
+
+func (h *ReportHandler) breakdown(c *gin.Context, groupBy, message string) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    filter, err := parseExpenseFilter(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    report, err := h.reportService.GetBreakdown(userID.(string), groupBy, filter)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse(message, report))
+}
+
+// @Summary Get spending timeline
+// @Description Total spending per month or week, oldest first and including periods without spending. Covers the last 12 months or weeks unless a range is given.
+// @Tags Reports
+// @Produce json
+// @Security BearerAuth
+// @Param interval query string false "month (default) or week"
+// @Param team_id query string false "Report on this team's expenses"
+// @Param date_from query string false "Earliest expense date, YYYY-MM-DD"
+// @Param date_to query string false "Latest expense date, YYYY-MM-DD, defaults to today"
+// @Param category query []string false "Only these categories" collectionFormat(multi)
+// @Param tag query []string false "Only expenses with any of these tags" collectionFormat(multi)
+// @Param status query []string false "Only these statuses" collectionFormat(multi)
+// @Success 200 {object} models.Report
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/reports/timeline [get]
+func (h *ReportHandler) GetTimeline(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    filter, err := parseExpenseFilter(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    report, err := h.reportService.GetTimeline(userID.(string), c.Query("interval"), filter)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Spending timeline retrieved successfully", report))
+}
+
+// @Summary Get spending trend
+// @Description Month-over-month spending with the change from each month to the next, up to the month of date_to or the current month
+// @Tags Reports
+// @Produce json
+// @Security BearerAuth
+// @Param months query int false "Number of months, 1 to 24, defaults to 6"
+// @Param team_id query string false "Report on this team's expenses"
+// @Param date_to query string false "A day in the last month, YYYY-MM-DD, defaults to today"
+// @Param category query []string false "Only these categories" collectionFormat(multi)
+// @Param tag query []string false "Only expenses with any of these tags" collectionFormat(multi)
+// @Param status query []string false "Only these statuses" collectionFormat(multi)
+// @Success 200 {object} models.SpendingTrend
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/reports/trends [get]
+func (h *ReportHandler) GetTrend(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    filter, err := parseExpenseFilter(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+        return
+    }
+    filter.DateFrom = ""
+
+    months := 0
+    if raw := c.Query("months"); raw != "" {
+        if months, err = strconv.Atoi(raw); err != nil {
+            c.JSON(http.StatusBadRequest, utils.ErrorResponse("invalid months"))
+            return
+        }
+    }
+
+    trend, err := h.reportService.GetTrend(userID.(string), months, filter)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Spending trend retrieved successfully", trend))
+}
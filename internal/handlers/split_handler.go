@@ -0,0 +1,190 @@
+package handlers
+
+import (
+    "net/http"
+    "github.com/gin-gonic/gin"
+    "pocketpilot/internal/models"
+    "pocketpilot/internal/services"
+    "pocketpilot/internal/utils"
+)
+
+type SplitHandler struct {
+    splitService *services.SplitService
+}
+
+func NewSplitHandler(splitService *services.SplitService) *SplitHandler {
+    return &SplitHandler{splitService: splitService}
+}
+
+// @Summary Split expense
+// @Description Split a team expense among team members equally, by exact amounts, by percentages or by shares, replacing any earlier split. The expense's owner paid, so the other members owe them their portions.
+// @Tags Splits
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Param split body models.SplitExpenseRequest true "Split payload"
+// @Success 200 {object} models.ExpenseSplits
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/splits [put]
+func (h *SplitHandler) SplitExpense(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.SplitExpenseRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    splits, err := h.splitService.SplitExpense(c.Param("id"), userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Expense split successfully", splits))
+}
+
+// @Summary Get expense split
+// @Description Retrieve how an expense is split among team members
+// @Tags Splits
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Success 200 {object} models.ExpenseSplits
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/splits [get]
+func (h *SplitHandler) GetExpenseSplits(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    splits, err := h.splitService.GetExpenseSplits(c.Param("id"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Expense split retrieved successfully", splits))
+}
+
+// @Summary Remove expense split
+// @Description Make a split expense the owner's alone again
+// @Tags Splits
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Expense ID"
+// @Success 204
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/expenses/{id}/splits [delete]
+func (h *SplitHandler) RemoveExpenseSplit(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    if err := h.splitService.RemoveExpenseSplit(c.Param("id"), userID.(string)); err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Expense split removed successfully", nil))
+}
+
+// @Summary Get team balances
+// @Description Work out who owes whom in a team from split expenses and settlements, per currency, with the fewest payments that settle every balance
+// @Tags Splits
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Team ID"
+// @Success 200 {object} models.TeamBalances
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/teams/{id}/balances [get]
+func (h *SplitHandler) GetTeamBalances(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    balances, err := h.splitService.GetTeamBalances(c.Param("id"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Team balances retrieved successfully", balances))
+}
+
+// @Summary Record settlement
+// @Description Record a payment between team members that pays off what one owes the other. Members record payments they made or received, admins and owners any payment.
+// @Tags Splits
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Team ID"
+// @Param settlement body models.CreateSettlementRequest true "Settlement payload"
+// @Success 201 {object} models.Settlement
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/teams/{id}/settlements [post]
+func (h *SplitHandler) CreateSettlement(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.CreateSettlementRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    settlement, err := h.splitService.CreateSettlement(c.Param("id"), userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusCreated, utils.SuccessResponse("Settlement recorded successfully", settlement))
+}
+
+// @Summary Get settlements
+// @Description List a team's settlements, newest first
+// @Tags Splits
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Team ID"
+// @Success 200 {array} models.Settlement
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/teams/{id}/settlements [get]
+func (h *SplitHandler) GetSettlements(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    settlements, err := h.splitService.GetSettlements(c.Param("id"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Settlements retrieved successfully", settlements))
+}
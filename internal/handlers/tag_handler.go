@@ -0,0 +1,139 @@
+package handlers
+
+import (
+    "net/http"
+    "github.com/gin-gonic/gin"
+    "pocketpilot/internal/models"
+    "pocketpilot/internal/services"
+    "pocketpilot/internal/utils"
+)
+
+type TagHandler struct {
+    tagService *services.TagService
+}
+
+func NewTagHandler(tagService *services.TagService) *TagHandler {
+    return &TagHandler{tagService: tagService}
+}
+
+// @Summary Get tags
+// @Description List the tags of the user's personal expenses, or of a team's expenses, with how many expenses carry each. Tags are created by tagging expenses.
+// @Tags Tags
+// @Produce json
+// @Security BearerAuth
+// @Param team_id query string false "List this team's tags"
+// @Success 200 {array} models.Tag
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/tags [get]
+func (h *TagHandler) GetTags(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    tags, err := h.tagService.GetTags(userID.(string), teamIDQuery(c))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Tags retrieved successfully", tags))
+}
+
+// @Summary Rename tag
+// @Description Rename a tag on all its expenses. Team tags are renamed by team owners and admins.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Tag ID"
+// @Param tag body models.RenameTagRequest true "New name"
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/tags/{id} [put]
+func (h *TagHandler) RenameTag(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.RenameTagRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    tag, err := h.tagService.RenameTag(c.Param("id"), userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Tag renamed successfully", tag))
+}
+
+// @Summary Merge tag
+// @Description Move a tag's expenses to another tag of the same user or team, then delete it
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Tag ID"
+// @Param merge body models.MergeTagRequest true "Tag to merge into"
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/tags/{id}/merge [post]
+func (h *TagHandler) MergeTag(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.MergeTagRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    tag, err := h.tagService.MergeTag(c.Param("id"), userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusBadRequest), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Tags merged successfully", tag))
+}
+
+// @Summary Delete tag
+// @Description Delete a tag and remove it from its expenses
+// @Tags Tags
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Tag ID"
+// @Success 204
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/tags/{id} [delete]
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    if err := h.tagService.DeleteTag(c.Param("id"), userID.(string)); err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Tag deleted successfully", nil))
+}
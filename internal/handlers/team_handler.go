@@ -0,0 +1,284 @@
+package handlers
+
+import (
+    "net/http"
+    "github.com/gin-gonic/gin"
+    "pocketpilot/internal/models"
+    "pocketpilot/internal/services"
+    "pocketpilot/internal/utils"
+)
+
+type TeamHandler struct {
+    teamService *services.TeamService
+}
+
+func NewTeamHandler(teamService *services.TeamService) *TeamHandler {
+    return &TeamHandler{teamService: teamService}
+}
+
+// @Summary Create team
+// @Description Create a new team owned by the authenticated user
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param team body models.CreateTeamRequest true "Team payload"
+// @Success 201 {object} models.Team
+// @Failure 400 {object} models.ErrorResponse
+// @Router /api/teams [post]
+func (h *TeamHandler) CreateTeam(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.CreateTeamRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    team, err := h.teamService.CreateTeam(userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusCreated, utils.SuccessResponse("Team created successfully", team))
+}
+
+// @Summary Get my teams
+// @Description Retrieve the teams the authenticated user belongs to
+// @Tags Teams
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Team
+// @Router /api/teams [get]
+func (h *TeamHandler) GetTeams(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    teams, err := h.teamService.GetUserTeams(userID.(string))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Teams retrieved successfully", teams))
+}
+
+// @Summary Get team
+// @Description Retrieve a team the authenticated user belongs to
+// @Tags Teams
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Team ID"
+// @Success 200 {object} models.Team
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/teams/{id} [get]
+func (h *TeamHandler) GetTeam(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    team, err := h.teamService.GetTeam(c.Param("id"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Team retrieved successfully", team))
+}
+
+// @Summary Rename team
+// @Description Rename a team (owners and admins only)
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Team ID"
+// @Param team body models.UpdateTeamRequest true "Team payload"
+// @Success 200 {object} models.Team
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/teams/{id} [put]
+func (h *TeamHandler) UpdateTeam(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.UpdateTeamRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    team, err := h.teamService.UpdateTeam(c.Param("id"), userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Team updated successfully", team))
+}
+
+// @Summary Delete team
+// @Description Delete a team (owners only). Its expenses become personal expenses; submitted and approved ones go back to draft.
+// @Tags Teams
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Team ID"
+// @Success 200
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/teams/{id} [delete]
+func (h *TeamHandler) DeleteTeam(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    err := h.teamService.DeleteTeam(c.Param("id"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Team deleted successfully", nil))
+}
+
+// @Summary Get team members
+// @Description List the members of a team
+// @Tags Teams
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Team ID"
+// @Success 200 {array} models.TeamMember
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/teams/{id}/members [get]
+func (h *TeamHandler) GetTeamMembers(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    members, err := h.teamService.GetTeamMembers(c.Param("id"), userID.(string))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Team members retrieved successfully", members))
+}
+
+// @Summary Add team member
+// @Description Add a registered user to a team by email (owners and admins only)
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Team ID"
+// @Param member body models.AddTeamMemberRequest true "Member payload"
+// @Success 201 {object} models.TeamMember
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/teams/{id}/members [post]
+func (h *TeamHandler) AddTeamMember(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.AddTeamMemberRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    member, err := h.teamService.AddTeamMember(c.Param("id"), userID.(string), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusCreated, utils.SuccessResponse("Team member added successfully", member))
+}
+
+// @Summary Change team member role
+// @Description Change a member's role (owners and admins only, ownership changes by owners only)
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Team ID"
+// @Param userId path string true "Member user ID"
+// @Param member body models.UpdateTeamMemberRequest true "Role payload"
+// @Success 200 {object} models.TeamMember
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/teams/{id}/members/{userId} [put]
+func (h *TeamHandler) UpdateTeamMember(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    var req models.UpdateTeamMemberRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request data"))
+        return
+    }
+
+    member, err := h.teamService.UpdateTeamMemberRole(c.Param("id"), userID.(string), c.Param("userId"), &req)
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Team member updated successfully", member))
+}
+
+// @Summary Remove team member
+// @Description Remove a member from a team, or leave it by passing your own user ID
+// @Tags Teams
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Team ID"
+// @Param userId path string true "Member user ID"
+// @Success 200
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/teams/{id}/members/{userId} [delete]
+func (h *TeamHandler) RemoveTeamMember(c *gin.Context) {
+    userID, exists := c.Get("userID")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not authenticated"))
+        return
+    }
+
+    err := h.teamService.RemoveTeamMember(c.Param("id"), userID.(string), c.Param("userId"))
+    if err != nil {
+        c.JSON(statusForError(err, http.StatusInternalServerError), utils.ErrorResponse(err.Error()))
+        return
+    }
+
+    c.JSON(http.StatusOK, utils.SuccessResponse("Team member removed successfully", nil))
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+    "net/http"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+)
+
+// VerifiedEmails looks up the current email of a user and whether it was verified
+type VerifiedEmails interface {
+    VerifiedEmail(userID string) (string, bool, error)
+}
+
+// RequireAdmin lets through only users whose email is in adminEmails and
+// verified. The email is looked up rather than taken from the token, which
+// may predate an email change. It must run after AuthMiddleware, which sets
+// the user's ID.
+func RequireAdmin(adminEmails []string, users VerifiedEmails) gin.HandlerFunc {
+    admins := make(map[string]bool, len(adminEmails))
+    for _, email := range adminEmails {
+        if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+            admins[email] = true
+        }
+    }
+
+    return func(c *gin.Context) {
+        email, verified, err := users.VerifiedEmail(c.GetString("userID"))
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not verify admin access"})
+            c.Abort()
+            return
+        }
+        if !verified || !admins[strings.ToLower(email)] {
+            c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+            c.Abort()
+            return
+        }
+
+        c.Next()
+    }
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubUser struct {
+	email    string
+	verified bool
+}
+
+// stubVerifiedEmails looks users up in a map, failing for unknown IDs
+type stubVerifiedEmails map[string]stubUser
+
+func (s stubVerifiedEmails) VerifiedEmail(userID string) (string, bool, error) {
+	user, ok := s[userID]
+	if !ok {
+		return "", false, errors.New("database unavailable")
+	}
+	return user.email, user.verified, nil
+}
+
+func TestRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	users := stubVerifiedEmails{
+		"admin":      {email: "Admin@Example.com", verified: true},
+		"unverified": {email: "admin@example.com"},
+		"member":     {email: "member@example.com", verified: true},
+		"changed":    {email: "new@example.com", verified: true},
+	}
+
+	router := gin.New()
+	router.POST("/rates", func(c *gin.Context) {
+		c.Set("userID", c.GetHeader("X-User"))
+		// The token was issued before the user changed their email
+		c.Set("userEmail", "admin@example.com")
+	}, RequireAdmin([]string{" admin@example.com ", ""}, users), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		userID string
+		status int
+	}{
+		{"Verified admin", "admin", http.StatusNoContent},
+		{"Unverified admin email", "unverified", http.StatusForbidden},
+		{"Not an admin", "member", http.StatusForbidden},
+		{"Admin email only in the token", "changed", http.StatusForbidden},
+		{"Lookup fails", "unknown", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/rates", nil)
+			req.Header.Set("X-User", tt.userID)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
@@ -8,8 +8,13 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// TokenRevocations tells whether an access token was revoked, by its jti or
+// along with its session
+type TokenRevocations interface {
+	IsTokenRevoked(tokenID, sessionID string) (bool, error)
+}
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+func AuthMiddleware(jwtSecret string, revocations TokenRevocations) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 
@@ -28,16 +33,30 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 
 	        tokenString := parts[1]
         claims, err := utils.ValidateToken(tokenString, jwtSecret)
-        if err != nil {
+        if err != nil || claims.ID == "" {
             c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
             c.Abort()
             return
         }
 
+        revoked, err := revocations.IsTokenRevoked(claims.ID, claims.SessionID)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not verify token"})
+            c.Abort()
+            return
+        }
+        if revoked {
+            c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+            c.Abort()
+            return
+        }
+
         // Set user ID in context for use in handlers
         c.Set("userID", claims.UserID)
         c.Set("userEmail", claims.Email)
+        c.Set("tokenID", claims.ID)
+        c.Set("sessionID", claims.SessionID)
         
         c.Next()
     }
-	}
\ No newline at end of file
+	}
@@ -0,0 +1,60 @@
+package models
+
+import (
+    "pocketpilot/pkg/money"
+    "time"
+)
+
+// Approval step statuses
+const (
+    ApprovalStepPending  = "pending"
+    ApprovalStepApproved = "approved"
+    ApprovalStepRejected = "rejected"
+)
+
+// ApprovalPolicy describes the approvers a team expense needs when it matches
+// the policy's conditions. Policies are evaluated by ascending priority and the
+// first match wins.
+type ApprovalPolicy struct {
+    ID         string               `json:"id"`
+    TeamID     string               `json:"team_id"`
+    Name       string               `json:"name"`
+    MinAmount  *money.Amount        `json:"min_amount,omitempty" swaggertype:"number"` // applies to expenses of at least this amount
+    Categories []string             `json:"categories,omitempty"` // applies only to these categories, all when empty
+    Priority   int                  `json:"priority"`
+    Steps      []ApprovalPolicyStep `json:"steps"`
+    CreatedAt  time.Time            `json:"created_at"`
+    UpdatedAt  time.Time            `json:"updated_at"`
+}
+
+// ApprovalPolicyStep is satisfied either by a named user or by any member
+// holding at least the given team role. Both must be owners or admins, the
+// roles that may approve.
+type ApprovalPolicyStep struct {
+    ApproverRole   *string `json:"approver_role,omitempty"`
+    ApproverUserID *string `json:"approver_user_id,omitempty"`
+}
+
+type ApprovalPolicyRequest struct {
+    Name       string               `json:"name" binding:"required,max=255"`
+    MinAmount  *money.Amount        `json:"min_amount,omitempty" binding:"omitempty,gte=0" swaggertype:"number"`
+    Categories []string             `json:"categories,omitempty"`
+    Priority   int                  `json:"priority"`
+    Steps      []ApprovalPolicyStep `json:"steps" binding:"required,min=1"`
+}
+
+// ExpenseApprovalStep records one required approval for a submission of an expense.
+// Each submission starts a new round so earlier decisions are kept.
+type ExpenseApprovalStep struct {
+    ID             string     `json:"id"`
+    ExpenseID      string     `json:"expense_id"`
+    Round          int        `json:"round"`
+    StepOrder      int        `json:"step_order"`
+    ApproverRole   *string    `json:"approver_role,omitempty"`
+    ApproverUserID *string    `json:"approver_user_id,omitempty"`
+    Status         string     `json:"status"` // pending, approved, rejected
+    DecidedBy      *string    `json:"decided_by,omitempty"`
+    DecidedAt      *time.Time `json:"decided_at,omitempty"`
+    Comment        *string    `json:"comment,omitempty"`
+    CreatedAt      time.Time  `json:"created_at"`
+}
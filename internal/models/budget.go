@@ -0,0 +1,79 @@
+package models
+
+import (
+    "pocketpilot/pkg/money"
+    "time"
+)
+
+// Budget periods, aligned to calendar months, quarters and years
+const (
+    BudgetPeriodMonthly   = "monthly"
+    BudgetPeriodQuarterly = "quarterly"
+    BudgetPeriodYearly    = "yearly"
+)
+
+// Budget is a spending limit per period on a user's personal expenses or a
+// team's expenses, either overall or in one category
+type Budget struct {
+    ID         string       `json:"id"`
+    UserID     string       `json:"user_id"`
+    TeamID     *string      `json:"team_id,omitempty"`
+    Category   *string      `json:"category,omitempty"` // unset for an overall budget
+    Amount     money.Amount `json:"amount" swaggertype:"number"`
+    Currency   string       `json:"currency"`
+    Period     string       `json:"period"`     // monthly, quarterly, yearly
+    StartDate  string       `json:"start_date"` // YYYY-MM-DD, spending before it is not counted
+    Rollover   bool         `json:"rollover"`   // carry what is left, or overspent, into the next period
+    Thresholds []int64      `json:"thresholds"` // percentages of the budget that raise an alert
+    CreatedAt  time.Time    `json:"created_at"`
+    UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+type CreateBudgetRequest struct {
+    TeamID     *string      `json:"team_id,omitempty"`
+    Category   *string      `json:"category,omitempty"`
+    Amount     money.Amount `json:"amount" binding:"required,gt=0" swaggertype:"number"`
+    Currency   string       `json:"currency" binding:"required"`
+    Period     string       `json:"period" binding:"required"`
+    StartDate  string       `json:"start_date,omitempty"` // defaults to today
+    Rollover   bool         `json:"rollover,omitempty"`
+    Thresholds []int64      `json:"thresholds,omitempty"` // defaults to 80 and 100
+}
+
+type UpdateBudgetRequest struct {
+    Amount     *money.Amount `json:"amount,omitempty" swaggertype:"number"`
+    Rollover   *bool         `json:"rollover,omitempty"`
+    Thresholds []int64       `json:"thresholds,omitempty"`
+}
+
+// BudgetStatus is what has been spent against a budget in one period
+type BudgetStatus struct {
+    Budget      *Budget      `json:"budget"`
+    PeriodStart string       `json:"period_start"`
+    PeriodEnd   string       `json:"period_end"`
+    Carried     money.Amount `json:"carried" swaggertype:"number"`   // left over from earlier periods, negative when overspent
+    Available   money.Amount `json:"available" swaggertype:"number"` // the budget amount plus what was carried
+    Spent       money.Amount `json:"spent" swaggertype:"number"`
+    Remaining   money.Amount `json:"remaining" swaggertype:"number"`
+    PercentUsed float64      `json:"percent_used"`
+    Unconverted int          `json:"unconverted"` // expense days left out for lack of an exchange rate
+}
+
+// DailySpending sums the expenses of one day in one currency
+type DailySpending struct {
+    Date     string
+    Currency string
+    Amount   money.Amount
+}
+
+// BudgetAlert is raised the first time spending in a period reaches a threshold
+type BudgetAlert struct {
+    ID          string       `json:"id"`
+    BudgetID    string       `json:"budget_id"`
+    PeriodStart string       `json:"period_start"`
+    Threshold   int64        `json:"threshold"`
+    Spent       money.Amount `json:"spent" swaggertype:"number"`
+    Available   money.Amount `json:"available" swaggertype:"number"`
+    ExpenseID   *string      `json:"expense_id,omitempty"` // the expense that crossed the threshold
+    CreatedAt   time.Time    `json:"created_at"`
+}
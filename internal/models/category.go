@@ -0,0 +1,42 @@
+package models
+
+import (
+    "time"
+)
+
+// Category classifies expenses. System categories have neither a user nor a
+// team and are offered to everyone; custom ones belong to a user's personal
+// expenses or to a team.
+type Category struct {
+    ID         string     `json:"id"`
+    UserID     *string    `json:"user_id,omitempty"`
+    TeamID     *string    `json:"team_id,omitempty"`
+    ParentID   *string    `json:"parent_id,omitempty"`
+    Name       string     `json:"name"`
+    Icon       *string    `json:"icon,omitempty"`
+    Color      *string    `json:"color,omitempty"` // #RRGGBB
+    ArchivedAt *time.Time `json:"archived_at,omitempty"` // archived categories stay on expenses but cannot be picked for new ones
+    CreatedAt  time.Time  `json:"created_at"`
+    UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// IsSystem reports whether the category is one of the defaults offered to everyone
+func (c *Category) IsSystem() bool {
+    return c.UserID == nil && c.TeamID == nil
+}
+
+type CreateCategoryRequest struct {
+    TeamID   *string `json:"team_id,omitempty"` // creates a team category instead of a personal one
+    ParentID *string `json:"parent_id,omitempty"`
+    Name     string  `json:"name" binding:"required,max=100"`
+    Icon     *string `json:"icon,omitempty" binding:"omitempty,max=50"`
+    Color    *string `json:"color,omitempty"`
+}
+
+type UpdateCategoryRequest struct {
+    ParentID *string `json:"parent_id,omitempty"` // empty string makes it top-level
+    Name     *string `json:"name,omitempty" binding:"omitempty,max=100"`
+    Icon     *string `json:"icon,omitempty" binding:"omitempty,max=50"`
+    Color    *string `json:"color,omitempty"`
+    Archived *bool   `json:"archived,omitempty"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+    "pocketpilot/pkg/money"
+    "time"
+)
+
+// Exchange rate sources
+const (
+    ExchangeRateSourceManual = "manual"
+    ExchangeRateSourceECB    = "ecb"
+    ExchangeRateSourceCSV    = "csv"
+)
+
+// ExchangeRate says one unit of BaseCurrency bought Rate units of Currency on RateDate
+type ExchangeRate struct {
+    BaseCurrency string     `json:"base_currency"`
+    Currency     string     `json:"currency"`
+    RateDate     string     `json:"rate_date"` // YYYY-MM-DD
+    Rate         money.Rate `json:"rate" swaggertype:"number"`
+    Source       string     `json:"source"` // manual, ecb, csv
+    UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type ExchangeRateInput struct {
+    BaseCurrency string     `json:"base_currency" binding:"required"`
+    Currency     string     `json:"currency" binding:"required"`
+    RateDate     string     `json:"rate_date" binding:"required"`
+    Rate         money.Rate `json:"rate" binding:"required" swaggertype:"number"`
+}
+
+type SetExchangeRatesRequest struct {
+    Rates []ExchangeRateInput `json:"rates" binding:"required,min=1,dive"`
+}
+
+type ExchangeRateImportResult struct {
+    Imported   int      `json:"imported"`
+    Currencies []string `json:"currencies"`
+    FirstDate  string   `json:"first_date"`
+    LastDate   string   `json:"last_date"`
+}
+
+// CurrencyTotal sums the expenses in one currency
+type CurrencyTotal struct {
+    Currency  string        `json:"currency"`
+    Count     int           `json:"count"`
+    Amount    money.Amount  `json:"amount" swaggertype:"number"`
+    Converted *money.Amount `json:"converted,omitempty" swaggertype:"number"` // in the base currency, unset when a rate is missing
+}
+
+// ExpenseTotals sums expenses matching a filter, converted to a base currency
+// with the rate of each expense's date
+type ExpenseTotals struct {
+    BaseCurrency string          `json:"base_currency"`
+    Count        int             `json:"count"`
+    Total        money.Amount    `json:"total" swaggertype:"number"`
+    ByCurrency   []CurrencyTotal `json:"by_currency"`
+    Unconverted  int             `json:"unconverted"` // expenses left out of total for lack of a rate
+}
@@ -1,40 +1,184 @@
 package models
 
 import (
+    "pocketpilot/pkg/money"
     "time"
 )
 
+// Expense approval workflow statuses
+const (
+    ExpenseStatusDraft      = "draft"
+    ExpenseStatusSubmitted  = "submitted"
+    ExpenseStatusApproved   = "approved"
+    ExpenseStatusRejected   = "rejected"
+    ExpenseStatusReimbursed = "reimbursed"
+)
+
 type Expense struct {
     ID             string    `json:"id"`
     UserID         string    `json:"user_id"`
     TeamID         *string   `json:"team_id,omitempty"`
-    Amount         float64   `json:"amount"`
+    Amount         money.Amount `json:"amount" swaggertype:"number"`
     Currency       string    `json:"currency"`
     Description    string    `json:"description"`
     Category       string    `json:"category"`
+    CategoryID     *string   `json:"category_id,omitempty"`
+    Tags           []string  `json:"tags,omitempty"`
     ExpenseDate    string    `json:"expense_date"` // YYYY-MM-DD
     ReceiptImageURL *string  `json:"receipt_image_url,omitempty"`
-    Status         string    `json:"status"` // pending, approved, rejected
+    Status         string    `json:"status"` // draft, submitted, approved, rejected, reimbursed
+    BankTransactionID *string `json:"bank_transaction_id,omitempty"` // set for expenses imported from bank statements
+    RecurringExpenseID *string `json:"recurring_expense_id,omitempty"` // set for expenses created from a recurring expense
+    BaseCurrency   *string     `json:"base_currency,omitempty"` // owner's base currency when the expense was recorded
+    ExchangeRate   *money.Rate `json:"exchange_rate,omitempty" swaggertype:"number"` // from Currency to BaseCurrency on the expense date
+    ConvertedAmount   *money.Amount `json:"converted_amount,omitempty" swaggertype:"number"` // in ConvertedCurrency, filled in by listings
+    ConvertedCurrency string        `json:"converted_currency,omitempty"`
+    SplitMethod    *string   `json:"split_method,omitempty"` // set when the expense is split among team members
+    BudgetAlerts   []*BudgetAlert `json:"budget_alerts,omitempty"` // alerts the expense raised, filled in on create and update
     CreatedAt      time.Time `json:"created_at"`
     UpdatedAt      time.Time `json:"updated_at"`
 }
 
 type CreateExpenseRequest struct {
-    Amount         float64 `json:"amount" binding:"required,gt=0"`
+    Amount         money.Amount `json:"amount" binding:"required,gt=0" swaggertype:"number"`
     Currency       string  `json:"currency" binding:"required"`
     Description    string  `json:"description" binding:"required"`
-    Category       string  `json:"category" binding:"required"`
+    Category       string  `json:"category" binding:"required"` // name of a category, matched ignoring case
     ExpenseDate    string  `json:"expense_date" binding:"required"`
     TeamID         *string `json:"team_id,omitempty"`
     ReceiptImageURL *string `json:"receipt_image_url,omitempty"`
+    Tags           []string `json:"tags,omitempty"` // created on the fly, matched ignoring case
 }
 
 type UpdateExpenseRequest struct {
-    Amount      *float64 `json:"amount,omitempty"`
+    Amount      *money.Amount `json:"amount,omitempty" swaggertype:"number"`
     Description *string  `json:"description,omitempty"`
     Category    *string  `json:"category,omitempty"`
     ExpenseDate *string  `json:"expense_date,omitempty"`
-    Status      *string  `json:"status,omitempty"`
+    Tags        []string `json:"tags,omitempty"` // replaces the tags when present, an empty list removes them
+}
+
+// ExpenseTransition is an entry in an expense's append-only status history
+type ExpenseTransition struct {
+    ID         string    `json:"id"`
+    ExpenseID  string    `json:"expense_id"`
+    FromStatus string    `json:"from_status"`
+    ToStatus   string    `json:"to_status"`
+    ActorID    string    `json:"actor_id"`
+    Reason     *string   `json:"reason,omitempty"`
+    CreatedAt  time.Time `json:"created_at"`
+}
+
+type ExpenseActionRequest struct {
+    Reason string `json:"reason,omitempty"` // required when rejecting
+}
+
+// ExpenseFilter narrows and orders expense listings. Empty fields do not filter.
+type ExpenseFilter struct {
+    DateFrom   string   // YYYY-MM-DD, inclusive
+    DateTo     string   // YYYY-MM-DD, inclusive
+    Categories []string
+    Tags       []string // expenses with any of these tags, ignoring case
+    Statuses   []string
+    Currency   string
+    MinAmount  *money.Amount
+    MaxAmount  *money.Amount
+    TeamID     *string
+    Search     string // matched against description
+    Sort       string // expense_date, amount, created_at, category, status
+    Order      string // asc, desc
+    After      *ExpenseCursor // keyset pagination: only expenses past this position
+}
+
+// ExpenseCursor is a position in an expense listing ordered by expense date
+type ExpenseCursor struct {
+    ExpenseDate string    `json:"d"` // YYYY-MM-DD
+    CreatedAt   time.Time `json:"c"`
+    ID          string    `json:"i"`
+    Order       string    `json:"o"` // asc, desc
+}
+
+// PageRequest selects a page of a listing, by page number or by cursor
+type PageRequest struct {
+    Page         int
+    Limit        int
+    Cursor       string // opaque cursor from a previous page, takes precedence over Page
+    IncludeTotal bool
+}
+
+// ExpensePage is one page of an expense listing
+type ExpensePage struct {
+    Expenses   []*Expense
+    Page       int // zero when paging by cursor
+    Limit      int
+    NextCursor string
+    HasMore    bool
+    Total      *int
+}
+
+// Expense export file formats
+const (
+    ExportFormatCSV  = "csv"
+    ExportFormatXLSX = "xlsx"
+)
+
+// ExpenseExportOptions configure an expense export
+type ExpenseExportOptions struct {
+    Format     string   // csv or xlsx
+    Columns    []string // empty exports the default columns
+    Locale     string   // e.g. en-US or de-DE, picks the date format, decimal separator and CSV delimiter
+    DateFormat string   // overrides the locale's date format, e.g. DD/MM/YYYY
+}
+
+// ExpenseImportMapping names the CSV header of each expense field. Empty
+// entries use the headers of the CSV export, so exports can be imported again.
+type ExpenseImportMapping struct {
+    ExpenseDate string `json:"expense_date,omitempty"`
+    Amount      string `json:"amount,omitempty"`
+    Currency    string `json:"currency,omitempty"`
+    Description string `json:"description,omitempty"`
+    Category    string `json:"category,omitempty"`
+}
+
+// ExpenseImportOptions configure a CSV import
+type ExpenseImportOptions struct {
+    Mapping         ExpenseImportMapping
+    TeamID          *string
+    DryRun          bool
+    Locale          string // picks the date format, decimal separator and delimiter, as for exports
+    DateFormat      string // overrides the locale's date format, e.g. DD/MM/YYYY
+    Delimiter       string // overrides the locale's delimiter
+    DefaultCurrency string // used for rows without a currency
+    DefaultCategory string // used for rows without a category
+}
+
+// StatementImportOptions configure a bank statement import
+type StatementImportOptions struct {
+    Format          string // ofx, qfx or qif; detected from the file when empty
+    TeamID          *string
+    DryRun          bool
+    Currency        string // for transactions whose file names no currency, which QIF never does
+    DefaultCategory string
+    DayFirst        bool // QIF dates are DD/MM/YY rather than MM/DD/YY
+}
+
+// ExpenseImportRowError explains why a row of an import was skipped
+type ExpenseImportRowError struct {
+    Row     int    `json:"row"` // line in a CSV file, the header being row 1, or transaction number in a statement
+    Field   string `json:"field,omitempty"`
+    Message string `json:"message"`
+}
+
+// ExpenseImportResult reports what an import did, or would do on a dry run
+type ExpenseImportResult struct {
+    DryRun        bool                    `json:"dry_run"`
+    TotalRows     int                     `json:"total_rows"`
+    ValidRows     int                     `json:"valid_rows"`
+    ImportedRows  int                     `json:"imported_rows"`
+    DuplicateRows int                     `json:"duplicate_rows"` // statements only, transactions imported before
+    SkippedRows   int                     `json:"skipped_rows"`   // statements only, credits such as deposits and refunds
+    Errors        []ExpenseImportRowError `json:"errors"`
+    Preview       []*Expense              `json:"preview,omitempty"` // dry runs only, the first valid rows
 }
 
 type ExpenseResponse struct {
@@ -0,0 +1,87 @@
+package models
+
+import (
+    "encoding/json"
+    "pocketpilot/pkg/money"
+    "time"
+)
+
+// Receipt OCR statuses
+const (
+    ReceiptOCRPending    = "pending"
+    ReceiptOCRProcessing = "processing"
+    ReceiptOCRCompleted  = "completed"
+    ReceiptOCRFailed     = "failed"
+    ReceiptOCRSkipped    = "skipped" // OCR is off, or cannot read the file type
+)
+
+// Receipt is a file uploaded as proof of an expense. The file is kept in the
+// receipt storage and downloaded through short-lived signed URLs.
+type Receipt struct {
+    ID           string     `json:"id"`
+    ExpenseID    string     `json:"expense_id"`
+    UserID       string     `json:"user_id"` // who uploaded it
+    StorageKey   string     `json:"-"`
+    FileName     string     `json:"file_name"`
+    ContentType  string     `json:"content_type"`
+    Size         int64      `json:"size"` // in bytes
+    URL          string     `json:"url,omitempty"`            // signed download URL, filled in when returned
+    URLExpiresAt *time.Time `json:"url_expires_at,omitempty"`
+    CreatedAt    time.Time  `json:"created_at"`
+    Variants     []*ReceiptVariant `json:"variants,omitempty"`
+
+    // Filled in by OCR after upload
+    OCRStatus       string          `json:"ocr_status"`
+    OCRError        *string         `json:"ocr_error,omitempty"`
+    MerchantName    *string         `json:"merchant_name,omitempty"`
+    TotalAmount     *money.Amount   `json:"total_amount,omitempty" swaggertype:"number"`
+    TaxAmount       *money.Amount   `json:"tax_amount,omitempty" swaggertype:"number"`
+    Currency        *string         `json:"currency,omitempty"`
+    TransactionDate *string         `json:"transaction_date,omitempty"` // YYYY-MM-DD
+    OCRRawData      json.RawMessage `json:"-"`                          // the OCR engine's output
+    ScannedAt       *time.Time      `json:"scanned_at,omitempty"`
+}
+
+// Receipt variants
+const (
+    ReceiptVariantSmall  = "small"  // thumbnail of at most 160 px on the longest side
+    ReceiptVariantMedium = "medium" // thumbnail of at most 480 px
+    ReceiptVariantLarge  = "large"  // thumbnail of at most 1024 px
+    ReceiptVariantPage   = "page"   // the first page of a PDF receipt, as an image
+)
+
+// ReceiptVariant is an image derived from a receipt, so clients need not
+// download the full file to show it
+type ReceiptVariant struct {
+    Name        string `json:"name"`
+    StorageKey  string `json:"-"`
+    ContentType string `json:"content_type"`
+    Width       int    `json:"width"`
+    Height      int    `json:"height"`
+    Size        int64  `json:"size"` // in bytes
+    URL         string `json:"url,omitempty"` // signed download URL, filled in when returned
+}
+
+// Receipt reconciliation statuses
+const (
+    ReconciliationMatched     = "matched"
+    ReconciliationMismatched  = "mismatched"
+    ReconciliationUnavailable = "unavailable" // OCR has not read the receipt, or found nothing to compare
+)
+
+// ReceiptReconciliation compares what OCR read on a receipt with its expense
+type ReceiptReconciliation struct {
+    ReceiptID string                `json:"receipt_id"`
+    ExpenseID string                `json:"expense_id"`
+    Status    string                `json:"status"`
+    Fields    []ReceiptFieldMatch   `json:"fields"`
+    Update    *UpdateExpenseRequest `json:"update,omitempty"` // the receipt's amount and date where they differ, ready for PUT /api/expenses/{id}
+}
+
+// ReceiptFieldMatch compares one field of an expense with the receipt
+type ReceiptFieldMatch struct {
+    Field        string `json:"field"` // amount, currency, expense_date or merchant
+    ExpenseValue string `json:"expense_value"`
+    ReceiptValue string `json:"receipt_value"`
+    Matches      bool   `json:"matches"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+    "pocketpilot/pkg/money"
+    "time"
+)
+
+// Recurrence frequencies
+const (
+    RecurrenceDaily   = "daily"
+    RecurrenceWeekly  = "weekly"
+    RecurrenceMonthly = "monthly"
+    RecurrenceYearly  = "yearly"
+)
+
+// RecurringExpense is a template the scheduler turns into an expense on every occurrence
+type RecurringExpense struct {
+    ID                 string    `json:"id"`
+    UserID             string    `json:"user_id"`
+    TeamID             *string   `json:"team_id,omitempty"`
+    Amount             money.Amount `json:"amount" swaggertype:"number"`
+    Currency           string    `json:"currency"`
+    Description        string    `json:"description"`
+    Category           string    `json:"category"`
+    CategoryID         *string   `json:"category_id,omitempty"`
+    Frequency          string    `json:"frequency"` // daily, weekly, monthly, yearly
+    Interval           int       `json:"interval"`  // every n days, weeks, months or years
+    StartDate          string    `json:"start_date"` // YYYY-MM-DD, the first occurrence
+    EndDate            *string   `json:"end_date,omitempty"` // YYYY-MM-DD, last possible occurrence
+    MaxOccurrences     *int      `json:"max_occurrences,omitempty"`
+    OccurrenceCount    int       `json:"occurrence_count"` // occurrences passed, including any skipped while paused
+    NextOccurrence     *string   `json:"next_occurrence,omitempty"` // YYYY-MM-DD, unset once the schedule is over
+    Active             bool      `json:"active"`
+    CreatedAt          time.Time `json:"created_at"`
+    UpdatedAt          time.Time `json:"updated_at"`
+}
+
+type CreateRecurringExpenseRequest struct {
+    Amount         money.Amount `json:"amount" binding:"required,gt=0" swaggertype:"number"`
+    Currency       string  `json:"currency" binding:"required"`
+    Description    string  `json:"description" binding:"required"`
+    Category       string  `json:"category" binding:"required"`
+    TeamID         *string `json:"team_id,omitempty"`
+    Frequency      string  `json:"frequency" binding:"required"`
+    Interval       int     `json:"interval,omitempty"` // defaults to 1
+    StartDate      string  `json:"start_date" binding:"required"`
+    EndDate        *string `json:"end_date,omitempty"`
+    MaxOccurrences *int    `json:"max_occurrences,omitempty"`
+}
+
+// UpdateRecurringExpenseRequest changes a template. The schedule itself cannot
+// change; create a new template instead.
+type UpdateRecurringExpenseRequest struct {
+    Amount         *money.Amount `json:"amount,omitempty" swaggertype:"number"`
+    Description    *string  `json:"description,omitempty"`
+    Category       *string  `json:"category,omitempty"`
+    EndDate        *string  `json:"end_date,omitempty"` // empty string removes the end date
+    MaxOccurrences *int     `json:"max_occurrences,omitempty"` // zero removes the limit
+    Active         *bool    `json:"active,omitempty"` // paused templates skip the occurrences they miss
+}
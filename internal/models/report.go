@@ -0,0 +1,77 @@
+package models
+
+import (
+    "pocketpilot/pkg/money"
+)
+
+// Dimensions reports group expenses by
+const (
+    ReportByCategory = "category"
+    ReportByTag      = "tag"
+    ReportByStatus   = "status"
+    ReportByMember   = "member"
+    ReportByMonth    = "month"
+    ReportByWeek     = "week"
+)
+
+// ReportRow is one aggregate from the reporting repository: the expenses of
+// one group on one day in one currency
+type ReportRow struct {
+    Key      string       // category, tag, status, user ID, month (YYYY-MM) or week start (YYYY-MM-DD)
+    Date     string       // YYYY-MM-DD
+    Currency string
+    Count    int
+    Amount   money.Amount
+}
+
+// ReportGroup totals the expenses of one category, tag, status, member or period
+type ReportGroup struct {
+    Key         string       `json:"key"`
+    Name        string       `json:"name,omitempty"` // member's name in member breakdowns
+    Count       int          `json:"count"`
+    Total       money.Amount `json:"total" swaggertype:"number"` // in the report's base currency
+    Share       float64      `json:"share"`                      // percent of the report total
+    Unconverted int          `json:"unconverted"`                // expenses left out of Total for lack of an exchange rate
+}
+
+// Report breaks down expenses in a date range by one dimension
+type Report struct {
+    GroupBy      string        `json:"group_by"` // category, tag, status, member, month, week
+    BaseCurrency string        `json:"base_currency"`
+    DateFrom     string        `json:"date_from"`
+    DateTo       string        `json:"date_to"`
+    Count        int           `json:"count"`
+    Total        money.Amount  `json:"total" swaggertype:"number"`
+    Unconverted  int           `json:"unconverted"`
+    Groups       []ReportGroup `json:"groups"`
+}
+
+// ReportSummary is the headline of a dashboard for a date range
+type ReportSummary struct {
+    BaseCurrency  string        `json:"base_currency"`
+    DateFrom      string        `json:"date_from"`
+    DateTo        string        `json:"date_to"`
+    Count         int           `json:"count"`
+    Total         money.Amount  `json:"total" swaggertype:"number"`
+    Average       money.Amount  `json:"average" swaggertype:"number"`       // per converted expense
+    DailyAverage  money.Amount  `json:"daily_average" swaggertype:"number"` // over every day of the range
+    Unconverted   int           `json:"unconverted"`
+    ByStatus      []ReportGroup `json:"by_status"`
+    TopCategories []ReportGroup `json:"top_categories"`
+}
+
+// TrendPoint is one month of spending compared with the month before
+type TrendPoint struct {
+    Month         string       `json:"month"` // YYYY-MM
+    Count         int          `json:"count"`
+    Total         money.Amount `json:"total" swaggertype:"number"`
+    Change        money.Amount `json:"change" swaggertype:"number"` // versus the month before
+    ChangePercent *float64     `json:"change_percent,omitempty"`    // unset when nothing was spent the month before
+    Unconverted   int          `json:"unconverted"`
+}
+
+// SpendingTrend is month-over-month spending up to and including a month
+type SpendingTrend struct {
+    BaseCurrency string       `json:"base_currency"`
+    Months       []TrendPoint `json:"months"`
+}
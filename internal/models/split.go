@@ -0,0 +1,93 @@
+package models
+
+import (
+    "pocketpilot/pkg/money"
+    "time"
+)
+
+// Ways of splitting an expense
+const (
+    SplitMethodEqual      = "equal"
+    SplitMethodExact      = "exact"
+    SplitMethodPercentage = "percentage"
+    SplitMethodShares     = "shares"
+)
+
+// ExpenseSplit is one member's portion of a team expense. The expense's owner
+// paid for it, so every other member with a portion owes the owner that amount.
+type ExpenseSplit struct {
+    ExpenseID  string        `json:"expense_id"`
+    UserID     string        `json:"user_id"`
+    Amount     money.Amount  `json:"amount" swaggertype:"number"`
+    Percentage *money.Amount `json:"percentage,omitempty" swaggertype:"number"` // for percentage splits
+    Shares     *int          `json:"shares,omitempty"`                         // for shares splits
+    CreatedAt  time.Time     `json:"created_at"`
+}
+
+// SplitInput is one member of a split. Amount is needed for exact splits,
+// Percentage for percentage splits and Shares for shares splits.
+type SplitInput struct {
+    UserID     string        `json:"user_id" binding:"required"`
+    Amount     *money.Amount `json:"amount,omitempty" swaggertype:"number"`
+    Percentage *money.Amount `json:"percentage,omitempty" swaggertype:"number"`
+    Shares     *int          `json:"shares,omitempty"`
+}
+
+type SplitExpenseRequest struct {
+    Method string       `json:"method" binding:"required"` // equal, exact, percentage, shares
+    Splits []SplitInput `json:"splits" binding:"required,min=1,dive"`
+}
+
+// ExpenseSplits is how an expense is split among team members
+type ExpenseSplits struct {
+    ExpenseID string          `json:"expense_id"`
+    PaidBy    string          `json:"paid_by"`
+    Method    string          `json:"method"`
+    Amount    money.Amount    `json:"amount" swaggertype:"number"`
+    Currency  string          `json:"currency"`
+    Splits    []*ExpenseSplit `json:"splits"`
+}
+
+// Settlement is a payment between team members that pays off what one owes the other
+type Settlement struct {
+    ID         string       `json:"id"`
+    TeamID     string       `json:"team_id"`
+    FromUserID string       `json:"from_user_id"`
+    ToUserID   string       `json:"to_user_id"`
+    Amount     money.Amount `json:"amount" swaggertype:"number"`
+    Currency   string       `json:"currency"`
+    Note       *string      `json:"note,omitempty"`
+    CreatedBy  string       `json:"created_by"`
+    CreatedAt  time.Time    `json:"created_at"`
+}
+
+type CreateSettlementRequest struct {
+    FromUserID string       `json:"from_user_id,omitempty"` // defaults to the caller
+    ToUserID   string       `json:"to_user_id" binding:"required"`
+    Amount     money.Amount `json:"amount" binding:"required,gt=0" swaggertype:"number"`
+    Currency   string       `json:"currency" binding:"required"`
+    Note       *string      `json:"note,omitempty"`
+}
+
+// Debt says FromUserID owes ToUserID Amount in Currency
+type Debt struct {
+    FromUserID string       `json:"from_user_id"`
+    ToUserID   string       `json:"to_user_id"`
+    Currency   string       `json:"currency"`
+    Amount     money.Amount `json:"amount" swaggertype:"number"`
+}
+
+// MemberBalance is what a member is owed in a currency, negative when they owe
+type MemberBalance struct {
+    UserID   string       `json:"user_id"`
+    Currency string       `json:"currency"`
+    Amount   money.Amount `json:"amount" swaggertype:"number"`
+}
+
+// TeamBalances is who owes whom in a team after settlements. Debts holds the
+// fewest payments that settle every balance, per currency.
+type TeamBalances struct {
+    TeamID   string          `json:"team_id"`
+    Balances []MemberBalance `json:"balances"`
+    Debts    []Debt          `json:"debts"`
+}
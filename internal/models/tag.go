@@ -0,0 +1,25 @@
+package models
+
+import (
+    "time"
+)
+
+// Tag marks expenses across categories, e.g. a client or an event. Tags of
+// personal expenses belong to their user and tags of team expenses to the team.
+type Tag struct {
+    ID           string    `json:"id"`
+    UserID       *string   `json:"user_id,omitempty"`
+    TeamID       *string   `json:"team_id,omitempty"`
+    Name         string    `json:"name"`
+    ExpenseCount int       `json:"expense_count"` // filled in by listings
+    CreatedAt    time.Time `json:"created_at"`
+    UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type RenameTagRequest struct {
+    Name string `json:"name" binding:"required,max=50"`
+}
+
+type MergeTagRequest struct {
+    IntoTagID string `json:"into_tag_id" binding:"required"` // tag that takes over the expenses and remains
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+    "time"
+)
+
+// Team roles, from most to least privileged
+const (
+    TeamRoleOwner  = "owner"
+    TeamRoleAdmin  = "admin"
+    TeamRoleMember = "member"
+    TeamRoleViewer = "viewer"
+)
+
+type Team struct {
+    ID        string    `json:"id"`
+    Name      string    `json:"name"`
+    CreatedBy string    `json:"created_by"`
+    BaseCurrency string `json:"base_currency"` // team expenses are converted to it
+    Role      string    `json:"role,omitempty"` // caller's role when listing their teams
+    CreatedAt time.Time `json:"created_at"`
+    UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TeamMember struct {
+    ID       string    `json:"id"`
+    TeamID   string    `json:"team_id"`
+    UserID   string    `json:"user_id"`
+    Role     string    `json:"role"`
+    JoinedAt time.Time `json:"joined_at"`
+    User     *User     `json:"user,omitempty"`
+}
+
+type CreateTeamRequest struct {
+    Name string `json:"name" binding:"required,max=255"`
+    BaseCurrency string `json:"base_currency,omitempty"` // defaults to USD
+}
+
+type UpdateTeamRequest struct {
+    Name string `json:"name" binding:"required,max=255"`
+    BaseCurrency *string `json:"base_currency,omitempty"`
+}
+
+type AddTeamMemberRequest struct {
+    Email string `json:"email" binding:"required,email"`
+    Role  string `json:"role,omitempty"` // defaults to member
+}
+
+type UpdateTeamMemberRequest struct {
+    Role string `json:"role" binding:"required"`
+}
+
+// IsValidTeamRole reports whether role is one of the known team roles
+func IsValidTeamRole(role string) bool {
+    switch role {
+    case TeamRoleOwner, TeamRoleAdmin, TeamRoleMember, TeamRoleViewer:
+        return true
+    }
+    return false
+}
+
+// TeamRoleRank orders team roles by privilege, unknown roles rank lowest
+func TeamRoleRank(role string) int {
+    switch role {
+    case TeamRoleOwner:
+        return 4
+    case TeamRoleAdmin:
+        return 3
+    case TeamRoleMember:
+        return 2
+    case TeamRoleViewer:
+        return 1
+    }
+    return 0
+}
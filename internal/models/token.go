@@ -0,0 +1,62 @@
+package models
+
+import (
+    "time"
+)
+
+// RefreshToken lets a client get a new access token without logging in again.
+// Only a hash of the token is stored. Each is good for a single refresh, which
+// replaces it with a new one in the same session.
+type RefreshToken struct {
+    ID              string
+    UserID          string
+    SessionID       string
+    TokenHash       string
+    AccessTokenID   string    // jti of the access token issued with it
+    AccessExpiresAt time.Time
+    ExpiresAt       time.Time
+    RotatedAt       *time.Time // set once it has been exchanged
+    RevokedAt       *time.Time
+    CreatedAt       time.Time
+}
+
+type RefreshTokenRequest struct {
+    RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Session is a login on one device. It lasts as long as its refresh tokens
+// keep being exchanged, until it is revoked.
+type Session struct {
+    ID         string     `json:"id"`
+    UserID     string     `json:"-"`
+    ClientName *string    `json:"client_name,omitempty"` // named by the client at login, e.g. "Work laptop"
+    UserAgent  *string    `json:"user_agent,omitempty"`
+    IPAddress  *string    `json:"ip_address,omitempty"`
+    Current    bool       `json:"current"` // the session of the request
+    CreatedAt  time.Time  `json:"created_at"`
+    LastSeenAt time.Time  `json:"last_seen_at"`
+    ExpiresAt  time.Time  `json:"expires_at"`
+    RevokedAt  *time.Time `json:"-"`
+}
+
+type RevokeSessionsResponse struct {
+    Revoked int64 `json:"revoked"` // how many sessions ended
+}
+
+// SessionClient describes the device a request comes from
+type SessionClient struct {
+    ClientName string
+    UserAgent  string
+    IPAddress  string
+}
+
+// PasswordReset lets a user who forgot their password set a new one through
+// a link sent by email. Only a hash of its token is stored.
+type PasswordReset struct {
+    ID        string
+    UserID    string
+    TokenHash string
+    ExpiresAt time.Time
+    UsedAt    *time.Time
+    CreatedAt time.Time
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+    "time"
+)
+
+// MFAChallenge is a login waiting for its second factor, after the password
+// was checked. Only a hash of its token is stored.
+type MFAChallenge struct {
+    ID        string
+    UserID    string
+    TokenHash string
+    Attempts  int
+    ExpiresAt time.Time
+    CreatedAt time.Time
+}
+
+// MFAChallengeResponse is what logging in returns instead of tokens when the
+// user has two-factor authentication on
+type MFAChallengeResponse struct {
+    MFARequired    bool      `json:"mfa_required"`
+    ChallengeToken string    `json:"challenge_token"` // send it back with the code to finish logging in
+    ExpiresAt      time.Time `json:"expires_at"`
+}
+
+type MFALoginRequest struct {
+    ChallengeToken string `json:"challenge_token" binding:"required"`
+    Code           string `json:"code" binding:"required"` // from the authenticator app, or a recovery code
+    ClientName     string `json:"client_name,omitempty" binding:"max=100"`
+}
+
+// TwoFactorSetup holds the secret to add to an authenticator app, either
+// typed in or scanned as a QR code of the otpauth URI
+type TwoFactorSetup struct {
+    Secret     string `json:"secret"`
+    OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type EnableTwoFactorRequest struct {
+    Code string `json:"code" binding:"required"` // from the authenticator app, proving it was set up
+}
+
+type DisableTwoFactorRequest struct {
+    Password string `json:"password" binding:"required"`
+    Code     string `json:"code" binding:"required"` // from the authenticator app, or a recovery code
+}
+
+// RecoveryCodesResponse lists recovery codes. They are only ever shown once.
+type RecoveryCodesResponse struct {
+    RecoveryCodes []string `json:"recovery_codes"`
+}
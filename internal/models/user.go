@@ -10,6 +10,11 @@ type User struct {
     PasswordHash string    `json:"-"`
     FirstName    string    `json:"first_name"`
     LastName     string    `json:"last_name"`
+    BaseCurrency string    `json:"base_currency"` // personal expenses are converted to it
+    EmailVerifiedAt    *time.Time `json:"email_verified_at"`
+    VerificationSentAt *time.Time `json:"-"` // when the last verification email went out
+    TwoFactorEnabledAt *time.Time `json:"two_factor_enabled_at"`
+    TOTPSecret         *string    `json:"-"` // set once two-factor setup starts
     CreatedAt    time.Time `json:"created_at"`
     UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -19,14 +24,44 @@ type RegisterRequest struct {
     Password  string `json:"password" binding:"required,min=6"`
     FirstName string `json:"first_name" binding:"required"`
     LastName  string `json:"last_name" binding:"required"`
+    BaseCurrency string `json:"base_currency,omitempty"` // defaults to USD
+    ClientName   string `json:"client_name,omitempty" binding:"max=100"` // names the session, e.g. "Work laptop"
+}
+
+type UpdateProfileRequest struct {
+    FirstName    *string `json:"first_name,omitempty"`
+    LastName     *string `json:"last_name,omitempty"`
+    BaseCurrency *string `json:"base_currency,omitempty"`
 }
 
 type LoginRequest struct {
     Email    string `json:"email" binding:"required,email"`
     Password string `json:"password" binding:"required"`
+    ClientName string `json:"client_name,omitempty" binding:"max=100"` // names the session, e.g. "Work laptop"
 }
 
 type AuthResponse struct {
-    Token string `json:"token"`
-    User  *User  `json:"user"`
-}
\ No newline at end of file
+    Token            string    `json:"token"` // short-lived access token
+    ExpiresAt        time.Time `json:"expires_at"`
+    RefreshToken     string    `json:"refresh_token"`
+    RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+    User             *User     `json:"user"`
+}
+
+type ChangePasswordRequest struct {
+    CurrentPassword string `json:"current_password" binding:"required"`
+    NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type ForgotPasswordRequest struct {
+    Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+    Token       string `json:"token" binding:"required"` // from the link sent by email
+    NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+type VerifyEmailRequest struct {
+    Token string `json:"token" binding:"required"` // from the link sent by email
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "pocketpilot/internal/models"
+
+    "github.com/lib/pq"
+)
+
+type ApprovalPolicyRepositoryImpl struct {
+    db *sql.DB
+}
+
+func NewApprovalPolicyRepository(db *sql.DB) *ApprovalPolicyRepositoryImpl {
+    return &ApprovalPolicyRepositoryImpl{db: db}
+}
+
+// CreatePolicy creates a new approval policy for a team
+func (r *ApprovalPolicyRepositoryImpl) CreatePolicy(policy *models.ApprovalPolicy) error {
+    steps, err := json.Marshal(policy.Steps)
+    if err != nil {
+        return err
+    }
+
+    query := `
+        INSERT INTO approval_policies (team_id, name, min_amount, categories, priority, steps)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at, updated_at
+    `
+
+    return r.db.QueryRow(
+        query,
+        policy.TeamID,
+        policy.Name,
+        policy.MinAmount,
+        pq.Array(policy.Categories),
+        policy.Priority,
+        steps,
+    ).Scan(&policy.ID, &policy.CreatedAt, &policy.UpdatedAt)
+}
+
+// GetPolicyByID retrieves an approval policy by ID
+func (r *ApprovalPolicyRepositoryImpl) GetPolicyByID(id string) (*models.ApprovalPolicy, error) {
+    query := `
+        SELECT id, team_id, name, min_amount, categories, priority, steps, created_at, updated_at
+        FROM approval_policies
+        WHERE id = $1
+    `
+
+    policy, err := scanPolicy(r.db.QueryRow(query, id))
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+
+    return policy, nil
+}
+
+// GetPoliciesByTeam retrieves a team's approval policies in evaluation order
+func (r *ApprovalPolicyRepositoryImpl) GetPoliciesByTeam(teamID string) ([]*models.ApprovalPolicy, error) {
+    query := `
+        SELECT id, team_id, name, min_amount, categories, priority, steps, created_at, updated_at
+        FROM approval_policies
+        WHERE team_id = $1
+        ORDER BY priority ASC, created_at ASC
+    `
+
+    rows, err := r.db.Query(query, teamID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var policies []*models.ApprovalPolicy
+    for rows.Next() {
+        policy, err := scanPolicy(rows)
+        if err != nil {
+            return nil, err
+        }
+        policies = append(policies, policy)
+    }
+
+    return policies, rows.Err()
+}
+
+// UpdatePolicy updates an existing approval policy
+func (r *ApprovalPolicyRepositoryImpl) UpdatePolicy(policy *models.ApprovalPolicy) error {
+    steps, err := json.Marshal(policy.Steps)
+    if err != nil {
+        return err
+    }
+
+    query := `
+        UPDATE approval_policies
+        SET name = $1, min_amount = $2, categories = $3, priority = $4, steps = $5, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $6 AND team_id = $7
+        RETURNING updated_at
+    `
+
+    return r.db.QueryRow(
+        query,
+        policy.Name,
+        policy.MinAmount,
+        pq.Array(policy.Categories),
+        policy.Priority,
+        steps,
+        policy.ID,
+        policy.TeamID,
+    ).Scan(&policy.UpdatedAt)
+}
+
+// DeletePolicy deletes a team's approval policy
+func (r *ApprovalPolicyRepositoryImpl) DeletePolicy(id, teamID string) error {
+    query := `DELETE FROM approval_policies WHERE id = $1 AND team_id = $2`
+    result, err := r.db.Exec(query, id, teamID)
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+
+    if rowsAffected == 0 {
+        return errors.New("approval policy not found")
+    }
+
+    return nil
+}
+
+type rowScanner interface {
+    Scan(dest ...interface{}) error
+}
+
+func scanPolicy(row rowScanner) (*models.ApprovalPolicy, error) {
+    policy := &models.ApprovalPolicy{}
+    var steps []byte
+    err := row.Scan(
+        &policy.ID,
+        &policy.TeamID,
+        &policy.Name,
+        &policy.MinAmount,
+        pq.Array(&policy.Categories),
+        &policy.Priority,
+        &steps,
+        &policy.CreatedAt,
+        &policy.UpdatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    if err := json.Unmarshal(steps, &policy.Steps); err != nil {
+        return nil, err
+    }
+
+    return policy, nil
+}
@@ -0,0 +1,254 @@
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "pocketpilot/internal/models"
+
+    "github.com/lib/pq"
+)
+
+type BudgetRepositoryImpl struct {
+    db *sql.DB
+}
+
+func NewBudgetRepository(db *sql.DB) *BudgetRepositoryImpl {
+    return &BudgetRepositoryImpl{db: db}
+}
+
+const budgetColumns = `
+    id, user_id, team_id, category, amount, currency, period, start_date::text, rollover, thresholds,
+    created_at, updated_at
+`
+
+// CreateBudget creates a new budget
+func (r *BudgetRepositoryImpl) CreateBudget(budget *models.Budget) error {
+    query := `
+        INSERT INTO budgets (user_id, team_id, category, amount, currency, period, start_date, rollover, thresholds)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING id, created_at, updated_at
+    `
+
+    return r.db.QueryRow(
+        query,
+        budget.UserID,
+        budget.TeamID,
+        budget.Category,
+        budget.Amount,
+        budget.Currency,
+        budget.Period,
+        budget.StartDate,
+        budget.Rollover,
+        pq.Array(budget.Thresholds),
+    ).Scan(&budget.ID, &budget.CreatedAt, &budget.UpdatedAt)
+}
+
+// GetBudgetByID retrieves a budget by ID
+func (r *BudgetRepositoryImpl) GetBudgetByID(id string) (*models.Budget, error) {
+    query := `SELECT ` + budgetColumns + ` FROM budgets WHERE id = $1`
+
+    budget, err := scanBudget(r.db.QueryRow(query, id))
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    return budget, nil
+}
+
+// GetBudgetsByUser retrieves a user's personal budgets
+func (r *BudgetRepositoryImpl) GetBudgetsByUser(userID string) ([]*models.Budget, error) {
+    query := `SELECT ` + budgetColumns + ` FROM budgets WHERE user_id = $1 AND team_id IS NULL ORDER BY created_at`
+    return r.queryBudgets(query, userID)
+}
+
+// GetBudgetsByTeam retrieves a team's budgets
+func (r *BudgetRepositoryImpl) GetBudgetsByTeam(teamID string) ([]*models.Budget, error) {
+    query := `SELECT ` + budgetColumns + ` FROM budgets WHERE team_id = $1 ORDER BY created_at`
+    return r.queryBudgets(query, teamID)
+}
+
+// GetBudgetsForExpense retrieves the budgets an expense counts against: the
+// team's budgets for team expenses, otherwise the owner's personal budgets,
+// overall or in the expense's category
+func (r *BudgetRepositoryImpl) GetBudgetsForExpense(expense *models.Expense) ([]*models.Budget, error) {
+    query := `
+        SELECT ` + budgetColumns + `
+        FROM budgets
+        WHERE (($1::uuid IS NOT NULL AND team_id = $1) OR ($1::uuid IS NULL AND team_id IS NULL AND user_id = $2))
+          AND (category IS NULL OR category = $3)
+          AND start_date <= $4
+        ORDER BY created_at
+    `
+    return r.queryBudgets(query, expense.TeamID, expense.UserID, expense.Category, expense.ExpenseDate)
+}
+
+// UpdateBudget updates a budget's amount, rollover and thresholds
+func (r *BudgetRepositoryImpl) UpdateBudget(budget *models.Budget) error {
+    query := `
+        UPDATE budgets
+        SET amount = $1, rollover = $2, thresholds = $3, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $4
+        RETURNING updated_at
+    `
+
+    return r.db.QueryRow(query, budget.Amount, budget.Rollover, pq.Array(budget.Thresholds), budget.ID).Scan(&budget.UpdatedAt)
+}
+
+// DeleteBudget deletes a budget and its alerts
+func (r *BudgetRepositoryImpl) DeleteBudget(id string) error {
+    result, err := r.db.Exec(`DELETE FROM budgets WHERE id = $1`, id)
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+
+    if rowsAffected == 0 {
+        return errors.New("budget not found")
+    }
+
+    return nil
+}
+
+// GetBudgetSpending sums the expenses counting against a budget per day and
+// currency between two dates, inclusive. Rejected expenses are left out.
+func (r *BudgetRepositoryImpl) GetBudgetSpending(budget *models.Budget, from, to string) ([]*models.DailySpending, error) {
+    query := `
+        SELECT expense_date::text, currency, SUM(amount)
+        FROM expenses
+        WHERE (($1::uuid IS NOT NULL AND team_id = $1) OR ($1::uuid IS NULL AND team_id IS NULL AND user_id = $2))
+          AND ($3::text IS NULL OR category = $3)
+          AND expense_date BETWEEN $4 AND $5
+          AND status <> 'rejected'
+        GROUP BY expense_date, currency
+        ORDER BY expense_date
+    `
+
+    rows, err := r.db.Query(query, budget.TeamID, budget.UserID, budget.Category, from, to)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var spending []*models.DailySpending
+    for rows.Next() {
+        day := &models.DailySpending{}
+        if err := rows.Scan(&day.Date, &day.Currency, &day.Amount); err != nil {
+            return nil, err
+        }
+        spending = append(spending, day)
+    }
+
+    return spending, rows.Err()
+}
+
+// CreateBudgetAlert records an alert unless the threshold already alerted in
+// that period. It returns false when it did.
+func (r *BudgetRepositoryImpl) CreateBudgetAlert(alert *models.BudgetAlert) (bool, error) {
+    query := `
+        INSERT INTO budget_alerts (budget_id, period_start, threshold, spent, available, expense_id)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (budget_id, period_start, threshold) DO NOTHING
+        RETURNING id, created_at
+    `
+
+    err := r.db.QueryRow(
+        query,
+        alert.BudgetID,
+        alert.PeriodStart,
+        alert.Threshold,
+        alert.Spent,
+        alert.Available,
+        alert.ExpenseID,
+    ).Scan(&alert.ID, &alert.CreatedAt)
+    if errors.Is(err, sql.ErrNoRows) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    return true, nil
+}
+
+// GetBudgetAlerts retrieves a budget's alerts, newest first
+func (r *BudgetRepositoryImpl) GetBudgetAlerts(budgetID string) ([]*models.BudgetAlert, error) {
+    query := `
+        SELECT id, budget_id, period_start::text, threshold, spent, available, expense_id, created_at
+        FROM budget_alerts
+        WHERE budget_id = $1
+        ORDER BY created_at DESC
+    `
+
+    rows, err := r.db.Query(query, budgetID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var alerts []*models.BudgetAlert
+    for rows.Next() {
+        alert := &models.BudgetAlert{}
+        err := rows.Scan(
+            &alert.ID,
+            &alert.BudgetID,
+            &alert.PeriodStart,
+            &alert.Threshold,
+            &alert.Spent,
+            &alert.Available,
+            &alert.ExpenseID,
+            &alert.CreatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        alerts = append(alerts, alert)
+    }
+
+    return alerts, rows.Err()
+}
+
+func (r *BudgetRepositoryImpl) queryBudgets(query string, args ...interface{}) ([]*models.Budget, error) {
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var budgets []*models.Budget
+    for rows.Next() {
+        budget, err := scanBudget(rows)
+        if err != nil {
+            return nil, err
+        }
+        budgets = append(budgets, budget)
+    }
+
+    return budgets, rows.Err()
+}
+
+func scanBudget(row rowScanner) (*models.Budget, error) {
+    budget := &models.Budget{}
+    err := row.Scan(
+        &budget.ID,
+        &budget.UserID,
+        &budget.TeamID,
+        &budget.Category,
+        &budget.Amount,
+        &budget.Currency,
+        &budget.Period,
+        &budget.StartDate,
+        &budget.Rollover,
+        pq.Array(&budget.Thresholds),
+        &budget.CreatedAt,
+        &budget.UpdatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return budget, nil
+}
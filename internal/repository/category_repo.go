@@ -0,0 +1,181 @@
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "pocketpilot/internal/models"
+)
+
+type CategoryRepositoryImpl struct {
+    db *sql.DB
+}
+
+func NewCategoryRepository(db *sql.DB) *CategoryRepositoryImpl {
+    return &CategoryRepositoryImpl{db: db}
+}
+
+const categoryColumns = `
+    id, user_id, team_id, parent_id, name, icon, color, archived_at, created_at, updated_at
+`
+
+// categoryScope matches the system categories plus the custom categories of a
+// team, or of a user's personal expenses when the team ($2) is null
+const categoryScope = `
+    ((user_id IS NULL AND team_id IS NULL)
+     OR ($2::uuid IS NULL AND team_id IS NULL AND user_id = $1)
+     OR ($2::uuid IS NOT NULL AND team_id = $2))
+`
+
+// CreateCategory creates a new custom category
+func (r *CategoryRepositoryImpl) CreateCategory(category *models.Category) error {
+    query := `
+        INSERT INTO categories (user_id, team_id, parent_id, name, icon, color)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at, updated_at
+    `
+
+    return r.db.QueryRow(
+        query,
+        category.UserID,
+        category.TeamID,
+        category.ParentID,
+        category.Name,
+        category.Icon,
+        category.Color,
+    ).Scan(&category.ID, &category.CreatedAt, &category.UpdatedAt)
+}
+
+// GetCategoryByID retrieves a category by ID
+func (r *CategoryRepositoryImpl) GetCategoryByID(id string) (*models.Category, error) {
+    query := `SELECT ` + categoryColumns + ` FROM categories WHERE id = $1`
+
+    category, err := scanCategory(r.db.QueryRow(query, id))
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    return category, nil
+}
+
+// GetCategories retrieves the categories offered for a user's personal
+// expenses, or a team's when teamID is set: the system categories and the
+// custom ones, sorted by name
+func (r *CategoryRepositoryImpl) GetCategories(userID string, teamID *string, includeArchived bool) ([]*models.Category, error) {
+    query := `
+        SELECT ` + categoryColumns + `
+        FROM categories
+        WHERE ` + categoryScope + `
+          AND ($3 OR archived_at IS NULL)
+        ORDER BY lower(name)
+    `
+
+    rows, err := r.db.Query(query, userID, teamID, includeArchived)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var categories []*models.Category
+    for rows.Next() {
+        category, err := scanCategory(rows)
+        if err != nil {
+            return nil, err
+        }
+        categories = append(categories, category)
+    }
+
+    return categories, rows.Err()
+}
+
+// GetCategoryByName finds a category offered for a user's personal expenses,
+// or a team's when teamID is set, by name ignoring case, archived or not
+func (r *CategoryRepositoryImpl) GetCategoryByName(userID string, teamID *string, name string) (*models.Category, error) {
+    query := `
+        SELECT ` + categoryColumns + `
+        FROM categories
+        WHERE ` + categoryScope + `
+          AND lower(name) = lower($3)
+        ORDER BY (user_id IS NULL AND team_id IS NULL) DESC
+        LIMIT 1
+    `
+
+    category, err := scanCategory(r.db.QueryRow(query, userID, teamID, name))
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    return category, nil
+}
+
+// UpdateCategory updates a custom category. A new name is carried over to the
+// expenses, recurring expenses and budgets using it in the same transaction.
+func (r *CategoryRepositoryImpl) UpdateCategory(category *models.Category, previousName string) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    err = tx.QueryRow(`
+        UPDATE categories
+        SET parent_id = $1, name = $2, icon = $3, color = $4, archived_at = $5, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $6
+        RETURNING updated_at
+    `, category.ParentID, category.Name, category.Icon, category.Color, category.ArchivedAt, category.ID).Scan(&category.UpdatedAt)
+    if err != nil {
+        return err
+    }
+
+    if category.Name != previousName {
+        if _, err := tx.Exec(`UPDATE expenses SET category = $1 WHERE category_id = $2`, category.Name, category.ID); err != nil {
+            return err
+        }
+        if _, err := tx.Exec(`UPDATE recurring_expenses SET category = $1 WHERE category_id = $2`, category.Name, category.ID); err != nil {
+            return err
+        }
+        _, err := tx.Exec(`
+            UPDATE budgets SET category = $1, updated_at = CURRENT_TIMESTAMP
+            WHERE lower(category) = lower($2)
+              AND (($3::uuid IS NOT NULL AND team_id = $3) OR ($3::uuid IS NULL AND team_id IS NULL AND user_id = $4))
+        `, category.Name, previousName, category.TeamID, category.UserID)
+        if err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit()
+}
+
+// CountSubcategories counts the categories with the given parent
+func (r *CategoryRepositoryImpl) CountSubcategories(id string) (int, error) {
+    var count int
+    err := r.db.QueryRow(`SELECT COUNT(*) FROM categories WHERE parent_id = $1`, id).Scan(&count)
+    if err != nil {
+        return 0, err
+    }
+    return count, nil
+}
+
+func scanCategory(row rowScanner) (*models.Category, error) {
+    category := &models.Category{}
+    err := row.Scan(
+        &category.ID,
+        &category.UserID,
+        &category.TeamID,
+        &category.ParentID,
+        &category.Name,
+        &category.Icon,
+        &category.Color,
+        &category.ArchivedAt,
+        &category.CreatedAt,
+        &category.UpdatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return category, nil
+}
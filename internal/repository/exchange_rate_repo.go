@@ -0,0 +1,95 @@
+package repository
+
+import (
+    "database/sql"
+    "pocketpilot/internal/models"
+
+    "github.com/lib/pq"
+)
+
+type ExchangeRateRepositoryImpl struct {
+    db *sql.DB
+}
+
+func NewExchangeRateRepository(db *sql.DB) *ExchangeRateRepositoryImpl {
+    return &ExchangeRateRepositoryImpl{db: db}
+}
+
+// SaveExchangeRates stores rates in one transaction, replacing any rate already
+// stored for the same currencies and date
+func (r *ExchangeRateRepositoryImpl) SaveExchangeRates(rates []*models.ExchangeRate) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    stmt, err := tx.Prepare(`
+        INSERT INTO exchange_rates (base_currency, currency, rate_date, rate, source)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (base_currency, currency, rate_date)
+        DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source, updated_at = CURRENT_TIMESTAMP
+        RETURNING updated_at
+    `)
+    if err != nil {
+        return err
+    }
+    defer stmt.Close()
+
+    for _, rate := range rates {
+        err := stmt.QueryRow(
+            rate.BaseCurrency,
+            rate.Currency,
+            rate.RateDate,
+            rate.Rate,
+            rate.Source,
+        ).Scan(&rate.UpdatedAt)
+        if err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit()
+}
+
+// GetExchangeRates retrieves the latest rate on or before the date of every
+// currency pair, limited to pairs involving the given currencies unless none
+// are given
+func (r *ExchangeRateRepositoryImpl) GetExchangeRates(date string, currencies []string) ([]*models.ExchangeRate, error) {
+    query := `
+        SELECT DISTINCT ON (base_currency, currency)
+               base_currency, currency, rate_date::text, rate, source, updated_at
+        FROM exchange_rates
+        WHERE rate_date <= $1
+          AND (cardinality($2::text[]) = 0 OR base_currency = ANY($2) OR currency = ANY($2))
+        ORDER BY base_currency, currency, rate_date DESC
+    `
+
+    if currencies == nil {
+        currencies = []string{}
+    }
+    rows, err := r.db.Query(query, date, pq.Array(currencies))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var rates []*models.ExchangeRate
+    for rows.Next() {
+        rate := &models.ExchangeRate{}
+        err := rows.Scan(
+            &rate.BaseCurrency,
+            &rate.Currency,
+            &rate.RateDate,
+            &rate.Rate,
+            &rate.Source,
+            &rate.UpdatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        rates = append(rates, rate)
+    }
+
+    return rates, rows.Err()
+}
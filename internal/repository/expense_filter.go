@@ -0,0 +1,109 @@
+package repository
+
+import (
+    "fmt"
+    "pocketpilot/internal/models"
+    "strings"
+
+    "github.com/lib/pq"
+)
+
+// expenseSortColumns whitelists the columns listings can be sorted by
+var expenseSortColumns = map[string]string{
+    "expense_date": "expense_date",
+    "amount":       "amount",
+    "created_at":   "created_at",
+    "category":     "category",
+    "status":       "status",
+}
+
+// expenseFilterClauses turns a filter into parameterized WHERE conditions.
+// Placeholders are numbered after the args already given.
+func expenseFilterClauses(filter *models.ExpenseFilter, args []interface{}) ([]string, []interface{}) {
+    var clauses []string
+    if filter == nil {
+        return clauses, args
+    }
+
+    add := func(clause string, value interface{}) {
+        args = append(args, value)
+        clauses = append(clauses, fmt.Sprintf(clause, len(args)))
+    }
+
+    if filter.DateFrom != "" {
+        add("expense_date >= $%d", filter.DateFrom)
+    }
+    if filter.DateTo != "" {
+        add("expense_date <= $%d", filter.DateTo)
+    }
+    if len(filter.Categories) > 0 {
+        add("category = ANY($%d)", pq.Array(filter.Categories))
+    }
+    if len(filter.Tags) > 0 {
+        lowered := make([]string, len(filter.Tags))
+        for i, tag := range filter.Tags {
+            lowered[i] = strings.ToLower(tag)
+        }
+        add(`id IN (
+            SELECT expense_tags.expense_id FROM expense_tags JOIN tags ON tags.id = expense_tags.tag_id
+            WHERE lower(tags.name) = ANY($%d))`, pq.Array(lowered))
+    }
+    if len(filter.Statuses) > 0 {
+        add("status = ANY($%d)", pq.Array(filter.Statuses))
+    }
+    if filter.Currency != "" {
+        add("currency = $%d", filter.Currency)
+    }
+    if filter.MinAmount != nil {
+        add("amount >= $%d", *filter.MinAmount)
+    }
+    if filter.MaxAmount != nil {
+        add("amount <= $%d", *filter.MaxAmount)
+    }
+    if filter.TeamID != nil {
+        add("team_id = $%d", *filter.TeamID)
+    }
+    if filter.Search != "" {
+        add(`description ILIKE '%%' || $%d || '%%' ESCAPE '\'`, escapeLike(filter.Search))
+    }
+    if filter.After != nil {
+        comparison := "<"
+        if strings.EqualFold(filter.After.Order, "asc") {
+            comparison = ">"
+        }
+        args = append(args, filter.After.ExpenseDate, filter.After.CreatedAt, filter.After.ID)
+        clauses = append(clauses, fmt.Sprintf(
+            "(expense_date, created_at, id) %s ($%d::date, $%d::timestamptz, $%d::uuid)",
+            comparison, len(args)-2, len(args)-1, len(args),
+        ))
+    }
+
+    return clauses, args
+}
+
+// expenseOrderBy builds the ORDER BY clause, always ending on created_at and id
+// so pages are stable
+func expenseOrderBy(filter *models.ExpenseFilter) string {
+    column := "expense_date"
+    direction := "DESC"
+    if filter != nil {
+        if c, ok := expenseSortColumns[filter.Sort]; ok {
+            column = c
+        }
+        if strings.EqualFold(filter.Order, "asc") {
+            direction = "ASC"
+        }
+    }
+
+    order := fmt.Sprintf("ORDER BY %s %s", column, direction)
+    if column != "created_at" {
+        order += ", created_at " + direction
+    }
+    return order + ", id " + direction
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally
+func escapeLike(s string) string {
+    replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+    return replacer.Replace(s)
+}
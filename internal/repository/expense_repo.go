@@ -3,9 +3,12 @@ package repository
 import (
     "database/sql"
     "errors"
+    "fmt"
     "pocketpilot/internal/models"
-    // "fmt"
+    "strings"
     "time"
+
+    "github.com/lib/pq"
 )
 
 type ExpenseRepositoryImpl struct {
@@ -16,15 +19,22 @@ func NewExpenseRepository(db *sql.DB) *ExpenseRepositoryImpl {
     return &ExpenseRepositoryImpl{db: db}
 }
 
-// CreateExpense creates a new expense
+// CreateExpense creates a new expense with its tags
 func (r *ExpenseRepositoryImpl) CreateExpense(expense *models.Expense) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
     query := `
-        INSERT INTO expenses (user_id, team_id, amount, currency, description, category, expense_date, receipt_image_url, status)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        INSERT INTO expenses (user_id, team_id, amount, currency, description, category, expense_date, receipt_image_url, status, bank_transaction_id,
+                              base_currency, exchange_rate, category_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
         RETURNING id, created_at, updated_at
     `
     
-    err := r.db.QueryRow(
+    err = tx.QueryRow(
         query,
         expense.UserID,
         expense.TeamID,
@@ -35,101 +45,141 @@ func (r *ExpenseRepositoryImpl) CreateExpense(expense *models.Expense) error {
         expense.ExpenseDate,
         expense.ReceiptImageURL,
         expense.Status,
+        expense.BankTransactionID,
+        expense.BaseCurrency,
+        expense.ExchangeRate,
+        expense.CategoryID,
     ).Scan(&expense.ID, &expense.CreatedAt, &expense.UpdatedAt)
-    
-    return err
+    if err != nil {
+        return err
+    }
+    if err := setExpenseTags(tx, expense); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// CreateExpenses creates several expenses in one transaction, none if any fails
+func (r *ExpenseRepositoryImpl) CreateExpenses(expenses []*models.Expense) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    stmt, err := tx.Prepare(`
+        INSERT INTO expenses (user_id, team_id, amount, currency, description, category, expense_date, receipt_image_url, status, bank_transaction_id,
+                              base_currency, exchange_rate, category_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+        RETURNING id, created_at, updated_at
+    `)
+    if err != nil {
+        return err
+    }
+    defer stmt.Close()
+
+    for _, expense := range expenses {
+        err := stmt.QueryRow(
+            expense.UserID,
+            expense.TeamID,
+            expense.Amount,
+            expense.Currency,
+            expense.Description,
+            expense.Category,
+            expense.ExpenseDate,
+            expense.ReceiptImageURL,
+            expense.Status,
+            expense.BankTransactionID,
+            expense.BaseCurrency,
+            expense.ExchangeRate,
+            expense.CategoryID,
+        ).Scan(&expense.ID, &expense.CreatedAt, &expense.UpdatedAt)
+        if err != nil {
+            return err
+        }
+        if err := setExpenseTags(tx, expense); err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit()
 }
 
 // GetExpenseByID retrieves an expense by ID
 func (r *ExpenseRepositoryImpl) GetExpenseByID(id string) (*models.Expense, error) {
     query := `
-        SELECT id, user_id, team_id, amount, currency, description, category, 
-               expense_date, receipt_image_url, status, created_at, updated_at
+        SELECT id, user_id, team_id, amount, currency, description, category, category_id,
+               expense_date, receipt_image_url, status, bank_transaction_id, recurring_expense_id, base_currency, exchange_rate, split_method, created_at, updated_at
         FROM expenses 
         WHERE id = $1
     `
     
-    expense := &models.Expense{}
-    err := r.db.QueryRow(query, id).Scan(
-        &expense.ID,
-        &expense.UserID,
-        &expense.TeamID,
-        &expense.Amount,
-        &expense.Currency,
-        &expense.Description,
-        &expense.Category,
-        &expense.ExpenseDate,
-        &expense.ReceiptImageURL,
-        &expense.Status,
-        &expense.CreatedAt,
-        &expense.UpdatedAt,
-    )
-    
+    expense, err := scanExpense(r.db.QueryRow(query, id))
     if err != nil {
         if errors.Is(err, sql.ErrNoRows) {
             return nil, nil
         }
         return nil, err
     }
+    if err := loadExpenseTags(r.db, []*models.Expense{expense}); err != nil {
+        return nil, err
+    }
     
     return expense, nil
 }
 
-// GetExpensesByUser retrieves all expenses for a user
-func (r *ExpenseRepositoryImpl) GetExpensesByUser(userID string, limit, offset int) ([]*models.Expense, error) {
+// GetExistingBankTransactionIDs returns which of the given bank transaction IDs
+// the user already imported
+func (r *ExpenseRepositoryImpl) GetExistingBankTransactionIDs(userID string, ids []string) ([]string, error) {
     query := `
-        SELECT id, user_id, team_id, amount, currency, description, category, 
-               expense_date, receipt_image_url, status, created_at, updated_at
-        FROM expenses 
-        WHERE user_id = $1
-        ORDER BY expense_date DESC, created_at DESC
-        LIMIT $2 OFFSET $3
+        SELECT bank_transaction_id
+        FROM expenses
+        WHERE user_id = $1 AND bank_transaction_id = ANY($2)
     `
-    
-    rows, err := r.db.Query(query, userID, limit, offset)
+
+    rows, err := r.db.Query(query, userID, pq.Array(ids))
     if err != nil {
         return nil, err
     }
     defer rows.Close()
-    
-    var expenses []*models.Expense
+
+    var existing []string
     for rows.Next() {
-        expense := &models.Expense{}
-        err := rows.Scan(
-            &expense.ID,
-            &expense.UserID,
-            &expense.TeamID,
-            &expense.Amount,
-            &expense.Currency,
-            &expense.Description,
-            &expense.Category,
-            &expense.ExpenseDate,
-            &expense.ReceiptImageURL,
-            &expense.Status,
-            &expense.CreatedAt,
-            &expense.UpdatedAt,
-        )
-        if err != nil {
+        var id string
+        if err := rows.Scan(&id); err != nil {
             return nil, err
         }
-        expenses = append(expenses, expense)
+        existing = append(existing, id)
     }
-    
-    return expenses, nil
+
+    return existing, rows.Err()
+}
+
+// GetExpensesByUser retrieves a user's expenses matching the filter
+func (r *ExpenseRepositoryImpl) GetExpensesByUser(userID string, filter *models.ExpenseFilter, limit, offset int) ([]*models.Expense, error) {
+    return r.listExpenses("user_id = $1", userID, filter, limit, offset)
 }
 
-// UpdateExpense updates an existing expense
+// UpdateExpense updates an existing expense and replaces its tags. Status is
+// changed through TransitionExpense.
 func (r *ExpenseRepositoryImpl) UpdateExpense(expense *models.Expense) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
     query := `
         UPDATE expenses 
         SET amount = $1, currency = $2, description = $3, category = $4, 
-            expense_date = $5, receipt_image_url = $6, status = $7, updated_at = $8
-        WHERE id = $9 AND user_id = $10
+            expense_date = $5, receipt_image_url = $6, base_currency = $7, exchange_rate = $8, updated_at = $9, category_id = $12
+        WHERE id = $10 AND user_id = $11
         RETURNING updated_at
     `
     
     expense.UpdatedAt = time.Now()
-    err := r.db.QueryRow(
+    err = tx.QueryRow(
         query,
         expense.Amount,
         expense.Currency,
@@ -137,13 +187,21 @@ func (r *ExpenseRepositoryImpl) UpdateExpense(expense *models.Expense) error {
         expense.Category,
         expense.ExpenseDate,
         expense.ReceiptImageURL,
-        expense.Status,
+        expense.BaseCurrency,
+        expense.ExchangeRate,
         expense.UpdatedAt,
         expense.ID,
         expense.UserID,
+        expense.CategoryID,
     ).Scan(&expense.UpdatedAt)
-    
-    return err
+    if err != nil {
+        return err
+    }
+    if err := setExpenseTags(tx, expense); err != nil {
+        return err
+    }
+
+    return tx.Commit()
 }
 
 // DeleteExpense deletes an expense
@@ -166,18 +224,100 @@ func (r *ExpenseRepositoryImpl) DeleteExpense(id, userID string) error {
     return nil
 }
 
-// GetExpensesByTeam retrieves expenses for a team
-func (r *ExpenseRepositoryImpl) GetExpensesByTeam(teamID string, limit, offset int) ([]*models.Expense, error) {
-    query := `
-        SELECT id, user_id, team_id, amount, currency, description, category, 
-               expense_date, receipt_image_url, status, created_at, updated_at
+// GetExpensesByTeam retrieves a team's expenses matching the filter
+func (r *ExpenseRepositoryImpl) GetExpensesByTeam(teamID string, filter *models.ExpenseFilter, limit, offset int) ([]*models.Expense, error) {
+    return r.listExpenses("team_id = $1", teamID, filter, limit, offset)
+}
+
+// CountExpensesByUser counts a user's expenses matching the filter, ignoring pagination
+func (r *ExpenseRepositoryImpl) CountExpensesByUser(userID string, filter *models.ExpenseFilter) (int, error) {
+    return r.countExpenses("user_id = $1", userID, filter)
+}
+
+// CountExpensesByTeam counts a team's expenses matching the filter, ignoring pagination
+func (r *ExpenseRepositoryImpl) CountExpensesByTeam(teamID string, filter *models.ExpenseFilter) (int, error) {
+    return r.countExpenses("team_id = $1", teamID, filter)
+}
+
+func (r *ExpenseRepositoryImpl) countExpenses(scope string, scopeArg interface{}, filter *models.ExpenseFilter) (int, error) {
+    if filter != nil && filter.After != nil {
+        unpaged := *filter
+        unpaged.After = nil
+        filter = &unpaged
+    }
+
+    clauses, args := expenseFilterClauses(filter, []interface{}{scopeArg})
+    where := append([]string{scope}, clauses...)
+    query := fmt.Sprintf(`SELECT COUNT(*) FROM expenses WHERE %s`, strings.Join(where, " AND "))
+
+    var count int
+    err := r.db.QueryRow(query, args...).Scan(&count)
+    if err != nil {
+        return 0, err
+    }
+
+    return count, nil
+}
+
+// StreamExpensesByUser calls fn with each of a user's expenses matching the
+// filter, reading them one at a time instead of loading the whole listing
+func (r *ExpenseRepositoryImpl) StreamExpensesByUser(userID string, filter *models.ExpenseFilter, fn func(*models.Expense) error) error {
+    return r.streamExpenses("user_id = $1", userID, filter, fn)
+}
+
+// StreamExpensesByTeam calls fn with each of a team's expenses matching the filter
+func (r *ExpenseRepositoryImpl) StreamExpensesByTeam(teamID string, filter *models.ExpenseFilter, fn func(*models.Expense) error) error {
+    return r.streamExpenses("team_id = $1", teamID, filter, fn)
+}
+
+func (r *ExpenseRepositoryImpl) streamExpenses(scope string, scopeArg interface{}, filter *models.ExpenseFilter, fn func(*models.Expense) error) error {
+    clauses, args := expenseFilterClauses(filter, []interface{}{scopeArg})
+    where := append([]string{scope}, clauses...)
+
+    query := fmt.Sprintf(`
+        SELECT id, user_id, team_id, amount, currency, description, category, category_id,
+               expense_date, receipt_image_url, status, bank_transaction_id, recurring_expense_id, base_currency, exchange_rate, split_method, created_at, updated_at
         FROM expenses 
-        WHERE team_id = $1
-        ORDER BY expense_date DESC, created_at DESC
-        LIMIT $2 OFFSET $3
-    `
+        WHERE %s
+        %s
+    `, strings.Join(where, " AND "), expenseOrderBy(filter))
+
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        expense, err := scanExpense(rows)
+        if err != nil {
+            return err
+        }
+        if err := fn(expense); err != nil {
+            return err
+        }
+    }
+
+    return rows.Err()
+}
+
+// listExpenses runs a filtered, sorted and paginated listing within a scope
+// such as a user or a team
+func (r *ExpenseRepositoryImpl) listExpenses(scope string, scopeArg interface{}, filter *models.ExpenseFilter, limit, offset int) ([]*models.Expense, error) {
+    clauses, args := expenseFilterClauses(filter, []interface{}{scopeArg})
+    where := append([]string{scope}, clauses...)
+
+    args = append(args, limit, offset)
+    query := fmt.Sprintf(`
+        SELECT id, user_id, team_id, amount, currency, description, category, category_id,
+               expense_date, receipt_image_url, status, bank_transaction_id, recurring_expense_id, base_currency, exchange_rate, split_method, created_at, updated_at
+        FROM expenses 
+        WHERE %s
+        %s
+        LIMIT $%d OFFSET $%d
+    `, strings.Join(where, " AND "), expenseOrderBy(filter), len(args)-1, len(args))
     
-    rows, err := r.db.Query(query, teamID, limit, offset)
+    rows, err := r.db.Query(query, args...)
     if err != nil {
         return nil, err
     }
@@ -185,26 +325,275 @@ func (r *ExpenseRepositoryImpl) GetExpensesByTeam(teamID string, limit, offset i
     
     var expenses []*models.Expense
     for rows.Next() {
-        expense := &models.Expense{}
-        err := rows.Scan(
-            &expense.ID,
-            &expense.UserID,
-            &expense.TeamID,
-            &expense.Amount,
-            &expense.Currency,
-            &expense.Description,
-            &expense.Category,
-            &expense.ExpenseDate,
-            &expense.ReceiptImageURL,
-            &expense.Status,
-            &expense.CreatedAt,
-            &expense.UpdatedAt,
-        )
+        expense, err := scanExpense(rows)
         if err != nil {
             return nil, err
         }
         expenses = append(expenses, expense)
     }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
     
-    return expenses, nil
-}
\ No newline at end of file
+    return expenses, loadExpenseTags(r.db, expenses)
+}
+
+func scanExpense(row rowScanner) (*models.Expense, error) {
+    expense := &models.Expense{}
+    err := row.Scan(
+        &expense.ID,
+        &expense.UserID,
+        &expense.TeamID,
+        &expense.Amount,
+        &expense.Currency,
+        &expense.Description,
+        &expense.Category,
+        &expense.CategoryID,
+        &expense.ExpenseDate,
+        &expense.ReceiptImageURL,
+        &expense.Status,
+        &expense.BankTransactionID,
+        &expense.RecurringExpenseID,
+        &expense.BaseCurrency,
+        &expense.ExchangeRate,
+        &expense.SplitMethod,
+        &expense.CreatedAt,
+        &expense.UpdatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return expense, nil
+}
+
+// TransitionExpense moves an expense from transition.FromStatus to transition.ToStatus,
+// appends the transition to its history and opens a new approval round with the
+// given steps, if any. It returns false without changing anything if the expense
+// is no longer in FromStatus.
+func (r *ExpenseRepositoryImpl) TransitionExpense(transition *models.ExpenseTransition, steps []*models.ExpenseApprovalStep) (bool, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    ok, err := applyTransition(tx, transition)
+    if err != nil || !ok {
+        return false, err
+    }
+
+    if len(steps) > 0 {
+        var round int
+        err = tx.QueryRow(
+            `SELECT COALESCE(MAX(round), 0) + 1 FROM expense_approval_steps WHERE expense_id = $1`,
+            transition.ExpenseID,
+        ).Scan(&round)
+        if err != nil {
+            return false, err
+        }
+
+        query := `
+            INSERT INTO expense_approval_steps (expense_id, round, step_order, approver_role, approver_user_id, status)
+            VALUES ($1, $2, $3, $4, $5, $6)
+            RETURNING id, created_at
+        `
+        for _, step := range steps {
+            step.ExpenseID = transition.ExpenseID
+            step.Round = round
+            err = tx.QueryRow(
+                query,
+                step.ExpenseID,
+                step.Round,
+                step.StepOrder,
+                step.ApproverRole,
+                step.ApproverUserID,
+                step.Status,
+            ).Scan(&step.ID, &step.CreatedAt)
+            if err != nil {
+                return false, err
+            }
+        }
+    }
+
+    return true, tx.Commit()
+}
+
+// DecideApprovalStep records an approver's decision on a pending step. When
+// transition is not nil the expense status changes in the same transaction.
+// It returns false without changing anything if the step was already decided
+// or the expense is no longer submitted. The expense row stays locked until
+// the decision is in, so a concurrent rejection cannot slip in between.
+func (r *ExpenseRepositoryImpl) DecideApprovalStep(step *models.ExpenseApprovalStep, transition *models.ExpenseTransition) (bool, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    var status string
+    err = tx.QueryRow(
+        `SELECT e.status
+         FROM expenses e
+         JOIN expense_approval_steps s ON s.expense_id = e.id
+         WHERE s.id = $1
+         FOR UPDATE OF e`,
+        step.ID,
+    ).Scan(&status)
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return false, nil
+        }
+        return false, err
+    }
+    if status != models.ExpenseStatusSubmitted {
+        return false, nil
+    }
+
+    query := `
+        UPDATE expense_approval_steps
+        SET status = $1, decided_by = $2, decided_at = CURRENT_TIMESTAMP, comment = $3
+        WHERE id = $4 AND status = $5
+        RETURNING decided_at
+    `
+    err = tx.QueryRow(
+        query,
+        step.Status,
+        step.DecidedBy,
+        step.Comment,
+        step.ID,
+        models.ApprovalStepPending,
+    ).Scan(&step.DecidedAt)
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return false, nil
+        }
+        return false, err
+    }
+
+    if transition != nil {
+        ok, err := applyTransition(tx, transition)
+        if err != nil || !ok {
+            return false, err
+        }
+    }
+
+    return true, tx.Commit()
+}
+
+// GetApprovalSteps retrieves all approval steps of an expense, by round and order
+func (r *ExpenseRepositoryImpl) GetApprovalSteps(expenseID string) ([]*models.ExpenseApprovalStep, error) {
+    query := `
+        SELECT id, expense_id, round, step_order, approver_role, approver_user_id,
+               status, decided_by, decided_at, comment, created_at
+        FROM expense_approval_steps
+        WHERE expense_id = $1
+        ORDER BY round ASC, step_order ASC
+    `
+
+    rows, err := r.db.Query(query, expenseID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var steps []*models.ExpenseApprovalStep
+    for rows.Next() {
+        step := &models.ExpenseApprovalStep{}
+        err := rows.Scan(
+            &step.ID,
+            &step.ExpenseID,
+            &step.Round,
+            &step.StepOrder,
+            &step.ApproverRole,
+            &step.ApproverUserID,
+            &step.Status,
+            &step.DecidedBy,
+            &step.DecidedAt,
+            &step.Comment,
+            &step.CreatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        steps = append(steps, step)
+    }
+
+    return steps, rows.Err()
+}
+
+// applyTransition changes the expense status if it still matches FromStatus and
+// appends the transition to its history
+func applyTransition(tx *sql.Tx, transition *models.ExpenseTransition) (bool, error) {
+    result, err := tx.Exec(
+        `UPDATE expenses SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND status = $3`,
+        transition.ToStatus,
+        transition.ExpenseID,
+        transition.FromStatus,
+    )
+    if err != nil {
+        return false, err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return false, err
+    }
+    if rowsAffected == 0 {
+        return false, nil
+    }
+
+    query := `
+        INSERT INTO expense_transitions (expense_id, from_status, to_status, actor_id, reason)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at
+    `
+    err = tx.QueryRow(
+        query,
+        transition.ExpenseID,
+        transition.FromStatus,
+        transition.ToStatus,
+        transition.ActorID,
+        transition.Reason,
+    ).Scan(&transition.ID, &transition.CreatedAt)
+    if err != nil {
+        return false, err
+    }
+
+    return true, nil
+}
+
+// GetExpenseTransitions retrieves the status history of an expense, oldest first
+func (r *ExpenseRepositoryImpl) GetExpenseTransitions(expenseID string) ([]*models.ExpenseTransition, error) {
+    query := `
+        SELECT id, expense_id, from_status, to_status, actor_id, reason, created_at
+        FROM expense_transitions
+        WHERE expense_id = $1
+        ORDER BY created_at ASC
+    `
+
+    rows, err := r.db.Query(query, expenseID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var transitions []*models.ExpenseTransition
+    for rows.Next() {
+        transition := &models.ExpenseTransition{}
+        err := rows.Scan(
+            &transition.ID,
+            &transition.ExpenseID,
+            &transition.FromStatus,
+            &transition.ToStatus,
+            &transition.ActorID,
+            &transition.Reason,
+            &transition.CreatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        transitions = append(transitions, transition)
+    }
+
+    return transitions, rows.Err()
+}
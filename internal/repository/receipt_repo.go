@@ -0,0 +1,264 @@
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "pocketpilot/internal/models"
+    "time"
+
+    "github.com/lib/pq"
+)
+
+type ReceiptRepositoryImpl struct {
+    db *sql.DB
+}
+
+func NewReceiptRepository(db *sql.DB) *ReceiptRepositoryImpl {
+    return &ReceiptRepositoryImpl{db: db}
+}
+
+const receiptColumns = `
+    id, expense_id, user_id, storage_key, file_name, content_type, size_bytes, created_at,
+    ocr_status, ocr_error, merchant_name, total_amount, tax_amount, currency, transaction_date, ocr_raw_data, scanned_at
+`
+
+// CreateReceipt records a receipt stored for an expense, with its variants
+func (r *ReceiptRepositoryImpl) CreateReceipt(receipt *models.Receipt) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    query := `
+        INSERT INTO receipts (expense_id, user_id, storage_key, file_name, content_type, size_bytes, ocr_status)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at
+    `
+
+    err = tx.QueryRow(
+        query,
+        receipt.ExpenseID,
+        receipt.UserID,
+        receipt.StorageKey,
+        receipt.FileName,
+        receipt.ContentType,
+        receipt.Size,
+        receipt.OCRStatus,
+    ).Scan(&receipt.ID, &receipt.CreatedAt)
+    if err != nil {
+        return err
+    }
+
+    for _, variant := range receipt.Variants {
+        _, err := tx.Exec(`
+            INSERT INTO receipt_variants (receipt_id, name, storage_key, content_type, width, height, size_bytes)
+            VALUES ($1, $2, $3, $4, $5, $6, $7)
+        `, receipt.ID, variant.Name, variant.StorageKey, variant.ContentType, variant.Width, variant.Height, variant.Size)
+        if err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit()
+}
+
+// GetReceiptByID retrieves a receipt by ID
+func (r *ReceiptRepositoryImpl) GetReceiptByID(id string) (*models.Receipt, error) {
+    query := `SELECT ` + receiptColumns + ` FROM receipts WHERE id = $1`
+
+    receipt, err := scanReceipt(r.db.QueryRow(query, id))
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    if err := loadReceiptVariants(r.db, []*models.Receipt{receipt}); err != nil {
+        return nil, err
+    }
+    return receipt, nil
+}
+
+// GetReceiptsByExpense retrieves the receipts of an expense, oldest first
+func (r *ReceiptRepositoryImpl) GetReceiptsByExpense(expenseID string) ([]*models.Receipt, error) {
+    query := `SELECT ` + receiptColumns + ` FROM receipts WHERE expense_id = $1 ORDER BY created_at ASC`
+
+    rows, err := r.db.Query(query, expenseID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var receipts []*models.Receipt
+    for rows.Next() {
+        receipt, err := scanReceipt(rows)
+        if err != nil {
+            return nil, err
+        }
+        receipts = append(receipts, receipt)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    return receipts, loadReceiptVariants(r.db, receipts)
+}
+
+// DeleteReceipt deletes the record of a receipt
+func (r *ReceiptRepositoryImpl) DeleteReceipt(id string) error {
+    _, err := r.db.Exec(`DELETE FROM receipts WHERE id = $1`, id)
+    return err
+}
+
+// GetReceiptsToScan retrieves receipts waiting for OCR, oldest first, along
+// with receipts whose scan started before staleBefore and never finished
+func (r *ReceiptRepositoryImpl) GetReceiptsToScan(staleBefore time.Time, limit int) ([]*models.Receipt, error) {
+    query := `
+        SELECT ` + receiptColumns + ` FROM receipts
+        WHERE ocr_status = 'pending' OR (ocr_status = 'processing' AND ocr_started_at < $1)
+        ORDER BY created_at ASC
+        LIMIT $2
+    `
+
+    rows, err := r.db.Query(query, staleBefore, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var receipts []*models.Receipt
+    for rows.Next() {
+        receipt, err := scanReceipt(rows)
+        if err != nil {
+            return nil, err
+        }
+        receipts = append(receipts, receipt)
+    }
+
+    return receipts, rows.Err()
+}
+
+// ClaimReceiptScan marks a receipt as being scanned, unless another scan has
+// it. Scans started before staleBefore are taken over.
+func (r *ReceiptRepositoryImpl) ClaimReceiptScan(id string, staleBefore time.Time) (bool, error) {
+    query := `
+        UPDATE receipts SET ocr_status = 'processing', ocr_started_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND (ocr_status = 'pending' OR (ocr_status = 'processing' AND ocr_started_at < $2))
+    `
+
+    result, err := r.db.Exec(query, id, staleBefore)
+    if err != nil {
+        return false, err
+    }
+    claimed, err := result.RowsAffected()
+    return claimed == 1, err
+}
+
+// SaveReceiptScan records the outcome of a scan
+func (r *ReceiptRepositoryImpl) SaveReceiptScan(receipt *models.Receipt) error {
+    query := `
+        UPDATE receipts
+        SET ocr_status = $1, ocr_error = $2, merchant_name = $3, total_amount = $4, tax_amount = $5,
+            currency = $6, transaction_date = $7, ocr_raw_data = $8, scanned_at = CURRENT_TIMESTAMP
+        WHERE id = $9
+        RETURNING scanned_at
+    `
+
+    var rawData interface{}
+    if len(receipt.OCRRawData) > 0 {
+        rawData = []byte(receipt.OCRRawData)
+    }
+    return r.db.QueryRow(
+        query,
+        receipt.OCRStatus,
+        receipt.OCRError,
+        receipt.MerchantName,
+        receipt.TotalAmount,
+        receipt.TaxAmount,
+        receipt.Currency,
+        receipt.TransactionDate,
+        rawData,
+        receipt.ID,
+    ).Scan(&receipt.ScannedAt)
+}
+
+// QueueReceiptScan puts a receipt back in line for OCR
+func (r *ReceiptRepositoryImpl) QueueReceiptScan(id string) error {
+    _, err := r.db.Exec(`UPDATE receipts SET ocr_status = 'pending', ocr_error = NULL WHERE id = $1`, id)
+    return err
+}
+
+func scanReceipt(row rowScanner) (*models.Receipt, error) {
+    receipt := &models.Receipt{}
+    var transactionDate *time.Time
+    var rawData []byte
+    err := row.Scan(
+        &receipt.ID,
+        &receipt.ExpenseID,
+        &receipt.UserID,
+        &receipt.StorageKey,
+        &receipt.FileName,
+        &receipt.ContentType,
+        &receipt.Size,
+        &receipt.CreatedAt,
+        &receipt.OCRStatus,
+        &receipt.OCRError,
+        &receipt.MerchantName,
+        &receipt.TotalAmount,
+        &receipt.TaxAmount,
+        &receipt.Currency,
+        &transactionDate,
+        &rawData,
+        &receipt.ScannedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    if transactionDate != nil {
+        date := transactionDate.Format("2006-01-02")
+        receipt.TransactionDate = &date
+    }
+    receipt.OCRRawData = rawData
+    return receipt, nil
+}
+
+// loadReceiptVariants fills in the variants of receipts, smallest first
+func loadReceiptVariants(db *sql.DB, receipts []*models.Receipt) error {
+    if len(receipts) == 0 {
+        return nil
+    }
+
+    byID := make(map[string]*models.Receipt, len(receipts))
+    ids := make([]string, len(receipts))
+    for i, receipt := range receipts {
+        byID[receipt.ID] = receipt
+        ids[i] = receipt.ID
+    }
+
+    rows, err := db.Query(`
+        SELECT receipt_id, name, storage_key, content_type, width, height, size_bytes
+        FROM receipt_variants
+        WHERE receipt_id = ANY($1)
+        ORDER BY width * height
+    `, pq.Array(ids))
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var receiptID string
+        variant := &models.ReceiptVariant{}
+        err := rows.Scan(&receiptID, &variant.Name, &variant.StorageKey, &variant.ContentType, &variant.Width, &variant.Height, &variant.Size)
+        if err != nil {
+            return err
+        }
+        if receipt, ok := byID[receiptID]; ok {
+            receipt.Variants = append(receipt.Variants, variant)
+        }
+    }
+
+    return rows.Err()
+}
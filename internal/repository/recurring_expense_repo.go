@@ -0,0 +1,240 @@
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "pocketpilot/internal/models"
+)
+
+type RecurringExpenseRepositoryImpl struct {
+    db *sql.DB
+}
+
+func NewRecurringExpenseRepository(db *sql.DB) *RecurringExpenseRepositoryImpl {
+    return &RecurringExpenseRepositoryImpl{db: db}
+}
+
+const recurringExpenseColumns = `
+    id, user_id, team_id, amount, currency, description, category, category_id, frequency, "interval",
+    start_date::text, end_date::text, max_occurrences, occurrence_count, next_occurrence::text,
+    active, created_at, updated_at
+`
+
+// CreateRecurringExpense creates a new recurring expense template
+func (r *RecurringExpenseRepositoryImpl) CreateRecurringExpense(recurring *models.RecurringExpense) error {
+    query := `
+        INSERT INTO recurring_expenses (user_id, team_id, amount, currency, description, category, frequency, "interval",
+                                        start_date, end_date, max_occurrences, occurrence_count, next_occurrence, active, category_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+        RETURNING id, created_at, updated_at
+    `
+
+    return r.db.QueryRow(
+        query,
+        recurring.UserID,
+        recurring.TeamID,
+        recurring.Amount,
+        recurring.Currency,
+        recurring.Description,
+        recurring.Category,
+        recurring.Frequency,
+        recurring.Interval,
+        recurring.StartDate,
+        recurring.EndDate,
+        recurring.MaxOccurrences,
+        recurring.OccurrenceCount,
+        recurring.NextOccurrence,
+        recurring.Active,
+        recurring.CategoryID,
+    ).Scan(&recurring.ID, &recurring.CreatedAt, &recurring.UpdatedAt)
+}
+
+// GetRecurringExpenseByID retrieves a recurring expense template by ID
+func (r *RecurringExpenseRepositoryImpl) GetRecurringExpenseByID(id string) (*models.RecurringExpense, error) {
+    query := `SELECT ` + recurringExpenseColumns + ` FROM recurring_expenses WHERE id = $1`
+
+    recurring, err := scanRecurringExpense(r.db.QueryRow(query, id))
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+
+    return recurring, nil
+}
+
+// GetRecurringExpensesByUser retrieves a user's recurring expense templates
+func (r *RecurringExpenseRepositoryImpl) GetRecurringExpensesByUser(userID string) ([]*models.RecurringExpense, error) {
+    query := `SELECT ` + recurringExpenseColumns + ` FROM recurring_expenses WHERE user_id = $1 ORDER BY created_at DESC`
+    return r.queryRecurringExpenses(query, userID)
+}
+
+// GetDueRecurringExpenses retrieves active templates with an occurrence on or
+// before the given date, the most overdue first
+func (r *RecurringExpenseRepositoryImpl) GetDueRecurringExpenses(date string, limit int) ([]*models.RecurringExpense, error) {
+    query := `
+        SELECT ` + recurringExpenseColumns + `
+        FROM recurring_expenses
+        WHERE active AND next_occurrence <= $1
+        ORDER BY next_occurrence ASC, id ASC
+        LIMIT $2
+    `
+    return r.queryRecurringExpenses(query, date, limit)
+}
+
+// UpdateRecurringExpense updates a template's details and schedule state
+func (r *RecurringExpenseRepositoryImpl) UpdateRecurringExpense(recurring *models.RecurringExpense) error {
+    query := `
+        UPDATE recurring_expenses
+        SET amount = $1, description = $2, category = $3, end_date = $4, max_occurrences = $5,
+            occurrence_count = $6, next_occurrence = $7, active = $8, category_id = $11, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $9 AND user_id = $10
+        RETURNING updated_at
+    `
+
+    return r.db.QueryRow(
+        query,
+        recurring.Amount,
+        recurring.Description,
+        recurring.Category,
+        recurring.EndDate,
+        recurring.MaxOccurrences,
+        recurring.OccurrenceCount,
+        recurring.NextOccurrence,
+        recurring.Active,
+        recurring.ID,
+        recurring.UserID,
+        recurring.CategoryID,
+    ).Scan(&recurring.UpdatedAt)
+}
+
+// DeleteRecurringExpense deletes a user's recurring expense template. Expenses
+// it already created are kept.
+func (r *RecurringExpenseRepositoryImpl) DeleteRecurringExpense(id, userID string) error {
+    query := `DELETE FROM recurring_expenses WHERE id = $1 AND user_id = $2`
+    result, err := r.db.Exec(query, id, userID)
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+
+    if rowsAffected == 0 {
+        return errors.New("recurring expense not found")
+    }
+
+    return nil
+}
+
+// MaterializeOccurrence creates the expense for the template's occurrence on
+// the given date and saves the template's advanced schedule in one transaction.
+// It returns false without changing anything if the template no longer has
+// that occurrence due, e.g. because another instance already created it. An
+// expense that already exists for the occurrence is not created twice.
+func (r *RecurringExpenseRepositoryImpl) MaterializeOccurrence(recurring *models.RecurringExpense, date string, expense *models.Expense) (bool, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    result, err := tx.Exec(
+        `UPDATE recurring_expenses
+         SET occurrence_count = $1, next_occurrence = $2, active = $3, updated_at = CURRENT_TIMESTAMP
+         WHERE id = $4 AND active AND next_occurrence = $5`,
+        recurring.OccurrenceCount,
+        recurring.NextOccurrence,
+        recurring.Active,
+        recurring.ID,
+        date,
+    )
+    if err != nil {
+        return false, err
+    }
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return false, err
+    }
+    if rowsAffected == 0 {
+        return false, nil
+    }
+
+    query := `
+        INSERT INTO expenses (user_id, team_id, amount, currency, description, category, expense_date, status,
+                              recurring_expense_id, occurrence_date, category_id, base_currency, exchange_rate)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $7, $10, $11, $12)
+        ON CONFLICT (recurring_expense_id, occurrence_date) WHERE recurring_expense_id IS NOT NULL DO NOTHING
+        RETURNING id, created_at, updated_at
+    `
+    err = tx.QueryRow(
+        query,
+        expense.UserID,
+        expense.TeamID,
+        expense.Amount,
+        expense.Currency,
+        expense.Description,
+        expense.Category,
+        expense.ExpenseDate,
+        expense.Status,
+        expense.RecurringExpenseID,
+        expense.CategoryID,
+        expense.BaseCurrency,
+        expense.ExchangeRate,
+    ).Scan(&expense.ID, &expense.CreatedAt, &expense.UpdatedAt)
+    if err != nil && !errors.Is(err, sql.ErrNoRows) {
+        return false, err
+    }
+
+    return true, tx.Commit()
+}
+
+func (r *RecurringExpenseRepositoryImpl) queryRecurringExpenses(query string, args ...interface{}) ([]*models.RecurringExpense, error) {
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var templates []*models.RecurringExpense
+    for rows.Next() {
+        recurring, err := scanRecurringExpense(rows)
+        if err != nil {
+            return nil, err
+        }
+        templates = append(templates, recurring)
+    }
+
+    return templates, rows.Err()
+}
+
+func scanRecurringExpense(row rowScanner) (*models.RecurringExpense, error) {
+    recurring := &models.RecurringExpense{}
+    err := row.Scan(
+        &recurring.ID,
+        &recurring.UserID,
+        &recurring.TeamID,
+        &recurring.Amount,
+        &recurring.Currency,
+        &recurring.Description,
+        &recurring.Category,
+        &recurring.CategoryID,
+        &recurring.Frequency,
+        &recurring.Interval,
+        &recurring.StartDate,
+        &recurring.EndDate,
+        &recurring.MaxOccurrences,
+        &recurring.OccurrenceCount,
+        &recurring.NextOccurrence,
+        &recurring.Active,
+        &recurring.CreatedAt,
+        &recurring.UpdatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return recurring, nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+    "pocketpilot/internal/models"
+    "strings"
+)
+
+// reportGroupColumns whitelists what reports can group expenses by
+var reportGroupColumns = map[string]string{
+    models.ReportByCategory: "category",
+    models.ReportByTag:      "tag",
+    models.ReportByStatus:   "status",
+    models.ReportByMember:   "user_id::text",
+    models.ReportByMonth:    "to_char(expense_date, 'YYYY-MM')",
+    models.ReportByWeek:     "to_char(date_trunc('week', expense_date), 'YYYY-MM-DD')",
+}
+
+// taggedExpenses lists each expense once per tag, with the tag's name, for
+// grouping by tag. Untagged expenses are left out.
+const taggedExpenses = `(
+    SELECT expenses.*, tags.name AS tag
+    FROM expenses
+    JOIN expense_tags ON expense_tags.expense_id = expenses.id
+    JOIN tags ON tags.id = expense_tags.tag_id
+) AS expenses`
+
+type ReportRepositoryImpl struct {
+    db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) *ReportRepositoryImpl {
+    return &ReportRepositoryImpl{db: db}
+}
+
+// SumExpensesByUser counts and sums a user's expenses matching the filter per
+// group, day and currency
+func (r *ReportRepositoryImpl) SumExpensesByUser(userID, groupBy string, filter *models.ExpenseFilter) ([]*models.ReportRow, error) {
+    return r.sumExpenses("user_id = $1", userID, groupBy, filter)
+}
+
+// SumExpensesByTeam counts and sums a team's expenses matching the filter per
+// group, day and currency
+func (r *ReportRepositoryImpl) SumExpensesByTeam(teamID, groupBy string, filter *models.ExpenseFilter) ([]*models.ReportRow, error) {
+    return r.sumExpenses("team_id = $1", teamID, groupBy, filter)
+}
+
+// sumExpenses aggregates in the database so reports never load the expenses
+// themselves. Rows are kept per day so amounts can be converted with the rate
+// of their own date.
+func (r *ReportRepositoryImpl) sumExpenses(scope string, scopeArg interface{}, groupBy string, filter *models.ExpenseFilter) ([]*models.ReportRow, error) {
+    column, ok := reportGroupColumns[groupBy]
+    if !ok {
+        return nil, fmt.Errorf("unsupported report grouping: %s", groupBy)
+    }
+
+    source := "expenses"
+    if groupBy == models.ReportByTag {
+        source = taggedExpenses
+    }
+
+    clauses, args := expenseFilterClauses(filter, []interface{}{scopeArg})
+    where := append([]string{scope}, clauses...)
+
+    query := fmt.Sprintf(`
+        SELECT %s AS group_key, expense_date::text, currency, COUNT(*), SUM(amount)
+        FROM %s
+        WHERE %s
+        GROUP BY group_key, expense_date, currency
+        ORDER BY group_key, expense_date
+    `, column, source, strings.Join(where, " AND "))
+
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var result []*models.ReportRow
+    for rows.Next() {
+        row := &models.ReportRow{}
+        if err := rows.Scan(&row.Key, &row.Date, &row.Currency, &row.Count, &row.Amount); err != nil {
+            return nil, err
+        }
+        result = append(result, row)
+    }
+
+    return result, rows.Err()
+}
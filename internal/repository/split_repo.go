@@ -0,0 +1,184 @@
+package repository
+
+import (
+    "database/sql"
+    "pocketpilot/internal/models"
+)
+
+type SplitRepositoryImpl struct {
+    db *sql.DB
+}
+
+func NewSplitRepository(db *sql.DB) *SplitRepositoryImpl {
+    return &SplitRepositoryImpl{db: db}
+}
+
+// SetExpenseSplits replaces an expense's split in one transaction. A nil
+// method with no splits removes the split.
+func (r *SplitRepositoryImpl) SetExpenseSplits(expenseID string, method *string, splits []*models.ExpenseSplit) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.Exec(`DELETE FROM expense_splits WHERE expense_id = $1`, expenseID); err != nil {
+        return err
+    }
+
+    for _, split := range splits {
+        err := tx.QueryRow(`
+            INSERT INTO expense_splits (expense_id, user_id, amount, percentage, shares)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING created_at
+        `, expenseID, split.UserID, split.Amount, split.Percentage, split.Shares).Scan(&split.CreatedAt)
+        if err != nil {
+            return err
+        }
+    }
+
+    _, err = tx.Exec(`UPDATE expenses SET split_method = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, method, expenseID)
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// GetExpenseSplits retrieves the portions of a split expense
+func (r *SplitRepositoryImpl) GetExpenseSplits(expenseID string) ([]*models.ExpenseSplit, error) {
+    query := `
+        SELECT expense_id, user_id, amount, percentage, shares, created_at
+        FROM expense_splits
+        WHERE expense_id = $1
+        ORDER BY created_at, user_id
+    `
+
+    rows, err := r.db.Query(query, expenseID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var splits []*models.ExpenseSplit
+    for rows.Next() {
+        split := &models.ExpenseSplit{}
+        err := rows.Scan(
+            &split.ExpenseID,
+            &split.UserID,
+            &split.Amount,
+            &split.Percentage,
+            &split.Shares,
+            &split.CreatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        splits = append(splits, split)
+    }
+
+    return splits, rows.Err()
+}
+
+// GetTeamSplitDebts sums what each member owes each other member for the
+// team's split expenses, per currency. Rejected expenses are left out.
+func (r *SplitRepositoryImpl) GetTeamSplitDebts(teamID string) ([]*models.Debt, error) {
+    query := `
+        SELECT s.user_id, e.user_id, e.currency, SUM(s.amount)
+        FROM expense_splits s
+        JOIN expenses e ON e.id = s.expense_id
+        WHERE e.team_id = $1 AND e.status <> 'rejected' AND s.user_id <> e.user_id
+        GROUP BY s.user_id, e.user_id, e.currency
+    `
+
+    return r.queryDebts(query, teamID)
+}
+
+// GetTeamSettledDebts sums the settlements each member paid each other member, per currency
+func (r *SplitRepositoryImpl) GetTeamSettledDebts(teamID string) ([]*models.Debt, error) {
+    query := `
+        SELECT from_user_id, to_user_id, currency, SUM(amount)
+        FROM settlements
+        WHERE team_id = $1
+        GROUP BY from_user_id, to_user_id, currency
+    `
+
+    return r.queryDebts(query, teamID)
+}
+
+func (r *SplitRepositoryImpl) queryDebts(query string, args ...interface{}) ([]*models.Debt, error) {
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var debts []*models.Debt
+    for rows.Next() {
+        debt := &models.Debt{}
+        if err := rows.Scan(&debt.FromUserID, &debt.ToUserID, &debt.Currency, &debt.Amount); err != nil {
+            return nil, err
+        }
+        debts = append(debts, debt)
+    }
+
+    return debts, rows.Err()
+}
+
+// CreateSettlement records a payment between team members
+func (r *SplitRepositoryImpl) CreateSettlement(settlement *models.Settlement) error {
+    query := `
+        INSERT INTO settlements (team_id, from_user_id, to_user_id, amount, currency, note, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at
+    `
+
+    return r.db.QueryRow(
+        query,
+        settlement.TeamID,
+        settlement.FromUserID,
+        settlement.ToUserID,
+        settlement.Amount,
+        settlement.Currency,
+        settlement.Note,
+        settlement.CreatedBy,
+    ).Scan(&settlement.ID, &settlement.CreatedAt)
+}
+
+// GetSettlementsByTeam retrieves a team's settlements, newest first
+func (r *SplitRepositoryImpl) GetSettlementsByTeam(teamID string) ([]*models.Settlement, error) {
+    query := `
+        SELECT id, team_id, from_user_id, to_user_id, amount, currency, note, created_by, created_at
+        FROM settlements
+        WHERE team_id = $1
+        ORDER BY created_at DESC
+    `
+
+    rows, err := r.db.Query(query, teamID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var settlements []*models.Settlement
+    for rows.Next() {
+        settlement := &models.Settlement{}
+        err := rows.Scan(
+            &settlement.ID,
+            &settlement.TeamID,
+            &settlement.FromUserID,
+            &settlement.ToUserID,
+            &settlement.Amount,
+            &settlement.Currency,
+            &settlement.Note,
+            &settlement.CreatedBy,
+            &settlement.CreatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        settlements = append(settlements, settlement)
+    }
+
+    return settlements, rows.Err()
+}
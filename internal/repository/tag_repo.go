@@ -0,0 +1,243 @@
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "pocketpilot/internal/models"
+    "strings"
+
+    "github.com/lib/pq"
+)
+
+type TagRepositoryImpl struct {
+    db *sql.DB
+}
+
+func NewTagRepository(db *sql.DB) *TagRepositoryImpl {
+    return &TagRepositoryImpl{db: db}
+}
+
+const tagColumns = `
+    id, user_id, team_id, name, created_at, updated_at
+`
+
+// tagScope matches the tags of a team, or of a user's personal expenses when
+// the team ($2) is null
+const tagScope = `
+    (($2::uuid IS NULL AND team_id IS NULL AND user_id = $1)
+     OR ($2::uuid IS NOT NULL AND team_id = $2))
+`
+
+// GetTags retrieves the tags of a user's personal expenses, or a team's when
+// teamID is set, with how many expenses carry each, sorted by name
+func (r *TagRepositoryImpl) GetTags(userID string, teamID *string) ([]*models.Tag, error) {
+    query := `
+        SELECT id, user_id, team_id, name, created_at, updated_at,
+               (SELECT COUNT(*) FROM expense_tags WHERE expense_tags.tag_id = tags.id)
+        FROM tags
+        WHERE ` + tagScope + `
+        ORDER BY lower(name)
+    `
+
+    rows, err := r.db.Query(query, userID, teamID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var tags []*models.Tag
+    for rows.Next() {
+        tag := &models.Tag{}
+        err := rows.Scan(&tag.ID, &tag.UserID, &tag.TeamID, &tag.Name, &tag.CreatedAt, &tag.UpdatedAt, &tag.ExpenseCount)
+        if err != nil {
+            return nil, err
+        }
+        tags = append(tags, tag)
+    }
+
+    return tags, rows.Err()
+}
+
+// GetTagByID retrieves a tag by ID
+func (r *TagRepositoryImpl) GetTagByID(id string) (*models.Tag, error) {
+    query := `SELECT ` + tagColumns + ` FROM tags WHERE id = $1`
+
+    tag, err := scanTag(r.db.QueryRow(query, id))
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    return tag, nil
+}
+
+// GetTagByName finds a tag of a user's personal expenses, or a team's when
+// teamID is set, by name ignoring case
+func (r *TagRepositoryImpl) GetTagByName(userID string, teamID *string, name string) (*models.Tag, error) {
+    query := `SELECT ` + tagColumns + ` FROM tags WHERE ` + tagScope + ` AND lower(name) = lower($3)`
+
+    tag, err := scanTag(r.db.QueryRow(query, userID, teamID, name))
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    return tag, nil
+}
+
+// UpdateTag renames a tag
+func (r *TagRepositoryImpl) UpdateTag(tag *models.Tag) error {
+    query := `
+        UPDATE tags SET name = $1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $2
+        RETURNING updated_at
+    `
+    return r.db.QueryRow(query, tag.Name, tag.ID).Scan(&tag.UpdatedAt)
+}
+
+// MergeTags moves the expenses of the source tag to the target tag and
+// deletes the source, in one transaction
+func (r *TagRepositoryImpl) MergeTags(sourceID, targetID string) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    _, err = tx.Exec(`
+        INSERT INTO expense_tags (expense_id, tag_id)
+        SELECT expense_id, $2 FROM expense_tags WHERE tag_id = $1
+        ON CONFLICT DO NOTHING
+    `, sourceID, targetID)
+    if err != nil {
+        return err
+    }
+    if _, err := tx.Exec(`DELETE FROM tags WHERE id = $1`, sourceID); err != nil {
+        return err
+    }
+    if _, err := tx.Exec(`UPDATE tags SET updated_at = CURRENT_TIMESTAMP WHERE id = $1`, targetID); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// DeleteTag deletes a tag, removing it from its expenses
+func (r *TagRepositoryImpl) DeleteTag(id string) error {
+    _, err := r.db.Exec(`DELETE FROM tags WHERE id = $1`, id)
+    return err
+}
+
+func scanTag(row rowScanner) (*models.Tag, error) {
+    tag := &models.Tag{}
+    err := row.Scan(
+        &tag.ID,
+        &tag.UserID,
+        &tag.TeamID,
+        &tag.Name,
+        &tag.CreatedAt,
+        &tag.UpdatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return tag, nil
+}
+
+// setExpenseTags replaces the tags of an expense with the ones its Tags name,
+// creating the missing ones for the expense's user or team. Tags is then set
+// to the stored names, sorted.
+func setExpenseTags(tx *sql.Tx, expense *models.Expense) error {
+    if _, err := tx.Exec(`DELETE FROM expense_tags WHERE expense_id = $1`, expense.ID); err != nil {
+        return err
+    }
+    if len(expense.Tags) == 0 {
+        return nil
+    }
+
+    var userID *string
+    if expense.TeamID == nil {
+        userID = &expense.UserID
+    }
+    lowered := make([]string, len(expense.Tags))
+    for i, name := range expense.Tags {
+        lowered[i] = strings.ToLower(name)
+    }
+
+    _, err := tx.Exec(`
+        INSERT INTO tags (user_id, team_id, name)
+        SELECT $1, $2, name FROM unnest($3::text[]) AS name
+        ON CONFLICT DO NOTHING
+    `, userID, expense.TeamID, pq.Array(expense.Tags))
+    if err != nil {
+        return err
+    }
+
+    rows, err := tx.Query(`
+        WITH linked AS (
+            INSERT INTO expense_tags (expense_id, tag_id)
+            SELECT $3, id FROM tags WHERE `+tagScope+` AND lower(name) = ANY($4)
+            RETURNING tag_id
+        )
+        SELECT tags.name FROM linked JOIN tags ON tags.id = linked.tag_id
+        ORDER BY lower(tags.name)
+    `, expense.UserID, expense.TeamID, expense.ID, pq.Array(lowered))
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+
+    var names []string
+    for rows.Next() {
+        var name string
+        if err := rows.Scan(&name); err != nil {
+            return err
+        }
+        names = append(names, name)
+    }
+    if err := rows.Err(); err != nil {
+        return err
+    }
+
+    expense.Tags = names
+    return nil
+}
+
+// loadExpenseTags fills in the tags of the expenses with one query
+func loadExpenseTags(db *sql.DB, expenses []*models.Expense) error {
+    if len(expenses) == 0 {
+        return nil
+    }
+
+    byID := make(map[string]*models.Expense, len(expenses))
+    ids := make([]string, len(expenses))
+    for i, expense := range expenses {
+        byID[expense.ID] = expense
+        ids[i] = expense.ID
+    }
+
+    rows, err := db.Query(`
+        SELECT expense_tags.expense_id, tags.name
+        FROM expense_tags JOIN tags ON tags.id = expense_tags.tag_id
+        WHERE expense_tags.expense_id = ANY($1)
+        ORDER BY lower(tags.name)
+    `, pq.Array(ids))
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var expenseID, name string
+        if err := rows.Scan(&expenseID, &name); err != nil {
+            return err
+        }
+        if expense, ok := byID[expenseID]; ok {
+            expense.Tags = append(expense.Tags, name)
+        }
+    }
+
+    return rows.Err()
+}
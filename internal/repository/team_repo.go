@@ -0,0 +1,318 @@
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "pocketpilot/internal/models"
+)
+
+type TeamRepositoryImpl struct {
+    db *sql.DB
+}
+
+func NewTeamRepository(db *sql.DB) *TeamRepositoryImpl {
+    return &TeamRepositoryImpl{db: db}
+}
+
+// CreateTeam creates a new team and makes its creator the owner
+func (r *TeamRepositoryImpl) CreateTeam(team *models.Team) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    query := `
+        INSERT INTO teams (name, created_by, base_currency)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at, updated_at
+    `
+    err = tx.QueryRow(query, team.Name, team.CreatedBy, team.BaseCurrency).Scan(&team.ID, &team.CreatedAt, &team.UpdatedAt)
+    if err != nil {
+        return err
+    }
+
+    _, err = tx.Exec(
+        `INSERT INTO team_members (team_id, user_id, role) VALUES ($1, $2, $3)`,
+        team.ID,
+        team.CreatedBy,
+        models.TeamRoleOwner,
+    )
+    if err != nil {
+        return err
+    }
+
+    team.Role = models.TeamRoleOwner
+    return tx.Commit()
+}
+
+// GetTeamByID retrieves a team by ID
+func (r *TeamRepositoryImpl) GetTeamByID(id string) (*models.Team, error) {
+    query := `
+        SELECT id, name, created_by, base_currency, created_at, updated_at
+        FROM teams
+        WHERE id = $1
+    `
+
+    team := &models.Team{}
+    err := r.db.QueryRow(query, id).Scan(
+        &team.ID,
+        &team.Name,
+        &team.CreatedBy,
+        &team.BaseCurrency,
+        &team.CreatedAt,
+        &team.UpdatedAt,
+    )
+
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+
+    return team, nil
+}
+
+// GetTeamsByUser retrieves all teams a user belongs to, with their role in each
+func (r *TeamRepositoryImpl) GetTeamsByUser(userID string) ([]*models.Team, error) {
+    query := `
+        SELECT t.id, t.name, t.created_by, t.base_currency, tm.role, t.created_at, t.updated_at
+        FROM teams t
+        JOIN team_members tm ON tm.team_id = t.id
+        WHERE tm.user_id = $1
+        ORDER BY t.name ASC, t.created_at ASC
+    `
+
+    rows, err := r.db.Query(query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var teams []*models.Team
+    for rows.Next() {
+        team := &models.Team{}
+        err := rows.Scan(
+            &team.ID,
+            &team.Name,
+            &team.CreatedBy,
+            &team.BaseCurrency,
+            &team.Role,
+            &team.CreatedAt,
+            &team.UpdatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        teams = append(teams, team)
+    }
+
+    return teams, rows.Err()
+}
+
+// UpdateTeam updates a team's name and base currency
+func (r *TeamRepositoryImpl) UpdateTeam(team *models.Team) error {
+    query := `
+        UPDATE teams
+        SET name = $1, base_currency = $2, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $3
+        RETURNING updated_at
+    `
+
+    return r.db.QueryRow(query, team.Name, team.BaseCurrency, team.ID).Scan(&team.UpdatedAt)
+}
+
+// DeleteTeam deletes a team. Its expenses are kept and become personal expenses
+// of the users who created them. Submitted and approved expenses, which nobody
+// could approve or reimburse any more, go back to draft, recorded as a
+// transition by actorID.
+func (r *TeamRepositoryImpl) DeleteTeam(id, actorID string) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    _, err = tx.Exec(
+        `INSERT INTO expense_transitions (expense_id, from_status, to_status, actor_id, reason)
+         SELECT id, status, $2::text, $3::uuid, $4::text
+         FROM expenses
+         WHERE team_id = $1 AND status IN ($5, $6)
+         FOR UPDATE`,
+        id,
+        models.ExpenseStatusDraft,
+        actorID,
+        "Team deleted",
+        models.ExpenseStatusSubmitted,
+        models.ExpenseStatusApproved,
+    )
+    if err != nil {
+        return err
+    }
+
+    _, err = tx.Exec(
+        `UPDATE expenses SET status = $2, updated_at = CURRENT_TIMESTAMP
+         WHERE team_id = $1 AND status IN ($3, $4)`,
+        id,
+        models.ExpenseStatusDraft,
+        models.ExpenseStatusSubmitted,
+        models.ExpenseStatusApproved,
+    )
+    if err != nil {
+        return err
+    }
+
+    if _, err := tx.Exec(`UPDATE expenses SET team_id = NULL WHERE team_id = $1`, id); err != nil {
+        return err
+    }
+
+    result, err := tx.Exec(`DELETE FROM teams WHERE id = $1`, id)
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+
+    if rowsAffected == 0 {
+        return errors.New("team not found")
+    }
+
+    return tx.Commit()
+}
+
+// GetTeamMember retrieves a user's membership in a team
+func (r *TeamRepositoryImpl) GetTeamMember(teamID, userID string) (*models.TeamMember, error) {
+    query := `
+        SELECT id, team_id, user_id, role, joined_at
+        FROM team_members
+        WHERE team_id = $1 AND user_id = $2
+    `
+
+    member := &models.TeamMember{}
+    err := r.db.QueryRow(query, teamID, userID).Scan(
+        &member.ID,
+        &member.TeamID,
+        &member.UserID,
+        &member.Role,
+        &member.JoinedAt,
+    )
+
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+
+    return member, nil
+}
+
+// GetTeamMembers retrieves all members of a team along with their user details
+func (r *TeamRepositoryImpl) GetTeamMembers(teamID string) ([]*models.TeamMember, error) {
+    query := `
+        SELECT tm.id, tm.team_id, tm.user_id, tm.role, tm.joined_at,
+               u.id, u.email, u.first_name, u.last_name, u.created_at, u.updated_at
+        FROM team_members tm
+        JOIN users u ON u.id = tm.user_id
+        WHERE tm.team_id = $1
+        ORDER BY tm.joined_at ASC
+    `
+
+    rows, err := r.db.Query(query, teamID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var members []*models.TeamMember
+    for rows.Next() {
+        member := &models.TeamMember{User: &models.User{}}
+        err := rows.Scan(
+            &member.ID,
+            &member.TeamID,
+            &member.UserID,
+            &member.Role,
+            &member.JoinedAt,
+            &member.User.ID,
+            &member.User.Email,
+            &member.User.FirstName,
+            &member.User.LastName,
+            &member.User.CreatedAt,
+            &member.User.UpdatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        members = append(members, member)
+    }
+
+    return members, rows.Err()
+}
+
+// AddTeamMember adds a user to a team
+func (r *TeamRepositoryImpl) AddTeamMember(member *models.TeamMember) error {
+    query := `
+        INSERT INTO team_members (team_id, user_id, role)
+        VALUES ($1, $2, $3)
+        RETURNING id, joined_at
+    `
+
+    return r.db.QueryRow(query, member.TeamID, member.UserID, member.Role).Scan(&member.ID, &member.JoinedAt)
+}
+
+// UpdateTeamMemberRole changes a member's role within a team
+func (r *TeamRepositoryImpl) UpdateTeamMemberRole(teamID, userID, role string) error {
+    query := `UPDATE team_members SET role = $1 WHERE team_id = $2 AND user_id = $3`
+    result, err := r.db.Exec(query, role, teamID, userID)
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+
+    if rowsAffected == 0 {
+        return errors.New("team member not found")
+    }
+
+    return nil
+}
+
+// RemoveTeamMember removes a user from a team
+func (r *TeamRepositoryImpl) RemoveTeamMember(teamID, userID string) error {
+    query := `DELETE FROM team_members WHERE team_id = $1 AND user_id = $2`
+    result, err := r.db.Exec(query, teamID, userID)
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+
+    if rowsAffected == 0 {
+        return errors.New("team member not found")
+    }
+
+    return nil
+}
+
+// CountTeamMembersByRole counts the members of a team holding the given role
+func (r *TeamRepositoryImpl) CountTeamMembersByRole(teamID, role string) (int, error) {
+    query := `SELECT COUNT(*) FROM team_members WHERE team_id = $1 AND role = $2`
+
+    var count int
+    err := r.db.QueryRow(query, teamID, role).Scan(&count)
+    if err != nil {
+        return 0, err
+    }
+
+    return count, nil
+}
@@ -0,0 +1,414 @@
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "pocketpilot/internal/models"
+    "time"
+)
+
+type TokenRepositoryImpl struct {
+    db *sql.DB
+}
+
+func NewTokenRepository(db *sql.DB) *TokenRepositoryImpl {
+    return &TokenRepositoryImpl{db: db}
+}
+
+const refreshTokenColumns = `
+    id, user_id, session_id, token_hash, access_token_id, access_expires_at, expires_at, rotated_at, revoked_at, created_at
+`
+
+const sessionColumns = `
+    id, user_id, client_name, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at
+`
+
+// CreateSession starts a session with its first refresh token. The database
+// assigns the jti of the access token issued with it.
+func (r *TokenRepositoryImpl) CreateSession(session *models.Session, token *models.RefreshToken) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    query := `
+        INSERT INTO sessions (user_id, client_name, user_agent, ip_address, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at, last_seen_at
+    `
+
+    err = tx.QueryRow(
+        query,
+        session.UserID,
+        session.ClientName,
+        session.UserAgent,
+        session.IPAddress,
+        session.ExpiresAt,
+    ).Scan(&session.ID, &session.CreatedAt, &session.LastSeenAt)
+    if err != nil {
+        return err
+    }
+
+    token.SessionID = session.ID
+    if err := insertRefreshToken(tx, token); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
+
+func insertRefreshToken(tx *sql.Tx, token *models.RefreshToken) error {
+    query := `
+        INSERT INTO refresh_tokens (user_id, session_id, token_hash, access_expires_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, access_token_id, created_at
+    `
+
+    return tx.QueryRow(
+        query,
+        token.UserID,
+        token.SessionID,
+        token.TokenHash,
+        token.AccessExpiresAt,
+        token.ExpiresAt,
+    ).Scan(&token.ID, &token.AccessTokenID, &token.CreatedAt)
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by the hash of its value
+func (r *TokenRepositoryImpl) GetRefreshTokenByHash(hash string) (*models.RefreshToken, error) {
+    query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE token_hash = $1`
+
+    token := &models.RefreshToken{}
+    err := r.db.QueryRow(query, hash).Scan(
+        &token.ID,
+        &token.UserID,
+        &token.SessionID,
+        &token.TokenHash,
+        &token.AccessTokenID,
+        &token.AccessExpiresAt,
+        &token.ExpiresAt,
+        &token.RotatedAt,
+        &token.RevokedAt,
+        &token.CreatedAt,
+    )
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    return token, nil
+}
+
+// RotateRefreshToken marks a refresh token as used and stores the one that
+// replaces it, in the same session, which is seen again from client. It
+// reports false, storing nothing, when the token was used or revoked in the
+// meantime.
+func (r *TokenRepositoryImpl) RotateRefreshToken(id string, next *models.RefreshToken, client *models.SessionClient) (bool, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    result, err := tx.Exec(`
+        UPDATE refresh_tokens
+        SET rotated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL
+    `, id)
+    if err != nil {
+        return false, err
+    }
+    if rotated, err := result.RowsAffected(); err != nil || rotated != 1 {
+        return false, err
+    }
+
+    if err := insertRefreshToken(tx, next); err != nil {
+        return false, err
+    }
+
+    _, err = tx.Exec(`
+        UPDATE sessions
+        SET last_seen_at = CURRENT_TIMESTAMP, expires_at = $2,
+            user_agent = COALESCE(NULLIF($3, ''), user_agent), ip_address = COALESCE(NULLIF($4, ''), ip_address)
+        WHERE id = $1
+    `, next.SessionID, next.ExpiresAt, client.UserAgent, client.IPAddress)
+    if err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit()
+}
+
+// GetSessionByID retrieves a session by ID
+func (r *TokenRepositoryImpl) GetSessionByID(id string) (*models.Session, error) {
+    query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = $1`
+
+    session, err := scanSession(r.db.QueryRow(query, id))
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    return session, nil
+}
+
+// GetActiveSessions lists the sessions of a user that are neither revoked nor
+// expired, most recently seen first
+func (r *TokenRepositoryImpl) GetActiveSessions(userID string) ([]*models.Session, error) {
+    query := `
+        SELECT ` + sessionColumns + `
+        FROM sessions
+        WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+        ORDER BY last_seen_at DESC
+    `
+
+    rows, err := r.db.Query(query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var sessions []*models.Session
+    for rows.Next() {
+        session, err := scanSession(rows)
+        if err != nil {
+            return nil, err
+        }
+        sessions = append(sessions, session)
+    }
+    return sessions, rows.Err()
+}
+
+func scanSession(row rowScanner) (*models.Session, error) {
+    session := &models.Session{}
+    err := row.Scan(
+        &session.ID,
+        &session.UserID,
+        &session.ClientName,
+        &session.UserAgent,
+        &session.IPAddress,
+        &session.CreatedAt,
+        &session.LastSeenAt,
+        &session.ExpiresAt,
+        &session.RevokedAt,
+    )
+    return session, err
+}
+
+// RevokeSession revokes a session with its tokens
+func (r *TokenRepositoryImpl) RevokeSession(id string) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if err := revokeSessions(tx, `id = $1`, id); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
+
+// RevokeOtherSessions revokes every session of a user but the given one, or
+// all of them when keepID is empty, returning how many it revoked
+func (r *TokenRepositoryImpl) RevokeOtherSessions(userID, keepID string) (int64, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return 0, err
+    }
+    defer tx.Rollback()
+
+    var count int64
+    err = tx.QueryRow(`
+        SELECT COUNT(*) FROM sessions
+        WHERE user_id = $1 AND id IS DISTINCT FROM NULLIF($2, '')::uuid
+            AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+    `, userID, keepID).Scan(&count)
+    if err != nil {
+        return 0, err
+    }
+
+    if err := revokeSessions(tx, `user_id = $1 AND id IS DISTINCT FROM NULLIF($2, '')::uuid`, userID, keepID); err != nil {
+        return 0, err
+    }
+    return count, tx.Commit()
+}
+
+// revokeSessions revokes the sessions matching a condition on the sessions
+// table along with their refresh tokens. Their access tokens stop working
+// with them, as the auth middleware checks the session of each token.
+func revokeSessions(tx *sql.Tx, condition string, args ...interface{}) error {
+    _, err := tx.Exec(`
+        UPDATE refresh_tokens
+        SET revoked_at = CURRENT_TIMESTAMP
+        WHERE revoked_at IS NULL
+            AND session_id IN (SELECT id FROM sessions WHERE `+condition+`)
+    `, args...)
+    if err != nil {
+        return err
+    }
+
+    _, err = tx.Exec(`
+        UPDATE sessions
+        SET revoked_at = CURRENT_TIMESTAMP
+        WHERE revoked_at IS NULL AND `+condition, args...)
+    return err
+}
+
+// UpdatePassword changes a user's password, ending every session but the
+// given one, or all of them when keepSessionID is empty, and voiding pending
+// password resets
+func (r *TokenRepositoryImpl) UpdatePassword(userID, passwordHash, keepSessionID string) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if err := setPassword(tx, userID, passwordHash); err != nil {
+        return err
+    }
+    if err := revokeSessions(tx, `user_id = $1 AND id IS DISTINCT FROM NULLIF($2, '')::uuid`, userID, keepSessionID); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
+
+// CreatePasswordReset stores a password reset
+func (r *TokenRepositoryImpl) CreatePasswordReset(reset *models.PasswordReset) error {
+    query := `
+        INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at
+    `
+
+    return r.db.QueryRow(query, reset.UserID, reset.TokenHash, reset.ExpiresAt).Scan(&reset.ID, &reset.CreatedAt)
+}
+
+// GetPasswordResetByHash retrieves a password reset by the hash of its token
+func (r *TokenRepositoryImpl) GetPasswordResetByHash(hash string) (*models.PasswordReset, error) {
+    query := `
+        SELECT id, user_id, token_hash, expires_at, used_at, created_at
+        FROM password_reset_tokens
+        WHERE token_hash = $1
+    `
+
+    reset := &models.PasswordReset{}
+    err := r.db.QueryRow(query, hash).Scan(
+        &reset.ID,
+        &reset.UserID,
+        &reset.TokenHash,
+        &reset.ExpiresAt,
+        &reset.UsedAt,
+        &reset.CreatedAt,
+    )
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    return reset, nil
+}
+
+// ResetPassword uses a password reset to set a new password, ending every
+// session of the user. It reports false, changing nothing, when the reset was
+// used or expired in the meantime.
+func (r *TokenRepositoryImpl) ResetPassword(resetID, passwordHash string) (bool, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    var userID string
+    err = tx.QueryRow(`
+        UPDATE password_reset_tokens
+        SET used_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+        RETURNING user_id
+    `, resetID).Scan(&userID)
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return false, nil
+        }
+        return false, err
+    }
+
+    if err := setPassword(tx, userID, passwordHash); err != nil {
+        return false, err
+    }
+    if err := revokeSessions(tx, `user_id = $1`, userID); err != nil {
+        return false, err
+    }
+    return true, tx.Commit()
+}
+
+// setPassword changes a user's password and voids their unused password resets
+func setPassword(tx *sql.Tx, userID, passwordHash string) error {
+    _, err := tx.Exec(`
+        UPDATE users
+        SET password_hash = $1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $2
+    `, passwordHash, userID)
+    if err != nil {
+        return err
+    }
+
+    _, err = tx.Exec(`
+        UPDATE password_reset_tokens
+        SET used_at = CURRENT_TIMESTAMP
+        WHERE user_id = $1 AND used_at IS NULL
+    `, userID)
+    return err
+}
+
+// RevokeAccessToken revokes a single access token until it expires
+func (r *TokenRepositoryImpl) RevokeAccessToken(jti string, expiresAt time.Time) error {
+    _, err := r.db.Exec(`
+        INSERT INTO revoked_access_tokens (jti, expires_at)
+        VALUES ($1, $2)
+        ON CONFLICT (jti) DO NOTHING
+    `, jti, expiresAt)
+    return err
+}
+
+// IsAccessTokenRevoked reports whether an access token was revoked, by itself
+// or with its session. Tokens issued before sessions existed have no session.
+func (r *TokenRepositoryImpl) IsAccessTokenRevoked(jti, sessionID string) (bool, error) {
+    query := `
+        SELECT EXISTS (SELECT 1 FROM revoked_access_tokens WHERE jti = $1)
+            OR EXISTS (SELECT 1 FROM sessions WHERE id = NULLIF($2, '')::uuid AND revoked_at IS NOT NULL)
+    `
+
+    var revoked bool
+    err := r.db.QueryRow(query, jti, sessionID).Scan(&revoked)
+    return revoked, err
+}
+
+// DeleteExpiredTokens removes sessions, refresh tokens, revocations, password
+// resets and two-factor login challenges that expired before the given time,
+// returning how many rows went
+func (r *TokenRepositoryImpl) DeleteExpiredTokens(before time.Time) (int64, error) {
+    var deleted int64
+    for _, query := range []string{
+        `DELETE FROM refresh_tokens WHERE expires_at < $1`,
+        `DELETE FROM sessions WHERE expires_at < $1`,
+        `DELETE FROM revoked_access_tokens WHERE expires_at < $1`,
+        `DELETE FROM password_reset_tokens WHERE expires_at < $1`,
+        `DELETE FROM mfa_challenges WHERE expires_at < $1`,
+    } {
+        result, err := r.db.Exec(query, before)
+        if err != nil {
+            return deleted, err
+        }
+        count, err := result.RowsAffected()
+        if err != nil {
+            return deleted, err
+        }
+        deleted += count
+    }
+    return deleted, nil
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "pocketpilot/internal/models"
+)
+
+type TwoFactorRepositoryImpl struct {
+    db *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) *TwoFactorRepositoryImpl {
+    return &TwoFactorRepositoryImpl{db: db}
+}
+
+// SetPendingTOTPSecret stores the secret of a two-factor setup that still has
+// to be confirmed with a code, replacing any earlier pending one. It reports
+// false when two-factor authentication is already on.
+func (r *TwoFactorRepositoryImpl) SetPendingTOTPSecret(userID, secret string) (bool, error) {
+    query := `
+        UPDATE users
+        SET totp_secret = $2, totp_last_step = NULL, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND totp_enabled_at IS NULL
+    `
+
+    result, err := r.db.Exec(query, userID, secret)
+    if err != nil {
+        return false, err
+    }
+    updated, err := result.RowsAffected()
+    return updated == 1, err
+}
+
+// EnableTwoFactor turns on two-factor authentication with the pending secret,
+// which was just confirmed with the code of the given step, and replaces the
+// recovery codes. It reports false, changing nothing, when it is already on
+// or the secret changed in the meantime.
+func (r *TwoFactorRepositoryImpl) EnableTwoFactor(userID, secret string, step int64, codeHashes []string) (bool, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    result, err := tx.Exec(`
+        UPDATE users
+        SET totp_enabled_at = CURRENT_TIMESTAMP, totp_last_step = $3, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND totp_secret = $2 AND totp_enabled_at IS NULL
+    `, userID, secret, step)
+    if err != nil {
+        return false, err
+    }
+    enabled, err := result.RowsAffected()
+    if err != nil || enabled != 1 {
+        return false, err
+    }
+
+    if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+        return false, err
+    }
+    for _, hash := range codeHashes {
+        _, err := tx.Exec(`INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash)
+        if err != nil {
+            return false, err
+        }
+    }
+
+    return true, tx.Commit()
+}
+
+// DisableTwoFactor turns off two-factor authentication, forgetting the secret
+// and the recovery codes
+func (r *TwoFactorRepositoryImpl) DisableTwoFactor(userID string) error {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    _, err = tx.Exec(`
+        UPDATE users
+        SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `, userID)
+    if err != nil {
+        return err
+    }
+    if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+        return err
+    }
+    if _, err := tx.Exec(`DELETE FROM mfa_challenges WHERE user_id = $1`, userID); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// UseTOTPStep records that the code of a time step was used. It reports false
+// when a code of that step or a later one was used already, so a code cannot
+// be replayed.
+func (r *TwoFactorRepositoryImpl) UseTOTPStep(userID string, step int64) (bool, error) {
+    query := `
+        UPDATE users SET totp_last_step = $2
+        WHERE id = $1 AND totp_enabled_at IS NOT NULL AND (totp_last_step IS NULL OR totp_last_step < $2)
+    `
+
+    result, err := r.db.Exec(query, userID, step)
+    if err != nil {
+        return false, err
+    }
+    used, err := result.RowsAffected()
+    return used == 1, err
+}
+
+// UseRecoveryCode uses up a recovery code of a user by its hash. It reports
+// false when there is no such code or it was used already.
+func (r *TwoFactorRepositoryImpl) UseRecoveryCode(userID, codeHash string) (bool, error) {
+    query := `
+        UPDATE recovery_codes SET used_at = CURRENT_TIMESTAMP
+        WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+    `
+
+    result, err := r.db.Exec(query, userID, codeHash)
+    if err != nil {
+        return false, err
+    }
+    used, err := result.RowsAffected()
+    return used == 1, err
+}
+
+// CreateMFAChallenge stores a login waiting for its second factor
+func (r *TwoFactorRepositoryImpl) CreateMFAChallenge(challenge *models.MFAChallenge) error {
+    query := `
+        INSERT INTO mfa_challenges (user_id, token_hash, expires_at)
+        VALUES ($1, $2, $3)
+        RETURNING id, attempts, created_at
+    `
+
+    return r.db.QueryRow(query, challenge.UserID, challenge.TokenHash, challenge.ExpiresAt).Scan(&challenge.ID, &challenge.Attempts, &challenge.CreatedAt)
+}
+
+// GetMFAChallengeByHash retrieves a login challenge by the hash of its token
+func (r *TwoFactorRepositoryImpl) GetMFAChallengeByHash(hash string) (*models.MFAChallenge, error) {
+    query := `
+        SELECT id, user_id, token_hash, attempts, expires_at, created_at
+        FROM mfa_challenges
+        WHERE token_hash = $1
+    `
+
+    challenge := &models.MFAChallenge{}
+    err := r.db.QueryRow(query, hash).Scan(
+        &challenge.ID,
+        &challenge.UserID,
+        &challenge.TokenHash,
+        &challenge.Attempts,
+        &challenge.ExpiresAt,
+        &challenge.CreatedAt,
+    )
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    return challenge, nil
+}
+
+// RecordMFAAttempt counts a code entered for a login challenge. It reports
+// false when the challenge expired or already had maxAttempts codes.
+func (r *TwoFactorRepositoryImpl) RecordMFAAttempt(id string, maxAttempts int) (bool, error) {
+    query := `
+        UPDATE mfa_challenges SET attempts = attempts + 1
+        WHERE id = $1 AND attempts < $2 AND expires_at > CURRENT_TIMESTAMP
+    `
+
+    result, err := r.db.Exec(query, id, maxAttempts)
+    if err != nil {
+        return false, err
+    }
+    recorded, err := result.RowsAffected()
+    return recorded == 1, err
+}
+
+// DeleteMFAChallenge ends a login challenge. It reports false when it was
+// already gone, finished by a concurrent request.
+func (r *TwoFactorRepositoryImpl) DeleteMFAChallenge(id string) (bool, error) {
+    result, err := r.db.Exec(`DELETE FROM mfa_challenges WHERE id = $1`, id)
+    if err != nil {
+        return false, err
+    }
+    deleted, err := result.RowsAffected()
+    return deleted == 1, err
+}
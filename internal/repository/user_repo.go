@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"pocketpilot/internal/models"
+	"time"
 )
 
 type UserRepositoryImpl struct {
@@ -17,8 +18,8 @@ func NewUserRepository(db *sql.DB) *UserRepositoryImpl {
 
 func (r *UserRepositoryImpl) CreateUser(user *models.User) error {
     query := `
-        INSERT INTO users (email, password_hash, first_name, last_name)
-        VALUES ($1, $2, $3, $4)
+        INSERT INTO users (email, password_hash, first_name, last_name, base_currency)
+        VALUES ($1, $2, $3, $4, $5)
         RETURNING id, created_at, updated_at
     `
     
@@ -28,6 +29,7 @@ func (r *UserRepositoryImpl) CreateUser(user *models.User) error {
         user.PasswordHash,
         user.FirstName,
         user.LastName,
+        user.BaseCurrency,
     ).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
     
     return err
@@ -35,7 +37,7 @@ func (r *UserRepositoryImpl) CreateUser(user *models.User) error {
 
 func (r *UserRepositoryImpl) GetUserByEmail(email string) (*models.User, error) {
     query := `
-        SELECT id, email, password_hash, first_name, last_name, created_at, updated_at
+        SELECT id, email, password_hash, first_name, last_name, base_currency, email_verified_at, email_verification_sent_at, totp_enabled_at, totp_secret, created_at, updated_at
         FROM users 
         WHERE email = $1
     `
@@ -47,6 +49,11 @@ func (r *UserRepositoryImpl) GetUserByEmail(email string) (*models.User, error)
         &user.PasswordHash,
         &user.FirstName,
         &user.LastName,
+        &user.BaseCurrency,
+        &user.EmailVerifiedAt,
+        &user.VerificationSentAt,
+        &user.TwoFactorEnabledAt,
+        &user.TOTPSecret,
         &user.CreatedAt,
         &user.UpdatedAt,
     )
@@ -63,7 +70,7 @@ func (r *UserRepositoryImpl) GetUserByEmail(email string) (*models.User, error)
 
 func (r *UserRepositoryImpl) GetUserByID(id string) (*models.User, error) {
     query := `
-        SELECT id, email, password_hash, first_name, last_name, created_at, updated_at
+        SELECT id, email, password_hash, first_name, last_name, base_currency, email_verified_at, email_verification_sent_at, totp_enabled_at, totp_secret, created_at, updated_at
         FROM users 
         WHERE id = $1
     `
@@ -75,6 +82,11 @@ func (r *UserRepositoryImpl) GetUserByID(id string) (*models.User, error) {
         &user.PasswordHash,
         &user.FirstName,
         &user.LastName,
+        &user.BaseCurrency,
+        &user.EmailVerifiedAt,
+        &user.VerificationSentAt,
+        &user.TwoFactorEnabledAt,
+        &user.TOTPSecret,
         &user.CreatedAt,
         &user.UpdatedAt,
     )
@@ -89,6 +101,18 @@ func (r *UserRepositoryImpl) GetUserByID(id string) (*models.User, error) {
     return user, nil
 }
 
+// UpdateUser updates a user's name and base currency
+func (r *UserRepositoryImpl) UpdateUser(user *models.User) error {
+    query := `
+        UPDATE users
+        SET first_name = $1, last_name = $2, base_currency = $3, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $4
+        RETURNING updated_at
+    `
+
+    return r.db.QueryRow(query, user.FirstName, user.LastName, user.BaseCurrency, user.ID).Scan(&user.UpdatedAt)
+}
+
 func (r *UserRepositoryImpl) EmailExists(email string) (bool, error) {
     query := `SELECT COUNT(*) FROM users WHERE email = $1`
     
@@ -99,4 +123,40 @@ func (r *UserRepositoryImpl) EmailExists(email string) (bool, error) {
     }
     
     return count > 0, nil
-}
\ No newline at end of file
+}
+
+// MarkEmailVerified records that a user verified their email, as long as it
+// is still the one that was verified. It reports false when nothing changed.
+func (r *UserRepositoryImpl) MarkEmailVerified(id, email string) (bool, error) {
+    query := `
+        UPDATE users
+        SET email_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND email = $2 AND email_verified_at IS NULL
+    `
+
+    result, err := r.db.Exec(query, id, email)
+    if err != nil {
+        return false, err
+    }
+    verified, err := result.RowsAffected()
+    return verified == 1, err
+}
+
+// ClaimVerificationEmail records that a verification email is being sent to
+// an unverified user, unless one was sent at or after sentBefore. It reports
+// whether the email may go out.
+func (r *UserRepositoryImpl) ClaimVerificationEmail(id string, sentBefore time.Time) (bool, error) {
+    query := `
+        UPDATE users
+        SET email_verification_sent_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND email_verified_at IS NULL
+            AND (email_verification_sent_at IS NULL OR email_verification_sent_at < $2)
+    `
+
+    result, err := r.db.Exec(query, id, sentBefore)
+    if err != nil {
+        return false, err
+    }
+    claimed, err := result.RowsAffected()
+    return claimed == 1, err
+}
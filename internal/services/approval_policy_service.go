@@ -0,0 +1,183 @@
+package services
+
+import (
+    "pocketpilot/internal/models"
+    "pocketpilot/pkg/money"
+    "strings"
+)
+
+// defaultApprovalSteps apply when no team policy matches: a single approval by an admin or owner
+var defaultApprovalSteps = []models.ApprovalPolicyStep{
+    {ApproverRole: stringPtr(models.TeamRoleAdmin)},
+}
+
+type ApprovalPolicyService struct {
+    policyRepo ApprovalPolicyRepository
+    teamRepo   TeamRepository
+    teamAuth   *TeamAuthorizer
+}
+
+func NewApprovalPolicyService(policyRepo ApprovalPolicyRepository, teamRepo TeamRepository) *ApprovalPolicyService {
+    return &ApprovalPolicyService{
+        policyRepo: policyRepo,
+        teamRepo:   teamRepo,
+        teamAuth:   NewTeamAuthorizer(teamRepo),
+    }
+}
+
+// GetTeamPolicies lists a team's approval policies in evaluation order
+func (s *ApprovalPolicyService) GetTeamPolicies(teamID, userID string) ([]*models.ApprovalPolicy, error) {
+    if _, err := s.teamAuth.Authorize(teamID, userID, PermReadTeamExpenses); err != nil {
+        return nil, err
+    }
+
+    return s.policyRepo.GetPoliciesByTeam(teamID)
+}
+
+// CreatePolicy adds an approval policy to a team, owners and admins only
+func (s *ApprovalPolicyService) CreatePolicy(teamID, userID string, req *models.ApprovalPolicyRequest) (*models.ApprovalPolicy, error) {
+    if _, err := s.teamAuth.Authorize(teamID, userID, PermManageApprovalPolicies); err != nil {
+        return nil, err
+    }
+
+    policy := &models.ApprovalPolicy{TeamID: teamID}
+    if err := s.applyRequest(policy, req); err != nil {
+        return nil, err
+    }
+
+    err := s.policyRepo.CreatePolicy(policy)
+    if err != nil {
+        return nil, err
+    }
+
+    return policy, nil
+}
+
+// UpdatePolicy replaces an approval policy's conditions and steps
+func (s *ApprovalPolicyService) UpdatePolicy(teamID, policyID, userID string, req *models.ApprovalPolicyRequest) (*models.ApprovalPolicy, error) {
+    if _, err := s.teamAuth.Authorize(teamID, userID, PermManageApprovalPolicies); err != nil {
+        return nil, err
+    }
+
+    policy, err := s.teamPolicy(teamID, policyID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.applyRequest(policy, req); err != nil {
+        return nil, err
+    }
+
+    err = s.policyRepo.UpdatePolicy(policy)
+    if err != nil {
+        return nil, err
+    }
+
+    return policy, nil
+}
+
+// DeletePolicy removes an approval policy from a team
+func (s *ApprovalPolicyService) DeletePolicy(teamID, policyID, userID string) error {
+    if _, err := s.teamAuth.Authorize(teamID, userID, PermManageApprovalPolicies); err != nil {
+        return err
+    }
+
+    if _, err := s.teamPolicy(teamID, policyID); err != nil {
+        return err
+    }
+
+    return s.policyRepo.DeletePolicy(policyID, teamID)
+}
+
+func (s *ApprovalPolicyService) teamPolicy(teamID, policyID string) (*models.ApprovalPolicy, error) {
+    policy, err := s.policyRepo.GetPolicyByID(policyID)
+    if err != nil {
+        return nil, err
+    }
+    if policy == nil || policy.TeamID != teamID {
+        return nil, ErrApprovalPolicyNotFound
+    }
+
+    return policy, nil
+}
+
+// applyRequest validates the request and copies it onto the policy
+func (s *ApprovalPolicyService) applyRequest(policy *models.ApprovalPolicy, req *models.ApprovalPolicyRequest) error {
+    named := make(map[string]bool)
+    for _, step := range req.Steps {
+        if (step.ApproverRole == nil) == (step.ApproverUserID == nil) {
+            return ErrInvalidApprovalStep
+        }
+        if step.ApproverRole != nil {
+            if !models.IsValidTeamRole(*step.ApproverRole) {
+                return ErrInvalidTeamRole
+            }
+            if !RoleHasPermission(*step.ApproverRole, PermApproveTeamExpense) {
+                return ErrInvalidApprover
+            }
+        }
+        if step.ApproverUserID != nil {
+            // One person cannot satisfy two steps of the same chain
+            if named[*step.ApproverUserID] {
+                return ErrDuplicateApprover
+            }
+            named[*step.ApproverUserID] = true
+
+            member, err := s.teamRepo.GetTeamMember(policy.TeamID, *step.ApproverUserID)
+            if err != nil {
+                return err
+            }
+            if member == nil {
+                return ErrTeamMemberNotFound
+            }
+            if !RoleHasPermission(member.Role, PermApproveTeamExpense) {
+                return ErrInvalidApprover
+            }
+        }
+    }
+
+    categories := make([]string, 0, len(req.Categories))
+    for _, category := range req.Categories {
+        if trimmed := strings.TrimSpace(category); trimmed != "" {
+            categories = append(categories, trimmed)
+        }
+    }
+
+    policy.Name = strings.TrimSpace(req.Name)
+    policy.MinAmount = req.MinAmount
+    policy.Categories = categories
+    policy.Priority = req.Priority
+    policy.Steps = req.Steps
+
+    return nil
+}
+
+// matchApprovalPolicy returns the first policy, in evaluation order, whose
+// conditions the expense meets. Minimum amounts are in the team's base
+// currency, which amount is the expense in; when it is nil, as no rate is
+// known, they are taken as met so the expense cannot skip a stricter chain.
+func matchApprovalPolicy(policies []*models.ApprovalPolicy, expense *models.Expense, amount *money.Amount) *models.ApprovalPolicy {
+    for _, policy := range policies {
+        if policy.MinAmount != nil && amount != nil && *amount < *policy.MinAmount {
+            continue
+        }
+        if len(policy.Categories) > 0 && !containsFold(policy.Categories, expense.Category) {
+            continue
+        }
+        return policy
+    }
+    return nil
+}
+
+func containsFold(values []string, value string) bool {
+    for _, v := range values {
+        if strings.EqualFold(v, value) {
+            return true
+        }
+    }
+    return false
+}
+
+func stringPtr(s string) *string {
+    return &s
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockApprovalPolicyRepository struct {
+	mock.Mock
+}
+
+func (m *MockApprovalPolicyRepository) CreatePolicy(policy *models.ApprovalPolicy) error {
+	args := m.Called(policy)
+	return args.Error(0)
+}
+
+func (m *MockApprovalPolicyRepository) GetPolicyByID(id string) (*models.ApprovalPolicy, error) {
+	args := m.Called(id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.ApprovalPolicy), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApprovalPolicyRepository) GetPoliciesByTeam(teamID string) ([]*models.ApprovalPolicy, error) {
+	args := m.Called(teamID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.ApprovalPolicy), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApprovalPolicyRepository) UpdatePolicy(policy *models.ApprovalPolicy) error {
+	args := m.Called(policy)
+	return args.Error(0)
+}
+
+func (m *MockApprovalPolicyRepository) DeletePolicy(id, teamID string) error {
+	args := m.Called(id, teamID)
+	return args.Error(0)
+}
+
+func amountPtr(s string) *money.Amount {
+	a := money.MustParse(s)
+	return &a
+}
+
+func TestMatchApprovalPolicy(t *testing.T) {
+	policies := []*models.ApprovalPolicy{
+		{ID: "travel", Categories: []string{"Travel"}, MinAmount: amountPtr("1000")},
+		{ID: "big", MinAmount: amountPtr("500")},
+		{ID: "meals", Categories: []string{"Food", "Meals"}},
+	}
+
+	tests := []struct {
+		name     string
+		expense  *models.Expense
+		expected string
+	}{
+		{"Small uncategorized expense", &models.Expense{Amount: money.MustParse("20"), Category: "Office"}, ""},
+		{"Large expense", &models.Expense{Amount: money.MustParse("500"), Category: "Office"}, "big"},
+		{"Large travel expense", &models.Expense{Amount: money.MustParse("1200"), Category: "travel"}, "travel"},
+		{"Small meal", &models.Expense{Amount: money.MustParse("15"), Category: "meals"}, "meals"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := matchApprovalPolicy(policies, tt.expense, &tt.expense.Amount)
+			if tt.expected == "" {
+				assert.Nil(t, policy)
+				return
+			}
+			require.NotNil(t, policy)
+			assert.Equal(t, tt.expected, policy.ID)
+		})
+	}
+
+	t.Run("Minimum amounts count as met without a rate", func(t *testing.T) {
+		policy := matchApprovalPolicy(policies, &models.Expense{Amount: money.MustParse("20"), Category: "Office"}, nil)
+
+		require.NotNil(t, policy)
+		assert.Equal(t, "big", policy.ID)
+	})
+}
+
+func TestApprovalPolicyService_CreatePolicy(t *testing.T) {
+	mockPolicyRepo := new(MockApprovalPolicyRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	policyService := NewApprovalPolicyService(mockPolicyRepo, mockTeamRepo)
+
+	mockTeamRepo.On("GetTeamMember", "team-1", "admin").Return(teamMember("team-1", "admin", models.TeamRoleAdmin), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "member").Return(teamMember("team-1", "member", models.TeamRoleMember), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "viewer").Return(teamMember("team-1", "viewer", models.TeamRoleViewer), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "finance").Return(teamMember("team-1", "finance", models.TeamRoleOwner), nil)
+
+	t.Run("Member cannot manage policies", func(t *testing.T) {
+		policy, err := policyService.CreatePolicy("team-1", "member", &models.ApprovalPolicyRequest{Name: "Big"})
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+		assert.Nil(t, policy)
+	})
+
+	t.Run("Step needs exactly one approver", func(t *testing.T) {
+		policy, err := policyService.CreatePolicy("team-1", "admin", &models.ApprovalPolicyRequest{
+			Name:  "Big",
+			Steps: []models.ApprovalPolicyStep{{ApproverRole: strPtr(models.TeamRoleAdmin), ApproverUserID: strPtr("member")}},
+		})
+
+		assert.ErrorIs(t, err, ErrInvalidApprovalStep)
+		assert.Nil(t, policy)
+	})
+
+	t.Run("Named approver must be a member", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "outsider").Return(nil, nil)
+
+		policy, err := policyService.CreatePolicy("team-1", "admin", &models.ApprovalPolicyRequest{
+			Name:  "Big",
+			Steps: []models.ApprovalPolicyStep{{ApproverUserID: strPtr("outsider")}},
+		})
+
+		assert.ErrorIs(t, err, ErrTeamMemberNotFound)
+		assert.Nil(t, policy)
+	})
+
+	t.Run("Role step must be able to approve", func(t *testing.T) {
+		for _, role := range []string{models.TeamRoleMember, models.TeamRoleViewer} {
+			policy, err := policyService.CreatePolicy("team-1", "admin", &models.ApprovalPolicyRequest{
+				Name:  "Big",
+				Steps: []models.ApprovalPolicyStep{{ApproverRole: strPtr(role)}},
+			})
+
+			assert.ErrorIs(t, err, ErrInvalidApprover, role)
+			assert.Nil(t, policy)
+		}
+	})
+
+	t.Run("Named approver must be able to approve", func(t *testing.T) {
+		for _, userID := range []string{"member", "viewer"} {
+			policy, err := policyService.CreatePolicy("team-1", "admin", &models.ApprovalPolicyRequest{
+				Name:  "Big",
+				Steps: []models.ApprovalPolicyStep{{ApproverUserID: strPtr(userID)}},
+			})
+
+			assert.ErrorIs(t, err, ErrInvalidApprover, userID)
+			assert.Nil(t, policy)
+		}
+	})
+
+	t.Run("Same approver named twice", func(t *testing.T) {
+		policy, err := policyService.CreatePolicy("team-1", "admin", &models.ApprovalPolicyRequest{
+			Name:  "Big",
+			Steps: []models.ApprovalPolicyStep{{ApproverUserID: strPtr("finance")}, {ApproverUserID: strPtr("finance")}},
+		})
+
+		assert.ErrorIs(t, err, ErrDuplicateApprover)
+		assert.Nil(t, policy)
+	})
+
+	t.Run("Admin creates policy", func(t *testing.T) {
+		mockPolicyRepo.On("CreatePolicy", mock.AnythingOfType("*models.ApprovalPolicy")).Return(nil).Once()
+
+		policy, err := policyService.CreatePolicy("team-1", "admin", &models.ApprovalPolicyRequest{
+			Name:       "Over 500",
+			MinAmount:  amountPtr("500"),
+			Categories: []string{" Travel ", ""},
+			Steps: []models.ApprovalPolicyStep{
+				{ApproverRole: strPtr(models.TeamRoleAdmin)},
+				{ApproverUserID: strPtr("finance")},
+			},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "team-1", policy.TeamID)
+		assert.Equal(t, []string{"Travel"}, policy.Categories)
+		assert.Len(t, policy.Steps, 2)
+	})
+}
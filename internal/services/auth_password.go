@@ -0,0 +1,98 @@
+package services
+
+import (
+    "fmt"
+    "log"
+    "net/url"
+    "pocketpilot/internal/models"
+    "pocketpilot/internal/utils"
+    "pocketpilot/pkg/mail"
+    "time"
+)
+
+// passwordResetTTL is how long a password reset link works
+const passwordResetTTL = time.Hour
+
+// ChangePassword sets a new password for a user who knows the current one.
+// Every other session is logged out, in case the old password leaked.
+func (s *AuthService) ChangePassword(userID, currentSessionID string, req *models.ChangePasswordRequest) error {
+    user, err := s.GetUserProfile(userID)
+    if err != nil {
+        return err
+    }
+    if !utils.CheckPasswordHash(req.CurrentPassword, user.PasswordHash) {
+        return ErrIncorrectPassword
+    }
+
+    hashedPassword, err := utils.HashPassword(req.NewPassword)
+    if err != nil {
+        return err
+    }
+    return s.tokenRepo.UpdatePassword(user.ID, hashedPassword, currentSessionID)
+}
+
+// ForgotPassword emails a password reset link to the user with the given
+// email. Whether such a user exists is not revealed, so unknown addresses and
+// failures to send are only logged.
+func (s *AuthService) ForgotPassword(req *models.ForgotPasswordRequest) error {
+    user, err := s.userRepo.GetUserByEmail(req.Email)
+    if err != nil {
+        return err
+    }
+    if user == nil {
+        return nil
+    }
+
+    token, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        return err
+    }
+    reset := &models.PasswordReset{
+        UserID:    user.ID,
+        TokenHash: utils.HashToken(token),
+        ExpiresAt: s.now().Add(passwordResetTTL),
+    }
+    if err := s.tokenRepo.CreatePasswordReset(reset); err != nil {
+        return err
+    }
+
+    link := s.appURL + "/reset-password?token=" + url.QueryEscape(token)
+    err = s.mailer.Send(&mail.Message{
+        To:      user.Email,
+        Subject: "Reset your PocketPilot password",
+        Body: fmt.Sprintf("Hi %s,\n\n"+
+            "Someone asked to reset the password of your PocketPilot account. "+
+            "To choose a new one, open this link within the next hour:\n\n%s\n\n"+
+            "If you did not ask for this, ignore this email and your password stays as it is.\n",
+            user.FirstName, link),
+    })
+    if err != nil {
+        log.Printf("Password reset email to user %s not sent: %v", user.ID, err)
+    }
+    return nil
+}
+
+// ResetPassword sets a new password with the token of a reset link. The link
+// works once, and every session of the user is logged out.
+func (s *AuthService) ResetPassword(req *models.ResetPasswordRequest) error {
+    reset, err := s.tokenRepo.GetPasswordResetByHash(utils.HashToken(req.Token))
+    if err != nil {
+        return err
+    }
+    if reset == nil || reset.UsedAt != nil || !s.now().Before(reset.ExpiresAt) {
+        return ErrInvalidResetToken
+    }
+
+    hashedPassword, err := utils.HashPassword(req.NewPassword)
+    if err != nil {
+        return err
+    }
+    done, err := s.tokenRepo.ResetPassword(reset.ID, hashedPassword)
+    if err != nil {
+        return err
+    }
+    if !done {
+        return ErrInvalidResetToken
+    }
+    return nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"net/url"
+	"pocketpilot/internal/models"
+	"pocketpilot/internal/utils"
+	"pocketpilot/pkg/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_ChangePassword(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockTokenRepository)
+	authService := NewAuthService(mockRepo, mockTokenRepo, new(MockTwoFactorRepository), mail.NewMemory(), "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
+
+	hashedPassword, _ := utils.HashPassword("old-password")
+	mockRepo.On("GetUserByID", "user-123").Return(&models.User{ID: "user-123", PasswordHash: hashedPassword}, nil)
+
+	t.Run("Logs out other sessions", func(t *testing.T) {
+		mockTokenRepo.On("UpdatePassword", "user-123", mock.MatchedBy(func(hash string) bool {
+			return utils.CheckPasswordHash("new-password", hash)
+		}), "session-1").Return(nil).Once()
+
+		err := authService.ChangePassword("user-123", "session-1", &models.ChangePasswordRequest{CurrentPassword: "old-password", NewPassword: "new-password"})
+
+		require.NoError(t, err)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Wrong current password", func(t *testing.T) {
+		err := authService.ChangePassword("user-123", "session-1", &models.ChangePasswordRequest{CurrentPassword: "guess", NewPassword: "new-password"})
+
+		assert.ErrorIs(t, err, ErrIncorrectPassword)
+	})
+}
+
+func TestAuthService_ForgotPassword(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockTokenRepository)
+	mailer := mail.NewMemory()
+	authService := NewAuthService(mockRepo, mockTokenRepo, new(MockTwoFactorRepository), mailer, "test-secret-key", "https://app.test/", 15*time.Minute, 30*24*time.Hour)
+
+	t.Run("Emails a reset link", func(t *testing.T) {
+		mockRepo.On("GetUserByEmail", "test@example.com").Return(&models.User{ID: "user-123", Email: "test@example.com", FirstName: "John"}, nil).Once()
+		var stored *models.PasswordReset
+		mockTokenRepo.On("CreatePasswordReset", mock.AnythingOfType("*models.PasswordReset")).Return(nil).Run(func(args mock.Arguments) {
+			stored = args.Get(0).(*models.PasswordReset)
+		}).Once()
+
+		require.NoError(t, authService.ForgotPassword(&models.ForgotPasswordRequest{Email: "test@example.com"}))
+
+		messages := mailer.Messages()
+		require.Len(t, messages, 1)
+		assert.Equal(t, "test@example.com", messages[0].To)
+
+		start := strings.Index(messages[0].Body, "https://app.test/reset-password?token=")
+		require.GreaterOrEqual(t, start, 0)
+		link, err := url.Parse(strings.Fields(messages[0].Body[start:])[0])
+		require.NoError(t, err)
+		token := link.Query().Get("token")
+		assert.Equal(t, utils.HashToken(token), stored.TokenHash)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+	})
+
+	t.Run("Unknown email is not revealed", func(t *testing.T) {
+		mockRepo.On("GetUserByEmail", "nobody@example.com").Return(nil, nil).Once()
+
+		require.NoError(t, authService.ForgotPassword(&models.ForgotPasswordRequest{Email: "nobody@example.com"}))
+		assert.Len(t, mailer.Messages(), 1)
+	})
+}
+
+func TestAuthService_ResetPassword(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	authService := NewAuthService(new(MockUserRepository), mockTokenRepo, new(MockTwoFactorRepository), mail.NewMemory(), "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
+
+	t.Run("Sets the new password", func(t *testing.T) {
+		mockTokenRepo.On("GetPasswordResetByHash", utils.HashToken("reset-1")).Return(&models.PasswordReset{ID: "reset-1", UserID: "user-123", ExpiresAt: time.Now().Add(time.Hour)}, nil).Once()
+		mockTokenRepo.On("ResetPassword", "reset-1", mock.MatchedBy(func(hash string) bool {
+			return utils.CheckPasswordHash("new-password", hash)
+		})).Return(true, nil).Once()
+
+		require.NoError(t, authService.ResetPassword(&models.ResetPasswordRequest{Token: "reset-1", NewPassword: "new-password"}))
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Used link", func(t *testing.T) {
+		usedAt := time.Now().Add(-time.Minute)
+		mockTokenRepo.On("GetPasswordResetByHash", utils.HashToken("reset-1")).Return(&models.PasswordReset{ID: "reset-1", UserID: "user-123", ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt}, nil).Once()
+
+		err := authService.ResetPassword(&models.ResetPasswordRequest{Token: "reset-1", NewPassword: "new-password"})
+
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+	})
+
+	t.Run("Expired link", func(t *testing.T) {
+		mockTokenRepo.On("GetPasswordResetByHash", utils.HashToken("reset-2")).Return(&models.PasswordReset{ID: "reset-2", UserID: "user-123", ExpiresAt: time.Now().Add(-time.Minute)}, nil).Once()
+
+		err := authService.ResetPassword(&models.ResetPasswordRequest{Token: "reset-2", NewPassword: "new-password"})
+
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+	})
+
+	t.Run("Used concurrently", func(t *testing.T) {
+		mockTokenRepo.On("GetPasswordResetByHash", utils.HashToken("reset-3")).Return(&models.PasswordReset{ID: "reset-3", UserID: "user-123", ExpiresAt: time.Now().Add(time.Hour)}, nil).Once()
+		mockTokenRepo.On("ResetPassword", "reset-3", mock.Anything).Return(false, nil).Once()
+
+		err := authService.ResetPassword(&models.ResetPasswordRequest{Token: "reset-3", NewPassword: "new-password"})
+
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+	})
+}
@@ -4,22 +4,43 @@ import (
 	"errors"
 	"pocketpilot/internal/models"
 	"pocketpilot/internal/utils"
+	"pocketpilot/pkg/mail"
+	"pocketpilot/pkg/money"
+	"strings"
+	"time"
 )
 
 type AuthService struct {
 	userRepo UserRepository
+	tokenRepo TokenRepository
+	twoFactorRepo TwoFactorRepository
+	mailer mail.Sender
 	jwtSecret string
+	appURL string
+	accessTTL time.Duration
+	refreshTTL time.Duration
+	now func() time.Time
 }
 
-func NewAuthService(userRepo UserRepository, jwtSecret string) *AuthService {
+// NewAuthService issues access tokens valid for accessTTL, along with refresh
+// tokens valid for refreshTTL that get new ones. Emails link to pages of the
+// web app at appURL.
+func NewAuthService(userRepo UserRepository, tokenRepo TokenRepository, twoFactorRepo TwoFactorRepository, mailer mail.Sender, jwtSecret, appURL string, accessTTL, refreshTTL time.Duration) *AuthService {
 	return &AuthService{
 		userRepo: userRepo,
+		tokenRepo: tokenRepo,
+		twoFactorRepo: twoFactorRepo,
+		mailer: mailer,
 		jwtSecret: jwtSecret,
+		appURL: strings.TrimSuffix(appURL, "/"),
+		accessTTL: accessTTL,
+		refreshTTL: refreshTTL,
+		now: time.Now,
 	}
 }
 
 //register new user account
-func (s *AuthService) Register(req *models.RegisterRequest) (*models.AuthResponse, error) {
+func (s *AuthService) Register(req *models.RegisterRequest, client *models.SessionClient) (*models.AuthResponse, error) {
 	exists, err := s.userRepo.EmailExists(req.Email)
 	if err != nil {
 		return nil,err
@@ -29,6 +50,14 @@ func (s *AuthService) Register(req *models.RegisterRequest) (*models.AuthRespons
 		return nil, errors.New("email already registered")
 	}
 
+	baseCurrency := defaultBaseCurrency
+	if req.BaseCurrency != "" {
+		baseCurrency, err = money.NormalizeCurrency(req.BaseCurrency)
+		if err != nil {
+			return nil, ErrInvalidCurrency
+		}
+	}
+
 	//hassh the pssword
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
@@ -41,6 +70,7 @@ func (s *AuthService) Register(req *models.RegisterRequest) (*models.AuthRespons
         PasswordHash: hashedPassword,
         FirstName:    req.FirstName,
         LastName:     req.LastName,
+        BaseCurrency: baseCurrency,
     }
 
 	err = s.userRepo.CreateUser(user)
@@ -48,46 +78,160 @@ func (s *AuthService) Register(req *models.RegisterRequest) (*models.AuthRespons
 		return nil,err
 	}
 
-	//generate jwt token
-	token, err := utils.GenerateToken(user.ID,user.Email,s.jwtSecret)
-	if err != nil {
-		return nil,err
-	}
-
-	    return &models.AuthResponse{
-        Token: token,
-        User:  user,
-    }, nil
+	s.sendVerificationEmail(user)
 
+	return s.startSession(user, client)
 }
 
 
-func (s *AuthService) Login(req *models.LoginRequest) (*models.AuthResponse, error) {
+// Login checks the user's password and starts a session. Users with
+// two-factor authentication get a challenge instead, to finish with
+// CompleteMFALogin.
+func (s *AuthService) Login(req *models.LoginRequest, client *models.SessionClient) (*models.AuthResponse, *models.MFAChallengeResponse, error) {
 	user, err := s.userRepo.GetUserByEmail(req.Email)
 
 	if err != nil {
-		return nil,err
+		return nil, nil, err
 	}
 
 	if user == nil {
-		return nil, errors.New("invalid email or password")
+		return nil, nil, errors.New("invalid email or password")
 	}
 
 	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
-		return nil , errors.New("invalid password")
+		return nil, nil, errors.New("invalid password")
+	}
+
+	if user.TwoFactorEnabledAt != nil {
+		challenge, err := s.startMFAChallenge(user)
+		return nil, challenge, err
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Email, s.jwtSecret)
+	authResponse, err := s.startSession(user, client)
+	return authResponse, nil, err
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token, in the same session. Each refresh token works once: presenting one
+// that was already exchanged means it leaked, so its whole session is revoked.
+func (s *AuthService) Refresh(refreshToken string, client *models.SessionClient) (*models.AuthResponse, error) {
+	token, err := s.tokenRepo.GetRefreshTokenByHash(utils.HashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if token.RotatedAt != nil {
+		return nil, s.revokeReusedSession(token)
+	}
+	if !s.now().Before(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetUserByID(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	next, value, err := s.newRefreshToken(user.ID, token.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := s.tokenRepo.RotateRefreshToken(token.ID, next, client)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// exchanged or revoked since it was read, by a concurrent refresh
+		return nil, s.revokeReusedSession(token)
+	}
+	return s.authResponse(user, next, value)
+}
+
+// Logout ends the session of the access token it is called with. Tokens
+// from before sessions existed are revoked by themselves.
+func (s *AuthService) Logout(accessTokenID, sessionID string) error {
+	if sessionID == "" {
+		return s.tokenRepo.RevokeAccessToken(accessTokenID, s.now().Add(s.accessTTL))
+	}
+	return s.tokenRepo.RevokeSession(sessionID)
+}
+
+// IsTokenRevoked reports whether an access token was revoked, by its jti or
+// along with its session
+func (s *AuthService) IsTokenRevoked(accessTokenID, sessionID string) (bool, error) {
+	return s.tokenRepo.IsAccessTokenRevoked(accessTokenID, sessionID)
+}
+
+// PruneExpiredTokens forgets sessions and tokens that have expired, returning
+// how many went
+func (s *AuthService) PruneExpiredTokens() (int64, error) {
+	return s.tokenRepo.DeleteExpiredTokens(s.now())
+}
+
+// startSession starts a session for a login on a device, with its first tokens
+func (s *AuthService) startSession(user *models.User, client *models.SessionClient) (*models.AuthResponse, error) {
+	token, value, err := s.newRefreshToken(user.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	session := &models.Session{
+		UserID:     user.ID,
+		ClientName: optionalString(client.ClientName, maxClientNameLength),
+		UserAgent:  optionalString(client.UserAgent, maxUserAgentLength),
+		IPAddress:  optionalString(client.IPAddress, maxIPAddressLength),
+		ExpiresAt:  token.ExpiresAt,
+	}
+	if err := s.tokenRepo.CreateSession(session, token); err != nil {
+		return nil, err
+	}
+	return s.authResponse(user, token, value)
+}
+
+// newRefreshToken makes a refresh token for a session, which is set when it
+// starts a new one, returning the record to store and the value to hand out
+func (s *AuthService) newRefreshToken(userID, sessionID string) (*models.RefreshToken, string, error) {
+	value, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := s.now()
+	return &models.RefreshToken{
+		UserID:          userID,
+		SessionID:       sessionID,
+		TokenHash:       utils.HashToken(value),
+		AccessExpiresAt: now.Add(s.accessTTL),
+		ExpiresAt:       now.Add(s.refreshTTL),
+	}, value, nil
+}
+
+// authResponse signs the access token that goes with a stored refresh token
+func (s *AuthService) authResponse(user *models.User, token *models.RefreshToken, refreshToken string) (*models.AuthResponse, error) {
+	accessToken, err := utils.GenerateToken(user.ID, user.Email, token.AccessTokenID, token.SessionID, s.jwtSecret, token.AccessExpiresAt)
 	if err != nil {
 		return nil, err
 	}
 
 	return &models.AuthResponse{
-		Token: token,
-		User: user,
+		Token:            accessToken,
+		ExpiresAt:        token.AccessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: token.ExpiresAt,
+		User:             user,
 	}, nil
 }
 
+func (s *AuthService) revokeReusedSession(token *models.RefreshToken) error {
+	if err := s.tokenRepo.RevokeSession(token.SessionID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
 
 func (s *AuthService) GetUserProfile(userID string) (*models.User, error) {
     user, err := s.userRepo.GetUserByID(userID)
@@ -99,4 +243,38 @@ func (s *AuthService) GetUserProfile(userID string) (*models.User, error) {
     }
 
     return user, nil
-}
\ No newline at end of file
+}
+
+// UpdateProfile changes the user's name or the base currency their personal
+// expenses are converted to
+func (s *AuthService) UpdateProfile(userID string, req *models.UpdateProfileRequest) (*models.User, error) {
+    user, err := s.GetUserProfile(userID)
+    if err != nil {
+        return nil, err
+    }
+
+    if req.FirstName != nil {
+        if strings.TrimSpace(*req.FirstName) == "" {
+            return nil, errors.New("first name must not be empty")
+        }
+        user.FirstName = *req.FirstName
+    }
+    if req.LastName != nil {
+        if strings.TrimSpace(*req.LastName) == "" {
+            return nil, errors.New("last name must not be empty")
+        }
+        user.LastName = *req.LastName
+    }
+    if req.BaseCurrency != nil {
+        currency, err := money.NormalizeCurrency(*req.BaseCurrency)
+        if err != nil {
+            return nil, ErrInvalidCurrency
+        }
+        user.BaseCurrency = currency
+    }
+
+    if err := s.userRepo.UpdateUser(user); err != nil {
+        return nil, err
+    }
+    return user, nil
+}
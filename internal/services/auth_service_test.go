@@ -3,7 +3,9 @@ package services
 import (
 	"pocketpilot/internal/models"
 	"pocketpilot/internal/utils"
+	"pocketpilot/pkg/mail"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -35,14 +37,120 @@ func (m *MockUserRepository) GetUserByID(id string) (*models.User, error) {
 	return nil, args.Error(1)
 }
 
+func (m *MockUserRepository) UpdateUser(user *models.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) EmailExists(email string) (bool, error) {
 	args := m.Called(email)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockUserRepository) MarkEmailVerified(id, email string) (bool, error) {
+	args := m.Called(id, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) ClaimVerificationEmail(id string, sentBefore time.Time) (bool, error) {
+	args := m.Called(id, sentBefore)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockTokenRepository) CreateSession(session *models.Session, token *models.RefreshToken) error {
+	args := m.Called(session, token)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) GetSessionByID(id string) (*models.Session, error) {
+	args := m.Called(id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Session), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTokenRepository) GetActiveSessions(userID string) ([]*models.Session, error) {
+	args := m.Called(userID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Session), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTokenRepository) RevokeSession(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) RevokeOtherSessions(userID, keepID string) (int64, error) {
+	args := m.Called(userID, keepID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTokenRepository) GetRefreshTokenByHash(hash string) (*models.RefreshToken, error) {
+	args := m.Called(hash)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.RefreshToken), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTokenRepository) RotateRefreshToken(id string, next *models.RefreshToken, client *models.SessionClient) (bool, error) {
+	args := m.Called(id, next, client)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenRepository) UpdatePassword(userID, passwordHash, keepSessionID string) error {
+	args := m.Called(userID, passwordHash, keepSessionID)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) CreatePasswordReset(reset *models.PasswordReset) error {
+	args := m.Called(reset)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) GetPasswordResetByHash(hash string) (*models.PasswordReset, error) {
+	args := m.Called(hash)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.PasswordReset), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTokenRepository) ResetPassword(resetID, passwordHash string) (bool, error) {
+	args := m.Called(resetID, passwordHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenRepository) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	args := m.Called(jti, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) IsAccessTokenRevoked(jti, sessionID string) (bool, error) {
+	args := m.Called(jti, sessionID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenRepository) DeleteExpiredTokens(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// testClient is the device the tests log in from
+var testClient = &models.SessionClient{ClientName: "Work laptop", UserAgent: "Mozilla/5.0", IPAddress: "203.0.113.7"}
+
 func TestAuthService_Register(t *testing.T) {
     mockRepo := new(MockUserRepository)
-    authService := NewAuthService(mockRepo, "test-secret-key")
+    mockTokenRepo := new(MockTokenRepository)
+    mailer := mail.NewMemory()
+    authService := NewAuthService(mockRepo, mockTokenRepo, new(MockTwoFactorRepository), mailer, "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
 
     registerReq := &models.RegisterRequest{
         Email:     "test@example.com",
@@ -64,8 +172,11 @@ func TestAuthService_Register(t *testing.T) {
             assert.Equal(t, "Doe", user.LastName)
         })
 
+        mockRepo.On("ClaimVerificationEmail", "user-123", mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+        mockTokenRepo.On("CreateSession", mock.AnythingOfType("*models.Session"), mock.AnythingOfType("*models.RefreshToken")).Return(nil).Once()
+
         // Execute
-        authResponse, err := authService.Register(registerReq)
+        authResponse, err := authService.Register(registerReq, testClient)
 
         // Assert
         require.NoError(t, err)
@@ -74,6 +185,12 @@ func TestAuthService_Register(t *testing.T) {
         assert.Equal(t, "user-123", authResponse.User.ID)
         assert.Equal(t, "test@example.com", authResponse.User.Email)
 
+        // A verification link goes out with the new account
+        messages := mailer.Messages()
+        require.Len(t, messages, 1)
+        assert.Equal(t, "test@example.com", messages[0].To)
+        assert.Contains(t, messages[0].Body, "https://app.test/verify-email?token=user-123.")
+
         mockRepo.AssertExpectations(t)
     })
 
@@ -81,7 +198,7 @@ func TestAuthService_Register(t *testing.T) {
         mockRepo.On("EmailExists", "existing@example.com").Return(true, nil)
 
         registerReq.Email = "existing@example.com"
-        authResponse, err := authService.Register(registerReq)
+        authResponse, err := authService.Register(registerReq, testClient)
 
         assert.Error(t, err)
         assert.Nil(t, authResponse)
@@ -94,7 +211,7 @@ func TestAuthService_Register(t *testing.T) {
         mockRepo.On("EmailExists", "error@example.com").Return(false, assert.AnError)
 
         registerReq.Email = "error@example.com"
-        authResponse, err := authService.Register(registerReq)
+        authResponse, err := authService.Register(registerReq, testClient)
 
         assert.Error(t, err)
         assert.Nil(t, authResponse)
@@ -105,7 +222,8 @@ func TestAuthService_Register(t *testing.T) {
 
 func TestAuthService_Login(t *testing.T) {
     mockRepo := new(MockUserRepository)
-    authService := NewAuthService(mockRepo, "test-secret-key")
+    mockTokenRepo := new(MockTokenRepository)
+    authService := NewAuthService(mockRepo, mockTokenRepo, new(MockTwoFactorRepository), mail.NewMemory(), "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
 
     loginReq := &models.LoginRequest{
         Email:    "test@example.com",
@@ -124,12 +242,28 @@ func TestAuthService_Login(t *testing.T) {
         }
 
         mockRepo.On("GetUserByEmail", "test@example.com").Return(mockUser, nil)
-
-        authResponse, err := authService.Login(loginReq)
+        mockTokenRepo.On("CreateSession", mock.MatchedBy(func(session *models.Session) bool {
+            return session.UserID == "user-123" && *session.ClientName == "Work laptop" && *session.IPAddress == "203.0.113.7"
+        }), mock.MatchedBy(func(token *models.RefreshToken) bool {
+            return token.UserID == "user-123" && len(token.TokenHash) == 64
+        })).Return(nil).Run(func(args mock.Arguments) {
+            token := args.Get(1).(*models.RefreshToken)
+            token.SessionID = "session-1"
+            token.AccessTokenID = "jti-1"
+        }).Once()
+
+        authResponse, challenge, err := authService.Login(loginReq, testClient)
 
         require.NoError(t, err)
+        assert.Nil(t, challenge)
         require.NotNil(t, authResponse)
         assert.NotEmpty(t, authResponse.Token)
+        assert.NotEmpty(t, authResponse.RefreshToken)
+        claims, err := utils.ValidateToken(authResponse.Token, "test-secret-key")
+        require.NoError(t, err)
+        assert.Equal(t, "jti-1", claims.ID)
+        assert.Equal(t, "session-1", claims.SessionID)
+        assert.WithinDuration(t, time.Now().Add(15*time.Minute), authResponse.ExpiresAt, time.Minute)
         assert.Equal(t, "user-123", authResponse.User.ID)
         assert.Equal(t, "test@example.com", authResponse.User.Email)
 
@@ -140,7 +274,7 @@ func TestAuthService_Login(t *testing.T) {
         mockRepo.On("GetUserByEmail", "nonexistent@example.com").Return(nil, nil)
 
         loginReq.Email = "nonexistent@example.com"
-        authResponse, err := authService.Login(loginReq)
+        authResponse, _, err := authService.Login(loginReq, testClient)
 
         assert.Error(t, err)
         assert.Nil(t, authResponse)
@@ -161,7 +295,7 @@ func TestAuthService_Login(t *testing.T) {
 
         mockRepo.On("GetUserByEmail", "test@example.com").Return(mockUser, nil)
 
-        authResponse, err := authService.Login(loginReq)
+        authResponse, _, err := authService.Login(loginReq, testClient)
 
         assert.Error(t, err)
         assert.Nil(t, authResponse)
@@ -174,7 +308,7 @@ func TestAuthService_Login(t *testing.T) {
         mockRepo.On("GetUserByEmail", "error@example.com").Return(nil, assert.AnError)
 
         loginReq.Email = "error@example.com"
-        authResponse, err := authService.Login(loginReq)
+        authResponse, _, err := authService.Login(loginReq, testClient)
 
         assert.Error(t, err)
         assert.Nil(t, authResponse)
@@ -186,7 +320,8 @@ func TestAuthService_Login(t *testing.T) {
 
 func TestAuthService_GetUserProfile(t *testing.T) {
     mockRepo := new(MockUserRepository)
-    authService := NewAuthService(mockRepo, "test-secret-key")
+    mockTokenRepo := new(MockTokenRepository)
+    authService := NewAuthService(mockRepo, mockTokenRepo, new(MockTwoFactorRepository), mail.NewMemory(), "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
 
     t.Run("Successful GetUserProfile", func(t *testing.T) {
         mockUser := &models.User{
@@ -229,3 +364,94 @@ func TestAuthService_GetUserProfile(t *testing.T) {
         mockRepo.AssertExpectations(t)
     })
 }
+
+func TestAuthService_Refresh(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockTokenRepository)
+	authService := NewAuthService(mockRepo, mockTokenRepo, new(MockTwoFactorRepository), mail.NewMemory(), "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
+
+	user := &models.User{ID: "user-123", Email: "test@example.com"}
+	mockRepo.On("GetUserByID", "user-123").Return(user, nil)
+	current := func() *models.RefreshToken {
+		return &models.RefreshToken{ID: "token-1", UserID: "user-123", SessionID: "session-1", ExpiresAt: time.Now().Add(time.Hour)}
+	}
+
+	t.Run("Rotates the token", func(t *testing.T) {
+		mockTokenRepo.On("GetRefreshTokenByHash", utils.HashToken("refresh-1")).Return(current(), nil).Once()
+		mockTokenRepo.On("RotateRefreshToken", "token-1", mock.MatchedBy(func(next *models.RefreshToken) bool {
+			return next.SessionID == "session-1" && next.TokenHash != utils.HashToken("refresh-1")
+		}), testClient).Return(true, nil).Run(func(args mock.Arguments) {
+			args.Get(1).(*models.RefreshToken).AccessTokenID = "jti-2"
+		}).Once()
+
+		authResponse, err := authService.Refresh("refresh-1", testClient)
+
+		require.NoError(t, err)
+		assert.NotEqual(t, "refresh-1", authResponse.RefreshToken)
+		claims, err := utils.ValidateToken(authResponse.Token, "test-secret-key")
+		require.NoError(t, err)
+		assert.Equal(t, "jti-2", claims.ID)
+		assert.Equal(t, "user-123", claims.UserID)
+	})
+
+	t.Run("Reuse revokes the session", func(t *testing.T) {
+		used := current()
+		usedAt := time.Now().Add(-time.Minute)
+		used.RotatedAt = &usedAt
+		mockTokenRepo.On("GetRefreshTokenByHash", utils.HashToken("refresh-1")).Return(used, nil).Once()
+		mockTokenRepo.On("RevokeSession", "session-1").Return(nil).Once()
+
+		_, err := authService.Refresh("refresh-1", testClient)
+
+		assert.ErrorIs(t, err, ErrRefreshTokenReused)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Concurrent refresh revokes the session", func(t *testing.T) {
+		mockTokenRepo.On("GetRefreshTokenByHash", utils.HashToken("refresh-1")).Return(current(), nil).Once()
+		mockTokenRepo.On("RotateRefreshToken", "token-1", mock.Anything, testClient).Return(false, nil).Once()
+		mockTokenRepo.On("RevokeSession", "session-1").Return(nil).Once()
+
+		_, err := authService.Refresh("refresh-1", testClient)
+
+		assert.ErrorIs(t, err, ErrRefreshTokenReused)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		expired := current()
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		mockTokenRepo.On("GetRefreshTokenByHash", utils.HashToken("refresh-1")).Return(expired, nil).Once()
+
+		_, err := authService.Refresh("refresh-1", testClient)
+
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	})
+
+	t.Run("Unknown token", func(t *testing.T) {
+		mockTokenRepo.On("GetRefreshTokenByHash", utils.HashToken("forged")).Return(nil, nil).Once()
+
+		_, err := authService.Refresh("forged", testClient)
+
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	})
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	authService := NewAuthService(new(MockUserRepository), mockTokenRepo, new(MockTwoFactorRepository), mail.NewMemory(), "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
+
+	t.Run("Ends the session", func(t *testing.T) {
+		mockTokenRepo.On("RevokeSession", "session-1").Return(nil).Once()
+
+		require.NoError(t, authService.Logout("jti-1", "session-1"))
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Revokes a token without session", func(t *testing.T) {
+		mockTokenRepo.On("RevokeAccessToken", "jti-2", mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		require.NoError(t, authService.Logout("jti-2", ""))
+		mockTokenRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+    "pocketpilot/internal/models"
+    "strings"
+    "unicode/utf8"
+)
+
+// Limits of the device details kept with a session, matching their columns
+const (
+    maxClientNameLength = 100
+    maxUserAgentLength  = 255
+    maxIPAddressLength  = 45
+)
+
+// GetSessions lists where a user is logged in, marking the session of the
+// request as current
+func (s *AuthService) GetSessions(userID, currentSessionID string) ([]*models.Session, error) {
+    sessions, err := s.tokenRepo.GetActiveSessions(userID)
+    if err != nil {
+        return nil, err
+    }
+    for _, session := range sessions {
+        session.Current = session.ID == currentSessionID
+    }
+    return sessions, nil
+}
+
+// RevokeSession logs a user out of one of their sessions, which may be the
+// current one
+func (s *AuthService) RevokeSession(userID, sessionID string) error {
+    session, err := s.tokenRepo.GetSessionByID(sessionID)
+    if err != nil {
+        return err
+    }
+    if session == nil || session.UserID != userID || session.RevokedAt != nil {
+        return ErrSessionNotFound
+    }
+    return s.tokenRepo.RevokeSession(session.ID)
+}
+
+// RevokeOtherSessions logs a user out everywhere but in the current session,
+// returning how many sessions ended
+func (s *AuthService) RevokeOtherSessions(userID, currentSessionID string) (int64, error) {
+    return s.tokenRepo.RevokeOtherSessions(userID, currentSessionID)
+}
+
+// optionalString trims a device detail to fit its column, with nil for none
+func optionalString(value string, maxLength int) *string {
+    value = strings.TrimSpace(value)
+    if value == "" {
+        return nil
+    }
+    if len(value) > maxLength {
+        value = value[:maxLength]
+        for !utf8.ValidString(value) {
+            value = value[:len(value)-1]
+        }
+    }
+    return &value
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_GetSessions(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	authService := NewAuthService(new(MockUserRepository), mockTokenRepo, new(MockTwoFactorRepository), mail.NewMemory(), "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
+
+	mockTokenRepo.On("GetActiveSessions", "user-123").Return([]*models.Session{
+		{ID: "session-1", UserID: "user-123"},
+		{ID: "session-2", UserID: "user-123"},
+	}, nil)
+
+	sessions, err := authService.GetSessions("user-123", "session-2")
+
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.False(t, sessions[0].Current)
+	assert.True(t, sessions[1].Current)
+}
+
+func TestAuthService_RevokeSession(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	authService := NewAuthService(new(MockUserRepository), mockTokenRepo, new(MockTwoFactorRepository), mail.NewMemory(), "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
+
+	mockTokenRepo.On("GetSessionByID", "session-1").Return(&models.Session{ID: "session-1", UserID: "user-123"}, nil)
+
+	t.Run("Own session", func(t *testing.T) {
+		mockTokenRepo.On("RevokeSession", "session-1").Return(nil).Once()
+
+		require.NoError(t, authService.RevokeSession("user-123", "session-1"))
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Session of another user", func(t *testing.T) {
+		err := authService.RevokeSession("user-456", "session-1")
+
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("Unknown session", func(t *testing.T) {
+		mockTokenRepo.On("GetSessionByID", "missing").Return(nil, nil).Once()
+
+		err := authService.RevokeSession("user-123", "missing")
+
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+	})
+}
+
+func TestAuthService_RevokeOtherSessions(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	authService := NewAuthService(new(MockUserRepository), mockTokenRepo, new(MockTwoFactorRepository), mail.NewMemory(), "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
+
+	mockTokenRepo.On("RevokeOtherSessions", "user-123", "session-1").Return(int64(2), nil).Once()
+
+	revoked, err := authService.RevokeOtherSessions("user-123", "session-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), revoked)
+	mockTokenRepo.AssertExpectations(t)
+}
+
+func TestOptionalString(t *testing.T) {
+	assert.Nil(t, optionalString("  ", maxUserAgentLength))
+	assert.Equal(t, "Work laptop", *optionalString(" Work laptop ", maxClientNameLength))
+
+	truncated := optionalString(strings.Repeat("é", 60), maxClientNameLength)
+	assert.Len(t, *truncated, maxClientNameLength)
+	assert.True(t, strings.HasSuffix(*truncated, "é"))
+}
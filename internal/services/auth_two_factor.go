@@ -0,0 +1,238 @@
+package services
+
+import (
+    "crypto/rand"
+    "encoding/base32"
+    "pocketpilot/internal/models"
+    "pocketpilot/internal/utils"
+    "pocketpilot/pkg/totp"
+    "strings"
+    "time"
+)
+
+const (
+    // totpIssuer names the account in authenticator apps
+    totpIssuer = "PocketPilot"
+    // totpSkew is how many 30 second steps a code may be off by, for clock
+    // drift and typing time
+    totpSkew = 1
+    // mfaChallengeTTL is how long a login waits for its second factor
+    mfaChallengeTTL = 5 * time.Minute
+    // maxMFAAttempts is how many codes a login challenge takes before the
+    // password has to be entered again
+    maxMFAAttempts = 5
+    // recoveryCodeCount is how many recovery codes enabling two-factor
+    // authentication hands out
+    recoveryCodeCount = 10
+)
+
+// SetupTwoFactor starts two-factor setup with a new secret for the user's
+// authenticator app. Nothing changes for logins until a code confirms it with
+// EnableTwoFactor.
+func (s *AuthService) SetupTwoFactor(userID string) (*models.TwoFactorSetup, error) {
+    user, err := s.GetUserProfile(userID)
+    if err != nil {
+        return nil, err
+    }
+    if user.TwoFactorEnabledAt != nil {
+        return nil, ErrTwoFactorEnabled
+    }
+
+    secret, err := totp.GenerateSecret()
+    if err != nil {
+        return nil, err
+    }
+    stored, err := s.twoFactorRepo.SetPendingTOTPSecret(user.ID, secret)
+    if err != nil {
+        return nil, err
+    }
+    if !stored {
+        return nil, ErrTwoFactorEnabled
+    }
+
+    return &models.TwoFactorSetup{
+        Secret:     secret,
+        OTPAuthURI: totp.URI(totpIssuer, user.Email, secret),
+    }, nil
+}
+
+// EnableTwoFactor turns on two-factor authentication once a code from the
+// authenticator app shows it was set up, returning the recovery codes
+func (s *AuthService) EnableTwoFactor(userID string, req *models.EnableTwoFactorRequest) (*models.RecoveryCodesResponse, error) {
+    user, err := s.GetUserProfile(userID)
+    if err != nil {
+        return nil, err
+    }
+    if user.TwoFactorEnabledAt != nil {
+        return nil, ErrTwoFactorEnabled
+    }
+    if user.TOTPSecret == nil {
+        return nil, ErrTwoFactorNotSetUp
+    }
+
+    step, ok := totp.Validate(*user.TOTPSecret, normalizeCode(req.Code), s.now(), totpSkew)
+    if !ok {
+        return nil, ErrInvalidTwoFactorCode
+    }
+
+    codes, hashes, err := newRecoveryCodes()
+    if err != nil {
+        return nil, err
+    }
+    enabled, err := s.twoFactorRepo.EnableTwoFactor(user.ID, *user.TOTPSecret, step, hashes)
+    if err != nil {
+        return nil, err
+    }
+    if !enabled {
+        // set up again or turned on by a concurrent request
+        return nil, ErrTwoFactorNotSetUp
+    }
+
+    return &models.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactor turns off two-factor authentication. Like logging in, it
+// takes the password and a code, so a stolen session alone cannot turn it off.
+func (s *AuthService) DisableTwoFactor(userID string, req *models.DisableTwoFactorRequest) error {
+    user, err := s.GetUserProfile(userID)
+    if err != nil {
+        return err
+    }
+    if user.TwoFactorEnabledAt == nil {
+        return ErrTwoFactorNotEnabled
+    }
+    if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+        return ErrIncorrectPassword
+    }
+    if err := s.checkSecondFactor(user, req.Code); err != nil {
+        return err
+    }
+
+    return s.twoFactorRepo.DisableTwoFactor(user.ID)
+}
+
+// CompleteMFALogin finishes a login that was challenged for a second factor,
+// with a code from the authenticator app or a recovery code
+func (s *AuthService) CompleteMFALogin(req *models.MFALoginRequest, client *models.SessionClient) (*models.AuthResponse, error) {
+    challenge, err := s.twoFactorRepo.GetMFAChallengeByHash(utils.HashToken(req.ChallengeToken))
+    if err != nil {
+        return nil, err
+    }
+    if challenge == nil || !s.now().Before(challenge.ExpiresAt) {
+        return nil, ErrInvalidMFAChallenge
+    }
+    counted, err := s.twoFactorRepo.RecordMFAAttempt(challenge.ID, maxMFAAttempts)
+    if err != nil {
+        return nil, err
+    }
+    if !counted {
+        return nil, ErrInvalidMFAChallenge
+    }
+
+    user, err := s.userRepo.GetUserByID(challenge.UserID)
+    if err != nil {
+        return nil, err
+    }
+    if user == nil || user.TwoFactorEnabledAt == nil {
+        return nil, ErrInvalidMFAChallenge
+    }
+    if err := s.checkSecondFactor(user, req.Code); err != nil {
+        return nil, err
+    }
+
+    finished, err := s.twoFactorRepo.DeleteMFAChallenge(challenge.ID)
+    if err != nil {
+        return nil, err
+    }
+    if !finished {
+        // finished by a concurrent request with another code
+        return nil, ErrInvalidMFAChallenge
+    }
+
+    return s.startSession(user, client)
+}
+
+// startMFAChallenge holds a login whose password checked out until the
+// second factor comes in
+func (s *AuthService) startMFAChallenge(user *models.User) (*models.MFAChallengeResponse, error) {
+    token, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        return nil, err
+    }
+    challenge := &models.MFAChallenge{
+        UserID:    user.ID,
+        TokenHash: utils.HashToken(token),
+        ExpiresAt: s.now().Add(mfaChallengeTTL),
+    }
+    if err := s.twoFactorRepo.CreateMFAChallenge(challenge); err != nil {
+        return nil, err
+    }
+
+    return &models.MFAChallengeResponse{
+        MFARequired:    true,
+        ChallengeToken: token,
+        ExpiresAt:      challenge.ExpiresAt,
+    }, nil
+}
+
+// checkSecondFactor accepts a current code from the authenticator app that
+// was not used yet, or an unused recovery code, which it uses up
+func (s *AuthService) checkSecondFactor(user *models.User, code string) error {
+    code = normalizeCode(code)
+
+    var used bool
+    var err error
+    if isTOTPCode(code) {
+        if user.TOTPSecret == nil {
+            return ErrInvalidTwoFactorCode
+        }
+        step, ok := totp.Validate(*user.TOTPSecret, code, s.now(), totpSkew)
+        if !ok {
+            return ErrInvalidTwoFactorCode
+        }
+        used, err = s.twoFactorRepo.UseTOTPStep(user.ID, step)
+    } else {
+        used, err = s.twoFactorRepo.UseRecoveryCode(user.ID, utils.HashToken(code))
+    }
+    if err != nil {
+        return err
+    }
+    if !used {
+        return ErrInvalidTwoFactorCode
+    }
+    return nil
+}
+
+// newRecoveryCodes returns recovery codes to show once, as xxxx-xxxx, along
+// with the hashes to store
+func newRecoveryCodes() ([]string, []string, error) {
+    codes := make([]string, recoveryCodeCount)
+    hashes := make([]string, recoveryCodeCount)
+    for i := range codes {
+        random := make([]byte, 5)
+        if _, err := rand.Read(random); err != nil {
+            return nil, nil, err
+        }
+        code := strings.ToLower(base32.StdEncoding.EncodeToString(random))
+        codes[i] = code[:4] + "-" + code[4:]
+        hashes[i] = utils.HashToken(code)
+    }
+    return codes, hashes, nil
+}
+
+// normalizeCode drops the spaces and dashes codes are typed with
+func normalizeCode(code string) string {
+    return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+func isTOTPCode(code string) bool {
+    if len(code) != totp.Digits {
+        return false
+    }
+    for _, r := range code {
+        if r < '0' || r > '9' {
+            return false
+        }
+    }
+    return true
+}
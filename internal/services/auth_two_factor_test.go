@@ -0,0 +1,264 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"pocketpilot/internal/utils"
+	"pocketpilot/pkg/mail"
+	"pocketpilot/pkg/totp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTwoFactorRepository struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorRepository) SetPendingTOTPSecret(userID, secret string) (bool, error) {
+	args := m.Called(userID, secret)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) EnableTwoFactor(userID, secret string, step int64, codeHashes []string) (bool, error) {
+	args := m.Called(userID, secret, step, codeHashes)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) DisableTwoFactor(userID string) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) UseTOTPStep(userID string, step int64) (bool, error) {
+	args := m.Called(userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) UseRecoveryCode(userID, codeHash string) (bool, error) {
+	args := m.Called(userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) CreateMFAChallenge(challenge *models.MFAChallenge) error {
+	args := m.Called(challenge)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) GetMFAChallengeByHash(hash string) (*models.MFAChallenge, error) {
+	args := m.Called(hash)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.MFAChallenge), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) RecordMFAAttempt(id string, maxAttempts int) (bool, error) {
+	args := m.Called(id, maxAttempts)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) DeleteMFAChallenge(id string) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+// twoFactorUser returns a user with two-factor authentication on, and the
+// current code of their authenticator
+func twoFactorUser(t *testing.T) (*models.User, string) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	hashedPassword, _ := utils.HashPassword("password123")
+	enabledAt := time.Now()
+	return &models.User{
+		ID:                 "user-123",
+		Email:              "test@example.com",
+		PasswordHash:       hashedPassword,
+		TOTPSecret:         &secret,
+		TwoFactorEnabledAt: &enabledAt,
+	}, code
+}
+
+func TestAuthService_SetupTwoFactor(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTwoFactorRepo := new(MockTwoFactorRepository)
+	authService := NewAuthService(mockRepo, new(MockTokenRepository), mockTwoFactorRepo, mail.NewMemory(), "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
+
+	t.Run("Creates a pending secret", func(t *testing.T) {
+		mockRepo.On("GetUserByID", "user-123").Return(&models.User{ID: "user-123", Email: "test@example.com"}, nil).Once()
+		mockTwoFactorRepo.On("SetPendingTOTPSecret", "user-123", mock.AnythingOfType("string")).Return(true, nil).Once()
+
+		setup, err := authService.SetupTwoFactor("user-123")
+
+		require.NoError(t, err)
+		assert.Len(t, setup.Secret, 32)
+		assert.True(t, strings.HasPrefix(setup.OTPAuthURI, "otpauth://totp/PocketPilot:test@example.com?"))
+		assert.Contains(t, setup.OTPAuthURI, "secret="+setup.Secret)
+		mockTwoFactorRepo.AssertExpectations(t)
+	})
+
+	t.Run("Already enabled", func(t *testing.T) {
+		user, _ := twoFactorUser(t)
+		mockRepo.On("GetUserByID", "user-123").Return(user, nil).Once()
+
+		_, err := authService.SetupTwoFactor("user-123")
+
+		assert.ErrorIs(t, err, ErrTwoFactorEnabled)
+	})
+}
+
+func TestAuthService_EnableTwoFactor(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTwoFactorRepo := new(MockTwoFactorRepository)
+	authService := NewAuthService(mockRepo, new(MockTokenRepository), mockTwoFactorRepo, mail.NewMemory(), "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	mockRepo.On("GetUserByID", "user-123").Return(&models.User{ID: "user-123", TOTPSecret: &secret}, nil)
+
+	t.Run("Returns recovery codes", func(t *testing.T) {
+		step := totp.Step(time.Now())
+		code, err := totp.Code(secret, step)
+		require.NoError(t, err)
+		var hashes []string
+		mockTwoFactorRepo.On("EnableTwoFactor", "user-123", secret, step, mock.AnythingOfType("[]string")).Return(true, nil).Run(func(args mock.Arguments) {
+			hashes = args.Get(3).([]string)
+		}).Once()
+
+		response, err := authService.EnableTwoFactor("user-123", &models.EnableTwoFactorRequest{Code: code[:3] + " " + code[3:]})
+
+		require.NoError(t, err)
+		require.Len(t, response.RecoveryCodes, 10)
+		require.Len(t, hashes, 10)
+		for i, code := range response.RecoveryCodes {
+			assert.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}$`, code)
+			assert.Equal(t, utils.HashToken(strings.ReplaceAll(code, "-", "")), hashes[i])
+		}
+	})
+
+	t.Run("Wrong code", func(t *testing.T) {
+		_, err := authService.EnableTwoFactor("user-123", &models.EnableTwoFactorRequest{Code: "abcdef"})
+
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	})
+
+	t.Run("Not set up", func(t *testing.T) {
+		mockRepo.On("GetUserByID", "user-456").Return(&models.User{ID: "user-456"}, nil).Once()
+
+		_, err := authService.EnableTwoFactor("user-456", &models.EnableTwoFactorRequest{Code: "123456"})
+
+		assert.ErrorIs(t, err, ErrTwoFactorNotSetUp)
+	})
+}
+
+func TestAuthService_TwoFactorLogin(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockTokenRepository)
+	mockTwoFactorRepo := new(MockTwoFactorRepository)
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockTwoFactorRepo, mail.NewMemory(), "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
+
+	user, code := twoFactorUser(t)
+	mockRepo.On("GetUserByEmail", "test@example.com").Return(user, nil)
+	mockRepo.On("GetUserByID", "user-123").Return(user, nil)
+
+	t.Run("Password alone gets a challenge", func(t *testing.T) {
+		var stored *models.MFAChallenge
+		mockTwoFactorRepo.On("CreateMFAChallenge", mock.AnythingOfType("*models.MFAChallenge")).Return(nil).Run(func(args mock.Arguments) {
+			stored = args.Get(0).(*models.MFAChallenge)
+		}).Once()
+
+		authResponse, challenge, err := authService.Login(&models.LoginRequest{Email: "test@example.com", Password: "password123"}, testClient)
+
+		require.NoError(t, err)
+		assert.Nil(t, authResponse)
+		require.NotNil(t, challenge)
+		assert.True(t, challenge.MFARequired)
+		assert.Equal(t, utils.HashToken(challenge.ChallengeToken), stored.TokenHash)
+		assert.WithinDuration(t, time.Now().Add(5*time.Minute), challenge.ExpiresAt, time.Minute)
+	})
+
+	challenge := &models.MFAChallenge{ID: "challenge-1", UserID: "user-123", ExpiresAt: time.Now().Add(5 * time.Minute)}
+	mockTwoFactorRepo.On("GetMFAChallengeByHash", utils.HashToken("challenge-token")).Return(challenge, nil)
+
+	t.Run("Code finishes the login", func(t *testing.T) {
+		mockTwoFactorRepo.On("RecordMFAAttempt", "challenge-1", 5).Return(true, nil).Once()
+		mockTwoFactorRepo.On("UseTOTPStep", "user-123", mock.AnythingOfType("int64")).Return(true, nil).Once()
+		mockTwoFactorRepo.On("DeleteMFAChallenge", "challenge-1").Return(true, nil).Once()
+		mockTokenRepo.On("CreateSession", mock.AnythingOfType("*models.Session"), mock.AnythingOfType("*models.RefreshToken")).Return(nil).Once()
+
+		authResponse, err := authService.CompleteMFALogin(&models.MFALoginRequest{ChallengeToken: "challenge-token", Code: code}, testClient)
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, authResponse.Token)
+		assert.NotEmpty(t, authResponse.RefreshToken)
+		mockTwoFactorRepo.AssertExpectations(t)
+	})
+
+	t.Run("Used code is refused", func(t *testing.T) {
+		mockTwoFactorRepo.On("RecordMFAAttempt", "challenge-1", 5).Return(true, nil).Once()
+		mockTwoFactorRepo.On("UseTOTPStep", "user-123", mock.AnythingOfType("int64")).Return(false, nil).Once()
+
+		_, err := authService.CompleteMFALogin(&models.MFALoginRequest{ChallengeToken: "challenge-token", Code: code}, testClient)
+
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	})
+
+	t.Run("Recovery code finishes the login", func(t *testing.T) {
+		mockTwoFactorRepo.On("RecordMFAAttempt", "challenge-1", 5).Return(true, nil).Once()
+		mockTwoFactorRepo.On("UseRecoveryCode", "user-123", utils.HashToken("abcd2345")).Return(true, nil).Once()
+		mockTwoFactorRepo.On("DeleteMFAChallenge", "challenge-1").Return(true, nil).Once()
+		mockTokenRepo.On("CreateSession", mock.AnythingOfType("*models.Session"), mock.AnythingOfType("*models.RefreshToken")).Return(nil).Once()
+
+		authResponse, err := authService.CompleteMFALogin(&models.MFALoginRequest{ChallengeToken: "challenge-token", Code: "ABCD-2345"}, testClient)
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, authResponse.Token)
+	})
+
+	t.Run("Too many attempts", func(t *testing.T) {
+		mockTwoFactorRepo.On("RecordMFAAttempt", "challenge-1", 5).Return(false, nil).Once()
+
+		_, err := authService.CompleteMFALogin(&models.MFALoginRequest{ChallengeToken: "challenge-token", Code: code}, testClient)
+
+		assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+	})
+
+	t.Run("Unknown challenge", func(t *testing.T) {
+		mockTwoFactorRepo.On("GetMFAChallengeByHash", utils.HashToken("forged")).Return(nil, nil).Once()
+
+		_, err := authService.CompleteMFALogin(&models.MFALoginRequest{ChallengeToken: "forged", Code: code}, testClient)
+
+		assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+	})
+}
+
+func TestAuthService_DisableTwoFactor(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTwoFactorRepo := new(MockTwoFactorRepository)
+	authService := NewAuthService(mockRepo, new(MockTokenRepository), mockTwoFactorRepo, mail.NewMemory(), "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
+
+	user, code := twoFactorUser(t)
+	mockRepo.On("GetUserByID", "user-123").Return(user, nil)
+
+	t.Run("Wrong password", func(t *testing.T) {
+		err := authService.DisableTwoFactor("user-123", &models.DisableTwoFactorRequest{Password: "guess", Code: code})
+
+		assert.ErrorIs(t, err, ErrIncorrectPassword)
+	})
+
+	t.Run("Password and code turn it off", func(t *testing.T) {
+		mockTwoFactorRepo.On("UseTOTPStep", "user-123", mock.AnythingOfType("int64")).Return(true, nil).Once()
+		mockTwoFactorRepo.On("DisableTwoFactor", "user-123").Return(nil).Once()
+
+		err := authService.DisableTwoFactor("user-123", &models.DisableTwoFactorRequest{Password: "password123", Code: code})
+
+		require.NoError(t, err)
+		mockTwoFactorRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/base64"
+    "fmt"
+    "log"
+    "net/url"
+    "pocketpilot/internal/models"
+    "pocketpilot/pkg/mail"
+    "strconv"
+    "strings"
+    "time"
+)
+
+const (
+    // emailVerificationTTL is how long a verification link works
+    emailVerificationTTL = 48 * time.Hour
+    // verificationResendInterval is how long a user waits before another
+    // verification email can be sent
+    verificationResendInterval = 2 * time.Minute
+)
+
+// VerifyEmail marks the email of a user as verified with the token of the
+// link sent to it. A link stops working once the user changes their email.
+func (s *AuthService) VerifyEmail(token string) (*models.User, error) {
+    userID, expires, err := s.parseVerificationToken(token)
+    if err != nil {
+        return nil, err
+    }
+    user, err := s.userRepo.GetUserByID(userID)
+    if err != nil {
+        return nil, err
+    }
+    if user == nil || !hmac.Equal([]byte(token), []byte(s.verificationToken(user, expires))) {
+        return nil, ErrInvalidVerificationToken
+    }
+    if user.EmailVerifiedAt != nil {
+        return user, nil
+    }
+
+    if _, err := s.userRepo.MarkEmailVerified(user.ID, user.Email); err != nil {
+        return nil, err
+    }
+    return s.GetUserProfile(user.ID)
+}
+
+// ResendVerificationEmail sends another verification link, at most once
+// every few minutes
+func (s *AuthService) ResendVerificationEmail(userID string) error {
+    user, err := s.GetUserProfile(userID)
+    if err != nil {
+        return err
+    }
+    if user.EmailVerifiedAt != nil {
+        return ErrEmailAlreadyVerified
+    }
+
+    claimed, err := s.userRepo.ClaimVerificationEmail(user.ID, s.now().Add(-verificationResendInterval))
+    if err != nil {
+        return err
+    }
+    if !claimed {
+        return ErrVerificationEmailThrottled
+    }
+    return s.mailer.Send(s.verificationEmail(user))
+}
+
+// VerifiedEmail returns the current email of a user and whether it was
+// verified, for checks that cannot trust the email of an access token
+func (s *AuthService) VerifiedEmail(userID string) (string, bool, error) {
+    user, err := s.userRepo.GetUserByID(userID)
+    if err != nil {
+        return "", false, err
+    }
+    if user == nil {
+        return "", false, nil
+    }
+    return user.Email, user.EmailVerifiedAt != nil, nil
+}
+
+// sendVerificationEmail sends the first verification link after registering.
+// Failures are only logged, as the user can ask for another link.
+func (s *AuthService) sendVerificationEmail(user *models.User) {
+    claimed, err := s.userRepo.ClaimVerificationEmail(user.ID, s.now())
+    if err == nil && claimed {
+        err = s.mailer.Send(s.verificationEmail(user))
+    }
+    if err != nil {
+        log.Printf("Verification email to user %s not sent: %v", user.ID, err)
+    }
+}
+
+func (s *AuthService) verificationEmail(user *models.User) *mail.Message {
+    token := s.verificationToken(user, s.now().Add(emailVerificationTTL).Unix())
+    link := s.appURL + "/verify-email?token=" + url.QueryEscape(token)
+    return &mail.Message{
+        To:      user.Email,
+        Subject: "Verify your PocketPilot email address",
+        Body: fmt.Sprintf("Hi %s,\n\n"+
+            "Please confirm that this is your email address by opening this link within the next two days:\n\n%s\n\n"+
+            "If you did not create a PocketPilot account, ignore this email.\n",
+            user.FirstName, link),
+    }
+}
+
+// verificationToken signs the user's ID and current email with an expiry, as
+// <user ID>.<expiry in Unix seconds>.<signature>
+func (s *AuthService) verificationToken(user *models.User, expires int64) string {
+    payload := user.ID + "." + strconv.FormatInt(expires, 10)
+    mac := hmac.New(sha256.New, []byte(s.jwtSecret))
+    mac.Write([]byte("email-verification\x00" + payload + "\x00" + strings.ToLower(user.Email)))
+    return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseVerificationToken returns the user ID and expiry of a verification
+// token that has not expired. Its signature can only be checked against the
+// user's current email.
+func (s *AuthService) parseVerificationToken(token string) (string, int64, error) {
+    parts := strings.Split(token, ".")
+    if len(parts) != 3 || parts[0] == "" {
+        return "", 0, ErrInvalidVerificationToken
+    }
+    expires, err := strconv.ParseInt(parts[1], 10, 64)
+    if err != nil || !s.now().Before(time.Unix(expires, 0)) {
+        return "", 0, ErrInvalidVerificationToken
+    }
+    return parts[0], expires, nil
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/mail"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_VerifyEmail(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	authService := NewAuthService(mockRepo, new(MockTokenRepository), new(MockTwoFactorRepository), mail.NewMemory(), "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
+
+	user := &models.User{ID: "user-123", Email: "test@example.com"}
+	expires := time.Now().Add(time.Hour).Unix()
+	token := authService.verificationToken(user, expires)
+	mockRepo.On("GetUserByID", "user-123").Return(user, nil).Once()
+
+	t.Run("Marks the email verified", func(t *testing.T) {
+		verifiedAt := time.Now()
+		mockRepo.On("MarkEmailVerified", "user-123", "test@example.com").Return(true, nil).Once()
+		mockRepo.On("GetUserByID", "user-123").Return(&models.User{ID: "user-123", Email: "test@example.com", EmailVerifiedAt: &verifiedAt}, nil).Once()
+
+		verified, err := authService.VerifyEmail(token)
+
+		require.NoError(t, err)
+		assert.NotNil(t, verified.EmailVerifiedAt)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Tampered token", func(t *testing.T) {
+		mockRepo.On("GetUserByID", "user-456").Return(&models.User{ID: "user-456", Email: "other@example.com"}, nil).Once()
+
+		_, err := authService.VerifyEmail("user-456" + token[len("user-123"):])
+
+		assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+	})
+
+	t.Run("Email changed since the link was sent", func(t *testing.T) {
+		mockRepo.On("GetUserByID", "user-123").Return(&models.User{ID: "user-123", Email: "new@example.com"}, nil).Once()
+
+		_, err := authService.VerifyEmail(token)
+
+		assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		_, err := authService.VerifyEmail(authService.verificationToken(user, time.Now().Add(-time.Minute).Unix()))
+
+		assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+	})
+
+	t.Run("Malformed token", func(t *testing.T) {
+		_, err := authService.VerifyEmail("not-a-token")
+
+		assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+	})
+}
+
+func TestAuthService_ResendVerificationEmail(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mailer := mail.NewMemory()
+	authService := NewAuthService(mockRepo, new(MockTokenRepository), new(MockTwoFactorRepository), mailer, "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
+
+	verifiedAt := time.Now()
+	mockRepo.On("GetUserByID", "user-123").Return(&models.User{ID: "user-123", Email: "test@example.com"}, nil)
+	mockRepo.On("GetUserByID", "verified").Return(&models.User{ID: "verified", EmailVerifiedAt: &verifiedAt}, nil)
+
+	t.Run("Sends a new link", func(t *testing.T) {
+		mockRepo.On("ClaimVerificationEmail", "user-123", mock.MatchedBy(func(sentBefore time.Time) bool {
+			return time.Since(sentBefore) >= 2*time.Minute
+		})).Return(true, nil).Once()
+
+		require.NoError(t, authService.ResendVerificationEmail("user-123"))
+		require.Len(t, mailer.Messages(), 1)
+		assert.Contains(t, mailer.Messages()[0].Body, "https://app.test/verify-email?token=user-123.")
+	})
+
+	t.Run("Throttled", func(t *testing.T) {
+		mockRepo.On("ClaimVerificationEmail", "user-123", mock.AnythingOfType("time.Time")).Return(false, nil).Once()
+
+		err := authService.ResendVerificationEmail("user-123")
+
+		assert.ErrorIs(t, err, ErrVerificationEmailThrottled)
+		assert.Len(t, mailer.Messages(), 1)
+	})
+
+	t.Run("Already verified", func(t *testing.T) {
+		err := authService.ResendVerificationEmail("verified")
+
+		assert.ErrorIs(t, err, ErrEmailAlreadyVerified)
+	})
+}
+
+func TestAuthService_VerifiedEmail(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	authService := NewAuthService(mockRepo, new(MockTokenRepository), new(MockTwoFactorRepository), mail.NewMemory(), "test-secret-key", "https://app.test", 15*time.Minute, 30*24*time.Hour)
+
+	verifiedAt := time.Now()
+	mockRepo.On("GetUserByID", "verified").Return(&models.User{ID: "verified", Email: "admin@example.com", EmailVerifiedAt: &verifiedAt}, nil)
+	mockRepo.On("GetUserByID", "unverified").Return(&models.User{ID: "unverified", Email: "admin@example.com"}, nil)
+	mockRepo.On("GetUserByID", "deleted").Return(nil, nil)
+
+	email, verified, err := authService.VerifiedEmail("verified")
+	require.NoError(t, err)
+	assert.Equal(t, "admin@example.com", email)
+	assert.True(t, verified)
+
+	email, verified, err = authService.VerifiedEmail("unverified")
+	require.NoError(t, err)
+	assert.Equal(t, "admin@example.com", email)
+	assert.False(t, verified)
+
+	_, verified, err = authService.VerifiedEmail("deleted")
+	require.NoError(t, err)
+	assert.False(t, verified)
+}
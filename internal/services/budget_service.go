@@ -0,0 +1,418 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "pocketpilot/internal/models"
+    "pocketpilot/pkg/money"
+    "sort"
+    "strings"
+    "time"
+)
+
+// defaultBudgetThresholds are the percentages of a budget that alert unless others are chosen
+var defaultBudgetThresholds = []int64{80, 100}
+
+// maxBudgetThreshold bounds alert thresholds, in percent of the budget
+const maxBudgetThreshold = 1000
+
+type BudgetService struct {
+    budgetRepo   BudgetRepository
+    rateRepo     ExchangeRateRepository
+    categoryRepo CategoryRepository
+    teamAuth     *TeamAuthorizer
+    now          func() time.Time
+}
+
+func NewBudgetService(budgetRepo BudgetRepository, rateRepo ExchangeRateRepository, teamRepo TeamRepository, categoryRepo CategoryRepository) *BudgetService {
+    return &BudgetService{
+        budgetRepo:   budgetRepo,
+        rateRepo:     rateRepo,
+        categoryRepo: categoryRepo,
+        teamAuth:   NewTeamAuthorizer(teamRepo),
+        now:        time.Now,
+    }
+}
+
+// CreateBudget creates a personal budget, or a team budget for team owners and admins
+func (s *BudgetService) CreateBudget(userID string, req *models.CreateBudgetRequest) (*models.Budget, error) {
+    if req.TeamID != nil {
+        if _, err := s.teamAuth.Authorize(*req.TeamID, userID, PermManageBudgets); err != nil {
+            return nil, err
+        }
+    }
+
+    switch req.Period {
+    case models.BudgetPeriodMonthly, models.BudgetPeriodQuarterly, models.BudgetPeriodYearly:
+    default:
+        return nil, fmt.Errorf("%w: period must be monthly, quarterly or yearly", ErrInvalidBudget)
+    }
+    currency, err := money.NormalizeCurrency(req.Currency)
+    if err != nil {
+        return nil, ErrInvalidCurrency
+    }
+    if err := validateAmount(req.Amount, currency); err != nil {
+        return nil, err
+    }
+
+    startDate := req.StartDate
+    if startDate == "" {
+        startDate = s.today().Format("2006-01-02")
+    }
+    if _, err := time.Parse("2006-01-02", startDate); err != nil {
+        return nil, errors.New("invalid start date format, use YYYY-MM-DD")
+    }
+
+    thresholds, err := budgetThresholds(req.Thresholds)
+    if err != nil {
+        return nil, err
+    }
+
+    budget := &models.Budget{
+        UserID:     userID,
+        TeamID:     req.TeamID,
+        Amount:     req.Amount,
+        Currency:   currency,
+        Period:     req.Period,
+        StartDate:  startDate,
+        Rollover:   req.Rollover,
+        Thresholds: thresholds,
+    }
+    if req.Category != nil && strings.TrimSpace(*req.Category) != "" {
+        category, err := resolveCategory(s.categoryRepo, userID, req.TeamID, *req.Category)
+        if err != nil {
+            return nil, err
+        }
+        budget.Category = &category.Name
+    }
+
+    if err := s.budgetRepo.CreateBudget(budget); err != nil {
+        return nil, err
+    }
+    return budget, nil
+}
+
+// GetBudgets lists a user's personal budgets, or a team's budgets for its members
+func (s *BudgetService) GetBudgets(userID string, teamID *string) ([]*models.Budget, error) {
+    if teamID == nil {
+        return s.budgetRepo.GetBudgetsByUser(userID)
+    }
+
+    if _, err := s.teamAuth.Authorize(*teamID, userID, PermReadTeamExpenses); err != nil {
+        return nil, err
+    }
+    return s.budgetRepo.GetBudgetsByTeam(*teamID)
+}
+
+// GetBudget retrieves a budget the user can see
+func (s *BudgetService) GetBudget(budgetID, userID string) (*models.Budget, error) {
+    return s.authorizedBudget(budgetID, userID, PermReadTeamExpenses)
+}
+
+// UpdateBudget changes a budget's amount, rollover or thresholds. Its scope and
+// period cannot change; create a new budget instead.
+func (s *BudgetService) UpdateBudget(budgetID, userID string, req *models.UpdateBudgetRequest) (*models.Budget, error) {
+    budget, err := s.authorizedBudget(budgetID, userID, PermManageBudgets)
+    if err != nil {
+        return nil, err
+    }
+
+    if req.Amount != nil {
+        if err := validateAmount(*req.Amount, budget.Currency); err != nil {
+            return nil, err
+        }
+        budget.Amount = *req.Amount
+    }
+    if req.Rollover != nil {
+        budget.Rollover = *req.Rollover
+    }
+    if req.Thresholds != nil {
+        thresholds, err := budgetThresholds(req.Thresholds)
+        if err != nil {
+            return nil, err
+        }
+        budget.Thresholds = thresholds
+    }
+
+    if err := s.budgetRepo.UpdateBudget(budget); err != nil {
+        return nil, err
+    }
+    return budget, nil
+}
+
+// DeleteBudget deletes a budget and its alerts
+func (s *BudgetService) DeleteBudget(budgetID, userID string) error {
+    budget, err := s.authorizedBudget(budgetID, userID, PermManageBudgets)
+    if err != nil {
+        return err
+    }
+
+    return s.budgetRepo.DeleteBudget(budget.ID)
+}
+
+// GetBudgetStatus works out spent and remaining for the budget period containing
+// the date, today when empty
+func (s *BudgetService) GetBudgetStatus(budgetID, userID, date string) (*models.BudgetStatus, error) {
+    budget, err := s.authorizedBudget(budgetID, userID, PermReadTeamExpenses)
+    if err != nil {
+        return nil, err
+    }
+    day, err := s.statusDate(date)
+    if err != nil {
+        return nil, err
+    }
+
+    return evaluateBudget(s.budgetRepo, newRateConverter(s.rateRepo), budget, day)
+}
+
+// GetBudgetStatuses works out spent and remaining for every personal or team
+// budget in the period containing the date, today when empty
+func (s *BudgetService) GetBudgetStatuses(userID string, teamID *string, date string) ([]*models.BudgetStatus, error) {
+    day, err := s.statusDate(date)
+    if err != nil {
+        return nil, err
+    }
+    budgets, err := s.GetBudgets(userID, teamID)
+    if err != nil {
+        return nil, err
+    }
+
+    converter := newRateConverter(s.rateRepo)
+    statuses := make([]*models.BudgetStatus, 0, len(budgets))
+    for _, budget := range budgets {
+        status, err := evaluateBudget(s.budgetRepo, converter, budget, day)
+        if err != nil {
+            return nil, err
+        }
+        statuses = append(statuses, status)
+    }
+    return statuses, nil
+}
+
+// GetBudgetAlerts lists the alerts a budget raised, newest first
+func (s *BudgetService) GetBudgetAlerts(budgetID, userID string) ([]*models.BudgetAlert, error) {
+    budget, err := s.authorizedBudget(budgetID, userID, PermReadTeamExpenses)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.budgetRepo.GetBudgetAlerts(budget.ID)
+}
+
+// authorizedBudget loads a budget the user may act on. Personal budgets are
+// only visible to their owner; team budgets need perm in the team.
+func (s *BudgetService) authorizedBudget(budgetID, userID string, perm TeamPermission) (*models.Budget, error) {
+    budget, err := s.budgetRepo.GetBudgetByID(budgetID)
+    if err != nil {
+        return nil, err
+    }
+    if budget == nil {
+        return nil, ErrBudgetNotFound
+    }
+
+    if budget.TeamID == nil {
+        if budget.UserID != userID {
+            return nil, ErrBudgetNotFound
+        }
+        return budget, nil
+    }
+    if _, err := s.teamAuth.Authorize(*budget.TeamID, userID, perm); err != nil {
+        if errors.Is(err, ErrTeamNotFound) {
+            return nil, ErrBudgetNotFound
+        }
+        return nil, err
+    }
+    return budget, nil
+}
+
+func (s *BudgetService) today() time.Time {
+    now := s.now().UTC()
+    return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (s *BudgetService) statusDate(date string) (time.Time, error) {
+    if date == "" {
+        return s.today(), nil
+    }
+    day, err := time.Parse("2006-01-02", date)
+    if err != nil {
+        return time.Time{}, errors.New("invalid date format, use YYYY-MM-DD")
+    }
+    return day, nil
+}
+
+// budgetThresholds validates alert thresholds, sorted and without duplicates
+func budgetThresholds(thresholds []int64) ([]int64, error) {
+    if len(thresholds) == 0 {
+        return append([]int64(nil), defaultBudgetThresholds...), nil
+    }
+
+    seen := make(map[int64]bool, len(thresholds))
+    var result []int64
+    for _, threshold := range thresholds {
+        if threshold < 1 || threshold > maxBudgetThreshold {
+            return nil, fmt.Errorf("%w: thresholds are percentages between 1 and %d", ErrInvalidBudget, maxBudgetThreshold)
+        }
+        if !seen[threshold] {
+            seen[threshold] = true
+            result = append(result, threshold)
+        }
+    }
+    sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+    return result, nil
+}
+
+// budgetPeriod returns the first and last day of the calendar month, quarter
+// or year containing the date
+func budgetPeriod(period string, date time.Time) (time.Time, time.Time) {
+    var start time.Time
+    var months int
+    switch period {
+    case models.BudgetPeriodQuarterly:
+        start = time.Date(date.Year(), date.Month()-(date.Month()-1)%3, 1, 0, 0, 0, 0, time.UTC)
+        months = 3
+    case models.BudgetPeriodYearly:
+        start = time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+        months = 12
+    default:
+        start = time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+        months = 1
+    }
+    return start, start.AddDate(0, months, -1)
+}
+
+// evaluateBudget works out the budget's status in the period containing the
+// date. Expenses in other currencies are converted with the rate of their day.
+// With rollover, what was left or overspent in every earlier period since the
+// budget started is carried into the period.
+func evaluateBudget(budgetRepo BudgetRepository, converter *rateConverter, budget *models.Budget, date time.Time) (*models.BudgetStatus, error) {
+    periodStart, periodEnd := budgetPeriod(budget.Period, date)
+    budgetStart, err := time.Parse("2006-01-02", budget.StartDate)
+    if err != nil {
+        return nil, err
+    }
+
+    from := periodStart
+    if budget.Rollover {
+        from, _ = budgetPeriod(budget.Period, budgetStart)
+    }
+    if from.Before(budgetStart) {
+        from = budgetStart
+    }
+
+    status := &models.BudgetStatus{
+        Budget:      budget,
+        PeriodStart: periodStart.Format("2006-01-02"),
+        PeriodEnd:   periodEnd.Format("2006-01-02"),
+    }
+    if periodEnd.Before(budgetStart) {
+        status.Available = budget.Amount
+        status.Remaining = budget.Amount
+        return status, nil
+    }
+
+    spending, err := budgetRepo.GetBudgetSpending(budget, from.Format("2006-01-02"), status.PeriodEnd)
+    if err != nil {
+        return nil, err
+    }
+
+    // Spending of earlier periods, by their first day
+    earlier := make(map[string]money.Amount)
+    for _, day := range spending {
+        date := day.Date
+        if len(date) > 10 {
+            date = date[:10]
+        }
+        converted, err := converter.Convert(day.Amount, day.Currency, budget.Currency, date)
+        if err != nil {
+            return nil, err
+        }
+        if converted == nil {
+            status.Unconverted++
+            continue
+        }
+
+        if date >= status.PeriodStart {
+            status.Spent = status.Spent.Add(*converted)
+            continue
+        }
+        spentOn, err := time.Parse("2006-01-02", date)
+        if err != nil {
+            return nil, err
+        }
+        start, _ := budgetPeriod(budget.Period, spentOn)
+        key := start.Format("2006-01-02")
+        earlier[key] = earlier[key].Add(*converted)
+    }
+
+    if budget.Rollover {
+        first, _ := budgetPeriod(budget.Period, budgetStart)
+        for start := first; start.Before(periodStart); {
+            key := start.Format("2006-01-02")
+            status.Carried = status.Carried.Add(budget.Amount.Sub(earlier[key]))
+            _, end := budgetPeriod(budget.Period, start)
+            start = end.AddDate(0, 0, 1)
+        }
+    }
+
+    status.Available = budget.Amount.Add(status.Carried)
+    status.Remaining = status.Available.Sub(status.Spent)
+    status.PercentUsed = percentUsed(status.Spent, status.Available)
+    return status, nil
+}
+
+// percentUsed is spent as a percentage of available, rounded to two decimals.
+// Spending with nothing available counts as 100%.
+func percentUsed(spent, available money.Amount) float64 {
+    if available <= 0 {
+        if spent > 0 || available < 0 {
+            return 100
+        }
+        return 0
+    }
+    return percentOf(spent, available)
+}
+
+// raiseBudgetAlerts checks the budgets an expense counts against and records
+// an alert for every threshold their period's spending reached for the first time
+func raiseBudgetAlerts(budgetRepo BudgetRepository, rateRepo ExchangeRateRepository, expense *models.Expense) ([]*models.BudgetAlert, error) {
+    budgets, err := budgetRepo.GetBudgetsForExpense(expense)
+    if err != nil || len(budgets) == 0 {
+        return nil, err
+    }
+    date, err := time.Parse("2006-01-02", expenseDay(expense))
+    if err != nil {
+        return nil, err
+    }
+
+    converter := newRateConverter(rateRepo)
+    var alerts []*models.BudgetAlert
+    for _, budget := range budgets {
+        status, err := evaluateBudget(budgetRepo, converter, budget, date)
+        if err != nil {
+            return alerts, err
+        }
+
+        for _, threshold := range budget.Thresholds {
+            if status.PercentUsed < float64(threshold) {
+                break
+            }
+            alert := &models.BudgetAlert{
+                BudgetID:    budget.ID,
+                PeriodStart: status.PeriodStart,
+                Threshold:   threshold,
+                Spent:       status.Spent,
+                Available:   status.Available,
+                ExpenseID:   &expense.ID,
+            }
+            created, err := budgetRepo.CreateBudgetAlert(alert)
+            if err != nil {
+                return alerts, err
+            }
+            if created {
+                alerts = append(alerts, alert)
+            }
+        }
+    }
+    return alerts, nil
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockBudgetRepository struct {
+	mock.Mock
+}
+
+func (m *MockBudgetRepository) CreateBudget(budget *models.Budget) error {
+	args := m.Called(budget)
+	return args.Error(0)
+}
+
+func (m *MockBudgetRepository) GetBudgetByID(id string) (*models.Budget, error) {
+	args := m.Called(id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Budget), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockBudgetRepository) GetBudgetsByUser(userID string) ([]*models.Budget, error) {
+	args := m.Called(userID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Budget), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockBudgetRepository) GetBudgetsByTeam(teamID string) ([]*models.Budget, error) {
+	args := m.Called(teamID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Budget), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockBudgetRepository) GetBudgetsForExpense(expense *models.Expense) ([]*models.Budget, error) {
+	args := m.Called(expense)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Budget), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockBudgetRepository) UpdateBudget(budget *models.Budget) error {
+	args := m.Called(budget)
+	return args.Error(0)
+}
+
+func (m *MockBudgetRepository) DeleteBudget(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockBudgetRepository) GetBudgetSpending(budget *models.Budget, from, to string) ([]*models.DailySpending, error) {
+	args := m.Called(budget, from, to)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.DailySpending), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockBudgetRepository) CreateBudgetAlert(alert *models.BudgetAlert) (bool, error) {
+	args := m.Called(alert)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockBudgetRepository) GetBudgetAlerts(budgetID string) ([]*models.BudgetAlert, error) {
+	args := m.Called(budgetID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.BudgetAlert), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// noBudgets returns a budget repository without any budgets
+func noBudgets() *MockBudgetRepository {
+	repo := new(MockBudgetRepository)
+	repo.On("GetBudgetsForExpense", mock.Anything).Return(nil, nil)
+	return repo
+}
+
+func TestBudgetPeriod(t *testing.T) {
+	date := time.Date(2026, time.August, 17, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		period string
+		start  string
+		end    string
+	}{
+		{models.BudgetPeriodMonthly, "2026-08-01", "2026-08-31"},
+		{models.BudgetPeriodQuarterly, "2026-07-01", "2026-09-30"},
+		{models.BudgetPeriodYearly, "2026-01-01", "2026-12-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			start, end := budgetPeriod(tt.period, date)
+			assert.Equal(t, tt.start, start.Format("2006-01-02"))
+			assert.Equal(t, tt.end, end.Format("2006-01-02"))
+		})
+	}
+}
+
+func TestBudgetService_CreateBudget(t *testing.T) {
+	mockBudgetRepo := new(MockBudgetRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	budgetService := NewBudgetService(mockBudgetRepo, new(MockExchangeRateRepository), mockTeamRepo, anyCategory())
+	budgetService.now = func() time.Time { return time.Date(2026, time.March, 9, 15, 0, 0, 0, time.UTC) }
+
+	mockTeamRepo.On("GetTeamMember", "team-1", "bob").Return(teamMember("team-1", "bob", models.TeamRoleMember), nil)
+
+	t.Run("Defaults start date and thresholds", func(t *testing.T) {
+		mockBudgetRepo.On("CreateBudget", mock.MatchedBy(func(b *models.Budget) bool {
+			return b.UserID == "user-1" && b.StartDate == "2026-03-09" && b.Currency == "EUR" && *b.Category == "Food"
+		})).Return(nil).Once()
+
+		budget, err := budgetService.CreateBudget("user-1", &models.CreateBudgetRequest{
+			Category: strPtr(" Food "),
+			Amount:   money.MustParse("400"),
+			Currency: "eur",
+			Period:   models.BudgetPeriodMonthly,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []int64{80, 100}, budget.Thresholds)
+		mockBudgetRepo.AssertExpectations(t)
+	})
+
+	t.Run("Thresholds are sorted without duplicates", func(t *testing.T) {
+		mockBudgetRepo.On("CreateBudget", mock.Anything).Return(nil).Once()
+
+		budget, err := budgetService.CreateBudget("user-1", &models.CreateBudgetRequest{
+			Amount:     money.MustParse("1000"),
+			Currency:   "USD",
+			Period:     models.BudgetPeriodYearly,
+			Thresholds: []int64{100, 50, 100, 120},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []int64{50, 100, 120}, budget.Thresholds)
+		assert.Nil(t, budget.Category)
+	})
+
+	t.Run("Invalid period", func(t *testing.T) {
+		_, err := budgetService.CreateBudget("user-1", &models.CreateBudgetRequest{
+			Amount: money.MustParse("100"), Currency: "USD", Period: "weekly",
+		})
+
+		assert.ErrorIs(t, err, ErrInvalidBudget)
+	})
+
+	t.Run("Invalid threshold", func(t *testing.T) {
+		_, err := budgetService.CreateBudget("user-1", &models.CreateBudgetRequest{
+			Amount: money.MustParse("100"), Currency: "USD", Period: models.BudgetPeriodMonthly, Thresholds: []int64{0},
+		})
+
+		assert.ErrorIs(t, err, ErrInvalidBudget)
+	})
+
+	t.Run("Members cannot budget for the team", func(t *testing.T) {
+		_, err := budgetService.CreateBudget("bob", &models.CreateBudgetRequest{
+			TeamID: strPtr("team-1"), Amount: money.MustParse("100"), Currency: "USD", Period: models.BudgetPeriodMonthly,
+		})
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+	})
+}
+
+func TestBudgetService_GetBudgetStatus(t *testing.T) {
+	mockBudgetRepo := new(MockBudgetRepository)
+	mockRateRepo := new(MockExchangeRateRepository)
+	budgetService := NewBudgetService(mockBudgetRepo, mockRateRepo, new(MockTeamRepository), anyCategory())
+
+	t.Run("Converts spending into the budget currency", func(t *testing.T) {
+		budget := &models.Budget{
+			ID: "budget-1", UserID: "user-1", Amount: money.MustParse("500"), Currency: "EUR",
+			Period: models.BudgetPeriodMonthly, StartDate: "2026-01-01", Thresholds: []int64{80, 100},
+		}
+		mockBudgetRepo.On("GetBudgetByID", "budget-1").Return(budget, nil)
+		mockBudgetRepo.On("GetBudgetSpending", budget, "2026-01-01", "2026-01-31").Return([]*models.DailySpending{
+			{Date: "2026-01-10", Currency: "EUR", Amount: money.MustParse("200")},
+			{Date: "2026-01-15", Currency: "USD", Amount: money.MustParse("108.76")},
+			{Date: "2026-01-20", Currency: "JPY", Amount: money.MustParse("5000")},
+		}, nil)
+		mockRateRepo.On("GetExchangeRates", "2026-01-15", mock.Anything).Return(ecbRates(), nil)
+		mockRateRepo.On("GetExchangeRates", "2026-01-20", mock.Anything).Return(nil, nil)
+
+		status, err := budgetService.GetBudgetStatus("budget-1", "user-1", "2026-01-25")
+
+		require.NoError(t, err)
+		assert.Equal(t, "2026-01-01", status.PeriodStart)
+		assert.Equal(t, money.MustParse("300"), status.Spent)
+		assert.Equal(t, money.MustParse("200"), status.Remaining)
+		assert.Equal(t, 60.0, status.PercentUsed)
+		assert.Equal(t, 1, status.Unconverted)
+	})
+
+	t.Run("Rollover carries leftovers and overspending", func(t *testing.T) {
+		budget := &models.Budget{
+			ID: "budget-2", UserID: "user-1", Amount: money.MustParse("100"), Currency: "USD",
+			Period: models.BudgetPeriodMonthly, StartDate: "2026-01-15", Rollover: true,
+		}
+		mockBudgetRepo.On("GetBudgetByID", "budget-2").Return(budget, nil)
+		mockBudgetRepo.On("GetBudgetSpending", budget, "2026-01-15", "2026-03-31").Return([]*models.DailySpending{
+			{Date: "2026-01-20", Currency: "USD", Amount: money.MustParse("40")},
+			{Date: "2026-02-03", Currency: "USD", Amount: money.MustParse("130")},
+			{Date: "2026-03-01", Currency: "USD", Amount: money.MustParse("45")},
+		}, nil)
+
+		status, err := budgetService.GetBudgetStatus("budget-2", "user-1", "2026-03-10")
+
+		require.NoError(t, err)
+		assert.Equal(t, money.MustParse("30"), status.Carried)
+		assert.Equal(t, money.MustParse("130"), status.Available)
+		assert.Equal(t, money.MustParse("85"), status.Remaining)
+		assert.Equal(t, 34.62, status.PercentUsed)
+	})
+
+	t.Run("Other users cannot see personal budgets", func(t *testing.T) {
+		_, err := budgetService.GetBudgetStatus("budget-1", "user-2", "")
+
+		assert.ErrorIs(t, err, ErrBudgetNotFound)
+	})
+}
+
+func TestExpenseService_CreateExpense_BudgetAlerts(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockBudgetRepo := new(MockBudgetRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, usersWithBaseCurrency("USD"), new(MockTeamRepository), new(MockApprovalPolicyRepository), noExchangeRates(), mockBudgetRepo, anyCategory(), nil)
+
+	budget := &models.Budget{
+		ID: "budget-1", UserID: "user-1", Category: strPtr("Food"), Amount: money.MustParse("100"), Currency: "USD",
+		Period: models.BudgetPeriodMonthly, StartDate: "2026-01-01", Thresholds: []int64{50, 80, 100},
+	}
+	mockExpenseRepo.On("CreateExpense", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Expense).ID = "exp-1"
+	}).Return(nil)
+	mockBudgetRepo.On("GetBudgetsForExpense", mock.Anything).Return([]*models.Budget{budget}, nil)
+	mockBudgetRepo.On("GetBudgetSpending", budget, "2026-01-01", "2026-01-31").Return([]*models.DailySpending{
+		{Date: "2026-01-05", Currency: "USD", Amount: money.MustParse("60")},
+		{Date: "2026-01-12", Currency: "USD", Amount: money.MustParse("25")},
+	}, nil)
+	// 50% alerted with an earlier expense
+	mockBudgetRepo.On("CreateBudgetAlert", mock.MatchedBy(func(a *models.BudgetAlert) bool { return a.Threshold == 50 })).Return(false, nil)
+	mockBudgetRepo.On("CreateBudgetAlert", mock.MatchedBy(func(a *models.BudgetAlert) bool {
+		return a.Threshold == 80 && a.PeriodStart == "2026-01-01" && a.Spent == money.MustParse("85") && *a.ExpenseID == "exp-1"
+	})).Return(true, nil)
+
+	expense, err := expenseService.CreateExpense("user-1", &models.CreateExpenseRequest{
+		Amount:      money.MustParse("25"),
+		Currency:    "USD",
+		Description: "Groceries",
+		Category:    "Food",
+		ExpenseDate: "2026-01-12",
+	})
+
+	require.NoError(t, err)
+	require.Len(t, expense.BudgetAlerts, 1)
+	assert.Equal(t, int64(80), expense.BudgetAlerts[0].Threshold)
+	mockBudgetRepo.AssertNumberOfCalls(t, "CreateBudgetAlert", 2)
+}
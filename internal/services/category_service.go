@@ -0,0 +1,285 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "pocketpilot/internal/models"
+    "regexp"
+    "strings"
+    "time"
+)
+
+// categoryColorPattern matches the #RRGGBB colors categories may have
+var categoryColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+type CategoryService struct {
+    categoryRepo CategoryRepository
+    teamAuth     *TeamAuthorizer
+}
+
+func NewCategoryService(categoryRepo CategoryRepository, teamRepo TeamRepository) *CategoryService {
+    return &CategoryService{
+        categoryRepo: categoryRepo,
+        teamAuth:     NewTeamAuthorizer(teamRepo),
+    }
+}
+
+// GetCategories lists the categories offered for the user's personal
+// expenses, or for a team's expenses to its members: the system categories
+// and the custom ones
+func (s *CategoryService) GetCategories(userID string, teamID *string, includeArchived bool) ([]*models.Category, error) {
+    if teamID != nil {
+        if _, err := s.teamAuth.Authorize(*teamID, userID, PermReadTeamExpenses); err != nil {
+            return nil, err
+        }
+    }
+
+    return s.categoryRepo.GetCategories(userID, teamID, includeArchived)
+}
+
+// CreateCategory creates a personal category, or a team category for team
+// owners and admins
+func (s *CategoryService) CreateCategory(userID string, req *models.CreateCategoryRequest) (*models.Category, error) {
+    category := &models.Category{TeamID: req.TeamID}
+    if req.TeamID != nil {
+        if _, err := s.teamAuth.Authorize(*req.TeamID, userID, PermManageCategories); err != nil {
+            return nil, err
+        }
+    } else {
+        category.UserID = &userID
+    }
+
+    name, err := s.availableName(userID, req.TeamID, req.Name, "")
+    if err != nil {
+        return nil, err
+    }
+    category.Name = name
+    if category.Color, err = categoryColor(req.Color); err != nil {
+        return nil, err
+    }
+    category.Icon = categoryIcon(req.Icon)
+
+    if req.ParentID != nil && *req.ParentID != "" {
+        if err := s.validateParent(userID, category, *req.ParentID); err != nil {
+            return nil, err
+        }
+        category.ParentID = req.ParentID
+    }
+
+    if err := s.categoryRepo.CreateCategory(category); err != nil {
+        return nil, err
+    }
+    return category, nil
+}
+
+// UpdateCategory renames, moves, restyles, archives or restores a custom
+// category. A new name carries over to the expenses filed under it.
+func (s *CategoryService) UpdateCategory(categoryID, userID string, req *models.UpdateCategoryRequest) (*models.Category, error) {
+    category, err := s.authorizedCategory(categoryID, userID)
+    if err != nil {
+        return nil, err
+    }
+    previousName := category.Name
+
+    if req.Name != nil {
+        if category.Name, err = s.availableName(userID, category.TeamID, *req.Name, category.ID); err != nil {
+            return nil, err
+        }
+    }
+    if req.Color != nil {
+        if category.Color, err = categoryColor(req.Color); err != nil {
+            return nil, err
+        }
+    }
+    if req.Icon != nil {
+        category.Icon = categoryIcon(req.Icon)
+    }
+    if req.ParentID != nil {
+        category.ParentID = nil
+        if *req.ParentID != "" {
+            if err := s.validateParent(userID, category, *req.ParentID); err != nil {
+                return nil, err
+            }
+            category.ParentID = req.ParentID
+        }
+    }
+    if req.Archived != nil {
+        switch {
+        case *req.Archived && category.ArchivedAt == nil:
+            now := time.Now()
+            category.ArchivedAt = &now
+        case !*req.Archived:
+            category.ArchivedAt = nil
+        }
+    }
+
+    if err := s.categoryRepo.UpdateCategory(category, previousName); err != nil {
+        return nil, err
+    }
+    return category, nil
+}
+
+// authorizedCategory loads a custom category the user may change. Personal
+// categories are only visible to their owner.
+func (s *CategoryService) authorizedCategory(categoryID, userID string) (*models.Category, error) {
+    category, err := s.categoryRepo.GetCategoryByID(categoryID)
+    if err != nil {
+        return nil, err
+    }
+    if category == nil {
+        return nil, ErrCategoryNotFound
+    }
+    if category.IsSystem() {
+        return nil, ErrSystemCategory
+    }
+
+    if category.TeamID == nil {
+        if *category.UserID != userID {
+            return nil, ErrCategoryNotFound
+        }
+        return category, nil
+    }
+    if _, err := s.teamAuth.Authorize(*category.TeamID, userID, PermManageCategories); err != nil {
+        if errors.Is(err, ErrTeamNotFound) {
+            return nil, ErrCategoryNotFound
+        }
+        return nil, err
+    }
+    return category, nil
+}
+
+// availableName trims a category name and checks no other category offered in
+// the same place has it, ignoring case
+func (s *CategoryService) availableName(userID string, teamID *string, name, categoryID string) (string, error) {
+    name = strings.Join(strings.Fields(name), " ")
+    if name == "" {
+        return "", fmt.Errorf("%w: name is required", ErrInvalidCategory)
+    }
+    if len(name) > 100 {
+        return "", fmt.Errorf("%w: name is limited to 100 characters", ErrInvalidCategory)
+    }
+
+    existing, err := s.categoryRepo.GetCategoryByName(userID, teamID, name)
+    if err != nil {
+        return "", err
+    }
+    if existing != nil && existing.ID != categoryID {
+        return "", ErrCategoryExists
+    }
+    return name, nil
+}
+
+// validateParent checks the parent is offered in the same place as the
+// category. Categories nest one level deep.
+func (s *CategoryService) validateParent(userID string, category *models.Category, parentID string) error {
+    if parentID == category.ID {
+        return fmt.Errorf("%w: a category cannot be its own parent", ErrInvalidCategory)
+    }
+
+    parent, err := s.categoryRepo.GetCategoryByID(parentID)
+    if err != nil {
+        return err
+    }
+    visible := parent != nil && (parent.IsSystem() ||
+        (category.TeamID != nil && parent.TeamID != nil && *parent.TeamID == *category.TeamID) ||
+        (category.TeamID == nil && parent.UserID != nil && *parent.UserID == userID))
+    if !visible {
+        return fmt.Errorf("%w: parent category not found", ErrInvalidCategory)
+    }
+    if parent.ParentID != nil {
+        return fmt.Errorf("%w: subcategories cannot have subcategories", ErrInvalidCategory)
+    }
+
+    if category.ID != "" {
+        children, err := s.categoryRepo.CountSubcategories(category.ID)
+        if err != nil {
+            return err
+        }
+        if children > 0 {
+            return fmt.Errorf("%w: categories with subcategories stay top-level", ErrInvalidCategory)
+        }
+    }
+    return nil
+}
+
+func categoryColor(color *string) (*string, error) {
+    if color == nil || *color == "" {
+        return nil, nil
+    }
+    if !categoryColorPattern.MatchString(*color) {
+        return nil, fmt.Errorf("%w: color must look like #RRGGBB", ErrInvalidCategory)
+    }
+    normalized := strings.ToUpper(*color)
+    return &normalized, nil
+}
+
+func categoryIcon(icon *string) *string {
+    if icon == nil || strings.TrimSpace(*icon) == "" {
+        return nil
+    }
+    trimmed := strings.TrimSpace(*icon)
+    return &trimmed
+}
+
+// resolveCategory finds the category a name refers to for a user's personal
+// expenses, or a team's when teamID is set, so spellings like "food" and
+// " Food" land on the same category. Archived categories cannot be picked.
+func resolveCategory(categoryRepo CategoryRepository, userID string, teamID *string, name string) (*models.Category, error) {
+    name = strings.Join(strings.Fields(name), " ")
+    if name == "" {
+        return nil, &expenseFieldError{"category", "category is required"}
+    }
+
+    category, err := categoryRepo.GetCategoryByName(userID, teamID, name)
+    if err != nil {
+        return nil, err
+    }
+    if category == nil {
+        return nil, fmt.Errorf("%w: %s", ErrUnknownCategory, name)
+    }
+    if category.ArchivedAt != nil {
+        return nil, fmt.Errorf("%w: %s", ErrCategoryArchived, category.Name)
+    }
+    return category, nil
+}
+
+// isCategoryError reports whether resolving a category failed because of the
+// name given rather than the database
+func isCategoryError(err error) bool {
+    var fieldErr *expenseFieldError
+    return errors.Is(err, ErrUnknownCategory) || errors.Is(err, ErrCategoryArchived) || errors.As(err, &fieldErr)
+}
+
+// categoryResolver resolves each category name once per user or team, for
+// imports that repeat the same few categories on every row
+type categoryResolver struct {
+    categoryRepo CategoryRepository
+    resolved     map[string]*models.Category
+}
+
+func newCategoryResolver(categoryRepo CategoryRepository) *categoryResolver {
+    return &categoryResolver{categoryRepo: categoryRepo, resolved: make(map[string]*models.Category)}
+}
+
+// Assign files the expense under the category its Category names
+func (r *categoryResolver) Assign(expense *models.Expense) error {
+    key := "user:" + expense.UserID
+    if expense.TeamID != nil {
+        key = "team:" + *expense.TeamID
+    }
+    key += ":" + strings.ToLower(strings.Join(strings.Fields(expense.Category), " "))
+
+    category, ok := r.resolved[key]
+    if !ok {
+        var err error
+        category, err = resolveCategory(r.categoryRepo, expense.UserID, expense.TeamID, expense.Category)
+        if err != nil {
+            return err
+        }
+        r.resolved[key] = category
+    }
+
+    expense.Category = category.Name
+    expense.CategoryID = &category.ID
+    return nil
+}
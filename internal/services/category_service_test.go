@@ -0,0 +1,232 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/money"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockCategoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockCategoryRepository) CreateCategory(category *models.Category) error {
+	args := m.Called(category)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) GetCategoryByID(id string) (*models.Category, error) {
+	args := m.Called(id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Category), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCategoryRepository) GetCategories(userID string, teamID *string, includeArchived bool) ([]*models.Category, error) {
+	args := m.Called(userID, teamID, includeArchived)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Category), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCategoryRepository) GetCategoryByName(userID string, teamID *string, name string) (*models.Category, error) {
+	args := m.Called(userID, teamID, name)
+	if fn, ok := args.Get(0).(func(string) *models.Category); ok {
+		return fn(name), args.Error(1)
+	}
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Category), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCategoryRepository) UpdateCategory(category *models.Category, previousName string) error {
+	args := m.Called(category, previousName)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) CountSubcategories(id string) (int, error) {
+	args := m.Called(id)
+	return args.Int(0), args.Error(1)
+}
+
+// anyCategory returns a category repository where every name is a system category
+func anyCategory() *MockCategoryRepository {
+	repo := new(MockCategoryRepository)
+	repo.On("GetCategoryByName", mock.Anything, mock.Anything, mock.Anything).Return(func(name string) *models.Category {
+		return &models.Category{ID: "cat-" + strings.ToLower(name), Name: name}
+	}, nil)
+	return repo
+}
+
+func TestCategoryService_CreateCategory(t *testing.T) {
+	mockCategoryRepo := new(MockCategoryRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	categoryService := NewCategoryService(mockCategoryRepo, mockTeamRepo)
+
+	t.Run("Personal category", func(t *testing.T) {
+		mockCategoryRepo.On("GetCategoryByName", "user-1", (*string)(nil), "Coffee beans").Return(nil, nil).Once()
+		mockCategoryRepo.On("CreateCategory", mock.MatchedBy(func(c *models.Category) bool {
+			return *c.UserID == "user-1" && c.TeamID == nil && c.Name == "Coffee beans" && *c.Color == "#A0522D"
+		})).Return(nil).Once()
+
+		category, err := categoryService.CreateCategory("user-1", &models.CreateCategoryRequest{
+			Name:  "  Coffee   beans ",
+			Color: strPtr("#a0522d"),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Coffee beans", category.Name)
+		mockCategoryRepo.AssertExpectations(t)
+	})
+
+	t.Run("Name taken ignoring case", func(t *testing.T) {
+		mockCategoryRepo.On("GetCategoryByName", "user-1", (*string)(nil), "food").
+			Return(&models.Category{ID: "cat-food", Name: "Food"}, nil).Once()
+
+		_, err := categoryService.CreateCategory("user-1", &models.CreateCategoryRequest{Name: "food"})
+
+		assert.ErrorIs(t, err, ErrCategoryExists)
+	})
+
+	t.Run("Invalid color", func(t *testing.T) {
+		mockCategoryRepo.On("GetCategoryByName", "user-1", (*string)(nil), "Pets").Return(nil, nil).Once()
+
+		_, err := categoryService.CreateCategory("user-1", &models.CreateCategoryRequest{Name: "Pets", Color: strPtr("red")})
+
+		assert.ErrorIs(t, err, ErrInvalidCategory)
+	})
+
+	t.Run("Subcategories cannot have subcategories", func(t *testing.T) {
+		mockCategoryRepo.On("GetCategoryByName", "user-1", (*string)(nil), "Espresso").Return(nil, nil).Once()
+		mockCategoryRepo.On("GetCategoryByID", "cat-coffee").
+			Return(&models.Category{ID: "cat-coffee", UserID: strPtr("user-1"), ParentID: strPtr("cat-food"), Name: "Coffee"}, nil).Once()
+
+		_, err := categoryService.CreateCategory("user-1", &models.CreateCategoryRequest{Name: "Espresso", ParentID: strPtr("cat-coffee")})
+
+		assert.ErrorIs(t, err, ErrInvalidCategory)
+	})
+
+	t.Run("Parent of another user", func(t *testing.T) {
+		mockCategoryRepo.On("GetCategoryByName", "user-1", (*string)(nil), "Treats").Return(nil, nil).Once()
+		mockCategoryRepo.On("GetCategoryByID", "cat-pets").
+			Return(&models.Category{ID: "cat-pets", UserID: strPtr("user-2"), Name: "Pets"}, nil).Once()
+
+		_, err := categoryService.CreateCategory("user-1", &models.CreateCategoryRequest{Name: "Treats", ParentID: strPtr("cat-pets")})
+
+		assert.ErrorIs(t, err, ErrInvalidCategory)
+	})
+
+	t.Run("Team members cannot create team categories", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "bob").Return(teamMember("team-1", "bob", models.TeamRoleMember), nil).Once()
+
+		_, err := categoryService.CreateCategory("bob", &models.CreateCategoryRequest{TeamID: strPtr("team-1"), Name: "Travel"})
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+	})
+}
+
+func TestCategoryService_UpdateCategory(t *testing.T) {
+	mockCategoryRepo := new(MockCategoryRepository)
+	categoryService := NewCategoryService(mockCategoryRepo, new(MockTeamRepository))
+
+	t.Run("Rename carries the previous name", func(t *testing.T) {
+		mockCategoryRepo.On("GetCategoryByID", "cat-1").
+			Return(&models.Category{ID: "cat-1", UserID: strPtr("user-1"), Name: "Coffee"}, nil).Once()
+		mockCategoryRepo.On("GetCategoryByName", "user-1", (*string)(nil), "Coffee & tea").Return(nil, nil).Once()
+		mockCategoryRepo.On("UpdateCategory", mock.MatchedBy(func(c *models.Category) bool {
+			return c.Name == "Coffee & tea" && c.ArchivedAt != nil
+		}), "Coffee").Return(nil).Once()
+
+		archived := true
+		category, err := categoryService.UpdateCategory("cat-1", "user-1", &models.UpdateCategoryRequest{
+			Name:     strPtr("Coffee & tea"),
+			Archived: &archived,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Coffee & tea", category.Name)
+		mockCategoryRepo.AssertExpectations(t)
+	})
+
+	t.Run("System categories are read-only", func(t *testing.T) {
+		mockCategoryRepo.On("GetCategoryByID", "cat-food").Return(&models.Category{ID: "cat-food", Name: "Food"}, nil).Once()
+
+		_, err := categoryService.UpdateCategory("cat-food", "user-1", &models.UpdateCategoryRequest{Name: strPtr("Groceries")})
+
+		assert.ErrorIs(t, err, ErrSystemCategory)
+	})
+
+	t.Run("Personal categories of other users are not found", func(t *testing.T) {
+		mockCategoryRepo.On("GetCategoryByID", "cat-2").
+			Return(&models.Category{ID: "cat-2", UserID: strPtr("user-2"), Name: "Pets"}, nil).Once()
+
+		_, err := categoryService.UpdateCategory("cat-2", "user-1", &models.UpdateCategoryRequest{Name: strPtr("Dogs")})
+
+		assert.ErrorIs(t, err, ErrCategoryNotFound)
+	})
+}
+
+func TestExpenseService_CreateExpense_Category(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockCategoryRepo := new(MockCategoryRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, usersWithBaseCurrency("USD"), new(MockTeamRepository), new(MockApprovalPolicyRepository), noExchangeRates(), noBudgets(), mockCategoryRepo, nil)
+
+	t.Run("Name is matched ignoring case", func(t *testing.T) {
+		mockCategoryRepo.On("GetCategoryByName", "user-1", (*string)(nil), "food").
+			Return(&models.Category{ID: "cat-food", Name: "Food"}, nil).Once()
+		mockExpenseRepo.On("CreateExpense", mock.MatchedBy(func(e *models.Expense) bool {
+			return e.Category == "Food" && *e.CategoryID == "cat-food"
+		})).Return(nil).Once()
+
+		expense, err := expenseService.CreateExpense("user-1", &models.CreateExpenseRequest{
+			Amount:      money.MustParse("12.50"),
+			Currency:    "USD",
+			Description: "Lunch",
+			Category:    "food",
+			ExpenseDate: "2026-03-02",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Food", expense.Category)
+		mockExpenseRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown category", func(t *testing.T) {
+		mockCategoryRepo.On("GetCategoryByName", "user-1", (*string)(nil), "Snacks").Return(nil, nil).Once()
+
+		_, err := expenseService.CreateExpense("user-1", &models.CreateExpenseRequest{
+			Amount:      money.MustParse("3"),
+			Currency:    "USD",
+			Description: "Chips",
+			Category:    "Snacks",
+			ExpenseDate: "2026-03-02",
+		})
+
+		assert.ErrorIs(t, err, ErrUnknownCategory)
+	})
+
+	t.Run("Archived category", func(t *testing.T) {
+		archivedAt := time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)
+		mockCategoryRepo.On("GetCategoryByName", "user-1", (*string)(nil), "Gym").
+			Return(&models.Category{ID: "cat-gym", UserID: strPtr("user-1"), Name: "Gym", ArchivedAt: &archivedAt}, nil).Once()
+
+		_, err := expenseService.CreateExpense("user-1", &models.CreateExpenseRequest{
+			Amount:      money.MustParse("40"),
+			Currency:    "USD",
+			Description: "Membership",
+			Category:    "Gym",
+			ExpenseDate: "2026-03-02",
+		})
+
+		assert.ErrorIs(t, err, ErrCategoryArchived)
+	})
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+    "encoding/base64"
+    "encoding/json"
+    "pocketpilot/internal/models"
+    "strings"
+)
+
+// encodeExpenseCursor builds the opaque cursor pointing just past the expense
+func encodeExpenseCursor(expense *models.Expense, order string) string {
+    date := expense.ExpenseDate
+    if len(date) > len("2006-01-02") {
+        date = date[:len("2006-01-02")]
+    }
+
+    cursor := models.ExpenseCursor{
+        ExpenseDate: date,
+        CreatedAt:   expense.CreatedAt,
+        ID:          expense.ID,
+        Order:       strings.ToLower(order),
+    }
+    if cursor.Order != "asc" {
+        cursor.Order = "desc"
+    }
+
+    data, _ := json.Marshal(cursor)
+    return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeExpenseCursor parses a cursor produced by encodeExpenseCursor
+func decodeExpenseCursor(raw string) (*models.ExpenseCursor, error) {
+    data, err := base64.RawURLEncoding.DecodeString(raw)
+    if err != nil {
+        return nil, ErrInvalidCursor
+    }
+
+    cursor := &models.ExpenseCursor{}
+    if err := json.Unmarshal(data, cursor); err != nil {
+        return nil, ErrInvalidCursor
+    }
+    if cursor.ExpenseDate == "" || cursor.ID == "" || cursor.CreatedAt.IsZero() {
+        return nil, ErrInvalidCursor
+    }
+
+    return cursor, nil
+}
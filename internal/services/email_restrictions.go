@@ -0,0 +1,58 @@
+package services
+
+import (
+    "fmt"
+    "pocketpilot/internal/models"
+    "strings"
+)
+
+// Actions that can be kept from users who have not verified their email
+const (
+    RestrictionJoinTeams      = "join_teams"
+    RestrictionCreateTeams    = "create_teams"
+    RestrictionSubmitExpenses = "submit_expenses"
+)
+
+// EmailRestrictions lists the actions that need a verified email. The zero
+// value restricts nothing.
+type EmailRestrictions map[string]bool
+
+// NewEmailRestrictions restricts the given actions; "none" restricts nothing
+func NewEmailRestrictions(actions []string) (EmailRestrictions, error) {
+    restrictions := EmailRestrictions{}
+    for _, action := range actions {
+        switch action = strings.ToLower(strings.TrimSpace(action)); action {
+        case RestrictionJoinTeams, RestrictionCreateTeams, RestrictionSubmitExpenses:
+            restrictions[action] = true
+        case "none", "":
+        default:
+            return nil, fmt.Errorf("unknown email verification restriction %q, use %s, %s, %s or none",
+                action, RestrictionJoinTeams, RestrictionCreateTeams, RestrictionSubmitExpenses)
+        }
+    }
+    return restrictions, nil
+}
+
+// check fails when a user who has not verified their email tries an action
+// that needs it
+func (r EmailRestrictions) check(user *models.User, action string) error {
+    if r[action] && user.EmailVerifiedAt == nil {
+        return ErrEmailNotVerified
+    }
+    return nil
+}
+
+// checkUser looks up the acting user only when the action is restricted
+func (r EmailRestrictions) checkUser(userRepo UserRepository, userID, action string) error {
+    if !r[action] {
+        return nil
+    }
+    user, err := userRepo.GetUserByID(userID)
+    if err != nil {
+        return err
+    }
+    if user == nil {
+        return ErrUserNotFound
+    }
+    return r.check(user, action)
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEmailRestrictions(t *testing.T) {
+	restrictions, err := NewEmailRestrictions([]string{" Join_Teams", "submit_expenses"})
+	require.NoError(t, err)
+	assert.Equal(t, EmailRestrictions{RestrictionJoinTeams: true, RestrictionSubmitExpenses: true}, restrictions)
+
+	restrictions, err = NewEmailRestrictions([]string{"none"})
+	require.NoError(t, err)
+	assert.Empty(t, restrictions)
+
+	_, err = NewEmailRestrictions([]string{"delete_everything"})
+	assert.Error(t, err)
+}
+
+func TestEmailRestrictions_Teams(t *testing.T) {
+	mockTeamRepo := new(MockTeamRepository)
+	mockUserRepo := new(MockUserRepository)
+	restrictions := EmailRestrictions{RestrictionJoinTeams: true, RestrictionCreateTeams: true}
+	teamService := NewTeamService(mockTeamRepo, mockUserRepo, restrictions)
+
+	verifiedAt := time.Now()
+	mockUserRepo.On("GetUserByID", "unverified").Return(&models.User{ID: "unverified"}, nil)
+	mockUserRepo.On("GetUserByEmail", "unverified@example.com").Return(&models.User{ID: "unverified"}, nil)
+	mockUserRepo.On("GetUserByEmail", "verified@example.com").Return(&models.User{ID: "verified", EmailVerifiedAt: &verifiedAt}, nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "owner").Return(teamMember("team-1", "owner", models.TeamRoleOwner), nil)
+
+	t.Run("Unverified user cannot create teams", func(t *testing.T) {
+		team, err := teamService.CreateTeam("unverified", &models.CreateTeamRequest{Name: "Trip"})
+
+		assert.ErrorIs(t, err, ErrEmailNotVerified)
+		assert.Nil(t, team)
+	})
+
+	t.Run("Unverified user cannot be added", func(t *testing.T) {
+		member, err := teamService.AddTeamMember("team-1", "owner", &models.AddTeamMemberRequest{Email: "unverified@example.com"})
+
+		assert.ErrorIs(t, err, ErrMemberEmailNotVerified)
+		assert.Nil(t, member)
+	})
+
+	t.Run("Verified user is added", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "verified").Return(nil, nil).Once()
+		mockTeamRepo.On("AddTeamMember", &models.TeamMember{TeamID: "team-1", UserID: "verified", Role: models.TeamRoleMember, User: &models.User{ID: "verified", EmailVerifiedAt: &verifiedAt}}).Return(nil).Once()
+
+		member, err := teamService.AddTeamMember("team-1", "owner", &models.AddTeamMemberRequest{Email: "verified@example.com"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "verified", member.UserID)
+	})
+}
+
+func TestEmailRestrictions_SubmitExpense(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	mockUserRepo := new(MockUserRepository)
+	restrictions := EmailRestrictions{RestrictionSubmitExpenses: true}
+	expenseService := NewExpenseService(mockExpenseRepo, mockUserRepo, mockTeamRepo, new(MockApprovalPolicyRepository), new(MockExchangeRateRepository), noBudgets(), anyCategory(), restrictions)
+
+	mockTeamRepo.On("GetTeamMember", "team-1", "member").Return(teamMember("team-1", "member", models.TeamRoleMember), nil)
+	mockUserRepo.On("GetUserByID", "member").Return(&models.User{ID: "member"}, nil)
+	mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(&models.Expense{ID: "exp-1", UserID: "member", TeamID: strPtr("team-1"), Status: models.ExpenseStatusDraft}, nil)
+
+	_, err := expenseService.SubmitExpense("exp-1", "member", &models.ExpenseActionRequest{})
+
+	assert.ErrorIs(t, err, ErrEmailNotVerified)
+}
@@ -0,0 +1,99 @@
+package services
+
+import "errors"
+
+// Errors returned by services that handlers map to specific HTTP statuses
+var (
+    ErrTeamNotFound       = errors.New("team not found")
+    ErrTeamMemberNotFound = errors.New("team member not found")
+    ErrTeamAccessDenied   = errors.New("access denied")
+    ErrAlreadyTeamMember  = errors.New("user is already a member of this team")
+    ErrInvalidTeamRole    = errors.New("invalid team role, use owner, admin, member or viewer")
+    ErrLastTeamOwner      = errors.New("team must keep at least one owner")
+    ErrUserNotFound       = errors.New("user not found")
+
+    ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired")
+    ErrRefreshTokenReused  = errors.New("refresh token was already used, log in again")
+    ErrSessionNotFound     = errors.New("session not found")
+    ErrIncorrectPassword   = errors.New("current password is incorrect")
+    ErrInvalidResetToken   = errors.New("password reset link is invalid, used or expired")
+
+    ErrEmailNotVerified           = errors.New("verify your email address first")
+    ErrMemberEmailNotVerified     = errors.New("this user has not verified their email address yet")
+    ErrEmailAlreadyVerified       = errors.New("email address is already verified")
+    ErrInvalidVerificationToken   = errors.New("verification link is invalid or expired")
+    ErrVerificationEmailThrottled = errors.New("a verification email was sent recently, try again in a few minutes")
+
+    ErrTwoFactorEnabled     = errors.New("two-factor authentication is already on")
+    ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is off")
+    ErrTwoFactorNotSetUp    = errors.New("start two-factor setup first")
+    ErrInvalidTwoFactorCode = errors.New("two-factor code is invalid or was already used")
+    ErrInvalidMFAChallenge  = errors.New("two-factor login expired or had too many attempts, log in again")
+
+    ErrExpenseNotFound     = errors.New("expense not found")
+    ErrExpenseAccessDenied = errors.New("access denied")
+
+    ErrExpenseLocked           = errors.New("expense can only be changed while it is a draft or rejected")
+    ErrInvalidTransition       = errors.New("expense status does not allow this action")
+    ErrRejectionReasonRequired = errors.New("a reason is required to reject an expense")
+    ErrSelfApproval            = errors.New("you cannot approve or reject your own expense")
+    ErrNotTeamExpense          = errors.New("only team expenses go through approval")
+
+    ErrInvalidCursor         = errors.New("invalid pagination cursor")
+    ErrCursorSortUnsupported = errors.New("cursor pagination is only available when sorting by expense_date")
+
+    ErrUnsupportedExportFormat = errors.New("unsupported export format, use csv or xlsx")
+    ErrInvalidExportColumn     = errors.New("unknown export column")
+    ErrInvalidDateFormat       = errors.New("invalid date format, combine YYYY, YY, MM and DD with - . / or spaces")
+
+    ErrInvalidImportFile    = errors.New("import file must be a CSV with a header row")
+    ErrInvalidImportMapping = errors.New("invalid column mapping")
+    ErrImportTooLarge       = errors.New("imports are limited to 10000 rows")
+
+    ErrUnsupportedStatementFormat = errors.New("unsupported statement format, use ofx, qfx or qif")
+    ErrInvalidStatement           = errors.New("invalid bank statement")
+
+    ErrRecurringExpenseNotFound = errors.New("recurring expense not found")
+    ErrInvalidRecurrence        = errors.New("invalid recurrence, use a daily, weekly, monthly or yearly frequency with a positive interval")
+
+    ErrInvalidCurrency       = errors.New("currency must be a three-letter ISO 4217 code")
+    ErrUnsupportedRateFormat = errors.New("unsupported exchange rate format, use ecb or csv")
+    ErrInvalidRateFile       = errors.New("invalid exchange rate file")
+    ErrInvalidExchangeRate   = errors.New("invalid exchange rate")
+
+    ErrExpenseNotSplit   = errors.New("expense is not split")
+    ErrSplitNeedsTeam    = errors.New("only team expenses can be split")
+    ErrInvalidSplit      = errors.New("invalid split")
+    ErrExpenseIsSplit    = errors.New("expense is split, change or remove the split before changing its amount")
+    ErrInvalidSettlement = errors.New("invalid settlement")
+
+    ErrBudgetNotFound = errors.New("budget not found")
+    ErrInvalidBudget  = errors.New("invalid budget")
+
+    ErrInvalidReport = errors.New("invalid report")
+
+    ErrCategoryNotFound = errors.New("category not found")
+    ErrUnknownCategory  = errors.New("unknown category, pick an existing one or create it first")
+    ErrCategoryArchived = errors.New("category is archived")
+    ErrCategoryExists   = errors.New("a category with this name already exists")
+    ErrInvalidCategory  = errors.New("invalid category")
+    ErrSystemCategory   = errors.New("system categories cannot be changed")
+
+    ErrTagNotFound = errors.New("tag not found")
+    ErrTagExists   = errors.New("a tag with this name already exists, merge the tags instead")
+    ErrInvalidTag  = errors.New("invalid tag")
+
+    ErrReceiptNotFound        = errors.New("receipt not found")
+    ErrInvalidReceipt         = errors.New("invalid receipt")
+    ErrReceiptTooLarge        = errors.New("receipts are limited to 10 MB")
+    ErrUnsupportedReceiptType = errors.New("unsupported receipt type, upload a JPEG, PNG, WebP or HEIC image or a PDF")
+    ErrInvalidSignedURL       = errors.New("download link is invalid or expired")
+    ErrReceiptOCRDisabled     = errors.New("receipt scanning is not configured")
+
+    ErrApprovalPolicyNotFound = errors.New("approval policy not found")
+    ErrInvalidApprovalStep    = errors.New("each approval step needs exactly one of approver_role or approver_user_id")
+    ErrInvalidApprover        = errors.New("approval steps can only be decided by team owners and admins")
+    ErrDuplicateApprover      = errors.New("a user can only be named on one approval step")
+    ErrNotStepApprover        = errors.New("you are not the approver for the current approval step")
+    ErrAlreadyApproved        = errors.New("you already decided an earlier step of this approval")
+)
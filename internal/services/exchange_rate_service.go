@@ -0,0 +1,241 @@
+package services
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "io"
+    "math/big"
+    "pocketpilot/internal/models"
+    "pocketpilot/pkg/exchangerate"
+    "pocketpilot/pkg/money"
+    "sort"
+    "strings"
+    "time"
+)
+
+// defaultBaseCurrency is used for users and teams that have not chosen one
+const defaultBaseCurrency = "USD"
+
+type ExchangeRateService struct {
+    rateRepo ExchangeRateRepository
+}
+
+func NewExchangeRateService(rateRepo ExchangeRateRepository) *ExchangeRateService {
+    return &ExchangeRateService{rateRepo: rateRepo}
+}
+
+// ImportRates stores the rates of an ECB XML or CSV rate file, replacing rates
+// already stored for the same currencies and dates
+func (s *ExchangeRateService) ImportRates(r io.Reader, format string) (*models.ExchangeRateImportResult, error) {
+    buffered := bufio.NewReader(r)
+    format = strings.ToLower(strings.TrimSpace(format))
+    if format == "" {
+        head, _ := buffered.Peek(512)
+        format = exchangerate.Detect(head)
+    }
+
+    parsed, err := exchangerate.Parse(buffered, format)
+    if err != nil {
+        if errors.Is(err, exchangerate.ErrUnknownFormat) {
+            return nil, ErrUnsupportedRateFormat
+        }
+        return nil, fmt.Errorf("%w: %v", ErrInvalidRateFile, err)
+    }
+
+    source := models.ExchangeRateSourceCSV
+    if format == exchangerate.FormatECB || format == "xml" {
+        source = models.ExchangeRateSourceECB
+    }
+
+    rates := make([]*models.ExchangeRate, len(parsed))
+    for i, rate := range parsed {
+        rates[i] = &models.ExchangeRate{
+            BaseCurrency: rate.Base,
+            Currency:     rate.Currency,
+            RateDate:     rate.Date.Format("2006-01-02"),
+            Rate:         rate.Value,
+            Source:       source,
+        }
+    }
+
+    if err := s.rateRepo.SaveExchangeRates(rates); err != nil {
+        return nil, err
+    }
+    return importResult(rates), nil
+}
+
+// SetRates stores rates entered by hand
+func (s *ExchangeRateService) SetRates(req *models.SetExchangeRatesRequest) (*models.ExchangeRateImportResult, error) {
+    rates := make([]*models.ExchangeRate, len(req.Rates))
+    for i, input := range req.Rates {
+        base, err := money.NormalizeCurrency(input.BaseCurrency)
+        if err != nil {
+            return nil, ErrInvalidCurrency
+        }
+        currency, err := money.NormalizeCurrency(input.Currency)
+        if err != nil {
+            return nil, ErrInvalidCurrency
+        }
+        if base == currency || input.Rate <= 0 {
+            return nil, ErrInvalidExchangeRate
+        }
+        if _, err := time.Parse("2006-01-02", input.RateDate); err != nil {
+            return nil, errors.New("invalid rate date format, use YYYY-MM-DD")
+        }
+
+        rates[i] = &models.ExchangeRate{
+            BaseCurrency: base,
+            Currency:     currency,
+            RateDate:     input.RateDate,
+            Rate:         input.Rate,
+            Source:       models.ExchangeRateSourceManual,
+        }
+    }
+
+    if err := s.rateRepo.SaveExchangeRates(rates); err != nil {
+        return nil, err
+    }
+    return importResult(rates), nil
+}
+
+// GetRates lists the latest stored rate of each currency pair on the date,
+// today when empty, optionally only the pairs involving a currency
+func (s *ExchangeRateService) GetRates(date, currency string) ([]*models.ExchangeRate, error) {
+    if date == "" {
+        date = time.Now().UTC().Format("2006-01-02")
+    }
+    if _, err := time.Parse("2006-01-02", date); err != nil {
+        return nil, errors.New("invalid date format, use YYYY-MM-DD")
+    }
+
+    var currencies []string
+    if currency != "" {
+        code, err := money.NormalizeCurrency(currency)
+        if err != nil {
+            return nil, ErrInvalidCurrency
+        }
+        currencies = []string{code}
+    }
+
+    return s.rateRepo.GetExchangeRates(date, currencies)
+}
+
+func importResult(rates []*models.ExchangeRate) *models.ExchangeRateImportResult {
+    result := &models.ExchangeRateImportResult{Imported: len(rates), Currencies: []string{}}
+    seen := make(map[string]bool)
+    for _, rate := range rates {
+        for _, code := range []string{rate.BaseCurrency, rate.Currency} {
+            if !seen[code] {
+                seen[code] = true
+                result.Currencies = append(result.Currencies, code)
+            }
+        }
+        if result.FirstDate == "" || rate.RateDate < result.FirstDate {
+            result.FirstDate = rate.RateDate
+        }
+        if rate.RateDate > result.LastDate {
+            result.LastDate = rate.RateDate
+        }
+    }
+    sort.Strings(result.Currencies)
+    return result
+}
+
+// rateConverter converts amounts with the rates known on each date, loading
+// a date's rates once. Pairs without a stored rate are converted through the
+// inverse rate or through a currency both have a rate against, like EUR for
+// ECB rates.
+type rateConverter struct {
+    rateRepo ExchangeRateRepository
+    dates    map[string]map[ratePair]*big.Rat
+}
+
+type ratePair struct {
+    from, to string
+}
+
+func newRateConverter(rateRepo ExchangeRateRepository) *rateConverter {
+    return &rateConverter{rateRepo: rateRepo, dates: make(map[string]map[ratePair]*big.Rat)}
+}
+
+// Rate returns how many units of to one unit of from bought on the date. It
+// returns false when no rate is known.
+func (c *rateConverter) Rate(from, to, date string) (money.Rate, bool, error) {
+    if from == to {
+        rate, _ := money.RateFromRat(big.NewRat(1, 1))
+        return rate, true, nil
+    }
+
+    rates, err := c.ratesOn(date)
+    if err != nil {
+        return 0, false, err
+    }
+
+    ratio := crossRate(rates, from, to)
+    if ratio == nil {
+        return 0, false, nil
+    }
+    rate, err := money.RateFromRat(ratio)
+    if err != nil {
+        return 0, false, nil
+    }
+    return rate, true, nil
+}
+
+// Convert converts an amount on the date. It returns nil when no rate is known.
+func (c *rateConverter) Convert(amount money.Amount, from, to, date string) (*money.Amount, error) {
+    rate, ok, err := c.Rate(from, to, date)
+    if err != nil || !ok {
+        return nil, err
+    }
+    converted, err := rate.Convert(amount, to)
+    if err != nil {
+        return nil, err
+    }
+    return &converted, nil
+}
+
+func (c *rateConverter) ratesOn(date string) (map[ratePair]*big.Rat, error) {
+    if rates, ok := c.dates[date]; ok {
+        return rates, nil
+    }
+
+    stored, err := c.rateRepo.GetExchangeRates(date, nil)
+    if err != nil {
+        return nil, err
+    }
+    rates := make(map[ratePair]*big.Rat, len(stored))
+    for _, rate := range stored {
+        rates[ratePair{rate.BaseCurrency, rate.Currency}] = rate.Rate.Rat()
+    }
+    c.dates[date] = rates
+    return rates, nil
+}
+
+// crossRate finds the rate from one currency to another: stored directly,
+// as the inverse of the opposite pair, or across a shared base currency
+func crossRate(rates map[ratePair]*big.Rat, from, to string) *big.Rat {
+    if rate, ok := rates[ratePair{from, to}]; ok {
+        return rate
+    }
+    if rate, ok := rates[ratePair{to, from}]; ok {
+        return new(big.Rat).Inv(rate)
+    }
+
+    // Stable choice of the shared base when there are several
+    var bases []string
+    for pair := range rates {
+        if pair.to == from {
+            if _, ok := rates[ratePair{pair.from, to}]; ok {
+                bases = append(bases, pair.from)
+            }
+        }
+    }
+    if len(bases) == 0 {
+        return nil
+    }
+    sort.Strings(bases)
+    base := bases[0]
+    return new(big.Rat).Quo(rates[ratePair{base, to}], rates[ratePair{base, from}])
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/money"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockExchangeRateRepository struct {
+	mock.Mock
+}
+
+func (m *MockExchangeRateRepository) SaveExchangeRates(rates []*models.ExchangeRate) error {
+	args := m.Called(rates)
+	return args.Error(0)
+}
+
+func (m *MockExchangeRateRepository) GetExchangeRates(date string, currencies []string) ([]*models.ExchangeRate, error) {
+	args := m.Called(date, currencies)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.ExchangeRate), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// noExchangeRates returns a rate repository without any stored rates
+func noExchangeRates() *MockExchangeRateRepository {
+	repo := new(MockExchangeRateRepository)
+	repo.On("GetExchangeRates", mock.Anything, mock.Anything).Return(nil, nil)
+	return repo
+}
+
+// usersWithBaseCurrency returns a user repository where every user converts to currency
+func usersWithBaseCurrency(currency string) *MockUserRepository {
+	repo := new(MockUserRepository)
+	repo.On("GetUserByID", mock.Anything).Return(&models.User{BaseCurrency: currency}, nil)
+	return repo
+}
+
+const ecbDaily = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2026-01-15">
+			<Cube currency="USD" rate="1.0876"/>
+			<Cube currency="JPY" rate="161.37"/>
+			<Cube currency="GBP" rate="0.8543"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestExchangeRateService_ImportRates(t *testing.T) {
+	t.Run("ECB XML is detected", func(t *testing.T) {
+		mockRateRepo := new(MockExchangeRateRepository)
+		rateService := NewExchangeRateService(mockRateRepo)
+		mockRateRepo.On("SaveExchangeRates", mock.MatchedBy(func(rates []*models.ExchangeRate) bool {
+			return len(rates) == 3 && rates[0].BaseCurrency == "EUR" && rates[0].Currency == "USD" &&
+				rates[0].RateDate == "2026-01-15" && rates[0].Rate.String() == "1.0876" &&
+				rates[0].Source == models.ExchangeRateSourceECB
+		})).Return(nil).Once()
+
+		result, err := rateService.ImportRates(strings.NewReader(ecbDaily), "")
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Imported)
+		assert.Equal(t, []string{"EUR", "GBP", "JPY", "USD"}, result.Currencies)
+		assert.Equal(t, "2026-01-15", result.FirstDate)
+		mockRateRepo.AssertExpectations(t)
+	})
+
+	t.Run("ECB wide CSV skips missing rates", func(t *testing.T) {
+		mockRateRepo := new(MockExchangeRateRepository)
+		rateService := NewExchangeRateService(mockRateRepo)
+		csv := "Date, USD, JPY, CYP,\n2026-01-16, 1.09, 162.1, N/A,\n2026-01-15, 1.0876, 161.37, N/A,\n"
+		mockRateRepo.On("SaveExchangeRates", mock.MatchedBy(func(rates []*models.ExchangeRate) bool {
+			return len(rates) == 4 && rates[3].Currency == "JPY" && rates[3].Source == models.ExchangeRateSourceCSV
+		})).Return(nil).Once()
+
+		result, err := rateService.ImportRates(strings.NewReader(csv), "csv")
+
+		require.NoError(t, err)
+		assert.Equal(t, 4, result.Imported)
+		assert.Equal(t, "2026-01-15", result.FirstDate)
+		assert.Equal(t, "2026-01-16", result.LastDate)
+	})
+
+	t.Run("One rate per row with a base column", func(t *testing.T) {
+		mockRateRepo := new(MockExchangeRateRepository)
+		rateService := NewExchangeRateService(mockRateRepo)
+		csv := "date,base,currency,rate\n2026-01-15,usd,kes,129.5\n"
+		mockRateRepo.On("SaveExchangeRates", mock.MatchedBy(func(rates []*models.ExchangeRate) bool {
+			return len(rates) == 1 && rates[0].BaseCurrency == "USD" && rates[0].Currency == "KES"
+		})).Return(nil).Once()
+
+		_, err := rateService.ImportRates(strings.NewReader(csv), "")
+
+		require.NoError(t, err)
+	})
+
+	t.Run("Bad files are rejected", func(t *testing.T) {
+		rateService := NewExchangeRateService(new(MockExchangeRateRepository))
+
+		_, err := rateService.ImportRates(strings.NewReader("date,currency,rate\n2026-01-15,USD,-1\n"), "")
+		assert.ErrorIs(t, err, ErrInvalidRateFile)
+
+		_, err = rateService.ImportRates(strings.NewReader(ecbDaily), "json")
+		assert.ErrorIs(t, err, ErrUnsupportedRateFormat)
+	})
+}
+
+func TestExchangeRateService_SetRates(t *testing.T) {
+	mockRateRepo := new(MockExchangeRateRepository)
+	rateService := NewExchangeRateService(mockRateRepo)
+
+	t.Run("Same currency twice", func(t *testing.T) {
+		_, err := rateService.SetRates(&models.SetExchangeRatesRequest{Rates: []models.ExchangeRateInput{
+			{BaseCurrency: "usd", Currency: "USD", RateDate: "2026-01-15", Rate: money.Rate(10000000000)},
+		}})
+		assert.ErrorIs(t, err, ErrInvalidExchangeRate)
+	})
+
+	t.Run("Codes are normalized", func(t *testing.T) {
+		mockRateRepo.On("SaveExchangeRates", mock.MatchedBy(func(rates []*models.ExchangeRate) bool {
+			return rates[0].BaseCurrency == "USD" && rates[0].Currency == "EUR" && rates[0].Source == models.ExchangeRateSourceManual
+		})).Return(nil).Once()
+
+		rate, err := money.ParseRate("0.92")
+		require.NoError(t, err)
+		result, err := rateService.SetRates(&models.SetExchangeRatesRequest{Rates: []models.ExchangeRateInput{
+			{BaseCurrency: "usd", Currency: "eur", RateDate: "2026-01-15", Rate: rate},
+		}})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Imported)
+	})
+}
+
+// ecbRates returns the rates stored from ecbDaily
+func ecbRates() []*models.ExchangeRate {
+	rate := func(currency, value string) *models.ExchangeRate {
+		parsed, _ := money.ParseRate(value)
+		return &models.ExchangeRate{BaseCurrency: "EUR", Currency: currency, RateDate: "2026-01-15", Rate: parsed}
+	}
+	return []*models.ExchangeRate{rate("USD", "1.0876"), rate("JPY", "161.37"), rate("GBP", "0.8543")}
+}
+
+func TestRateConverter(t *testing.T) {
+	mockRateRepo := new(MockExchangeRateRepository)
+	mockRateRepo.On("GetExchangeRates", "2026-01-15", []string(nil)).Return(ecbRates(), nil).Once()
+	mockRateRepo.On("GetExchangeRates", "1999-01-01", []string(nil)).Return(nil, nil).Once()
+	converter := newRateConverter(mockRateRepo)
+
+	tests := []struct {
+		amount string
+		from   string
+		to     string
+		want   string
+	}{
+		{"100", "EUR", "USD", "108.76"},
+		{"108.76", "USD", "EUR", "100"},
+		{"10", "USD", "JPY", "1484"},
+		{"10", "GBP", "USD", "12.73"},
+		{"5", "USD", "USD", "5"},
+	}
+	for _, tt := range tests {
+		converted, err := converter.Convert(money.MustParse(tt.amount), tt.from, tt.to, "2026-01-15")
+
+		require.NoError(t, err)
+		require.NotNil(t, converted, tt.from+" to "+tt.to)
+		assert.Equal(t, tt.want, converted.String(), tt.from+" to "+tt.to)
+	}
+
+	converted, err := converter.Convert(money.MustParse("10"), "USD", "EUR", "1999-01-01")
+	require.NoError(t, err)
+	assert.Nil(t, converted)
+	mockRateRepo.AssertExpectations(t)
+}
+
+func TestExpenseService_ExchangeRates(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockRateRepo := new(MockExchangeRateRepository)
+	mockRateRepo.On("GetExchangeRates", "2026-01-15", []string(nil)).Return(ecbRates(), nil)
+	mockRateRepo.On("GetExchangeRates", "2026-01-16", []string(nil)).Return(nil, nil)
+	expenseService := NewExpenseService(mockExpenseRepo, usersWithBaseCurrency("USD"), new(MockTeamRepository), new(MockApprovalPolicyRepository), mockRateRepo, noBudgets(), anyCategory(), nil)
+
+	t.Run("Rate is recorded at creation", func(t *testing.T) {
+		mockExpenseRepo.On("CreateExpense", mock.AnythingOfType("*models.Expense")).Return(nil).Once()
+
+		expense, err := expenseService.CreateExpense("user-1", &models.CreateExpenseRequest{
+			Amount:      money.MustParse("20"),
+			Currency:    "gbp",
+			Description: "Train",
+			Category:    "Travel",
+			ExpenseDate: "2026-01-15",
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, expense.BaseCurrency)
+		assert.Equal(t, "USD", *expense.BaseCurrency)
+		require.NotNil(t, expense.ExchangeRate)
+		assert.Equal(t, "1.2730890788", expense.ExchangeRate.String())
+	})
+
+	t.Run("Listings convert to the base currency", func(t *testing.T) {
+		recorded, _ := money.ParseRate("1.25")
+		mockExpenseRepo.On("GetExpensesByUser", "user-1", mock.AnythingOfType("*models.ExpenseFilter"), 11, 0).Return([]*models.Expense{
+			{ID: "recorded", Amount: money.MustParse("10"), Currency: "GBP", ExpenseDate: "2026-01-15T00:00:00Z", BaseCurrency: strPtr("USD"), ExchangeRate: &recorded},
+			{ID: "looked-up", Amount: money.MustParse("100"), Currency: "EUR", ExpenseDate: "2026-01-15T00:00:00Z"},
+			{ID: "missing", Amount: money.MustParse("100"), Currency: "EUR", ExpenseDate: "2026-01-16T00:00:00Z"},
+		}, nil).Once()
+
+		page, err := expenseService.GetUserExpenses("user-1", nil, nil)
+
+		require.NoError(t, err)
+		require.Len(t, page.Expenses, 3)
+		assert.Equal(t, "12.5", page.Expenses[0].ConvertedAmount.String())
+		assert.Equal(t, "108.76", page.Expenses[1].ConvertedAmount.String())
+		assert.Equal(t, "USD", page.Expenses[1].ConvertedCurrency)
+		assert.Nil(t, page.Expenses[2].ConvertedAmount)
+	})
+
+	t.Run("Totals sum each currency and the converted total", func(t *testing.T) {
+		mockExpenseRepo.On("StreamExpensesByUser", "user-1", mock.AnythingOfType("*models.ExpenseFilter"), mock.Anything).
+			Run(func(args mock.Arguments) {
+				fn := args.Get(2).(func(*models.Expense) error)
+				for _, expense := range []*models.Expense{
+					{Amount: money.MustParse("100"), Currency: "EUR", ExpenseDate: "2026-01-15"},
+					{Amount: money.MustParse("50"), Currency: "EUR", ExpenseDate: "2026-01-15"},
+					{Amount: money.MustParse("10.5"), Currency: "USD", ExpenseDate: "2026-01-16"},
+					{Amount: money.MustParse("1000"), Currency: "JPY", ExpenseDate: "2026-01-16"},
+				} {
+					require.NoError(t, fn(expense))
+				}
+			}).Return(nil).Once()
+
+		totals, err := expenseService.GetUserExpenseTotals("user-1", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "USD", totals.BaseCurrency)
+		assert.Equal(t, 4, totals.Count)
+		assert.Equal(t, 1, totals.Unconverted)
+		assert.Equal(t, "173.64", totals.Total.String())
+		require.Len(t, totals.ByCurrency, 3)
+		assert.Equal(t, "EUR", totals.ByCurrency[0].Currency)
+		assert.Equal(t, "150", totals.ByCurrency[0].Amount.String())
+		assert.Equal(t, "163.14", totals.ByCurrency[0].Converted.String())
+		assert.Equal(t, "JPY", totals.ByCurrency[1].Currency)
+		assert.Nil(t, totals.ByCurrency[1].Converted)
+	})
+}
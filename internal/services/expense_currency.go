@@ -0,0 +1,204 @@
+package services
+
+import (
+    "pocketpilot/internal/models"
+    "pocketpilot/pkg/money"
+    "sort"
+)
+
+// baseCurrency returns the currency a team's expenses, or a user's personal
+// expenses when teamID is nil, are converted to
+func (s *ExpenseService) baseCurrency(userID string, teamID *string) (string, error) {
+    return baseCurrencyFor(s.userRepo, s.teamRepo, userID, teamID)
+}
+
+func baseCurrencyFor(userRepo UserRepository, teamRepo TeamRepository, userID string, teamID *string) (string, error) {
+    if teamID != nil {
+        team, err := teamRepo.GetTeamByID(*teamID)
+        if err != nil {
+            return "", err
+        }
+        if team == nil {
+            return "", ErrTeamNotFound
+        }
+        if team.BaseCurrency != "" {
+            return team.BaseCurrency, nil
+        }
+        return defaultBaseCurrency, nil
+    }
+
+    user, err := userRepo.GetUserByID(userID)
+    if err != nil {
+        return "", err
+    }
+    if user != nil && user.BaseCurrency != "" {
+        return user.BaseCurrency, nil
+    }
+    return defaultBaseCurrency, nil
+}
+
+// recordExchangeRates stamps expenses with their owner's base currency and the
+// rate to it on the expense date, leaving the rate unset when none is stored
+func (s *ExpenseService) recordExchangeRates(expenses ...*models.Expense) error {
+    converter := newRateConverter(s.rateRepo)
+    bases := make(map[string]string)
+
+    for _, expense := range expenses {
+        key := "user:" + expense.UserID
+        if expense.TeamID != nil {
+            key = "team:" + *expense.TeamID
+        }
+        base, ok := bases[key]
+        if !ok {
+            var err error
+            base, err = s.baseCurrency(expense.UserID, expense.TeamID)
+            if err != nil {
+                return err
+            }
+            bases[key] = base
+        }
+
+        if err := stampExchangeRate(converter, expense, base); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// stampExchangeRate records base and the rate to it on the expense date on an
+// expense, leaving the rate unset when none is stored
+func stampExchangeRate(converter *rateConverter, expense *models.Expense, base string) error {
+    rate, found, err := converter.Rate(expense.Currency, base, expenseDay(expense))
+    if err != nil {
+        return err
+    }
+    expense.BaseCurrency = &base
+    expense.ExchangeRate = nil
+    if found {
+        expense.ExchangeRate = &rate
+    }
+    return nil
+}
+
+// convertExpenses fills in the amounts of expenses in the base currency, with
+// the rate recorded on the expense when it was for the same base currency
+func (s *ExpenseService) convertExpenses(expenses []*models.Expense, base string) error {
+    converter := newRateConverter(s.rateRepo)
+    for _, expense := range expenses {
+        converted, err := convertExpense(converter, expense, base)
+        if err != nil {
+            return err
+        }
+        expense.ConvertedAmount = converted
+        expense.ConvertedCurrency = base
+    }
+    return nil
+}
+
+func convertExpense(converter *rateConverter, expense *models.Expense, base string) (*money.Amount, error) {
+    if expense.ExchangeRate != nil && expense.BaseCurrency != nil && *expense.BaseCurrency == base {
+        converted, err := expense.ExchangeRate.Convert(expense.Amount, base)
+        if err != nil {
+            return nil, err
+        }
+        return &converted, nil
+    }
+    return converter.Convert(expense.Amount, expense.Currency, base, expenseDay(expense))
+}
+
+// expenseDay returns the expense date as YYYY-MM-DD, as scanned dates carry a time
+func expenseDay(expense *models.Expense) string {
+    if len(expense.ExpenseDate) > 10 {
+        return expense.ExpenseDate[:10]
+    }
+    return expense.ExpenseDate
+}
+
+// GetUserExpenseTotals sums a user's expenses matching the filter in their base currency
+func (s *ExpenseService) GetUserExpenseTotals(userID string, filter *models.ExpenseFilter) (*models.ExpenseTotals, error) {
+    base, err := s.baseCurrency(userID, nil)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.expenseTotals(filter, base, func(f *models.ExpenseFilter, fn func(*models.Expense) error) error {
+        return s.expenseRepo.StreamExpensesByUser(userID, f, fn)
+    })
+}
+
+// GetTeamExpenseTotals sums a team's expenses matching the filter in the team's
+// base currency, for members who can read them
+func (s *ExpenseService) GetTeamExpenseTotals(teamID, userID string, filter *models.ExpenseFilter) (*models.ExpenseTotals, error) {
+    if _, err := s.teamAuth.Authorize(teamID, userID, PermReadTeamExpenses); err != nil {
+        return nil, err
+    }
+    base, err := s.baseCurrency(userID, &teamID)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.expenseTotals(filter, base, func(f *models.ExpenseFilter, fn func(*models.Expense) error) error {
+        return s.expenseRepo.StreamExpensesByTeam(teamID, f, fn)
+    })
+}
+
+// expenseTotals converts each expense with the rate of its own date. Expenses
+// without a known rate are counted per currency but left out of the total.
+func (s *ExpenseService) expenseTotals(
+    filter *models.ExpenseFilter,
+    base string,
+    stream func(*models.ExpenseFilter, func(*models.Expense) error) error,
+) (*models.ExpenseTotals, error) {
+    if filter == nil {
+        filter = &models.ExpenseFilter{}
+    }
+    if err := validateExpenseFilter(filter); err != nil {
+        return nil, err
+    }
+
+    converter := newRateConverter(s.rateRepo)
+    totals := &models.ExpenseTotals{BaseCurrency: base, ByCurrency: []models.CurrencyTotal{}}
+    byCurrency := make(map[string]*models.CurrencyTotal)
+
+    err := stream(filter, func(expense *models.Expense) error {
+        converted, err := convertExpense(converter, expense, base)
+        if err != nil {
+            return err
+        }
+
+        currency := byCurrency[expense.Currency]
+        if currency == nil {
+            zero := money.Amount(0)
+            currency = &models.CurrencyTotal{Currency: expense.Currency, Converted: &zero}
+            byCurrency[expense.Currency] = currency
+        }
+        currency.Count++
+        currency.Amount = currency.Amount.Add(expense.Amount)
+        totals.Count++
+
+        if converted == nil {
+            currency.Converted = nil
+            totals.Unconverted++
+            return nil
+        }
+        if currency.Converted != nil {
+            sum := currency.Converted.Add(*converted)
+            currency.Converted = &sum
+        }
+        totals.Total = totals.Total.Add(*converted)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    for _, currency := range byCurrency {
+        totals.ByCurrency = append(totals.ByCurrency, *currency)
+    }
+    sort.Slice(totals.ByCurrency, func(i, j int) bool {
+        return totals.ByCurrency[i].Currency < totals.ByCurrency[j].Currency
+    })
+
+    return totals, nil
+}
@@ -0,0 +1,383 @@
+package services
+
+import (
+    "encoding/csv"
+    "io"
+    "pocketpilot/internal/models"
+    "pocketpilot/pkg/money"
+    "pocketpilot/pkg/xlsx"
+    "strings"
+    "time"
+)
+
+// exportFlushEvery is how many rows are buffered before they are sent on
+const exportFlushEvery = 500
+
+// exportLocale holds the formatting conventions of a locale
+type exportLocale struct {
+    DateFormat string // in YYYY/MM/DD tokens
+    Decimal    string
+    Delimiter  rune // CSV field separator; locales with a decimal comma use semicolons
+}
+
+// defaultExportLocale is used when no known locale is given
+var defaultExportLocale = exportLocale{DateFormat: "YYYY-MM-DD", Decimal: ".", Delimiter: ','}
+
+var exportLocales = map[string]exportLocale{
+    "en-us": {DateFormat: "MM/DD/YYYY", Decimal: ".", Delimiter: ','},
+    "en-gb": {DateFormat: "DD/MM/YYYY", Decimal: ".", Delimiter: ','},
+    "de-de": {DateFormat: "DD.MM.YYYY", Decimal: ",", Delimiter: ';'},
+    "fr-fr": {DateFormat: "DD/MM/YYYY", Decimal: ",", Delimiter: ';'},
+    "es-es": {DateFormat: "DD/MM/YYYY", Decimal: ",", Delimiter: ';'},
+    "it-it": {DateFormat: "DD/MM/YYYY", Decimal: ",", Delimiter: ';'},
+    "nl-nl": {DateFormat: "DD-MM-YYYY", Decimal: ",", Delimiter: ';'},
+    "pt-br": {DateFormat: "DD/MM/YYYY", Decimal: ",", Delimiter: ';'},
+    "ja-jp": {DateFormat: "YYYY/MM/DD", Decimal: ".", Delimiter: ','},
+}
+
+// exportColumn is a column an export can include
+type exportColumn struct {
+    Header string
+    Value  func(*models.Expense) interface{} // string, money.Amount, time.Time (date-time) or exportDate
+}
+
+// exportDate is a calendar date without a time of day
+type exportDate time.Time
+
+var exportColumns = map[string]exportColumn{
+    "id":          {"ID", func(e *models.Expense) interface{} { return e.ID }},
+    "date":        {"Date", func(e *models.Expense) interface{} { return expenseDateValue(e) }},
+    "description": {"Description", func(e *models.Expense) interface{} { return e.Description }},
+    "category":    {"Category", func(e *models.Expense) interface{} { return e.Category }},
+    "amount":      {"Amount", func(e *models.Expense) interface{} { return e.Amount }},
+    "currency":    {"Currency", func(e *models.Expense) interface{} { return e.Currency }},
+    "status":      {"Status", func(e *models.Expense) interface{} { return e.Status }},
+    "team_id":     {"Team ID", func(e *models.Expense) interface{} { return derefString(e.TeamID) }},
+    "user_id":     {"User ID", func(e *models.Expense) interface{} { return e.UserID }},
+    "receipt_url": {"Receipt URL", func(e *models.Expense) interface{} { return derefString(e.ReceiptImageURL) }},
+    "created_at":  {"Created At", func(e *models.Expense) interface{} { return e.CreatedAt.UTC() }},
+    "updated_at":  {"Updated At", func(e *models.Expense) interface{} { return e.UpdatedAt.UTC() }},
+}
+
+// defaultExportColumns are exported when no columns are requested
+var defaultExportColumns = []string{"date", "description", "category", "amount", "currency", "status"}
+
+// expenseRowWriter writes an export file one row at a time
+type expenseRowWriter interface {
+    WriteHeader(columns []exportColumn) error
+    WriteExpense(columns []exportColumn, expense *models.Expense) error
+    Flush() error
+    Close() error
+}
+
+// ExportUserExpenses streams a user's expenses matching the filter to w
+func (s *ExpenseService) ExportUserExpenses(userID string, filter *models.ExpenseFilter, opts *models.ExpenseExportOptions, w io.Writer) error {
+    return s.exportExpenses(filter, opts, w, func(f *models.ExpenseFilter, fn func(*models.Expense) error) error {
+        return s.expenseRepo.StreamExpensesByUser(userID, f, fn)
+    })
+}
+
+// ExportTeamExpenses streams a team's expenses matching the filter to w, for members who can read them
+func (s *ExpenseService) ExportTeamExpenses(teamID, userID string, filter *models.ExpenseFilter, opts *models.ExpenseExportOptions, w io.Writer) error {
+    if _, err := s.teamAuth.Authorize(teamID, userID, PermReadTeamExpenses); err != nil {
+        return err
+    }
+
+    return s.exportExpenses(filter, opts, w, func(f *models.ExpenseFilter, fn func(*models.Expense) error) error {
+        return s.expenseRepo.StreamExpensesByTeam(teamID, f, fn)
+    })
+}
+
+// exportExpenses validates the export before anything is written, so callers can
+// still report errors, then streams the expenses through the format's writer
+func (s *ExpenseService) exportExpenses(
+    filter *models.ExpenseFilter,
+    opts *models.ExpenseExportOptions,
+    w io.Writer,
+    stream func(*models.ExpenseFilter, func(*models.Expense) error) error,
+) error {
+    if filter == nil {
+        filter = &models.ExpenseFilter{}
+    }
+    if err := validateExpenseFilter(filter); err != nil {
+        return err
+    }
+    if opts == nil {
+        opts = &models.ExpenseExportOptions{}
+    }
+
+    columns, err := resolveExportColumns(opts.Columns)
+    if err != nil {
+        return err
+    }
+
+    locale := resolveExportLocale(opts.Locale)
+    if opts.DateFormat != "" {
+        locale.DateFormat = opts.DateFormat
+    }
+    dateLayout, excelDateFormat, err := parseDateFormat(locale.DateFormat)
+    if err != nil {
+        return err
+    }
+
+    var writer expenseRowWriter
+    switch strings.ToLower(opts.Format) {
+    case "", models.ExportFormatCSV:
+        writer = newCSVExpenseWriter(w, locale, dateLayout)
+    case models.ExportFormatXLSX:
+        writer, err = newXLSXExpenseWriter(w, excelDateFormat)
+        if err != nil {
+            return err
+        }
+    default:
+        return ErrUnsupportedExportFormat
+    }
+
+    if err := writer.WriteHeader(columns); err != nil {
+        return err
+    }
+
+    rows := 0
+    err = stream(filter, func(expense *models.Expense) error {
+        if err := writer.WriteExpense(columns, expense); err != nil {
+            return err
+        }
+        rows++
+        if rows%exportFlushEvery == 0 {
+            return writer.Flush()
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+
+    return writer.Close()
+}
+
+// resolveExportColumns looks up the requested columns, keeping their order
+func resolveExportColumns(keys []string) ([]exportColumn, error) {
+    if len(keys) == 0 {
+        keys = defaultExportColumns
+    }
+
+    columns := make([]exportColumn, 0, len(keys))
+    for _, key := range keys {
+        column, ok := exportColumns[strings.ToLower(strings.TrimSpace(key))]
+        if !ok {
+            return nil, ErrInvalidExportColumn
+        }
+        columns = append(columns, column)
+    }
+    return columns, nil
+}
+
+// exportLanguageLocales picks a locale for tags that only name a language or
+// name a region we have no conventions for
+var exportLanguageLocales = map[string]string{
+    "en": "en-us",
+    "de": "de-de",
+    "fr": "fr-fr",
+    "es": "es-es",
+    "it": "it-it",
+    "nl": "nl-nl",
+    "pt": "pt-br",
+    "ja": "ja-jp",
+}
+
+// resolveExportLocale finds the locale by tag, then by language alone, so that
+// "de" or "de-AT" fall back to de-DE conventions
+func resolveExportLocale(tag string) exportLocale {
+    tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+    if locale, ok := exportLocales[tag]; ok {
+        return locale
+    }
+
+    language := strings.SplitN(tag, "-", 2)[0]
+    if locale, ok := exportLocales[exportLanguageLocales[language]]; ok {
+        return locale
+    }
+    return defaultExportLocale
+}
+
+// parseDateFormat converts a YYYY, YY, MM and DD pattern into a Go time layout
+// and an Excel number format
+func parseDateFormat(pattern string) (string, string, error) {
+    var layout, excel strings.Builder
+    hasYear, hasMonth, hasDay := false, false, false
+
+    for rest := pattern; rest != ""; {
+        switch {
+        case strings.HasPrefix(rest, "YYYY"):
+            layout.WriteString("2006")
+            excel.WriteString("yyyy")
+            rest, hasYear = rest[4:], true
+        case strings.HasPrefix(rest, "YY"):
+            layout.WriteString("06")
+            excel.WriteString("yy")
+            rest, hasYear = rest[2:], true
+        case strings.HasPrefix(rest, "MM"):
+            layout.WriteString("01")
+            excel.WriteString("mm")
+            rest, hasMonth = rest[2:], true
+        case strings.HasPrefix(rest, "DD"):
+            layout.WriteString("02")
+            excel.WriteString("dd")
+            rest, hasDay = rest[2:], true
+        case strings.ContainsRune("-./ ", rune(rest[0])):
+            layout.WriteByte(rest[0])
+            if rest[0] == ' ' {
+                excel.WriteByte(' ')
+            } else {
+                excel.WriteString(`\` + rest[:1])
+            }
+            rest = rest[1:]
+        default:
+            return "", "", ErrInvalidDateFormat
+        }
+    }
+
+    if !hasYear || !hasMonth || !hasDay {
+        return "", "", ErrInvalidDateFormat
+    }
+    return layout.String(), excel.String(), nil
+}
+
+// expenseDateValue parses the expense date, which the database driver may
+// return with a time part. Unparseable dates are exported as stored.
+func expenseDateValue(expense *models.Expense) interface{} {
+    raw := expense.ExpenseDate
+    if len(raw) > 10 {
+        raw = raw[:10]
+    }
+    date, err := time.Parse("2006-01-02", raw)
+    if err != nil {
+        return expense.ExpenseDate
+    }
+    return exportDate(date)
+}
+
+func derefString(s *string) string {
+    if s == nil {
+        return ""
+    }
+    return *s
+}
+
+// csvExpenseWriter writes CSV in the locale's conventions, starting with a
+// byte order mark so spreadsheet apps detect UTF-8
+type csvExpenseWriter struct {
+    csv        *csv.Writer
+    out        io.Writer
+    locale     exportLocale
+    dateLayout string
+    record     []string
+}
+
+func newCSVExpenseWriter(w io.Writer, locale exportLocale, dateLayout string) *csvExpenseWriter {
+    writer := csv.NewWriter(w)
+    writer.Comma = locale.Delimiter
+    return &csvExpenseWriter{csv: writer, out: w, locale: locale, dateLayout: dateLayout}
+}
+
+func (w *csvExpenseWriter) WriteHeader(columns []exportColumn) error {
+    if _, err := io.WriteString(w.out, "\ufeff"); err != nil {
+        return err
+    }
+
+    header := make([]string, len(columns))
+    for i, column := range columns {
+        header[i] = column.Header
+    }
+    return w.csv.Write(header)
+}
+
+func (w *csvExpenseWriter) WriteExpense(columns []exportColumn, expense *models.Expense) error {
+    w.record = w.record[:0]
+    for _, column := range columns {
+        var field string
+        switch value := column.Value(expense).(type) {
+        case exportDate:
+            field = time.Time(value).Format(w.dateLayout)
+        case time.Time:
+            field = value.Format(w.dateLayout + " 15:04:05")
+        case money.Amount:
+            field = strings.Replace(value.Format(expense.Currency), ".", w.locale.Decimal, 1)
+        case string:
+            field = escapeFormula(value)
+        }
+        w.record = append(w.record, field)
+    }
+    return w.csv.Write(w.record)
+}
+
+func (w *csvExpenseWriter) Flush() error {
+    w.csv.Flush()
+    return w.csv.Error()
+}
+
+func (w *csvExpenseWriter) Close() error {
+    return w.Flush()
+}
+
+// escapeFormula stops spreadsheet apps from evaluating text that looks like a formula
+func escapeFormula(s string) string {
+    if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+        return "'" + s
+    }
+    return s
+}
+
+// xlsxExpenseWriter writes a workbook with real date and number cells, leaving
+// number display to the spreadsheet app's locale
+type xlsxExpenseWriter struct {
+    xlsx  *xlsx.Writer
+    cells []xlsx.Cell
+}
+
+func newXLSXExpenseWriter(w io.Writer, dateFormat string) (*xlsxExpenseWriter, error) {
+    writer, err := xlsx.NewWriter(w, xlsx.Options{
+        SheetName:      "Expenses",
+        DateFormat:     dateFormat,
+        DateTimeFormat: dateFormat + " hh:mm:ss",
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &xlsxExpenseWriter{xlsx: writer}, nil
+}
+
+func (w *xlsxExpenseWriter) WriteHeader(columns []exportColumn) error {
+    w.cells = w.cells[:0]
+    for _, column := range columns {
+        w.cells = append(w.cells, xlsx.Header(column.Header))
+    }
+    return w.xlsx.WriteRow(w.cells...)
+}
+
+func (w *xlsxExpenseWriter) WriteExpense(columns []exportColumn, expense *models.Expense) error {
+    w.cells = w.cells[:0]
+    for _, column := range columns {
+        var cell xlsx.Cell
+        switch value := column.Value(expense).(type) {
+        case exportDate:
+            cell = xlsx.Date(time.Time(value))
+        case time.Time:
+            cell = xlsx.DateTime(value)
+        case money.Amount:
+            cell = xlsx.Number(value.Float64())
+        case string:
+            cell = xlsx.Text(value)
+        }
+        w.cells = append(w.cells, cell)
+    }
+    return w.xlsx.WriteRow(w.cells...)
+}
+
+func (w *xlsxExpenseWriter) Flush() error {
+    return w.xlsx.Flush()
+}
+
+func (w *xlsxExpenseWriter) Close() error {
+    return w.xlsx.Close()
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/money"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func exportExpenses() []*models.Expense {
+	return []*models.Expense{
+		{
+			ID:          "exp-1",
+			Amount:      money.MustParse("1234.5"),
+			Currency:    "EUR",
+			Description: "Hotel, two nights",
+			Category:    "Travel",
+			ExpenseDate: "2026-01-15T00:00:00Z",
+			Status:      models.ExpenseStatusApproved,
+			CreatedAt:   time.Date(2026, 1, 16, 8, 30, 0, 0, time.UTC),
+		},
+		{
+			ID:          "exp-2",
+			Amount:      money.MustParse("9.99"),
+			Currency:    "EUR",
+			Description: "=HYPERLINK(\"http://evil\")",
+			Category:    "Food",
+			ExpenseDate: "2026-01-02",
+			Status:      models.ExpenseStatusDraft,
+		},
+	}
+}
+
+// streamRows makes a Stream mock call fn with each expense
+func streamRows(expenses []*models.Expense) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		fn := args.Get(2).(func(*models.Expense) error)
+		for _, expense := range expenses {
+			if err := fn(expense); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestExpenseService_ExportCSV(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, new(MockUserRepository), new(MockTeamRepository), new(MockApprovalPolicyRepository), new(MockExchangeRateRepository), noBudgets(), anyCategory(), nil)
+
+	mockExpenseRepo.On("StreamExpensesByUser", "user-1", mock.AnythingOfType("*models.ExpenseFilter"), mock.Anything).
+		Run(streamRows(exportExpenses())).Return(nil)
+
+	t.Run("Default columns in ISO format", func(t *testing.T) {
+		var out bytes.Buffer
+		err := expenseService.ExportUserExpenses("user-1", nil, nil, &out)
+
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimPrefix(out.String(), "\ufeff"), "\n")
+		assert.Equal(t, "Date,Description,Category,Amount,Currency,Status", lines[0])
+		assert.Equal(t, `2026-01-15,"Hotel, two nights",Travel,1234.50,EUR,approved`, lines[1])
+		assert.Equal(t, `2026-01-02,"'=HYPERLINK(""http://evil"")",Food,9.99,EUR,draft`, lines[2])
+	})
+
+	t.Run("German locale with chosen columns", func(t *testing.T) {
+		var out bytes.Buffer
+		err := expenseService.ExportUserExpenses("user-1", nil, &models.ExpenseExportOptions{
+			Locale:  "de-AT",
+			Columns: []string{"id", "amount", "date", "created_at"},
+		}, &out)
+
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimPrefix(out.String(), "\ufeff"), "\n")
+		assert.Equal(t, "ID;Amount;Date;Created At", lines[0])
+		assert.Equal(t, "exp-1;1234,50;15.01.2026;16.01.2026 08:30:00", lines[1])
+	})
+
+	t.Run("Date format overrides locale", func(t *testing.T) {
+		var out bytes.Buffer
+		err := expenseService.ExportUserExpenses("user-1", nil, &models.ExpenseExportOptions{
+			Locale:     "en-US",
+			DateFormat: "YYYY/MM/DD",
+			Columns:    []string{"date"},
+		}, &out)
+
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), "2026/01/15\n")
+	})
+
+	t.Run("Invalid options write nothing", func(t *testing.T) {
+		tests := []struct {
+			opts *models.ExpenseExportOptions
+			err  error
+		}{
+			{&models.ExpenseExportOptions{Format: "pdf"}, ErrUnsupportedExportFormat},
+			{&models.ExpenseExportOptions{Columns: []string{"date", "password"}}, ErrInvalidExportColumn},
+			{&models.ExpenseExportOptions{DateFormat: "DD/MM"}, ErrInvalidDateFormat},
+			{&models.ExpenseExportOptions{DateFormat: "%Y-%m-%d"}, ErrInvalidDateFormat},
+		}
+
+		for _, tt := range tests {
+			var out bytes.Buffer
+			err := expenseService.ExportUserExpenses("user-1", nil, tt.opts, &out)
+
+			assert.ErrorIs(t, err, tt.err)
+			assert.Zero(t, out.Len())
+		}
+	})
+}
+
+func TestExpenseService_ExportXLSX(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, new(MockUserRepository), mockTeamRepo, new(MockApprovalPolicyRepository), new(MockExchangeRateRepository), noBudgets(), anyCategory(), nil)
+
+	mockTeamRepo.On("GetTeamMember", "team-1", "viewer").Return(teamMember("team-1", "viewer", models.TeamRoleViewer), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "outsider").Return(nil, nil)
+	mockTeamRepo.On("GetTeamByID", "team-1").Return(&models.Team{ID: "team-1"}, nil)
+
+	t.Run("Non member cannot export", func(t *testing.T) {
+		var out bytes.Buffer
+		err := expenseService.ExportTeamExpenses("team-1", "outsider", nil, &models.ExpenseExportOptions{Format: "xlsx"}, &out)
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+		assert.Zero(t, out.Len())
+	})
+
+	t.Run("Viewer exports a workbook", func(t *testing.T) {
+		mockExpenseRepo.On("StreamExpensesByTeam", "team-1", mock.AnythingOfType("*models.ExpenseFilter"), mock.Anything).
+			Run(streamRows(exportExpenses())).Return(nil).Once()
+
+		var out bytes.Buffer
+		err := expenseService.ExportTeamExpenses("team-1", "viewer", nil, &models.ExpenseExportOptions{
+			Format: "xlsx",
+			Locale: "en-GB",
+		}, &out)
+		require.NoError(t, err)
+
+		archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+		require.NoError(t, err)
+
+		parts := map[string]string{}
+		for _, f := range archive.File {
+			r, err := f.Open()
+			require.NoError(t, err)
+			content, err := io.ReadAll(r)
+			require.NoError(t, err)
+			parts[f.Name] = string(content)
+		}
+
+		require.Contains(t, parts, "[Content_Types].xml")
+		require.Contains(t, parts, "xl/workbook.xml")
+		assert.Contains(t, parts["xl/styles.xml"], `formatCode="dd\/mm\/yyyy"`)
+
+		sheet := parts["xl/worksheets/sheet1.xml"]
+		// 2026-01-15 is Excel serial 46037
+		assert.Contains(t, sheet, `<c r="A2" s="1"><v>46037</v></c>`)
+		assert.Contains(t, sheet, `<c r="D2" s="3"><v>1234.5</v></c>`)
+		assert.Contains(t, sheet, `Hotel, two nights`)
+		assert.Contains(t, sheet, `<c r="A3" s="1"><v>46024</v></c>`)
+	})
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+    "bufio"
+    "encoding/csv"
+    "errors"
+    "fmt"
+    "io"
+    "pocketpilot/internal/models"
+    "pocketpilot/pkg/money"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+const (
+    // maxImportRows caps the rows of a single import
+    maxImportRows = 10000
+    // importPreviewRows is how many parsed expenses a dry run returns
+    importPreviewRows = 20
+)
+
+// importField is an expense field read from an import column
+type importField struct {
+    Name     string // as in CreateExpenseRequest and the mapping
+    Header   string // default header, the one exports write
+    Required bool   // whether the column must exist when there is no default value
+}
+
+var importFields = []importField{
+    {Name: "expense_date", Header: "Date", Required: true},
+    {Name: "amount", Header: "Amount", Required: true},
+    {Name: "currency", Header: "Currency"},
+    {Name: "description", Header: "Description", Required: true},
+    {Name: "category", Header: "Category"},
+}
+
+// ImportExpenses reads expenses from a CSV file and creates the valid rows in a
+// single transaction. Rows that fail validation are skipped and reported. On a
+// dry run nothing is created and a preview of the parsed rows is returned.
+func (s *ExpenseService) ImportExpenses(userID string, r io.Reader, opts *models.ExpenseImportOptions) (*models.ExpenseImportResult, error) {
+    if opts == nil {
+        opts = &models.ExpenseImportOptions{}
+    }
+
+    if opts.TeamID != nil {
+        if _, err := s.teamAuth.Authorize(*opts.TeamID, userID, PermCreateTeamExpense); err != nil {
+            return nil, err
+        }
+    }
+
+    locale := resolveExportLocale(opts.Locale)
+    if opts.DateFormat != "" {
+        locale.DateFormat = opts.DateFormat
+    }
+    dateLayout, _, err := parseDateFormat(locale.DateFormat)
+    if err != nil {
+        return nil, err
+    }
+    if opts.Delimiter != "" {
+        delimiter, size := utf8.DecodeRuneInString(opts.Delimiter)
+        if size != len(opts.Delimiter) || delimiter == '"' || delimiter == '\n' || delimiter == '\r' {
+            return nil, fmt.Errorf("%w: delimiter must be a single character", ErrInvalidImportMapping)
+        }
+        locale.Delimiter = delimiter
+    }
+
+    reader := csv.NewReader(skipBOM(r))
+    reader.Comma = locale.Delimiter
+    reader.FieldsPerRecord = -1
+    reader.TrimLeadingSpace = true
+
+    header, err := reader.Read()
+    if err != nil {
+        return nil, ErrInvalidImportFile
+    }
+    columns, err := importColumns(header, opts)
+    if err != nil {
+        return nil, err
+    }
+
+    result := &models.ExpenseImportResult{DryRun: opts.DryRun, Errors: []models.ExpenseImportRowError{}}
+    categories := newCategoryResolver(s.categoryRepo)
+    var expenses []*models.Expense
+    for {
+        record, err := reader.Read()
+        if err == io.EOF {
+            break
+        }
+
+        result.TotalRows++
+        if result.TotalRows > maxImportRows {
+            return nil, ErrImportTooLarge
+        }
+
+        if err != nil {
+            var parseErr *csv.ParseError
+            if !errors.As(err, &parseErr) {
+                return nil, err
+            }
+            result.Errors = append(result.Errors, models.ExpenseImportRowError{Row: parseErr.StartLine, Message: parseErr.Err.Error()})
+            continue
+        }
+        row, _ := reader.FieldPos(0)
+
+        req, rowErr := parseImportRow(record, columns, opts, locale, dateLayout)
+        if rowErr == nil {
+            rowErr = validateExpenseRequest(req)
+        }
+        if rowErr != nil {
+            rowError := models.ExpenseImportRowError{Row: row, Message: rowErr.Error()}
+            var fieldErr *expenseFieldError
+            if errors.As(rowErr, &fieldErr) {
+                rowError.Field = fieldErr.Field
+            }
+            result.Errors = append(result.Errors, rowError)
+            continue
+        }
+
+        expense := newExpense(userID, req)
+        if err := categories.Assign(expense); err != nil {
+            if !isCategoryError(err) {
+                return nil, err
+            }
+            result.Errors = append(result.Errors, models.ExpenseImportRowError{Row: row, Field: "category", Message: err.Error()})
+            continue
+        }
+        expenses = append(expenses, expense)
+    }
+
+    result.ValidRows = len(expenses)
+    if opts.DryRun {
+        if len(expenses) > importPreviewRows {
+            expenses = expenses[:importPreviewRows]
+        }
+        result.Preview = expenses
+        return result, nil
+    }
+
+    if len(expenses) > 0 {
+        if err := s.recordExchangeRates(expenses...); err != nil {
+            return nil, err
+        }
+        if err := s.expenseRepo.CreateExpenses(expenses); err != nil {
+            return nil, err
+        }
+    }
+    result.ImportedRows = len(expenses)
+
+    return result, nil
+}
+
+// importColumns finds the column index of each field, -1 for fields the file
+// does not have. Headers are matched case-insensitively.
+func importColumns(header []string, opts *models.ExpenseImportOptions) (map[string]int, error) {
+    positions := make(map[string]int, len(header))
+    for i, name := range header {
+        key := strings.ToLower(strings.TrimSpace(name))
+        if _, ok := positions[key]; !ok && key != "" {
+            positions[key] = i
+        }
+    }
+
+    mapping := map[string]string{
+        "expense_date": opts.Mapping.ExpenseDate,
+        "amount":       opts.Mapping.Amount,
+        "currency":     opts.Mapping.Currency,
+        "description":  opts.Mapping.Description,
+        "category":     opts.Mapping.Category,
+    }
+    defaults := map[string]string{
+        "currency": opts.DefaultCurrency,
+        "category": opts.DefaultCategory,
+    }
+
+    columns := make(map[string]int, len(importFields))
+    for _, field := range importFields {
+        name, explicit := mapping[field.Name], mapping[field.Name] != ""
+        if !explicit {
+            name = field.Header
+        }
+
+        i, ok := positions[strings.ToLower(strings.TrimSpace(name))]
+        switch {
+        case ok:
+            columns[field.Name] = i
+        case explicit:
+            return nil, fmt.Errorf("%w: the file has no %q column", ErrInvalidImportMapping, name)
+        case field.Required || strings.TrimSpace(defaults[field.Name]) == "":
+            return nil, fmt.Errorf("%w: map a column to %s", ErrInvalidImportMapping, field.Name)
+        default:
+            columns[field.Name] = -1
+        }
+    }
+    return columns, nil
+}
+
+// parseImportRow converts a CSV record into an expense request in the import's
+// locale. Missing currencies and categories take the import's defaults.
+func parseImportRow(record []string, columns map[string]int, opts *models.ExpenseImportOptions, locale exportLocale, dateLayout string) (*models.CreateExpenseRequest, error) {
+    value := func(field string) string {
+        i := columns[field]
+        if i < 0 || i >= len(record) {
+            return ""
+        }
+        return strings.TrimSpace(record[i])
+    }
+
+    req := &models.CreateExpenseRequest{
+        Currency:    strings.ToUpper(value("currency")),
+        Description: unescapeFormula(value("description")),
+        Category:    unescapeFormula(value("category")),
+        TeamID:      opts.TeamID,
+    }
+    if req.Currency == "" {
+        req.Currency = strings.ToUpper(strings.TrimSpace(opts.DefaultCurrency))
+    }
+    if req.Category == "" {
+        req.Category = strings.TrimSpace(opts.DefaultCategory)
+    }
+
+    date, err := time.Parse(dateLayout, value("expense_date"))
+    if err != nil {
+        return nil, &expenseFieldError{"expense_date", fmt.Sprintf("invalid date %q, expected %s", value("expense_date"), locale.DateFormat)}
+    }
+    req.ExpenseDate = date.Format("2006-01-02")
+
+    amount, err := parseImportAmount(value("amount"), locale.Decimal)
+    if err != nil {
+        return nil, &expenseFieldError{"amount", fmt.Sprintf("invalid amount %q", value("amount"))}
+    }
+    req.Amount = amount
+
+    return req, nil
+}
+
+// parseImportAmount parses a number written with the given decimal separator,
+// ignoring digit grouping such as 1.234,50 or 1 234.50
+func parseImportAmount(raw, decimal string) (money.Amount, error) {
+    group := ","
+    if decimal == "," {
+        group = "."
+    }
+
+    cleaned := strings.Map(func(r rune) rune {
+        switch r {
+        case ' ', '\u00a0', '\u202f', '\'':
+            return -1
+        }
+        return r
+    }, raw)
+    cleaned = strings.ReplaceAll(cleaned, group, "")
+    cleaned = strings.Replace(cleaned, decimal, ".", 1)
+
+    return money.Parse(cleaned)
+}
+
+// unescapeFormula undoes escapeFormula so exported text imports unchanged
+func unescapeFormula(s string) string {
+    if len(s) > 1 && s[0] == '\'' && strings.ContainsRune("=+-@", rune(s[1])) {
+        return s[1:]
+    }
+    return s
+}
+
+// skipBOM drops a leading UTF-8 byte order mark, which spreadsheet apps often write
+func skipBOM(r io.Reader) io.Reader {
+    buffered := bufio.NewReader(r)
+    if bom, err := buffered.Peek(3); err == nil && string(bom) == "\ufeff" {
+        buffered.Discard(3)
+    }
+    return buffered
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/money"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const importCSV = "\ufeffDate,Description,Category,Amount,Currency\n" +
+	"2026-01-15,\"Hotel, two nights\",Travel,\"1,234.50\",eur\n" +
+	"15/01/2026,Taxi,Travel,12,EUR\n" +
+	"2026-01-16,Lunch,Food,-3,EUR\n" +
+	"2026-01-17,,Food,8,EUR\n" +
+	"2026-01-18,\"'=SUM(A1)\",Office,5,\n"
+
+func TestExpenseService_ImportExpenses(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, usersWithBaseCurrency("USD"), mockTeamRepo, new(MockApprovalPolicyRepository), noExchangeRates(), noBudgets(), anyCategory(), nil)
+
+	t.Run("Dry run reports row errors without creating", func(t *testing.T) {
+		result, err := expenseService.ImportExpenses("user-1", strings.NewReader(importCSV), &models.ExpenseImportOptions{
+			DryRun:          true,
+			DefaultCurrency: "usd",
+		})
+
+		require.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, 5, result.TotalRows)
+		assert.Equal(t, 2, result.ValidRows)
+		assert.Zero(t, result.ImportedRows)
+		assert.Equal(t, []models.ExpenseImportRowError{
+			{Row: 3, Field: "expense_date", Message: `invalid date "15/01/2026", expected YYYY-MM-DD`},
+			{Row: 4, Field: "amount", Message: "amount must be greater than 0"},
+			{Row: 5, Field: "description", Message: "description is required"},
+		}, result.Errors)
+
+		require.Len(t, result.Preview, 2)
+		assert.Equal(t, money.MustParse("1234.5"), result.Preview[0].Amount)
+		assert.Equal(t, "EUR", result.Preview[0].Currency)
+		assert.Equal(t, models.ExpenseStatusDraft, result.Preview[0].Status)
+		assert.Equal(t, "=SUM(A1)", result.Preview[1].Description)
+		assert.Equal(t, "USD", result.Preview[1].Currency)
+		mockExpenseRepo.AssertNotCalled(t, "CreateExpenses", mock.Anything)
+	})
+
+	t.Run("Valid rows are created together", func(t *testing.T) {
+		mockExpenseRepo.On("CreateExpenses", mock.MatchedBy(func(expenses []*models.Expense) bool {
+			return len(expenses) == 2 && expenses[0].UserID == "user-1" && expenses[1].ExpenseDate == "2026-01-18"
+		})).Return(nil).Once()
+
+		result, err := expenseService.ImportExpenses("user-1", strings.NewReader(importCSV), &models.ExpenseImportOptions{
+			DefaultCurrency: "USD",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.ImportedRows)
+		assert.Len(t, result.Errors, 3)
+		assert.Nil(t, result.Preview)
+	})
+
+	t.Run("Mapping and locale", func(t *testing.T) {
+		csv := "Buchungstag;Betrag;Zweck\n15.01.2026;1.234,50;Hotel\n"
+
+		result, err := expenseService.ImportExpenses("user-1", strings.NewReader(csv), &models.ExpenseImportOptions{
+			DryRun:          true,
+			Locale:          "de-DE",
+			Mapping:         models.ExpenseImportMapping{ExpenseDate: "buchungstag", Amount: "Betrag", Description: "Zweck"},
+			DefaultCurrency: "EUR",
+			DefaultCategory: "Travel",
+		})
+
+		require.NoError(t, err)
+		require.Len(t, result.Preview, 1)
+		assert.Empty(t, result.Errors)
+		assert.Equal(t, "2026-01-15", result.Preview[0].ExpenseDate)
+		assert.Equal(t, money.MustParse("1234.5"), result.Preview[0].Amount)
+		assert.Equal(t, "Travel", result.Preview[0].Category)
+	})
+
+	t.Run("Unusable files and mappings", func(t *testing.T) {
+		tests := []struct {
+			name string
+			csv  string
+			opts *models.ExpenseImportOptions
+			err  error
+		}{
+			{"Empty file", "", nil, ErrInvalidImportFile},
+			{"Mapped column missing", importCSV, &models.ExpenseImportOptions{Mapping: models.ExpenseImportMapping{Amount: "Total"}}, ErrInvalidImportMapping},
+			{"No currency column or default", "Date,Description,Amount,Category\n", nil, ErrInvalidImportMapping},
+			{"Long delimiter", importCSV, &models.ExpenseImportOptions{Delimiter: "||"}, ErrInvalidImportMapping},
+			{"Too many rows", "Date,Description,Amount,Currency,Category\n" + strings.Repeat("2026-01-01,x,1,EUR,Food\n", maxImportRows+1), &models.ExpenseImportOptions{DryRun: true}, ErrImportTooLarge},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				result, err := expenseService.ImportExpenses("user-1", strings.NewReader(tt.csv), tt.opts)
+
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, result)
+			})
+		}
+	})
+
+	t.Run("Viewer cannot import into a team", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "viewer").Return(teamMember("team-1", "viewer", models.TeamRoleViewer), nil)
+
+		result, err := expenseService.ImportExpenses("viewer", strings.NewReader(importCSV), &models.ExpenseImportOptions{TeamID: strPtr("team-1")})
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+		assert.Nil(t, result)
+	})
+}
@@ -2,99 +2,205 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"pocketpilot/internal/models"
-	"pocketpilot/internal/repository"
+	"pocketpilot/pkg/money"
+	"strings"
 	"time"
 )
 
+// maxPageLimit caps the page size of expense listings
+const maxPageLimit = 100
+
 type ExpenseService struct {
     expenseRepo ExpenseRepository
-    userRepo    *repository.UserRepositoryImpl
+    userRepo    UserRepository
+    teamRepo    TeamRepository
+    policyRepo  ApprovalPolicyRepository
+    rateRepo    ExchangeRateRepository
+    budgetRepo  BudgetRepository
+    categoryRepo CategoryRepository
+    teamAuth    *TeamAuthorizer
+    restrictions EmailRestrictions
 }
 
-func NewExpenseService(expenseRepo ExpenseRepository, userRepo *repository.UserRepositoryImpl) *ExpenseService {
+func NewExpenseService(expenseRepo ExpenseRepository, userRepo UserRepository, teamRepo TeamRepository, policyRepo ApprovalPolicyRepository, rateRepo ExchangeRateRepository, budgetRepo BudgetRepository, categoryRepo CategoryRepository, restrictions EmailRestrictions) *ExpenseService {
     return &ExpenseService{
         expenseRepo: expenseRepo,
         userRepo:    userRepo,
+        teamRepo:    teamRepo,
+        policyRepo:  policyRepo,
+        rateRepo:    rateRepo,
+        budgetRepo:  budgetRepo,
+        categoryRepo: categoryRepo,
+        teamAuth:    NewTeamAuthorizer(teamRepo),
+        restrictions: restrictions,
     }
 }
 
 // CreateExpense creates a new expense for a user
 func (s *ExpenseService) CreateExpense(userID string, req *models.CreateExpenseRequest) (*models.Expense, error) {
-    // Validate expense date
-    if _, err := time.Parse("2006-01-02", req.ExpenseDate); err != nil {
-        return nil, errors.New("invalid expense date format, use YYYY-MM-DD")
+    if err := validateExpenseRequest(req); err != nil {
+        return nil, err
     }
 
-    expense := &models.Expense{
-        UserID:         userID,
-        TeamID:         req.TeamID,
-        Amount:         req.Amount,
-        Currency:       req.Currency,
-        Description:    req.Description,
-        Category:       req.Category,
-        ExpenseDate:    req.ExpenseDate,
-        ReceiptImageURL: req.ReceiptImageURL,
-        Status:         "pending",
+    // Team expenses require a role that can create them
+    if req.TeamID != nil {
+        if _, err := s.teamAuth.Authorize(*req.TeamID, userID, PermCreateTeamExpense); err != nil {
+            return nil, err
+        }
+    }
+
+    expense := newExpense(userID, req)
+    if err := newCategoryResolver(s.categoryRepo).Assign(expense); err != nil {
+        return nil, err
+    }
+    tags, err := normalizeTags(req.Tags)
+    if err != nil {
+        return nil, err
+    }
+    if len(tags) > 0 {
+        expense.Tags = tags
+    }
+    if err := s.recordExchangeRates(expense); err != nil {
+        return nil, err
     }
 
-    err := s.expenseRepo.CreateExpense(expense)
+    err = s.expenseRepo.CreateExpense(expense)
     if err != nil {
         return nil, err
     }
 
+    s.checkBudgets(expense)
     return expense, nil
 }
 
-// GetExpense retrieves an expense by ID with authorization
-func (s *ExpenseService) GetExpense(expenseID, userID string) (*models.Expense, error) {
-    expense, err := s.expenseRepo.GetExpenseByID(expenseID)
-    if err != nil {
-        return nil, err
+// checkBudgets raises alerts for the budget thresholds the expense's spending
+// reached. The expense is saved by then, so failures are only logged.
+func (s *ExpenseService) checkBudgets(expense *models.Expense) {
+    alerts, err := raiseBudgetAlerts(s.budgetRepo, s.rateRepo, expense)
+    for _, alert := range alerts {
+        log.Printf("Budget %s reached %d%% in the period starting %s (expense %s)", alert.BudgetID, alert.Threshold, alert.PeriodStart, expense.ID)
     }
-    if expense == nil {
-        return nil, errors.New("expense not found")
+    if err != nil {
+        log.Printf("Failed to check budgets for expense %s: %v", expense.ID, err)
     }
+    expense.BudgetAlerts = alerts
+}
+
+// expenseFieldError is a validation error on one field of an expense
+type expenseFieldError struct {
+    Field   string
+    Message string
+}
 
-    // Check if user owns the expense or it's a team expense they have access to
-    if expense.UserID != userID {
-        return nil, errors.New("access denied")
+func (e *expenseFieldError) Error() string {
+    return e.Message
+}
+
+// validateExpenseRequest checks a new expense, whether it comes from the API or an import
+func validateExpenseRequest(req *models.CreateExpenseRequest) error {
+    if _, err := time.Parse("2006-01-02", req.ExpenseDate); err != nil {
+        return &expenseFieldError{"expense_date", "invalid expense date format, use YYYY-MM-DD"}
+    }
+    if strings.TrimSpace(req.Currency) == "" {
+        return &expenseFieldError{"currency", "currency is required"}
+    }
+    currency, err := money.NormalizeCurrency(req.Currency)
+    if err != nil {
+        return &expenseFieldError{"currency", "currency must be a three-letter ISO 4217 code"}
+    }
+    if err := validateAmount(req.Amount, currency); err != nil {
+        return err
+    }
+    if strings.TrimSpace(req.Description) == "" {
+        return &expenseFieldError{"description", "description is required"}
+    }
+    if strings.TrimSpace(req.Category) == "" {
+        return &expenseFieldError{"category", "category is required"}
     }
+    return nil
+}
 
-    return expense, nil
+// validateAmount checks an amount is positive and has no more decimals than its currency allows
+func validateAmount(amount money.Amount, currency string) error {
+    if amount <= 0 {
+        return &expenseFieldError{"amount", "amount must be greater than 0"}
+    }
+    if amount.Validate(currency) != nil {
+        return &expenseFieldError{"amount", fmt.Sprintf("%s amounts have at most %d decimal places", currency, money.Decimals(currency))}
+    }
+    return nil
 }
 
-// GetUserExpenses retrieves all expenses for a user
-func (s *ExpenseService) GetUserExpenses(userID string, page, limit int) ([]*models.Expense, error) {
-    if page < 1 {
-        page = 1
+// newExpense builds a draft expense from a validated request
+func newExpense(userID string, req *models.CreateExpenseRequest) *models.Expense {
+    return &models.Expense{
+        UserID:         userID,
+        TeamID:         req.TeamID,
+        Amount:         req.Amount,
+        Currency:       strings.ToUpper(strings.TrimSpace(req.Currency)),
+        Description:    req.Description,
+        Category:       req.Category,
+        ExpenseDate:    req.ExpenseDate,
+        ReceiptImageURL: req.ReceiptImageURL,
+        Status:         models.ExpenseStatusDraft,
     }
-    if limit < 1 {
-        limit = 10
+}
+
+// GetExpense retrieves an expense by ID with authorization
+func (s *ExpenseService) GetExpense(expenseID, userID string) (*models.Expense, error) {
+    return s.authorizedExpense(expenseID, userID, PermReadTeamExpenses, PermReadTeamExpenses)
+}
+
+// GetUserExpenses retrieves a page of a user's expenses matching the filter,
+// with amounts converted to the user's base currency
+func (s *ExpenseService) GetUserExpenses(userID string, filter *models.ExpenseFilter, page *models.PageRequest) (*models.ExpensePage, error) {
+    result, err := s.listExpenses(filter, page,
+        func(f *models.ExpenseFilter, limit, offset int) ([]*models.Expense, error) {
+            return s.expenseRepo.GetExpensesByUser(userID, f, limit, offset)
+        },
+        func(f *models.ExpenseFilter) (int, error) {
+            return s.expenseRepo.CountExpensesByUser(userID, f)
+        },
+    )
+    if err != nil {
+        return nil, err
     }
-    offset := (page - 1) * limit
 
-    return s.expenseRepo.GetExpensesByUser(userID, limit, offset)
+    base, err := s.baseCurrency(userID, nil)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.convertExpenses(result.Expenses, base); err != nil {
+        return nil, err
+    }
+    return result, nil
 }
 
 // UpdateExpense updates an existing expense
 func (s *ExpenseService) UpdateExpense(expenseID, userID string, req *models.UpdateExpenseRequest) (*models.Expense, error) {
-    // Get existing expense
-    expense, err := s.expenseRepo.GetExpenseByID(expenseID)
+    // Get existing expense the user may edit
+    expense, err := s.authorizedExpense(expenseID, userID, PermUpdateOwnExpense, PermUpdateAnyExpense)
     if err != nil {
         return nil, err
     }
-    if expense == nil {
-        return nil, errors.New("expense not found")
-    }
 
-    // Check ownership
-    if expense.UserID != userID {
-        return nil, errors.New("access denied")
+    // Submitted and decided expenses are frozen
+    if !isExpenseEditable(expense.Status) {
+        return nil, ErrExpenseLocked
     }
 
     // Update fields if provided
     if req.Amount != nil {
+        if err := validateAmount(*req.Amount, expense.Currency); err != nil {
+            return nil, err
+        }
+        // Portions of a split no longer add up once the amount changes
+        if expense.SplitMethod != nil && *req.Amount != expense.Amount {
+            return nil, ErrExpenseIsSplit
+        }
         expense.Amount = *req.Amount
     }
     if req.Description != nil {
@@ -102,6 +208,9 @@ func (s *ExpenseService) UpdateExpense(expenseID, userID string, req *models.Upd
     }
     if req.Category != nil {
         expense.Category = *req.Category
+        if err := newCategoryResolver(s.categoryRepo).Assign(expense); err != nil {
+            return nil, err
+        }
     }
     if req.ExpenseDate != nil {
         if _, err := time.Parse("2006-01-02", *req.ExpenseDate); err != nil {
@@ -109,8 +218,15 @@ func (s *ExpenseService) UpdateExpense(expenseID, userID string, req *models.Upd
         }
         expense.ExpenseDate = *req.ExpenseDate
     }
-    if req.Status != nil {
-        expense.Status = *req.Status
+    if req.Tags != nil {
+        if expense.Tags, err = normalizeTags(req.Tags); err != nil {
+            return nil, err
+        }
+    }
+
+    // The rate follows the expense date
+    if err := s.recordExchangeRates(expense); err != nil {
+        return nil, err
     }
 
     err = s.expenseRepo.UpdateExpense(expense)
@@ -118,24 +234,209 @@ func (s *ExpenseService) UpdateExpense(expenseID, userID string, req *models.Upd
         return nil, err
     }
 
+    s.checkBudgets(expense)
     return expense, nil
 }
 
 // DeleteExpense deletes an expense
 func (s *ExpenseService) DeleteExpense(expenseID, userID string) error {
-    return s.expenseRepo.DeleteExpense(expenseID, userID)
+    expense, err := s.authorizedExpense(expenseID, userID, PermDeleteOwnExpense, PermDeleteAnyExpense)
+    if err != nil {
+        return err
+    }
+    if !isExpenseEditable(expense.Status) {
+        return ErrExpenseLocked
+    }
+
+    return s.expenseRepo.DeleteExpense(expense.ID, expense.UserID)
 }
 
-// GetTeamExpenses retrieves expenses for a team
-func (s *ExpenseService) GetTeamExpenses(teamID, userID string, page, limit int) ([]*models.Expense, error) {
-    // TODO: Add team membership validation
-    if page < 1 {
-        page = 1
+// GetTeamExpenses retrieves a page of a team's expenses matching the filter, for
+// members who can read them, with amounts converted to the team's base currency
+func (s *ExpenseService) GetTeamExpenses(teamID, userID string, filter *models.ExpenseFilter, page *models.PageRequest) (*models.ExpensePage, error) {
+    if _, err := s.teamAuth.Authorize(teamID, userID, PermReadTeamExpenses); err != nil {
+        return nil, err
+    }
+
+    result, err := s.listExpenses(filter, page,
+        func(f *models.ExpenseFilter, limit, offset int) ([]*models.Expense, error) {
+            return s.expenseRepo.GetExpensesByTeam(teamID, f, limit, offset)
+        },
+        func(f *models.ExpenseFilter) (int, error) {
+            return s.expenseRepo.CountExpensesByTeam(teamID, f)
+        },
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    base, err := s.baseCurrency(userID, &teamID)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.convertExpenses(result.Expenses, base); err != nil {
+        return nil, err
+    }
+    return result, nil
+}
+
+// listExpenses pages through a listing either by cursor or by page number.
+// One extra row is fetched to know whether another page follows.
+func (s *ExpenseService) listExpenses(
+    filter *models.ExpenseFilter,
+    page *models.PageRequest,
+    list func(*models.ExpenseFilter, int, int) ([]*models.Expense, error),
+    count func(*models.ExpenseFilter) (int, error),
+) (*models.ExpensePage, error) {
+    if filter == nil {
+        filter = &models.ExpenseFilter{}
+    }
+    if page == nil {
+        page = &models.PageRequest{}
+    }
+    if err := validateExpenseFilter(filter); err != nil {
+        return nil, err
+    }
+
+    pageNumber, limit := page.Page, page.Limit
+    if pageNumber < 1 {
+        pageNumber = 1
     }
     if limit < 1 {
         limit = 10
     }
-    offset := (page - 1) * limit
+    if limit > maxPageLimit {
+        limit = maxPageLimit
+    }
+    offset := (pageNumber - 1) * limit
+
+    // Cursors only make sense for the expense date ordering they were built from
+    keyset := filter.Sort == "" || filter.Sort == "expense_date"
+    query := *filter
+    if page.Cursor != "" {
+        if !keyset {
+            return nil, ErrCursorSortUnsupported
+        }
+        cursor, err := decodeExpenseCursor(page.Cursor)
+        if err != nil {
+            return nil, err
+        }
+        if !strings.EqualFold(cursor.Order, orderOrDefault(filter.Order)) {
+            return nil, ErrInvalidCursor
+        }
+        query.After = cursor
+        pageNumber, offset = 0, 0
+    }
+
+    expenses, err := list(&query, limit+1, offset)
+    if err != nil {
+        return nil, err
+    }
+
+    result := &models.ExpensePage{Expenses: expenses, Page: pageNumber, Limit: limit}
+    if len(expenses) > limit {
+        result.Expenses = expenses[:limit]
+        result.HasMore = true
+        if keyset {
+            result.NextCursor = encodeExpenseCursor(result.Expenses[limit-1], orderOrDefault(filter.Order))
+        }
+    }
+
+    if page.IncludeTotal {
+        total, err := count(filter)
+        if err != nil {
+            return nil, err
+        }
+        result.Total = &total
+    }
+
+    return result, nil
+}
 
-    return s.expenseRepo.GetExpensesByTeam(teamID, limit, offset)
-}
\ No newline at end of file
+func orderOrDefault(order string) string {
+    if strings.EqualFold(order, "asc") {
+        return "asc"
+    }
+    return "desc"
+}
+
+// validateExpenseFilter rejects filters the repository cannot apply safely
+func validateExpenseFilter(filter *models.ExpenseFilter) error {
+    if filter == nil {
+        return nil
+    }
+
+    for _, date := range []string{filter.DateFrom, filter.DateTo} {
+        if date == "" {
+            continue
+        }
+        if _, err := time.Parse("2006-01-02", date); err != nil {
+            return errors.New("invalid date filter format, use YYYY-MM-DD")
+        }
+    }
+    if filter.DateFrom != "" && filter.DateTo != "" && filter.DateFrom > filter.DateTo {
+        return errors.New("date_from must not be after date_to")
+    }
+    if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+        return errors.New("min_amount must not be greater than max_amount")
+    }
+
+    for _, status := range filter.Statuses {
+        switch status {
+        case models.ExpenseStatusDraft, models.ExpenseStatusSubmitted, models.ExpenseStatusApproved,
+            models.ExpenseStatusRejected, models.ExpenseStatusReimbursed:
+        default:
+            return errors.New("invalid status filter: " + status)
+        }
+    }
+
+    switch filter.Sort {
+    case "", "expense_date", "amount", "created_at", "category", "status":
+    default:
+        return errors.New("invalid sort field, use expense_date, amount, created_at, category or status")
+    }
+    switch strings.ToLower(filter.Order) {
+    case "", "asc", "desc":
+    default:
+        return errors.New("invalid sort order, use asc or desc")
+    }
+
+    return nil
+}
+
+func (s *ExpenseService) authorizedExpense(expenseID, userID string, ownPerm, anyPerm TeamPermission) (*models.Expense, error) {
+    return authorizeExpense(s.expenseRepo, s.teamAuth, expenseID, userID, ownPerm, anyPerm)
+}
+
+// authorizeExpense loads an expense and checks the user may act on it.
+// Personal expenses are only accessible to their owner. For team expenses the
+// owner needs ownPerm and everyone else needs anyPerm in the team.
+func authorizeExpense(expenseRepo ExpenseRepository, teamAuth *TeamAuthorizer, expenseID, userID string, ownPerm, anyPerm TeamPermission) (*models.Expense, error) {
+    expense, err := expenseRepo.GetExpenseByID(expenseID)
+    if err != nil {
+        return nil, err
+    }
+    if expense == nil {
+        return nil, ErrExpenseNotFound
+    }
+
+    if expense.TeamID == nil {
+        if expense.UserID != userID {
+            return nil, ErrExpenseAccessDenied
+        }
+        return expense, nil
+    }
+
+    perm := anyPerm
+    if expense.UserID == userID {
+        perm = ownPerm
+    }
+    if _, err := teamAuth.Authorize(*expense.TeamID, userID, perm); err != nil {
+        if errors.Is(err, ErrTeamNotFound) {
+            return nil, ErrExpenseAccessDenied
+        }
+        return nil, err
+    }
+
+    return expense, nil
+}
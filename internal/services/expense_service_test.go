@@ -1,13 +1,16 @@
 package services
 
 import (
+	"encoding/json"
 	"pocketpilot/internal/models"
-	// "testing"
-	// "time"
+	"pocketpilot/pkg/money"
+	"strings"
+	"testing"
+	"time"
 
-	// "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	// "github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/require"
 )
 
 type MockExpenseRepository struct {
@@ -19,16 +22,21 @@ func (m *MockExpenseRepository) CreateExpense(expense *models.Expense) error {
 	return args.Error(0)
 }
 
-func (m *MockExpenseRepository) GetExpensesByID(id string) (*models.Expense, error) {
+func (m *MockExpenseRepository) CreateExpenses(expenses []*models.Expense) error {
+	args := m.Called(expenses)
+	return args.Error(0)
+}
+
+func (m *MockExpenseRepository) GetExpenseByID(id string) (*models.Expense, error) {
 	args := m.Called(id)
 	if args.Get(0) != nil {
-		return nil, args.Error(1)
+		return args.Get(0).(*models.Expense), args.Error(1)
 	}
-	return args.Get(0).(*models.Expense), args.Error(1)
+	return nil, args.Error(1)
 }
 
-func (m *MockExpenseRepository) GetExpensesByUser(userID string, limit, offset int) ([]*models.Expense, error) {
-    args := m.Called(userID, limit, offset)
+func (m *MockExpenseRepository) GetExpensesByUser(userID string, filter *models.ExpenseFilter, limit, offset int) ([]*models.Expense, error) {
+    args := m.Called(userID, filter, limit, offset)
     if args.Get(0) == nil {
         return nil, args.Error(1)
     }
@@ -45,18 +53,424 @@ func (m *MockExpenseRepository) DeleteExpense(id, userID string) error {
     return args.Error(0)
 }
 
-func (m *MockExpenseRepository) GetExpensesByTeam(teamID string, limit, offset int) ([]*models.Expense, error) {
-    args := m.Called(teamID, limit, offset)
+func (m *MockExpenseRepository) GetExpensesByTeam(teamID string, filter *models.ExpenseFilter, limit, offset int) ([]*models.Expense, error) {
+    args := m.Called(teamID, filter, limit, offset)
     if args.Get(0) == nil {
         return nil, args.Error(1)
     }
     return args.Get(0).([]*models.Expense), args.Error(1)
 }
 
-// func TestExpenseService_CreateExpense(t *testing.T) {
-// 	mockExpenseRepo := new(MockExpenseRepository)
-// 	mockUserRepo := new(MockUserRepository)
-// 	// expsenseService := &ExpenseService{expenseRepo: mockExpenseRepo, userRepo: mockUserRepo}
-// }
+func (m *MockExpenseRepository) TransitionExpense(transition *models.ExpenseTransition, steps []*models.ExpenseApprovalStep) (bool, error) {
+    args := m.Called(transition, steps)
+    return args.Bool(0), args.Error(1)
+}
+
+func (m *MockExpenseRepository) GetExpenseTransitions(expenseID string) ([]*models.ExpenseTransition, error) {
+    args := m.Called(expenseID)
+    if args.Get(0) == nil {
+        return nil, args.Error(1)
+    }
+    return args.Get(0).([]*models.ExpenseTransition), args.Error(1)
+}
+
+func (m *MockExpenseRepository) DecideApprovalStep(step *models.ExpenseApprovalStep, transition *models.ExpenseTransition) (bool, error) {
+    args := m.Called(step, transition)
+    return args.Bool(0), args.Error(1)
+}
+
+func (m *MockExpenseRepository) GetApprovalSteps(expenseID string) ([]*models.ExpenseApprovalStep, error) {
+    args := m.Called(expenseID)
+    if args.Get(0) == nil {
+        return nil, args.Error(1)
+    }
+    return args.Get(0).([]*models.ExpenseApprovalStep), args.Error(1)
+}
+
+func (m *MockExpenseRepository) CountExpensesByUser(userID string, filter *models.ExpenseFilter) (int, error) {
+    args := m.Called(userID, filter)
+    return args.Int(0), args.Error(1)
+}
+
+func (m *MockExpenseRepository) CountExpensesByTeam(teamID string, filter *models.ExpenseFilter) (int, error) {
+    args := m.Called(teamID, filter)
+    return args.Int(0), args.Error(1)
+}
+
+func (m *MockExpenseRepository) StreamExpensesByUser(userID string, filter *models.ExpenseFilter, fn func(*models.Expense) error) error {
+	args := m.Called(userID, filter, fn)
+	return args.Error(0)
+}
+
+func (m *MockExpenseRepository) StreamExpensesByTeam(teamID string, filter *models.ExpenseFilter, fn func(*models.Expense) error) error {
+	args := m.Called(teamID, filter, fn)
+	return args.Error(0)
+}
+
+func (m *MockExpenseRepository) GetExistingBankTransactionIDs(userID string, ids []string) ([]string, error) {
+	args := m.Called(userID, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestExpenseService_CreateExpense(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, usersWithBaseCurrency("USD"), mockTeamRepo, new(MockApprovalPolicyRepository), noExchangeRates(), noBudgets(), anyCategory(), nil)
+
+	req := &models.CreateExpenseRequest{
+		Amount:      money.MustParse("42.5"),
+		Currency:    "USD",
+		Description: "Team lunch",
+		Category:    "Food",
+		ExpenseDate: "2026-01-15",
+	}
+
+	t.Run("Personal expense", func(t *testing.T) {
+		mockExpenseRepo.On("CreateExpense", mock.AnythingOfType("*models.Expense")).Return(nil).Once()
+
+		expense, err := expenseService.CreateExpense("user-1", req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", expense.UserID)
+		assert.Equal(t, models.ExpenseStatusDraft, expense.Status)
+	})
+
+	t.Run("Invalid date", func(t *testing.T) {
+		badReq := *req
+		badReq.ExpenseDate = "15/01/2026"
+
+		expense, err := expenseService.CreateExpense("user-1", &badReq)
+
+		assert.Error(t, err)
+		assert.Nil(t, expense)
+	})
+
+	t.Run("Amounts follow the currency's decimals", func(t *testing.T) {
+		tests := []struct {
+			amount   string
+			currency string
+			valid    bool
+		}{
+			{"1.5", "JPY", false},
+			{"1500", "jpy", true},
+			{"9.999", "USD", false},
+			{"9.99", "USD", true},
+			{"1.234", "KWD", true},
+			{"1", "US$", false},
+		}
+
+		for _, tt := range tests {
+			amountReq := *req
+			amountReq.Amount = money.MustParse(tt.amount)
+			amountReq.Currency = tt.currency
+			if tt.valid {
+				mockExpenseRepo.On("CreateExpense", mock.AnythingOfType("*models.Expense")).Return(nil).Once()
+			}
+
+			expense, err := expenseService.CreateExpense("user-1", &amountReq)
+
+			if tt.valid {
+				require.NoError(t, err, tt.amount+" "+tt.currency)
+				assert.Equal(t, strings.ToUpper(tt.currency), expense.Currency)
+				assert.Equal(t, tt.amount, expense.Amount.String())
+			} else {
+				assert.Error(t, err, tt.amount+" "+tt.currency)
+			}
+		}
+	})
+
+	t.Run("Viewer cannot create team expense", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "viewer").Return(teamMember("team-1", "viewer", models.TeamRoleViewer), nil)
+		teamReq := *req
+		teamReq.TeamID = strPtr("team-1")
+
+		expense, err := expenseService.CreateExpense("viewer", &teamReq)
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+		assert.Nil(t, expense)
+	})
+
+	t.Run("Non-member cannot create team expense", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "outsider").Return(nil, nil)
+		mockTeamRepo.On("GetTeamByID", "team-1").Return(&models.Team{ID: "team-1"}, nil)
+		teamReq := *req
+		teamReq.TeamID = strPtr("team-1")
+
+		expense, err := expenseService.CreateExpense("outsider", &teamReq)
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+		assert.Nil(t, expense)
+	})
+
+	t.Run("Unknown team", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-x", "user-1").Return(nil, nil)
+		mockTeamRepo.On("GetTeamByID", "team-x").Return(nil, nil)
+		teamReq := *req
+		teamReq.TeamID = strPtr("team-x")
+
+		expense, err := expenseService.CreateExpense("user-1", &teamReq)
+
+		assert.ErrorIs(t, err, ErrTeamNotFound)
+		assert.Nil(t, expense)
+	})
+}
+
+func TestExpenseAmountJSON(t *testing.T) {
+	var req models.CreateExpenseRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"amount": 0.1, "currency": "EUR"}`), &req))
+
+	total := req.Amount.Add(req.Amount).Add(req.Amount)
+	assert.Equal(t, money.MustParse("0.3"), total)
+
+	data, err := json.Marshal(&models.Expense{Amount: total})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"amount":0.3,`)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": 0.0001}`), &req))
+	require.NoError(t, json.Unmarshal([]byte(`{"amount": "12.50"}`), &req))
+	assert.Equal(t, "12.50", req.Amount.Format("USD"))
+	assert.Equal(t, "13", req.Amount.Format("JPY"))
+}
 
+func TestExpenseService_GetExpense(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, new(MockUserRepository), mockTeamRepo, new(MockApprovalPolicyRepository), new(MockExchangeRateRepository), noBudgets(), anyCategory(), nil)
 
+	mockExpenseRepo.On("GetExpenseByID", "personal").Return(&models.Expense{ID: "personal", UserID: "user-1"}, nil)
+	mockExpenseRepo.On("GetExpenseByID", "team").Return(&models.Expense{ID: "team", UserID: "user-1", TeamID: strPtr("team-1")}, nil)
+	mockExpenseRepo.On("GetExpenseByID", "missing").Return(nil, nil)
+
+	t.Run("Missing expense", func(t *testing.T) {
+		_, err := expenseService.GetExpense("missing", "user-1")
+		assert.ErrorIs(t, err, ErrExpenseNotFound)
+	})
+
+	t.Run("Someone else's personal expense", func(t *testing.T) {
+		_, err := expenseService.GetExpense("personal", "user-2")
+		assert.ErrorIs(t, err, ErrExpenseAccessDenied)
+	})
+
+	t.Run("Team viewer can read team expense", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "viewer").Return(teamMember("team-1", "viewer", models.TeamRoleViewer), nil)
+
+		expense, err := expenseService.GetExpense("team", "viewer")
+
+		require.NoError(t, err)
+		assert.Equal(t, "team", expense.ID)
+	})
+}
+
+func TestExpenseService_UpdateExpense(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, usersWithBaseCurrency("USD"), mockTeamRepo, new(MockApprovalPolicyRepository), noExchangeRates(), noBudgets(), anyCategory(), nil)
+
+	mockTeamRepo.On("GetTeamMember", "team-1", "member").Return(teamMember("team-1", "member", models.TeamRoleMember), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "admin").Return(teamMember("team-1", "admin", models.TeamRoleAdmin), nil)
+	mockTeamRepo.On("GetTeamByID", "team-1").Return(&models.Team{ID: "team-1", BaseCurrency: "EUR"}, nil)
+	mockExpenseRepo.On("UpdateExpense", mock.AnythingOfType("*models.Expense")).Return(nil)
+
+	newExpense := func(status string) *models.Expense {
+		return &models.Expense{ID: "exp-1", UserID: "member", TeamID: strPtr("team-1"), Status: status}
+	}
+
+	t.Run("Member edits own draft", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(newExpense(models.ExpenseStatusDraft), nil).Once()
+
+		expense, err := expenseService.UpdateExpense("exp-1", "member", &models.UpdateExpenseRequest{Description: strPtr("Taxi")})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Taxi", expense.Description)
+	})
+
+	t.Run("Admin edits member draft", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(newExpense(models.ExpenseStatusRejected), nil).Once()
+
+		expense, err := expenseService.UpdateExpense("exp-1", "admin", &models.UpdateExpenseRequest{Category: strPtr("Travel")})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Travel", expense.Category)
+	})
+
+	t.Run("Split expense keeps its amount", func(t *testing.T) {
+		expense := newExpense(models.ExpenseStatusDraft)
+		expense.Amount = money.MustParse("60")
+		expense.SplitMethod = strPtr(models.SplitMethodEqual)
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(expense, nil).Once()
+
+		_, err := expenseService.UpdateExpense("exp-1", "member", &models.UpdateExpenseRequest{Amount: amountPtr("75")})
+
+		assert.ErrorIs(t, err, ErrExpenseIsSplit)
+	})
+
+	t.Run("Submitted expense is locked", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(newExpense(models.ExpenseStatusSubmitted), nil).Once()
+
+		_, err := expenseService.UpdateExpense("exp-1", "member", &models.UpdateExpenseRequest{Description: strPtr("Taxi")})
+
+		assert.ErrorIs(t, err, ErrExpenseLocked)
+	})
+}
+
+func TestExpenseService_DeleteExpense(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, new(MockUserRepository), mockTeamRepo, new(MockApprovalPolicyRepository), new(MockExchangeRateRepository), noBudgets(), anyCategory(), nil)
+
+	mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(&models.Expense{ID: "exp-1", UserID: "author", TeamID: strPtr("team-1"), Status: models.ExpenseStatusDraft}, nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "member").Return(teamMember("team-1", "member", models.TeamRoleMember), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "owner").Return(teamMember("team-1", "owner", models.TeamRoleOwner), nil)
+
+	t.Run("Member cannot delete others' expenses", func(t *testing.T) {
+		err := expenseService.DeleteExpense("exp-1", "member")
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+	})
+
+	t.Run("Owner deletes any team expense", func(t *testing.T) {
+		mockExpenseRepo.On("DeleteExpense", "exp-1", "author").Return(nil).Once()
+
+		err := expenseService.DeleteExpense("exp-1", "owner")
+
+		assert.NoError(t, err)
+		mockExpenseRepo.AssertExpectations(t)
+	})
+}
+
+func TestExpenseService_GetTeamExpenses(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, usersWithBaseCurrency("USD"), mockTeamRepo, new(MockApprovalPolicyRepository), noExchangeRates(), noBudgets(), anyCategory(), nil)
+
+	t.Run("Non-member is denied", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "outsider").Return(nil, nil)
+		mockTeamRepo.On("GetTeamByID", "team-1").Return(&models.Team{ID: "team-1"}, nil)
+
+		page, err := expenseService.GetTeamExpenses("team-1", "outsider", nil, &models.PageRequest{Page: 1, Limit: 10})
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+		assert.Nil(t, page)
+	})
+
+	t.Run("Viewer lists team expenses", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "viewer").Return(teamMember("team-1", "viewer", models.TeamRoleViewer), nil)
+		filter := &models.ExpenseFilter{Statuses: []string{models.ExpenseStatusSubmitted}}
+		mockExpenseRepo.On("GetExpensesByTeam", "team-1", filter, 11, 10).Return([]*models.Expense{{ID: "exp-1"}}, nil)
+
+		page, err := expenseService.GetTeamExpenses("team-1", "viewer", filter, &models.PageRequest{Page: 2, Limit: 10})
+
+		require.NoError(t, err)
+		assert.Len(t, page.Expenses, 1)
+		assert.False(t, page.HasMore)
+		assert.Equal(t, 2, page.Page)
+	})
+}
+
+func TestExpenseService_GetUserExpenses(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, usersWithBaseCurrency("USD"), new(MockTeamRepository), new(MockApprovalPolicyRepository), noExchangeRates(), noBudgets(), anyCategory(), nil)
+
+	t.Run("Valid filter is passed to the repository", func(t *testing.T) {
+		filter := &models.ExpenseFilter{
+			DateFrom:   "2026-01-01",
+			DateTo:     "2026-01-31",
+			Categories: []string{"Food", "Travel"},
+			MinAmount:  amountPtr("10"),
+			MaxAmount:  amountPtr("100"),
+			Sort:       "amount",
+			Order:      "ASC",
+		}
+		mockExpenseRepo.On("GetExpensesByUser", "user-1", filter, 11, 0).Return([]*models.Expense{{ID: "exp-1"}}, nil).Once()
+
+		page, err := expenseService.GetUserExpenses("user-1", filter, &models.PageRequest{})
+
+		require.NoError(t, err)
+		assert.Len(t, page.Expenses, 1)
+		assert.Equal(t, 10, page.Limit)
+		mockExpenseRepo.AssertExpectations(t)
+	})
+
+	invalid := map[string]*models.ExpenseFilter{
+		"Bad date":          {DateFrom: "01/02/2026"},
+		"Inverted dates":    {DateFrom: "2026-02-01", DateTo: "2026-01-01"},
+		"Inverted amounts":  {MinAmount: amountPtr("50"), MaxAmount: amountPtr("5")},
+		"Unknown status":    {Statuses: []string{"pending"}},
+		"Unknown sort":      {Sort: "user_id; DROP TABLE expenses"},
+		"Unknown direction": {Order: "sideways"},
+	}
+	for name, filter := range invalid {
+		t.Run(name, func(t *testing.T) {
+			page, err := expenseService.GetUserExpenses("user-1", filter, &models.PageRequest{Page: 1, Limit: 10})
+
+			assert.Error(t, err)
+			assert.Nil(t, page)
+		})
+	}
+}
+
+func TestExpenseService_CursorPagination(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, usersWithBaseCurrency("USD"), new(MockTeamRepository), new(MockApprovalPolicyRepository), noExchangeRates(), noBudgets(), anyCategory(), nil)
+
+	created := time.Date(2026, 1, 15, 9, 30, 0, 123456000, time.UTC)
+	rows := []*models.Expense{
+		{ID: "exp-3", ExpenseDate: "2026-01-20T00:00:00Z", CreatedAt: created},
+		{ID: "exp-2", ExpenseDate: "2026-01-15T00:00:00Z", CreatedAt: created},
+		{ID: "exp-1", ExpenseDate: "2026-01-10T00:00:00Z", CreatedAt: created},
+	}
+
+	var cursor string
+	t.Run("First page returns a cursor and total", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpensesByUser", "user-1", mock.MatchedBy(func(f *models.ExpenseFilter) bool {
+			return f.After == nil
+		}), 3, 0).Return(rows, nil).Once()
+		mockExpenseRepo.On("CountExpensesByUser", "user-1", mock.AnythingOfType("*models.ExpenseFilter")).Return(3, nil).Once()
+
+		page, err := expenseService.GetUserExpenses("user-1", nil, &models.PageRequest{Limit: 2, IncludeTotal: true})
+
+		require.NoError(t, err)
+		assert.Len(t, page.Expenses, 2)
+		assert.True(t, page.HasMore)
+		assert.NotEmpty(t, page.NextCursor)
+		require.NotNil(t, page.Total)
+		assert.Equal(t, 3, *page.Total)
+		cursor = page.NextCursor
+	})
+
+	t.Run("Cursor resumes after the last expense", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpensesByUser", "user-1", mock.MatchedBy(func(f *models.ExpenseFilter) bool {
+			return f.After != nil && f.After.ID == "exp-2" && f.After.ExpenseDate == "2026-01-15" &&
+				f.After.CreatedAt.Equal(created) && f.After.Order == "desc"
+		}), 3, 0).Return(rows[2:], nil).Once()
+
+		page, err := expenseService.GetUserExpenses("user-1", nil, &models.PageRequest{Page: 5, Limit: 2, Cursor: cursor})
+
+		require.NoError(t, err)
+		assert.Len(t, page.Expenses, 1)
+		assert.False(t, page.HasMore)
+		assert.Empty(t, page.NextCursor)
+		assert.Zero(t, page.Page)
+	})
+
+	t.Run("Garbage cursor", func(t *testing.T) {
+		_, err := expenseService.GetUserExpenses("user-1", nil, &models.PageRequest{Cursor: "not-a-cursor"})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("Cursor with another order", func(t *testing.T) {
+		_, err := expenseService.GetUserExpenses("user-1", &models.ExpenseFilter{Order: "asc"}, &models.PageRequest{Cursor: cursor})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("Cursor with another sort", func(t *testing.T) {
+		_, err := expenseService.GetUserExpenses("user-1", &models.ExpenseFilter{Sort: "amount"}, &models.PageRequest{Cursor: cursor})
+		assert.ErrorIs(t, err, ErrCursorSortUnsupported)
+	})
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "pocketpilot/internal/models"
+    "pocketpilot/pkg/statement"
+    "strings"
+)
+
+// defaultStatementCategory is given to statement transactions when neither the
+// file nor the import names a category
+const defaultStatementCategory = "Uncategorized"
+
+// ImportStatement creates expenses from the debits of an OFX/QFX or QIF bank
+// statement. Transactions imported before, recognized by their bank transaction
+// ID, are skipped, so overlapping statements can be imported safely. Credits
+// are not expenses and are skipped too.
+func (s *ExpenseService) ImportStatement(userID string, r io.Reader, opts *models.StatementImportOptions) (*models.ExpenseImportResult, error) {
+    if opts == nil {
+        opts = &models.StatementImportOptions{}
+    }
+
+    if opts.TeamID != nil {
+        if _, err := s.teamAuth.Authorize(*opts.TeamID, userID, PermCreateTeamExpense); err != nil {
+            return nil, err
+        }
+    }
+
+    transactions, err := parseStatement(r, opts)
+    if err != nil {
+        return nil, err
+    }
+    if len(transactions) > maxImportRows {
+        return nil, ErrImportTooLarge
+    }
+
+    result := &models.ExpenseImportResult{DryRun: opts.DryRun, TotalRows: len(transactions), Errors: []models.ExpenseImportRowError{}}
+    categories := newCategoryResolver(s.categoryRepo)
+    var expenses []*models.Expense
+    seen := make(map[string]bool, len(transactions))
+    for i, transaction := range transactions {
+        if transaction.Amount.Sign() >= 0 {
+            result.SkippedRows++
+            continue
+        }
+
+        bankID := transaction.ID
+        if transaction.Account != "" {
+            bankID = transaction.Account + ":" + bankID
+        }
+        if seen[bankID] {
+            result.DuplicateRows++
+            continue
+        }
+        seen[bankID] = true
+
+        req := statementExpenseRequest(transaction, opts)
+        if err := validateExpenseRequest(req); err != nil {
+            rowError := models.ExpenseImportRowError{Row: i + 1, Message: err.Error()}
+            var fieldErr *expenseFieldError
+            if errors.As(err, &fieldErr) {
+                rowError.Field = fieldErr.Field
+            }
+            result.Errors = append(result.Errors, rowError)
+            continue
+        }
+
+        expense := newExpense(userID, req)
+        expense.BankTransactionID = &bankID
+        err := categories.Assign(expense)
+        // Bank categories rarely match ours, so unknown ones fall back to the default
+        if isCategoryError(err) && transaction.Category != "" {
+            expense.Category = statementFallbackCategory(opts)
+            err = categories.Assign(expense)
+        }
+        if err != nil {
+            if !isCategoryError(err) {
+                return nil, err
+            }
+            result.Errors = append(result.Errors, models.ExpenseImportRowError{Row: i + 1, Field: "category", Message: err.Error()})
+            continue
+        }
+        expenses = append(expenses, expense)
+    }
+
+    candidates := len(expenses)
+    expenses, err = s.withoutImportedTransactions(userID, expenses)
+    if err != nil {
+        return nil, err
+    }
+    result.DuplicateRows += candidates - len(expenses)
+
+    result.ValidRows = len(expenses)
+    if opts.DryRun {
+        if len(expenses) > importPreviewRows {
+            expenses = expenses[:importPreviewRows]
+        }
+        result.Preview = expenses
+        return result, nil
+    }
+
+    if len(expenses) > 0 {
+        if err := s.recordExchangeRates(expenses...); err != nil {
+            return nil, err
+        }
+        if err := s.expenseRepo.CreateExpenses(expenses); err != nil {
+            return nil, err
+        }
+    }
+    result.ImportedRows = len(expenses)
+
+    return result, nil
+}
+
+// parseStatement reads the transactions of a statement in the requested
+// format, or the detected one
+func parseStatement(r io.Reader, opts *models.StatementImportOptions) ([]statement.Transaction, error) {
+    data, err := io.ReadAll(r)
+    if err != nil {
+        return nil, err
+    }
+
+    format := strings.ToLower(strings.TrimSpace(opts.Format))
+    switch format {
+    case "":
+        format, err = statement.Detect(data)
+        if err != nil {
+            return nil, ErrUnsupportedStatementFormat
+        }
+    case "qfx":
+        format = statement.FormatOFX
+    case statement.FormatOFX, statement.FormatQIF:
+    default:
+        return nil, ErrUnsupportedStatementFormat
+    }
+
+    var transactions []statement.Transaction
+    if format == statement.FormatQIF {
+        transactions, err = statement.ParseQIF(bytes.NewReader(data), statement.QIFOptions{DayFirst: opts.DayFirst})
+    } else {
+        transactions, err = statement.ParseOFX(bytes.NewReader(data))
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidStatement, err)
+    }
+    return transactions, nil
+}
+
+// statementExpenseRequest maps a debit to a new expense: the payee describes
+// it and the posted date becomes the expense date
+func statementExpenseRequest(transaction statement.Transaction, opts *models.StatementImportOptions) *models.CreateExpenseRequest {
+    req := &models.CreateExpenseRequest{
+        Amount:      transaction.Amount.Neg(),
+        Currency:    strings.ToUpper(strings.TrimSpace(transaction.Currency)),
+        Description: transaction.Payee,
+        Category:    transaction.Category,
+        TeamID:      opts.TeamID,
+    }
+    if !transaction.Date.IsZero() {
+        req.ExpenseDate = transaction.Date.Format("2006-01-02")
+    }
+    if req.Description == "" {
+        req.Description = transaction.Memo
+    }
+    if req.Currency == "" {
+        req.Currency = strings.ToUpper(strings.TrimSpace(opts.Currency))
+    }
+    if req.Category == "" {
+        req.Category = statementFallbackCategory(opts)
+    }
+    return req
+}
+
+// statementFallbackCategory is the category of transactions without a usable one of their own
+func statementFallbackCategory(opts *models.StatementImportOptions) string {
+    if category := strings.TrimSpace(opts.DefaultCategory); category != "" {
+        return category
+    }
+    return defaultStatementCategory
+}
+
+// withoutImportedTransactions drops expenses whose bank transaction the user imported before
+func (s *ExpenseService) withoutImportedTransactions(userID string, expenses []*models.Expense) ([]*models.Expense, error) {
+    if len(expenses) == 0 {
+        return expenses, nil
+    }
+
+    ids := make([]string, len(expenses))
+    for i, expense := range expenses {
+        ids[i] = *expense.BankTransactionID
+    }
+    existing, err := s.expenseRepo.GetExistingBankTransactionIDs(userID, ids)
+    if err != nil {
+        return nil, err
+    }
+    if len(existing) == 0 {
+        return expenses, nil
+    }
+
+    imported := make(map[string]bool, len(existing))
+    for _, id := range existing {
+        imported[id] = true
+    }
+
+    fresh := expenses[:0]
+    for _, expense := range expenses {
+        if !imported[*expense.BankTransactionID] {
+            fresh = append(fresh, expense)
+        }
+    }
+    return fresh, nil
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/money"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const sgmlOFX = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>EUR
+<BANKACCTFROM><BANKID>123<ACCTID>DE0042<ACCTTYPE>CHECKING</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>20260101<DTEND>20260131
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20260115120000.000[-5:EST]<TRNAMT>-1.234,50<FITID>T1<NAME>Hotel Adlon &amp; Spa<MEMO>Two nights</STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20260116<TRNAMT>2500.00<FITID>T2<NAME>Salary</STMTTRN>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20260117<TRNAMT>-12.00<FITID>T3<MEMO>Card payment</STMTTRN>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20260117<TRNAMT>-12.00<FITID>T3<MEMO>Card payment</STMTTRN>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20260118<TRNAMT>-40.00<FITID>T4<NAME>Bookshop<CURRENCY><CURRATE>1.1<CURSYM>USD</CURRENCY></STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+const xmlOFX = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX>
+  <CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS>
+    <CURDEF>USD</CURDEF>
+    <CCACCTFROM><ACCTID>4111</ACCTID></CCACCTFROM>
+    <BANKTRANLIST>
+      <STMTTRN>
+        <TRNTYPE>DEBIT</TRNTYPE>
+        <DTPOSTED>20260203</DTPOSTED>
+        <TRNAMT>-9.99</TRNAMT>
+        <FITID>202602030001</FITID>
+        <NAME>Streaming Co</NAME>
+      </STMTTRN>
+    </BANKTRANLIST>
+  </CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+// currencyOFX has a transaction whose amount is in another currency than CURDEF
+const currencyOFX = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX>
+  <CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS>
+    <CURDEF>USD</CURDEF>
+    <CCACCTFROM><ACCTID>4111</ACCTID></CCACCTFROM>
+    <BANKTRANLIST>
+      <STMTTRN>
+        <TRNTYPE>DEBIT</TRNTYPE>
+        <DTPOSTED>20260115</DTPOSTED>
+        <TRNAMT>-20.00</TRNAMT>
+        <FITID>1</FITID>
+        <NAME>London Taxi</NAME>
+        <CURRENCY><CURRATE>1.27</CURRATE><CURSYM>gbp</CURSYM></CURRENCY>
+      </STMTTRN>
+      <STMTTRN>
+        <TRNTYPE>DEBIT</TRNTYPE>
+        <DTPOSTED>20260116</DTPOSTED>
+        <TRNAMT>-4.50</TRNAMT>
+        <FITID>2</FITID>
+        <NAME>Coffee</NAME>
+      </STMTTRN>
+    </BANKTRANLIST>
+  </CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+// origCurrencyOFX has a transaction paid in yen whose amount the bank already
+// converted to CURDEF
+const origCurrencyOFX = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS>
+<CURDEF>USD
+<CCACCTFROM><ACCTID>4111</CCACCTFROM>
+<BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20260115<TRNAMT>-33.10<FITID>1<NAME>Tokyo Ramen<ORIGCURRENCY><CURRATE>0.0066<CURSYM>JPY</ORIGCURRENCY></STMTTRN>
+</BANKTRANLIST>
+</CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+const qif = `!Account
+NChecking
+TBank
+^
+!Type:Bank
+D1/15'26
+T-1,234.50
+PHotel
+LTravel
+^
+D01/16/2026
+T-5.00
+PCoffee
+^
+D01/16/2026
+T-5.00
+PCoffee
+^
+D01/17/2026
+T-100.00
+PSavings
+L[Savings]
+^
+D01/18/2026
+T50.00
+PRefund
+^
+`
+
+func TestExpenseService_ImportStatement(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, usersWithBaseCurrency("USD"), new(MockTeamRepository), new(MockApprovalPolicyRepository), noExchangeRates(), noBudgets(), anyCategory(), nil)
+
+	t.Run("OFX 1.x dry run skips credits and repeated transactions", func(t *testing.T) {
+		mockExpenseRepo.On("GetExistingBankTransactionIDs", "user-1", []string{"DE0042:T1", "DE0042:T3", "DE0042:T4"}).
+			Return([]string{"DE0042:T4"}, nil).Once()
+
+		result, err := expenseService.ImportStatement("user-1", strings.NewReader(sgmlOFX), &models.StatementImportOptions{DryRun: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, 5, result.TotalRows)
+		assert.Equal(t, 1, result.SkippedRows)
+		assert.Equal(t, 2, result.DuplicateRows)
+		assert.Equal(t, 2, result.ValidRows)
+		assert.Empty(t, result.Errors)
+
+		require.Len(t, result.Preview, 2)
+		hotel := result.Preview[0]
+		assert.Equal(t, "Hotel Adlon & Spa", hotel.Description)
+		assert.Equal(t, money.MustParse("1234.5"), hotel.Amount)
+		assert.Equal(t, "EUR", hotel.Currency)
+		assert.Equal(t, "2026-01-15", hotel.ExpenseDate)
+		assert.Equal(t, defaultStatementCategory, hotel.Category)
+		assert.Equal(t, "DE0042:T1", *hotel.BankTransactionID)
+		assert.Equal(t, "Card payment", result.Preview[1].Description)
+		mockExpenseRepo.AssertNotCalled(t, "CreateExpenses", mock.Anything)
+	})
+
+	t.Run("OFX 2.x import", func(t *testing.T) {
+		mockExpenseRepo.On("GetExistingBankTransactionIDs", "user-1", []string{"4111:202602030001"}).Return(nil, nil).Once()
+		mockExpenseRepo.On("CreateExpenses", mock.MatchedBy(func(expenses []*models.Expense) bool {
+			return len(expenses) == 1 && expenses[0].Currency == "USD" && expenses[0].Amount == money.MustParse("9.99") &&
+				expenses[0].Category == "Subscriptions" && expenses[0].Status == models.ExpenseStatusDraft
+		})).Return(nil).Once()
+
+		result, err := expenseService.ImportStatement("user-1", strings.NewReader(xmlOFX), &models.StatementImportOptions{
+			Format:          "qfx",
+			DefaultCategory: "Subscriptions",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.ImportedRows)
+	})
+
+	t.Run("OFX amounts in a CURRENCY aggregate are in its currency", func(t *testing.T) {
+		mockExpenseRepo.On("GetExistingBankTransactionIDs", "user-1", []string{"4111:1", "4111:2"}).Return(nil, nil).Once()
+
+		result, err := expenseService.ImportStatement("user-1", strings.NewReader(currencyOFX), &models.StatementImportOptions{DryRun: true})
+
+		require.NoError(t, err)
+		require.Len(t, result.Preview, 2)
+		assert.Equal(t, "GBP", result.Preview[0].Currency)
+		assert.Equal(t, money.MustParse("20"), result.Preview[0].Amount)
+		assert.Equal(t, "USD", result.Preview[1].Currency)
+	})
+
+	t.Run("OFX amounts with an ORIGCURRENCY aggregate stay in CURDEF", func(t *testing.T) {
+		mockExpenseRepo.On("GetExistingBankTransactionIDs", "user-1", []string{"4111:1"}).Return(nil, nil).Once()
+
+		result, err := expenseService.ImportStatement("user-1", strings.NewReader(origCurrencyOFX), &models.StatementImportOptions{DryRun: true})
+
+		require.NoError(t, err)
+		require.Len(t, result.Preview, 1)
+		assert.Equal(t, "Tokyo Ramen", result.Preview[0].Description)
+		assert.Equal(t, money.MustParse("33.1"), result.Preview[0].Amount)
+		assert.Equal(t, "USD", result.Preview[0].Currency)
+	})
+
+	t.Run("QIF needs a currency", func(t *testing.T) {
+		result, err := expenseService.ImportStatement("user-1", strings.NewReader(qif), &models.StatementImportOptions{DryRun: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.SkippedRows)
+		assert.Len(t, result.Errors, 4)
+		assert.Equal(t, models.ExpenseImportRowError{Row: 1, Field: "currency", Message: "currency is required"}, result.Errors[0])
+	})
+
+	t.Run("QIF import", func(t *testing.T) {
+		var ids []string
+		mockExpenseRepo.On("GetExistingBankTransactionIDs", "user-1", mock.Anything).
+			Run(func(args mock.Arguments) { ids = args.Get(1).([]string) }).Return(nil, nil).Twice()
+
+		first, err := expenseService.ImportStatement("user-1", strings.NewReader(qif), &models.StatementImportOptions{DryRun: true, Currency: "usd"})
+		require.NoError(t, err)
+		firstIDs := ids
+
+		_, err = expenseService.ImportStatement("user-1", strings.NewReader(qif), &models.StatementImportOptions{DryRun: true, Currency: "usd"})
+		require.NoError(t, err)
+
+		// Derived IDs are stable across imports and tell identical entries apart
+		assert.Equal(t, firstIDs, ids)
+		assert.Len(t, ids, 4)
+		assert.NotEqual(t, ids[1], ids[2])
+
+		require.Len(t, first.Preview, 4)
+		assert.Equal(t, "2026-01-15", first.Preview[0].ExpenseDate)
+		assert.Equal(t, money.MustParse("1234.5"), first.Preview[0].Amount)
+		assert.Equal(t, "Travel", first.Preview[0].Category)
+		assert.Equal(t, "USD", first.Preview[0].Currency)
+		assert.Equal(t, defaultStatementCategory, first.Preview[3].Category)
+	})
+
+	t.Run("Unreadable statements", func(t *testing.T) {
+		tests := []struct {
+			name string
+			data string
+			opts *models.StatementImportOptions
+			err  error
+		}{
+			{"Unknown content", "Date,Amount\n", nil, ErrUnsupportedStatementFormat},
+			{"Unknown format", sgmlOFX, &models.StatementImportOptions{Format: "mt940"}, ErrUnsupportedStatementFormat},
+			{"Bad amount", "<OFX><STMTTRN><TRNAMT>abc</STMTTRN></OFX>", nil, ErrInvalidStatement},
+			{"Bad QIF date", "!Type:Bank\nD13/45/2026\n^\n", nil, ErrInvalidStatement},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				result, err := expenseService.ImportStatement("user-1", strings.NewReader(tt.data), tt.opts)
+
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, result)
+			})
+		}
+	})
+}
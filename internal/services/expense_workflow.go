@@ -0,0 +1,442 @@
+package services
+
+import (
+    "pocketpilot/internal/models"
+    "pocketpilot/pkg/money"
+    "sort"
+    "strings"
+)
+
+// ExpenseAction is a step of the expense approval workflow
+type ExpenseAction string
+
+const (
+    ExpenseActionSubmit    ExpenseAction = "submit"
+    ExpenseActionApprove   ExpenseAction = "approve"
+    ExpenseActionReject    ExpenseAction = "reject"
+    ExpenseActionReimburse ExpenseAction = "reimburse"
+)
+
+// expenseTransitions maps each action to the statuses it can be applied in and
+// the status it leads to. Rejected expenses can be edited and resubmitted.
+var expenseTransitions = map[ExpenseAction]map[string]string{
+    ExpenseActionSubmit: {
+        models.ExpenseStatusDraft:    models.ExpenseStatusSubmitted,
+        models.ExpenseStatusRejected: models.ExpenseStatusSubmitted,
+    },
+    ExpenseActionApprove: {
+        models.ExpenseStatusSubmitted: models.ExpenseStatusApproved,
+    },
+    ExpenseActionReject: {
+        models.ExpenseStatusSubmitted: models.ExpenseStatusRejected,
+    },
+    ExpenseActionReimburse: {
+        models.ExpenseStatusApproved: models.ExpenseStatusReimbursed,
+    },
+}
+
+// isExpenseEditable reports whether an expense in the given status can still be
+// changed or deleted by its owner
+func isExpenseEditable(status string) bool {
+    return status == models.ExpenseStatusDraft || status == models.ExpenseStatusRejected
+}
+
+// SubmitExpense submits a draft or rejected team expense for approval
+func (s *ExpenseService) SubmitExpense(expenseID, userID string, req *models.ExpenseActionRequest) (*models.Expense, error) {
+    return s.transitionExpense(expenseID, userID, ExpenseActionSubmit, req)
+}
+
+// ApproveExpense approves a submitted expense
+func (s *ExpenseService) ApproveExpense(expenseID, userID string, req *models.ExpenseActionRequest) (*models.Expense, error) {
+    return s.transitionExpense(expenseID, userID, ExpenseActionApprove, req)
+}
+
+// RejectExpense rejects a submitted expense, a reason is mandatory
+func (s *ExpenseService) RejectExpense(expenseID, userID string, req *models.ExpenseActionRequest) (*models.Expense, error) {
+    return s.transitionExpense(expenseID, userID, ExpenseActionReject, req)
+}
+
+// ReimburseExpense marks an approved expense as paid back
+func (s *ExpenseService) ReimburseExpense(expenseID, userID string, req *models.ExpenseActionRequest) (*models.Expense, error) {
+    return s.transitionExpense(expenseID, userID, ExpenseActionReimburse, req)
+}
+
+// GetExpenseHistory retrieves the status transitions of an expense the user can read
+func (s *ExpenseService) GetExpenseHistory(expenseID, userID string) ([]*models.ExpenseTransition, error) {
+    if _, err := s.authorizedExpense(expenseID, userID, PermReadTeamExpenses, PermReadTeamExpenses); err != nil {
+        return nil, err
+    }
+
+    return s.expenseRepo.GetExpenseTransitions(expenseID)
+}
+
+// GetExpenseApprovals retrieves the approval steps of every submission of an expense
+func (s *ExpenseService) GetExpenseApprovals(expenseID, userID string) ([]*models.ExpenseApprovalStep, error) {
+    if _, err := s.authorizedExpense(expenseID, userID, PermReadTeamExpenses, PermReadTeamExpenses); err != nil {
+        return nil, err
+    }
+
+    return s.expenseRepo.GetApprovalSteps(expenseID)
+}
+
+func (s *ExpenseService) transitionExpense(expenseID, userID string, action ExpenseAction, req *models.ExpenseActionRequest) (*models.Expense, error) {
+    expense, err := s.authorizedExpense(expenseID, userID, PermReadTeamExpenses, PermReadTeamExpenses)
+    if err != nil {
+        return nil, err
+    }
+
+    // Only team expenses have someone to approve them
+    if expense.TeamID == nil {
+        return nil, ErrNotTeamExpense
+    }
+
+    var steps []*models.ExpenseApprovalStep
+    switch action {
+    case ExpenseActionSubmit:
+        if expense.UserID != userID {
+            return nil, ErrExpenseAccessDenied
+        }
+        if _, err := s.teamAuth.Authorize(*expense.TeamID, userID, PermCreateTeamExpense); err != nil {
+            return nil, err
+        }
+        if err := s.restrictions.checkUser(s.userRepo, userID, RestrictionSubmitExpenses); err != nil {
+            return nil, err
+        }
+    case ExpenseActionReimburse:
+        if _, err := s.teamAuth.Authorize(*expense.TeamID, userID, PermApproveTeamExpense); err != nil {
+            return nil, err
+        }
+        if expense.UserID == userID {
+            return nil, ErrSelfApproval
+        }
+    }
+
+    toStatus, ok := expenseTransitions[action][expense.Status]
+    if !ok {
+        return nil, ErrInvalidTransition
+    }
+
+    var reason *string
+    if req != nil {
+        if trimmed := strings.TrimSpace(req.Reason); trimmed != "" {
+            reason = &trimmed
+        }
+    }
+    if action == ExpenseActionReject && reason == nil {
+        return nil, ErrRejectionReasonRequired
+    }
+
+    transition := &models.ExpenseTransition{
+        ExpenseID:  expense.ID,
+        FromStatus: expense.Status,
+        ToStatus:   toStatus,
+        ActorID:    userID,
+        Reason:     reason,
+    }
+
+    switch action {
+    case ExpenseActionApprove, ExpenseActionReject:
+        return s.decideApprovalStep(expense, userID, action, transition)
+    case ExpenseActionSubmit:
+        steps, err = s.approvalStepsFor(expense)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    ok, err = s.expenseRepo.TransitionExpense(transition, steps)
+    if err != nil {
+        return nil, err
+    }
+    if !ok {
+        // The status changed since we read it
+        return nil, ErrInvalidTransition
+    }
+
+    expense.Status = toStatus
+    expense.UpdatedAt = transition.CreatedAt
+
+    return expense, nil
+}
+
+// approvalStepsFor builds the approval chain of the first team policy the
+// expense matches, or the default single admin approval
+func (s *ExpenseService) approvalStepsFor(expense *models.Expense) ([]*models.ExpenseApprovalStep, error) {
+    policies, err := s.policyRepo.GetPoliciesByTeam(*expense.TeamID)
+    if err != nil {
+        return nil, err
+    }
+
+    amount, err := s.approvalAmount(expense, policies)
+    if err != nil {
+        return nil, err
+    }
+
+    policySteps := defaultApprovalSteps
+    if policy := matchApprovalPolicy(policies, expense, amount); policy != nil {
+        members, err := s.teamRepo.GetTeamMembers(*expense.TeamID)
+        if err != nil {
+            return nil, err
+        }
+        policySteps = approvalChain(policy.Steps, expense.UserID, members)
+    }
+
+    steps := make([]*models.ExpenseApprovalStep, 0, len(policySteps))
+    for i, step := range policySteps {
+        steps = append(steps, &models.ExpenseApprovalStep{
+            StepOrder:      i + 1,
+            ApproverRole:   step.ApproverRole,
+            ApproverUserID: step.ApproverUserID,
+            Status:         models.ApprovalStepPending,
+        })
+    }
+
+    return steps, nil
+}
+
+// approvalChain adapts policy steps to the team, so the chain can complete. A
+// named approver who submitted the expense, is named on an earlier step or
+// cannot approve is replaced by the team's admins and owners. Steps needing
+// more approvers than the team has besides the submitter are left out.
+func approvalChain(policySteps []models.ApprovalPolicyStep, submitterID string, members []*models.TeamMember) []models.ApprovalPolicyStep {
+    // Approvers not named on a step, who are left for role steps
+    pool := make(map[string]*models.TeamMember)
+    for _, member := range members {
+        if member.UserID != submitterID && RoleHasPermission(member.Role, PermApproveTeamExpense) {
+            pool[member.UserID] = member
+        }
+    }
+
+    chain := make([]models.ApprovalPolicyStep, 0, len(policySteps))
+    for _, step := range policySteps {
+        if step.ApproverUserID != nil {
+            if _, ok := pool[*step.ApproverUserID]; ok {
+                delete(pool, *step.ApproverUserID)
+                chain = append(chain, step)
+                continue
+            }
+            step = models.ApprovalPolicyStep{ApproverRole: stringPtr(models.TeamRoleAdmin)}
+        } else if !RoleHasPermission(*step.ApproverRole, PermApproveTeamExpense) {
+            step = models.ApprovalPolicyStep{ApproverRole: stringPtr(models.TeamRoleAdmin)}
+        }
+        chain = append(chain, step)
+    }
+
+    // Each role step needs an approver of its own. Fill the strictest steps
+    // first, each with the least senior approver it accepts.
+    order := make([]int, 0, len(chain))
+    for i, step := range chain {
+        if step.ApproverUserID == nil {
+            order = append(order, i)
+        }
+    }
+    sort.SliceStable(order, func(a, b int) bool {
+        return models.TeamRoleRank(*chain[order[a]].ApproverRole) > models.TeamRoleRank(*chain[order[b]].ApproverRole)
+    })
+    unfilled := make(map[int]bool)
+    for _, i := range order {
+        var pick *models.TeamMember
+        for _, member := range pool {
+            if models.TeamRoleRank(member.Role) < models.TeamRoleRank(*chain[i].ApproverRole) {
+                continue
+            }
+            if pick == nil || models.TeamRoleRank(member.Role) < models.TeamRoleRank(pick.Role) {
+                pick = member
+            }
+        }
+        if pick == nil {
+            unfilled[i] = true
+            continue
+        }
+        delete(pool, pick.UserID)
+    }
+
+    steps := make([]models.ApprovalPolicyStep, 0, len(chain))
+    for i, step := range chain {
+        if !unfilled[i] {
+            steps = append(steps, step)
+        }
+    }
+    if len(steps) == 0 {
+        return defaultApprovalSteps
+    }
+    return steps
+}
+
+// approvalAmount converts the expense to the team's base currency, which
+// policy minimum amounts are in, with the rate of the expense date. It
+// returns nil when no policy has a minimum amount or no rate is known.
+func (s *ExpenseService) approvalAmount(expense *models.Expense, policies []*models.ApprovalPolicy) (*money.Amount, error) {
+    for _, policy := range policies {
+        if policy.MinAmount == nil {
+            continue
+        }
+        base, err := s.baseCurrency(expense.UserID, expense.TeamID)
+        if err != nil {
+            return nil, err
+        }
+        return convertExpense(newRateConverter(s.rateRepo), expense, base)
+    }
+    return nil, nil
+}
+
+// decideApprovalStep records the user's decision on the next pending step of the
+// current approval round. A rejection, or the approval of the last step, also
+// moves the expense to its new status.
+func (s *ExpenseService) decideApprovalStep(expense *models.Expense, userID string, action ExpenseAction, transition *models.ExpenseTransition) (*models.Expense, error) {
+    if expense.UserID == userID {
+        return nil, ErrSelfApproval
+    }
+
+    member, err := s.teamAuth.Authorize(*expense.TeamID, userID, PermReadTeamExpenses)
+    if err != nil {
+        return nil, err
+    }
+
+    allSteps, err := s.expenseRepo.GetApprovalSteps(expense.ID)
+    if err != nil {
+        return nil, err
+    }
+    round := currentApprovalRound(allSteps)
+
+    var pending *models.ExpenseApprovalStep
+    remaining := 0
+    for _, step := range round {
+        if step.Status == models.ApprovalStepPending {
+            if pending == nil {
+                pending = step
+            }
+            remaining++
+        } else if step.DecidedBy != nil && *step.DecidedBy == userID {
+            // One person cannot satisfy two steps of the same chain
+            return nil, ErrAlreadyApproved
+        }
+    }
+
+    // Expenses submitted before approval chains existed have no steps
+    if pending == nil {
+        if !RoleHasPermission(member.Role, PermApproveTeamExpense) {
+            return nil, ErrTeamAccessDenied
+        }
+        ok, err := s.expenseRepo.TransitionExpense(transition, nil)
+        if err != nil {
+            return nil, err
+        }
+        if !ok {
+            return nil, ErrInvalidTransition
+        }
+        expense.Status = transition.ToStatus
+        expense.UpdatedAt = transition.CreatedAt
+        return expense, nil
+    }
+
+    step, err := s.decidingStep(expense, pending, round)
+    if err != nil {
+        return nil, err
+    }
+    if !canApproveStep(step, member) {
+        return nil, ErrNotStepApprover
+    }
+    // Someone named on a later step keeps themselves for it
+    if pending.ApproverUserID == nil {
+        for _, later := range round {
+            if later != pending && later.Status == models.ApprovalStepPending && later.ApproverUserID != nil && *later.ApproverUserID == userID {
+                return nil, ErrNotStepApprover
+            }
+        }
+    }
+
+    decision := &models.ExpenseApprovalStep{
+        ID:        pending.ID,
+        Status:    models.ApprovalStepApproved,
+        DecidedBy: &userID,
+        Comment:   transition.Reason,
+    }
+    if action == ExpenseActionReject {
+        decision.Status = models.ApprovalStepRejected
+    }
+
+    final := action == ExpenseActionReject || remaining == 1
+    var finalTransition *models.ExpenseTransition
+    if final {
+        finalTransition = transition
+    }
+
+    ok, err := s.expenseRepo.DecideApprovalStep(decision, finalTransition)
+    if err != nil {
+        return nil, err
+    }
+    if !ok {
+        return nil, ErrInvalidTransition
+    }
+
+    if final {
+        expense.Status = transition.ToStatus
+        expense.UpdatedAt = transition.CreatedAt
+    }
+
+    return expense, nil
+}
+
+// decidingStep returns who decides a step. A step naming someone who can no
+// longer decide it, as they submitted the expense, decided an earlier step,
+// left the team or lost the right to approve, goes to the team's admins and
+// owners instead.
+func (s *ExpenseService) decidingStep(expense *models.Expense, step *models.ExpenseApprovalStep, round []*models.ExpenseApprovalStep) (*models.ExpenseApprovalStep, error) {
+    if step.ApproverUserID == nil {
+        return step, nil
+    }
+
+    named := *step.ApproverUserID
+    available := named != expense.UserID
+    for _, decided := range round {
+        if decided.DecidedBy != nil && *decided.DecidedBy == named {
+            available = false
+        }
+    }
+    if available {
+        member, err := s.teamRepo.GetTeamMember(*expense.TeamID, named)
+        if err != nil {
+            return nil, err
+        }
+        available = member != nil && RoleHasPermission(member.Role, PermApproveTeamExpense)
+    }
+    if available {
+        return step, nil
+    }
+
+    return &models.ExpenseApprovalStep{ID: step.ID, ApproverRole: stringPtr(models.TeamRoleAdmin)}, nil
+}
+
+// currentApprovalRound returns the steps of the latest submission
+func currentApprovalRound(steps []*models.ExpenseApprovalStep) []*models.ExpenseApprovalStep {
+    latest := 0
+    for _, step := range steps {
+        if step.Round > latest {
+            latest = step.Round
+        }
+    }
+
+    var round []*models.ExpenseApprovalStep
+    for _, step := range steps {
+        if step.Round == latest {
+            round = append(round, step)
+        }
+    }
+    return round
+}
+
+// canApproveStep reports whether the member satisfies the step's approver.
+// Only roles that may approve team expenses decide steps, whoever they name.
+func canApproveStep(step *models.ExpenseApprovalStep, member *models.TeamMember) bool {
+    if !RoleHasPermission(member.Role, PermApproveTeamExpense) {
+        return false
+    }
+    if step.ApproverUserID != nil {
+        return *step.ApproverUserID == member.UserID
+    }
+    if step.ApproverRole != nil {
+        return models.TeamRoleRank(member.Role) >= models.TeamRoleRank(*step.ApproverRole)
+    }
+    return false
+}
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpenseService_Workflow(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	mockPolicyRepo := new(MockApprovalPolicyRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, new(MockUserRepository), mockTeamRepo, mockPolicyRepo, new(MockExchangeRateRepository), noBudgets(), anyCategory(), nil)
+
+	mockTeamRepo.On("GetTeamMember", "team-1", "member").Return(teamMember("team-1", "member", models.TeamRoleMember), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "admin").Return(teamMember("team-1", "admin", models.TeamRoleAdmin), nil)
+	mockPolicyRepo.On("GetPoliciesByTeam", "team-1").Return(nil, nil)
+
+	expenseIn := func(status, owner string) *models.Expense {
+		return &models.Expense{ID: "exp-1", UserID: owner, TeamID: strPtr("team-1"), Status: status}
+	}
+
+	t.Run("Owner submits draft with default approval", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(expenseIn(models.ExpenseStatusDraft, "member"), nil).Once()
+		mockExpenseRepo.On("TransitionExpense", mock.MatchedBy(func(tr *models.ExpenseTransition) bool {
+			return tr.FromStatus == models.ExpenseStatusDraft && tr.ToStatus == models.ExpenseStatusSubmitted && tr.ActorID == "member"
+		}), mock.MatchedBy(func(steps []*models.ExpenseApprovalStep) bool {
+			return len(steps) == 1 && *steps[0].ApproverRole == models.TeamRoleAdmin
+		})).Return(true, nil).Once()
+
+		expense, err := expenseService.SubmitExpense("exp-1", "member", &models.ExpenseActionRequest{})
+
+		require.NoError(t, err)
+		assert.Equal(t, models.ExpenseStatusSubmitted, expense.Status)
+	})
+
+	t.Run("Only the owner can submit", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(expenseIn(models.ExpenseStatusDraft, "member"), nil).Once()
+
+		_, err := expenseService.SubmitExpense("exp-1", "admin", &models.ExpenseActionRequest{})
+
+		assert.ErrorIs(t, err, ErrExpenseAccessDenied)
+	})
+
+	t.Run("Member is not the approver", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(expenseIn(models.ExpenseStatusSubmitted, "someone"), nil).Once()
+		mockExpenseRepo.On("GetApprovalSteps", "exp-1").Return([]*models.ExpenseApprovalStep{
+			{ID: "step-1", Round: 1, StepOrder: 1, ApproverRole: strPtr(models.TeamRoleAdmin), Status: models.ApprovalStepPending},
+		}, nil).Once()
+
+		_, err := expenseService.ApproveExpense("exp-1", "member", &models.ExpenseActionRequest{})
+
+		assert.ErrorIs(t, err, ErrNotStepApprover)
+	})
+
+	t.Run("Member cannot approve expense without steps", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(expenseIn(models.ExpenseStatusSubmitted, "someone"), nil).Once()
+		mockExpenseRepo.On("GetApprovalSteps", "exp-1").Return(nil, nil).Once()
+
+		_, err := expenseService.ApproveExpense("exp-1", "member", &models.ExpenseActionRequest{})
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+	})
+
+	t.Run("Admin cannot approve own expense", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(expenseIn(models.ExpenseStatusSubmitted, "admin"), nil).Once()
+
+		_, err := expenseService.ApproveExpense("exp-1", "admin", &models.ExpenseActionRequest{})
+
+		assert.ErrorIs(t, err, ErrSelfApproval)
+	})
+
+	t.Run("Draft cannot be approved", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(expenseIn(models.ExpenseStatusDraft, "member"), nil).Once()
+
+		_, err := expenseService.ApproveExpense("exp-1", "admin", &models.ExpenseActionRequest{})
+
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+	})
+
+	t.Run("Rejection requires a reason", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(expenseIn(models.ExpenseStatusSubmitted, "member"), nil).Once()
+
+		_, err := expenseService.RejectExpense("exp-1", "admin", &models.ExpenseActionRequest{Reason: "   "})
+
+		assert.ErrorIs(t, err, ErrRejectionReasonRequired)
+	})
+
+	t.Run("Admin rejects current step with reason", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(expenseIn(models.ExpenseStatusSubmitted, "member"), nil).Once()
+		mockExpenseRepo.On("GetApprovalSteps", "exp-1").Return([]*models.ExpenseApprovalStep{
+			{ID: "step-1", Round: 1, StepOrder: 1, ApproverRole: strPtr(models.TeamRoleAdmin), Status: models.ApprovalStepPending},
+			{ID: "step-2", Round: 1, StepOrder: 2, ApproverUserID: strPtr("finance"), Status: models.ApprovalStepPending},
+		}, nil).Once()
+		mockExpenseRepo.On("DecideApprovalStep", mock.MatchedBy(func(step *models.ExpenseApprovalStep) bool {
+			return step.ID == "step-1" && step.Status == models.ApprovalStepRejected
+		}), mock.MatchedBy(func(tr *models.ExpenseTransition) bool {
+			return tr != nil && tr.ToStatus == models.ExpenseStatusRejected && *tr.Reason == "Missing receipt"
+		})).Return(true, nil).Once()
+
+		expense, err := expenseService.RejectExpense("exp-1", "admin", &models.ExpenseActionRequest{Reason: "Missing receipt"})
+
+		require.NoError(t, err)
+		assert.Equal(t, models.ExpenseStatusRejected, expense.Status)
+	})
+
+	t.Run("Concurrent change is reported as invalid transition", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(expenseIn(models.ExpenseStatusApproved, "member"), nil).Once()
+		mockExpenseRepo.On("TransitionExpense", mock.AnythingOfType("*models.ExpenseTransition"), mock.Anything).Return(false, nil).Once()
+
+		_, err := expenseService.ReimburseExpense("exp-1", "admin", &models.ExpenseActionRequest{})
+
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+	})
+
+	t.Run("Personal expenses have no approval", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-2").Return(&models.Expense{ID: "exp-2", UserID: "member", Status: models.ExpenseStatusDraft}, nil).Once()
+
+		_, err := expenseService.SubmitExpense("exp-2", "member", &models.ExpenseActionRequest{})
+
+		assert.ErrorIs(t, err, ErrNotTeamExpense)
+	})
+}
+
+func TestExpenseService_ApprovalChain(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	mockPolicyRepo := new(MockApprovalPolicyRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, new(MockUserRepository), mockTeamRepo, mockPolicyRepo, new(MockExchangeRateRepository), noBudgets(), anyCategory(), nil)
+
+	mockTeamRepo.On("GetTeamMember", "team-1", "member").Return(teamMember("team-1", "member", models.TeamRoleMember), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "manager").Return(teamMember("team-1", "manager", models.TeamRoleAdmin), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "finance").Return(teamMember("team-1", "finance", models.TeamRoleAdmin), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "viewer").Return(teamMember("team-1", "viewer", models.TeamRoleViewer), nil)
+	mockTeamRepo.On("GetTeamByID", "team-1").Return(&models.Team{ID: "team-1", BaseCurrency: "USD"}, nil)
+	mockTeamRepo.On("GetTeamMembers", "team-1").Return([]*models.TeamMember{
+		teamMember("team-1", "member", models.TeamRoleMember),
+		teamMember("team-1", "manager", models.TeamRoleAdmin),
+		teamMember("team-1", "finance", models.TeamRoleAdmin),
+		teamMember("team-1", "viewer", models.TeamRoleViewer),
+	}, nil)
+
+	submitted := func() *models.Expense {
+		return &models.Expense{ID: "exp-1", UserID: "member", TeamID: strPtr("team-1"), Amount: money.MustParse("750"), Status: models.ExpenseStatusSubmitted}
+	}
+
+	t.Run("Matching policy builds the chain on submit", func(t *testing.T) {
+		mockPolicyRepo.On("GetPoliciesByTeam", "team-1").Return([]*models.ApprovalPolicy{
+			{ID: "big", MinAmount: amountPtr("500"), Steps: []models.ApprovalPolicyStep{
+				{ApproverRole: strPtr(models.TeamRoleAdmin)},
+				{ApproverUserID: strPtr("finance")},
+			}},
+		}, nil).Once()
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(&models.Expense{ID: "exp-1", UserID: "member", TeamID: strPtr("team-1"), Amount: money.MustParse("750"), Currency: "USD", Status: models.ExpenseStatusDraft}, nil).Once()
+		mockExpenseRepo.On("TransitionExpense", mock.AnythingOfType("*models.ExpenseTransition"), mock.MatchedBy(func(steps []*models.ExpenseApprovalStep) bool {
+			return len(steps) == 2 && steps[0].StepOrder == 1 && *steps[1].ApproverUserID == "finance"
+		})).Return(true, nil).Once()
+
+		expense, err := expenseService.SubmitExpense("exp-1", "member", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.ExpenseStatusSubmitted, expense.Status)
+	})
+
+	chain := func(firstStatus string, decidedBy *string) []*models.ExpenseApprovalStep {
+		return []*models.ExpenseApprovalStep{
+			{ID: "old", Round: 1, StepOrder: 1, ApproverRole: strPtr(models.TeamRoleAdmin), Status: models.ApprovalStepRejected, DecidedBy: strPtr("finance")},
+			{ID: "step-1", Round: 2, StepOrder: 1, ApproverRole: strPtr(models.TeamRoleAdmin), Status: firstStatus, DecidedBy: decidedBy},
+			{ID: "step-2", Round: 2, StepOrder: 2, ApproverUserID: strPtr("finance"), Status: models.ApprovalStepPending},
+		}
+	}
+
+	t.Run("Manager cannot decide the finance step", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(submitted(), nil).Once()
+		mockExpenseRepo.On("GetApprovalSteps", "exp-1").Return(chain(models.ApprovalStepApproved, strPtr("someone")), nil).Once()
+
+		_, err := expenseService.ApproveExpense("exp-1", "manager", nil)
+
+		assert.ErrorIs(t, err, ErrNotStepApprover)
+	})
+
+	t.Run("Steps only go to roles that can approve", func(t *testing.T) {
+		// Steps stored before approvers had to be owners or admins
+		legacy := []*models.ExpenseApprovalStep{
+			{ID: "step-1", Round: 1, StepOrder: 1, ApproverUserID: strPtr("viewer"), Status: models.ApprovalStepPending},
+			{ID: "step-2", Round: 1, StepOrder: 2, ApproverRole: strPtr(models.TeamRoleViewer), Status: models.ApprovalStepPending},
+		}
+
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(submitted(), nil).Once()
+		mockExpenseRepo.On("GetApprovalSteps", "exp-1").Return(legacy, nil).Once()
+		_, err := expenseService.ApproveExpense("exp-1", "viewer", nil)
+		assert.ErrorIs(t, err, ErrNotStepApprover)
+
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(submitted(), nil).Once()
+		mockExpenseRepo.On("GetApprovalSteps", "exp-1").Return(legacy[1:], nil).Once()
+		_, err = expenseService.ApproveExpense("exp-1", "viewer", nil)
+		assert.ErrorIs(t, err, ErrNotStepApprover)
+	})
+
+	t.Run("Manager approval keeps expense submitted", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(submitted(), nil).Once()
+		mockExpenseRepo.On("GetApprovalSteps", "exp-1").Return(chain(models.ApprovalStepPending, nil), nil).Once()
+		mockExpenseRepo.On("DecideApprovalStep", mock.MatchedBy(func(step *models.ExpenseApprovalStep) bool {
+			return step.ID == "step-1" && step.Status == models.ApprovalStepApproved
+		}), (*models.ExpenseTransition)(nil)).Return(true, nil).Once()
+
+		expense, err := expenseService.ApproveExpense("exp-1", "manager", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.ExpenseStatusSubmitted, expense.Status)
+	})
+
+	t.Run("Manager cannot also approve the finance step", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(submitted(), nil).Once()
+		mockExpenseRepo.On("GetApprovalSteps", "exp-1").Return(chain(models.ApprovalStepApproved, strPtr("manager")), nil).Once()
+
+		_, err := expenseService.ApproveExpense("exp-1", "manager", nil)
+
+		assert.ErrorIs(t, err, ErrAlreadyApproved)
+	})
+
+	t.Run("Finance approval completes the chain", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(submitted(), nil).Once()
+		mockExpenseRepo.On("GetApprovalSteps", "exp-1").Return(chain(models.ApprovalStepApproved, strPtr("manager")), nil).Once()
+		mockExpenseRepo.On("DecideApprovalStep", mock.MatchedBy(func(step *models.ExpenseApprovalStep) bool {
+			return step.ID == "step-2" && step.Status == models.ApprovalStepApproved
+		}), mock.MatchedBy(func(tr *models.ExpenseTransition) bool {
+			return tr != nil && tr.ToStatus == models.ExpenseStatusApproved && tr.ActorID == "finance"
+		})).Return(true, nil).Once()
+
+		expense, err := expenseService.ApproveExpense("exp-1", "finance", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.ExpenseStatusApproved, expense.Status)
+	})
+
+	t.Run("Finance keeps itself for its own step", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(submitted(), nil).Once()
+		mockExpenseRepo.On("GetApprovalSteps", "exp-1").Return(chain(models.ApprovalStepPending, nil), nil).Once()
+
+		_, err := expenseService.ApproveExpense("exp-1", "finance", nil)
+
+		assert.ErrorIs(t, err, ErrNotStepApprover)
+	})
+}
+
+func TestApprovalChain(t *testing.T) {
+	members := []*models.TeamMember{
+		teamMember("team-1", "submitter", models.TeamRoleAdmin),
+		teamMember("team-1", "boss", models.TeamRoleOwner),
+		teamMember("team-1", "manager", models.TeamRoleAdmin),
+		teamMember("team-1", "finance", models.TeamRoleAdmin),
+		teamMember("team-1", "member", models.TeamRoleMember),
+	}
+	admin := models.ApprovalPolicyStep{ApproverRole: strPtr(models.TeamRoleAdmin)}
+	owner := models.ApprovalPolicyStep{ApproverRole: strPtr(models.TeamRoleOwner)}
+	named := func(userID string) models.ApprovalPolicyStep {
+		return models.ApprovalPolicyStep{ApproverUserID: strPtr(userID)}
+	}
+
+	tests := []struct {
+		name     string
+		steps    []models.ApprovalPolicyStep
+		expected []models.ApprovalPolicyStep
+	}{
+		{"Satisfiable chain is kept", []models.ApprovalPolicyStep{admin, named("finance"), owner}, []models.ApprovalPolicyStep{admin, named("finance"), owner}},
+		{"Submitter named", []models.ApprovalPolicyStep{named("submitter"), named("finance")}, []models.ApprovalPolicyStep{admin, named("finance")}},
+		{"Same user named twice", []models.ApprovalPolicyStep{named("finance"), named("finance")}, []models.ApprovalPolicyStep{named("finance"), admin}},
+		{"Named approver left the team", []models.ApprovalPolicyStep{named("former")}, []models.ApprovalPolicyStep{admin}},
+		{"Named approver cannot approve", []models.ApprovalPolicyStep{named("member"), owner}, []models.ApprovalPolicyStep{admin, owner}},
+		{"Role that cannot approve", []models.ApprovalPolicyStep{{ApproverRole: strPtr(models.TeamRoleMember)}}, []models.ApprovalPolicyStep{admin}},
+		{"More steps than approvers", []models.ApprovalPolicyStep{admin, admin, admin, admin}, []models.ApprovalPolicyStep{admin, admin, admin}},
+		{"Owner step taken by a named owner", []models.ApprovalPolicyStep{named("boss"), owner}, []models.ApprovalPolicyStep{named("boss")}},
+		{"Owner step keeps the owner from admin steps", []models.ApprovalPolicyStep{admin, admin, owner}, []models.ApprovalPolicyStep{admin, admin, owner}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, approvalChain(tt.steps, "submitter", members))
+		})
+	}
+
+	t.Run("Nobody left to approve", func(t *testing.T) {
+		alone := []*models.TeamMember{teamMember("team-1", "submitter", models.TeamRoleOwner)}
+
+		assert.Equal(t, defaultApprovalSteps, approvalChain([]models.ApprovalPolicyStep{owner, named("finance")}, "submitter", alone))
+	})
+}
+
+func TestExpenseService_UnavailableApprover(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, new(MockUserRepository), mockTeamRepo, new(MockApprovalPolicyRepository), new(MockExchangeRateRepository), noBudgets(), anyCategory(), nil)
+
+	mockTeamRepo.On("GetTeamMember", "team-1", "manager").Return(teamMember("team-1", "manager", models.TeamRoleAdmin), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "finance").Return(teamMember("team-1", "finance", models.TeamRoleAdmin), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "submitter").Return(teamMember("team-1", "submitter", models.TeamRoleAdmin), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "former").Return(nil, nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "demoted").Return(teamMember("team-1", "demoted", models.TeamRoleMember), nil)
+
+	// Chains stored before submit adapted them, or whose approver changed since
+	tests := []struct {
+		name  string
+		steps []*models.ExpenseApprovalStep
+	}{
+		{"Named approver left the team", []*models.ExpenseApprovalStep{
+			{ID: "step-1", Round: 1, StepOrder: 1, ApproverUserID: strPtr("former"), Status: models.ApprovalStepPending},
+		}},
+		{"Named approver can no longer approve", []*models.ExpenseApprovalStep{
+			{ID: "step-1", Round: 1, StepOrder: 1, ApproverUserID: strPtr("demoted"), Status: models.ApprovalStepPending},
+		}},
+		{"Submitter named", []*models.ExpenseApprovalStep{
+			{ID: "step-1", Round: 1, StepOrder: 1, ApproverUserID: strPtr("submitter"), Status: models.ApprovalStepPending},
+		}},
+		{"Same user named twice", []*models.ExpenseApprovalStep{
+			{ID: "step-0", Round: 1, StepOrder: 1, ApproverUserID: strPtr("finance"), Status: models.ApprovalStepApproved, DecidedBy: strPtr("finance")},
+			{ID: "step-1", Round: 1, StepOrder: 2, ApproverUserID: strPtr("finance"), Status: models.ApprovalStepPending},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(&models.Expense{
+				ID: "exp-1", UserID: "submitter", TeamID: strPtr("team-1"), Status: models.ExpenseStatusSubmitted,
+			}, nil).Once()
+			mockExpenseRepo.On("GetApprovalSteps", "exp-1").Return(tt.steps, nil).Once()
+			mockExpenseRepo.On("DecideApprovalStep", mock.MatchedBy(func(step *models.ExpenseApprovalStep) bool {
+				return step.ID == "step-1" && *step.DecidedBy == "manager"
+			}), mock.MatchedBy(func(tr *models.ExpenseTransition) bool {
+				return tr != nil && tr.ToStatus == models.ExpenseStatusApproved
+			})).Return(true, nil).Once()
+
+			expense, err := expenseService.ApproveExpense("exp-1", "manager", nil)
+
+			require.NoError(t, err)
+			assert.Equal(t, models.ExpenseStatusApproved, expense.Status)
+		})
+	}
+
+	t.Run("Available named approver keeps the step", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(&models.Expense{
+			ID: "exp-1", UserID: "submitter", TeamID: strPtr("team-1"), Status: models.ExpenseStatusSubmitted,
+		}, nil).Once()
+		mockExpenseRepo.On("GetApprovalSteps", "exp-1").Return([]*models.ExpenseApprovalStep{
+			{ID: "step-1", Round: 1, StepOrder: 1, ApproverUserID: strPtr("finance"), Status: models.ApprovalStepPending},
+		}, nil).Once()
+
+		_, err := expenseService.ApproveExpense("exp-1", "manager", nil)
+
+		assert.ErrorIs(t, err, ErrNotStepApprover)
+	})
+}
+
+func TestExpenseService_ApprovalPolicyCurrency(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	mockPolicyRepo := new(MockApprovalPolicyRepository)
+	mockRateRepo := new(MockExchangeRateRepository)
+	mockRateRepo.On("GetExchangeRates", "2026-01-15", []string(nil)).Return(ecbRates(), nil)
+	expenseService := NewExpenseService(mockExpenseRepo, new(MockUserRepository), mockTeamRepo, mockPolicyRepo, mockRateRepo, noBudgets(), anyCategory(), nil)
+
+	mockTeamRepo.On("GetTeamMember", mock.Anything, "member").Return(teamMember("team-1", "member", models.TeamRoleMember), nil)
+	mockTeamRepo.On("GetTeamByID", "eur-team").Return(&models.Team{ID: "eur-team", BaseCurrency: "EUR"}, nil)
+	mockTeamRepo.On("GetTeamByID", "jpy-team").Return(&models.Team{ID: "jpy-team", BaseCurrency: "JPY"}, nil)
+	bigPolicy := []*models.ApprovalPolicy{
+		{ID: "big", MinAmount: amountPtr("500"), Steps: []models.ApprovalPolicyStep{
+			{ApproverRole: strPtr(models.TeamRoleAdmin)},
+			{ApproverUserID: strPtr("finance")},
+		}},
+	}
+	mockPolicyRepo.On("GetPoliciesByTeam", mock.Anything).Return(bigPolicy, nil)
+	mockTeamRepo.On("GetTeamMembers", mock.Anything).Return([]*models.TeamMember{
+		teamMember("team-1", "member", models.TeamRoleMember),
+		teamMember("team-1", "manager", models.TeamRoleAdmin),
+		teamMember("team-1", "finance", models.TeamRoleAdmin),
+	}, nil)
+
+	submit := func(teamID, amount, currency string) []*models.ExpenseApprovalStep {
+		var steps []*models.ExpenseApprovalStep
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(&models.Expense{
+			ID: "exp-1", UserID: "member", TeamID: strPtr(teamID), Amount: money.MustParse(amount), Currency: currency,
+			ExpenseDate: "2026-01-15", Status: models.ExpenseStatusDraft,
+		}, nil).Once()
+		mockExpenseRepo.On("TransitionExpense", mock.AnythingOfType("*models.ExpenseTransition"), mock.Anything).Return(true, nil).Run(func(args mock.Arguments) {
+			steps = args.Get(1).([]*models.ExpenseApprovalStep)
+		}).Once()
+
+		_, err := expenseService.SubmitExpense("exp-1", "member", nil)
+		require.NoError(t, err)
+		return steps
+	}
+
+	t.Run("Small yen expense stays under a euro threshold", func(t *testing.T) {
+		steps := submit("eur-team", "600", "JPY")
+
+		assert.Len(t, steps, 1)
+	})
+
+	t.Run("Euro expense passes a yen threshold", func(t *testing.T) {
+		steps := submit("jpy-team", "400", "EUR")
+
+		assert.Len(t, steps, 2)
+	})
+}
@@ -1,20 +1,157 @@
 package services
 
-import "pocketpilot/internal/models"
+import (
+    "pocketpilot/internal/models"
+    "time"
+)
 
 type UserRepository interface {
     GetUserByEmail(email string) (*models.User, error) 
 	CreateUser(user *models.User) error
 	GetUserByID(id string) (*models.User, error)
+	UpdateUser(user *models.User) error
 	EmailExists(email string) (bool, error)
+	MarkEmailVerified(id, email string) (bool, error)
+	ClaimVerificationEmail(id string, sentBefore time.Time) (bool, error)
+}
+
+type TokenRepository interface {
+    CreateSession(*models.Session, *models.RefreshToken) error
+    GetSessionByID(string) (*models.Session, error)
+    GetActiveSessions(string) ([]*models.Session, error)
+    RevokeSession(string) error
+    RevokeOtherSessions(string, string) (int64, error)
+    GetRefreshTokenByHash(string) (*models.RefreshToken, error)
+    RotateRefreshToken(string, *models.RefreshToken, *models.SessionClient) (bool, error)
+    UpdatePassword(string, string, string) error
+    CreatePasswordReset(*models.PasswordReset) error
+    GetPasswordResetByHash(string) (*models.PasswordReset, error)
+    ResetPassword(string, string) (bool, error)
+    RevokeAccessToken(string, time.Time) error
+    IsAccessTokenRevoked(string, string) (bool, error)
+    DeleteExpiredTokens(time.Time) (int64, error)
+}
+
+type TwoFactorRepository interface {
+    SetPendingTOTPSecret(string, string) (bool, error)
+    EnableTwoFactor(string, string, int64, []string) (bool, error)
+    DisableTwoFactor(string) error
+    UseTOTPStep(string, int64) (bool, error)
+    UseRecoveryCode(string, string) (bool, error)
+    CreateMFAChallenge(*models.MFAChallenge) error
+    GetMFAChallengeByHash(string) (*models.MFAChallenge, error)
+    RecordMFAAttempt(string, int) (bool, error)
+    DeleteMFAChallenge(string) (bool, error)
 }
 
 type ExpenseRepository interface {
     CreateExpense(*models.Expense) error
+    CreateExpenses([]*models.Expense) error
     GetExpenseByID(string) (*models.Expense, error)
-    GetExpensesByID(string) (*models.Expense, error)
-    GetExpensesByUser(string, int, int) ([]*models.Expense, error)
+    GetExpensesByUser(string, *models.ExpenseFilter, int, int) ([]*models.Expense, error)
     UpdateExpense(*models.Expense) error
     DeleteExpense(string, string) error
-    GetExpensesByTeam(string, int, int) ([]*models.Expense, error)
+    GetExpensesByTeam(string, *models.ExpenseFilter, int, int) ([]*models.Expense, error)
+    CountExpensesByUser(string, *models.ExpenseFilter) (int, error)
+    CountExpensesByTeam(string, *models.ExpenseFilter) (int, error)
+    StreamExpensesByUser(string, *models.ExpenseFilter, func(*models.Expense) error) error
+    StreamExpensesByTeam(string, *models.ExpenseFilter, func(*models.Expense) error) error
+    GetExistingBankTransactionIDs(string, []string) ([]string, error)
+    TransitionExpense(*models.ExpenseTransition, []*models.ExpenseApprovalStep) (bool, error)
+    GetExpenseTransitions(string) ([]*models.ExpenseTransition, error)
+    DecideApprovalStep(*models.ExpenseApprovalStep, *models.ExpenseTransition) (bool, error)
+    GetApprovalSteps(string) ([]*models.ExpenseApprovalStep, error)
+}
+
+type TeamRepository interface {
+    CreateTeam(*models.Team) error
+    GetTeamByID(string) (*models.Team, error)
+    GetTeamsByUser(string) ([]*models.Team, error)
+    UpdateTeam(*models.Team) error
+    DeleteTeam(string, string) error
+    GetTeamMember(string, string) (*models.TeamMember, error)
+    GetTeamMembers(string) ([]*models.TeamMember, error)
+    AddTeamMember(*models.TeamMember) error
+    UpdateTeamMemberRole(string, string, string) error
+    RemoveTeamMember(string, string) error
+    CountTeamMembersByRole(string, string) (int, error)
+}
+
+type ApprovalPolicyRepository interface {
+    CreatePolicy(*models.ApprovalPolicy) error
+    GetPolicyByID(string) (*models.ApprovalPolicy, error)
+    GetPoliciesByTeam(string) ([]*models.ApprovalPolicy, error)
+    UpdatePolicy(*models.ApprovalPolicy) error
+    DeletePolicy(string, string) error
+}
+
+type RecurringExpenseRepository interface {
+    CreateRecurringExpense(*models.RecurringExpense) error
+    GetRecurringExpenseByID(string) (*models.RecurringExpense, error)
+    GetRecurringExpensesByUser(string) ([]*models.RecurringExpense, error)
+    GetDueRecurringExpenses(string, int) ([]*models.RecurringExpense, error)
+    UpdateRecurringExpense(*models.RecurringExpense) error
+    DeleteRecurringExpense(string, string) error
+    MaterializeOccurrence(*models.RecurringExpense, string, *models.Expense) (bool, error)
+}
+
+type ExchangeRateRepository interface {
+    SaveExchangeRates([]*models.ExchangeRate) error
+    GetExchangeRates(string, []string) ([]*models.ExchangeRate, error)
+}
+
+type SplitRepository interface {
+    SetExpenseSplits(string, *string, []*models.ExpenseSplit) error
+    GetExpenseSplits(string) ([]*models.ExpenseSplit, error)
+    GetTeamSplitDebts(string) ([]*models.Debt, error)
+    GetTeamSettledDebts(string) ([]*models.Debt, error)
+    CreateSettlement(*models.Settlement) error
+    GetSettlementsByTeam(string) ([]*models.Settlement, error)
+}
+
+type BudgetRepository interface {
+    CreateBudget(*models.Budget) error
+    GetBudgetByID(string) (*models.Budget, error)
+    GetBudgetsByUser(string) ([]*models.Budget, error)
+    GetBudgetsByTeam(string) ([]*models.Budget, error)
+    GetBudgetsForExpense(*models.Expense) ([]*models.Budget, error)
+    UpdateBudget(*models.Budget) error
+    DeleteBudget(string) error
+    GetBudgetSpending(*models.Budget, string, string) ([]*models.DailySpending, error)
+    CreateBudgetAlert(*models.BudgetAlert) (bool, error)
+    GetBudgetAlerts(string) ([]*models.BudgetAlert, error)
+}
+
+type ReportRepository interface {
+    SumExpensesByUser(string, string, *models.ExpenseFilter) ([]*models.ReportRow, error)
+    SumExpensesByTeam(string, string, *models.ExpenseFilter) ([]*models.ReportRow, error)
+}
+
+type CategoryRepository interface {
+    CreateCategory(*models.Category) error
+    GetCategoryByID(string) (*models.Category, error)
+    GetCategories(string, *string, bool) ([]*models.Category, error)
+    GetCategoryByName(string, *string, string) (*models.Category, error)
+    UpdateCategory(*models.Category, string) error
+    CountSubcategories(string) (int, error)
+}
+
+type TagRepository interface {
+    GetTags(string, *string) ([]*models.Tag, error)
+    GetTagByID(string) (*models.Tag, error)
+    GetTagByName(string, *string, string) (*models.Tag, error)
+    UpdateTag(*models.Tag) error
+    MergeTags(string, string) error
+    DeleteTag(string) error
+}
+
+type ReceiptRepository interface {
+    CreateReceipt(*models.Receipt) error
+    GetReceiptByID(string) (*models.Receipt, error)
+    GetReceiptsByExpense(string) ([]*models.Receipt, error)
+    DeleteReceipt(string) error
+    GetReceiptsToScan(time.Time, int) ([]*models.Receipt, error)
+    ClaimReceiptScan(string, time.Time) (bool, error)
+    SaveReceiptScan(*models.Receipt) error
+    QueueReceiptScan(string) error
 }
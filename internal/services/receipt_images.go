@@ -0,0 +1,150 @@
+package services
+
+import (
+    "bytes"
+    "image"
+    "image/jpeg"
+    "image/png"
+    "log"
+    "path"
+    "pocketpilot/internal/models"
+    "pocketpilot/pkg/imaging"
+    "strings"
+)
+
+const (
+    // uprightJPEGQuality is used when a photo has to be re-encoded to turn it upright
+    uprightJPEGQuality = 92
+    // thumbnailJPEGQuality is used for thumbnails and rendered PDF pages
+    thumbnailJPEGQuality = 85
+    // pdfPageSize is the longest side of rendered PDF pages, in pixels
+    pdfPageSize = 1600
+)
+
+// receiptThumbnails lists the thumbnails made of receipts, largest first so
+// each is scaled down from the previous one
+var receiptThumbnails = []struct {
+    name    string
+    maxSide int
+}{
+    {models.ReceiptVariantLarge, 1024},
+    {models.ReceiptVariantMedium, 480},
+    {models.ReceiptVariantSmall, 160},
+}
+
+// preparedReceipt is an uploaded file ready to store, with its variants
+type preparedReceipt struct {
+    data     []byte
+    variants []*preparedVariant
+}
+
+type preparedVariant struct {
+    models.ReceiptVariant
+    data []byte
+}
+
+// prepareReceipt makes an uploaded receipt ready to store. Photos are turned
+// upright and lose their metadata, which can say where they were taken, and
+// JPEG and PNG images get thumbnails. PDFs get their first page rendered, when
+// a renderer is set up. WebP images are stripped but get no thumbnails and
+// HEIC photos are stored as they are, as neither can be decoded here.
+// Processing problems are logged rather than failing the upload.
+func (s *ReceiptService) prepareReceipt(data []byte, contentType string) *preparedReceipt {
+    prepared := &preparedReceipt{data: data}
+
+    var img *image.RGBA
+    switch contentType {
+    case "image/jpeg", "image/png":
+        var decoded image.Image
+        var err error
+        if contentType == "image/jpeg" {
+            decoded, err = jpeg.Decode(bytes.NewReader(data))
+        } else {
+            decoded, err = png.Decode(bytes.NewReader(data))
+        }
+        if err != nil {
+            log.Printf("Receipt image not decoded: %v", err)
+            prepared.data = stripReceiptMetadata(data, contentType)
+            return prepared
+        }
+        img = imaging.Flatten(decoded)
+
+        orientation := 1
+        if contentType == "image/jpeg" {
+            orientation = imaging.Orientation(data)
+        }
+        if orientation > 1 {
+            img = imaging.Orient(img, orientation)
+            upright, err := imaging.EncodeJPEG(img, uprightJPEGQuality)
+            if err != nil {
+                log.Printf("Receipt photo not turned upright: %v", err)
+                prepared.data = stripReceiptMetadata(data, contentType)
+            } else {
+                prepared.data = upright
+            }
+        } else {
+            prepared.data = stripReceiptMetadata(data, contentType)
+        }
+
+    case "image/webp":
+        prepared.data = stripReceiptMetadata(data, contentType)
+        return prepared
+
+    case "application/pdf":
+        if s.pdfRenderer == nil {
+            return prepared
+        }
+        page, err := s.pdfRenderer.RenderFirstPage(data, pdfPageSize)
+        if err != nil {
+            log.Printf("Receipt PDF page not rendered: %v", err)
+            return prepared
+        }
+        img = imaging.Flatten(page)
+        if variant := encodeReceiptVariant(models.ReceiptVariantPage, img); variant != nil {
+            prepared.variants = append(prepared.variants, variant)
+        }
+
+    default:
+        return prepared
+    }
+
+    for _, thumbnail := range receiptThumbnails {
+        img = imaging.Resize(img, thumbnail.maxSide)
+        if variant := encodeReceiptVariant(thumbnail.name, img); variant != nil {
+            prepared.variants = append(prepared.variants, variant)
+        }
+    }
+    return prepared
+}
+
+func stripReceiptMetadata(data []byte, contentType string) []byte {
+    stripped, err := imaging.StripMetadata(data, contentType)
+    if err != nil {
+        log.Printf("Receipt metadata not stripped: %v", err)
+        return data
+    }
+    return stripped
+}
+
+func encodeReceiptVariant(name string, img *image.RGBA) *preparedVariant {
+    data, err := imaging.EncodeJPEG(img, thumbnailJPEGQuality)
+    if err != nil {
+        log.Printf("Receipt %s variant not made: %v", name, err)
+        return nil
+    }
+    return &preparedVariant{
+        ReceiptVariant: models.ReceiptVariant{
+            Name:        name,
+            ContentType: "image/jpeg",
+            Width:       img.Bounds().Dx(),
+            Height:      img.Bounds().Dy(),
+            Size:        int64(len(data)),
+        },
+        data: data,
+    }
+}
+
+// receiptVariantKey stores a variant next to its receipt
+func receiptVariantKey(key, name string) string {
+    return strings.TrimSuffix(key, path.Ext(key)) + "_" + name + ".jpg"
+}
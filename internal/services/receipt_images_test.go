@@ -0,0 +1,124 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/imaging"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePDFRenderer renders every PDF as a blank page
+type fakePDFRenderer struct {
+	width, height int
+}
+
+func (r fakePDFRenderer) RenderFirstPage(data []byte, maxSide int) (image.Image, error) {
+	return image.NewGray(image.Rect(0, 0, r.width, r.height)), nil
+}
+
+// photoWithOrientation encodes a landscape JPEG carrying an EXIF block with
+// the given orientation and a GPS tag
+func photoWithOrientation(t *testing.T, width, height, orientation int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 180, B: 160, A: 255})
+		}
+	}
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, img, nil))
+
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00\x2a")
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(2))
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{uint16(orientation), 0})
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x8825, 4})
+	binary.Write(&tiff, binary.BigEndian, []uint32{1, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	exif := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+
+	var photo bytes.Buffer
+	photo.Write(encoded.Bytes()[:2])
+	photo.Write([]byte{0xFF, 0xE1})
+	binary.Write(&photo, binary.BigEndian, uint16(len(exif)+2))
+	photo.Write(exif)
+	photo.Write(encoded.Bytes()[2:])
+	return photo.Bytes()
+}
+
+func variantNames(variants []*preparedVariant) []string {
+	names := make([]string, len(variants))
+	for i, variant := range variants {
+		names[i] = variant.Name
+	}
+	return names
+}
+
+func TestPrepareReceipt(t *testing.T) {
+	receiptService := NewReceiptService(new(MockReceiptRepository), new(MockExpenseRepository), new(MockTeamRepository), newMemoryStorage(), nil, fakePDFRenderer{width: 1200, height: 1600}, 15*time.Minute)
+
+	t.Run("Photos are turned upright and stripped", func(t *testing.T) {
+		photo := photoWithOrientation(t, 2000, 1000, 6)
+		require.Equal(t, 6, imaging.Orientation(photo))
+
+		prepared := receiptService.prepareReceipt(photo, "image/jpeg")
+
+		assert.Equal(t, 1, imaging.Orientation(prepared.data))
+		assert.NotContains(t, string(prepared.data), "Exif")
+		upright, err := jpeg.DecodeConfig(bytes.NewReader(prepared.data))
+		require.NoError(t, err)
+		assert.Equal(t, 1000, upright.Width)
+		assert.Equal(t, 2000, upright.Height)
+
+		assert.Equal(t, []string{models.ReceiptVariantLarge, models.ReceiptVariantMedium, models.ReceiptVariantSmall}, variantNames(prepared.variants))
+		assert.Equal(t, 512, prepared.variants[0].Width)
+		assert.Equal(t, 1024, prepared.variants[0].Height)
+		assert.Equal(t, 80, prepared.variants[2].Width)
+		assert.Equal(t, 160, prepared.variants[2].Height)
+	})
+
+	t.Run("Upright photos keep their image data", func(t *testing.T) {
+		photo := photoWithOrientation(t, 300, 200, 1)
+
+		prepared := receiptService.prepareReceipt(photo, "image/jpeg")
+
+		assert.NotContains(t, string(prepared.data), "Exif")
+		assert.Less(t, len(prepared.data), len(photo))
+		assert.Equal(t, []string{models.ReceiptVariantLarge, models.ReceiptVariantMedium, models.ReceiptVariantSmall}, variantNames(prepared.variants))
+		assert.Equal(t, 300, prepared.variants[0].Width)
+	})
+
+	t.Run("PDFs get their first page rendered", func(t *testing.T) {
+		pdf := []byte("%PDF-1.7")
+
+		prepared := receiptService.prepareReceipt(pdf, "application/pdf")
+
+		assert.Equal(t, pdf, prepared.data)
+		assert.Equal(t, []string{models.ReceiptVariantPage, models.ReceiptVariantLarge, models.ReceiptVariantMedium, models.ReceiptVariantSmall}, variantNames(prepared.variants))
+		assert.Equal(t, 1600, prepared.variants[0].Height)
+		assert.Equal(t, "image/jpeg", prepared.variants[0].ContentType)
+	})
+
+	t.Run("Unreadable images are kept", func(t *testing.T) {
+		prepared := receiptService.prepareReceipt(pngHeader, "image/png")
+
+		assert.Equal(t, pngHeader, prepared.data)
+		assert.Empty(t, prepared.variants)
+	})
+}
+
+func TestReceiptVariantKey(t *testing.T) {
+	assert.Equal(t, "receipts/exp-1/ab12_small.jpg", receiptVariantKey("receipts/exp-1/ab12.png", models.ReceiptVariantSmall))
+	assert.Equal(t, "receipts/exp-1/ab12_page.jpg", receiptVariantKey("receipts/exp-1/ab12.pdf", models.ReceiptVariantPage))
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "pocketpilot/internal/models"
+    "pocketpilot/pkg/ocr"
+    "pocketpilot/pkg/storage"
+    "time"
+)
+
+const (
+    // receiptScanQueueSize is how many uploads can wait for a scan before
+    // further ones are left to the next sweep
+    receiptScanQueueSize = 100
+    // receiptScanBatch caps how many receipts one sweep scans
+    receiptScanBatch = 50
+    // receiptScanStaleAfter is when a scan that never finished, say because
+    // the server stopped, is started again
+    receiptScanStaleAfter = 10 * time.Minute
+)
+
+// ReceiptScanner reads receipts with OCR in the background and stores what it
+// finds on them. Uploads are queued for an immediate scan; a periodic sweep
+// picks up whatever the queue missed, including receipts uploaded while the
+// server was down.
+type ReceiptScanner struct {
+    receiptRepo ReceiptRepository
+    storage     storage.Storage
+    engine      ocr.Engine
+    interval    time.Duration
+    queue       chan string
+    now         func() time.Time
+}
+
+func NewReceiptScanner(receiptRepo ReceiptRepository, store storage.Storage, engine ocr.Engine, interval time.Duration) *ReceiptScanner {
+    return &ReceiptScanner{
+        receiptRepo: receiptRepo,
+        storage:     store,
+        engine:      engine,
+        interval:    interval,
+        queue:       make(chan string, receiptScanQueueSize),
+        now:         time.Now,
+    }
+}
+
+// Queue asks for a receipt to be scanned soon. It never blocks: when the
+// queue is full the receipt waits for the next sweep.
+func (s *ReceiptScanner) Queue(receiptID string) {
+    select {
+    case s.queue <- receiptID:
+    default:
+    }
+}
+
+// Run scans queued receipts as they come and sweeps for missed ones on every
+// tick until ctx is done
+func (s *ReceiptScanner) Run(ctx context.Context) {
+    ticker := time.NewTicker(s.interval)
+    defer ticker.Stop()
+
+    s.sweep()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case receiptID := <-s.queue:
+            if err := s.ScanReceipt(receiptID); err != nil {
+                log.Printf("Receipt OCR: receipt %s: %v", receiptID, err)
+            }
+        case <-ticker.C:
+            s.sweep()
+        }
+    }
+}
+
+func (s *ReceiptScanner) sweep() {
+    receipts, err := s.receiptRepo.GetReceiptsToScan(s.now().Add(-receiptScanStaleAfter), receiptScanBatch)
+    if err != nil {
+        log.Printf("Receipt OCR: %v", err)
+        return
+    }
+    for _, receipt := range receipts {
+        if err := s.ScanReceipt(receipt.ID); err != nil {
+            log.Printf("Receipt OCR: receipt %s: %v", receipt.ID, err)
+        }
+    }
+}
+
+// ScanReceipt reads a receipt waiting for OCR and stores the outcome. A
+// receipt the engine cannot read is recorded as failed or skipped rather
+// than returned as an error; errors mean the outcome could not be stored.
+func (s *ReceiptScanner) ScanReceipt(receiptID string) error {
+    claimed, err := s.receiptRepo.ClaimReceiptScan(receiptID, s.now().Add(-receiptScanStaleAfter))
+    if err != nil || !claimed {
+        return err
+    }
+    receipt, err := s.receiptRepo.GetReceiptByID(receiptID)
+    if err != nil || receipt == nil {
+        return err
+    }
+
+    result, err := s.recognize(receipt)
+    switch {
+    case errors.Is(err, ocr.ErrUnsupportedType):
+        message := fmt.Sprintf("OCR cannot read %s files", receipt.ContentType)
+        receipt.OCRStatus = models.ReceiptOCRSkipped
+        receipt.OCRError = &message
+    case err != nil:
+        message := err.Error()
+        receipt.OCRStatus = models.ReceiptOCRFailed
+        receipt.OCRError = &message
+    default:
+        applyReceiptText(receipt, result)
+    }
+    return s.receiptRepo.SaveReceiptScan(receipt)
+}
+
+// recognize reads the receipt file, or the image of its first page when the
+// engine cannot read PDFs
+func (s *ReceiptScanner) recognize(receipt *models.Receipt) (*ocr.Result, error) {
+    result, err := s.recognizeFile(receipt.StorageKey, receipt.ContentType)
+    if !errors.Is(err, ocr.ErrUnsupportedType) {
+        return result, err
+    }
+    for _, variant := range receipt.Variants {
+        if variant.Name == models.ReceiptVariantPage {
+            return s.recognizeFile(variant.StorageKey, variant.ContentType)
+        }
+    }
+    return nil, err
+}
+
+func (s *ReceiptScanner) recognizeFile(key, contentType string) (*ocr.Result, error) {
+    file, err := s.storage.Get(key)
+    if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+
+    data, err := io.ReadAll(file)
+    if err != nil {
+        return nil, err
+    }
+    return s.engine.Recognize(data, contentType)
+}
+
+// applyReceiptText fills in a receipt from the text OCR recognized on it
+func applyReceiptText(receipt *models.Receipt, result *ocr.Result) {
+    fields := ocr.Parse(result.Text)
+
+    receipt.OCRStatus = models.ReceiptOCRCompleted
+    receipt.OCRError = nil
+    receipt.OCRRawData = result.Raw
+    receipt.MerchantName = nil
+    if fields.Merchant != "" {
+        receipt.MerchantName = &fields.Merchant
+    }
+    receipt.TotalAmount = fields.Total
+    receipt.TaxAmount = fields.Tax
+    receipt.Currency = nil
+    if fields.Currency != "" {
+        receipt.Currency = &fields.Currency
+    }
+    receipt.TransactionDate = nil
+    if fields.Date != nil {
+        date := fields.Date.Format("2006-01-02")
+        receipt.TransactionDate = &date
+    }
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/money"
+	"pocketpilot/pkg/ocr"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const dinerReceipt = `JOE'S DINER
+123 Main St
+Tel 555-123-4567
+03/14/2026 12:30
+Burger 12.50
+Fries 4.00
+Subtotal 16.50
+Sales Tax 8.875% 1.46
+TOTAL $17.96
+VISA 17.96
+Thank you!`
+
+// writeFixture stores the OCR text of a file for ocr.Fixtures
+func writeFixture(t *testing.T, dir string, data []byte, text string) {
+	sum := sha256.Sum256(data)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, hex.EncodeToString(sum[:])+".txt"), []byte(text), 0o600))
+}
+
+func TestReceiptScanner_ScanReceipt(t *testing.T) {
+	mockReceiptRepo := new(MockReceiptRepository)
+	store := newMemoryStorage()
+	fixtures := t.TempDir()
+	scanner := NewReceiptScanner(mockReceiptRepo, store, ocr.NewFixtures(fixtures), time.Minute)
+
+	t.Run("Store parsed fields", func(t *testing.T) {
+		store.objects["receipts/exp-1/a.png"] = pngHeader
+		writeFixture(t, fixtures, pngHeader, dinerReceipt)
+		mockReceiptRepo.On("ClaimReceiptScan", "rec-1", mock.Anything).Return(true, nil).Once()
+		mockReceiptRepo.On("GetReceiptByID", "rec-1").Return(&models.Receipt{ID: "rec-1", StorageKey: "receipts/exp-1/a.png", ContentType: "image/png"}, nil).Once()
+		var saved *models.Receipt
+		mockReceiptRepo.On("SaveReceiptScan", mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(0).(*models.Receipt)
+		}).Return(nil).Once()
+
+		require.NoError(t, scanner.ScanReceipt("rec-1"))
+
+		require.NotNil(t, saved)
+		assert.Equal(t, models.ReceiptOCRCompleted, saved.OCRStatus)
+		assert.Equal(t, "JOE'S DINER", *saved.MerchantName)
+		assert.Equal(t, "17.96", saved.TotalAmount.String())
+		assert.Equal(t, "1.46", saved.TaxAmount.String())
+		assert.Equal(t, "USD", *saved.Currency)
+		assert.Equal(t, "2026-03-14", *saved.TransactionDate)
+		assert.NotEmpty(t, saved.OCRRawData)
+	})
+
+	t.Run("Record engine failures", func(t *testing.T) {
+		store.objects["receipts/exp-1/b.pdf"] = []byte("%PDF-1.7 unreadable")
+		mockReceiptRepo.On("ClaimReceiptScan", "rec-2", mock.Anything).Return(true, nil).Once()
+		mockReceiptRepo.On("GetReceiptByID", "rec-2").Return(&models.Receipt{ID: "rec-2", StorageKey: "receipts/exp-1/b.pdf", ContentType: "application/pdf"}, nil).Once()
+		mockReceiptRepo.On("SaveReceiptScan", mock.MatchedBy(func(receipt *models.Receipt) bool {
+			return receipt.OCRStatus == models.ReceiptOCRFailed && receipt.OCRError != nil
+		})).Return(nil).Once()
+
+		require.NoError(t, scanner.ScanReceipt("rec-2"))
+		mockReceiptRepo.AssertExpectations(t)
+	})
+
+	t.Run("Skip receipts claimed elsewhere", func(t *testing.T) {
+		mockReceiptRepo.On("ClaimReceiptScan", "rec-3", mock.Anything).Return(false, nil).Once()
+
+		require.NoError(t, scanner.ScanReceipt("rec-3"))
+		mockReceiptRepo.AssertNotCalled(t, "GetReceiptByID", "rec-3")
+	})
+}
+
+func TestReceiptScanner_UnsupportedType(t *testing.T) {
+	mockReceiptRepo := new(MockReceiptRepository)
+	store := newMemoryStorage()
+	store.objects["receipts/exp-1/c.pdf"] = []byte("%PDF-1.7")
+	scanner := NewReceiptScanner(mockReceiptRepo, store, ocr.NewTesseract(""), time.Minute)
+
+	mockReceiptRepo.On("ClaimReceiptScan", "rec-1", mock.Anything).Return(true, nil).Once()
+	mockReceiptRepo.On("GetReceiptByID", "rec-1").Return(&models.Receipt{ID: "rec-1", StorageKey: "receipts/exp-1/c.pdf", ContentType: "application/pdf"}, nil).Once()
+	mockReceiptRepo.On("SaveReceiptScan", mock.MatchedBy(func(receipt *models.Receipt) bool {
+		return receipt.OCRStatus == models.ReceiptOCRSkipped
+	})).Return(nil).Once()
+
+	require.NoError(t, scanner.ScanReceipt("rec-1"))
+	mockReceiptRepo.AssertExpectations(t)
+}
+
+func TestReconcileReceipt(t *testing.T) {
+	total := money.MustParse("17.96")
+	receipt := &models.Receipt{
+		ID:              "rec-1",
+		OCRStatus:       models.ReceiptOCRCompleted,
+		MerchantName:    strPtr("JOE'S DINER"),
+		TotalAmount:     &total,
+		Currency:        strPtr("USD"),
+		TransactionDate: strPtr("2026-03-14"),
+	}
+
+	t.Run("Matching expense", func(t *testing.T) {
+		expense := &models.Expense{ID: "exp-1", Amount: money.MustParse("17.96"), Currency: "USD", Description: "Lunch at Joe's Diner", ExpenseDate: "2026-03-14T00:00:00Z", Status: models.ExpenseStatusDraft}
+
+		reconciliation := reconcileReceipt(expense, receipt)
+
+		assert.Equal(t, models.ReconciliationMatched, reconciliation.Status)
+		assert.Len(t, reconciliation.Fields, 4)
+		assert.Nil(t, reconciliation.Update)
+	})
+
+	t.Run("Prefill mismatched amount and date", func(t *testing.T) {
+		expense := &models.Expense{ID: "exp-1", Amount: money.MustParse("15"), Currency: "USD", Description: "Lunch", ExpenseDate: "2026-03-13", Status: models.ExpenseStatusDraft}
+
+		reconciliation := reconcileReceipt(expense, receipt)
+
+		assert.Equal(t, models.ReconciliationMismatched, reconciliation.Status)
+		require.NotNil(t, reconciliation.Update)
+		assert.Equal(t, "17.96", reconciliation.Update.Amount.String())
+		assert.Equal(t, "2026-03-14", *reconciliation.Update.ExpenseDate)
+	})
+
+	t.Run("No update for locked expenses", func(t *testing.T) {
+		expense := &models.Expense{ID: "exp-1", Amount: money.MustParse("15"), Currency: "USD", Description: "Lunch", ExpenseDate: "2026-03-14", Status: models.ExpenseStatusApproved}
+
+		reconciliation := reconcileReceipt(expense, receipt)
+
+		assert.Equal(t, models.ReconciliationMismatched, reconciliation.Status)
+		assert.Nil(t, reconciliation.Update)
+	})
+
+	t.Run("Unavailable before OCR", func(t *testing.T) {
+		reconciliation := reconcileReceipt(&models.Expense{ID: "exp-1"}, &models.Receipt{ID: "rec-2", OCRStatus: models.ReceiptOCRPending})
+
+		assert.Equal(t, models.ReconciliationUnavailable, reconciliation.Status)
+	})
+}
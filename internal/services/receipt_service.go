@@ -0,0 +1,442 @@
+package services
+
+import (
+    "bytes"
+    "crypto/rand"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "path"
+    "pocketpilot/internal/models"
+    "pocketpilot/pkg/imaging"
+    "pocketpilot/pkg/storage"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+const (
+    // MaxReceiptSize caps the size of an uploaded receipt, in bytes
+    MaxReceiptSize = 10 << 20
+    // maxReceiptsPerExpense caps how many receipts one expense can have
+    maxReceiptsPerExpense = 10
+    // maxReceiptFileName caps the length of a stored file name, in bytes
+    maxReceiptFileName = 255
+)
+
+// receiptExtensions lists the receipt content types accepted, with the
+// extension their files are stored under
+var receiptExtensions = map[string]string{
+    "image/jpeg":      ".jpg",
+    "image/png":       ".png",
+    "image/webp":      ".webp",
+    "image/heic":      ".heic",
+    "application/pdf": ".pdf",
+}
+
+type ReceiptService struct {
+    receiptRepo ReceiptRepository
+    expenseRepo ExpenseRepository
+    storage     storage.Storage
+    scanner     *ReceiptScanner     // nil when OCR is off
+    pdfRenderer imaging.PDFRenderer // nil when PDF pages are not rendered
+    teamAuth    *TeamAuthorizer
+    urlTTL      time.Duration
+    now         func() time.Time
+}
+
+// NewReceiptService creates the receipt service. A nil scanner turns OCR off,
+// and a nil renderer leaves PDF receipts without images.
+func NewReceiptService(receiptRepo ReceiptRepository, expenseRepo ExpenseRepository, teamRepo TeamRepository, store storage.Storage, scanner *ReceiptScanner, pdfRenderer imaging.PDFRenderer, urlTTL time.Duration) *ReceiptService {
+    return &ReceiptService{
+        receiptRepo: receiptRepo,
+        expenseRepo: expenseRepo,
+        storage:     store,
+        scanner:     scanner,
+        pdfRenderer: pdfRenderer,
+        teamAuth:    NewTeamAuthorizer(teamRepo),
+        urlTTL:      urlTTL,
+        now:         time.Now,
+    }
+}
+
+// UploadReceipt stores a receipt for an expense the user may edit, along with
+// its thumbnails, and queues it for OCR. The type is sniffed from the file's
+// content rather than trusted from the client.
+func (s *ReceiptService) UploadReceipt(expenseID, userID, fileName string, file io.Reader) (*models.Receipt, error) {
+    expense, err := authorizeExpense(s.expenseRepo, s.teamAuth, expenseID, userID, PermUpdateOwnExpense, PermUpdateAnyExpense)
+    if err != nil {
+        return nil, err
+    }
+    if !isExpenseEditable(expense.Status) {
+        return nil, ErrExpenseLocked
+    }
+
+    existing, err := s.receiptRepo.GetReceiptsByExpense(expense.ID)
+    if err != nil {
+        return nil, err
+    }
+    if len(existing) >= maxReceiptsPerExpense {
+        return nil, fmt.Errorf("%w: an expense has at most %d receipts", ErrInvalidReceipt, maxReceiptsPerExpense)
+    }
+
+    data, err := io.ReadAll(io.LimitReader(file, MaxReceiptSize+1))
+    if err != nil {
+        return nil, err
+    }
+    if len(data) > MaxReceiptSize {
+        return nil, ErrReceiptTooLarge
+    }
+    if len(data) == 0 {
+        return nil, fmt.Errorf("%w: the file is empty", ErrInvalidReceipt)
+    }
+    contentType := receiptContentType(data)
+    if contentType == "" {
+        return nil, ErrUnsupportedReceiptType
+    }
+
+    key, err := receiptKey(expense.ID, receiptExtensions[contentType])
+    if err != nil {
+        return nil, err
+    }
+    prepared := s.prepareReceipt(data, contentType)
+
+    receipt := &models.Receipt{
+        ExpenseID:   expense.ID,
+        UserID:      userID,
+        StorageKey:  key,
+        FileName:    receiptFileName(fileName, receiptExtensions[contentType]),
+        ContentType: contentType,
+        Size:        int64(len(prepared.data)),
+        OCRStatus:   models.ReceiptOCRPending,
+    }
+    if s.scanner == nil {
+        receipt.OCRStatus = models.ReceiptOCRSkipped
+    }
+    if err := s.storage.Put(key, bytes.NewReader(prepared.data), receipt.Size, contentType); err != nil {
+        return nil, err
+    }
+    for _, variant := range prepared.variants {
+        variant.StorageKey = receiptVariantKey(key, variant.Name)
+        receipt.Variants = append(receipt.Variants, &variant.ReceiptVariant)
+        if err := s.storage.Put(variant.StorageKey, bytes.NewReader(variant.data), variant.Size, variant.ContentType); err != nil {
+            s.removeFiles(receipt)
+            return nil, err
+        }
+    }
+
+    if err := s.receiptRepo.CreateReceipt(receipt); err != nil {
+        s.removeFiles(receipt)
+        return nil, err
+    }
+    if s.scanner != nil {
+        s.scanner.Queue(receipt.ID)
+    }
+
+    if err := s.sign(receipt); err != nil {
+        return nil, err
+    }
+    return receipt, nil
+}
+
+// GetReceipts lists the receipts of an expense with fresh download URLs
+func (s *ReceiptService) GetReceipts(expenseID, userID string) ([]*models.Receipt, error) {
+    expense, err := authorizeExpense(s.expenseRepo, s.teamAuth, expenseID, userID, PermReadTeamExpenses, PermReadTeamExpenses)
+    if err != nil {
+        return nil, err
+    }
+
+    receipts, err := s.receiptRepo.GetReceiptsByExpense(expense.ID)
+    if err != nil {
+        return nil, err
+    }
+    for _, receipt := range receipts {
+        if err := s.sign(receipt); err != nil {
+            return nil, err
+        }
+    }
+    return receipts, nil
+}
+
+// GetReceipt retrieves one receipt of an expense with a fresh download URL
+func (s *ReceiptService) GetReceipt(expenseID, receiptID, userID string) (*models.Receipt, error) {
+    expense, err := authorizeExpense(s.expenseRepo, s.teamAuth, expenseID, userID, PermReadTeamExpenses, PermReadTeamExpenses)
+    if err != nil {
+        return nil, err
+    }
+
+    receipt, err := s.expenseReceipt(expense.ID, receiptID)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.sign(receipt); err != nil {
+        return nil, err
+    }
+    return receipt, nil
+}
+
+// DeleteReceipt removes a receipt from an expense the user may edit
+func (s *ReceiptService) DeleteReceipt(expenseID, receiptID, userID string) error {
+    expense, err := authorizeExpense(s.expenseRepo, s.teamAuth, expenseID, userID, PermUpdateOwnExpense, PermUpdateAnyExpense)
+    if err != nil {
+        return err
+    }
+    if !isExpenseEditable(expense.Status) {
+        return ErrExpenseLocked
+    }
+
+    receipt, err := s.expenseReceipt(expense.ID, receiptID)
+    if err != nil {
+        return err
+    }
+    if err := s.receiptRepo.DeleteReceipt(receipt.ID); err != nil {
+        return err
+    }
+
+    // The record is gone, so leftover files are only logged
+    s.removeFiles(receipt)
+    return nil
+}
+
+// ReceiptDownloadURL returns a fresh download URL for a receipt, or for one
+// of its variants when variant is set
+func (s *ReceiptService) ReceiptDownloadURL(expenseID, receiptID, userID, variant string) (string, error) {
+    receipt, err := s.GetReceipt(expenseID, receiptID, userID)
+    if err != nil {
+        return "", err
+    }
+    if variant == "" {
+        return receipt.URL, nil
+    }
+
+    for _, v := range receipt.Variants {
+        if v.Name == variant {
+            return v.URL, nil
+        }
+    }
+    return "", fmt.Errorf("%w: the receipt has no %s variant", ErrReceiptNotFound, variant)
+}
+
+// RescanReceipt queues a receipt for OCR again, say after the engine failed.
+// Receipts already waiting for a scan are left as they are.
+func (s *ReceiptService) RescanReceipt(expenseID, receiptID, userID string) (*models.Receipt, error) {
+    if s.scanner == nil {
+        return nil, ErrReceiptOCRDisabled
+    }
+    expense, err := authorizeExpense(s.expenseRepo, s.teamAuth, expenseID, userID, PermUpdateOwnExpense, PermUpdateAnyExpense)
+    if err != nil {
+        return nil, err
+    }
+
+    receipt, err := s.expenseReceipt(expense.ID, receiptID)
+    if err != nil {
+        return nil, err
+    }
+    if receipt.OCRStatus != models.ReceiptOCRPending && receipt.OCRStatus != models.ReceiptOCRProcessing {
+        if err := s.receiptRepo.QueueReceiptScan(receipt.ID); err != nil {
+            return nil, err
+        }
+        receipt.OCRStatus = models.ReceiptOCRPending
+        receipt.OCRError = nil
+        s.scanner.Queue(receipt.ID)
+    }
+
+    if err := s.sign(receipt); err != nil {
+        return nil, err
+    }
+    return receipt, nil
+}
+
+// ReconcileReceipt compares what OCR read on a receipt with its expense, and
+// offers the receipt's values as an update of the expense while it is editable
+func (s *ReceiptService) ReconcileReceipt(expenseID, receiptID, userID string) (*models.ReceiptReconciliation, error) {
+    expense, err := authorizeExpense(s.expenseRepo, s.teamAuth, expenseID, userID, PermReadTeamExpenses, PermReadTeamExpenses)
+    if err != nil {
+        return nil, err
+    }
+
+    receipt, err := s.expenseReceipt(expense.ID, receiptID)
+    if err != nil {
+        return nil, err
+    }
+    return reconcileReceipt(expense, receipt), nil
+}
+
+// OpenSignedReceipt opens a receipt file through a signed URL of a storage
+// backend whose URLs the API serves itself. It returns the file's content type.
+func (s *ReceiptService) OpenSignedReceipt(key, expires, signature string) (io.ReadCloser, string, error) {
+    verifier, ok := s.storage.(storage.SignatureVerifier)
+    if !ok {
+        return nil, "", ErrReceiptNotFound
+    }
+    if err := verifier.Verify(key, expires, signature); err != nil {
+        if errors.Is(err, storage.ErrInvalidKey) {
+            return nil, "", ErrReceiptNotFound
+        }
+        return nil, "", ErrInvalidSignedURL
+    }
+
+    file, err := s.storage.Get(key)
+    if err != nil {
+        if errors.Is(err, storage.ErrNotFound) {
+            return nil, "", ErrReceiptNotFound
+        }
+        return nil, "", err
+    }
+
+    contentType := "application/octet-stream"
+    for candidate, extension := range receiptExtensions {
+        if strings.HasSuffix(key, extension) {
+            contentType = candidate
+        }
+    }
+    return file, contentType, nil
+}
+
+func (s *ReceiptService) expenseReceipt(expenseID, receiptID string) (*models.Receipt, error) {
+    receipt, err := s.receiptRepo.GetReceiptByID(receiptID)
+    if err != nil {
+        return nil, err
+    }
+    if receipt == nil || receipt.ExpenseID != expenseID {
+        return nil, ErrReceiptNotFound
+    }
+    return receipt, nil
+}
+
+// sign fills in download URLs, for the receipt and its variants, valid for
+// the service's URL lifetime
+func (s *ReceiptService) sign(receipt *models.Receipt) error {
+    url, err := s.storage.SignedURL(receipt.StorageKey, s.urlTTL)
+    if err != nil {
+        return err
+    }
+    for _, variant := range receipt.Variants {
+        if variant.URL, err = s.storage.SignedURL(variant.StorageKey, s.urlTTL); err != nil {
+            return err
+        }
+    }
+    expiresAt := s.now().Add(s.urlTTL).UTC()
+    receipt.URL = url
+    receipt.URLExpiresAt = &expiresAt
+    return nil
+}
+
+// removeFiles deletes the stored files of a receipt, logging failures
+func (s *ReceiptService) removeFiles(receipt *models.Receipt) {
+    keys := []string{receipt.StorageKey}
+    for _, variant := range receipt.Variants {
+        keys = append(keys, variant.StorageKey)
+    }
+    for _, key := range keys {
+        if err := s.storage.Delete(key); err != nil {
+            log.Printf("Failed to remove receipt file %s: %v", key, err)
+        }
+    }
+}
+
+// reconcileReceipt compares the amount, currency and date of an expense with
+// its receipt. The merchant is compared with the description for information
+// only, as descriptions rarely name the merchant verbatim.
+func reconcileReceipt(expense *models.Expense, receipt *models.Receipt) *models.ReceiptReconciliation {
+    reconciliation := &models.ReceiptReconciliation{
+        ReceiptID: receipt.ID,
+        ExpenseID: expense.ID,
+        Status:    models.ReconciliationUnavailable,
+        Fields:    []models.ReceiptFieldMatch{},
+    }
+    if receipt.OCRStatus != models.ReceiptOCRCompleted {
+        return reconciliation
+    }
+
+    update := &models.UpdateExpenseRequest{}
+    compared, mismatched := false, false
+    compare := func(field, expenseValue, receiptValue string, matches bool) {
+        reconciliation.Fields = append(reconciliation.Fields, models.ReceiptFieldMatch{Field: field, ExpenseValue: expenseValue, ReceiptValue: receiptValue, Matches: matches})
+        if field != "merchant" {
+            compared = true
+            mismatched = mismatched || !matches
+        }
+    }
+
+    if receipt.TotalAmount != nil {
+        matches := receipt.TotalAmount.Rat().Cmp(expense.Amount.Rat()) == 0
+        compare("amount", expense.Amount.String(), receipt.TotalAmount.String(), matches)
+        if !matches {
+            amount := *receipt.TotalAmount
+            update.Amount = &amount
+        }
+    }
+    if receipt.Currency != nil {
+        compare("currency", expense.Currency, *receipt.Currency, strings.EqualFold(expense.Currency, *receipt.Currency))
+    }
+    if receipt.TransactionDate != nil {
+        day := expenseDay(expense)
+        matches := day == *receipt.TransactionDate
+        compare("expense_date", day, *receipt.TransactionDate, matches)
+        if !matches {
+            date := *receipt.TransactionDate
+            update.ExpenseDate = &date
+        }
+    }
+    if receipt.MerchantName != nil {
+        matches := strings.Contains(strings.ToLower(expense.Description), strings.ToLower(*receipt.MerchantName))
+        compare("merchant", expense.Description, *receipt.MerchantName, matches)
+    }
+
+    if !compared {
+        return reconciliation
+    }
+    reconciliation.Status = models.ReconciliationMatched
+    if mismatched {
+        reconciliation.Status = models.ReconciliationMismatched
+    }
+    if (update.Amount != nil || update.ExpenseDate != nil) && isExpenseEditable(expense.Status) {
+        reconciliation.Update = update
+    }
+    return reconciliation
+}
+
+// receiptContentType sniffs the type of a receipt file, or returns "" for
+// types receipts cannot have
+func receiptContentType(data []byte) string {
+    // HEIC, which iPhones take photos in, is an ISO media file with a heic brand
+    if len(data) >= 12 && string(data[4:8]) == "ftyp" {
+        switch string(data[8:12]) {
+        case "heic", "heix", "heim", "heis", "mif1":
+            return "image/heic"
+        }
+    }
+
+    contentType := http.DetectContentType(data)
+    if _, ok := receiptExtensions[contentType]; ok {
+        return contentType
+    }
+    return ""
+}
+
+// receiptKey names a receipt file randomly, so keys cannot be guessed
+func receiptKey(expenseID, extension string) (string, error) {
+    random := make([]byte, 16)
+    if _, err := rand.Read(random); err != nil {
+        return "", err
+    }
+    return "receipts/" + expenseID + "/" + hex.EncodeToString(random) + extension, nil
+}
+
+// receiptFileName keeps the base name of the uploaded file for display,
+// falling back to a generic name
+func receiptFileName(name, extension string) string {
+    name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, `\`, "/")))
+    if name == "" || name == "." || name == "/" {
+        return "receipt" + extension
+    }
+    for len(name) > maxReceiptFileName {
+        _, size := utf8.DecodeLastRuneInString(name)
+        name = name[:len(name)-size]
+    }
+    return name
+}
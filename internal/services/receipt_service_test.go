@@ -0,0 +1,281 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/storage"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockReceiptRepository struct {
+	mock.Mock
+}
+
+func (m *MockReceiptRepository) CreateReceipt(receipt *models.Receipt) error {
+	args := m.Called(receipt)
+	return args.Error(0)
+}
+
+func (m *MockReceiptRepository) GetReceiptByID(id string) (*models.Receipt, error) {
+	args := m.Called(id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Receipt), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReceiptRepository) GetReceiptsByExpense(expenseID string) ([]*models.Receipt, error) {
+	args := m.Called(expenseID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Receipt), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReceiptRepository) DeleteReceipt(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockReceiptRepository) GetReceiptsToScan(staleBefore time.Time, limit int) ([]*models.Receipt, error) {
+	args := m.Called(staleBefore, limit)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Receipt), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReceiptRepository) ClaimReceiptScan(id string, staleBefore time.Time) (bool, error) {
+	args := m.Called(id, staleBefore)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockReceiptRepository) SaveReceiptScan(receipt *models.Receipt) error {
+	args := m.Called(receipt)
+	return args.Error(0)
+}
+
+func (m *MockReceiptRepository) QueueReceiptScan(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// memoryStorage keeps objects in memory, signing URLs with a fixed token
+type memoryStorage struct {
+	objects map[string][]byte
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{objects: map[string][]byte{}}
+}
+
+func (s *memoryStorage) Put(key string, r io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memoryStorage) Get(key string) (io.ReadCloser, error) {
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryStorage) Delete(key string) error {
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *memoryStorage) SignedURL(key string, expires time.Duration) (string, error) {
+	return "https://files.test/" + key + "?signature=valid", nil
+}
+
+func (s *memoryStorage) Verify(key, expires, signature string) error {
+	if signature != "valid" {
+		return storage.ErrInvalidSignature
+	}
+	return nil
+}
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestReceiptService_UploadReceipt(t *testing.T) {
+	mockReceiptRepo := new(MockReceiptRepository)
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	store := newMemoryStorage()
+	receiptService := NewReceiptService(mockReceiptRepo, mockExpenseRepo, mockTeamRepo, store, nil, nil, 15*time.Minute)
+
+	mockExpenseRepo.On("GetExpenseByID", "draft").Return(&models.Expense{ID: "draft", UserID: "user-1", Status: models.ExpenseStatusDraft}, nil)
+	mockExpenseRepo.On("GetExpenseByID", "submitted").Return(&models.Expense{ID: "submitted", UserID: "user-1", Status: models.ExpenseStatusSubmitted}, nil)
+	mockReceiptRepo.On("GetReceiptsByExpense", "draft").Return(nil, nil)
+
+	t.Run("Store sniffed image", func(t *testing.T) {
+		mockReceiptRepo.On("CreateReceipt", mock.MatchedBy(func(receipt *models.Receipt) bool {
+			return receipt.ContentType == "image/png" && receipt.FileName == "lunch.png" && receipt.Size == int64(len(pngHeader))
+		})).Return(nil).Once()
+
+		receipt, err := receiptService.UploadReceipt("draft", "user-1", `C:\photos\lunch.png`, bytes.NewReader(pngHeader))
+
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(receipt.StorageKey, "receipts/draft/"))
+		assert.True(t, strings.HasSuffix(receipt.StorageKey, ".png"))
+		assert.Equal(t, pngHeader, store.objects[receipt.StorageKey])
+		assert.Contains(t, receipt.URL, receipt.StorageKey)
+		assert.Equal(t, models.ReceiptOCRSkipped, receipt.OCRStatus)
+		require.NotNil(t, receipt.URLExpiresAt)
+		mockReceiptRepo.AssertExpectations(t)
+	})
+
+	t.Run("Store photo with thumbnails", func(t *testing.T) {
+		photo := photoWithOrientation(t, 640, 480, 1)
+		mockReceiptRepo.On("CreateReceipt", mock.MatchedBy(func(receipt *models.Receipt) bool {
+			return receipt.ContentType == "image/jpeg" && len(receipt.Variants) == 3
+		})).Return(nil).Once()
+
+		receipt, err := receiptService.UploadReceipt("draft", "user-1", "lunch.jpg", bytes.NewReader(photo))
+
+		require.NoError(t, err)
+		assert.NotContains(t, string(store.objects[receipt.StorageKey]), "Exif")
+		for _, variant := range receipt.Variants {
+			assert.Equal(t, receiptVariantKey(receipt.StorageKey, variant.Name), variant.StorageKey)
+			assert.Len(t, store.objects[variant.StorageKey], int(variant.Size))
+			assert.Contains(t, variant.URL, variant.StorageKey)
+		}
+		mockReceiptRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unsupported type", func(t *testing.T) {
+		_, err := receiptService.UploadReceipt("draft", "user-1", "receipt.png", strings.NewReader("<html><body>not an image</body></html>"))
+
+		assert.ErrorIs(t, err, ErrUnsupportedReceiptType)
+	})
+
+	t.Run("Too large", func(t *testing.T) {
+		file := io.MultiReader(bytes.NewReader(pngHeader), bytes.NewReader(make([]byte, MaxReceiptSize)))
+
+		_, err := receiptService.UploadReceipt("draft", "user-1", "big.png", file)
+
+		assert.ErrorIs(t, err, ErrReceiptTooLarge)
+	})
+
+	t.Run("Empty file", func(t *testing.T) {
+		_, err := receiptService.UploadReceipt("draft", "user-1", "empty.png", strings.NewReader(""))
+
+		assert.ErrorIs(t, err, ErrInvalidReceipt)
+	})
+
+	t.Run("Locked expense", func(t *testing.T) {
+		_, err := receiptService.UploadReceipt("submitted", "user-1", "lunch.png", bytes.NewReader(pngHeader))
+
+		assert.ErrorIs(t, err, ErrExpenseLocked)
+	})
+
+	t.Run("Expense of another user", func(t *testing.T) {
+		_, err := receiptService.UploadReceipt("draft", "user-2", "lunch.png", bytes.NewReader(pngHeader))
+
+		assert.ErrorIs(t, err, ErrExpenseAccessDenied)
+	})
+}
+
+func TestReceiptService_GetReceipt(t *testing.T) {
+	mockReceiptRepo := new(MockReceiptRepository)
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	receiptService := NewReceiptService(mockReceiptRepo, mockExpenseRepo, mockTeamRepo, newMemoryStorage(), nil, nil, 15*time.Minute)
+
+	mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(&models.Expense{ID: "exp-1", UserID: "author", TeamID: strPtr("team-1"), Status: models.ExpenseStatusApproved}, nil)
+	mockReceiptRepo.On("GetReceiptByID", "rec-1").Return(&models.Receipt{ID: "rec-1", ExpenseID: "exp-1", StorageKey: "receipts/exp-1/a.pdf"}, nil)
+	mockReceiptRepo.On("GetReceiptByID", "rec-2").Return(&models.Receipt{ID: "rec-2", ExpenseID: "exp-2", StorageKey: "receipts/exp-2/b.pdf"}, nil)
+
+	t.Run("Team members get a signed URL", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "bob").Return(teamMember("team-1", "bob", models.TeamRoleMember), nil).Once()
+
+		receipt, err := receiptService.GetReceipt("exp-1", "rec-1", "bob")
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://files.test/receipts/exp-1/a.pdf?signature=valid", receipt.URL)
+	})
+
+	t.Run("Receipts of other expenses are not found", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "bob").Return(teamMember("team-1", "bob", models.TeamRoleMember), nil).Once()
+
+		_, err := receiptService.GetReceipt("exp-1", "rec-2", "bob")
+
+		assert.ErrorIs(t, err, ErrReceiptNotFound)
+	})
+
+	t.Run("Non-members are denied", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "mallory").Return(nil, nil).Once()
+		mockTeamRepo.On("GetTeamByID", "team-1").Return(&models.Team{ID: "team-1"}, nil).Once()
+
+		_, err := receiptService.GetReceipt("exp-1", "rec-1", "mallory")
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+	})
+}
+
+func TestReceiptService_ReceiptDownloadURL(t *testing.T) {
+	mockReceiptRepo := new(MockReceiptRepository)
+	mockExpenseRepo := new(MockExpenseRepository)
+	receiptService := NewReceiptService(mockReceiptRepo, mockExpenseRepo, new(MockTeamRepository), newMemoryStorage(), nil, nil, 15*time.Minute)
+
+	mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(&models.Expense{ID: "exp-1", UserID: "user-1", Status: models.ExpenseStatusDraft}, nil)
+	mockReceiptRepo.On("GetReceiptByID", "rec-1").Return(&models.Receipt{
+		ID:         "rec-1",
+		ExpenseID:  "exp-1",
+		StorageKey: "receipts/exp-1/a.pdf",
+		Variants: []*models.ReceiptVariant{
+			{Name: models.ReceiptVariantPage, StorageKey: "receipts/exp-1/a_page.jpg"},
+			{Name: models.ReceiptVariantSmall, StorageKey: "receipts/exp-1/a_small.jpg"},
+		},
+	}, nil)
+
+	url, err := receiptService.ReceiptDownloadURL("exp-1", "rec-1", "user-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://files.test/receipts/exp-1/a.pdf?signature=valid", url)
+
+	url, err = receiptService.ReceiptDownloadURL("exp-1", "rec-1", "user-1", models.ReceiptVariantSmall)
+	require.NoError(t, err)
+	assert.Equal(t, "https://files.test/receipts/exp-1/a_small.jpg?signature=valid", url)
+
+	_, err = receiptService.ReceiptDownloadURL("exp-1", "rec-1", "user-1", models.ReceiptVariantLarge)
+	assert.ErrorIs(t, err, ErrReceiptNotFound)
+}
+
+func TestReceiptService_OpenSignedReceipt(t *testing.T) {
+	store := newMemoryStorage()
+	store.objects["receipts/exp-1/a.pdf"] = []byte("%PDF-1.7")
+	receiptService := NewReceiptService(new(MockReceiptRepository), new(MockExpenseRepository), new(MockTeamRepository), store, nil, nil, 15*time.Minute)
+
+	file, contentType, err := receiptService.OpenSignedReceipt("receipts/exp-1/a.pdf", "0", "valid")
+	require.NoError(t, err)
+	defer file.Close()
+	assert.Equal(t, "application/pdf", contentType)
+
+	_, _, err = receiptService.OpenSignedReceipt("receipts/exp-1/a.pdf", "0", "forged")
+	assert.ErrorIs(t, err, ErrInvalidSignedURL)
+
+	_, _, err = receiptService.OpenSignedReceipt("receipts/exp-1/missing.pdf", "0", "valid")
+	assert.ErrorIs(t, err, ErrReceiptNotFound)
+}
+
+func TestReceiptContentType(t *testing.T) {
+	assert.Equal(t, "image/png", receiptContentType(pngHeader))
+	assert.Equal(t, "image/jpeg", receiptContentType([]byte("\xff\xd8\xff\xe0\x00\x10JFIF")))
+	assert.Equal(t, "application/pdf", receiptContentType([]byte("%PDF-1.4\n")))
+	assert.Equal(t, "image/heic", receiptContentType([]byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00")))
+	assert.Equal(t, "", receiptContentType([]byte("GIF89a")))
+}
@@ -0,0 +1,370 @@
+package services
+
+import (
+    "errors"
+    "log"
+    "pocketpilot/internal/models"
+    "strings"
+    "time"
+)
+
+const (
+    // recurringBatchSize is how many due templates MaterializeDue loads at a time
+    recurringBatchSize = 100
+    // maxCatchUpOccurrences bounds how many missed occurrences of one template a
+    // single run creates; the rest follow on the next run
+    maxCatchUpOccurrences = 1000
+)
+
+type RecurringExpenseService struct {
+    recurringRepo RecurringExpenseRepository
+    userRepo      UserRepository
+    teamRepo      TeamRepository
+    categoryRepo  CategoryRepository
+    rateRepo      ExchangeRateRepository
+    teamAuth      *TeamAuthorizer
+    now           func() time.Time
+}
+
+func NewRecurringExpenseService(recurringRepo RecurringExpenseRepository, userRepo UserRepository, teamRepo TeamRepository, categoryRepo CategoryRepository, rateRepo ExchangeRateRepository) *RecurringExpenseService {
+    return &RecurringExpenseService{
+        recurringRepo: recurringRepo,
+        userRepo:      userRepo,
+        teamRepo:      teamRepo,
+        categoryRepo:  categoryRepo,
+        rateRepo:      rateRepo,
+        teamAuth:      NewTeamAuthorizer(teamRepo),
+        now:           time.Now,
+    }
+}
+
+// CreateRecurringExpense creates a recurring expense template. Its first
+// occurrence is the start date; start dates in the past are caught up on.
+func (s *RecurringExpenseService) CreateRecurringExpense(userID string, req *models.CreateRecurringExpenseRequest) (*models.RecurringExpense, error) {
+    err := validateExpenseRequest(&models.CreateExpenseRequest{
+        Amount:      req.Amount,
+        Currency:    req.Currency,
+        Description: req.Description,
+        Category:    req.Category,
+        ExpenseDate: req.StartDate,
+    })
+    if err != nil {
+        var fieldErr *expenseFieldError
+        if errors.As(err, &fieldErr) && fieldErr.Field == "expense_date" {
+            return nil, errors.New("invalid start date format, use YYYY-MM-DD")
+        }
+        return nil, err
+    }
+
+    frequency := strings.ToLower(strings.TrimSpace(req.Frequency))
+    interval := req.Interval
+    if interval == 0 {
+        interval = 1
+    }
+    if !validFrequency(frequency) || interval < 1 {
+        return nil, ErrInvalidRecurrence
+    }
+    if err := validateScheduleEnd(req.StartDate, req.EndDate, req.MaxOccurrences); err != nil {
+        return nil, err
+    }
+
+    if req.TeamID != nil {
+        if _, err := s.teamAuth.Authorize(*req.TeamID, userID, PermCreateTeamExpense); err != nil {
+            return nil, err
+        }
+    }
+    category, err := resolveCategory(s.categoryRepo, userID, req.TeamID, req.Category)
+    if err != nil {
+        return nil, err
+    }
+
+    recurring := &models.RecurringExpense{
+        UserID:         userID,
+        TeamID:         req.TeamID,
+        Amount:         req.Amount,
+        Currency:       strings.ToUpper(strings.TrimSpace(req.Currency)),
+        Description:    req.Description,
+        Category:       category.Name,
+        CategoryID:     &category.ID,
+        Frequency:      frequency,
+        Interval:       interval,
+        StartDate:      req.StartDate,
+        EndDate:        req.EndDate,
+        MaxOccurrences: req.MaxOccurrences,
+        Active:         true,
+    }
+    recurring.NextOccurrence = nextOccurrence(recurring)
+
+    err = s.recurringRepo.CreateRecurringExpense(recurring)
+    if err != nil {
+        return nil, err
+    }
+
+    return recurring, nil
+}
+
+// GetRecurringExpenses lists a user's recurring expense templates
+func (s *RecurringExpenseService) GetRecurringExpenses(userID string) ([]*models.RecurringExpense, error) {
+    return s.recurringRepo.GetRecurringExpensesByUser(userID)
+}
+
+// GetRecurringExpense retrieves one of a user's recurring expense templates
+func (s *RecurringExpenseService) GetRecurringExpense(id, userID string) (*models.RecurringExpense, error) {
+    recurring, err := s.recurringRepo.GetRecurringExpenseByID(id)
+    if err != nil {
+        return nil, err
+    }
+    if recurring == nil || recurring.UserID != userID {
+        return nil, ErrRecurringExpenseNotFound
+    }
+    return recurring, nil
+}
+
+// UpdateRecurringExpense changes a template's details, end or state. Changes
+// apply to occurrences not created yet. A resumed template picks up at its
+// first occurrence from today on, skipping the ones it missed while paused.
+func (s *RecurringExpenseService) UpdateRecurringExpense(id, userID string, req *models.UpdateRecurringExpenseRequest) (*models.RecurringExpense, error) {
+    recurring, err := s.GetRecurringExpense(id, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    if req.Amount != nil {
+        if err := validateAmount(*req.Amount, recurring.Currency); err != nil {
+            return nil, err
+        }
+        recurring.Amount = *req.Amount
+    }
+    if req.Description != nil {
+        if strings.TrimSpace(*req.Description) == "" {
+            return nil, errors.New("description is required")
+        }
+        recurring.Description = *req.Description
+    }
+    if req.Category != nil {
+        if strings.TrimSpace(*req.Category) == "" {
+            return nil, errors.New("category is required")
+        }
+        category, err := resolveCategory(s.categoryRepo, recurring.UserID, recurring.TeamID, *req.Category)
+        if err != nil {
+            return nil, err
+        }
+        recurring.Category = category.Name
+        recurring.CategoryID = &category.ID
+    }
+    if req.EndDate != nil {
+        recurring.EndDate = req.EndDate
+        if *req.EndDate == "" {
+            recurring.EndDate = nil
+        }
+    }
+    if req.MaxOccurrences != nil {
+        recurring.MaxOccurrences = req.MaxOccurrences
+        if *req.MaxOccurrences == 0 {
+            recurring.MaxOccurrences = nil
+        }
+    }
+    if err := validateScheduleEnd(recurring.StartDate, recurring.EndDate, recurring.MaxOccurrences); err != nil {
+        return nil, err
+    }
+
+    resumed := req.Active != nil && *req.Active && !recurring.Active
+    if req.Active != nil {
+        recurring.Active = *req.Active
+    }
+
+    recurring.NextOccurrence = nextOccurrence(recurring)
+    if resumed {
+        today := s.today()
+        for recurring.NextOccurrence != nil && *recurring.NextOccurrence < today {
+            recurring.OccurrenceCount++
+            recurring.NextOccurrence = nextOccurrence(recurring)
+        }
+    }
+
+    err = s.recurringRepo.UpdateRecurringExpense(recurring)
+    if err != nil {
+        return nil, err
+    }
+
+    return recurring, nil
+}
+
+// DeleteRecurringExpense deletes a template. Expenses it created are kept.
+func (s *RecurringExpenseService) DeleteRecurringExpense(id, userID string) error {
+    if _, err := s.GetRecurringExpense(id, userID); err != nil {
+        return err
+    }
+    return s.recurringRepo.DeleteRecurringExpense(id, userID)
+}
+
+// MaterializeDue creates an expense for every occurrence that is due, including
+// the ones missed while the server was down. Each occurrence becomes exactly one
+// expense, even when several instances run at once. It returns how many
+// expenses were created.
+func (s *RecurringExpenseService) MaterializeDue() (int, error) {
+    today := s.today()
+    created := 0
+    var firstErr error
+
+    for {
+        templates, err := s.recurringRepo.GetDueRecurringExpenses(today, recurringBatchSize)
+        if err != nil {
+            return created, err
+        }
+
+        for _, recurring := range templates {
+            n, err := s.materialize(recurring, today)
+            created += n
+            if err != nil {
+                log.Printf("recurring expense %s: %v", recurring.ID, err)
+                if firstErr == nil {
+                    firstErr = err
+                }
+            }
+        }
+
+        // Failed templates stay due, so stop rather than load them again
+        if len(templates) < recurringBatchSize || firstErr != nil {
+            return created, firstErr
+        }
+    }
+}
+
+// materialize creates the due occurrences of one template, oldest first
+func (s *RecurringExpenseService) materialize(recurring *models.RecurringExpense, today string) (int, error) {
+    // Team templates stop once their owner may no longer create team expenses
+    if recurring.TeamID != nil {
+        if _, err := s.teamAuth.Authorize(*recurring.TeamID, recurring.UserID, PermCreateTeamExpense); err != nil {
+            if !errors.Is(err, ErrTeamAccessDenied) && !errors.Is(err, ErrTeamNotFound) {
+                return 0, err
+            }
+            recurring.Active = false
+            recurring.NextOccurrence = nil
+            return 0, s.recurringRepo.UpdateRecurringExpense(recurring)
+        }
+    }
+
+    // Occurrences record the rate of their own date, like expenses entered by hand
+    base, err := baseCurrencyFor(s.userRepo, s.teamRepo, recurring.UserID, recurring.TeamID)
+    if err != nil {
+        return 0, err
+    }
+    converter := newRateConverter(s.rateRepo)
+
+    created := 0
+    for i := 0; i < maxCatchUpOccurrences && recurring.NextOccurrence != nil && *recurring.NextOccurrence <= today; i++ {
+        date := *recurring.NextOccurrence
+        expense := &models.Expense{
+            UserID:             recurring.UserID,
+            TeamID:             recurring.TeamID,
+            Amount:             recurring.Amount,
+            Currency:           recurring.Currency,
+            Description:        recurring.Description,
+            Category:           recurring.Category,
+            CategoryID:         recurring.CategoryID,
+            ExpenseDate:        date,
+            Status:             models.ExpenseStatusDraft,
+            RecurringExpenseID: &recurring.ID,
+        }
+        if err := stampExchangeRate(converter, expense, base); err != nil {
+            return created, err
+        }
+
+        recurring.OccurrenceCount++
+        recurring.NextOccurrence = nextOccurrence(recurring)
+
+        ok, err := s.recurringRepo.MaterializeOccurrence(recurring, date, expense)
+        if err != nil {
+            return created, err
+        }
+        if !ok {
+            // Someone else advanced the template
+            return created, nil
+        }
+        if expense.ID != "" {
+            created++
+        }
+    }
+
+    return created, nil
+}
+
+// today is the current date in UTC, as YYYY-MM-DD
+func (s *RecurringExpenseService) today() string {
+    return s.now().UTC().Format("2006-01-02")
+}
+
+func validFrequency(frequency string) bool {
+    switch frequency {
+    case models.RecurrenceDaily, models.RecurrenceWeekly, models.RecurrenceMonthly, models.RecurrenceYearly:
+        return true
+    }
+    return false
+}
+
+// validateScheduleEnd checks the optional end date and occurrence limit of a schedule
+func validateScheduleEnd(startDate string, endDate *string, maxOccurrences *int) error {
+    if endDate != nil {
+        if _, err := time.Parse("2006-01-02", *endDate); err != nil {
+            return errors.New("invalid end date format, use YYYY-MM-DD")
+        }
+        if *endDate < startDate {
+            return errors.New("end date must not be before the start date")
+        }
+    }
+    if maxOccurrences != nil && *maxOccurrences < 1 {
+        return errors.New("max occurrences must be at least 1")
+    }
+    return nil
+}
+
+// nextOccurrence returns the date of the template's occurrence after the
+// OccurrenceCount ones already passed, or nil once the schedule is over or
+// the template is paused
+func nextOccurrence(recurring *models.RecurringExpense) *string {
+    if !recurring.Active {
+        return nil
+    }
+    if recurring.MaxOccurrences != nil && recurring.OccurrenceCount >= *recurring.MaxOccurrences {
+        return nil
+    }
+
+    start, err := time.Parse("2006-01-02", recurring.StartDate)
+    if err != nil {
+        return nil
+    }
+    date := occurrenceDate(start, recurring.Frequency, recurring.Interval, recurring.OccurrenceCount).Format("2006-01-02")
+    if recurring.EndDate != nil && date > *recurring.EndDate {
+        return nil
+    }
+    return &date
+}
+
+// occurrenceDate returns the nth occurrence of a schedule, counting from 0 at
+// the start date. Occurrences are computed from the start rather than the
+// previous occurrence, so monthly and yearly schedules keep the start's day,
+// falling back to the last day of shorter months.
+func occurrenceDate(start time.Time, frequency string, interval, n int) time.Time {
+    switch frequency {
+    case models.RecurrenceDaily:
+        return start.AddDate(0, 0, n*interval)
+    case models.RecurrenceWeekly:
+        return start.AddDate(0, 0, 7*n*interval)
+    case models.RecurrenceYearly:
+        return addMonthsClamped(start, 12*n*interval)
+    default:
+        return addMonthsClamped(start, n*interval)
+    }
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+    first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+    lastDay := first.AddDate(0, 1, -1).Day()
+
+    day := t.Day()
+    if day > lastDay {
+        day = lastDay
+    }
+    return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC)
+}
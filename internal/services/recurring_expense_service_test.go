@@ -0,0 +1,347 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRecurringExpenseRepository struct {
+	mock.Mock
+}
+
+func (m *MockRecurringExpenseRepository) CreateRecurringExpense(recurring *models.RecurringExpense) error {
+	args := m.Called(recurring)
+	return args.Error(0)
+}
+
+func (m *MockRecurringExpenseRepository) GetRecurringExpenseByID(id string) (*models.RecurringExpense, error) {
+	args := m.Called(id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.RecurringExpense), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRecurringExpenseRepository) GetRecurringExpensesByUser(userID string) ([]*models.RecurringExpense, error) {
+	args := m.Called(userID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.RecurringExpense), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRecurringExpenseRepository) GetDueRecurringExpenses(date string, limit int) ([]*models.RecurringExpense, error) {
+	args := m.Called(date, limit)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.RecurringExpense), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRecurringExpenseRepository) UpdateRecurringExpense(recurring *models.RecurringExpense) error {
+	args := m.Called(recurring)
+	return args.Error(0)
+}
+
+func (m *MockRecurringExpenseRepository) DeleteRecurringExpense(id, userID string) error {
+	args := m.Called(id, userID)
+	return args.Error(0)
+}
+
+func (m *MockRecurringExpenseRepository) MaterializeOccurrence(recurring *models.RecurringExpense, date string, expense *models.Expense) (bool, error) {
+	args := m.Called(recurring, date, expense)
+	return args.Bool(0), args.Error(1)
+}
+
+func newTestRecurringService(repo *MockRecurringExpenseRepository, teamRepo *MockTeamRepository, today string) *RecurringExpenseService {
+	service := NewRecurringExpenseService(repo, usersWithBaseCurrency("USD"), teamRepo, anyCategory(), noExchangeRates())
+	service.now = func() time.Time {
+		date, _ := time.Parse("2006-01-02", today)
+		return date.Add(15 * time.Hour)
+	}
+	return service
+}
+
+func TestOccurrenceDate(t *testing.T) {
+	tests := []struct {
+		name      string
+		start     string
+		frequency string
+		interval  int
+		n         int
+		expected  string
+	}{
+		{"Daily", "2026-01-30", models.RecurrenceDaily, 1, 3, "2026-02-02"},
+		{"Every other week", "2026-01-01", models.RecurrenceWeekly, 2, 2, "2026-01-29"},
+		{"Monthly keeps the day", "2026-01-15", models.RecurrenceMonthly, 1, 11, "2026-12-15"},
+		{"Monthly clamps to short months", "2026-01-31", models.RecurrenceMonthly, 1, 1, "2026-02-28"},
+		{"Monthly returns to the start day", "2026-01-31", models.RecurrenceMonthly, 1, 2, "2026-03-31"},
+		{"Quarterly", "2025-11-30", models.RecurrenceMonthly, 3, 1, "2026-02-28"},
+		{"Yearly from a leap day", "2024-02-29", models.RecurrenceYearly, 1, 1, "2025-02-28"},
+		{"Yearly back on a leap day", "2024-02-29", models.RecurrenceYearly, 4, 1, "2028-02-29"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, _ := time.Parse("2006-01-02", tt.start)
+			assert.Equal(t, tt.expected, occurrenceDate(start, tt.frequency, tt.interval, tt.n).Format("2006-01-02"))
+		})
+	}
+}
+
+func TestRecurringExpenseService_CreateRecurringExpense(t *testing.T) {
+	t.Run("Valid template", func(t *testing.T) {
+		mockRepo := new(MockRecurringExpenseRepository)
+		service := newTestRecurringService(mockRepo, new(MockTeamRepository), "2026-03-10")
+
+		mockRepo.On("CreateRecurringExpense", mock.AnythingOfType("*models.RecurringExpense")).Return(nil)
+
+		recurring, err := service.CreateRecurringExpense("user-1", &models.CreateRecurringExpenseRequest{
+			Amount:      money.MustParse("1200"),
+			Currency:    "EUR",
+			Description: "Rent",
+			Category:    "Housing",
+			Frequency:   "Monthly",
+			StartDate:   "2026-04-01",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, models.RecurrenceMonthly, recurring.Frequency)
+		assert.Equal(t, 1, recurring.Interval)
+		assert.True(t, recurring.Active)
+		assert.Equal(t, "2026-04-01", *recurring.NextOccurrence)
+	})
+
+	t.Run("Invalid templates", func(t *testing.T) {
+		service := newTestRecurringService(new(MockRecurringExpenseRepository), new(MockTeamRepository), "2026-03-10")
+		endBeforeStart := "2026-03-31"
+		zero := 0
+
+		tests := []struct {
+			name   string
+			modify func(*models.CreateRecurringExpenseRequest)
+			err    string
+		}{
+			{"Unknown frequency", func(r *models.CreateRecurringExpenseRequest) { r.Frequency = "hourly" }, ErrInvalidRecurrence.Error()},
+			{"Negative interval", func(r *models.CreateRecurringExpenseRequest) { r.Interval = -1 }, ErrInvalidRecurrence.Error()},
+			{"Bad start date", func(r *models.CreateRecurringExpenseRequest) { r.StartDate = "01/04/2026" }, "invalid start date format, use YYYY-MM-DD"},
+			{"End before start", func(r *models.CreateRecurringExpenseRequest) { r.EndDate = &endBeforeStart }, "end date must not be before the start date"},
+			{"No occurrences", func(r *models.CreateRecurringExpenseRequest) { r.MaxOccurrences = &zero }, "max occurrences must be at least 1"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req := &models.CreateRecurringExpenseRequest{
+					Amount:      money.MustParse("1200"),
+					Currency:    "EUR",
+					Description: "Rent",
+					Category:    "Housing",
+					Frequency:   "monthly",
+					StartDate:   "2026-04-01",
+				}
+				tt.modify(req)
+
+				recurring, err := service.CreateRecurringExpense("user-1", req)
+
+				assert.EqualError(t, err, tt.err)
+				assert.Nil(t, recurring)
+			})
+		}
+	})
+}
+
+func TestRecurringExpenseService_UpdateRecurringExpense(t *testing.T) {
+	t.Run("Resuming skips missed occurrences", func(t *testing.T) {
+		mockRepo := new(MockRecurringExpenseRepository)
+		service := newTestRecurringService(mockRepo, new(MockTeamRepository), "2026-03-10")
+
+		paused := &models.RecurringExpense{
+			ID:              "rec-1",
+			UserID:          "user-1",
+			Frequency:       models.RecurrenceWeekly,
+			Interval:        1,
+			StartDate:       "2026-01-05",
+			OccurrenceCount: 2,
+		}
+		mockRepo.On("GetRecurringExpenseByID", "rec-1").Return(paused, nil)
+		mockRepo.On("UpdateRecurringExpense", paused).Return(nil)
+
+		active := true
+		recurring, err := service.UpdateRecurringExpense("rec-1", "user-1", &models.UpdateRecurringExpenseRequest{Active: &active})
+
+		require.NoError(t, err)
+		assert.Equal(t, "2026-03-16", *recurring.NextOccurrence)
+		assert.Equal(t, 10, recurring.OccurrenceCount)
+	})
+
+	t.Run("Other users' templates are not found", func(t *testing.T) {
+		mockRepo := new(MockRecurringExpenseRepository)
+		service := newTestRecurringService(mockRepo, new(MockTeamRepository), "2026-03-10")
+
+		mockRepo.On("GetRecurringExpenseByID", "rec-1").Return(&models.RecurringExpense{ID: "rec-1", UserID: "user-2"}, nil)
+
+		recurring, err := service.UpdateRecurringExpense("rec-1", "user-1", &models.UpdateRecurringExpenseRequest{})
+
+		assert.ErrorIs(t, err, ErrRecurringExpenseNotFound)
+		assert.Nil(t, recurring)
+		mockRepo.AssertNotCalled(t, "UpdateRecurringExpense", mock.Anything)
+	})
+}
+
+func TestRecurringExpenseService_MaterializeDue(t *testing.T) {
+	t.Run("Catches up on missed occurrences", func(t *testing.T) {
+		mockRepo := new(MockRecurringExpenseRepository)
+		service := newTestRecurringService(mockRepo, new(MockTeamRepository), "2026-04-01")
+
+		maxOccurrences := 3
+		recurring := &models.RecurringExpense{
+			ID:              "rec-1",
+			UserID:          "user-1",
+			Amount:          money.MustParse("50"),
+			Currency:        "USD",
+			Description:     "Gym",
+			Category:        "Health",
+			Frequency:       models.RecurrenceMonthly,
+			Interval:        1,
+			StartDate:       "2026-01-31",
+			MaxOccurrences:  &maxOccurrences,
+			OccurrenceCount: 1,
+			NextOccurrence:  strPtr("2026-02-28"),
+			Active:          true,
+		}
+		mockRepo.On("GetDueRecurringExpenses", "2026-04-01", recurringBatchSize).Return([]*models.RecurringExpense{recurring}, nil).Once()
+
+		var dates, next []string
+		mockRepo.On("MaterializeOccurrence", recurring, mock.Anything, mock.AnythingOfType("*models.Expense")).
+			Run(func(args mock.Arguments) {
+				expense := args.Get(2).(*models.Expense)
+				assert.Equal(t, args.String(1), expense.ExpenseDate)
+				assert.Equal(t, "rec-1", *expense.RecurringExpenseID)
+				assert.Equal(t, models.ExpenseStatusDraft, expense.Status)
+				expense.ID = "exp-" + expense.ExpenseDate
+
+				dates = append(dates, args.String(1))
+				if recurring.NextOccurrence != nil {
+					next = append(next, *recurring.NextOccurrence)
+				}
+			}).Return(true, nil)
+
+		created, err := service.MaterializeDue()
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, created)
+		assert.Equal(t, []string{"2026-02-28", "2026-03-31"}, dates)
+		assert.Equal(t, []string{"2026-03-31"}, next)
+		assert.Nil(t, recurring.NextOccurrence)
+		assert.Equal(t, 3, recurring.OccurrenceCount)
+	})
+
+	t.Run("Records the exchange rate of each occurrence", func(t *testing.T) {
+		mockRepo := new(MockRecurringExpenseRepository)
+		mockTeamRepo := new(MockTeamRepository)
+		mockRateRepo := new(MockExchangeRateRepository)
+		service := newTestRecurringService(mockRepo, mockTeamRepo, "2026-01-16")
+		service.rateRepo = mockRateRepo
+
+		recurring := &models.RecurringExpense{
+			ID:             "rec-1",
+			UserID:         "user-1",
+			TeamID:         strPtr("team-1"),
+			Amount:         money.MustParse("50"),
+			Currency:       "USD",
+			Frequency:      models.RecurrenceDaily,
+			Interval:       1,
+			StartDate:      "2026-01-15",
+			NextOccurrence: strPtr("2026-01-15"),
+			Active:         true,
+		}
+		mockRepo.On("GetDueRecurringExpenses", "2026-01-16", recurringBatchSize).Return([]*models.RecurringExpense{recurring}, nil).Once()
+		mockTeamRepo.On("GetTeamMember", "team-1", "user-1").Return(&models.TeamMember{Role: models.TeamRoleMember}, nil)
+		mockTeamRepo.On("GetTeamByID", "team-1").Return(&models.Team{ID: "team-1", BaseCurrency: "EUR"}, nil)
+		mockRateRepo.On("GetExchangeRates", "2026-01-15", mock.Anything).Return(ecbRates(), nil)
+		mockRateRepo.On("GetExchangeRates", "2026-01-16", mock.Anything).Return(nil, nil)
+
+		var expenses []*models.Expense
+		mockRepo.On("MaterializeOccurrence", recurring, mock.Anything, mock.AnythingOfType("*models.Expense")).
+			Run(func(args mock.Arguments) {
+				expense := args.Get(2).(*models.Expense)
+				expense.ID = "exp-" + expense.ExpenseDate
+				expenses = append(expenses, expense)
+			}).Return(true, nil)
+
+		created, err := service.MaterializeDue()
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, created)
+		require.Len(t, expenses, 2)
+
+		require.NotNil(t, expenses[0].BaseCurrency)
+		assert.Equal(t, "EUR", *expenses[0].BaseCurrency)
+		require.NotNil(t, expenses[0].ExchangeRate)
+		converted, err := expenses[0].ExchangeRate.Convert(expenses[0].Amount, "EUR")
+		require.NoError(t, err)
+		assert.Equal(t, money.MustParse("45.97"), converted)
+
+		// No rate is stored for the second day yet
+		require.NotNil(t, expenses[1].BaseCurrency)
+		assert.Equal(t, "EUR", *expenses[1].BaseCurrency)
+		assert.Nil(t, expenses[1].ExchangeRate)
+	})
+
+	t.Run("Stops when another run took the occurrence", func(t *testing.T) {
+		mockRepo := new(MockRecurringExpenseRepository)
+		service := newTestRecurringService(mockRepo, new(MockTeamRepository), "2026-04-01")
+
+		recurring := &models.RecurringExpense{
+			ID:             "rec-1",
+			UserID:         "user-1",
+			Frequency:      models.RecurrenceDaily,
+			Interval:       1,
+			StartDate:      "2026-03-30",
+			NextOccurrence: strPtr("2026-03-30"),
+			Active:         true,
+		}
+		mockRepo.On("GetDueRecurringExpenses", "2026-04-01", recurringBatchSize).Return([]*models.RecurringExpense{recurring}, nil).Once()
+		mockRepo.On("MaterializeOccurrence", recurring, "2026-03-30", mock.Anything).Return(false, nil).Once()
+
+		created, err := service.MaterializeDue()
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, created)
+		mockRepo.AssertNumberOfCalls(t, "MaterializeOccurrence", 1)
+	})
+
+	t.Run("Deactivates team templates the owner can no longer use", func(t *testing.T) {
+		mockRepo := new(MockRecurringExpenseRepository)
+		mockTeamRepo := new(MockTeamRepository)
+		service := newTestRecurringService(mockRepo, mockTeamRepo, "2026-04-01")
+
+		recurring := &models.RecurringExpense{
+			ID:             "rec-1",
+			UserID:         "user-1",
+			TeamID:         strPtr("team-1"),
+			Frequency:      models.RecurrenceDaily,
+			Interval:       1,
+			StartDate:      "2026-03-30",
+			NextOccurrence: strPtr("2026-03-30"),
+			Active:         true,
+		}
+		mockRepo.On("GetDueRecurringExpenses", "2026-04-01", recurringBatchSize).Return([]*models.RecurringExpense{recurring}, nil).Once()
+		mockTeamRepo.On("GetTeamMember", "team-1", "user-1").Return(&models.TeamMember{Role: models.TeamRoleViewer}, nil)
+		mockRepo.On("UpdateRecurringExpense", recurring).Return(nil).Once()
+
+		created, err := service.MaterializeDue()
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, created)
+		assert.False(t, recurring.Active)
+		assert.Nil(t, recurring.NextOccurrence)
+		mockRepo.AssertNotCalled(t, "MaterializeOccurrence", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
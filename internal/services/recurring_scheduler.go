@@ -0,0 +1,41 @@
+package services
+
+import (
+    "context"
+    "log"
+    "time"
+)
+
+// RecurringScheduler periodically turns due recurring expense occurrences into
+// expenses. Its first run on startup catches up on anything missed while the
+// server was down.
+type RecurringScheduler struct {
+    recurringService *RecurringExpenseService
+    interval         time.Duration
+}
+
+func NewRecurringScheduler(recurringService *RecurringExpenseService, interval time.Duration) *RecurringScheduler {
+    return &RecurringScheduler{recurringService: recurringService, interval: interval}
+}
+
+// Run materializes due occurrences now and then on every tick until ctx is done
+func (s *RecurringScheduler) Run(ctx context.Context) {
+    ticker := time.NewTicker(s.interval)
+    defer ticker.Stop()
+
+    for {
+        created, err := s.recurringService.MaterializeDue()
+        if err != nil {
+            log.Printf("Recurring expenses: %v", err)
+        }
+        if created > 0 {
+            log.Printf("Recurring expenses: created %d expenses", created)
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
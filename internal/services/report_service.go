@@ -0,0 +1,409 @@
+package services
+
+import (
+    "fmt"
+    "math"
+    "math/big"
+    "pocketpilot/internal/models"
+    "pocketpilot/pkg/money"
+    "sort"
+    "strings"
+    "time"
+)
+
+const (
+    // defaultTrendMonths and maxTrendMonths bound how many months a trend covers
+    defaultTrendMonths = 6
+    maxTrendMonths     = 24
+    // topCategoryCount is how many categories a summary lists
+    topCategoryCount = 5
+    // defaultTimelineBuckets is how many months or weeks a timeline covers unless a range is given
+    defaultTimelineBuckets = 12
+)
+
+// spendingStatuses are the statuses that count as spending. Rejected expenses
+// are left out of reports unless a status filter asks for them.
+var spendingStatuses = []string{
+    models.ExpenseStatusDraft,
+    models.ExpenseStatusSubmitted,
+    models.ExpenseStatusApproved,
+    models.ExpenseStatusReimbursed,
+}
+
+type ReportService struct {
+    reportRepo ReportRepository
+    userRepo   UserRepository
+    teamRepo   TeamRepository
+    rateRepo   ExchangeRateRepository
+    teamAuth   *TeamAuthorizer
+    now        func() time.Time
+}
+
+func NewReportService(reportRepo ReportRepository, userRepo UserRepository, teamRepo TeamRepository, rateRepo ExchangeRateRepository) *ReportService {
+    return &ReportService{
+        reportRepo: reportRepo,
+        userRepo:   userRepo,
+        teamRepo:   teamRepo,
+        rateRepo:   rateRepo,
+        teamAuth:   NewTeamAuthorizer(teamRepo),
+        now:        time.Now,
+    }
+}
+
+// GetSummary totals spending in a date range, the current month unless given,
+// with a breakdown by status that includes rejected expenses and the top categories
+func (s *ReportService) GetSummary(userID string, filter *models.ExpenseFilter) (*models.ReportSummary, error) {
+    filter, err := s.reportFilter(filter, "")
+    if err != nil {
+        return nil, err
+    }
+    base, err := s.authorize(userID, filter)
+    if err != nil {
+        return nil, err
+    }
+
+    byStatus, err := s.report(userID, models.ReportByStatus, filter, base)
+    if err != nil {
+        return nil, err
+    }
+    byCategory, err := s.report(userID, models.ReportByCategory, spendingFilter(filter), base)
+    if err != nil {
+        return nil, err
+    }
+
+    summary := &models.ReportSummary{
+        BaseCurrency:  base,
+        DateFrom:      filter.DateFrom,
+        DateTo:        filter.DateTo,
+        Count:         byCategory.Count,
+        Total:         byCategory.Total,
+        Unconverted:   byCategory.Unconverted,
+        ByStatus:      byStatus.Groups,
+        TopCategories: byCategory.Groups,
+    }
+    if len(summary.TopCategories) > topCategoryCount {
+        summary.TopCategories = summary.TopCategories[:topCategoryCount]
+    }
+
+    decimals := money.Decimals(base)
+    if converted := summary.Count - summary.Unconverted; converted > 0 {
+        if summary.Average, err = summary.Total.MulRat(big.NewRat(1, int64(converted)), decimals); err != nil {
+            return nil, err
+        }
+    }
+    from, _ := time.Parse("2006-01-02", filter.DateFrom)
+    to, _ := time.Parse("2006-01-02", filter.DateTo)
+    days := int64(to.Sub(from).Hours()/24) + 1
+    if summary.DailyAverage, err = summary.Total.MulRat(big.NewRat(1, days), decimals); err != nil {
+        return nil, err
+    }
+
+    return summary, nil
+}
+
+// GetBreakdown totals spending in a date range per category, tag, status or
+// team member. The range is the current month unless given. Expenses with
+// several tags count toward each of them.
+func (s *ReportService) GetBreakdown(userID, groupBy string, filter *models.ExpenseFilter) (*models.Report, error) {
+    switch groupBy {
+    case models.ReportByCategory, models.ReportByTag, models.ReportByStatus:
+    case models.ReportByMember:
+        if filter == nil || filter.TeamID == nil {
+            return nil, fmt.Errorf("%w: member breakdowns need a team_id", ErrInvalidReport)
+        }
+    default:
+        return nil, fmt.Errorf("%w: group by category, tag, status or member", ErrInvalidReport)
+    }
+
+    filter, err := s.reportFilter(filter, "")
+    if err != nil {
+        return nil, err
+    }
+    base, err := s.authorize(userID, filter)
+    if err != nil {
+        return nil, err
+    }
+    if groupBy != models.ReportByStatus {
+        filter = spendingFilter(filter)
+    }
+
+    report, err := s.report(userID, groupBy, filter, base)
+    if err != nil {
+        return nil, err
+    }
+    if groupBy == models.ReportByMember {
+        if err := s.nameMembers(*filter.TeamID, report); err != nil {
+            return nil, err
+        }
+    }
+    return report, nil
+}
+
+// GetTimeline totals spending per month or week, oldest first, with empty
+// periods included. The range is the last 12 months or weeks unless given.
+func (s *ReportService) GetTimeline(userID, interval string, filter *models.ExpenseFilter) (*models.Report, error) {
+    if interval == "" {
+        interval = models.ReportByMonth
+    }
+    if interval != models.ReportByMonth && interval != models.ReportByWeek {
+        return nil, fmt.Errorf("%w: interval must be month or week", ErrInvalidReport)
+    }
+
+    filter, err := s.reportFilter(filter, interval)
+    if err != nil {
+        return nil, err
+    }
+    base, err := s.authorize(userID, filter)
+    if err != nil {
+        return nil, err
+    }
+
+    report, err := s.report(userID, interval, spendingFilter(filter), base)
+    if err != nil {
+        return nil, err
+    }
+
+    groups := make(map[string]models.ReportGroup, len(report.Groups))
+    for _, group := range report.Groups {
+        groups[group.Key] = group
+    }
+    report.Groups = report.Groups[:0]
+    for _, key := range timelineKeys(interval, filter.DateFrom, filter.DateTo) {
+        group, ok := groups[key]
+        if !ok {
+            group = models.ReportGroup{Key: key}
+        }
+        report.Groups = append(report.Groups, group)
+    }
+    return report, nil
+}
+
+// GetTrend compares each month's spending with the month before, for the given
+// number of months up to the month of DateTo, or the current month
+func (s *ReportService) GetTrend(userID string, months int, filter *models.ExpenseFilter) (*models.SpendingTrend, error) {
+    if months == 0 {
+        months = defaultTrendMonths
+    }
+    if months < 1 || months > maxTrendMonths {
+        return nil, fmt.Errorf("%w: months must be between 1 and %d", ErrInvalidReport, maxTrendMonths)
+    }
+
+    filter, err := s.reportFilter(filter, "")
+    if err != nil {
+        return nil, err
+    }
+    base, err := s.authorize(userID, filter)
+    if err != nil {
+        return nil, err
+    }
+
+    // One extra month gives the first month something to compare with
+    to, _ := time.Parse("2006-01-02", filter.DateTo)
+    lastMonth := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+    firstMonth := lastMonth.AddDate(0, -months, 0)
+    filter.DateFrom = firstMonth.Format("2006-01-02")
+    filter.DateTo = lastMonth.AddDate(0, 1, -1).Format("2006-01-02")
+
+    report, err := s.report(userID, models.ReportByMonth, spendingFilter(filter), base)
+    if err != nil {
+        return nil, err
+    }
+    groups := make(map[string]models.ReportGroup, len(report.Groups))
+    for _, group := range report.Groups {
+        groups[group.Key] = group
+    }
+
+    trend := &models.SpendingTrend{BaseCurrency: base, Months: []models.TrendPoint{}}
+    previous := groups[firstMonth.Format("2006-01")]
+    for i := 1; i <= months; i++ {
+        group := groups[firstMonth.AddDate(0, i, 0).Format("2006-01")]
+        point := models.TrendPoint{
+            Month:       firstMonth.AddDate(0, i, 0).Format("2006-01"),
+            Count:       group.Count,
+            Total:       group.Total,
+            Change:      group.Total.Sub(previous.Total),
+            Unconverted: group.Unconverted,
+        }
+        if previous.Total > 0 {
+            percent := percentOf(point.Change, previous.Total)
+            point.ChangePercent = &percent
+        }
+        trend.Months = append(trend.Months, point)
+        previous = group
+    }
+    return trend, nil
+}
+
+// reportFilter validates a report filter and fills in its date range. Without
+// one, timelines cover the last 12 months or weeks and other reports the
+// current month. Sorting and pagination do not apply to reports.
+func (s *ReportService) reportFilter(filter *models.ExpenseFilter, interval string) (*models.ExpenseFilter, error) {
+    result := models.ExpenseFilter{}
+    if filter != nil {
+        result = *filter
+    }
+    result.Sort, result.Order, result.After = "", "", nil
+    if err := validateExpenseFilter(&result); err != nil {
+        return nil, err
+    }
+
+    if result.DateTo == "" {
+        now := s.now().UTC()
+        result.DateTo = now.Format("2006-01-02")
+        if result.DateFrom > result.DateTo {
+            return nil, fmt.Errorf("%w: date_from must not be in the future without a date_to", ErrInvalidReport)
+        }
+    }
+    if result.DateFrom == "" {
+        to, _ := time.Parse("2006-01-02", result.DateTo)
+        from := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+        switch interval {
+        case models.ReportByMonth:
+            from = from.AddDate(0, 1-defaultTimelineBuckets, 0)
+        case models.ReportByWeek:
+            from = weekStart(to).AddDate(0, 0, 7*(1-defaultTimelineBuckets))
+        }
+        result.DateFrom = from.Format("2006-01-02")
+    }
+    return &result, nil
+}
+
+// authorize checks the user may report on the filter's team, if any, and
+// returns the currency the report is in
+func (s *ReportService) authorize(userID string, filter *models.ExpenseFilter) (string, error) {
+    if filter.TeamID != nil {
+        if _, err := s.teamAuth.Authorize(*filter.TeamID, userID, PermReadTeamExpenses); err != nil {
+            return "", err
+        }
+    }
+    return baseCurrencyFor(s.userRepo, s.teamRepo, userID, filter.TeamID)
+}
+
+// report folds the repository's aggregates into groups, converting each day's
+// amounts to the base currency with that day's rate
+func (s *ReportService) report(userID, groupBy string, filter *models.ExpenseFilter, base string) (*models.Report, error) {
+    var rows []*models.ReportRow
+    var err error
+    if filter.TeamID != nil {
+        rows, err = s.reportRepo.SumExpensesByTeam(*filter.TeamID, groupBy, filter)
+    } else {
+        rows, err = s.reportRepo.SumExpensesByUser(userID, groupBy, filter)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    report := &models.Report{
+        GroupBy:      groupBy,
+        BaseCurrency: base,
+        DateFrom:     filter.DateFrom,
+        DateTo:       filter.DateTo,
+        Groups:       []models.ReportGroup{},
+    }
+    converter := newRateConverter(s.rateRepo)
+    index := make(map[string]int)
+    for _, row := range rows {
+        i, ok := index[row.Key]
+        if !ok {
+            i = len(report.Groups)
+            index[row.Key] = i
+            report.Groups = append(report.Groups, models.ReportGroup{Key: row.Key})
+        }
+        group := &report.Groups[i]
+
+        date := row.Date
+        if len(date) > 10 {
+            date = date[:10]
+        }
+        converted, err := converter.Convert(row.Amount, row.Currency, base, date)
+        if err != nil {
+            return nil, err
+        }
+
+        group.Count += row.Count
+        report.Count += row.Count
+        if converted == nil {
+            group.Unconverted += row.Count
+            report.Unconverted += row.Count
+            continue
+        }
+        group.Total = group.Total.Add(*converted)
+        report.Total = report.Total.Add(*converted)
+    }
+
+    for i := range report.Groups {
+        report.Groups[i].Share = percentOf(report.Groups[i].Total, report.Total)
+    }
+    if groupBy != models.ReportByMonth && groupBy != models.ReportByWeek {
+        sort.SliceStable(report.Groups, func(i, j int) bool {
+            if report.Groups[i].Total != report.Groups[j].Total {
+                return report.Groups[i].Total > report.Groups[j].Total
+            }
+            return report.Groups[i].Key < report.Groups[j].Key
+        })
+    }
+    return report, nil
+}
+
+// nameMembers fills in the names of the team members in a member breakdown
+func (s *ReportService) nameMembers(teamID string, report *models.Report) error {
+    members, err := s.teamRepo.GetTeamMembers(teamID)
+    if err != nil {
+        return err
+    }
+
+    names := make(map[string]string, len(members))
+    for _, member := range members {
+        if member.User != nil {
+            names[member.UserID] = strings.TrimSpace(member.User.FirstName + " " + member.User.LastName)
+        }
+    }
+    for i := range report.Groups {
+        report.Groups[i].Name = names[report.Groups[i].Key]
+    }
+    return nil
+}
+
+// spendingFilter leaves rejected expenses out unless the filter picks statuses
+func spendingFilter(filter *models.ExpenseFilter) *models.ExpenseFilter {
+    if len(filter.Statuses) > 0 {
+        return filter
+    }
+    result := *filter
+    result.Statuses = spendingStatuses
+    return &result
+}
+
+// timelineKeys lists the months (YYYY-MM) or week starts (YYYY-MM-DD) between two dates
+func timelineKeys(interval, from, to string) []string {
+    start, _ := time.Parse("2006-01-02", from)
+    end, _ := time.Parse("2006-01-02", to)
+
+    var keys []string
+    if interval == models.ReportByWeek {
+        for week := weekStart(start); !week.After(end); week = week.AddDate(0, 0, 7) {
+            keys = append(keys, week.Format("2006-01-02"))
+        }
+        return keys
+    }
+    for month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(end); month = month.AddDate(0, 1, 0) {
+        keys = append(keys, month.Format("2006-01"))
+    }
+    return keys
+}
+
+// weekStart returns the Monday of the date's week, as Postgres truncates weeks
+func weekStart(date time.Time) time.Time {
+    offset := (int(date.Weekday()) + 6) % 7
+    return time.Date(date.Year(), date.Month(), date.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// percentOf is part as a percentage of whole, rounded to two decimals, or 0
+// when whole is not positive
+func percentOf(part, whole money.Amount) float64 {
+    if whole <= 0 {
+        return 0
+    }
+    ratio, _ := new(big.Rat).Quo(part.Rat(), whole.Rat()).Float64()
+    return math.Round(ratio*10000) / 100
+}
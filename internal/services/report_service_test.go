@@ -0,0 +1,201 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockReportRepository struct {
+	mock.Mock
+}
+
+func (m *MockReportRepository) SumExpensesByUser(userID, groupBy string, filter *models.ExpenseFilter) ([]*models.ReportRow, error) {
+	args := m.Called(userID, groupBy, filter)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.ReportRow), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReportRepository) SumExpensesByTeam(teamID, groupBy string, filter *models.ExpenseFilter) ([]*models.ReportRow, error) {
+	args := m.Called(teamID, groupBy, filter)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.ReportRow), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// reportRange matches filters covering exactly the given dates
+func reportRange(from, to string) interface{} {
+	return mock.MatchedBy(func(f *models.ExpenseFilter) bool {
+		return f.DateFrom == from && f.DateTo == to
+	})
+}
+
+func newTestReportService(reportRepo *MockReportRepository, teamRepo *MockTeamRepository, rateRepo *MockExchangeRateRepository) *ReportService {
+	reportService := NewReportService(reportRepo, usersWithBaseCurrency("USD"), teamRepo, rateRepo)
+	reportService.now = func() time.Time { return time.Date(2026, time.March, 18, 9, 0, 0, 0, time.UTC) }
+	return reportService
+}
+
+func TestReportService_GetBreakdown(t *testing.T) {
+	mockReportRepo := new(MockReportRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	mockRateRepo := new(MockExchangeRateRepository)
+	reportService := newTestReportService(mockReportRepo, mockTeamRepo, mockRateRepo)
+
+	t.Run("Categories default to the current month and leave out rejected expenses", func(t *testing.T) {
+		mockReportRepo.On("SumExpensesByUser", "user-1", models.ReportByCategory, mock.MatchedBy(func(f *models.ExpenseFilter) bool {
+			return f.DateFrom == "2026-03-01" && f.DateTo == "2026-03-18" && len(f.Statuses) == 4
+		})).Return([]*models.ReportRow{
+			{Key: "Food", Date: "2026-03-02", Currency: "USD", Count: 2, Amount: money.MustParse("30")},
+			{Key: "Food", Date: "2026-03-10", Currency: "USD", Count: 1, Amount: money.MustParse("15")},
+			{Key: "Rent", Date: "2026-03-01", Currency: "USD", Count: 1, Amount: money.MustParse("900")},
+			{Key: "Travel", Date: "2026-03-05", Currency: "JPY", Count: 1, Amount: money.MustParse("5000")},
+		}, nil).Once()
+		mockRateRepo.On("GetExchangeRates", "2026-03-05", mock.Anything).Return(nil, nil).Once()
+
+		report, err := reportService.GetBreakdown("user-1", models.ReportByCategory, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "USD", report.BaseCurrency)
+		assert.Equal(t, 5, report.Count)
+		assert.Equal(t, money.MustParse("945"), report.Total)
+		assert.Equal(t, 1, report.Unconverted)
+		require.Len(t, report.Groups, 3)
+		assert.Equal(t, "Rent", report.Groups[0].Key)
+		assert.Equal(t, 95.24, report.Groups[0].Share)
+		assert.Equal(t, "Food", report.Groups[1].Key)
+		assert.Equal(t, 3, report.Groups[1].Count)
+		assert.Equal(t, money.MustParse("45"), report.Groups[1].Total)
+		assert.Equal(t, 1, report.Groups[2].Unconverted)
+	})
+
+	t.Run("Tags filtered by tag", func(t *testing.T) {
+		mockReportRepo.On("SumExpensesByUser", "user-1", models.ReportByTag, mock.MatchedBy(func(f *models.ExpenseFilter) bool {
+			return len(f.Tags) == 1 && f.Tags[0] == "client-acme"
+		})).Return([]*models.ReportRow{
+			{Key: "client-acme", Date: "2026-03-02", Currency: "USD", Count: 2, Amount: money.MustParse("250")},
+		}, nil).Once()
+
+		report, err := reportService.GetBreakdown("user-1", models.ReportByTag, &models.ExpenseFilter{Tags: []string{"client-acme"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, models.ReportByTag, report.GroupBy)
+		require.Len(t, report.Groups, 1)
+		assert.Equal(t, money.MustParse("250"), report.Groups[0].Total)
+	})
+
+	t.Run("Members need a team", func(t *testing.T) {
+		_, err := reportService.GetBreakdown("user-1", models.ReportByMember, &models.ExpenseFilter{})
+
+		assert.ErrorIs(t, err, ErrInvalidReport)
+	})
+
+	t.Run("Members are named", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "user-1").Return(teamMember("team-1", "user-1", models.TeamRoleViewer), nil)
+		mockTeamRepo.On("GetTeamByID", "team-1").Return(&models.Team{ID: "team-1", BaseCurrency: "USD"}, nil)
+		mockTeamRepo.On("GetTeamMembers", "team-1").Return([]*models.TeamMember{
+			{TeamID: "team-1", UserID: "user-1", User: &models.User{FirstName: "Ada", LastName: "Lovelace"}},
+		}, nil)
+		mockReportRepo.On("SumExpensesByTeam", "team-1", models.ReportByMember, reportRange("2026-03-01", "2026-03-18")).Return([]*models.ReportRow{
+			{Key: "user-1", Date: "2026-03-02", Currency: "USD", Count: 1, Amount: money.MustParse("20")},
+			{Key: "former", Date: "2026-03-03", Currency: "USD", Count: 1, Amount: money.MustParse("10")},
+		}, nil).Once()
+
+		report, err := reportService.GetBreakdown("user-1", models.ReportByMember, &models.ExpenseFilter{TeamID: strPtr("team-1")})
+
+		require.NoError(t, err)
+		require.Len(t, report.Groups, 2)
+		assert.Equal(t, "Ada Lovelace", report.Groups[0].Name)
+		assert.Empty(t, report.Groups[1].Name)
+	})
+
+	t.Run("Invalid dimension", func(t *testing.T) {
+		_, err := reportService.GetBreakdown("user-1", "weekday", nil)
+
+		assert.ErrorIs(t, err, ErrInvalidReport)
+	})
+}
+
+func TestReportService_GetTimeline(t *testing.T) {
+	mockReportRepo := new(MockReportRepository)
+	reportService := newTestReportService(mockReportRepo, new(MockTeamRepository), new(MockExchangeRateRepository))
+
+	mockReportRepo.On("SumExpensesByUser", "user-1", models.ReportByWeek, reportRange("2026-02-26", "2026-03-18")).Return([]*models.ReportRow{
+		{Key: "2026-02-23", Date: "2026-02-27", Currency: "USD", Count: 1, Amount: money.MustParse("12")},
+		{Key: "2026-03-09", Date: "2026-03-11", Currency: "USD", Count: 2, Amount: money.MustParse("40")},
+	}, nil)
+
+	report, err := reportService.GetTimeline("user-1", models.ReportByWeek, &models.ExpenseFilter{DateFrom: "2026-02-26", DateTo: "2026-03-18"})
+
+	require.NoError(t, err)
+	var keys []string
+	for _, group := range report.Groups {
+		keys = append(keys, group.Key)
+	}
+	assert.Equal(t, []string{"2026-02-23", "2026-03-02", "2026-03-09", "2026-03-16"}, keys)
+	assert.Equal(t, money.MustParse("0"), report.Groups[1].Total)
+	assert.Equal(t, money.MustParse("40"), report.Groups[2].Total)
+
+	_, err = reportService.GetTimeline("user-1", "day", nil)
+	assert.ErrorIs(t, err, ErrInvalidReport)
+}
+
+func TestReportService_GetTrend(t *testing.T) {
+	mockReportRepo := new(MockReportRepository)
+	reportService := newTestReportService(mockReportRepo, new(MockTeamRepository), new(MockExchangeRateRepository))
+
+	mockReportRepo.On("SumExpensesByUser", "user-1", models.ReportByMonth, reportRange("2025-12-01", "2026-03-31")).Return([]*models.ReportRow{
+		{Key: "2025-12", Date: "2025-12-20", Currency: "USD", Count: 2, Amount: money.MustParse("200")},
+		{Key: "2026-01", Date: "2026-01-10", Currency: "USD", Count: 3, Amount: money.MustParse("250")},
+		{Key: "2026-03", Date: "2026-03-04", Currency: "USD", Count: 1, Amount: money.MustParse("80")},
+	}, nil)
+
+	trend, err := reportService.GetTrend("user-1", 3, nil)
+
+	require.NoError(t, err)
+	require.Len(t, trend.Months, 3)
+	assert.Equal(t, "2026-01", trend.Months[0].Month)
+	assert.Equal(t, money.MustParse("50"), trend.Months[0].Change)
+	assert.Equal(t, 25.0, *trend.Months[0].ChangePercent)
+	assert.Equal(t, money.MustParse("-250"), trend.Months[1].Change)
+	assert.Equal(t, -100.0, *trend.Months[1].ChangePercent)
+	assert.Equal(t, money.MustParse("80"), trend.Months[2].Change)
+	assert.Nil(t, trend.Months[2].ChangePercent)
+
+	_, err = reportService.GetTrend("user-1", 30, nil)
+	assert.ErrorIs(t, err, ErrInvalidReport)
+}
+
+func TestReportService_GetSummary(t *testing.T) {
+	mockReportRepo := new(MockReportRepository)
+	reportService := newTestReportService(mockReportRepo, new(MockTeamRepository), new(MockExchangeRateRepository))
+
+	filter := &models.ExpenseFilter{DateFrom: "2026-03-01", DateTo: "2026-03-10"}
+	mockReportRepo.On("SumExpensesByUser", "user-1", models.ReportByStatus, mock.MatchedBy(func(f *models.ExpenseFilter) bool {
+		return len(f.Statuses) == 0
+	})).Return([]*models.ReportRow{
+		{Key: models.ExpenseStatusApproved, Date: "2026-03-02", Currency: "USD", Count: 3, Amount: money.MustParse("100")},
+		{Key: models.ExpenseStatusRejected, Date: "2026-03-03", Currency: "USD", Count: 1, Amount: money.MustParse("500")},
+	}, nil)
+	mockReportRepo.On("SumExpensesByUser", "user-1", models.ReportByCategory, mock.Anything).Return([]*models.ReportRow{
+		{Key: "Food", Date: "2026-03-02", Currency: "USD", Count: 3, Amount: money.MustParse("100")},
+	}, nil)
+
+	summary, err := reportService.GetSummary("user-1", filter)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, summary.Count)
+	assert.Equal(t, money.MustParse("100"), summary.Total)
+	assert.Equal(t, money.MustParse("33.33"), summary.Average)
+	assert.Equal(t, money.MustParse("10"), summary.DailyAverage)
+	assert.Len(t, summary.ByStatus, 2)
+	assert.Equal(t, "Food", summary.TopCategories[0].Key)
+}
@@ -0,0 +1,369 @@
+package services
+
+import (
+    "fmt"
+    "pocketpilot/internal/models"
+    "pocketpilot/pkg/money"
+    "sort"
+    "strings"
+)
+
+// hundredPercent is what the percentages of a split add up to
+var hundredPercent = money.MustParse("100")
+
+type SplitService struct {
+    splitRepo   SplitRepository
+    expenseRepo ExpenseRepository
+    teamRepo    TeamRepository
+    teamAuth    *TeamAuthorizer
+}
+
+func NewSplitService(splitRepo SplitRepository, expenseRepo ExpenseRepository, teamRepo TeamRepository) *SplitService {
+    return &SplitService{
+        splitRepo:   splitRepo,
+        expenseRepo: expenseRepo,
+        teamRepo:    teamRepo,
+        teamAuth:    NewTeamAuthorizer(teamRepo),
+    }
+}
+
+// SplitExpense splits a team expense among team members, replacing any earlier
+// split. The expense's owner paid for it, so the other members owe them their portions.
+func (s *SplitService) SplitExpense(expenseID, userID string, req *models.SplitExpenseRequest) (*models.ExpenseSplits, error) {
+    expense, err := authorizeExpense(s.expenseRepo, s.teamAuth, expenseID, userID, PermUpdateOwnExpense, PermUpdateAnyExpense)
+    if err != nil {
+        return nil, err
+    }
+    if expense.TeamID == nil {
+        return nil, ErrSplitNeedsTeam
+    }
+    // Submitted and decided expenses are frozen, their split included
+    if !isExpenseEditable(expense.Status) {
+        return nil, ErrExpenseLocked
+    }
+
+    method := strings.ToLower(strings.TrimSpace(req.Method))
+    splits, err := computeSplits(expense.Amount, expense.Currency, method, req.Splits)
+    if err != nil {
+        return nil, err
+    }
+    for _, split := range splits {
+        member, err := s.teamRepo.GetTeamMember(*expense.TeamID, split.UserID)
+        if err != nil {
+            return nil, err
+        }
+        if member == nil {
+            return nil, fmt.Errorf("%w: user %s is not a member of the team", ErrInvalidSplit, split.UserID)
+        }
+        split.ExpenseID = expense.ID
+    }
+
+    if err := s.splitRepo.SetExpenseSplits(expense.ID, &method, splits); err != nil {
+        return nil, err
+    }
+    return expenseSplits(expense, method, splits), nil
+}
+
+// GetExpenseSplits retrieves how an expense is split, for anyone who can read it
+func (s *SplitService) GetExpenseSplits(expenseID, userID string) (*models.ExpenseSplits, error) {
+    expense, err := authorizeExpense(s.expenseRepo, s.teamAuth, expenseID, userID, PermReadTeamExpenses, PermReadTeamExpenses)
+    if err != nil {
+        return nil, err
+    }
+    if expense.SplitMethod == nil {
+        return nil, ErrExpenseNotSplit
+    }
+
+    splits, err := s.splitRepo.GetExpenseSplits(expense.ID)
+    if err != nil {
+        return nil, err
+    }
+    return expenseSplits(expense, *expense.SplitMethod, splits), nil
+}
+
+// RemoveExpenseSplit makes the expense the owner's alone again
+func (s *SplitService) RemoveExpenseSplit(expenseID, userID string) error {
+    expense, err := authorizeExpense(s.expenseRepo, s.teamAuth, expenseID, userID, PermUpdateOwnExpense, PermUpdateAnyExpense)
+    if err != nil {
+        return err
+    }
+    if expense.SplitMethod == nil {
+        return ErrExpenseNotSplit
+    }
+    if !isExpenseEditable(expense.Status) {
+        return ErrExpenseLocked
+    }
+
+    return s.splitRepo.SetExpenseSplits(expense.ID, nil, nil)
+}
+
+// GetTeamBalances works out who owes whom in a team from its split expenses and
+// settlements, along with the fewest payments that would settle everything
+func (s *SplitService) GetTeamBalances(teamID, userID string) (*models.TeamBalances, error) {
+    if _, err := s.teamAuth.Authorize(teamID, userID, PermReadTeamExpenses); err != nil {
+        return nil, err
+    }
+
+    owed, err := s.splitRepo.GetTeamSplitDebts(teamID)
+    if err != nil {
+        return nil, err
+    }
+    settled, err := s.splitRepo.GetTeamSettledDebts(teamID)
+    if err != nil {
+        return nil, err
+    }
+
+    // Per currency, what each member is owed
+    net := make(map[string]map[string]money.Amount)
+    add := func(currency, userID string, amount money.Amount) {
+        if net[currency] == nil {
+            net[currency] = make(map[string]money.Amount)
+        }
+        net[currency][userID] = net[currency][userID].Add(amount)
+    }
+    for _, debt := range owed {
+        add(debt.Currency, debt.ToUserID, debt.Amount)
+        add(debt.Currency, debt.FromUserID, debt.Amount.Neg())
+    }
+    // Paying someone back counts as lending them the amount
+    for _, payment := range settled {
+        add(payment.Currency, payment.FromUserID, payment.Amount)
+        add(payment.Currency, payment.ToUserID, payment.Amount.Neg())
+    }
+
+    currencies := make([]string, 0, len(net))
+    for currency := range net {
+        currencies = append(currencies, currency)
+    }
+    sort.Strings(currencies)
+
+    result := &models.TeamBalances{TeamID: teamID, Balances: []models.MemberBalance{}, Debts: []models.Debt{}}
+    for _, currency := range currencies {
+        balances := net[currency]
+        users := make([]string, 0, len(balances))
+        for user, amount := range balances {
+            if amount != 0 {
+                users = append(users, user)
+            }
+        }
+        sort.Strings(users)
+        for _, user := range users {
+            result.Balances = append(result.Balances, models.MemberBalance{UserID: user, Currency: currency, Amount: balances[user]})
+        }
+        result.Debts = append(result.Debts, simplifyDebts(currency, balances)...)
+    }
+
+    return result, nil
+}
+
+// CreateSettlement records a payment between two team members. Members record
+// payments they made or received, admins and owners any payment.
+func (s *SplitService) CreateSettlement(teamID, userID string, req *models.CreateSettlementRequest) (*models.Settlement, error) {
+    member, err := s.teamAuth.Authorize(teamID, userID, PermCreateTeamExpense)
+    if err != nil {
+        return nil, err
+    }
+
+    from := req.FromUserID
+    if from == "" {
+        from = userID
+    }
+    if from != userID && req.ToUserID != userID && !RoleHasPermission(member.Role, PermUpdateAnyExpense) {
+        return nil, ErrTeamAccessDenied
+    }
+    if from == req.ToUserID {
+        return nil, fmt.Errorf("%w: a member cannot pay themselves", ErrInvalidSettlement)
+    }
+
+    currency, err := money.NormalizeCurrency(req.Currency)
+    if err != nil {
+        return nil, ErrInvalidCurrency
+    }
+    if err := validateAmount(req.Amount, currency); err != nil {
+        return nil, err
+    }
+
+    for _, party := range []string{from, req.ToUserID} {
+        if party == userID {
+            continue
+        }
+        other, err := s.teamRepo.GetTeamMember(teamID, party)
+        if err != nil {
+            return nil, err
+        }
+        if other == nil {
+            return nil, fmt.Errorf("%w: user %s is not a member of the team", ErrInvalidSettlement, party)
+        }
+    }
+
+    settlement := &models.Settlement{
+        TeamID:     teamID,
+        FromUserID: from,
+        ToUserID:   req.ToUserID,
+        Amount:     req.Amount,
+        Currency:   currency,
+        Note:       req.Note,
+        CreatedBy:  userID,
+    }
+    if err := s.splitRepo.CreateSettlement(settlement); err != nil {
+        return nil, err
+    }
+    return settlement, nil
+}
+
+// GetSettlements lists a team's settlements, newest first
+func (s *SplitService) GetSettlements(teamID, userID string) ([]*models.Settlement, error) {
+    if _, err := s.teamAuth.Authorize(teamID, userID, PermReadTeamExpenses); err != nil {
+        return nil, err
+    }
+
+    return s.splitRepo.GetSettlementsByTeam(teamID)
+}
+
+// computeSplits works out each member's portion of an amount. Portions always
+// add up to the amount exactly, in the currency's smallest unit.
+func computeSplits(amount money.Amount, currency, method string, inputs []models.SplitInput) ([]*models.ExpenseSplit, error) {
+    if len(inputs) == 0 {
+        return nil, fmt.Errorf("%w: at least one member is required", ErrInvalidSplit)
+    }
+    seen := make(map[string]bool, len(inputs))
+    for _, input := range inputs {
+        if seen[input.UserID] {
+            return nil, fmt.Errorf("%w: user %s appears more than once", ErrInvalidSplit, input.UserID)
+        }
+        seen[input.UserID] = true
+    }
+
+    decimals := money.Decimals(currency)
+    splits := make([]*models.ExpenseSplit, len(inputs))
+    for i, input := range inputs {
+        splits[i] = &models.ExpenseSplit{UserID: input.UserID}
+    }
+
+    switch method {
+    case models.SplitMethodEqual:
+        for i, part := range amount.Allocate(len(inputs), decimals) {
+            splits[i].Amount = part
+        }
+
+    case models.SplitMethodExact:
+        var total money.Amount
+        for i, input := range inputs {
+            if input.Amount == nil || input.Amount.Sign() < 0 {
+                return nil, fmt.Errorf("%w: exact splits need an amount of 0 or more for every member", ErrInvalidSplit)
+            }
+            if input.Amount.Validate(currency) != nil {
+                return nil, fmt.Errorf("%w: %s amounts have at most %d decimal places", ErrInvalidSplit, currency, decimals)
+            }
+            splits[i].Amount = *input.Amount
+            total = total.Add(*input.Amount)
+        }
+        if total != amount {
+            return nil, fmt.Errorf("%w: amounts add up to %s, not %s", ErrInvalidSplit, total.Format(currency), amount.Format(currency))
+        }
+
+    case models.SplitMethodPercentage:
+        weights := make([]int64, len(inputs))
+        var total money.Amount
+        for i, input := range inputs {
+            if input.Percentage == nil || input.Percentage.Sign() < 0 {
+                return nil, fmt.Errorf("%w: percentage splits need a percentage of 0 or more for every member", ErrInvalidSplit)
+            }
+            percentage := *input.Percentage
+            splits[i].Percentage = &percentage
+            weights[i] = int64(percentage)
+            total = total.Add(percentage)
+        }
+        if total != hundredPercent {
+            return nil, fmt.Errorf("%w: percentages add up to %s, not 100", ErrInvalidSplit, total)
+        }
+        for i, part := range amount.AllocateWeights(weights, decimals) {
+            splits[i].Amount = part
+        }
+
+    case models.SplitMethodShares:
+        weights := make([]int64, len(inputs))
+        for i, input := range inputs {
+            if input.Shares == nil || *input.Shares < 1 {
+                return nil, fmt.Errorf("%w: shares splits need at least one share for every member", ErrInvalidSplit)
+            }
+            shares := *input.Shares
+            splits[i].Shares = &shares
+            weights[i] = int64(shares)
+        }
+        for i, part := range amount.AllocateWeights(weights, decimals) {
+            splits[i].Amount = part
+        }
+
+    default:
+        return nil, fmt.Errorf("%w: method must be equal, exact, percentage or shares", ErrInvalidSplit)
+    }
+
+    return splits, nil
+}
+
+// simplifyDebts settles the balances of one currency with few payments by
+// repeatedly having the largest debtor pay the largest creditor. It needs at
+// most one payment fewer than there are members with a balance.
+func simplifyDebts(currency string, balances map[string]money.Amount) []models.Debt {
+    type position struct {
+        userID string
+        amount money.Amount // always positive
+    }
+    var creditors, debtors []*position
+    for userID, amount := range balances {
+        switch {
+        case amount > 0:
+            creditors = append(creditors, &position{userID, amount})
+        case amount < 0:
+            debtors = append(debtors, &position{userID, amount.Neg()})
+        }
+    }
+    largestFirst := func(positions []*position) {
+        sort.Slice(positions, func(i, j int) bool {
+            if positions[i].amount != positions[j].amount {
+                return positions[i].amount > positions[j].amount
+            }
+            return positions[i].userID < positions[j].userID
+        })
+    }
+    largestFirst(creditors)
+    largestFirst(debtors)
+
+    var debts []models.Debt
+    for len(creditors) > 0 && len(debtors) > 0 {
+        creditor, debtor := creditors[0], debtors[0]
+        payment := creditor.amount
+        if debtor.amount < payment {
+            payment = debtor.amount
+        }
+        debts = append(debts, models.Debt{FromUserID: debtor.userID, ToUserID: creditor.userID, Currency: currency, Amount: payment})
+
+        creditor.amount = creditor.amount.Sub(payment)
+        debtor.amount = debtor.amount.Sub(payment)
+        if creditor.amount == 0 {
+            creditors = creditors[1:]
+        }
+        if debtor.amount == 0 {
+            debtors = debtors[1:]
+        }
+        largestFirst(creditors)
+        largestFirst(debtors)
+    }
+
+    return debts
+}
+
+func expenseSplits(expense *models.Expense, method string, splits []*models.ExpenseSplit) *models.ExpenseSplits {
+    if splits == nil {
+        splits = []*models.ExpenseSplit{}
+    }
+    return &models.ExpenseSplits{
+        ExpenseID: expense.ID,
+        PaidBy:    expense.UserID,
+        Method:    method,
+        Amount:    expense.Amount,
+        Currency:  expense.Currency,
+        Splits:    splits,
+    }
+}
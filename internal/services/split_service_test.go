@@ -0,0 +1,314 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockSplitRepository struct {
+	mock.Mock
+}
+
+func (m *MockSplitRepository) SetExpenseSplits(expenseID string, method *string, splits []*models.ExpenseSplit) error {
+	args := m.Called(expenseID, method, splits)
+	return args.Error(0)
+}
+
+func (m *MockSplitRepository) GetExpenseSplits(expenseID string) ([]*models.ExpenseSplit, error) {
+	args := m.Called(expenseID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.ExpenseSplit), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSplitRepository) GetTeamSplitDebts(teamID string) ([]*models.Debt, error) {
+	args := m.Called(teamID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Debt), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSplitRepository) GetTeamSettledDebts(teamID string) ([]*models.Debt, error) {
+	args := m.Called(teamID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Debt), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSplitRepository) CreateSettlement(settlement *models.Settlement) error {
+	args := m.Called(settlement)
+	return args.Error(0)
+}
+
+func (m *MockSplitRepository) GetSettlementsByTeam(teamID string) ([]*models.Settlement, error) {
+	args := m.Called(teamID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Settlement), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func splitAmounts(splits []*models.ExpenseSplit) []string {
+	amounts := make([]string, len(splits))
+	for i, split := range splits {
+		amounts[i] = split.Amount.String()
+	}
+	return amounts
+}
+
+func TestComputeSplits(t *testing.T) {
+	members := func(inputs ...models.SplitInput) []models.SplitInput {
+		for i := range inputs {
+			inputs[i].UserID = string(rune('a' + i))
+		}
+		return inputs
+	}
+
+	t.Run("Equal split gives leftover cents to the first members", func(t *testing.T) {
+		splits, err := computeSplits(money.MustParse("100"), "USD", models.SplitMethodEqual, members(models.SplitInput{}, models.SplitInput{}, models.SplitInput{}))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"33.34", "33.33", "33.33"}, splitAmounts(splits))
+	})
+
+	t.Run("Equal split in a currency without minor units", func(t *testing.T) {
+		splits, err := computeSplits(money.MustParse("1000"), "JPY", models.SplitMethodEqual, members(models.SplitInput{}, models.SplitInput{}, models.SplitInput{}))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"334", "333", "333"}, splitAmounts(splits))
+	})
+
+	t.Run("Exact amounts must add up", func(t *testing.T) {
+		_, err := computeSplits(money.MustParse("50"), "USD", models.SplitMethodExact,
+			members(models.SplitInput{Amount: amountPtr("20")}, models.SplitInput{Amount: amountPtr("20")}))
+		assert.ErrorIs(t, err, ErrInvalidSplit)
+
+		splits, err := computeSplits(money.MustParse("50"), "USD", models.SplitMethodExact,
+			members(models.SplitInput{Amount: amountPtr("20")}, models.SplitInput{Amount: amountPtr("30")}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"20", "30"}, splitAmounts(splits))
+	})
+
+	t.Run("Percentages round to the cent and add up", func(t *testing.T) {
+		splits, err := computeSplits(money.MustParse("10"), "USD", models.SplitMethodPercentage, members(
+			models.SplitInput{Percentage: amountPtr("33.333")},
+			models.SplitInput{Percentage: amountPtr("33.333")},
+			models.SplitInput{Percentage: amountPtr("33.334")},
+		))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"3.33", "3.33", "3.34"}, splitAmounts(splits))
+		assert.Equal(t, "33.334", splits[2].Percentage.String())
+	})
+
+	t.Run("Percentages must add up to 100", func(t *testing.T) {
+		_, err := computeSplits(money.MustParse("10"), "USD", models.SplitMethodPercentage,
+			members(models.SplitInput{Percentage: amountPtr("60")}, models.SplitInput{Percentage: amountPtr("30")}))
+		assert.ErrorIs(t, err, ErrInvalidSplit)
+	})
+
+	t.Run("Shares", func(t *testing.T) {
+		splits, err := computeSplits(money.MustParse("90"), "EUR", models.SplitMethodShares,
+			members(models.SplitInput{Shares: intPtr(2)}, models.SplitInput{Shares: intPtr(1)}))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"60", "30"}, splitAmounts(splits))
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		_, err := computeSplits(money.MustParse("90"), "EUR", models.SplitMethodShares, members(models.SplitInput{}))
+		assert.ErrorIs(t, err, ErrInvalidSplit)
+
+		_, err = computeSplits(money.MustParse("90"), "EUR", "thirds", members(models.SplitInput{}))
+		assert.ErrorIs(t, err, ErrInvalidSplit)
+
+		_, err = computeSplits(money.MustParse("90"), "EUR", models.SplitMethodEqual, []models.SplitInput{{UserID: "a"}, {UserID: "a"}})
+		assert.ErrorIs(t, err, ErrInvalidSplit)
+	})
+}
+
+func TestSplitService_SplitExpense(t *testing.T) {
+	mockSplitRepo := new(MockSplitRepository)
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	splitService := NewSplitService(mockSplitRepo, mockExpenseRepo, mockTeamRepo)
+
+	mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(&models.Expense{
+		ID: "exp-1", UserID: "alice", TeamID: strPtr("team-1"), Amount: money.MustParse("60"), Currency: "USD", Status: models.ExpenseStatusDraft,
+	}, nil)
+	mockExpenseRepo.On("GetExpenseByID", "submitted").Return(&models.Expense{
+		ID: "submitted", UserID: "alice", TeamID: strPtr("team-1"), Amount: money.MustParse("60"), Currency: "USD",
+		Status: models.ExpenseStatusSubmitted, SplitMethod: strPtr(models.SplitMethodEqual),
+	}, nil)
+	mockExpenseRepo.On("GetExpenseByID", "personal").Return(&models.Expense{ID: "personal", UserID: "alice", Amount: money.MustParse("60"), Status: models.ExpenseStatusDraft}, nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "alice").Return(teamMember("team-1", "alice", models.TeamRoleMember), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "bob").Return(teamMember("team-1", "bob", models.TeamRoleMember), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "mallory").Return(nil, nil)
+
+	t.Run("Owner splits equally", func(t *testing.T) {
+		mockSplitRepo.On("SetExpenseSplits", "exp-1", strPtr(models.SplitMethodEqual), mock.MatchedBy(func(splits []*models.ExpenseSplit) bool {
+			return len(splits) == 2 && splits[1].ExpenseID == "exp-1" && splits[1].Amount == money.MustParse("30")
+		})).Return(nil).Once()
+
+		result, err := splitService.SplitExpense("exp-1", "alice", &models.SplitExpenseRequest{
+			Method: "Equal",
+			Splits: []models.SplitInput{{UserID: "alice"}, {UserID: "bob"}},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "alice", result.PaidBy)
+		assert.Equal(t, models.SplitMethodEqual, result.Method)
+		mockSplitRepo.AssertExpectations(t)
+	})
+
+	t.Run("Only team members can share", func(t *testing.T) {
+		_, err := splitService.SplitExpense("exp-1", "alice", &models.SplitExpenseRequest{
+			Method: models.SplitMethodEqual,
+			Splits: []models.SplitInput{{UserID: "alice"}, {UserID: "mallory"}},
+		})
+
+		assert.ErrorIs(t, err, ErrInvalidSplit)
+	})
+
+	t.Run("Member cannot split someone else's expense", func(t *testing.T) {
+		_, err := splitService.SplitExpense("exp-1", "bob", &models.SplitExpenseRequest{
+			Method: models.SplitMethodEqual,
+			Splits: []models.SplitInput{{UserID: "bob"}},
+		})
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+	})
+
+	t.Run("Personal expenses cannot be split", func(t *testing.T) {
+		_, err := splitService.SplitExpense("personal", "alice", &models.SplitExpenseRequest{
+			Method: models.SplitMethodEqual,
+			Splits: []models.SplitInput{{UserID: "alice"}},
+		})
+
+		assert.ErrorIs(t, err, ErrSplitNeedsTeam)
+	})
+
+	t.Run("Submitted expenses cannot be split again", func(t *testing.T) {
+		_, err := splitService.SplitExpense("submitted", "alice", &models.SplitExpenseRequest{
+			Method: models.SplitMethodEqual,
+			Splits: []models.SplitInput{{UserID: "alice"}, {UserID: "bob"}},
+		})
+		assert.ErrorIs(t, err, ErrExpenseLocked)
+
+		err = splitService.RemoveExpenseSplit("submitted", "alice")
+		assert.ErrorIs(t, err, ErrExpenseLocked)
+		mockSplitRepo.AssertNotCalled(t, "SetExpenseSplits", "submitted", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSimplifyDebts(t *testing.T) {
+	// a owes b 10, b owes c 10: a can pay c directly
+	debts := simplifyDebts("USD", map[string]money.Amount{
+		"a": money.MustParse("-10"),
+		"b": 0,
+		"c": money.MustParse("10"),
+	})
+	assert.Equal(t, []models.Debt{{FromUserID: "a", ToUserID: "c", Currency: "USD", Amount: money.MustParse("10")}}, debts)
+
+	debts = simplifyDebts("USD", map[string]money.Amount{
+		"a": money.MustParse("-30"),
+		"b": money.MustParse("-20"),
+		"c": money.MustParse("25"),
+		"d": money.MustParse("25"),
+	})
+	assert.Equal(t, []models.Debt{
+		{FromUserID: "a", ToUserID: "c", Currency: "USD", Amount: money.MustParse("25")},
+		{FromUserID: "b", ToUserID: "d", Currency: "USD", Amount: money.MustParse("20")},
+		{FromUserID: "a", ToUserID: "d", Currency: "USD", Amount: money.MustParse("5")},
+	}, debts)
+}
+
+func TestSplitService_GetTeamBalances(t *testing.T) {
+	mockSplitRepo := new(MockSplitRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	splitService := NewSplitService(mockSplitRepo, new(MockExpenseRepository), mockTeamRepo)
+
+	mockTeamRepo.On("GetTeamMember", "team-1", "alice").Return(teamMember("team-1", "alice", models.TeamRoleMember), nil)
+	mockSplitRepo.On("GetTeamSplitDebts", "team-1").Return([]*models.Debt{
+		{FromUserID: "bob", ToUserID: "alice", Currency: "USD", Amount: money.MustParse("30")},
+		{FromUserID: "carol", ToUserID: "bob", Currency: "USD", Amount: money.MustParse("30")},
+		{FromUserID: "alice", ToUserID: "bob", Currency: "EUR", Amount: money.MustParse("12.5")},
+	}, nil)
+	mockSplitRepo.On("GetTeamSettledDebts", "team-1").Return([]*models.Debt{
+		{FromUserID: "alice", ToUserID: "bob", Currency: "EUR", Amount: money.MustParse("12.5")},
+		{FromUserID: "carol", ToUserID: "alice", Currency: "USD", Amount: money.MustParse("10")},
+	}, nil)
+
+	balances, err := splitService.GetTeamBalances("team-1", "alice")
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.MemberBalance{
+		{UserID: "alice", Currency: "USD", Amount: money.MustParse("20")},
+		{UserID: "carol", Currency: "USD", Amount: money.MustParse("-20")},
+	}, balances.Balances)
+	assert.Equal(t, []models.Debt{
+		{FromUserID: "carol", ToUserID: "alice", Currency: "USD", Amount: money.MustParse("20")},
+	}, balances.Debts)
+}
+
+func TestSplitService_CreateSettlement(t *testing.T) {
+	mockSplitRepo := new(MockSplitRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	splitService := NewSplitService(mockSplitRepo, new(MockExpenseRepository), mockTeamRepo)
+
+	mockTeamRepo.On("GetTeamMember", "team-1", "alice").Return(teamMember("team-1", "alice", models.TeamRoleMember), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "bob").Return(teamMember("team-1", "bob", models.TeamRoleMember), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "carol").Return(teamMember("team-1", "carol", models.TeamRoleMember), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "owner").Return(teamMember("team-1", "owner", models.TeamRoleOwner), nil)
+
+	t.Run("Member records their own payment", func(t *testing.T) {
+		mockSplitRepo.On("CreateSettlement", mock.MatchedBy(func(s *models.Settlement) bool {
+			return s.FromUserID == "bob" && s.ToUserID == "alice" && s.Currency == "USD" && s.CreatedBy == "bob"
+		})).Return(nil).Once()
+
+		settlement, err := splitService.CreateSettlement("team-1", "bob", &models.CreateSettlementRequest{
+			ToUserID: "alice", Amount: money.MustParse("30"), Currency: "usd",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, money.MustParse("30"), settlement.Amount)
+	})
+
+	t.Run("Member cannot record payments between others", func(t *testing.T) {
+		_, err := splitService.CreateSettlement("team-1", "carol", &models.CreateSettlementRequest{
+			FromUserID: "bob", ToUserID: "alice", Amount: money.MustParse("30"), Currency: "USD",
+		})
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+	})
+
+	t.Run("Owner records payments between others", func(t *testing.T) {
+		mockSplitRepo.On("CreateSettlement", mock.AnythingOfType("*models.Settlement")).Return(nil).Once()
+
+		_, err := splitService.CreateSettlement("team-1", "owner", &models.CreateSettlementRequest{
+			FromUserID: "bob", ToUserID: "alice", Amount: money.MustParse("30"), Currency: "USD",
+		})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("Paying yourself", func(t *testing.T) {
+		_, err := splitService.CreateSettlement("team-1", "bob", &models.CreateSettlementRequest{
+			ToUserID: "bob", Amount: money.MustParse("30"), Currency: "USD",
+		})
+
+		assert.ErrorIs(t, err, ErrInvalidSettlement)
+	})
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "pocketpilot/internal/models"
+    "strings"
+)
+
+const (
+    // maxExpenseTags is how many tags one expense can carry
+    maxExpenseTags = 20
+    // maxTagLength is the longest tag name, in characters
+    maxTagLength = 50
+)
+
+type TagService struct {
+    tagRepo  TagRepository
+    teamAuth *TeamAuthorizer
+}
+
+func NewTagService(tagRepo TagRepository, teamRepo TeamRepository) *TagService {
+    return &TagService{
+        tagRepo:  tagRepo,
+        teamAuth: NewTeamAuthorizer(teamRepo),
+    }
+}
+
+// GetTags lists the tags of the user's personal expenses, or of a team's
+// expenses to its members, with how many expenses carry each
+func (s *TagService) GetTags(userID string, teamID *string) ([]*models.Tag, error) {
+    if teamID != nil {
+        if _, err := s.teamAuth.Authorize(*teamID, userID, PermReadTeamExpenses); err != nil {
+            return nil, err
+        }
+    }
+
+    return s.tagRepo.GetTags(userID, teamID)
+}
+
+// RenameTag renames a tag on all its expenses. Renaming to the name of another
+// tag fails; merge the tags instead.
+func (s *TagService) RenameTag(tagID, userID string, req *models.RenameTagRequest) (*models.Tag, error) {
+    tag, err := s.authorizedTag(tagID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    name, err := tagName(req.Name)
+    if err != nil {
+        return nil, err
+    }
+    existing, err := s.tagRepo.GetTagByName(userID, tag.TeamID, name)
+    if err != nil {
+        return nil, err
+    }
+    if existing != nil && existing.ID != tag.ID {
+        return nil, ErrTagExists
+    }
+
+    tag.Name = name
+    if err := s.tagRepo.UpdateTag(tag); err != nil {
+        return nil, err
+    }
+    return tag, nil
+}
+
+// MergeTag moves the expenses of a tag to another tag of the same user or team
+// and deletes it. It returns the tag that remains.
+func (s *TagService) MergeTag(tagID, userID string, req *models.MergeTagRequest) (*models.Tag, error) {
+    source, err := s.authorizedTag(tagID, userID)
+    if err != nil {
+        return nil, err
+    }
+    if req.IntoTagID == source.ID {
+        return nil, fmt.Errorf("%w: a tag cannot be merged into itself", ErrInvalidTag)
+    }
+
+    target, err := s.tagRepo.GetTagByID(req.IntoTagID)
+    if err != nil {
+        return nil, err
+    }
+    if target == nil || !sameTagOwner(source, target) {
+        return nil, fmt.Errorf("%w: tag to merge into not found", ErrInvalidTag)
+    }
+
+    if err := s.tagRepo.MergeTags(source.ID, target.ID); err != nil {
+        return nil, err
+    }
+    return target, nil
+}
+
+// DeleteTag deletes a tag and removes it from its expenses
+func (s *TagService) DeleteTag(tagID, userID string) error {
+    tag, err := s.authorizedTag(tagID, userID)
+    if err != nil {
+        return err
+    }
+    return s.tagRepo.DeleteTag(tag.ID)
+}
+
+// authorizedTag loads a tag the user may change: one of their personal tags,
+// or a tag of a team they manage tags of
+func (s *TagService) authorizedTag(tagID, userID string) (*models.Tag, error) {
+    tag, err := s.tagRepo.GetTagByID(tagID)
+    if err != nil {
+        return nil, err
+    }
+    if tag == nil {
+        return nil, ErrTagNotFound
+    }
+
+    if tag.TeamID == nil {
+        if *tag.UserID != userID {
+            return nil, ErrTagNotFound
+        }
+        return tag, nil
+    }
+    if _, err := s.teamAuth.Authorize(*tag.TeamID, userID, PermManageTags); err != nil {
+        if errors.Is(err, ErrTeamNotFound) {
+            return nil, ErrTagNotFound
+        }
+        return nil, err
+    }
+    return tag, nil
+}
+
+func sameTagOwner(a, b *models.Tag) bool {
+    if a.TeamID != nil || b.TeamID != nil {
+        return a.TeamID != nil && b.TeamID != nil && *a.TeamID == *b.TeamID
+    }
+    return *a.UserID == *b.UserID
+}
+
+// tagName trims a tag name and checks it can be stored and filtered on
+func tagName(name string) (string, error) {
+    name = strings.Join(strings.Fields(name), " ")
+    if name == "" {
+        return "", fmt.Errorf("%w: name is required", ErrInvalidTag)
+    }
+    if len([]rune(name)) > maxTagLength {
+        return "", fmt.Errorf("%w: name is limited to %d characters", ErrInvalidTag, maxTagLength)
+    }
+    // Filters take comma-separated tags
+    if strings.Contains(name, ",") {
+        return "", fmt.Errorf("%w: name cannot contain commas", ErrInvalidTag)
+    }
+    return name, nil
+}
+
+// normalizeTags trims the tags of an expense and drops repeats, ignoring case
+func normalizeTags(tags []string) ([]string, error) {
+    seen := make(map[string]bool, len(tags))
+    normalized := []string{}
+    for _, tag := range tags {
+        name, err := tagName(tag)
+        if err != nil {
+            return nil, &expenseFieldError{"tags", err.Error()}
+        }
+        if seen[strings.ToLower(name)] {
+            continue
+        }
+        seen[strings.ToLower(name)] = true
+        normalized = append(normalized, name)
+    }
+    if len(normalized) > maxExpenseTags {
+        return nil, &expenseFieldError{"tags", fmt.Sprintf("expenses have at most %d tags", maxExpenseTags)}
+    }
+    return normalized, nil
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"pocketpilot/pkg/money"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTagRepository struct {
+	mock.Mock
+}
+
+func (m *MockTagRepository) GetTags(userID string, teamID *string) ([]*models.Tag, error) {
+	args := m.Called(userID, teamID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Tag), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTagRepository) GetTagByID(id string) (*models.Tag, error) {
+	args := m.Called(id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Tag), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTagRepository) GetTagByName(userID string, teamID *string, name string) (*models.Tag, error) {
+	args := m.Called(userID, teamID, name)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Tag), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTagRepository) UpdateTag(tag *models.Tag) error {
+	args := m.Called(tag)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) MergeTags(sourceID, targetID string) error {
+	args := m.Called(sourceID, targetID)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) DeleteTag(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := normalizeTags([]string{" client-acme ", "Conference  2026", "CLIENT-ACME"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"client-acme", "Conference 2026"}, tags)
+
+	_, err = normalizeTags([]string{"a,b"})
+	assert.Error(t, err)
+
+	_, err = normalizeTags([]string{" "})
+	assert.Error(t, err)
+
+	_, err = normalizeTags([]string{strings.Repeat("x", maxTagLength+1)})
+	assert.Error(t, err)
+
+	many := make([]string, maxExpenseTags+1)
+	for i := range many {
+		many[i] = strings.Repeat("t", i+1)
+	}
+	_, err = normalizeTags(many)
+	assert.Error(t, err)
+}
+
+func TestTagService_RenameTag(t *testing.T) {
+	mockTagRepo := new(MockTagRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	tagService := NewTagService(mockTagRepo, mockTeamRepo)
+
+	t.Run("Rename personal tag", func(t *testing.T) {
+		mockTagRepo.On("GetTagByID", "tag-1").Return(&models.Tag{ID: "tag-1", UserID: strPtr("user-1"), Name: "acme"}, nil).Once()
+		mockTagRepo.On("GetTagByName", "user-1", (*string)(nil), "client-acme").Return(nil, nil).Once()
+		mockTagRepo.On("UpdateTag", mock.MatchedBy(func(tag *models.Tag) bool { return tag.Name == "client-acme" })).Return(nil).Once()
+
+		tag, err := tagService.RenameTag("tag-1", "user-1", &models.RenameTagRequest{Name: " client-acme "})
+
+		require.NoError(t, err)
+		assert.Equal(t, "client-acme", tag.Name)
+		mockTagRepo.AssertExpectations(t)
+	})
+
+	t.Run("Name of another tag", func(t *testing.T) {
+		mockTagRepo.On("GetTagByID", "tag-1").Return(&models.Tag{ID: "tag-1", UserID: strPtr("user-1"), Name: "acme"}, nil).Once()
+		mockTagRepo.On("GetTagByName", "user-1", (*string)(nil), "Travel").Return(&models.Tag{ID: "tag-2", Name: "travel"}, nil).Once()
+
+		_, err := tagService.RenameTag("tag-1", "user-1", &models.RenameTagRequest{Name: "Travel"})
+
+		assert.ErrorIs(t, err, ErrTagExists)
+	})
+
+	t.Run("Personal tags of other users are not found", func(t *testing.T) {
+		mockTagRepo.On("GetTagByID", "tag-3").Return(&models.Tag{ID: "tag-3", UserID: strPtr("user-2"), Name: "acme"}, nil).Once()
+
+		_, err := tagService.RenameTag("tag-3", "user-1", &models.RenameTagRequest{Name: "client"})
+
+		assert.ErrorIs(t, err, ErrTagNotFound)
+	})
+
+	t.Run("Team members cannot rename team tags", func(t *testing.T) {
+		mockTagRepo.On("GetTagByID", "tag-4").Return(&models.Tag{ID: "tag-4", TeamID: strPtr("team-1"), Name: "offsite"}, nil).Once()
+		mockTeamRepo.On("GetTeamMember", "team-1", "bob").Return(teamMember("team-1", "bob", models.TeamRoleMember), nil).Once()
+
+		_, err := tagService.RenameTag("tag-4", "bob", &models.RenameTagRequest{Name: "retreat"})
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+	})
+}
+
+func TestTagService_MergeTag(t *testing.T) {
+	mockTagRepo := new(MockTagRepository)
+	tagService := NewTagService(mockTagRepo, new(MockTeamRepository))
+
+	t.Run("Merge into tag of the same user", func(t *testing.T) {
+		mockTagRepo.On("GetTagByID", "tag-1").Return(&models.Tag{ID: "tag-1", UserID: strPtr("user-1"), Name: "acme"}, nil).Once()
+		mockTagRepo.On("GetTagByID", "tag-2").Return(&models.Tag{ID: "tag-2", UserID: strPtr("user-1"), Name: "client-acme"}, nil).Once()
+		mockTagRepo.On("MergeTags", "tag-1", "tag-2").Return(nil).Once()
+
+		tag, err := tagService.MergeTag("tag-1", "user-1", &models.MergeTagRequest{IntoTagID: "tag-2"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "client-acme", tag.Name)
+		mockTagRepo.AssertExpectations(t)
+	})
+
+	t.Run("Tags of a team cannot be merged into personal tags", func(t *testing.T) {
+		mockTagRepo.On("GetTagByID", "tag-1").Return(&models.Tag{ID: "tag-1", UserID: strPtr("user-1"), Name: "acme"}, nil).Once()
+		mockTagRepo.On("GetTagByID", "tag-5").Return(&models.Tag{ID: "tag-5", TeamID: strPtr("team-1"), Name: "acme"}, nil).Once()
+
+		_, err := tagService.MergeTag("tag-1", "user-1", &models.MergeTagRequest{IntoTagID: "tag-5"})
+
+		assert.ErrorIs(t, err, ErrInvalidTag)
+	})
+
+	t.Run("Into itself", func(t *testing.T) {
+		mockTagRepo.On("GetTagByID", "tag-1").Return(&models.Tag{ID: "tag-1", UserID: strPtr("user-1"), Name: "acme"}, nil).Once()
+
+		_, err := tagService.MergeTag("tag-1", "user-1", &models.MergeTagRequest{IntoTagID: "tag-1"})
+
+		assert.ErrorIs(t, err, ErrInvalidTag)
+	})
+}
+
+func TestExpenseService_Tags(t *testing.T) {
+	mockExpenseRepo := new(MockExpenseRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	expenseService := NewExpenseService(mockExpenseRepo, usersWithBaseCurrency("USD"), mockTeamRepo, new(MockApprovalPolicyRepository), noExchangeRates(), noBudgets(), anyCategory(), nil)
+
+	t.Run("Create with tags", func(t *testing.T) {
+		mockExpenseRepo.On("CreateExpense", mock.MatchedBy(func(e *models.Expense) bool {
+			return assert.ObjectsAreEqual([]string{"client-acme", "conference-2026"}, e.Tags)
+		})).Return(nil).Once()
+
+		_, err := expenseService.CreateExpense("user-1", &models.CreateExpenseRequest{
+			Amount:      money.MustParse("120"),
+			Currency:    "USD",
+			Description: "Conference dinner",
+			Category:    "Food",
+			ExpenseDate: "2026-03-02",
+			Tags:        []string{"client-acme", "conference-2026", "Client-Acme"},
+		})
+
+		require.NoError(t, err)
+		mockExpenseRepo.AssertExpectations(t)
+	})
+
+	t.Run("Update with an empty list removes the tags", func(t *testing.T) {
+		mockExpenseRepo.On("GetExpenseByID", "exp-1").Return(&models.Expense{
+			ID: "exp-1", UserID: "user-1", Amount: money.MustParse("10"), Currency: "USD",
+			Category: "Food", ExpenseDate: "2026-03-02", Status: models.ExpenseStatusDraft, Tags: []string{"acme"},
+		}, nil).Once()
+		mockExpenseRepo.On("UpdateExpense", mock.MatchedBy(func(e *models.Expense) bool {
+			return e.Tags != nil && len(e.Tags) == 0
+		})).Return(nil).Once()
+
+		_, err := expenseService.UpdateExpense("exp-1", "user-1", &models.UpdateExpenseRequest{Tags: []string{}})
+
+		require.NoError(t, err)
+		mockExpenseRepo.AssertExpectations(t)
+	})
+}
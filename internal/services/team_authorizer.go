@@ -0,0 +1,96 @@
+package services
+
+import "pocketpilot/internal/models"
+
+// TeamPermission is an action a team member may be allowed to perform on team expenses
+type TeamPermission string
+
+const (
+    PermReadTeamExpenses   TeamPermission = "expenses:read"
+    PermCreateTeamExpense  TeamPermission = "expenses:create"
+    PermUpdateOwnExpense   TeamPermission = "expenses:update_own"
+    PermUpdateAnyExpense   TeamPermission = "expenses:update_any"
+    PermApproveTeamExpense TeamPermission = "expenses:approve"
+    PermDeleteOwnExpense   TeamPermission = "expenses:delete_own"
+    PermDeleteAnyExpense   TeamPermission = "expenses:delete_any"
+
+    PermManageApprovalPolicies TeamPermission = "approval_policies:manage"
+    PermManageBudgets          TeamPermission = "budgets:manage"
+    PermManageCategories       TeamPermission = "categories:manage"
+    PermManageTags             TeamPermission = "tags:manage"
+)
+
+// teamRolePermissions lists what each team role is allowed to do
+var teamRolePermissions = map[string][]TeamPermission{
+    models.TeamRoleOwner: {
+        PermReadTeamExpenses, PermCreateTeamExpense,
+        PermUpdateOwnExpense, PermUpdateAnyExpense,
+        PermApproveTeamExpense,
+        PermDeleteOwnExpense, PermDeleteAnyExpense,
+        PermManageApprovalPolicies,
+        PermManageBudgets,
+        PermManageCategories,
+        PermManageTags,
+    },
+    models.TeamRoleAdmin: {
+        PermReadTeamExpenses, PermCreateTeamExpense,
+        PermUpdateOwnExpense, PermUpdateAnyExpense,
+        PermApproveTeamExpense,
+        PermDeleteOwnExpense, PermDeleteAnyExpense,
+        PermManageApprovalPolicies,
+        PermManageBudgets,
+        PermManageCategories,
+        PermManageTags,
+    },
+    models.TeamRoleMember: {
+        PermReadTeamExpenses, PermCreateTeamExpense,
+        PermUpdateOwnExpense,
+        PermDeleteOwnExpense,
+    },
+    models.TeamRoleViewer: {
+        PermReadTeamExpenses,
+    },
+}
+
+// RoleHasPermission reports whether a team role grants the permission
+func RoleHasPermission(role string, perm TeamPermission) bool {
+    for _, p := range teamRolePermissions[role] {
+        if p == perm {
+            return true
+        }
+    }
+    return false
+}
+
+// TeamAuthorizer checks team permissions against team_members.role
+type TeamAuthorizer struct {
+    teamRepo TeamRepository
+}
+
+func NewTeamAuthorizer(teamRepo TeamRepository) *TeamAuthorizer {
+    return &TeamAuthorizer{teamRepo: teamRepo}
+}
+
+// Authorize returns the user's membership if their role in the team grants perm.
+// It fails with ErrTeamNotFound for unknown teams and ErrTeamAccessDenied otherwise.
+func (a *TeamAuthorizer) Authorize(teamID, userID string, perm TeamPermission) (*models.TeamMember, error) {
+    member, err := a.teamRepo.GetTeamMember(teamID, userID)
+    if err != nil {
+        return nil, err
+    }
+    if member == nil {
+        team, err := a.teamRepo.GetTeamByID(teamID)
+        if err != nil {
+            return nil, err
+        }
+        if team == nil {
+            return nil, ErrTeamNotFound
+        }
+        return nil, ErrTeamAccessDenied
+    }
+    if !RoleHasPermission(member.Role, perm) {
+        return nil, ErrTeamAccessDenied
+    }
+
+    return member, nil
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+    "pocketpilot/internal/models"
+    "pocketpilot/pkg/money"
+    "strings"
+)
+
+type TeamService struct {
+    teamRepo     TeamRepository
+    userRepo     UserRepository
+    restrictions EmailRestrictions
+}
+
+func NewTeamService(teamRepo TeamRepository, userRepo UserRepository, restrictions EmailRestrictions) *TeamService {
+    return &TeamService{
+        teamRepo:     teamRepo,
+        userRepo:     userRepo,
+        restrictions: restrictions,
+    }
+}
+
+// CreateTeam creates a team owned by the user
+func (s *TeamService) CreateTeam(userID string, req *models.CreateTeamRequest) (*models.Team, error) {
+    if err := s.restrictions.checkUser(s.userRepo, userID, RestrictionCreateTeams); err != nil {
+        return nil, err
+    }
+
+    baseCurrency := defaultBaseCurrency
+    if req.BaseCurrency != "" {
+        currency, err := money.NormalizeCurrency(req.BaseCurrency)
+        if err != nil {
+            return nil, ErrInvalidCurrency
+        }
+        baseCurrency = currency
+    }
+
+    team := &models.Team{
+        Name:         strings.TrimSpace(req.Name),
+        CreatedBy:    userID,
+        BaseCurrency: baseCurrency,
+    }
+
+    err := s.teamRepo.CreateTeam(team)
+    if err != nil {
+        return nil, err
+    }
+
+    return team, nil
+}
+
+// GetUserTeams retrieves all teams the user belongs to
+func (s *TeamService) GetUserTeams(userID string) ([]*models.Team, error) {
+    return s.teamRepo.GetTeamsByUser(userID)
+}
+
+// GetTeam retrieves a team the user is a member of
+func (s *TeamService) GetTeam(teamID, userID string) (*models.Team, error) {
+    member, err := s.membership(teamID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    team, err := s.teamRepo.GetTeamByID(teamID)
+    if err != nil {
+        return nil, err
+    }
+    if team == nil {
+        return nil, ErrTeamNotFound
+    }
+    team.Role = member.Role
+
+    return team, nil
+}
+
+// UpdateTeam renames a team, owners and admins only
+func (s *TeamService) UpdateTeam(teamID, userID string, req *models.UpdateTeamRequest) (*models.Team, error) {
+    member, err := s.membership(teamID, userID)
+    if err != nil {
+        return nil, err
+    }
+    if !hasTeamRole(member.Role, models.TeamRoleOwner, models.TeamRoleAdmin) {
+        return nil, ErrTeamAccessDenied
+    }
+
+    team, err := s.teamRepo.GetTeamByID(teamID)
+    if err != nil {
+        return nil, err
+    }
+    if team == nil {
+        return nil, ErrTeamNotFound
+    }
+
+    team.Name = strings.TrimSpace(req.Name)
+    if req.BaseCurrency != nil {
+        currency, err := money.NormalizeCurrency(*req.BaseCurrency)
+        if err != nil {
+            return nil, ErrInvalidCurrency
+        }
+        team.BaseCurrency = currency
+    }
+    err = s.teamRepo.UpdateTeam(team)
+    if err != nil {
+        return nil, err
+    }
+    team.Role = member.Role
+
+    return team, nil
+}
+
+// DeleteTeam deletes a team, owners only. Its expenses become personal, and
+// those waiting for approval or reimbursement go back to draft.
+func (s *TeamService) DeleteTeam(teamID, userID string) error {
+    member, err := s.membership(teamID, userID)
+    if err != nil {
+        return err
+    }
+    if member.Role != models.TeamRoleOwner {
+        return ErrTeamAccessDenied
+    }
+
+    return s.teamRepo.DeleteTeam(teamID, userID)
+}
+
+// GetTeamMembers lists the members of a team the user belongs to
+func (s *TeamService) GetTeamMembers(teamID, userID string) ([]*models.TeamMember, error) {
+    if _, err := s.membership(teamID, userID); err != nil {
+        return nil, err
+    }
+
+    return s.teamRepo.GetTeamMembers(teamID)
+}
+
+// AddTeamMember adds a registered user to the team by email.
+// Owners and admins can add members, only owners can add other owners.
+func (s *TeamService) AddTeamMember(teamID, userID string, req *models.AddTeamMemberRequest) (*models.TeamMember, error) {
+    member, err := s.membership(teamID, userID)
+    if err != nil {
+        return nil, err
+    }
+    if !hasTeamRole(member.Role, models.TeamRoleOwner, models.TeamRoleAdmin) {
+        return nil, ErrTeamAccessDenied
+    }
+
+    role := req.Role
+    if role == "" {
+        role = models.TeamRoleMember
+    }
+    if !models.IsValidTeamRole(role) {
+        return nil, ErrInvalidTeamRole
+    }
+    if role == models.TeamRoleOwner && member.Role != models.TeamRoleOwner {
+        return nil, ErrTeamAccessDenied
+    }
+
+    user, err := s.userRepo.GetUserByEmail(strings.TrimSpace(req.Email))
+    if err != nil {
+        return nil, err
+    }
+    if user == nil {
+        return nil, ErrUserNotFound
+    }
+    if s.restrictions.check(user, RestrictionJoinTeams) != nil {
+        return nil, ErrMemberEmailNotVerified
+    }
+
+    existing, err := s.teamRepo.GetTeamMember(teamID, user.ID)
+    if err != nil {
+        return nil, err
+    }
+    if existing != nil {
+        return nil, ErrAlreadyTeamMember
+    }
+
+    newMember := &models.TeamMember{
+        TeamID: teamID,
+        UserID: user.ID,
+        Role:   role,
+        User:   user,
+    }
+
+    err = s.teamRepo.AddTeamMember(newMember)
+    if err != nil {
+        return nil, err
+    }
+
+    return newMember, nil
+}
+
+// UpdateTeamMemberRole changes a member's role.
+// Owners and admins can change roles, only owners can grant or revoke ownership.
+func (s *TeamService) UpdateTeamMemberRole(teamID, userID, memberUserID string, req *models.UpdateTeamMemberRequest) (*models.TeamMember, error) {
+    member, err := s.membership(teamID, userID)
+    if err != nil {
+        return nil, err
+    }
+    if !hasTeamRole(member.Role, models.TeamRoleOwner, models.TeamRoleAdmin) {
+        return nil, ErrTeamAccessDenied
+    }
+    if !models.IsValidTeamRole(req.Role) {
+        return nil, ErrInvalidTeamRole
+    }
+
+    target, err := s.teamRepo.GetTeamMember(teamID, memberUserID)
+    if err != nil {
+        return nil, err
+    }
+    if target == nil {
+        return nil, ErrTeamMemberNotFound
+    }
+
+    if (req.Role == models.TeamRoleOwner || target.Role == models.TeamRoleOwner) && member.Role != models.TeamRoleOwner {
+        return nil, ErrTeamAccessDenied
+    }
+    if target.Role == models.TeamRoleOwner && req.Role != models.TeamRoleOwner {
+        if err := s.ensureAnotherOwner(teamID); err != nil {
+            return nil, err
+        }
+    }
+
+    err = s.teamRepo.UpdateTeamMemberRole(teamID, memberUserID, req.Role)
+    if err != nil {
+        return nil, err
+    }
+    target.Role = req.Role
+
+    return target, nil
+}
+
+// RemoveTeamMember removes a member from the team. Any member can leave,
+// owners and admins can remove others, only owners can remove owners.
+func (s *TeamService) RemoveTeamMember(teamID, userID, memberUserID string) error {
+    member, err := s.membership(teamID, userID)
+    if err != nil {
+        return err
+    }
+
+    target := member
+    if memberUserID != userID {
+        if !hasTeamRole(member.Role, models.TeamRoleOwner, models.TeamRoleAdmin) {
+            return ErrTeamAccessDenied
+        }
+
+        target, err = s.teamRepo.GetTeamMember(teamID, memberUserID)
+        if err != nil {
+            return err
+        }
+        if target == nil {
+            return ErrTeamMemberNotFound
+        }
+        if target.Role == models.TeamRoleOwner && member.Role != models.TeamRoleOwner {
+            return ErrTeamAccessDenied
+        }
+    }
+
+    if target.Role == models.TeamRoleOwner {
+        if err := s.ensureAnotherOwner(teamID); err != nil {
+            return err
+        }
+    }
+
+    return s.teamRepo.RemoveTeamMember(teamID, memberUserID)
+}
+
+// membership returns the user's membership, hiding teams they do not belong to
+func (s *TeamService) membership(teamID, userID string) (*models.TeamMember, error) {
+    member, err := s.teamRepo.GetTeamMember(teamID, userID)
+    if err != nil {
+        return nil, err
+    }
+    if member == nil {
+        return nil, ErrTeamNotFound
+    }
+
+    return member, nil
+}
+
+// ensureAnotherOwner fails if removing one owner would leave the team without any
+func (s *TeamService) ensureAnotherOwner(teamID string) error {
+    owners, err := s.teamRepo.CountTeamMembersByRole(teamID, models.TeamRoleOwner)
+    if err != nil {
+        return err
+    }
+    if owners <= 1 {
+        return ErrLastTeamOwner
+    }
+
+    return nil
+}
+
+func hasTeamRole(role string, allowed ...string) bool {
+    for _, r := range allowed {
+        if role == r {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"pocketpilot/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTeamRepository struct {
+	mock.Mock
+}
+
+func (m *MockTeamRepository) CreateTeam(team *models.Team) error {
+	args := m.Called(team)
+	return args.Error(0)
+}
+
+func (m *MockTeamRepository) GetTeamByID(id string) (*models.Team, error) {
+	args := m.Called(id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Team), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTeamRepository) GetTeamsByUser(userID string) ([]*models.Team, error) {
+	args := m.Called(userID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Team), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTeamRepository) UpdateTeam(team *models.Team) error {
+	args := m.Called(team)
+	return args.Error(0)
+}
+
+func (m *MockTeamRepository) DeleteTeam(id, actorID string) error {
+	args := m.Called(id, actorID)
+	return args.Error(0)
+}
+
+func (m *MockTeamRepository) GetTeamMember(teamID, userID string) (*models.TeamMember, error) {
+	args := m.Called(teamID, userID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.TeamMember), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTeamRepository) GetTeamMembers(teamID string) ([]*models.TeamMember, error) {
+	args := m.Called(teamID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.TeamMember), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTeamRepository) AddTeamMember(member *models.TeamMember) error {
+	args := m.Called(member)
+	return args.Error(0)
+}
+
+func (m *MockTeamRepository) UpdateTeamMemberRole(teamID, userID, role string) error {
+	args := m.Called(teamID, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockTeamRepository) RemoveTeamMember(teamID, userID string) error {
+	args := m.Called(teamID, userID)
+	return args.Error(0)
+}
+
+func (m *MockTeamRepository) CountTeamMembersByRole(teamID, role string) (int, error) {
+	args := m.Called(teamID, role)
+	return args.Int(0), args.Error(1)
+}
+
+func teamMember(teamID, userID, role string) *models.TeamMember {
+	return &models.TeamMember{ID: "member-" + userID, TeamID: teamID, UserID: userID, Role: role}
+}
+
+func TestTeamService_CreateTeam(t *testing.T) {
+	mockTeamRepo := new(MockTeamRepository)
+	teamService := NewTeamService(mockTeamRepo, new(MockUserRepository), nil)
+
+	mockTeamRepo.On("CreateTeam", mock.AnythingOfType("*models.Team")).Return(nil).Run(func(args mock.Arguments) {
+		team := args.Get(0).(*models.Team)
+		team.ID = "team-123"
+		team.Role = models.TeamRoleOwner
+	})
+
+	team, err := teamService.CreateTeam("user-123", &models.CreateTeamRequest{Name: "  Finance  "})
+
+	require.NoError(t, err)
+	assert.Equal(t, "team-123", team.ID)
+	assert.Equal(t, "Finance", team.Name)
+	assert.Equal(t, "user-123", team.CreatedBy)
+	assert.Equal(t, models.TeamRoleOwner, team.Role)
+	mockTeamRepo.AssertExpectations(t)
+}
+
+func TestTeamService_GetTeam(t *testing.T) {
+	mockTeamRepo := new(MockTeamRepository)
+	teamService := NewTeamService(mockTeamRepo, new(MockUserRepository), nil)
+
+	t.Run("Non-member cannot see team", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "outsider").Return(nil, nil)
+
+		team, err := teamService.GetTeam("team-1", "outsider")
+
+		assert.ErrorIs(t, err, ErrTeamNotFound)
+		assert.Nil(t, team)
+	})
+
+	t.Run("Member sees team with their role", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "viewer").Return(teamMember("team-1", "viewer", models.TeamRoleViewer), nil)
+		mockTeamRepo.On("GetTeamByID", "team-1").Return(&models.Team{ID: "team-1", Name: "Trip"}, nil)
+
+		team, err := teamService.GetTeam("team-1", "viewer")
+
+		require.NoError(t, err)
+		assert.Equal(t, "Trip", team.Name)
+		assert.Equal(t, models.TeamRoleViewer, team.Role)
+	})
+}
+
+func TestTeamService_AddTeamMember(t *testing.T) {
+	mockTeamRepo := new(MockTeamRepository)
+	mockUserRepo := new(MockUserRepository)
+	teamService := NewTeamService(mockTeamRepo, mockUserRepo, nil)
+
+	mockTeamRepo.On("GetTeamMember", "team-1", "owner").Return(teamMember("team-1", "owner", models.TeamRoleOwner), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "admin").Return(teamMember("team-1", "admin", models.TeamRoleAdmin), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "member").Return(teamMember("team-1", "member", models.TeamRoleMember), nil)
+
+	t.Run("Member cannot add members", func(t *testing.T) {
+		member, err := teamService.AddTeamMember("team-1", "member", &models.AddTeamMemberRequest{Email: "new@example.com"})
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+		assert.Nil(t, member)
+	})
+
+	t.Run("Admin cannot add owners", func(t *testing.T) {
+		member, err := teamService.AddTeamMember("team-1", "admin", &models.AddTeamMemberRequest{Email: "new@example.com", Role: models.TeamRoleOwner})
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+		assert.Nil(t, member)
+	})
+
+	t.Run("Invalid role", func(t *testing.T) {
+		member, err := teamService.AddTeamMember("team-1", "owner", &models.AddTeamMemberRequest{Email: "new@example.com", Role: "superuser"})
+
+		assert.ErrorIs(t, err, ErrInvalidTeamRole)
+		assert.Nil(t, member)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		mockUserRepo.On("GetUserByEmail", "ghost@example.com").Return(nil, nil)
+
+		member, err := teamService.AddTeamMember("team-1", "owner", &models.AddTeamMemberRequest{Email: "ghost@example.com"})
+
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		assert.Nil(t, member)
+	})
+
+	t.Run("Already a member", func(t *testing.T) {
+		mockUserRepo.On("GetUserByEmail", "member@example.com").Return(&models.User{ID: "member"}, nil)
+
+		member, err := teamService.AddTeamMember("team-1", "owner", &models.AddTeamMemberRequest{Email: "member@example.com"})
+
+		assert.ErrorIs(t, err, ErrAlreadyTeamMember)
+		assert.Nil(t, member)
+	})
+
+	t.Run("Admin adds member with default role", func(t *testing.T) {
+		mockUserRepo.On("GetUserByEmail", "new@example.com").Return(&models.User{ID: "new-user"}, nil)
+		mockTeamRepo.On("GetTeamMember", "team-1", "new-user").Return(nil, nil)
+		mockTeamRepo.On("AddTeamMember", mock.AnythingOfType("*models.TeamMember")).Return(nil)
+
+		member, err := teamService.AddTeamMember("team-1", "admin", &models.AddTeamMemberRequest{Email: "new@example.com"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "new-user", member.UserID)
+		assert.Equal(t, models.TeamRoleMember, member.Role)
+		mockTeamRepo.AssertExpectations(t)
+	})
+}
+
+func TestTeamService_UpdateTeamMemberRole(t *testing.T) {
+	mockTeamRepo := new(MockTeamRepository)
+	teamService := NewTeamService(mockTeamRepo, new(MockUserRepository), nil)
+
+	mockTeamRepo.On("GetTeamMember", "team-1", "owner").Return(teamMember("team-1", "owner", models.TeamRoleOwner), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "admin").Return(teamMember("team-1", "admin", models.TeamRoleAdmin), nil)
+
+	t.Run("Admin cannot demote owner", func(t *testing.T) {
+		member, err := teamService.UpdateTeamMemberRole("team-1", "admin", "owner", &models.UpdateTeamMemberRequest{Role: models.TeamRoleMember})
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+		assert.Nil(t, member)
+	})
+
+	t.Run("Last owner cannot step down", func(t *testing.T) {
+		mockTeamRepo.On("CountTeamMembersByRole", "team-1", models.TeamRoleOwner).Return(1, nil).Once()
+
+		member, err := teamService.UpdateTeamMemberRole("team-1", "owner", "owner", &models.UpdateTeamMemberRequest{Role: models.TeamRoleAdmin})
+
+		assert.ErrorIs(t, err, ErrLastTeamOwner)
+		assert.Nil(t, member)
+	})
+
+	t.Run("Owner promotes admin", func(t *testing.T) {
+		mockTeamRepo.On("UpdateTeamMemberRole", "team-1", "admin", models.TeamRoleOwner).Return(nil)
+
+		member, err := teamService.UpdateTeamMemberRole("team-1", "owner", "admin", &models.UpdateTeamMemberRequest{Role: models.TeamRoleOwner})
+
+		require.NoError(t, err)
+		assert.Equal(t, models.TeamRoleOwner, member.Role)
+	})
+}
+
+func TestTeamService_RemoveTeamMember(t *testing.T) {
+	mockTeamRepo := new(MockTeamRepository)
+	teamService := NewTeamService(mockTeamRepo, new(MockUserRepository), nil)
+
+	mockTeamRepo.On("GetTeamMember", "team-1", "owner").Return(teamMember("team-1", "owner", models.TeamRoleOwner), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "viewer").Return(teamMember("team-1", "viewer", models.TeamRoleViewer), nil)
+
+	t.Run("Viewer cannot remove others", func(t *testing.T) {
+		err := teamService.RemoveTeamMember("team-1", "viewer", "owner")
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+	})
+
+	t.Run("Viewer can leave", func(t *testing.T) {
+		mockTeamRepo.On("RemoveTeamMember", "team-1", "viewer").Return(nil).Once()
+
+		err := teamService.RemoveTeamMember("team-1", "viewer", "viewer")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("Last owner cannot leave", func(t *testing.T) {
+		mockTeamRepo.On("CountTeamMembersByRole", "team-1", models.TeamRoleOwner).Return(1, nil).Once()
+
+		err := teamService.RemoveTeamMember("team-1", "owner", "owner")
+
+		assert.ErrorIs(t, err, ErrLastTeamOwner)
+	})
+
+	t.Run("Unknown member", func(t *testing.T) {
+		mockTeamRepo.On("GetTeamMember", "team-1", "ghost").Return(nil, nil)
+
+		err := teamService.RemoveTeamMember("team-1", "owner", "ghost")
+
+		assert.ErrorIs(t, err, ErrTeamMemberNotFound)
+	})
+}
+
+func TestTeamService_DeleteTeam(t *testing.T) {
+	mockTeamRepo := new(MockTeamRepository)
+	teamService := NewTeamService(mockTeamRepo, new(MockUserRepository), nil)
+
+	mockTeamRepo.On("GetTeamMember", "team-1", "owner").Return(teamMember("team-1", "owner", models.TeamRoleOwner), nil)
+	mockTeamRepo.On("GetTeamMember", "team-1", "admin").Return(teamMember("team-1", "admin", models.TeamRoleAdmin), nil)
+
+	t.Run("Admin cannot delete", func(t *testing.T) {
+		err := teamService.DeleteTeam("team-1", "admin")
+
+		assert.ErrorIs(t, err, ErrTeamAccessDenied)
+		mockTeamRepo.AssertNotCalled(t, "DeleteTeam", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Owner deletes, recorded as the actor of reset expenses", func(t *testing.T) {
+		mockTeamRepo.On("DeleteTeam", "team-1", "owner").Return(nil).Once()
+
+		err := teamService.DeleteTeam("team-1", "owner")
+
+		assert.NoError(t, err)
+		mockTeamRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+    "context"
+    "log"
+    "time"
+)
+
+// TokenPruner periodically forgets expired refresh tokens and revocations of
+// access tokens that have expired anyway, which keeps the revocation check fast
+type TokenPruner struct {
+    authService *AuthService
+    interval    time.Duration
+}
+
+func NewTokenPruner(authService *AuthService, interval time.Duration) *TokenPruner {
+    return &TokenPruner{authService: authService, interval: interval}
+}
+
+// Run prunes now and then on every tick until ctx is done
+func (p *TokenPruner) Run(ctx context.Context) {
+    ticker := time.NewTicker(p.interval)
+    defer ticker.Stop()
+
+    for {
+        pruned, err := p.authService.PruneExpiredTokens()
+        if err != nil {
+            log.Printf("Token pruning: %v", err)
+        }
+        if pruned > 0 {
+            log.Printf("Token pruning: removed %d expired tokens", pruned)
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
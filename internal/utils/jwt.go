@@ -8,17 +8,21 @@ import (
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email string `json:"email"`
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID, email, secret string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
-
+// GenerateToken issues an access token for a session that expires at
+// expiresAt. tokenID becomes its jti, by which it can be revoked before it
+// expires.
+func GenerateToken(userID, email, tokenID, sessionID, secret string, expiresAt time.Time) (string, error) {
 	claims := &Claims {
 		UserID: userID,
 		Email: email,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-            ExpiresAt: jwt.NewNumericDate(expirationTime),
+            ID:        tokenID,
+            ExpiresAt: jwt.NewNumericDate(expiresAt),
             IssuedAt:  jwt.NewNumericDate(time.Now()),
             Issuer:    "pocket-pilot",
         },
@@ -33,7 +37,7 @@ func ValidateToken(tokenString, secret string) (*Claims, error) {
     
     token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
         return []byte(secret), nil
-    })
+    }, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
     
     if err != nil {
         return nil, err
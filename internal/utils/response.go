@@ -1,10 +1,20 @@
 package utils
 
 type APIResponse struct {
-    Success bool        `json:"success"`
-    Message string      `json:"message"`
-    Data    interface{} `json:"data,omitempty"`
-    Error   string      `json:"error,omitempty"`
+    Success    bool        `json:"success"`
+    Message    string      `json:"message"`
+    Data       interface{} `json:"data,omitempty"`
+    Pagination *Pagination `json:"pagination,omitempty"`
+    Error      string      `json:"error,omitempty"`
+}
+
+// Pagination describes where a page sits in a listing
+type Pagination struct {
+    Page       int    `json:"page,omitempty"` // only set for page-number pagination
+    Limit      int    `json:"limit"`
+    HasMore    bool   `json:"has_more"`
+    NextCursor string `json:"next_cursor,omitempty"`
+    Total      *int   `json:"total,omitempty"`
 }
 
 func SuccessResponse(message string, data interface{}) APIResponse {
@@ -15,9 +25,18 @@ func SuccessResponse(message string, data interface{}) APIResponse {
     }
 }
 
+func PaginatedResponse(message string, data interface{}, pagination *Pagination) APIResponse {
+    return APIResponse{
+        Success:    true,
+        Message:    message,
+        Data:       data,
+        Pagination: pagination,
+    }
+}
+
 func ErrorResponse(message string) APIResponse {
     return APIResponse{
         Success: false,
         Error:   message,
     }
-}
\ No newline at end of file
+}
@@ -0,0 +1,46 @@
+--
+-- Expense approval workflow: draft -> submitted -> approved/rejected -> reimbursed
+--
+
+-- Existing pending team expenses are waiting for approval, personal ones become drafts
+UPDATE public.expenses SET status = 'submitted' WHERE status = 'pending' AND team_id IS NOT NULL;
+UPDATE public.expenses SET status = 'draft' WHERE status = 'pending';
+
+ALTER TABLE public.expenses ALTER COLUMN status SET DEFAULT 'draft';
+ALTER TABLE public.expenses ALTER COLUMN status SET NOT NULL;
+ALTER TABLE public.expenses
+    ADD CONSTRAINT expenses_status_check CHECK (status IN ('draft', 'submitted', 'approved', 'rejected', 'reimbursed'));
+
+CREATE TABLE public.expense_transitions (
+    id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    expense_id uuid NOT NULL,
+    from_status character varying(50) NOT NULL,
+    to_status character varying(50) NOT NULL,
+    actor_id uuid NOT NULL,
+    reason text,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE ONLY public.expense_transitions
+    ADD CONSTRAINT expense_transitions_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.expense_transitions
+    ADD CONSTRAINT expense_transitions_expense_id_fkey FOREIGN KEY (expense_id) REFERENCES public.expenses(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.expense_transitions
+    ADD CONSTRAINT expense_transitions_actor_id_fkey FOREIGN KEY (actor_id) REFERENCES public.users(id);
+
+CREATE INDEX idx_expense_transitions_expense_id ON public.expense_transitions USING btree (expense_id, created_at);
+
+-- The history is append-only
+CREATE FUNCTION public.expense_transitions_immutable() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    RAISE EXCEPTION 'expense_transitions is append-only';
+END;
+$$;
+
+CREATE TRIGGER expense_transitions_no_update
+    BEFORE UPDATE ON public.expense_transitions
+    FOR EACH ROW EXECUTE FUNCTION public.expense_transitions_immutable();
@@ -0,0 +1,53 @@
+--
+-- Multi-level approval chains configurable per team
+--
+
+CREATE TABLE public.approval_policies (
+    id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    team_id uuid NOT NULL,
+    name character varying(255) NOT NULL,
+    min_amount numeric(10,2),
+    categories text[] DEFAULT '{}'::text[] NOT NULL,
+    priority integer DEFAULT 0 NOT NULL,
+    steps jsonb NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE ONLY public.approval_policies
+    ADD CONSTRAINT approval_policies_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.approval_policies
+    ADD CONSTRAINT approval_policies_team_id_fkey FOREIGN KEY (team_id) REFERENCES public.teams(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_approval_policies_team_id ON public.approval_policies USING btree (team_id, priority);
+
+CREATE TABLE public.expense_approval_steps (
+    id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    expense_id uuid NOT NULL,
+    round integer NOT NULL,
+    step_order integer NOT NULL,
+    approver_role character varying(50),
+    approver_user_id uuid,
+    status character varying(50) DEFAULT 'pending' NOT NULL,
+    decided_by uuid,
+    decided_at timestamp with time zone,
+    comment text,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT expense_approval_steps_approver_check CHECK ((approver_role IS NULL) <> (approver_user_id IS NULL))
+);
+
+ALTER TABLE ONLY public.expense_approval_steps
+    ADD CONSTRAINT expense_approval_steps_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.expense_approval_steps
+    ADD CONSTRAINT expense_approval_steps_expense_id_round_step_order_key UNIQUE (expense_id, round, step_order);
+
+ALTER TABLE ONLY public.expense_approval_steps
+    ADD CONSTRAINT expense_approval_steps_expense_id_fkey FOREIGN KEY (expense_id) REFERENCES public.expenses(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.expense_approval_steps
+    ADD CONSTRAINT expense_approval_steps_approver_user_id_fkey FOREIGN KEY (approver_user_id) REFERENCES public.users(id);
+
+ALTER TABLE ONLY public.expense_approval_steps
+    ADD CONSTRAINT expense_approval_steps_decided_by_fkey FOREIGN KEY (decided_by) REFERENCES public.users(id);
@@ -0,0 +1,7 @@
+--
+-- Indexes backing keyset pagination of expense listings on (expense_date, created_at, id)
+--
+
+CREATE INDEX idx_expenses_user_keyset ON public.expenses USING btree (user_id, expense_date DESC, created_at DESC, id DESC);
+
+CREATE INDEX idx_expenses_team_keyset ON public.expenses USING btree (team_id, expense_date DESC, created_at DESC, id DESC);
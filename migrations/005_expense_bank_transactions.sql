@@ -0,0 +1,7 @@
+--
+-- Bank transaction IDs of expenses imported from statements, so a statement can be imported again without duplicates
+--
+
+ALTER TABLE public.expenses ADD COLUMN bank_transaction_id character varying(255);
+
+CREATE UNIQUE INDEX idx_expenses_user_bank_transaction ON public.expenses USING btree (user_id, bank_transaction_id) WHERE (bank_transaction_id IS NOT NULL);
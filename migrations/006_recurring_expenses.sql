@@ -0,0 +1,50 @@
+--
+-- Recurring expense templates and the expenses the scheduler creates from them
+--
+
+CREATE TABLE public.recurring_expenses (
+    id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    user_id uuid NOT NULL,
+    team_id uuid,
+    amount numeric(10,2) NOT NULL,
+    currency character varying(3) DEFAULT 'USD'::character varying NOT NULL,
+    description text NOT NULL,
+    category character varying(100) NOT NULL,
+    frequency character varying(20) NOT NULL,
+    "interval" integer DEFAULT 1 NOT NULL,
+    start_date date NOT NULL,
+    end_date date,
+    max_occurrences integer,
+    occurrence_count integer DEFAULT 0 NOT NULL,
+    next_occurrence date,
+    active boolean DEFAULT true NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT recurring_expenses_frequency_check CHECK (((frequency)::text = ANY ((ARRAY['daily'::character varying, 'weekly'::character varying, 'monthly'::character varying, 'yearly'::character varying])::text[]))),
+    CONSTRAINT recurring_expenses_interval_check CHECK ("interval" >= 1),
+    CONSTRAINT recurring_expenses_max_occurrences_check CHECK (max_occurrences IS NULL OR max_occurrences >= 1)
+);
+
+ALTER TABLE ONLY public.recurring_expenses
+    ADD CONSTRAINT recurring_expenses_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.recurring_expenses
+    ADD CONSTRAINT recurring_expenses_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.recurring_expenses
+    ADD CONSTRAINT recurring_expenses_team_id_fkey FOREIGN KEY (team_id) REFERENCES public.teams(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_recurring_expenses_user_id ON public.recurring_expenses USING btree (user_id);
+
+-- The scheduler's lookup of due templates
+CREATE INDEX idx_recurring_expenses_due ON public.recurring_expenses USING btree (next_occurrence) WHERE active;
+
+ALTER TABLE public.expenses ADD COLUMN recurring_expense_id uuid;
+
+ALTER TABLE public.expenses ADD COLUMN occurrence_date date;
+
+ALTER TABLE ONLY public.expenses
+    ADD CONSTRAINT expenses_recurring_expense_id_fkey FOREIGN KEY (recurring_expense_id) REFERENCES public.recurring_expenses(id) ON DELETE SET NULL;
+
+-- Each occurrence of a template becomes at most one expense
+CREATE UNIQUE INDEX idx_expenses_recurring_occurrence ON public.expenses USING btree (recurring_expense_id, occurrence_date) WHERE (recurring_expense_id IS NOT NULL);
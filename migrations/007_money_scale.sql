@@ -0,0 +1,9 @@
+--
+-- Amounts keep three decimals so currencies like KWD and BHD fit exactly
+--
+
+ALTER TABLE public.expenses ALTER COLUMN amount TYPE numeric(15,3);
+
+ALTER TABLE public.recurring_expenses ALTER COLUMN amount TYPE numeric(15,3);
+
+ALTER TABLE public.approval_policies ALTER COLUMN min_amount TYPE numeric(15,3);
@@ -0,0 +1,29 @@
+--
+-- Exchange rates, base currencies of users and teams, and the rate each expense was recorded with
+--
+
+CREATE TABLE public.exchange_rates (
+    base_currency character varying(3) NOT NULL,
+    currency character varying(3) NOT NULL,
+    rate_date date NOT NULL,
+    rate numeric(20,10) NOT NULL,
+    source character varying(20) DEFAULT 'manual'::character varying NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT exchange_rates_rate_check CHECK (rate > (0)::numeric)
+);
+
+ALTER TABLE ONLY public.exchange_rates
+    ADD CONSTRAINT exchange_rates_pkey PRIMARY KEY (base_currency, currency, rate_date);
+
+-- Lookups of the latest rate on or before a date
+CREATE INDEX idx_exchange_rates_date ON public.exchange_rates USING btree (rate_date DESC);
+
+ALTER TABLE public.users ADD COLUMN base_currency character varying(3) DEFAULT 'USD'::character varying NOT NULL;
+
+ALTER TABLE public.teams ADD COLUMN base_currency character varying(3) DEFAULT 'USD'::character varying NOT NULL;
+
+-- The rate from the expense's currency to its owner's base currency on the expense date
+ALTER TABLE public.expenses ADD COLUMN base_currency character varying(3);
+
+ALTER TABLE public.expenses ADD COLUMN exchange_rate numeric(20,10);
@@ -0,0 +1,61 @@
+--
+-- Team expenses split among members, and the payments that settle what they owe each other
+--
+
+ALTER TABLE public.expenses ADD COLUMN split_method character varying(20);
+
+ALTER TABLE public.expenses
+    ADD CONSTRAINT expenses_split_method_check CHECK (split_method IS NULL OR ((split_method)::text = ANY ((ARRAY['equal'::character varying, 'exact'::character varying, 'percentage'::character varying, 'shares'::character varying])::text[])));
+
+CREATE TABLE public.expense_splits (
+    expense_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    amount numeric(15,3) NOT NULL,
+    percentage numeric(6,3),
+    shares integer,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT expense_splits_amount_check CHECK (amount >= (0)::numeric),
+    CONSTRAINT expense_splits_shares_check CHECK (shares IS NULL OR shares >= 1)
+);
+
+ALTER TABLE ONLY public.expense_splits
+    ADD CONSTRAINT expense_splits_pkey PRIMARY KEY (expense_id, user_id);
+
+ALTER TABLE ONLY public.expense_splits
+    ADD CONSTRAINT expense_splits_expense_id_fkey FOREIGN KEY (expense_id) REFERENCES public.expenses(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.expense_splits
+    ADD CONSTRAINT expense_splits_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_expense_splits_user_id ON public.expense_splits USING btree (user_id);
+
+CREATE TABLE public.settlements (
+    id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    team_id uuid NOT NULL,
+    from_user_id uuid NOT NULL,
+    to_user_id uuid NOT NULL,
+    amount numeric(15,3) NOT NULL,
+    currency character varying(3) NOT NULL,
+    note text,
+    created_by uuid NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT settlements_amount_check CHECK (amount > (0)::numeric),
+    CONSTRAINT settlements_users_check CHECK (from_user_id <> to_user_id)
+);
+
+ALTER TABLE ONLY public.settlements
+    ADD CONSTRAINT settlements_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.settlements
+    ADD CONSTRAINT settlements_team_id_fkey FOREIGN KEY (team_id) REFERENCES public.teams(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.settlements
+    ADD CONSTRAINT settlements_from_user_id_fkey FOREIGN KEY (from_user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.settlements
+    ADD CONSTRAINT settlements_to_user_id_fkey FOREIGN KEY (to_user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.settlements
+    ADD CONSTRAINT settlements_created_by_fkey FOREIGN KEY (created_by) REFERENCES public.users(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_settlements_team_id ON public.settlements USING btree (team_id, created_at DESC);
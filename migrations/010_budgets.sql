@@ -0,0 +1,56 @@
+--
+-- Spending limits per user or team, overall or per category, and the alerts raised as they fill up
+--
+
+CREATE TABLE public.budgets (
+    id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    user_id uuid NOT NULL,
+    team_id uuid,
+    category character varying(100),
+    amount numeric(15,3) NOT NULL,
+    currency character varying(3) NOT NULL,
+    period character varying(20) NOT NULL,
+    start_date date NOT NULL,
+    rollover boolean DEFAULT false NOT NULL,
+    thresholds integer[] DEFAULT '{80,100}'::integer[] NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT budgets_amount_check CHECK (amount > (0)::numeric),
+    CONSTRAINT budgets_period_check CHECK (((period)::text = ANY ((ARRAY['monthly'::character varying, 'quarterly'::character varying, 'yearly'::character varying])::text[])))
+);
+
+ALTER TABLE ONLY public.budgets
+    ADD CONSTRAINT budgets_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.budgets
+    ADD CONSTRAINT budgets_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.budgets
+    ADD CONSTRAINT budgets_team_id_fkey FOREIGN KEY (team_id) REFERENCES public.teams(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_budgets_user_id ON public.budgets USING btree (user_id) WHERE (team_id IS NULL);
+
+CREATE INDEX idx_budgets_team_id ON public.budgets USING btree (team_id) WHERE (team_id IS NOT NULL);
+
+CREATE TABLE public.budget_alerts (
+    id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    budget_id uuid NOT NULL,
+    period_start date NOT NULL,
+    threshold integer NOT NULL,
+    spent numeric(15,3) NOT NULL,
+    available numeric(15,3) NOT NULL,
+    expense_id uuid,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE ONLY public.budget_alerts
+    ADD CONSTRAINT budget_alerts_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.budget_alerts
+    ADD CONSTRAINT budget_alerts_budget_id_fkey FOREIGN KEY (budget_id) REFERENCES public.budgets(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.budget_alerts
+    ADD CONSTRAINT budget_alerts_expense_id_fkey FOREIGN KEY (expense_id) REFERENCES public.expenses(id) ON DELETE SET NULL;
+
+-- Each threshold alerts once per budget period
+CREATE UNIQUE INDEX idx_budget_alerts_once ON public.budget_alerts USING btree (budget_id, period_start, threshold);
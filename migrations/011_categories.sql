@@ -0,0 +1,129 @@
+--
+-- Categories become rows: system defaults everyone sees, plus custom ones per user or team
+--
+
+CREATE TABLE public.categories (
+    id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    user_id uuid,
+    team_id uuid,
+    parent_id uuid,
+    name character varying(100) NOT NULL,
+    icon character varying(50),
+    color character varying(7),
+    archived_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT categories_scope_check CHECK (((user_id IS NULL) OR (team_id IS NULL))),
+    CONSTRAINT categories_color_check CHECK (((color IS NULL) OR ((color)::text ~ '^#[0-9A-Fa-f]{6}$'::text)))
+);
+
+ALTER TABLE ONLY public.categories
+    ADD CONSTRAINT categories_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.categories
+    ADD CONSTRAINT categories_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.categories
+    ADD CONSTRAINT categories_team_id_fkey FOREIGN KEY (team_id) REFERENCES public.teams(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.categories
+    ADD CONSTRAINT categories_parent_id_fkey FOREIGN KEY (parent_id) REFERENCES public.categories(id) ON DELETE SET NULL;
+
+-- Names are unique, ignoring case, among system categories and within each user and team
+CREATE UNIQUE INDEX idx_categories_system_name ON public.categories USING btree (lower((name)::text)) WHERE ((user_id IS NULL) AND (team_id IS NULL));
+
+CREATE UNIQUE INDEX idx_categories_user_name ON public.categories USING btree (user_id, lower((name)::text)) WHERE (user_id IS NOT NULL);
+
+CREATE UNIQUE INDEX idx_categories_team_name ON public.categories USING btree (team_id, lower((name)::text)) WHERE (team_id IS NOT NULL);
+
+INSERT INTO public.categories (name, icon, color) VALUES
+    ('Food', 'utensils', '#F97316'),
+    ('Groceries', 'shopping-cart', '#84CC16'),
+    ('Transport', 'car', '#3B82F6'),
+    ('Travel', 'plane', '#0EA5E9'),
+    ('Lodging', 'bed', '#6366F1'),
+    ('Rent', 'home', '#8B5CF6'),
+    ('Utilities', 'bolt', '#EAB308'),
+    ('Health', 'heart', '#EF4444'),
+    ('Entertainment', 'film', '#EC4899'),
+    ('Shopping', 'bag', '#14B8A6'),
+    ('Office', 'briefcase', '#64748B'),
+    ('Software', 'laptop', '#06B6D4'),
+    ('Education', 'book', '#A855F7'),
+    ('Fees', 'receipt', '#78716C'),
+    ('Uncategorized', 'tag', '#9CA3AF');
+
+ALTER TABLE public.expenses ADD COLUMN category_id uuid;
+
+ALTER TABLE ONLY public.expenses
+    ADD CONSTRAINT expenses_category_id_fkey FOREIGN KEY (category_id) REFERENCES public.categories(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_expenses_category_id ON public.expenses USING btree (category_id);
+
+ALTER TABLE public.recurring_expenses ADD COLUMN category_id uuid;
+
+ALTER TABLE ONLY public.recurring_expenses
+    ADD CONSTRAINT recurring_expenses_category_id_fkey FOREIGN KEY (category_id) REFERENCES public.categories(id) ON DELETE SET NULL;
+
+--
+-- Map the free-text categories. Values matching a system category, ignoring case
+-- and surrounding spaces, join it; the rest become custom categories of the user
+-- or team that used them, spelled as first used.
+--
+
+UPDATE public.expenses SET category = COALESCE(NULLIF(btrim((category)::text), ''), 'Uncategorized');
+
+UPDATE public.recurring_expenses SET category = COALESCE(NULLIF(btrim((category)::text), ''), 'Uncategorized');
+
+UPDATE public.budgets SET category = NULLIF(btrim((category)::text), '');
+
+INSERT INTO public.categories (user_id, name)
+SELECT DISTINCT ON (used.user_id, lower(used.category)) used.user_id, used.category
+FROM (
+    SELECT user_id, category, created_at FROM public.expenses WHERE team_id IS NULL AND user_id IS NOT NULL
+    UNION ALL
+    SELECT user_id, category, created_at FROM public.recurring_expenses WHERE team_id IS NULL
+    UNION ALL
+    SELECT user_id, category, created_at FROM public.budgets WHERE team_id IS NULL AND category IS NOT NULL
+) used
+WHERE NOT EXISTS (
+    SELECT 1 FROM public.categories c
+    WHERE c.user_id IS NULL AND c.team_id IS NULL AND lower((c.name)::text) = lower((used.category)::text)
+)
+ORDER BY used.user_id, lower(used.category), used.created_at;
+
+INSERT INTO public.categories (team_id, name)
+SELECT DISTINCT ON (used.team_id, lower(used.category)) used.team_id, used.category
+FROM (
+    SELECT team_id, category, created_at FROM public.expenses WHERE team_id IS NOT NULL
+    UNION ALL
+    SELECT team_id, category, created_at FROM public.recurring_expenses WHERE team_id IS NOT NULL
+    UNION ALL
+    SELECT team_id, category, created_at FROM public.budgets WHERE team_id IS NOT NULL AND category IS NOT NULL
+) used
+WHERE NOT EXISTS (
+    SELECT 1 FROM public.categories c
+    WHERE c.user_id IS NULL AND c.team_id IS NULL AND lower((c.name)::text) = lower((used.category)::text)
+)
+ORDER BY used.team_id, lower(used.category), used.created_at;
+
+UPDATE public.expenses e SET category_id = c.id, category = c.name
+FROM public.categories c
+WHERE lower((c.name)::text) = lower((e.category)::text)
+  AND ((c.user_id IS NULL AND c.team_id IS NULL)
+       OR (e.team_id IS NULL AND c.user_id = e.user_id)
+       OR (c.team_id = e.team_id));
+
+UPDATE public.recurring_expenses r SET category_id = c.id, category = c.name
+FROM public.categories c
+WHERE lower((c.name)::text) = lower((r.category)::text)
+  AND ((c.user_id IS NULL AND c.team_id IS NULL)
+       OR (r.team_id IS NULL AND c.user_id = r.user_id)
+       OR (c.team_id = r.team_id));
+
+UPDATE public.budgets b SET category = c.name
+FROM public.categories c
+WHERE lower((c.name)::text) = lower((b.category)::text)
+  AND ((c.user_id IS NULL AND c.team_id IS NULL)
+       OR (b.team_id IS NULL AND c.user_id = b.user_id)
+       OR (c.team_id = b.team_id));
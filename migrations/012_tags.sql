@@ -0,0 +1,43 @@
+--
+-- Free-form tags on expenses, owned by a user for personal expenses or by a team for team expenses
+--
+
+CREATE TABLE public.tags (
+    id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    user_id uuid,
+    team_id uuid,
+    name character varying(50) NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT tags_scope_check CHECK (((user_id IS NULL) <> (team_id IS NULL)))
+);
+
+ALTER TABLE ONLY public.tags
+    ADD CONSTRAINT tags_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.tags
+    ADD CONSTRAINT tags_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.tags
+    ADD CONSTRAINT tags_team_id_fkey FOREIGN KEY (team_id) REFERENCES public.teams(id) ON DELETE CASCADE;
+
+-- Names are unique, ignoring case, within each user and team
+CREATE UNIQUE INDEX idx_tags_user_name ON public.tags USING btree (user_id, lower((name)::text)) WHERE (user_id IS NOT NULL);
+
+CREATE UNIQUE INDEX idx_tags_team_name ON public.tags USING btree (team_id, lower((name)::text)) WHERE (team_id IS NOT NULL);
+
+CREATE TABLE public.expense_tags (
+    expense_id uuid NOT NULL,
+    tag_id uuid NOT NULL
+);
+
+ALTER TABLE ONLY public.expense_tags
+    ADD CONSTRAINT expense_tags_pkey PRIMARY KEY (expense_id, tag_id);
+
+ALTER TABLE ONLY public.expense_tags
+    ADD CONSTRAINT expense_tags_expense_id_fkey FOREIGN KEY (expense_id) REFERENCES public.expenses(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.expense_tags
+    ADD CONSTRAINT expense_tags_tag_id_fkey FOREIGN KEY (tag_id) REFERENCES public.tags(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_expense_tags_tag_id ON public.expense_tags USING btree (tag_id);
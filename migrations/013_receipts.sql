@@ -0,0 +1,40 @@
+--
+-- Receipt files uploaded for expenses; the files themselves live in the configured storage backend.
+-- The receipts table comes from the initial schema, where it only held OCR data. Rows from then
+-- have no file: they keep a legacy/ storage key that no upload uses and an empty size.
+--
+
+DELETE FROM public.receipts WHERE expense_id IS NULL;
+
+ALTER TABLE public.receipts
+    ALTER COLUMN expense_id SET NOT NULL,
+    ADD COLUMN user_id uuid,
+    ADD COLUMN storage_key character varying(255),
+    ADD COLUMN file_name character varying(255),
+    ADD COLUMN content_type character varying(100),
+    ADD COLUMN size_bytes bigint;
+
+UPDATE public.receipts r
+SET user_id = e.user_id,
+    storage_key = 'legacy/' || r.id::text,
+    file_name = 'receipt',
+    content_type = 'application/octet-stream',
+    size_bytes = 0
+FROM public.expenses e
+WHERE e.id = r.expense_id;
+
+DELETE FROM public.receipts WHERE user_id IS NULL;
+
+ALTER TABLE public.receipts
+    ALTER COLUMN user_id SET NOT NULL,
+    ALTER COLUMN storage_key SET NOT NULL,
+    ALTER COLUMN file_name SET NOT NULL,
+    ALTER COLUMN content_type SET NOT NULL,
+    ALTER COLUMN size_bytes SET NOT NULL,
+    ADD CONSTRAINT receipts_size_bytes_check CHECK (((size_bytes > 0) OR ((storage_key)::text ~~ 'legacy/%'::text)));
+
+ALTER TABLE ONLY public.receipts
+    ADD CONSTRAINT receipts_storage_key_key UNIQUE (storage_key);
+
+ALTER TABLE ONLY public.receipts
+    ADD CONSTRAINT receipts_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
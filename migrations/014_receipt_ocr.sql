@@ -0,0 +1,24 @@
+--
+-- Text recognized on receipts and the fields parsed from it. merchant_name, total_amount,
+-- tax_amount, transaction_date and ocr_raw_data come from the initial schema.
+--
+
+ALTER TABLE public.receipts
+    ADD COLUMN ocr_status character varying(20) DEFAULT 'pending'::character varying NOT NULL,
+    ADD COLUMN ocr_error text,
+    ADD COLUMN ocr_started_at timestamp with time zone,
+    ADD COLUMN currency character varying(3),
+    ADD COLUMN scanned_at timestamp with time zone,
+    ALTER COLUMN total_amount TYPE numeric(15,3),
+    ALTER COLUMN tax_amount TYPE numeric(15,3),
+    ALTER COLUMN transaction_date TYPE date USING transaction_date::date;
+
+ALTER TABLE public.receipts
+    ADD CONSTRAINT receipts_ocr_status_check CHECK (((ocr_status)::text = ANY ((ARRAY['pending'::character varying, 'processing'::character varying, 'completed'::character varying, 'failed'::character varying, 'skipped'::character varying])::text[])));
+
+-- Receipts from before uploads have no file to scan; keep what the old OCR found
+UPDATE public.receipts
+SET ocr_status = 'completed', scanned_at = created_at
+WHERE (storage_key)::text ~~ 'legacy/%'::text;
+
+CREATE INDEX idx_receipts_ocr_pending ON public.receipts USING btree (created_at) WHERE ((ocr_status)::text = ANY ((ARRAY['pending'::character varying, 'processing'::character varying])::text[]));
@@ -0,0 +1,24 @@
+--
+-- Images derived from receipts: thumbnails, and the first page of PDF receipts
+--
+
+CREATE TABLE public.receipt_variants (
+    receipt_id uuid NOT NULL,
+    name character varying(20) NOT NULL,
+    storage_key character varying(255) NOT NULL,
+    content_type character varying(100) NOT NULL,
+    width integer NOT NULL,
+    height integer NOT NULL,
+    size_bytes bigint NOT NULL,
+    CONSTRAINT receipt_variants_dimensions_check CHECK (((width > 0) AND (height > 0))),
+    CONSTRAINT receipt_variants_size_bytes_check CHECK ((size_bytes > 0))
+);
+
+ALTER TABLE ONLY public.receipt_variants
+    ADD CONSTRAINT receipt_variants_pkey PRIMARY KEY (receipt_id, name);
+
+ALTER TABLE ONLY public.receipt_variants
+    ADD CONSTRAINT receipt_variants_storage_key_key UNIQUE (storage_key);
+
+ALTER TABLE ONLY public.receipt_variants
+    ADD CONSTRAINT receipt_variants_receipt_id_fkey FOREIGN KEY (receipt_id) REFERENCES public.receipts(id) ON DELETE CASCADE;
@@ -0,0 +1,49 @@
+--
+-- Refresh tokens, stored as SHA-256 hashes. Each login starts a family; every
+-- refresh rotates the token within it, and presenting a rotated token again
+-- revokes the whole family.
+--
+
+CREATE TABLE public.refresh_tokens (
+    id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    user_id uuid NOT NULL,
+    family_id uuid NOT NULL,
+    token_hash character(64) NOT NULL,
+    access_token_id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    access_expires_at timestamp with time zone NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    rotated_at timestamp with time zone,
+    revoked_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE ONLY public.refresh_tokens
+    ADD CONSTRAINT refresh_tokens_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.refresh_tokens
+    ADD CONSTRAINT refresh_tokens_token_hash_key UNIQUE (token_hash);
+
+ALTER TABLE ONLY public.refresh_tokens
+    ADD CONSTRAINT refresh_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_refresh_tokens_family_id ON public.refresh_tokens USING btree (family_id);
+
+CREATE INDEX idx_refresh_tokens_access_token_id ON public.refresh_tokens USING btree (access_token_id);
+
+CREATE INDEX idx_refresh_tokens_expires_at ON public.refresh_tokens USING btree (expires_at);
+
+--
+-- Access tokens revoked before they expire, by their jti claim. Rows can go
+-- once the token has expired anyway.
+--
+
+CREATE TABLE public.revoked_access_tokens (
+    jti uuid NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    revoked_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE ONLY public.revoked_access_tokens
+    ADD CONSTRAINT revoked_access_tokens_pkey PRIMARY KEY (jti);
+
+CREATE INDEX idx_revoked_access_tokens_expires_at ON public.revoked_access_tokens USING btree (expires_at);
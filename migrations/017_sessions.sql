@@ -0,0 +1,40 @@
+--
+-- Sessions: one per login on a device. The refresh tokens of a login, until
+-- now grouped by family_id, belong to its session.
+--
+
+CREATE TABLE public.sessions (
+    id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    user_id uuid NOT NULL,
+    client_name character varying(100),
+    user_agent character varying(255),
+    ip_address character varying(45),
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    revoked_at timestamp with time zone
+);
+
+ALTER TABLE ONLY public.sessions
+    ADD CONSTRAINT sessions_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.sessions
+    ADD CONSTRAINT sessions_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_sessions_user_id ON public.sessions USING btree (user_id);
+
+CREATE INDEX idx_sessions_expires_at ON public.sessions USING btree (expires_at);
+
+-- Logins from before sessions existed become sessions without device details
+INSERT INTO public.sessions (id, user_id, created_at, last_seen_at, expires_at, revoked_at)
+SELECT family_id, user_id, min(created_at), max(created_at), max(expires_at),
+    CASE WHEN bool_and(revoked_at IS NOT NULL) THEN max(revoked_at) END
+FROM public.refresh_tokens
+GROUP BY family_id, user_id;
+
+ALTER TABLE public.refresh_tokens RENAME COLUMN family_id TO session_id;
+
+ALTER INDEX public.idx_refresh_tokens_family_id RENAME TO idx_refresh_tokens_session_id;
+
+ALTER TABLE ONLY public.refresh_tokens
+    ADD CONSTRAINT refresh_tokens_session_id_fkey FOREIGN KEY (session_id) REFERENCES public.sessions(id) ON DELETE CASCADE;
@@ -0,0 +1,10 @@
+--
+-- Email verification. Accounts created before it existed count as verified,
+-- so the restrictions on unverified users do not lock them out.
+--
+
+ALTER TABLE public.users
+    ADD COLUMN email_verified_at timestamp with time zone,
+    ADD COLUMN email_verification_sent_at timestamp with time zone;
+
+UPDATE public.users SET email_verified_at = created_at;
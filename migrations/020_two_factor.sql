@@ -0,0 +1,57 @@
+--
+-- TOTP two-factor authentication. totp_secret is set when enrollment starts
+-- and only counts once totp_enabled_at is set; totp_last_step keeps a code
+-- from being used twice.
+--
+
+ALTER TABLE public.users
+    ADD COLUMN totp_secret character varying(64),
+    ADD COLUMN totp_enabled_at timestamp with time zone,
+    ADD COLUMN totp_last_step bigint;
+
+--
+-- One-time recovery codes for when the authenticator is lost, stored as
+-- SHA-256 hashes
+--
+
+CREATE TABLE public.recovery_codes (
+    id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    user_id uuid NOT NULL,
+    code_hash character(64) NOT NULL,
+    used_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE ONLY public.recovery_codes
+    ADD CONSTRAINT recovery_codes_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.recovery_codes
+    ADD CONSTRAINT recovery_codes_user_id_code_hash_key UNIQUE (user_id, code_hash);
+
+ALTER TABLE ONLY public.recovery_codes
+    ADD CONSTRAINT recovery_codes_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+--
+-- Logins waiting for the second factor, stored as SHA-256 hashes of the
+-- challenge tokens handed out after the password was checked
+--
+
+CREATE TABLE public.mfa_challenges (
+    id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    user_id uuid NOT NULL,
+    token_hash character(64) NOT NULL,
+    attempts integer DEFAULT 0 NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE ONLY public.mfa_challenges
+    ADD CONSTRAINT mfa_challenges_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.mfa_challenges
+    ADD CONSTRAINT mfa_challenges_token_hash_key UNIQUE (token_hash);
+
+ALTER TABLE ONLY public.mfa_challenges
+    ADD CONSTRAINT mfa_challenges_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_mfa_challenges_expires_at ON public.mfa_challenges USING btree (expires_at);
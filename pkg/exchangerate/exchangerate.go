@@ -0,0 +1,220 @@
+// Package exchangerate parses exchange rate files: the European Central Bank's
+// XML feeds and CSV files, either ECB-style wide tables or one rate per row
+package exchangerate
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/csv"
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "io"
+    "pocketpilot/pkg/money"
+    "strings"
+    "time"
+)
+
+// File formats
+const (
+    FormatECB = "ecb" // eurofxref-daily.xml, eurofxref-hist.xml and friends
+    FormatCSV = "csv"
+)
+
+// ECBBase is the base currency of ECB reference rates
+const ECBBase = "EUR"
+
+// ErrUnknownFormat is returned when a file is neither ECB XML nor CSV
+var ErrUnknownFormat = errors.New("exchangerate: unknown file format")
+
+// Rate says one unit of Base bought Value units of Currency on Date
+type Rate struct {
+    Base     string
+    Currency string
+    Date     time.Time
+    Value    money.Rate
+}
+
+// Detect guesses the format of a rate file from its first bytes
+func Detect(head []byte) string {
+    head = bytes.TrimSpace(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")))
+    if bytes.HasPrefix(head, []byte("<")) {
+        return FormatECB
+    }
+    return FormatCSV
+}
+
+// Parse reads a rate file in the given format, detecting it when empty
+func Parse(r io.Reader, format string) ([]Rate, error) {
+    buffered := bufio.NewReader(r)
+    if format == "" {
+        head, _ := buffered.Peek(512)
+        format = Detect(head)
+    }
+
+    switch strings.ToLower(format) {
+    case FormatECB, "xml":
+        return ParseECB(buffered)
+    case FormatCSV:
+        return ParseCSV(buffered)
+    }
+    return nil, ErrUnknownFormat
+}
+
+// ParseECB reads the ECB's XML reference rates, where each
+// <Cube time="2026-01-02"> holds <Cube currency="USD" rate="1.0876"/> entries
+func ParseECB(r io.Reader) ([]Rate, error) {
+    decoder := xml.NewDecoder(r)
+
+    var (
+        rates []Rate
+        date  time.Time
+    )
+    for {
+        token, err := decoder.Token()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, fmt.Errorf("exchangerate: %w", err)
+        }
+
+        start, ok := token.(xml.StartElement)
+        if !ok || start.Name.Local != "Cube" {
+            continue
+        }
+
+        var currency, value string
+        for _, attr := range start.Attr {
+            switch attr.Name.Local {
+            case "time":
+                date, err = time.Parse("2006-01-02", attr.Value)
+                if err != nil {
+                    return nil, fmt.Errorf("exchangerate: invalid date %q", attr.Value)
+                }
+            case "currency":
+                currency = attr.Value
+            case "rate":
+                value = attr.Value
+            }
+        }
+        if currency == "" {
+            continue
+        }
+        if date.IsZero() {
+            return nil, fmt.Errorf("exchangerate: rate for %s has no date", currency)
+        }
+
+        rate, err := newRate(ECBBase, currency, date, value)
+        if err != nil {
+            return nil, err
+        }
+        rates = append(rates, rate)
+    }
+
+    if len(rates) == 0 {
+        return nil, errors.New("exchangerate: no rates found")
+    }
+    return rates, nil
+}
+
+// ParseCSV reads rates from a CSV file with a header row, in one of two layouts:
+//
+//   - one rate per row with date, currency and rate columns, and an optional
+//     base column defaulting to EUR
+//   - the ECB's wide layout, a Date column followed by one column per currency
+//     holding EUR rates, where N/A or empty cells are skipped
+func ParseCSV(r io.Reader) ([]Rate, error) {
+    reader := csv.NewReader(r)
+    reader.FieldsPerRecord = -1
+    reader.TrimLeadingSpace = true
+
+    header, err := reader.Read()
+    if err != nil {
+        return nil, fmt.Errorf("exchangerate: missing header row: %w", err)
+    }
+
+    columns := make(map[string]int, len(header))
+    for i, name := range header {
+        name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+        columns[name] = i
+        header[i] = name
+    }
+    dateColumn, ok := columns["date"]
+    if !ok {
+        return nil, errors.New("exchangerate: CSV needs a date column")
+    }
+    _, hasCurrency := columns["currency"]
+    _, hasRate := columns["rate"]
+
+    var rates []Rate
+    line := 1
+    for {
+        record, err := reader.Read()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, fmt.Errorf("exchangerate: %w", err)
+        }
+        line++
+
+        field := func(i int) string {
+            if i < len(record) {
+                return strings.TrimSpace(record[i])
+            }
+            return ""
+        }
+
+        date, err := time.Parse("2006-01-02", field(dateColumn))
+        if err != nil {
+            return nil, fmt.Errorf("exchangerate: line %d: invalid date %q", line, field(dateColumn))
+        }
+
+        if hasCurrency && hasRate {
+            base := ECBBase
+            if i, ok := columns["base"]; ok && field(i) != "" {
+                base = field(i)
+            }
+            rate, err := newRate(base, field(columns["currency"]), date, field(columns["rate"]))
+            if err != nil {
+                return nil, fmt.Errorf("%w (line %d)", err, line)
+            }
+            rates = append(rates, rate)
+            continue
+        }
+
+        for i, currency := range header {
+            value := field(i)
+            if i == dateColumn || currency == "" || value == "" || strings.EqualFold(value, "N/A") {
+                continue
+            }
+            rate, err := newRate(ECBBase, currency, date, value)
+            if err != nil {
+                return nil, fmt.Errorf("%w (line %d)", err, line)
+            }
+            rates = append(rates, rate)
+        }
+    }
+
+    if len(rates) == 0 {
+        return nil, errors.New("exchangerate: no rates found")
+    }
+    return rates, nil
+}
+
+func newRate(base, currency string, date time.Time, value string) (Rate, error) {
+    baseCode, err := money.NormalizeCurrency(base)
+    if err != nil {
+        return Rate{}, fmt.Errorf("exchangerate: invalid currency %q", base)
+    }
+    code, err := money.NormalizeCurrency(currency)
+    if err != nil {
+        return Rate{}, fmt.Errorf("exchangerate: invalid currency %q", currency)
+    }
+    rate, err := money.ParseRate(value)
+    if err != nil {
+        return Rate{}, fmt.Errorf("exchangerate: invalid rate %q for %s", value, code)
+    }
+    return Rate{Base: baseCode, Currency: code, Date: date, Value: rate}, nil
+}
@@ -0,0 +1,194 @@
+// Package imaging prepares receipt images: it turns photos upright, strips
+// their metadata, makes thumbnails and renders PDF pages
+package imaging
+
+import (
+    "bytes"
+    "encoding/binary"
+    "errors"
+)
+
+// ErrMalformed is returned for files whose structure cannot be walked
+var ErrMalformed = errors.New("imaging: malformed file")
+
+const exifOrientationTag = 0x0112
+
+// Orientation returns the EXIF orientation of a JPEG, from 1 (upright) to 8.
+// Files without one are upright.
+func Orientation(data []byte) int {
+    orientation := 1
+    walkJPEG(data, func(marker byte, segment []byte) bool {
+        if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+            if o := tiffOrientation(segment[6:]); o >= 1 && o <= 8 {
+                orientation = o
+            }
+            return false
+        }
+        return true
+    })
+    return orientation
+}
+
+// tiffOrientation reads the orientation tag from the first IFD of EXIF data
+func tiffOrientation(tiff []byte) int {
+    if len(tiff) < 8 {
+        return 0
+    }
+    var order binary.ByteOrder
+    switch string(tiff[:2]) {
+    case "II":
+        order = binary.LittleEndian
+    case "MM":
+        order = binary.BigEndian
+    default:
+        return 0
+    }
+
+    offset := int(order.Uint32(tiff[4:8]))
+    if offset < 8 || offset+2 > len(tiff) {
+        return 0
+    }
+    entries := int(order.Uint16(tiff[offset:]))
+    for i := 0; i < entries; i++ {
+        entry := offset + 2 + i*12
+        if entry+12 > len(tiff) {
+            return 0
+        }
+        if order.Uint16(tiff[entry:]) == exifOrientationTag {
+            return int(order.Uint16(tiff[entry+8:]))
+        }
+    }
+    return 0
+}
+
+// walkJPEG calls fn with each marker segment before the image data, until fn
+// returns false. It returns the offset where the image data starts.
+func walkJPEG(data []byte, fn func(marker byte, segment []byte) bool) (int, error) {
+    if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+        return 0, ErrMalformed
+    }
+    i := 2
+    for i+4 <= len(data) {
+        if data[i] != 0xFF {
+            return 0, ErrMalformed
+        }
+        marker := data[i+1]
+        if marker == 0xFF {
+            i++ // fill byte
+            continue
+        }
+        if marker == 0xDA || marker == 0xD9 {
+            return i, nil
+        }
+        length := int(binary.BigEndian.Uint16(data[i+2:]))
+        if length < 2 || i+2+length > len(data) {
+            return 0, ErrMalformed
+        }
+        if !fn(marker, data[i+4:i+2+length]) {
+            return i, nil
+        }
+        i += 2 + length
+    }
+    return 0, ErrMalformed
+}
+
+// StripMetadata removes the metadata of a JPEG, PNG or WebP file without
+// re-encoding it: EXIF, which includes where a photo was taken, XMP, IPTC and
+// comments. Other types are returned as they are.
+func StripMetadata(data []byte, contentType string) ([]byte, error) {
+    switch contentType {
+    case "image/jpeg":
+        return stripJPEG(data)
+    case "image/png":
+        return stripPNG(data)
+    case "image/webp":
+        return stripWebP(data)
+    }
+    return data, nil
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+    out := bytes.NewBuffer(make([]byte, 0, len(data)))
+    out.Write(data[:2])
+    start, err := walkJPEG(data, func(marker byte, segment []byte) bool {
+        // APP1 holds EXIF and XMP, APP13 IPTC, and COM comments
+        if marker != 0xE1 && marker != 0xED && marker != 0xFE {
+            out.Write([]byte{0xFF, marker})
+            binary.Write(out, binary.BigEndian, uint16(len(segment)+2))
+            out.Write(segment)
+        }
+        return true
+    })
+    if err != nil {
+        return nil, err
+    }
+    out.Write(data[start:])
+    return out.Bytes(), nil
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+func stripPNG(data []byte) ([]byte, error) {
+    if !bytes.HasPrefix(data, pngSignature) {
+        return nil, ErrMalformed
+    }
+    out := bytes.NewBuffer(make([]byte, 0, len(data)))
+    out.Write(pngSignature)
+    for i := len(pngSignature); i < len(data); {
+        if i+12 > len(data) {
+            return nil, ErrMalformed
+        }
+        length := int(binary.BigEndian.Uint32(data[i:]))
+        end := i + 12 + length
+        if length < 0 || end > len(data) {
+            return nil, ErrMalformed
+        }
+        switch string(data[i+4 : i+8]) {
+        case "eXIf", "tEXt", "zTXt", "iTXt":
+        default:
+            out.Write(data[i:end])
+        }
+        i = end
+    }
+    return out.Bytes(), nil
+}
+
+// WebP extended format flags for metadata chunks
+const (
+    webpFlagEXIF = 0x08
+    webpFlagXMP  = 0x04
+)
+
+func stripWebP(data []byte) ([]byte, error) {
+    if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+        return nil, ErrMalformed
+    }
+    out := bytes.NewBuffer(make([]byte, 0, len(data)))
+    out.Write(data[:12])
+    for i := 12; i < len(data); {
+        if i+8 > len(data) {
+            return nil, ErrMalformed
+        }
+        size := int(binary.LittleEndian.Uint32(data[i+4:]))
+        end := i + 8 + size + size%2
+        if size < 0 || end > len(data) {
+            return nil, ErrMalformed
+        }
+        switch fourCC := string(data[i : i+4]); fourCC {
+        case "EXIF", "XMP ":
+        case "VP8X":
+            chunk := append([]byte(nil), data[i:end]...)
+            if size > 0 {
+                chunk[8] &^= webpFlagEXIF | webpFlagXMP
+            }
+            out.Write(chunk)
+        default:
+            out.Write(data[i:end])
+        }
+        i = end
+    }
+
+    stripped := out.Bytes()
+    binary.LittleEndian.PutUint32(stripped[4:8], uint32(len(stripped)-8))
+    return stripped, nil
+}
@@ -0,0 +1,73 @@
+package imaging
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "image"
+    "image/png"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// pdfRenderTimeout bounds how long rendering one page may take
+const pdfRenderTimeout = 30 * time.Second
+
+// PDFRenderer renders the first page of a PDF as an image
+type PDFRenderer interface {
+    RenderFirstPage(data []byte, maxSide int) (image.Image, error)
+}
+
+// Poppler renders PDFs with pdftoppm, from the Poppler utilities
+type Poppler struct {
+    command string
+}
+
+// NewPoppler runs command, or pdftoppm from the PATH when it is empty
+func NewPoppler(command string) *Poppler {
+    if command == "" {
+        command = "pdftoppm"
+    }
+    return &Poppler{command: command}
+}
+
+// RenderFirstPage renders the first page so its longest side is maxSide pixels
+func (p *Poppler) RenderFirstPage(data []byte, maxSide int) (image.Image, error) {
+    dir, err := os.MkdirTemp("", "receipt-pdf-*")
+    if err != nil {
+        return nil, fmt.Errorf("imaging: %w", err)
+    }
+    defer os.RemoveAll(dir)
+
+    input := filepath.Join(dir, "receipt.pdf")
+    if err := os.WriteFile(input, data, 0o600); err != nil {
+        return nil, fmt.Errorf("imaging: %w", err)
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), pdfRenderTimeout)
+    defer cancel()
+
+    var stderr bytes.Buffer
+    cmd := exec.CommandContext(ctx, p.command, "-f", "1", "-l", "1", "-singlefile", "-png",
+        "-scale-to", strconv.Itoa(maxSide), input, filepath.Join(dir, "page"))
+    cmd.Stderr = &stderr
+    if err := cmd.Run(); err != nil {
+        return nil, fmt.Errorf("imaging: pdftoppm: %v: %s", err, strings.TrimSpace(stderr.String()))
+    }
+
+    page, err := os.Open(filepath.Join(dir, "page.png"))
+    if err != nil {
+        return nil, fmt.Errorf("imaging: %w", err)
+    }
+    defer page.Close()
+
+    img, err := png.Decode(page)
+    if err != nil {
+        return nil, fmt.Errorf("imaging: %w", err)
+    }
+    return img, nil
+}
@@ -0,0 +1,107 @@
+package imaging
+
+import (
+    "bytes"
+    "fmt"
+    "image"
+    "image/color"
+    "image/draw"
+    "image/jpeg"
+)
+
+// Flatten copies an image onto an opaque white canvas, as receipts with
+// transparency would otherwise turn black in a JPEG
+func Flatten(img image.Image) *image.RGBA {
+    bounds := img.Bounds()
+    canvas := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+    draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+    draw.Draw(canvas, canvas.Bounds(), img, bounds.Min, draw.Over)
+    return canvas
+}
+
+// Orient turns an image upright according to its EXIF orientation
+func Orient(img *image.RGBA, orientation int) *image.RGBA {
+    if orientation < 2 || orientation > 8 {
+        return img
+    }
+    w, h := img.Bounds().Dx(), img.Bounds().Dy()
+    dw, dh := w, h
+    if orientation >= 5 {
+        dw, dh = h, w
+    }
+
+    dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+    for y := 0; y < h; y++ {
+        for x := 0; x < w; x++ {
+            var dx, dy int
+            switch orientation {
+            case 2: // mirrored
+                dx, dy = w-1-x, y
+            case 3: // upside down
+                dx, dy = w-1-x, h-1-y
+            case 4: // upside down and mirrored
+                dx, dy = x, h-1-y
+            case 5: // transposed
+                dx, dy = y, x
+            case 6: // needs turning clockwise
+                dx, dy = h-1-y, x
+            case 7: // transversed
+                dx, dy = h-1-y, w-1-x
+            case 8: // needs turning counterclockwise
+                dx, dy = y, w-1-x
+            }
+            src := img.PixOffset(img.Rect.Min.X+x, img.Rect.Min.Y+y)
+            copy(dst.Pix[dst.PixOffset(dx, dy):], img.Pix[src:src+4])
+        }
+    }
+    return dst
+}
+
+// Resize scales an image down so its longest side is at most maxSide,
+// averaging the pixels each new pixel covers. Smaller images are returned as
+// they are.
+func Resize(img *image.RGBA, maxSide int) *image.RGBA {
+    w, h := img.Bounds().Dx(), img.Bounds().Dy()
+    if w <= maxSide && h <= maxSide {
+        return img
+    }
+    dw, dh := maxSide, h*maxSide/w
+    if h > w {
+        dw, dh = w*maxSide/h, maxSide
+    }
+    dw, dh = max(dw, 1), max(dh, 1)
+
+    dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+    for dy := 0; dy < dh; dy++ {
+        y0, y1 := dy*h/dh, max((dy+1)*h/dh, dy*h/dh+1)
+        for dx := 0; dx < dw; dx++ {
+            x0, x1 := dx*w/dw, max((dx+1)*w/dw, dx*w/dw+1)
+
+            var sum [4]int
+            for y := y0; y < y1; y++ {
+                row := img.PixOffset(img.Rect.Min.X+x0, img.Rect.Min.Y+y)
+                for x := x0; x < x1; x++ {
+                    for c := 0; c < 4; c++ {
+                        sum[c] += int(img.Pix[row+c])
+                    }
+                    row += 4
+                }
+            }
+            n := (x1 - x0) * (y1 - y0)
+            offset := dst.PixOffset(dx, dy)
+            for c := 0; c < 4; c++ {
+                dst.Pix[offset+c] = uint8(sum[c] / n)
+            }
+        }
+    }
+    return dst
+}
+
+// EncodeJPEG encodes an image as a JPEG of the given quality, from 1 to 100
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+    var buf bytes.Buffer
+    if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+        return nil, fmt.Errorf("imaging: %w", err)
+    }
+    return buf.Bytes(), nil
+}
@@ -0,0 +1,70 @@
+package mail
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "sync"
+    "time"
+)
+
+// Dir writes each email into a directory as an .eml file instead of sending
+// it, for development
+type Dir struct {
+    dir  string
+    from string
+    now  func() time.Time
+    mu   sync.Mutex
+    seq  int
+}
+
+func NewDir(dir, from string) (*Dir, error) {
+    if err := os.MkdirAll(dir, 0o750); err != nil {
+        return nil, fmt.Errorf("mail: %w", err)
+    }
+    return &Dir{dir: dir, from: from, now: time.Now}, nil
+}
+
+func (d *Dir) Send(msg *Message) error {
+    if err := msg.validate(); err != nil {
+        return err
+    }
+
+    d.mu.Lock()
+    d.seq++
+    now := d.now()
+    name := fmt.Sprintf("%s-%04d.eml", now.UTC().Format("20060102T150405.000"), d.seq)
+    d.mu.Unlock()
+
+    if err := os.WriteFile(filepath.Join(d.dir, name), msg.render(d.from, now), 0o640); err != nil {
+        return fmt.Errorf("mail: %w", err)
+    }
+    return nil
+}
+
+// Memory keeps sent emails in memory, for tests
+type Memory struct {
+    mu       sync.Mutex
+    messages []Message
+}
+
+func NewMemory() *Memory {
+    return &Memory{}
+}
+
+func (m *Memory) Send(msg *Message) error {
+    if err := msg.validate(); err != nil {
+        return err
+    }
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.messages = append(m.messages, *msg)
+    return nil
+}
+
+// Messages returns the emails sent so far, oldest first
+func (m *Memory) Messages() []Message {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    return append([]Message(nil), m.messages...)
+}
@@ -0,0 +1,62 @@
+// Package mail sends the emails of the application, such as password reset
+// links, over SMTP or, for development and tests, into a directory or memory
+package mail
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "mime"
+    "strings"
+    "time"
+)
+
+// Backends
+const (
+    BackendSMTP   = "smtp"
+    BackendFile   = "file"
+    BackendMemory = "memory"
+)
+
+// ErrInvalidMessage is returned for messages without a recipient or with
+// header values that could inject further headers
+var ErrInvalidMessage = errors.New("mail: invalid message")
+
+// Message is a plain-text email
+type Message struct {
+    To      string
+    Subject string
+    Body    string
+}
+
+// Sender sends emails
+type Sender interface {
+    Send(msg *Message) error
+}
+
+func (m *Message) validate() error {
+    if m.To == "" || strings.ContainsAny(m.To+m.Subject, "\r\n") {
+        return ErrInvalidMessage
+    }
+    return nil
+}
+
+// render formats a message as RFC 5322 text, with CRLF line endings
+func (m *Message) render(from string, date time.Time) []byte {
+    var buf bytes.Buffer
+    header := func(name, value string) {
+        fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+    }
+    header("From", from)
+    header("To", m.To)
+    header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+    header("Date", date.Format(time.RFC1123Z))
+    header("MIME-Version", "1.0")
+    header("Content-Type", "text/plain; charset=utf-8")
+    header("Content-Transfer-Encoding", "8bit")
+    buf.WriteString("\r\n")
+
+    body := strings.ReplaceAll(m.Body, "\r\n", "\n")
+    buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+    return buf.Bytes()
+}
@@ -0,0 +1,50 @@
+package mail
+
+import (
+    "fmt"
+    "net"
+    "net/smtp"
+    "time"
+)
+
+// SMTPConfig locates an SMTP server. Connections are upgraded with STARTTLS
+// when the server offers it, and credentials are only sent over TLS.
+type SMTPConfig struct {
+    Host     string
+    Port     string
+    Username string
+    Password string
+    From     string
+}
+
+// SMTP sends emails through an SMTP server
+type SMTP struct {
+    cfg SMTPConfig
+    now func() time.Time
+}
+
+func NewSMTP(cfg SMTPConfig) (*SMTP, error) {
+    if cfg.Host == "" || cfg.From == "" {
+        return nil, fmt.Errorf("mail: SMTP needs a host and a from address")
+    }
+    if cfg.Port == "" {
+        cfg.Port = "587"
+    }
+    return &SMTP{cfg: cfg, now: time.Now}, nil
+}
+
+func (s *SMTP) Send(msg *Message) error {
+    if err := msg.validate(); err != nil {
+        return err
+    }
+
+    var auth smtp.Auth
+    if s.cfg.Username != "" {
+        auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+    }
+    addr := net.JoinHostPort(s.cfg.Host, s.cfg.Port)
+    if err := smtp.SendMail(addr, auth, s.cfg.From, []string{msg.To}, msg.render(s.cfg.From, s.now())); err != nil {
+        return fmt.Errorf("mail: %w", err)
+    }
+    return nil
+}
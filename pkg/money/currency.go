@@ -0,0 +1,48 @@
+package money
+
+import (
+    "errors"
+    "strings"
+)
+
+// ErrInvalidCurrency is returned for codes that are not three letters
+var ErrInvalidCurrency = errors.New("money: currency must be a three-letter ISO 4217 code")
+
+// currencyDecimals lists the ISO 4217 currencies whose minor unit is not a
+// hundredth; all others have two decimals
+var currencyDecimals = map[string]int{
+    "BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+    "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+    "BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// Decimals returns how many fractional digits amounts in the currency have
+func Decimals(currency string) int {
+    if decimals, ok := currencyDecimals[strings.ToUpper(currency)]; ok {
+        return decimals
+    }
+    return 2
+}
+
+// NormalizeCurrency upper-cases a currency code and checks it has the ISO 4217 shape
+func NormalizeCurrency(code string) (string, error) {
+    code = strings.ToUpper(strings.TrimSpace(code))
+    if len(code) != 3 {
+        return "", ErrInvalidCurrency
+    }
+    for _, r := range code {
+        if r < 'A' || r > 'Z' {
+            return "", ErrInvalidCurrency
+        }
+    }
+    return code, nil
+}
+
+// Validate checks the amount has no more fractional digits than the currency
+// allows, so 1.5 JPY or 1.005 USD are rejected rather than silently rounded
+func (a Amount) Validate(currency string) error {
+    if a.Digits() > Decimals(currency) {
+        return ErrTooPrecise
+    }
+    return nil
+}
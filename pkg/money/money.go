@@ -0,0 +1,365 @@
+// Package money holds exact decimal amounts and ISO 4217 currency rules
+package money
+
+import (
+    "database/sql/driver"
+    "errors"
+    "fmt"
+    "math"
+    "math/big"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// Scale is the number of fractional digits an Amount keeps, enough for every
+// ISO 4217 currency in circulation
+const Scale = 3
+
+const unit = 1000 // 10^Scale
+
+var (
+    // ErrInvalidAmount is returned for values that are not decimal numbers
+    ErrInvalidAmount = errors.New("money: invalid amount")
+    // ErrTooPrecise is returned for amounts with more fractional digits than allowed
+    ErrTooPrecise = errors.New("money: too many decimal places")
+    // ErrOutOfRange is returned for amounts too large to represent
+    ErrOutOfRange = errors.New("money: amount out of range")
+)
+
+// decimalPattern matches plain decimal numbers. big.Rat alone would also take
+// fractions, exponents, underscores and hexadecimal, octal and binary numbers.
+var decimalPattern = regexp.MustCompile(`^[+-]?[0-9]+(\.[0-9]+)?$`)
+
+// Amount is an exact decimal amount, stored as an integer number of
+// thousandths. The zero value is 0.
+type Amount int64
+
+// Parse reads a decimal number such as "12", "-0.5" or "1234.567" exactly
+func Parse(s string) (Amount, error) {
+    s = strings.TrimSpace(s)
+    if !decimalPattern.MatchString(s) {
+        return 0, ErrInvalidAmount
+    }
+
+    r, ok := new(big.Rat).SetString(s)
+    if !ok {
+        return 0, ErrInvalidAmount
+    }
+    return FromRat(r)
+}
+
+// MustParse is like Parse but panics on invalid input, for constants and tests
+func MustParse(s string) Amount {
+    a, err := Parse(s)
+    if err != nil {
+        panic(err)
+    }
+    return a
+}
+
+// FromRat converts an exact rational number, failing if it has more than
+// Scale fractional digits
+func FromRat(r *big.Rat) (Amount, error) {
+    scaled := new(big.Rat).Mul(r, big.NewRat(unit, 1))
+    if !scaled.IsInt() {
+        return 0, ErrTooPrecise
+    }
+    n := scaled.Num()
+    if !n.IsInt64() {
+        return 0, ErrOutOfRange
+    }
+    return Amount(n.Int64()), nil
+}
+
+// FromMinor converts an amount in a currency's minor units, like cents, to an Amount
+func FromMinor(minor int64, currency string) Amount {
+    return Amount(minor * pow10(Scale-Decimals(currency)))
+}
+
+// Rat returns the amount as an exact rational number
+func (a Amount) Rat() *big.Rat {
+    return big.NewRat(int64(a), unit)
+}
+
+// Float64 returns the nearest float64, for display in spreadsheets and charts only
+func (a Amount) Float64() float64 {
+    return float64(a) / unit
+}
+
+// Add returns a + b
+func (a Amount) Add(b Amount) Amount {
+    return a + b
+}
+
+// Sub returns a - b
+func (a Amount) Sub(b Amount) Amount {
+    return a - b
+}
+
+// Neg returns -a
+func (a Amount) Neg() Amount {
+    return -a
+}
+
+// Abs returns the absolute value of a
+func (a Amount) Abs() Amount {
+    if a < 0 {
+        return -a
+    }
+    return a
+}
+
+// Sign returns -1, 0 or 1
+func (a Amount) Sign() int {
+    switch {
+    case a < 0:
+        return -1
+    case a > 0:
+        return 1
+    }
+    return 0
+}
+
+// Digits returns how many fractional digits the amount needs, from 0 to Scale
+func (a Amount) Digits() int {
+    digits := Scale
+    for v := int64(a); digits > 0 && v%10 == 0; v /= 10 {
+        digits--
+    }
+    return digits
+}
+
+// Round rounds the amount to the given number of fractional digits, halves
+// away from zero as is usual for money
+func (a Amount) Round(decimals int) Amount {
+    if decimals >= Scale {
+        return a
+    }
+    if decimals < 0 {
+        decimals = 0
+    }
+
+    step := pow10(Scale - decimals)
+    v := int64(a)
+    rest := v % step
+    v -= rest
+    if rest < 0 {
+        rest = -rest
+        if rest*2 >= step {
+            v -= step
+        }
+    } else if rest*2 >= step {
+        v += step
+    }
+    return Amount(v)
+}
+
+// RoundTo rounds the amount to the decimals of the currency
+func (a Amount) RoundTo(currency string) Amount {
+    return a.Round(Decimals(currency))
+}
+
+// MulRat multiplies the amount by an exact rate and rounds the result to the
+// given number of fractional digits, halves away from zero
+func (a Amount) MulRat(r *big.Rat, decimals int) (Amount, error) {
+    product := new(big.Rat).Mul(a.Rat(), r)
+
+    // Count in steps of the last kept digit, round to a whole number of steps
+    step := big.NewRat(pow10(Scale-clampDecimals(decimals)), unit)
+    q := new(big.Rat).Quo(product, step)
+    num, den := q.Num(), q.Denom()
+    whole, rest := new(big.Int).QuoRem(num, den, new(big.Int))
+    rest.Abs(rest).Mul(rest, big.NewInt(2))
+    if rest.Cmp(den) >= 0 {
+        if num.Sign() < 0 {
+            whole.Sub(whole, big.NewInt(1))
+        } else {
+            whole.Add(whole, big.NewInt(1))
+        }
+    }
+
+    return FromRat(new(big.Rat).Mul(new(big.Rat).SetInt(whole), step))
+}
+
+// Allocate splits the amount into n parts that add up to it exactly, each
+// rounded to the given decimals. Earlier parts get the leftover smallest units.
+func (a Amount) Allocate(n, decimals int) []Amount {
+    if n <= 0 {
+        return nil
+    }
+    step := pow10(Scale - clampDecimals(decimals))
+    units := int64(a.Round(decimals)) / step
+
+    parts := make([]Amount, n)
+    share, left := units/int64(n), units%int64(n)
+    for i := range parts {
+        part := share
+        switch {
+        case left > 0 && int64(i) < left:
+            part++
+        case left < 0 && int64(i) < -left:
+            part--
+        }
+        parts[i] = Amount(part * step)
+    }
+    return parts
+}
+
+// AllocateWeights splits the amount into parts proportional to the weights
+// that add up to it exactly, each rounded to the given decimals. Leftover
+// smallest units go to the parts that lost the most to rounding, earlier
+// parts first on ties. Weights must not be negative and not all zero.
+func (a Amount) AllocateWeights(weights []int64, decimals int) []Amount {
+    var total int64
+    for _, w := range weights {
+        total += w
+    }
+    if len(weights) == 0 || total <= 0 {
+        return nil
+    }
+
+    step := pow10(Scale - clampDecimals(decimals))
+    units := int64(a.Round(decimals)) / step
+    sign := int64(1)
+    if units < 0 {
+        sign, units = -1, -units
+    }
+
+    parts := make([]Amount, len(weights))
+    rests := make([]*big.Int, len(weights))
+    left := units
+    for i, w := range weights {
+        // units*w can overflow int64 for large amounts
+        whole, rest := new(big.Int).QuoRem(
+            new(big.Int).Mul(big.NewInt(units), big.NewInt(w)), big.NewInt(total), new(big.Int))
+        parts[i] = Amount(whole.Int64())
+        rests[i] = rest
+        left -= whole.Int64()
+    }
+
+    order := make([]int, len(weights))
+    for i := range order {
+        order[i] = i
+    }
+    sort.SliceStable(order, func(i, j int) bool {
+        return rests[order[i]].Cmp(rests[order[j]]) > 0
+    })
+    for _, i := range order[:left] {
+        parts[i]++
+    }
+
+    for i := range parts {
+        parts[i] = Amount(int64(parts[i]) * step * sign)
+    }
+    return parts
+}
+
+// String formats the amount with as few fractional digits as needed: 12.5, 1200
+func (a Amount) String() string {
+    return a.StringFixed(a.Digits())
+}
+
+// StringFixed formats the amount with exactly the given number of fractional digits
+func (a Amount) StringFixed(decimals int) string {
+    decimals = clampDecimals(decimals)
+    v := int64(a.Round(decimals))
+
+    sign := ""
+    if v < 0 {
+        sign = "-"
+    }
+    abs := uint64(v)
+    if v < 0 {
+        abs = uint64(-v)
+    }
+
+    whole := strconv.FormatUint(abs/unit, 10)
+    if decimals == 0 {
+        return sign + whole
+    }
+    fraction := fmt.Sprintf("%03d", abs%unit)
+    return sign + whole + "." + fraction[:decimals]
+}
+
+// Format formats the amount with the decimals of the currency: 12.50 USD, 1200 JPY
+func (a Amount) Format(currency string) string {
+    return a.StringFixed(Decimals(currency))
+}
+
+// MarshalJSON writes the amount as a JSON number without rounding
+func (a Amount) MarshalJSON() ([]byte, error) {
+    return []byte(a.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number or numeric string without going through float64
+func (a *Amount) UnmarshalJSON(data []byte) error {
+    s := string(data)
+    if s == "null" {
+        return nil
+    }
+    s = strings.Trim(s, `"`)
+
+    v, err := Parse(s)
+    if err != nil {
+        return err
+    }
+    *a = v
+    return nil
+}
+
+// Scan reads a numeric column
+func (a *Amount) Scan(src interface{}) error {
+    switch v := src.(type) {
+    case nil:
+        *a = 0
+        return nil
+    case []byte:
+        return a.scanString(string(v))
+    case string:
+        return a.scanString(v)
+    case int64:
+        *a = Amount(v * unit)
+        return nil
+    case float64:
+        if math.IsNaN(v) || math.IsInf(v, 0) {
+            return ErrInvalidAmount
+        }
+        *a = Amount(math.Round(v * unit))
+        return nil
+    }
+    return fmt.Errorf("money: cannot scan %T into Amount", src)
+}
+
+func (a *Amount) scanString(s string) error {
+    v, err := Parse(s)
+    if err != nil {
+        return err
+    }
+    *a = v
+    return nil
+}
+
+// Value writes the amount as an exact decimal string
+func (a Amount) Value() (driver.Value, error) {
+    return a.String(), nil
+}
+
+func clampDecimals(decimals int) int {
+    switch {
+    case decimals < 0:
+        return 0
+    case decimals > Scale:
+        return Scale
+    }
+    return decimals
+}
+
+func pow10(n int) int64 {
+    p := int64(1)
+    for i := 0; i < n; i++ {
+        p *= 10
+    }
+    return p
+}
@@ -0,0 +1,284 @@
+package money
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Amount
+	}{
+		{"12", 12000},
+		{"-0.5", -500},
+		{"+3.25", 3250},
+		{" 1234.567 ", 1234567},
+		{"007.10", 7100},
+		{"0", 0},
+		{"-0", 0},
+		{"9223372036854775.807", Amount(9223372036854775807)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			amount, err := Parse(tt.input)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, amount)
+		})
+	}
+}
+
+func TestParse_Rejects(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected error
+	}{
+		{"", ErrInvalidAmount},
+		{"   ", ErrInvalidAmount},
+		{"abc", ErrInvalidAmount},
+		{"1/3", ErrInvalidAmount},
+		{"0x10", ErrInvalidAmount},
+		{"0X1A", ErrInvalidAmount},
+		{"0b11", ErrInvalidAmount},
+		{"0o17", ErrInvalidAmount},
+		{"1_000", ErrInvalidAmount},
+		{"1e3", ErrInvalidAmount},
+		{"1.5E-1", ErrInvalidAmount},
+		{".5", ErrInvalidAmount},
+		{"5.", ErrInvalidAmount},
+		{"1,000", ErrInvalidAmount},
+		{"--1", ErrInvalidAmount},
+		{"1 000", ErrInvalidAmount},
+		{"١٢", ErrInvalidAmount},
+		{"Inf", ErrInvalidAmount},
+		{"NaN", ErrInvalidAmount},
+		{"1.0005", ErrTooPrecise},
+		{"-0.0001", ErrTooPrecise},
+		{"9223372036854775.808", ErrOutOfRange},
+		{"-9223372036854776.000", ErrOutOfRange},
+		{"100000000000000000000", ErrOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
+
+func TestAmount_UnmarshalJSON(t *testing.T) {
+	var amount Amount
+	require.NoError(t, amount.UnmarshalJSON([]byte(`"12.50"`)))
+	assert.Equal(t, Amount(12500), amount)
+	require.NoError(t, amount.UnmarshalJSON([]byte(`7`)))
+	assert.Equal(t, Amount(7000), amount)
+
+	for _, input := range []string{`"0x10"`, `1e3`, `"1_000"`} {
+		assert.ErrorIs(t, amount.UnmarshalJSON([]byte(input)), ErrInvalidAmount, input)
+	}
+	assert.Equal(t, Amount(7000), amount, "a rejected value leaves the amount alone")
+}
+
+func TestAmount_Round(t *testing.T) {
+	tests := []struct {
+		input    string
+		decimals int
+		expected string
+	}{
+		{"1.005", 2, "1.01"},
+		{"-1.005", 2, "-1.01"},
+		{"1.004", 2, "1"},
+		{"2.5", 0, "3"},
+		{"-2.5", 0, "-3"},
+		{"2.499", 0, "2"},
+		{"1.235", 3, "1.235"},
+		{"1.235", 5, "1.235"},
+		{"7.5", -1, "8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, MustParse(tt.expected), MustParse(tt.input).Round(tt.decimals))
+		})
+	}
+}
+
+func TestAmount_StringFixed(t *testing.T) {
+	tests := []struct {
+		input    string
+		decimals int
+		expected string
+	}{
+		{"12.5", 2, "12.50"},
+		{"0", 2, "0.00"},
+		{"-0.5", 0, "-1"},
+		{"1234.567", 3, "1234.567"},
+		{"1234.567", 2, "1234.57"},
+		{"-0.004", 2, "0.00"},
+		{"-12.345", 2, "-12.35"},
+		{"3", 5, "3.000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MustParse(tt.input).StringFixed(tt.decimals))
+		})
+	}
+
+	assert.Equal(t, "12.5", MustParse("12.50").String())
+	assert.Equal(t, "1200", MustParse("1200").String())
+	assert.Equal(t, "-0.001", MustParse("-0.001").String())
+}
+
+func TestAmount_Format(t *testing.T) {
+	tests := []struct {
+		input    string
+		currency string
+		expected string
+	}{
+		{"1200", "JPY", "1200"},
+		{"0.5", "jpy", "1"},
+		{"-0.5", "JPY", "-1"},
+		{"12.5", "USD", "12.50"},
+		{"-2.345", "EUR", "-2.35"},
+		{"1.5", "KWD", "1.500"},
+		{"1.2345", "KWD", ""},
+		{"3.001", "BHD", "3.001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input+" "+tt.currency, func(t *testing.T) {
+			amount, err := Parse(tt.input)
+			if tt.expected == "" {
+				assert.ErrorIs(t, err, ErrTooPrecise)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, amount.Format(tt.currency))
+		})
+	}
+}
+
+func TestAmount_MulRat(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		num, den int64
+		currency string
+		expected string
+	}{
+		{"Thirds round down", "10", 1, 3, "USD", "3.33"},
+		{"Thirds round up", "20", 1, 3, "USD", "6.67"},
+		{"Negative thirds", "-20", 1, 3, "USD", "-6.67"},
+		{"Half a cent away from zero", "1", 1, 8, "USD", "0.13"},
+		{"Negative half a cent away from zero", "-1", 1, 8, "USD", "-0.13"},
+		{"Kept in a three decimal currency", "1", 1, 8, "KWD", "0.125"},
+		{"Half a yen away from zero", "1.5", 1, 2, "JPY", "1"},
+		{"Yen from euros", "100", 16137, 100, "JPY", "16137"},
+		{"Yen rounding", "1.23", 16137, 100, "JPY", "198"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := MustParse(tt.amount).MulRat(big.NewRat(tt.num, tt.den), Decimals(tt.currency))
+
+			require.NoError(t, err)
+			assert.Equal(t, MustParse(tt.expected), result)
+		})
+	}
+
+	_, err := MustParse("9000000000000000").MulRat(big.NewRat(2, 1), 2)
+	assert.ErrorIs(t, err, ErrOutOfRange)
+}
+
+func sum(parts []Amount) Amount {
+	var total Amount
+	for _, part := range parts {
+		total = total.Add(part)
+	}
+	return total
+}
+
+func amounts(values ...string) []Amount {
+	parsed := make([]Amount, len(values))
+	for i, value := range values {
+		parsed[i] = MustParse(value)
+	}
+	return parsed
+}
+
+func TestAmount_Allocate(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		n        int
+		currency string
+		expected []Amount
+	}{
+		{"Cents to the first parts", "100", 3, "USD", amounts("33.34", "33.33", "33.33")},
+		{"Negative", "-100", 3, "USD", amounts("-33.34", "-33.33", "-33.33")},
+		{"Two leftover cents", "0.05", 3, "USD", amounts("0.02", "0.02", "0.01")},
+		{"Fewer cents than parts", "0.01", 3, "USD", amounts("0.01", "0", "0")},
+		{"Yen", "100", 3, "JPY", amounts("34", "33", "33")},
+		{"Three decimals", "10", 3, "KWD", amounts("3.334", "3.333", "3.333")},
+		{"Even", "10", 4, "EUR", amounts("2.50", "2.50", "2.50", "2.50")},
+		{"One part", "12.34", 1, "EUR", amounts("12.34")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount := MustParse(tt.amount)
+			parts := amount.Allocate(tt.n, Decimals(tt.currency))
+
+			assert.Equal(t, tt.expected, parts)
+			assert.Equal(t, amount, sum(parts))
+			for _, part := range parts {
+				assert.NoError(t, part.Validate(tt.currency))
+			}
+		})
+	}
+
+	assert.Nil(t, MustParse("10").Allocate(0, 2))
+}
+
+func TestAmount_AllocateWeights(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		weights  []int64
+		currency string
+		expected []Amount
+	}{
+		{"Equal weights, earlier parts first", "100", []int64{1, 1, 1}, "USD", amounts("33.34", "33.33", "33.33")},
+		{"Leftover to the largest remainder", "10", []int64{1, 2}, "USD", amounts("3.33", "6.67")},
+		{"Negative", "-10", []int64{1, 2}, "USD", amounts("-3.33", "-6.67")},
+		{"Percentages", "99.99", []int64{50, 30, 20}, "USD", amounts("49.99", "30", "20")},
+		{"Zero weight", "10", []int64{0, 1}, "USD", amounts("0", "10")},
+		{"Yen", "1000", []int64{1, 1, 1}, "JPY", amounts("334", "333", "333")},
+		{"Three decimals", "1", []int64{1, 1, 1}, "BHD", amounts("0.334", "0.333", "0.333")},
+		{"Products beyond int64", "9000000000000", []int64{1 << 40, 1 << 40, 1 << 40}, "USD", amounts("3000000000000", "3000000000000", "3000000000000")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount := MustParse(tt.amount)
+			parts := amount.AllocateWeights(tt.weights, Decimals(tt.currency))
+
+			assert.Equal(t, tt.expected, parts)
+			assert.Equal(t, amount, sum(parts))
+			for _, part := range parts {
+				assert.NoError(t, part.Validate(tt.currency))
+			}
+		})
+	}
+
+	assert.Nil(t, MustParse("10").AllocateWeights(nil, 2))
+	assert.Nil(t, MustParse("10").AllocateWeights([]int64{0, 0}, 2))
+}
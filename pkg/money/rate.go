@@ -0,0 +1,124 @@
+package money
+
+import (
+    "database/sql/driver"
+    "errors"
+    "fmt"
+    "math/big"
+    "strconv"
+    "strings"
+)
+
+// RateScale is the number of fractional digits a Rate keeps
+const RateScale = 10
+
+const rateUnit = 10000000000 // 10^RateScale
+
+// ErrInvalidRate is returned for exchange rates that are not positive decimal numbers
+var ErrInvalidRate = errors.New("money: exchange rate must be a positive decimal number")
+
+// Rate is an exchange rate, how many units of one currency buy one unit of
+// another, stored as an integer number of 10^-10ths
+type Rate int64
+
+// ParseRate reads a positive decimal rate such as "1.0876" or "161.37", rounding
+// digits beyond RateScale
+func ParseRate(s string) (Rate, error) {
+    s = strings.TrimSpace(s)
+    if !decimalPattern.MatchString(s) {
+        return 0, ErrInvalidRate
+    }
+
+    r, ok := new(big.Rat).SetString(s)
+    if !ok {
+        return 0, ErrInvalidRate
+    }
+    return RateFromRat(r)
+}
+
+// RateFromRat converts an exact ratio to a Rate, rounding digits beyond RateScale
+func RateFromRat(r *big.Rat) (Rate, error) {
+    if r.Sign() <= 0 {
+        return 0, ErrInvalidRate
+    }
+
+    scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt64(rateUnit))
+    num, den := scaled.Num(), scaled.Denom()
+    whole, rest := new(big.Int).QuoRem(num, den, new(big.Int))
+    if rest.Mul(rest, big.NewInt(2)).Cmp(den) >= 0 {
+        whole.Add(whole, big.NewInt(1))
+    }
+    if whole.Sign() == 0 {
+        return 0, ErrInvalidRate
+    }
+    if !whole.IsInt64() {
+        return 0, ErrOutOfRange
+    }
+    return Rate(whole.Int64()), nil
+}
+
+// Rat returns the rate as an exact rational number
+func (r Rate) Rat() *big.Rat {
+    return big.NewRat(int64(r), rateUnit)
+}
+
+// Convert multiplies an amount by the rate, rounding to the decimals of the
+// target currency
+func (r Rate) Convert(a Amount, currency string) (Amount, error) {
+    return a.MulRat(r.Rat(), Decimals(currency))
+}
+
+// String formats the rate with as few fractional digits as needed
+func (r Rate) String() string {
+    s := strconv.FormatInt(int64(r)/rateUnit, 10)
+    fraction := strings.TrimRight(fmt.Sprintf("%010d", int64(r)%rateUnit), "0")
+    if fraction == "" {
+        return s
+    }
+    return s + "." + fraction
+}
+
+// MarshalJSON writes the rate as a JSON number without rounding
+func (r Rate) MarshalJSON() ([]byte, error) {
+    return []byte(r.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number or numeric string without going through float64
+func (r *Rate) UnmarshalJSON(data []byte) error {
+    s := string(data)
+    if s == "null" {
+        return nil
+    }
+
+    v, err := ParseRate(strings.Trim(s, `"`))
+    if err != nil {
+        return err
+    }
+    *r = v
+    return nil
+}
+
+// Scan reads a numeric column
+func (r *Rate) Scan(src interface{}) error {
+    switch v := src.(type) {
+    case []byte:
+        return r.scanString(string(v))
+    case string:
+        return r.scanString(v)
+    }
+    return fmt.Errorf("money: cannot scan %T into Rate", src)
+}
+
+func (r *Rate) scanString(s string) error {
+    v, err := ParseRate(s)
+    if err != nil {
+        return err
+    }
+    *r = v
+    return nil
+}
+
+// Value writes the rate as an exact decimal string
+func (r Rate) Value() (driver.Value, error) {
+    return r.String(), nil
+}
@@ -0,0 +1,95 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate_Rejects(t *testing.T) {
+	for _, input := range []string{"", "1/3", "0x10", "0b11", "1_000", "1e3", ".5", "1,0876"} {
+		_, err := ParseRate(input)
+
+		assert.ErrorIs(t, err, ErrInvalidRate, input)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Rate
+	}{
+		{"1.0876", 10876000000},
+		{"161.37", 1613700000000},
+		{" 2 ", 20000000000},
+		{"0.0000000001", 1},
+		{"0.00000000005", 1},
+		{"1.23456789015", 12345678902},
+		{"1.23456789014", 12345678901},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			rate, err := ParseRate(tt.input)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rate)
+		})
+	}
+
+	for _, input := range []string{"0", "-1.5", "0.00000000004"} {
+		_, err := ParseRate(input)
+		assert.ErrorIs(t, err, ErrInvalidRate, input)
+	}
+	_, err := ParseRate("1000000000")
+	assert.ErrorIs(t, err, ErrOutOfRange)
+}
+
+func TestRate_String(t *testing.T) {
+	assert.Equal(t, "1.0876", mustParseRate(t, "1.0876").String())
+	assert.Equal(t, "2", mustParseRate(t, "2.000").String())
+	assert.Equal(t, "0.0000000001", Rate(1).String())
+
+	data, err := mustParseRate(t, "161.37").MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, "161.37", string(data))
+
+	var rate Rate
+	require.NoError(t, rate.UnmarshalJSON([]byte(`"0.9194"`)))
+	assert.Equal(t, mustParseRate(t, "0.9194"), rate)
+}
+
+func TestRate_Convert(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		rate     string
+		currency string
+		expected string
+	}{
+		{"Dollars to euros", "100", "0.9194", "EUR", "91.94"},
+		{"Euros to yen", "100", "161.37", "JPY", "16137"},
+		{"Rounded to yen", "1.23", "161.37", "JPY", "198"},
+		{"Half a cent away from zero", "10", "0.3335", "EUR", "3.34"},
+		{"Below half a cent", "10", "0.33335", "EUR", "3.33"},
+		{"Negative", "-10", "0.3335", "EUR", "-3.34"},
+		{"Three decimals", "1", "0.30712", "KWD", "0.307"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converted, err := mustParseRate(t, tt.rate).Convert(MustParse(tt.amount), tt.currency)
+
+			require.NoError(t, err)
+			assert.Equal(t, MustParse(tt.expected), converted)
+		})
+	}
+}
+
+func mustParseRate(t *testing.T, s string) Rate {
+	t.Helper()
+	rate, err := ParseRate(s)
+	require.NoError(t, err)
+	return rate
+}
@@ -0,0 +1,68 @@
+// Package ocr reads the text of receipt images and PDFs and picks out the
+// merchant, totals and date
+package ocr
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io/fs"
+    "os"
+    "path/filepath"
+)
+
+// Engines
+const (
+    EngineGoogleVision = "google-vision"
+    EngineTesseract    = "tesseract"
+    EngineFixtures     = "fixtures" // canned text, for offline development and tests
+)
+
+// ErrUnsupportedType is returned for files an engine cannot read
+var ErrUnsupportedType = errors.New("ocr: the engine cannot read this file type")
+
+// Engine recognizes the text of a file
+type Engine interface {
+    Recognize(data []byte, contentType string) (*Result, error)
+}
+
+// Result is the text an engine recognized
+type Result struct {
+    Text string
+    Raw  json.RawMessage // the engine's own output, kept so receipts can be parsed again later
+}
+
+// Fixtures answers with text prepared in advance: the text of a file is read
+// from dir/<SHA-256 of the file, in hex>.txt
+type Fixtures struct {
+    dir string
+}
+
+func NewFixtures(dir string) *Fixtures {
+    return &Fixtures{dir: dir}
+}
+
+func (f *Fixtures) Recognize(data []byte, contentType string) (*Result, error) {
+    sum := sha256.Sum256(data)
+    name := hex.EncodeToString(sum[:]) + ".txt"
+
+    text, err := os.ReadFile(filepath.Join(f.dir, name))
+    if err != nil {
+        if errors.Is(err, fs.ErrNotExist) {
+            return nil, fmt.Errorf("ocr: no fixture %s", name)
+        }
+        return nil, fmt.Errorf("ocr: %w", err)
+    }
+    return textResult(EngineFixtures, string(text))
+}
+
+// textResult wraps plain text from engines that have no structured output
+func textResult(engine, text string) (*Result, error) {
+    raw, err := json.Marshal(map[string]string{"engine": engine, "text": text})
+    if err != nil {
+        return nil, fmt.Errorf("ocr: %w", err)
+    }
+    return &Result{Text: text, Raw: raw}, nil
+}
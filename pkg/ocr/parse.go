@@ -0,0 +1,301 @@
+package ocr
+
+import (
+    "pocketpilot/pkg/money"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+    "unicode"
+)
+
+// Fields are what Parse found on a receipt. Anything it could not find is
+// left empty.
+type Fields struct {
+    Merchant string
+    Total    *money.Amount
+    Tax      *money.Amount
+    Currency string // ISO 4217
+    Date     *time.Time
+}
+
+// maxMerchantLength caps the merchant name, in runes
+const maxMerchantLength = 100
+
+var (
+    // amountPattern matches amounts with two decimals, with optional thousands separators
+    amountPattern = regexp.MustCompile(`\d+(?:[.,]\d{3})*[.,]\d{2}`)
+    // wholeAmountPattern matches amounts of currencies without minor units
+    wholeAmountPattern = regexp.MustCompile(`\d{1,3}(?:[.,]\d{3})+|\d+`)
+
+    grandTotalPattern = regexp.MustCompile(`(?i)\b(grand\s+total|total\s+due|amount\s+due|balance\s+due|total\s+to\s+pay|total\s+amount|amount\s+paid)\b`)
+    totalPattern      = regexp.MustCompile(`(?i)\b(total|totaal|summe|gesamt|montant|importe)\b`)
+    notTotalPattern   = regexp.MustCompile(`(?i)(sub\s*-?\s*total|total\s+(items?|qty|quantity|savings|discount|number)|\b(items?|qty)\s+total)`)
+    taxPattern        = regexp.MustCompile(`(?i)\b(tax|vat|gst|hst|pst|qst|tva|mwst|ust|iva)\b`)
+    taxTotalPattern   = regexp.MustCompile(`(?i)(total\s+(tax|vat|gst)|(tax|vat|gst)\s+total)`)
+    notTaxPattern     = regexp.MustCompile(`(?i)(before\s+tax|excl|tax\s*(id|no|number|#)|vat\s*(id|no|number|reg|#))`)
+
+    isoDatePattern     = regexp.MustCompile(`\b(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})\b`)
+    numericDatePattern = regexp.MustCompile(`\b(\d{1,2})[-/.](\d{1,2})[-/.](\d{4}|\d{2})\b`)
+    dayMonthPattern    = regexp.MustCompile(`(?i)\b(\d{1,2})\.?\s+(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?,?\s+(\d{4})\b`)
+    monthDayPattern    = regexp.MustCompile(`(?i)\b(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?\s+(\d{1,2}),?\s+(\d{4})\b`)
+
+    currencyCodePattern = regexp.MustCompile(`\b(USD|EUR|GBP|CAD|AUD|NZD|CHF|JPY|CNY|INR|SEK|NOK|DKK|PLN|CZK|HUF|RON|MXN|BRL|ZAR|SGD|HKD|KRW|KES|NGN|RWF|UGX|TZS|GHS)\b`)
+    // notMerchantPattern matches header lines that are not the merchant's name
+    notMerchantPattern = regexp.MustCompile(`(?i)(receipt|invoice|welcome|thank|\btel\b|phone|fax|www\.|https?:|@|order|table|server|cashier|store\s*#|trans(action)?\b)`)
+)
+
+var currencySymbols = []struct{ symbol, code string }{
+    {"€", "EUR"}, {"£", "GBP"}, {"¥", "JPY"}, {"₹", "INR"}, {"₩", "KRW"}, {"₦", "NGN"}, {"$", "USD"},
+}
+
+var months = map[string]time.Month{
+    "jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+    "may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+    "sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+// Parse picks the merchant, total, tax, currency and date out of the text of a
+// receipt. It reads receipts the way they are usually laid out: the merchant
+// heads the receipt and labelled amounts sit on the same line as their label,
+// or on the next line when OCR split them.
+func Parse(text string) *Fields {
+    var lines []string
+    for _, line := range strings.Split(strings.ReplaceAll(text, "\r", ""), "\n") {
+        if line = strings.TrimSpace(line); line != "" {
+            lines = append(lines, line)
+        }
+    }
+
+    fields := &Fields{Currency: parseCurrency(text)}
+    wholeAmounts := fields.Currency != "" && money.Decimals(fields.Currency) == 0
+    fields.Merchant = parseMerchant(lines)
+    fields.Total = parseTotal(lines, wholeAmounts)
+    fields.Tax = parseTax(lines, wholeAmounts)
+    fields.Date = parseDate(text, fields.Currency)
+    return fields
+}
+
+func parseCurrency(text string) string {
+    if code := currencyCodePattern.FindString(text); code != "" {
+        return code
+    }
+    for _, currency := range currencySymbols {
+        if strings.Contains(text, currency.symbol) {
+            return currency.code
+        }
+    }
+    return ""
+}
+
+func parseMerchant(lines []string) string {
+    for i, line := range lines {
+        if i == 5 {
+            break
+        }
+        letters := 0
+        for _, r := range line {
+            if unicode.IsLetter(r) {
+                letters++
+            }
+        }
+        if letters < 3 || notMerchantPattern.MatchString(line) || amountPattern.MatchString(line) || parseDate(line, "") != nil {
+            continue
+        }
+
+        merchant := strings.Trim(strings.Join(strings.Fields(line), " "), "*-=#:.,")
+        if runes := []rune(merchant); len(runes) > maxMerchantLength {
+            merchant = string(runes[:maxMerchantLength])
+        }
+        if merchant != "" {
+            return merchant
+        }
+    }
+    return ""
+}
+
+// parseTotal prefers explicit grand totals to lines merely saying total, and
+// the largest amount among equals, as card and cash lines repeat the total
+func parseTotal(lines []string, wholeAmounts bool) *money.Amount {
+    var best *money.Amount
+    bestRank := 0
+    for i, line := range lines {
+        rank := 0
+        switch {
+        case grandTotalPattern.MatchString(line):
+            rank = 2
+        case totalPattern.MatchString(line) && !notTotalPattern.MatchString(line) && !taxTotalPattern.MatchString(line):
+            rank = 1
+        default:
+            continue
+        }
+
+        amount := labelledAmount(lines, i, wholeAmounts)
+        if amount == nil || rank < bestRank {
+            continue
+        }
+        if rank > bestRank || best == nil || amount.Rat().Cmp(best.Rat()) > 0 {
+            best, bestRank = amount, rank
+        }
+    }
+    return best
+}
+
+// parseTax takes a tax total when the receipt has one, and otherwise adds up
+// the tax lines, of which receipts with several rates have one per rate
+func parseTax(lines []string, wholeAmounts bool) *money.Amount {
+    var sum *money.Amount
+    for i, line := range lines {
+        if !taxPattern.MatchString(line) || notTaxPattern.MatchString(line) {
+            continue
+        }
+        amount := labelledAmount(lines, i, wholeAmounts)
+        if amount == nil {
+            continue
+        }
+
+        if taxTotalPattern.MatchString(line) {
+            return amount
+        }
+        // Totals including tax are not tax
+        if grandTotalPattern.MatchString(line) || totalPattern.MatchString(line) {
+            continue
+        }
+        if sum == nil {
+            sum = amount
+        } else {
+            total := sum.Add(*amount)
+            sum = &total
+        }
+    }
+    return sum
+}
+
+// labelledAmount returns the last amount on a labelled line, as rate columns
+// come first, or the amount on the next line when it holds nothing else
+func labelledAmount(lines []string, i int, wholeAmounts bool) *money.Amount {
+    if amounts := findAmounts(lines[i], wholeAmounts); len(amounts) > 0 {
+        return &amounts[len(amounts)-1]
+    }
+    if i+1 < len(lines) && !strings.ContainsFunc(lines[i+1], unicode.IsLetter) {
+        if amounts := findAmounts(lines[i+1], wholeAmounts); len(amounts) > 0 {
+            return &amounts[0]
+        }
+    }
+    return nil
+}
+
+// findAmounts returns the amounts on a line, skipping percentages and digits
+// that are part of longer numbers
+func findAmounts(line string, wholeAmounts bool) []money.Amount {
+    pattern := amountPattern
+    if wholeAmounts {
+        pattern = wholeAmountPattern
+    }
+
+    var amounts []money.Amount
+    for _, match := range pattern.FindAllStringIndex(line, -1) {
+        start, end := match[0], match[1]
+        if start > 0 && strings.ContainsAny(line[start-1:start], "0123456789.,") {
+            continue
+        }
+        rest := strings.TrimLeft(line[end:], " ")
+        if strings.HasPrefix(rest, "%") || (end < len(line) && unicode.IsDigit(rune(line[end]))) {
+            continue
+        }
+        if amount, ok := parseAmount(line[start:end], wholeAmounts); ok {
+            amounts = append(amounts, amount)
+        }
+    }
+    return amounts
+}
+
+// parseAmount reads both 1,234.56 and 1.234,56: the last separator followed
+// by two digits is the decimal point, and any other separator groups thousands
+func parseAmount(s string, whole bool) (amount money.Amount, ok bool) {
+    integer, fraction := s, ""
+    if !whole {
+        integer, fraction = s[:len(s)-3], s[len(s)-2:]
+    }
+    integer = strings.NewReplacer(",", "", ".", "").Replace(integer)
+    // Amounts the database cannot hold are misread numbers rather than totals
+    if len(integer) > 12 {
+        return amount, false
+    }
+
+    if fraction != "" {
+        integer += "." + fraction
+    }
+    amount, err := money.Parse(integer)
+    if err != nil {
+        return amount, false
+    }
+    return amount, true
+}
+
+// parseDate returns the first date on the receipt. Numeric dates are read
+// month first for dollar receipts and day first otherwise, unless the
+// numbers only make sense the other way round.
+func parseDate(text, currency string) *time.Time {
+    type candidate struct {
+        at   int
+        date *time.Time
+    }
+    var first *candidate
+    consider := func(at int, date *time.Time) {
+        if date != nil && (first == nil || at < first.at) {
+            first = &candidate{at: at, date: date}
+        }
+    }
+
+    if m := isoDatePattern.FindStringSubmatchIndex(text); m != nil {
+        consider(m[0], makeDate(atoi(text[m[2]:m[3]]), atoi(text[m[4]:m[5]]), atoi(text[m[6]:m[7]])))
+    }
+    if m := numericDatePattern.FindStringSubmatchIndex(text); m != nil {
+        a, b, year := atoi(text[m[2]:m[3]]), atoi(text[m[4]:m[5]]), atoi(text[m[6]:m[7]])
+        if year < 100 {
+            year += 2000
+        }
+        monthFirst := currency == "USD"
+        if a > 12 {
+            monthFirst = false
+        } else if b > 12 {
+            monthFirst = true
+        }
+        if monthFirst {
+            consider(m[0], makeDate(year, a, b))
+        } else {
+            consider(m[0], makeDate(year, b, a))
+        }
+    }
+    if m := dayMonthPattern.FindStringSubmatchIndex(text); m != nil {
+        month := months[strings.ToLower(text[m[4]:m[5]])]
+        consider(m[0], makeDate(atoi(text[m[6]:m[7]]), int(month), atoi(text[m[2]:m[3]])))
+    }
+    if m := monthDayPattern.FindStringSubmatchIndex(text); m != nil {
+        month := months[strings.ToLower(text[m[2]:m[3]])]
+        consider(m[0], makeDate(atoi(text[m[6]:m[7]]), int(month), atoi(text[m[4]:m[5]])))
+    }
+
+    if first == nil {
+        return nil
+    }
+    return first.date
+}
+
+// makeDate returns the date, or nil for impossible or implausible dates
+func makeDate(year, month, day int) *time.Time {
+    if year < 2000 || year > 2099 || month < 1 || month > 12 || day < 1 {
+        return nil
+    }
+    date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+    if date.Day() != day {
+        return nil
+    }
+    return &date
+}
+
+func atoi(s string) int {
+    n, _ := strconv.Atoi(s)
+    return n
+}
@@ -0,0 +1,48 @@
+package ocr
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "os/exec"
+    "strings"
+    "time"
+)
+
+// tesseractTimeout bounds how long one file may take to recognize
+const tesseractTimeout = time.Minute
+
+// Tesseract runs the tesseract command line tool locally. It reads images
+// only; PDFs and HEIC photos are unsupported.
+type Tesseract struct {
+    command string
+}
+
+// NewTesseract runs command, or tesseract from the PATH when it is empty
+func NewTesseract(command string) *Tesseract {
+    if command == "" {
+        command = "tesseract"
+    }
+    return &Tesseract{command: command}
+}
+
+func (t *Tesseract) Recognize(data []byte, contentType string) (*Result, error) {
+    switch contentType {
+    case "image/jpeg", "image/png", "image/webp":
+    default:
+        return nil, ErrUnsupportedType
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), tesseractTimeout)
+    defer cancel()
+
+    var stdout, stderr bytes.Buffer
+    cmd := exec.CommandContext(ctx, t.command, "stdin", "stdout")
+    cmd.Stdin = bytes.NewReader(data)
+    cmd.Stdout = &stdout
+    cmd.Stderr = &stderr
+    if err := cmd.Run(); err != nil {
+        return nil, fmt.Errorf("ocr: tesseract: %v: %s", err, strings.TrimSpace(stderr.String()))
+    }
+    return textResult(EngineTesseract, stdout.String())
+}
@@ -0,0 +1,135 @@
+package ocr
+
+import (
+    "bytes"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "strings"
+    "time"
+)
+
+const (
+    visionEndpoint = "https://vision.googleapis.com/v1"
+    // visionMaxPages is how many pages of a PDF Vision reads in one request
+    visionMaxPages = 5
+)
+
+// GoogleVision recognizes text with the Google Cloud Vision API, using
+// document text detection. It reads JPEG, PNG and WebP images and PDFs; HEIC
+// photos are unsupported.
+type GoogleVision struct {
+    apiKey   string
+    endpoint string
+    client   *http.Client
+}
+
+func NewGoogleVision(apiKey string) *GoogleVision {
+    return &GoogleVision{
+        apiKey:   apiKey,
+        endpoint: visionEndpoint,
+        client:   &http.Client{Timeout: time.Minute},
+    }
+}
+
+type visionFeature struct {
+    Type string `json:"type"`
+}
+
+// visionAnnotation is one response of images:annotate, or of files:annotate,
+// which nests a response per page
+type visionAnnotation struct {
+    FullTextAnnotation *struct {
+        Text string `json:"text"`
+    } `json:"fullTextAnnotation"`
+    Error *struct {
+        Message string `json:"message"`
+    } `json:"error"`
+    Responses []visionAnnotation `json:"responses"`
+}
+
+func (v *GoogleVision) Recognize(data []byte, contentType string) (*Result, error) {
+    content := base64.StdEncoding.EncodeToString(data)
+    features := []visionFeature{{Type: "DOCUMENT_TEXT_DETECTION"}}
+
+    var method string
+    var request map[string]interface{}
+    switch contentType {
+    case "image/jpeg", "image/png", "image/webp":
+        method = "images:annotate"
+        request = map[string]interface{}{
+            "image":    map[string]string{"content": content},
+            "features": features,
+        }
+    case "application/pdf":
+        pages := make([]int, visionMaxPages)
+        for i := range pages {
+            pages[i] = i + 1
+        }
+        method = "files:annotate"
+        request = map[string]interface{}{
+            "inputConfig": map[string]string{"content": content, "mimeType": contentType},
+            "features":    features,
+            "pages":       pages,
+        }
+    default:
+        return nil, ErrUnsupportedType
+    }
+
+    body, err := json.Marshal(map[string]interface{}{"requests": []interface{}{request}})
+    if err != nil {
+        return nil, fmt.Errorf("ocr: %w", err)
+    }
+    resp, err := v.client.Post(v.endpoint+"/"+method+"?key="+url.QueryEscape(v.apiKey), "application/json", bytes.NewReader(body))
+    if err != nil {
+        return nil, fmt.Errorf("ocr: google vision: %w", err)
+    }
+    defer resp.Body.Close()
+
+    raw, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return nil, fmt.Errorf("ocr: google vision: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        var failure struct {
+            Error struct {
+                Message string `json:"message"`
+            } `json:"error"`
+        }
+        json.Unmarshal(raw, &failure)
+        return nil, fmt.Errorf("ocr: google vision: %s %s", resp.Status, failure.Error.Message)
+    }
+
+    var annotated struct {
+        Responses []visionAnnotation `json:"responses"`
+    }
+    if err := json.Unmarshal(raw, &annotated); err != nil {
+        return nil, fmt.Errorf("ocr: google vision: %w", err)
+    }
+    var texts []string
+    for _, annotation := range annotated.Responses {
+        if err := annotation.collect(&texts); err != nil {
+            return nil, err
+        }
+    }
+    return &Result{Text: strings.Join(texts, "\n"), Raw: raw}, nil
+}
+
+// collect appends the text of an annotation and of its pages
+func (a visionAnnotation) collect(texts *[]string) error {
+    if a.Error != nil && a.Error.Message != "" {
+        return fmt.Errorf("ocr: google vision: %s", a.Error.Message)
+    }
+    if a.FullTextAnnotation != nil {
+        *texts = append(*texts, a.FullTextAnnotation.Text)
+    }
+    for _, page := range a.Responses {
+        if err := page.collect(texts); err != nil {
+            return err
+        }
+    }
+    return nil
+}
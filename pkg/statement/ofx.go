@@ -0,0 +1,130 @@
+package statement
+
+import (
+    "bytes"
+    "fmt"
+    "io"
+    "strings"
+    "time"
+)
+
+// ofxEntities are the character references OFX files use
+var ofxEntities = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'", "&nbsp;", " ", "&amp;", "&")
+
+// ParseOFX reads the bank and credit card transactions of an OFX or QFX file.
+// Both the SGML syntax of OFX 1.x, where elements are not closed, and the XML
+// syntax of OFX 2.x are understood.
+func ParseOFX(r io.Reader) ([]Transaction, error) {
+    data, err := io.ReadAll(r)
+    if err != nil {
+        return nil, err
+    }
+
+    start := bytes.Index(bytes.ToUpper(data), []byte("<OFX>"))
+    if start < 0 {
+        return nil, ErrUnknownFormat
+    }
+    data = data[start:]
+
+    var (
+        transactions []Transaction
+        current      *Transaction
+        currency     string // CURDEF of the statement being read
+        account      string
+        aggregate    string // CURRENCY or ORIGCURRENCY while one of them is open
+        ids          = newIDSet()
+    )
+
+    for len(data) > 0 {
+        open := bytes.IndexByte(data, '<')
+        if open < 0 {
+            break
+        }
+        end := bytes.IndexByte(data[open:], '>')
+        if end < 0 {
+            return nil, fmt.Errorf("statement: unterminated OFX tag")
+        }
+        tag := strings.ToUpper(strings.TrimSpace(string(data[open+1 : open+end])))
+        data = data[open+end+1:]
+
+        next := bytes.IndexByte(data, '<')
+        if next < 0 {
+            next = len(data)
+        }
+        value := strings.TrimSpace(ofxEntities.Replace(string(data[:next])))
+
+        if tag == "" || tag[0] == '?' || tag[0] == '!' {
+            continue
+        }
+        if tag[0] == '/' {
+            if tag == "/"+aggregate || tag == "/STMTTRN" {
+                aggregate = ""
+            }
+            if tag == "/STMTTRN" && current != nil {
+                if current.ID == "" {
+                    current.ID = ids.derive("ofx", current)
+                }
+                transactions = append(transactions, *current)
+                current = nil
+            }
+            continue
+        }
+
+        switch tag {
+        case "STMTTRN":
+            current = &Transaction{Account: account, Currency: currency}
+        case "CURDEF":
+            currency = strings.ToUpper(value)
+        case "ACCTID":
+            if current == nil {
+                account = value
+            }
+        }
+        if current == nil {
+            continue
+        }
+
+        n := len(transactions) + 1
+        switch tag {
+        case "FITID":
+            current.ID = value
+        case "DTPOSTED":
+            date, err := parseOFXDate(value)
+            if err != nil {
+                return nil, fmt.Errorf("statement: transaction %d: invalid posted date %q", n, value)
+            }
+            current.Date = date
+        case "TRNAMT":
+            amount, err := parseAmount(value)
+            if err != nil {
+                return nil, fmt.Errorf("statement: transaction %d: invalid amount %q", n, value)
+            }
+            current.Amount = amount
+        case "NAME":
+            if current.Payee == "" {
+                current.Payee = value
+            }
+        case "MEMO":
+            current.Memo = value
+        case "CURRENCY", "ORIGCURRENCY":
+            aggregate = tag
+        case "CURSYM":
+            // CURRENCY gives the currency the amount is in. The amount of an
+            // ORIGCURRENCY transaction was already converted to CURDEF, and
+            // CURSYM only tells what it was paid in.
+            if aggregate == "CURRENCY" {
+                current.Currency = strings.ToUpper(value)
+            }
+        }
+    }
+
+    return transactions, nil
+}
+
+// parseOFXDate reads the date part of an OFX datetime such as 20260115120000.000[-5:EST]
+func parseOFXDate(value string) (time.Time, error) {
+    if len(value) < 8 {
+        return time.Time{}, fmt.Errorf("short date")
+    }
+    return time.Parse("20060102", value[:8])
+}
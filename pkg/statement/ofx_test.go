@@ -0,0 +1,119 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"pocketpilot/pkg/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleOFX is an OFX 1.x statement in SGML syntax, where elements holding a
+// value are not closed
+const sampleOFX = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>usd
+<BANKACCTFROM><BANKID>121000358<ACCTID>1234567890<ACCTTYPE>CHECKING</BANKACCTFROM>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20260115120000.000[-5:EST]
+<TRNAMT>-4.50
+<FITID>2026011501
+<NAME>Coffee &amp; Co
+<MEMO>Oat latte
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20260116
+<TRNAMT>-108.76
+<FITID>2026011601
+<NAME>Hotel
+<CURRENCY><CURRATE>1.0876<CURSYM>EUR</CURRENCY>
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20260117
+<TRNAMT>-117.05
+<FITID>2026011701
+<NAME>Train
+<ORIGCURRENCY><CURRATE>1.1705<CURSYM>GBP</ORIGCURRENCY>
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+func TestParseOFX(t *testing.T) {
+	transactions, err := ParseOFX(strings.NewReader(sampleOFX))
+	require.NoError(t, err)
+	require.Len(t, transactions, 3)
+
+	coffee := transactions[0]
+	assert.Equal(t, "2026011501", coffee.ID)
+	assert.Equal(t, "1234567890", coffee.Account)
+	assert.Equal(t, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC), coffee.Date)
+	assert.Equal(t, money.MustParse("-4.5"), coffee.Amount)
+	assert.Equal(t, "Coffee & Co", coffee.Payee)
+	assert.Equal(t, "Oat latte", coffee.Memo)
+
+	t.Run("Currencies", func(t *testing.T) {
+		assert.Equal(t, "USD", transactions[0].Currency, "the statement's CURDEF")
+		assert.Equal(t, "EUR", transactions[1].Currency, "CURRENCY gives the currency of the amount")
+		assert.Equal(t, "USD", transactions[2].Currency, "ORIGCURRENCY amounts are already in CURDEF")
+		assert.Equal(t, money.MustParse("-117.05"), transactions[2].Amount)
+	})
+
+	t.Run("XML syntax", func(t *testing.T) {
+		transactions, err := ParseOFX(strings.NewReader(`<?xml version="1.0"?><?OFX OFXHEADER="200" VERSION="220"?>
+<OFX><CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS><CURDEF>EUR</CURDEF>
+<CCACCTFROM><ACCTID>4111</ACCTID></CCACCTFROM>
+<BANKTRANLIST><STMTTRN><DTPOSTED>20260115</DTPOSTED><TRNAMT>-1.234,56</TRNAMT><FITID>A1</FITID>
+<NAME>Laptop</NAME><ORIGCURRENCY><CURRATE>0.8543</CURRATE><CURSYM>GBP</CURSYM></ORIGCURRENCY><MEMO>Work</MEMO></STMTTRN>
+</BANKTRANLIST></CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1></OFX>`))
+
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		assert.Equal(t, "4111", transactions[0].Account)
+		assert.Equal(t, money.MustParse("-1234.56"), transactions[0].Amount)
+		assert.Equal(t, "EUR", transactions[0].Currency)
+		assert.Equal(t, "Work", transactions[0].Memo)
+	})
+
+	t.Run("Missing FITIDs are derived", func(t *testing.T) {
+		withoutIDs := strings.NewReplacer("<FITID>2026011501\n", "", "<FITID>2026011601\n", "").Replace(sampleOFX)
+
+		first, err := ParseOFX(strings.NewReader(withoutIDs))
+		require.NoError(t, err)
+		second, err := ParseOFX(strings.NewReader(withoutIDs))
+		require.NoError(t, err)
+
+		assert.Regexp(t, `^ofx-[0-9a-f]{32}$`, first[0].ID)
+		assert.NotEqual(t, first[0].ID, first[1].ID)
+		assert.Equal(t, "2026011701", first[2].ID)
+		for i := range first {
+			assert.Equal(t, first[i].ID, second[i].ID)
+		}
+	})
+
+	t.Run("Invalid files", func(t *testing.T) {
+		_, err := ParseOFX(strings.NewReader("!Type:Bank\n"))
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+
+		_, err = ParseOFX(strings.NewReader(strings.Replace(sampleOFX, "<TRNAMT>-108.76", "<TRNAMT>lots", 1)))
+		assert.ErrorContains(t, err, "transaction 2")
+
+		_, err = ParseOFX(strings.NewReader(strings.Replace(sampleOFX, "<DTPOSTED>20260116", "<DTPOSTED>2026", 1)))
+		assert.ErrorContains(t, err, "transaction 2")
+
+		_, err = ParseOFX(strings.NewReader("<OFX><STMTTRN"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,167 @@
+package statement
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// qifTransactionTypes are the !Type sections that hold account transactions.
+// Investment, category and memorized lists are skipped.
+var qifTransactionTypes = map[string]bool{
+    "bank":  true,
+    "cash":  true,
+    "ccard": true,
+    "oth a": true,
+    "oth l": true,
+}
+
+// QIFOptions configure QIF parsing
+type QIFOptions struct {
+    DayFirst bool // dates are DD/MM/YY rather than Quicken's US MM/DD/YY
+}
+
+// ParseQIF reads the transactions of a QIF file. QIF has no transaction IDs,
+// so IDs are derived from each entry's content.
+func ParseQIF(r io.Reader, opts QIFOptions) ([]Transaction, error) {
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+    var (
+        transactions []Transaction
+        current      Transaction
+        started      bool
+        section      string
+        account      string
+        ids          = newIDSet()
+        line         int
+    )
+
+    for scanner.Scan() {
+        line++
+        text := strings.TrimRight(scanner.Text(), "\r")
+        if line == 1 {
+            text = strings.TrimPrefix(text, "\ufeff")
+        }
+        if strings.TrimSpace(text) == "" {
+            continue
+        }
+
+        if text[0] == '!' {
+            header := strings.ToLower(strings.TrimSpace(text[1:]))
+            switch {
+            case strings.HasPrefix(header, "type:"):
+                section = strings.TrimSpace(strings.TrimPrefix(header, "type:"))
+            case header == "account":
+                section = "account"
+            case strings.HasPrefix(header, "option:"), strings.HasPrefix(header, "clear:"):
+                // Options do not change how transactions read
+            default:
+                section = header
+            }
+            continue
+        }
+
+        code, value := text[0], strings.TrimSpace(text[1:])
+        if section == "account" {
+            if code == 'N' {
+                account = value
+            }
+            continue
+        }
+        if !qifTransactionTypes[section] {
+            continue
+        }
+
+        if code == '^' {
+            if started {
+                current.Account = account
+                current.ID = ids.derive("qif", &current)
+                transactions = append(transactions, current)
+            }
+            current, started = Transaction{}, false
+            continue
+        }
+        started = true
+
+        switch code {
+        case 'D':
+            date, err := parseQIFDate(value, opts.DayFirst)
+            if err != nil {
+                return nil, fmt.Errorf("statement: line %d: invalid date %q", line, value)
+            }
+            current.Date = date
+        case 'T', 'U':
+            amount, err := parseAmount(value)
+            if err != nil {
+                return nil, fmt.Errorf("statement: line %d: invalid amount %q", line, value)
+            }
+            current.Amount = amount
+        case 'P':
+            current.Payee = value
+        case 'M':
+            current.Memo = value
+        case 'L':
+            // Bracketed categories are transfers to another account
+            if !strings.HasPrefix(value, "[") {
+                current.Category = value
+            }
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, err
+    }
+
+    return transactions, nil
+}
+
+// parseQIFDate reads the many date spellings of QIF files: 01/15/2026, 1/15/26,
+// 1/15'26 (an apostrophe marks years from 2000) and 2026-01-15
+func parseQIFDate(value string, dayFirst bool) (time.Time, error) {
+    value = strings.ReplaceAll(value, " ", "")
+    century2000 := strings.Contains(value, "'")
+
+    parts := strings.FieldsFunc(value, func(r rune) bool {
+        return r == '/' || r == '-' || r == '.' || r == '\''
+    })
+    if len(parts) != 3 {
+        return time.Time{}, fmt.Errorf("not a date")
+    }
+
+    numbers := make([]int, 3)
+    for i, part := range parts {
+        n, err := strconv.Atoi(part)
+        if err != nil {
+            return time.Time{}, err
+        }
+        numbers[i] = n
+    }
+
+    var year, month, day int
+    switch {
+    case len(parts[0]) == 4:
+        year, month, day = numbers[0], numbers[1], numbers[2]
+    case dayFirst:
+        day, month, year = numbers[0], numbers[1], numbers[2]
+    default:
+        month, day, year = numbers[0], numbers[1], numbers[2]
+    }
+
+    if len(parts[2]) <= 2 && len(parts[0]) != 4 {
+        switch {
+        case century2000 || year < 70:
+            year += 2000
+        default:
+            year += 1900
+        }
+    }
+
+    date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+    if date.Year() != year || int(date.Month()) != month || date.Day() != day {
+        return time.Time{}, fmt.Errorf("not a date")
+    }
+    return date, nil
+}
@@ -0,0 +1,128 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"pocketpilot/pkg/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQIFDate(t *testing.T) {
+	tests := []struct {
+		input    string
+		dayFirst bool
+		expected time.Time
+	}{
+		{"01/15/2026", false, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"1/15/26", false, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"1/15'26", false, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"1/15' 5", false, time.Date(2005, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"12/31/99", false, time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{"2026-01-15", false, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"15/01/2026", true, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"15.01.26", true, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"3/4'26", true, time.Date(2026, 4, 3, 0, 0, 0, 0, time.UTC)},
+		{"3/4'26", false, time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"2026-01-15", true, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"2/29/2028", false, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			date, err := parseQIFDate(tt.input, tt.dayFirst)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, date)
+		})
+	}
+
+	invalid := []struct {
+		input    string
+		dayFirst bool
+	}{
+		{"", false},
+		{"1/15", false},
+		{"a/b/c", false},
+		{"13/01/2026", false},
+		{"15/01/2026", false},
+		{"01/15/2026", true},
+		{"2/30/2026", false},
+		{"2/29/2026", false},
+		{"0/10/2026", false},
+		{"1/2/3/4", false},
+	}
+
+	for _, tt := range invalid {
+		_, err := parseQIFDate(tt.input, tt.dayFirst)
+		assert.Error(t, err, tt.input)
+	}
+}
+
+const sampleQIF = "\ufeff!Account\r\nNChecking\r\nTBank\r\n^\r\n" +
+	"!Type:Cat\nNGroceries\n^\n" +
+	"!Type:Bank\n" +
+	"D1/15'26\nT-4.50\nPCoffee\nMOat latte\nLFood:Coffee\n^\n" +
+	"D1/15'26\nT-4.50\nPCoffee\nMOat latte\nLFood:Coffee\n^\n" +
+	"D1/16'26\nU-1.234,56\nPRent\nL[Savings]\n^\n"
+
+func TestParseQIF(t *testing.T) {
+	transactions, err := ParseQIF(strings.NewReader(sampleQIF), QIFOptions{})
+	require.NoError(t, err)
+	require.Len(t, transactions, 3)
+
+	coffee := transactions[0]
+	assert.Equal(t, "Checking", coffee.Account)
+	assert.Equal(t, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC), coffee.Date)
+	assert.Equal(t, money.MustParse("-4.5"), coffee.Amount)
+	assert.Equal(t, "Coffee", coffee.Payee)
+	assert.Equal(t, "Oat latte", coffee.Memo)
+	assert.Equal(t, "Food:Coffee", coffee.Category)
+	assert.Empty(t, coffee.Currency)
+
+	rent := transactions[2]
+	assert.Equal(t, money.MustParse("-1234.56"), rent.Amount)
+	assert.Empty(t, rent.Category, "transfers have no category")
+
+	t.Run("Derived IDs are stable", func(t *testing.T) {
+		assert.NotEqual(t, transactions[0].ID, transactions[1].ID, "identical entries get distinct IDs")
+
+		again, err := ParseQIF(strings.NewReader(sampleQIF), QIFOptions{})
+		require.NoError(t, err)
+		for i := range transactions {
+			assert.Equal(t, transactions[i].ID, again[i].ID)
+		}
+
+		// A later statement that overlaps derives the same IDs for the same entries
+		later, err := ParseQIF(strings.NewReader("!Type:Bank\nD1/14'26\nT-9.99\nPBooks\n^\n"+
+			"D1/15'26\nT-4.50\nPCoffee\nMOat latte\n^\n"), QIFOptions{})
+		require.NoError(t, err)
+		require.Len(t, later, 2)
+		assert.NotEqual(t, transactions[0].ID, later[1].ID, "the account is part of the ID")
+
+		withAccount, err := ParseQIF(strings.NewReader("!Account\nNChecking\n^\n!Type:Bank\nD1/14'26\nT-9.99\nPBooks\n^\n"+
+			"D1/15'26\nT-4.50\nPCoffee\nMOat latte\n^\n"), QIFOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, transactions[0].ID, withAccount[1].ID)
+	})
+
+	t.Run("Day first", func(t *testing.T) {
+		transactions, err := ParseQIF(strings.NewReader("!Type:CCard\nD15/01/2026\nT-20,00\nPFuel\n^\n"), QIFOptions{DayFirst: true})
+
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		assert.Equal(t, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC), transactions[0].Date)
+		assert.Equal(t, money.MustParse("-20"), transactions[0].Amount)
+	})
+
+	t.Run("Invalid entries name the line", func(t *testing.T) {
+		_, err := ParseQIF(strings.NewReader("!Type:Bank\nD15/01/2026\nT-20.00\n^\n"), QIFOptions{})
+		assert.ErrorContains(t, err, "line 2")
+
+		_, err = ParseQIF(strings.NewReader("!Type:Bank\nD01/15/2026\nTtwenty\n^\n"), QIFOptions{})
+		assert.ErrorContains(t, err, "line 3")
+	})
+}
@@ -0,0 +1,85 @@
+// Package statement parses bank statement files in the OFX/QFX and QIF formats
+package statement
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "pocketpilot/pkg/money"
+    "strings"
+    "time"
+)
+
+// Statement formats
+const (
+    FormatOFX = "ofx" // also QFX, Quicken's OFX variant
+    FormatQIF = "qif"
+)
+
+// ErrUnknownFormat is returned when a file is neither OFX nor QIF
+var ErrUnknownFormat = errors.New("statement: unknown file format")
+
+// Transaction is a single entry of a statement
+type Transaction struct {
+    ID       string    // FITID for OFX; derived from the entry's content for QIF, which has no IDs
+    Account  string    // account the statement is for, when the file says
+    Date     time.Time // posted date
+    Amount   money.Amount // negative for money leaving the account
+    Payee    string
+    Memo     string
+    Category string // QIF only
+    Currency string // ISO 4217, empty when the file does not say
+}
+
+// Detect guesses the format of a statement from its content
+func Detect(data []byte) (string, error) {
+    head := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+    if len(head) > 512 {
+        head = head[:512]
+    }
+    upper := bytes.ToUpper(head)
+
+    switch {
+    case bytes.Contains(upper, []byte("OFXHEADER")), bytes.Contains(upper, []byte("<OFX")):
+        return FormatOFX, nil
+    case bytes.HasPrefix(upper, []byte("!TYPE")), bytes.HasPrefix(upper, []byte("!ACCOUNT")), bytes.HasPrefix(upper, []byte("!OPTION")):
+        return FormatQIF, nil
+    }
+    return "", ErrUnknownFormat
+}
+
+// parseAmount reads an amount written with a decimal point or, as some banks
+// do, a decimal comma, ignoring digit grouping: -1,234.56 or -1.234,56
+func parseAmount(value string) (money.Amount, error) {
+    value = strings.NewReplacer(" ", "", "\u00a0", "", "'", "").Replace(strings.TrimSpace(value))
+
+    comma, dot := strings.LastIndex(value, ","), strings.LastIndex(value, ".")
+    switch {
+    case comma > dot && (dot >= 0 || len(value)-comma-1 != 3):
+        // The comma is the decimal separator
+        value = strings.ReplaceAll(value, ".", "")
+        value = strings.Replace(value, ",", ".", 1)
+    default:
+        value = strings.ReplaceAll(value, ",", "")
+    }
+
+    return money.Parse(value)
+}
+
+// idSet derives stable IDs for transactions that have none. Identical entries
+// in one file, like two coffees on the same day, get distinct IDs by their order.
+type idSet map[string]int
+
+func newIDSet() idSet {
+    return idSet{}
+}
+
+func (s idSet) derive(prefix string, t *Transaction) string {
+    key := fmt.Sprintf("%s|%s|%s|%s|%s", t.Account, t.Date.Format("2006-01-02"), t.Amount.StringFixed(2), t.Payee, t.Memo)
+    s[key]++
+
+    sum := sha256.Sum256([]byte(fmt.Sprintf("%s#%d", key, s[key])))
+    return prefix + "-" + hex.EncodeToString(sum[:16])
+}
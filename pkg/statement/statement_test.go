@@ -0,0 +1,92 @@
+package statement
+
+import (
+	"testing"
+	"time"
+
+	"pocketpilot/pkg/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"12.50", "12.5"},
+		{"-1,234.56", "-1234.56"},
+		{"1.234,56", "1234.56"},
+		{"-1.234.567,89", "-1234567.89"},
+		{"1,234", "1234"},
+		{"12,345,678", "12345678"},
+		{"-12,5", "-12.5"},
+		{"0,05", "0.05"},
+		{"1 234,56", "1234.56"},
+		{"1\u00a0234,56", "1234.56"},
+		{"1'234.50", "1234.5"},
+		{" +5.00 ", "5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			amount, err := parseAmount(tt.input)
+
+			require.NoError(t, err)
+			assert.Equal(t, money.MustParse(tt.expected), amount)
+		})
+	}
+
+	for _, input := range []string{"", "abc", "1.2.3", "1,2,3", "1e3", "0x10", "$5.00"} {
+		_, err := parseAmount(input)
+		assert.ErrorIs(t, err, money.ErrInvalidAmount, input)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"OFXHEADER:100\nDATA:OFXSGML\n\n<OFX>", FormatOFX},
+		{"<?xml version=\"1.0\"?>\n<?OFX OFXHEADER=\"200\"?>\n<OFX>", FormatOFX},
+		{"\xef\xbb\xbf!Type:Bank\nD1/15/26\n^", FormatQIF},
+		{"  !Account\nNChecking\n^", FormatQIF},
+		{"!Option:AutoSwitch\n", FormatQIF},
+	}
+
+	for _, tt := range tests {
+		format, err := Detect([]byte(tt.input))
+
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, format, tt.input)
+	}
+
+	_, err := Detect([]byte("date,amount,payee\n2026-01-15,-4.50,Coffee"))
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestIDSet(t *testing.T) {
+	coffee := func() *Transaction {
+		return &Transaction{Account: "1234", Date: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC), Amount: money.MustParse("-4.5"), Payee: "Coffee"}
+	}
+
+	first := newIDSet()
+	a, b := first.derive("qif", coffee()), first.derive("qif", coffee())
+	assert.NotEqual(t, a, b, "identical entries get distinct IDs")
+	assert.Regexp(t, `^qif-[0-9a-f]{32}$`, a)
+
+	// The same entries derive the same IDs in a later import
+	second := newIDSet()
+	lunch := coffee()
+	lunch.Payee = "Lunch"
+	second.derive("qif", lunch)
+	assert.Equal(t, a, second.derive("qif", coffee()))
+	assert.Equal(t, b, second.derive("qif", coffee()))
+
+	other := coffee()
+	other.Account = "5678"
+	assert.NotEqual(t, a, newIDSet().derive("qif", other))
+	assert.NotEqual(t, a, newIDSet().derive("ofx", coffee()))
+}
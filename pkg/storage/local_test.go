@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLocal(t *testing.T) *Local {
+	l, err := NewLocal(t.TempDir(), "https://pocketpilot.test/files/", []byte("secret"))
+	require.NoError(t, err)
+	l.now = func() time.Time { return time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC) }
+	return l
+}
+
+// signedQuery splits a signed URL into its key and query parameters
+func signedQuery(t *testing.T, signedURL string) (string, url.Values) {
+	parsed, err := url.Parse(signedURL)
+	require.NoError(t, err)
+	return strings.TrimPrefix(parsed.Path, "/files/"), parsed.Query()
+}
+
+func TestLocal_PutGetDelete(t *testing.T) {
+	l := newTestLocal(t)
+	key := "receipts/user-1/receipt.jpg"
+
+	require.NoError(t, l.Put(key, strings.NewReader("jpeg bytes"), 10, "image/jpeg"))
+
+	object, err := l.Get(key)
+	require.NoError(t, err)
+	body, err := io.ReadAll(object)
+	object.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg bytes", string(body))
+
+	require.NoError(t, l.Delete(key))
+	_, err = l.Get(key)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Deleting again is not an error
+	assert.NoError(t, l.Delete(key))
+}
+
+func TestLocal_PutShortBody(t *testing.T) {
+	l := newTestLocal(t)
+
+	err := l.Put("receipts/short.jpg", strings.NewReader("jpeg"), 10, "image/jpeg")
+	assert.Error(t, err)
+
+	_, err = l.Get("receipts/short.jpg")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	entries, err := os.ReadDir(filepath.Join(l.dir, "receipts"))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the temporary file is removed")
+}
+
+func TestLocal_SignedURL(t *testing.T) {
+	l := newTestLocal(t)
+	key := "receipts/user-1/a b.jpg"
+
+	signedURL, err := l.SignedURL(key, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(signedURL, "https://pocketpilot.test/files/receipts/user-1/a%20b.jpg?"), signedURL)
+
+	urlKey, query := signedQuery(t, signedURL)
+	assert.Equal(t, key, urlKey)
+	assert.Equal(t, "1768482000", query.Get("expires"))
+
+	t.Run("Round trip", func(t *testing.T) {
+		assert.NoError(t, l.Verify(key, query.Get("expires"), query.Get("signature")))
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		later := *l
+		later.now = func() time.Time { return l.now().Add(time.Hour + time.Second) }
+
+		assert.ErrorIs(t, later.Verify(key, query.Get("expires"), query.Get("signature")), ErrInvalidSignature)
+	})
+
+	t.Run("Extended expiry", func(t *testing.T) {
+		assert.ErrorIs(t, l.Verify(key, "1768485600", query.Get("signature")), ErrInvalidSignature)
+	})
+
+	t.Run("Malformed expiry", func(t *testing.T) {
+		assert.ErrorIs(t, l.Verify(key, "soon", query.Get("signature")), ErrInvalidSignature)
+	})
+
+	t.Run("Tampered signature", func(t *testing.T) {
+		signature := []byte(query.Get("signature"))
+		signature[0] ^= 1
+
+		assert.ErrorIs(t, l.Verify(key, query.Get("expires"), string(signature)), ErrInvalidSignature)
+	})
+
+	t.Run("Other key", func(t *testing.T) {
+		assert.ErrorIs(t, l.Verify("receipts/user-2/a b.jpg", query.Get("expires"), query.Get("signature")), ErrInvalidSignature)
+	})
+
+	t.Run("Other secret", func(t *testing.T) {
+		other := *l
+		other.secret = []byte("other secret")
+
+		assert.ErrorIs(t, other.Verify(key, query.Get("expires"), query.Get("signature")), ErrInvalidSignature)
+	})
+}
+
+func TestValidKey(t *testing.T) {
+	for _, key := range []string{"receipt.jpg", "receipts/user-1/receipt.jpg", "receipts/..jpg", "receipts/a..b/c"} {
+		assert.NoError(t, validKey(key), key)
+	}
+	for _, key := range []string{"", "/etc/passwd", "..", "../secret", "receipts/../../secret", "receipts/..", "receipts/./a.jpg", "receipts//a.jpg", "receipts/", `receipts\..\a.jpg`} {
+		assert.ErrorIs(t, validKey(key), ErrInvalidKey, key)
+	}
+}
+
+func TestLocal_RejectsEscapingKeys(t *testing.T) {
+	l := newTestLocal(t)
+	outside := filepath.Join(filepath.Dir(l.dir), "outside.txt")
+
+	err := l.Put("../outside.txt", strings.NewReader("x"), 1, "text/plain")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	assert.NoFileExists(t, outside)
+
+	_, err = l.Get("receipts/../../outside.txt")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	assert.ErrorIs(t, l.Delete("../outside.txt"), ErrInvalidKey)
+
+	_, err = l.SignedURL("../outside.txt", time.Hour)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	assert.ErrorIs(t, l.Verify("../outside.txt", "1768482000", "signature"), ErrInvalidKey)
+}
@@ -0,0 +1,281 @@
+package storage
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+const (
+    s3Algorithm      = "AWS4-HMAC-SHA256"
+    s3Service        = "s3"
+    s3UnsignedBody   = "UNSIGNED-PAYLOAD"
+    s3TimeFormat     = "20060102T150405Z"
+    s3DateFormat     = "20060102"
+    maxS3URLDuration = 7 * 24 * time.Hour
+)
+
+// S3Config configures an S3-compatible bucket
+type S3Config struct {
+    Endpoint        string // e.g. https://s3.eu-west-1.amazonaws.com, or http://localhost:9000 for MinIO
+    Region          string // defaults to us-east-1
+    Bucket          string
+    AccessKeyID     string
+    SecretAccessKey string
+    PathStyle       bool // address the bucket as Endpoint/Bucket rather than Bucket.Endpoint, as MinIO needs
+}
+
+// S3 stores objects in an S3-compatible bucket, signing requests with AWS
+// Signature Version 4. Signed URLs are presigned GET requests, served by the
+// bucket itself.
+type S3 struct {
+    config   S3Config
+    endpoint *url.URL
+    client   *http.Client
+    now      func() time.Time
+}
+
+func NewS3(config S3Config) (*S3, error) {
+    endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+    if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+        return nil, fmt.Errorf("storage: invalid S3 endpoint %q", config.Endpoint)
+    }
+    if config.Bucket == "" {
+        return nil, fmt.Errorf("storage: an S3 bucket is required")
+    }
+    if config.Region == "" {
+        config.Region = "us-east-1"
+    }
+
+    return &S3{
+        config:   config,
+        endpoint: endpoint,
+        client:   &http.Client{Timeout: time.Minute},
+        now:      time.Now,
+    }, nil
+}
+
+func (s *S3) Put(key string, r io.Reader, size int64, contentType string) error {
+    req, err := s.request(http.MethodPut, key, r)
+    if err != nil {
+        return err
+    }
+    req.ContentLength = size
+    if contentType != "" {
+        req.Header.Set("Content-Type", contentType)
+    }
+
+    resp, err := s.do(req)
+    if err != nil {
+        return err
+    }
+    resp.Body.Close()
+    return nil
+}
+
+func (s *S3) Get(key string) (io.ReadCloser, error) {
+    req, err := s.request(http.MethodGet, key, nil)
+    if err != nil {
+        return nil, err
+    }
+
+    resp, err := s.do(req)
+    if err != nil {
+        return nil, err
+    }
+    return resp.Body, nil
+}
+
+func (s *S3) Delete(key string) error {
+    req, err := s.request(http.MethodDelete, key, nil)
+    if err != nil {
+        return err
+    }
+
+    resp, err := s.do(req)
+    if err != nil && !errors.Is(err, ErrNotFound) {
+        return err
+    }
+    if resp != nil {
+        resp.Body.Close()
+    }
+    return nil
+}
+
+// SignedURL presigns a GET of the object, valid for at most seven days as S3 allows
+func (s *S3) SignedURL(key string, expires time.Duration) (string, error) {
+    if err := validKey(key); err != nil {
+        return "", err
+    }
+    if expires <= 0 || expires > maxS3URLDuration {
+        return "", fmt.Errorf("storage: signed URLs last between 1 second and 7 days")
+    }
+
+    now := s.now().UTC()
+    objectURL := s.objectURL(key)
+    query := url.Values{}
+    query.Set("X-Amz-Algorithm", s3Algorithm)
+    query.Set("X-Amz-Credential", s.config.AccessKeyID+"/"+s.scope(now))
+    query.Set("X-Amz-Date", now.Format(s3TimeFormat))
+    query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+    query.Set("X-Amz-SignedHeaders", "host")
+
+    canonical := strings.Join([]string{
+        http.MethodGet,
+        objectURL.EscapedPath(),
+        canonicalQuery(query),
+        "host:" + objectURL.Host + "\n",
+        "host",
+        s3UnsignedBody,
+    }, "\n")
+    query.Set("X-Amz-Signature", s.signature(now, canonical))
+
+    objectURL.RawQuery = canonicalQuery(query)
+    return objectURL.String(), nil
+}
+
+// request builds a request for an object, signed in its headers
+func (s *S3) request(method, key string, body io.Reader) (*http.Request, error) {
+    if err := validKey(key); err != nil {
+        return nil, err
+    }
+
+    req, err := http.NewRequest(method, s.objectURL(key).String(), body)
+    if err != nil {
+        return nil, fmt.Errorf("storage: %w", err)
+    }
+    return req, nil
+}
+
+// do signs and sends a request, turning error responses into errors
+func (s *S3) do(req *http.Request) (*http.Response, error) {
+    now := s.now().UTC()
+    req.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+    req.Header.Set("X-Amz-Content-Sha256", s3UnsignedBody)
+
+    signedHeaders := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+    if req.Header.Get("Content-Type") != "" {
+        signedHeaders = append([]string{"content-type"}, signedHeaders...)
+    }
+    var headers strings.Builder
+    for _, name := range signedHeaders {
+        value := req.Header.Get(name)
+        if name == "host" {
+            value = req.URL.Host
+        }
+        headers.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+    }
+
+    canonical := strings.Join([]string{
+        req.Method,
+        req.URL.EscapedPath(),
+        canonicalQuery(req.URL.Query()),
+        headers.String(),
+        strings.Join(signedHeaders, ";"),
+        s3UnsignedBody,
+    }, "\n")
+    req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+        s3Algorithm, s.config.AccessKeyID, s.scope(now), strings.Join(signedHeaders, ";"), s.signature(now, canonical)))
+
+    resp, err := s.client.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("storage: %w", err)
+    }
+    if resp.StatusCode == http.StatusNotFound {
+        resp.Body.Close()
+        return nil, ErrNotFound
+    }
+    if resp.StatusCode >= 300 {
+        defer resp.Body.Close()
+        detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+        return nil, fmt.Errorf("storage: S3 %s %s: %s %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(detail)))
+    }
+    return resp, nil
+}
+
+// objectURL addresses an object path-style or virtual-hosted-style
+func (s *S3) objectURL(key string) *url.URL {
+    objectURL := *s.endpoint
+    prefix := ""
+    if s.config.PathStyle {
+        prefix = "/" + s.config.Bucket
+    } else {
+        objectURL.Host = s.config.Bucket + "." + objectURL.Host
+    }
+    objectURL.Path = prefix + "/" + key
+    objectURL.RawPath = uriEncode(prefix, false) + "/" + uriEncode(key, false)
+    return &objectURL
+}
+
+func (s *S3) scope(now time.Time) string {
+    return now.Format(s3DateFormat) + "/" + s.config.Region + "/" + s3Service + "/aws4_request"
+}
+
+// signature signs a canonical request with a key derived for the day, region and service
+func (s *S3) signature(now time.Time, canonicalRequest string) string {
+    hash := sha256.Sum256([]byte(canonicalRequest))
+    stringToSign := strings.Join([]string{
+        s3Algorithm,
+        now.Format(s3TimeFormat),
+        s.scope(now),
+        hex.EncodeToString(hash[:]),
+    }, "\n")
+
+    key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), now.Format(s3DateFormat))
+    key = hmacSHA256(key, s.config.Region)
+    key = hmacSHA256(key, s3Service)
+    key = hmacSHA256(key, "aws4_request")
+    return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+    mac := hmac.New(sha256.New, key)
+    mac.Write([]byte(data))
+    return mac.Sum(nil)
+}
+
+// canonicalQuery sorts and encodes query parameters the way Signature Version 4 expects
+func canonicalQuery(query url.Values) string {
+    keys := make([]string, 0, len(query))
+    for key := range query {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+
+    var pairs []string
+    for _, key := range keys {
+        values := append([]string(nil), query[key]...)
+        sort.Strings(values)
+        for _, value := range values {
+            pairs = append(pairs, uriEncode(key, true)+"="+uriEncode(value, true))
+        }
+    }
+    return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything but unreserved characters, and slashes
+// too when encodeSlash is set
+func uriEncode(s string, encodeSlash bool) string {
+    var b strings.Builder
+    for i := 0; i < len(s); i++ {
+        c := s[i]
+        switch {
+        case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+            b.WriteByte(c)
+        case c == '/' && !encodeSlash:
+            b.WriteByte(c)
+        default:
+            fmt.Fprintf(&b, "%%%02X", c)
+        }
+    }
+    return b.String()
+}
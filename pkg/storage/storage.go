@@ -0,0 +1,58 @@
+// Package storage keeps uploaded files such as receipts, either in a local
+// directory or in an S3-compatible bucket, and hands out short-lived signed
+// URLs to download them
+package storage
+
+import (
+    "errors"
+    "io"
+    "strings"
+    "time"
+)
+
+// Backends
+const (
+    BackendLocal = "local"
+    BackendS3    = "s3"
+)
+
+var (
+    // ErrNotFound is returned when no object is stored under a key
+    ErrNotFound = errors.New("storage: object not found")
+    // ErrInvalidKey is returned for keys that are empty or could escape the storage root
+    ErrInvalidKey = errors.New("storage: invalid key")
+    // ErrInvalidSignature is returned for signed URLs that were tampered with or expired
+    ErrInvalidSignature = errors.New("storage: invalid or expired signature")
+)
+
+// Storage stores objects under slash-separated keys such as receipts/abc/def.jpg
+type Storage interface {
+    // Put stores size bytes read from r under key, replacing any earlier object
+    Put(key string, r io.Reader, size int64, contentType string) error
+    // Get opens the object stored under key
+    Get(key string) (io.ReadCloser, error)
+    // Delete removes the object stored under key, if any
+    Delete(key string) error
+    // SignedURL returns a URL anyone can download the object from until it expires
+    SignedURL(key string, expires time.Duration) (string, error)
+}
+
+// SignatureVerifier is implemented by backends whose signed URLs are served
+// by the application itself rather than by the backend
+type SignatureVerifier interface {
+    // Verify checks the expires and signature query parameters of a signed URL for key
+    Verify(key, expires, signature string) error
+}
+
+// validKey rejects keys that are empty, absolute or climb out of the root
+func validKey(key string) error {
+    if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+        return ErrInvalidKey
+    }
+    for _, segment := range strings.Split(key, "/") {
+        if segment == "" || segment == "." || segment == ".." {
+            return ErrInvalidKey
+        }
+    }
+    return nil
+}
@@ -0,0 +1,113 @@
+// Package totp implements the time-based one-time passwords of RFC 6238 that
+// authenticator apps show: six digits from HMAC-SHA1 over 30 second steps
+package totp
+
+import (
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha1"
+    "encoding/base32"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "net/url"
+    "strings"
+    "time"
+)
+
+// Parameters of the codes, the defaults every authenticator app supports
+const (
+    Digits = 6
+    Period = 30 * time.Second
+)
+
+// secretSize is the length of generated secrets in bytes, as RFC 4226 advises
+const secretSize = 20
+
+// ErrInvalidSecret is returned for secrets that are not base32
+var ErrInvalidSecret = errors.New("totp: invalid secret")
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a random secret, base32 encoded without padding
+func GenerateSecret() (string, error) {
+    secret := make([]byte, secretSize)
+    if _, err := rand.Read(secret); err != nil {
+        return "", err
+    }
+    return encoding.EncodeToString(secret), nil
+}
+
+// Step returns the number of the time step t falls in
+func Step(t time.Time) int64 {
+    return t.Unix() / int64(Period/time.Second)
+}
+
+// Code returns the code of a secret for a time step
+func Code(secret string, step int64) (string, error) {
+    key, err := decodeSecret(secret)
+    if err != nil {
+        return "", err
+    }
+    return code(key, step), nil
+}
+
+// Validate checks a code against the step of t and the skew steps on either
+// side of it, to allow for clock drift and typing time. It returns the step
+// the code belongs to, so callers can refuse a code that was already used.
+func Validate(secret, value string, t time.Time, skew int) (int64, bool) {
+    key, err := decodeSecret(secret)
+    if err != nil || len(value) != Digits {
+        return 0, false
+    }
+
+    current := Step(t)
+    for offset := -int64(skew); offset <= int64(skew); offset++ {
+        step := current + offset
+        if hmac.Equal([]byte(code(key, step)), []byte(value)) {
+            return step, true
+        }
+    }
+    return 0, false
+}
+
+// URI returns the otpauth:// URI that authenticator apps read from a QR code,
+// labelled with the issuer and the account
+func URI(issuer, account, secret string) string {
+    query := url.Values{}
+    query.Set("secret", secret)
+    query.Set("issuer", issuer)
+    query.Set("algorithm", "SHA1")
+    query.Set("digits", fmt.Sprint(Digits))
+    query.Set("period", fmt.Sprint(int(Period/time.Second)))
+
+    label := url.PathEscape(issuer + ":" + account)
+    return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+    key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+    if err != nil || len(key) == 0 {
+        return nil, ErrInvalidSecret
+    }
+    return key, nil
+}
+
+// code is the HOTP value of RFC 4226 for a counter
+func code(key []byte, counter int64) string {
+    return fmt.Sprintf("%0*d", Digits, truncate(key, counter)%1000000)
+}
+
+// truncate is the dynamically truncated HMAC of RFC 4226, before it is cut
+// down to a number of digits
+func truncate(key []byte, counter int64) uint32 {
+    var message [8]byte
+    binary.BigEndian.PutUint64(message[:], uint64(counter))
+
+    mac := hmac.New(sha1.New, key)
+    mac.Write(message[:])
+    sum := mac.Sum(nil)
+
+    offset := sum[len(sum)-1] & 0x0f
+    return binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+}
@@ -0,0 +1,155 @@
+package totp
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The ASCII secret "12345678901234567890" of the RFC 4226 and RFC 6238 test
+// vectors, base32 encoded
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode_RFC4226(t *testing.T) {
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, value := range expected {
+		code, err := Code(rfcSecret, int64(counter))
+
+		require.NoError(t, err)
+		assert.Equal(t, value, code, "counter %d", counter)
+	}
+}
+
+func TestCode_RFC6238(t *testing.T) {
+	key, err := decodeSecret(rfcSecret)
+	require.NoError(t, err)
+
+	tests := []struct {
+		unix     int64
+		step     int64
+		expected string
+	}{
+		{59, 0x1, "94287082"},
+		{1111111109, 0x23523EC, "07081804"},
+		{1111111111, 0x23523ED, "14050471"},
+		{1234567890, 0x273EF07, "89005924"},
+		{2000000000, 0x3F940AA, "69279037"},
+		{20000000000, 0x27BC86AA, "65353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.unix), func(t *testing.T) {
+			step := Step(time.Unix(tt.unix, 0))
+			require.Equal(t, tt.step, step)
+
+			// The RFC lists eight digits, the codes keep the last six of them
+			assert.Equal(t, tt.expected, fmt.Sprintf("%08d", truncate(key, step)%100000000))
+			assert.Equal(t, tt.expected[2:], code(key, step))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	codeAt := func(step int64) string {
+		value, err := Code(rfcSecret, step)
+		require.NoError(t, err)
+		return value
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		skew  int
+		step  int64
+		ok    bool
+	}{
+		{"Current step", codeAt(current), 0, current, true},
+		{"Previous step without skew", codeAt(current - 1), 0, 0, false},
+		{"Previous step", codeAt(current - 1), 1, current - 1, true},
+		{"Next step", codeAt(current + 1), 1, current + 1, true},
+		{"Outside the window", codeAt(current - 2), 1, 0, false},
+		{"Wider window", codeAt(current - 2), 2, current - 2, true},
+		{"Wrong code", "000000", 1, 0, false},
+		{"Too short", codeAt(current)[1:], 1, 0, false},
+		{"Eight digits", "14050471", 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, tt.value, now, tt.skew)
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.step, step)
+		})
+	}
+
+	t.Run("Step boundaries", func(t *testing.T) {
+		start := time.Unix(current*30, 0)
+
+		_, ok := Validate(rfcSecret, codeAt(current), start, 0)
+		assert.True(t, ok)
+		_, ok = Validate(rfcSecret, codeAt(current), start.Add(Period-time.Second), 0)
+		assert.True(t, ok)
+		_, ok = Validate(rfcSecret, codeAt(current), start.Add(Period), 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("Invalid secret", func(t *testing.T) {
+		_, ok := Validate("not base32!", codeAt(current), now, 1)
+		assert.False(t, ok)
+	})
+}
+
+func TestDecodeSecret(t *testing.T) {
+	for _, secret := range []string{"ME", "me", "ME======", "me======"} {
+		key, err := decodeSecret(secret)
+
+		require.NoError(t, err, secret)
+		assert.Equal(t, []byte("a"), key, secret)
+	}
+
+	for _, secret := range []string{"", "======", "M", "ME1", "ME!", "ME==ME"} {
+		_, err := decodeSecret(secret)
+
+		assert.ErrorIs(t, err, ErrInvalidSecret, secret)
+	}
+
+	_, err := Code("ME!", 1)
+	assert.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	other, err := GenerateSecret()
+	require.NoError(t, err)
+
+	assert.NotEqual(t, secret, other)
+	assert.NotContains(t, secret, "=")
+	key, err := decodeSecret(secret)
+	require.NoError(t, err)
+	assert.Len(t, key, secretSize)
+}
+
+func TestURI(t *testing.T) {
+	uri, err := url.Parse(URI("PocketPilot", "ada@example.com", rfcSecret))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/PocketPilot:ada@example.com", uri.Path)
+	assert.Equal(t, url.Values{
+		"secret":    {rfcSecret},
+		"issuer":    {"PocketPilot"},
+		"algorithm": {"SHA1"},
+		"digits":    {"6"},
+		"period":    {"30"},
+	}, uri.Query())
+}
@@ -0,0 +1,250 @@
+// Package xlsx writes single-sheet Excel workbooks row by row, so large sheets
+// can be streamed without holding them in memory.
+package xlsx
+
+import (
+    "archive/zip"
+    "bufio"
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Cell styles, indexes into the cellXfs of styles.xml
+const (
+    styleDefault = iota
+    styleDate
+    styleDateTime
+    styleNumber
+    styleHeader
+)
+
+// excelEpoch is day zero of Excel's 1900 date system, accounting for its 1900 leap year bug
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// Options configure a workbook
+type Options struct {
+    SheetName      string
+    DateFormat     string // Excel format code for date cells, e.g. dd/mm/yyyy
+    DateTimeFormat string // Excel format code for date-time cells
+}
+
+// Cell is a single value of a row
+type Cell struct {
+    text    string
+    number  float64
+    numeric bool
+    style   int
+}
+
+// Text is a string cell
+func Text(s string) Cell {
+    return Cell{text: s}
+}
+
+// Header is a bold string cell
+func Header(s string) Cell {
+    return Cell{text: s, style: styleHeader}
+}
+
+// Number is a numeric cell shown with two decimals
+func Number(f float64) Cell {
+    return Cell{number: f, numeric: true, style: styleNumber}
+}
+
+// Date is a date cell shown in the workbook's date format
+func Date(t time.Time) Cell {
+    return Cell{number: serial(t), numeric: true, style: styleDate}
+}
+
+// DateTime is a date-time cell shown in the workbook's date-time format
+func DateTime(t time.Time) Cell {
+    return Cell{number: serial(t), numeric: true, style: styleDateTime}
+}
+
+// Writer streams rows into the only sheet of a workbook. Close must be called
+// to complete the file.
+type Writer struct {
+    zip    *zip.Writer
+    sheet  *bufio.Writer
+    opts   Options
+    rows   int
+    closed bool
+}
+
+// NewWriter starts a workbook on w
+func NewWriter(w io.Writer, opts Options) (*Writer, error) {
+    if opts.SheetName == "" {
+        opts.SheetName = "Sheet1"
+    }
+    if opts.DateFormat == "" {
+        opts.DateFormat = "yyyy-mm-dd"
+    }
+    if opts.DateTimeFormat == "" {
+        opts.DateTimeFormat = opts.DateFormat + " hh:mm:ss"
+    }
+
+    zw := zip.NewWriter(w)
+    part, err := zw.Create("xl/worksheets/sheet1.xml")
+    if err != nil {
+        return nil, err
+    }
+
+    sheet := bufio.NewWriter(part)
+    sheet.WriteString(xml.Header)
+    sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+    return &Writer{zip: zw, sheet: sheet, opts: opts}, nil
+}
+
+// WriteRow appends a row to the sheet
+func (w *Writer) WriteRow(cells ...Cell) error {
+    if w.closed {
+        return errors.New("xlsx: write to closed writer")
+    }
+
+    w.rows++
+    fmt.Fprintf(w.sheet, `<row r="%d">`, w.rows)
+    for i, cell := range cells {
+        ref := columnName(i) + strconv.Itoa(w.rows)
+        if cell.numeric {
+            fmt.Fprintf(w.sheet, `<c r="%s" s="%d"><v>%s</v></c>`, ref, cell.style, strconv.FormatFloat(cell.number, 'f', -1, 64))
+            continue
+        }
+        if cell.text == "" && cell.style == styleDefault {
+            continue
+        }
+        fmt.Fprintf(w.sheet, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, cell.style)
+        if err := xml.EscapeText(w.sheet, []byte(cell.text)); err != nil {
+            return err
+        }
+        w.sheet.WriteString(`</t></is></c>`)
+    }
+    _, err := w.sheet.WriteString(`</row>`)
+    return err
+}
+
+// Flush writes buffered rows to the underlying writer
+func (w *Writer) Flush() error {
+    if err := w.sheet.Flush(); err != nil {
+        return err
+    }
+    return w.zip.Flush()
+}
+
+// Close finishes the sheet and writes the rest of the workbook
+func (w *Writer) Close() error {
+    if w.closed {
+        return nil
+    }
+    w.closed = true
+
+    w.sheet.WriteString(`</sheetData></worksheet>`)
+    if err := w.sheet.Flush(); err != nil {
+        return err
+    }
+
+    parts := []struct {
+        name    string
+        content string
+    }{
+        {"[Content_Types].xml", contentTypes},
+        {"_rels/.rels", rootRels},
+        {"xl/workbook.xml", fmt.Sprintf(workbook, attr(sheetName(w.opts.SheetName)))},
+        {"xl/_rels/workbook.xml.rels", workbookRels},
+        {"xl/styles.xml", fmt.Sprintf(styles, attr(w.opts.DateFormat), attr(w.opts.DateTimeFormat))},
+    }
+    for _, part := range parts {
+        f, err := w.zip.Create(part.name)
+        if err != nil {
+            return err
+        }
+        if _, err := io.WriteString(f, part.content); err != nil {
+            return err
+        }
+    }
+
+    return w.zip.Close()
+}
+
+// columnName converts a zero-based column index to its letters: 0 is A, 26 is AA
+func columnName(i int) string {
+    name := ""
+    for i++; i > 0; i = (i - 1) / 26 {
+        name = string(rune('A'+(i-1)%26)) + name
+    }
+    return name
+}
+
+// serial converts the wall clock time of t to an Excel serial date
+func serial(t time.Time) float64 {
+    wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+    return wall.Sub(excelEpoch).Hours() / 24
+}
+
+// sheetName drops the characters Excel does not allow in sheet names and
+// trims the name to its 31 character limit
+func sheetName(name string) string {
+    name = strings.Map(func(r rune) rune {
+        if strings.ContainsRune(`[]:*?/\`, r) {
+            return -1
+        }
+        return r
+    }, name)
+    if runes := []rune(name); len(runes) > 31 {
+        name = string(runes[:31])
+    }
+    if name == "" {
+        return "Sheet1"
+    }
+    return name
+}
+
+func attr(s string) string {
+    var b strings.Builder
+    xml.EscapeText(&b, []byte(s))
+    return strings.ReplaceAll(b.String(), `"`, "&quot;")
+}
+
+const contentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+    `<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+    `<Default Extension="xml" ContentType="application/xml"/>` +
+    `<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+    `<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+    `<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+    `</Types>`
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+    `<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+    `</Relationships>`
+
+const workbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+    `xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+    `<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const workbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+    `<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+    `<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+    `</Relationships>`
+
+// styles holds the cell formats in the order of the style constants. Format 4 is
+// Excel's built-in #,##0.00.
+const styles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+    `<numFmts count="2"><numFmt numFmtId="164" formatCode="%s"/><numFmt numFmtId="165" formatCode="%s"/></numFmts>` +
+    `<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+    `<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+    `<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+    `<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+    `<cellXfs count="5">` +
+    `<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+    `<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+    `<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+    `<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+    `<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+    `</cellXfs>` +
+    `<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+    `</styleSheet>`